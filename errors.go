@@ -30,6 +30,10 @@ var (
 	// chosen to generate a certificate is not supported.
 	ErrPrivateKeyType = errors.New("private key type not supported")
 
+	// ErrCertificateKeyMismatch indicates that a certificate was supplied
+	// with a private key that doesn't belong to its public key.
+	ErrCertificateKeyMismatch = errors.New("private key does not match certificate")
+
 	// ErrModifyingPeerIdentity indicates that an attempt to modify
 	// PeerIdentity was made after RTCPeerConnection has been initialized.
 	ErrModifyingPeerIdentity = errors.New("peerIdentity cannot be modified")
@@ -68,4 +72,46 @@ var (
 	// set together. Such configuration is not supported by the specification
 	// and is mutually exclusive.
 	ErrRetransmitsOrPacketLifeTime = errors.New("both MaxPacketLifeTime and MaxRetransmits was set")
+
+	// ErrNoRemoteFingerprint indicates that a remote description has no
+	// certificate fingerprint, without which the DTLS peer can't be
+	// verified.
+	ErrNoRemoteFingerprint = errors.New("remote description has no fingerprint")
+
+	// ErrNoRemoteDescription indicates that a remote candidate was added
+	// before the remote description it belongs to was set.
+	ErrNoRemoteDescription = errors.New("remote description is not set")
+
+	// ErrInvalidCandidate indicates that a remote candidate could not be
+	// parsed.
+	ErrInvalidCandidate = errors.New("invalid candidate")
+
+	// ErrNoRtcpMux indicates that a remote description has a media section
+	// that doesn't multiplex RTCP with RTP, which is all that is supported.
+	ErrNoRtcpMux = errors.New("remote description does not support rtcp-mux")
+
+	// ErrIncorrectSignalingState indicates that a description was set, or
+	// an offer or answer created, in a signaling state that doesn't allow
+	// it, like an answer while no offer is pending.
+	ErrIncorrectSignalingState = errors.New("operation can not be run in current signaling state")
+
+	// ErrSDPDoesNotMatchOffer indicates that the local offer set is not the
+	// one CreateOffer returned last.
+	ErrSDPDoesNotMatchOffer = errors.New("new sdp does not match previous offer")
+
+	// ErrSDPDoesNotMatchAnswer indicates that the local answer set is not
+	// the one CreateAnswer returned last.
+	ErrSDPDoesNotMatchAnswer = errors.New("new sdp does not match previous answer")
+
+	// ErrSenderNotCreatedByConnection indicates that RemoveTrack was called
+	// with a sender of another RTCPeerConnection.
+	ErrSenderNotCreatedByConnection = errors.New("sender not created by connection")
+
+	// ErrNotReceivedTrack indicates that a local track was read from, only
+	// the tracks OnTrack is called with can be.
+	ErrNotReceivedTrack = errors.New("track is not received")
+
+	// ErrDataChannelNotOpen indicates that a message was sent on a
+	// DataChannel that isn't open.
+	ErrDataChannelNotOpen = errors.New("datachannel is not open")
 )
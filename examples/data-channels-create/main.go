@@ -10,7 +10,6 @@ import (
 
 	"github.com/pions/webrtc"
 	"github.com/pions/webrtc/pkg/datachannel"
-	"github.com/pions/webrtc/pkg/ice"
 )
 
 func main() {
@@ -33,21 +32,26 @@ func main() {
 
 	// Set the handler for ICE connection state
 	// This will notify you when the peer has connected/disconnected
-	peerConnection.OnICEConnectionStateChange = func(connectionState ice.ConnectionState) {
+	peerConnection.OnICEConnectionStateChange = func(connectionState webrtc.RTCIceConnectionState) {
 		fmt.Printf("Connection State has changed %s \n", connectionState.String())
+	}
 
-		// TODO: find the correct place for this
-		if connectionState == ice.ConnectionStateConnected {
-			fmt.Println("sending openchannel")
-			err := dataChannel.SendOpenChannelMessage()
-			if err != nil {
-				fmt.Println("faild to send openchannel", err)
-			}
+	// Register channel opening handling, the DataChannel opens once the
+	// peers are connected
+	dataChannel.Lock()
+	dataChannel.OnOpen = func() {
+		fmt.Printf("Data channel '%s'-'%d' open. Random messages will now be sent to any connected DataChannels every 5 seconds\n", dataChannel.Label, *dataChannel.ID)
+		for {
+			time.Sleep(5 * time.Second)
+			message := randSeq(15)
+			fmt.Printf("Sending %s \n", message)
+
+			err := dataChannel.SendText(message)
+			check(err)
 		}
 	}
 
 	// Register the Onmessage to handle incoming messages
-	dataChannel.Lock()
 	dataChannel.Onmessage = func(payload datachannel.Payload) {
 		switch p := payload.(type) {
 		case *datachannel.PayloadString:
@@ -64,6 +68,10 @@ func main() {
 	offer, err := peerConnection.CreateOffer(nil)
 	check(err)
 
+	// Sets the LocalDescription
+	err = peerConnection.SetLocalDescription(offer)
+	check(err)
+
 	// Output the offer in base64 so we can paste it in browser
 	fmt.Println(base64.StdEncoding.EncodeToString([]byte(offer.Sdp)))
 
@@ -80,16 +88,8 @@ func main() {
 	err = peerConnection.SetRemoteDescription(answer)
 	check(err)
 
-	// Send messages every 5 seconds
-	fmt.Println("Random messages will now be sent to any connected DataChannels every 5 seconds")
-	for {
-		time.Sleep(5 * time.Second)
-		message := randSeq(15)
-		fmt.Printf("Sending %s \n", message)
-
-		err := dataChannel.Send(datachannel.PayloadString{Data: []byte(message)})
-		check(err)
-	}
+	// Block forever
+	select {}
 }
 
 // randSeq is used to generate a random message
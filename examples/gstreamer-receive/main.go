@@ -10,7 +10,6 @@ import (
 
 	"github.com/pions/webrtc"
 	"github.com/pions/webrtc/examples/gstreamer-receive/gst"
-	"github.com/pions/webrtc/pkg/ice"
 )
 
 func main() {
@@ -46,20 +45,24 @@ func main() {
 
 	// Set a handler for when a new remote track starts, this handler creates a gstreamer pipeline
 	// for the given codec
-	peerConnection.Ontrack = func(track *webrtc.RTCTrack) {
+	peerConnection.OnTrack = func(track *webrtc.RTCTrack) {
 		codec := track.Codec
 		fmt.Printf("Track has started, of type %d: %s \n", track.PayloadType, codec.Name)
 		pipeline := gst.CreatePipeline(codec.Name)
 		pipeline.Start()
+		buf := make([]byte, 1500)
 		for {
-			p := <-track.Packets
-			pipeline.Push(p.Raw)
+			n, err := track.Read(buf)
+			if err != nil {
+				return
+			}
+			pipeline.Push(buf[:n])
 		}
 	}
 
 	// Set the handler for ICE connection state
 	// This will notify you when the peer has connected/disconnected
-	peerConnection.OnICEConnectionStateChange = func(connectionState ice.ConnectionState) {
+	peerConnection.OnICEConnectionStateChange = func(connectionState webrtc.RTCIceConnectionState) {
 		fmt.Printf("Connection State has changed %s \n", connectionState.String())
 	}
 
@@ -72,12 +75,17 @@ func main() {
 		panic(err)
 	}
 
-	// Sets the LocalDescription, and starts our UDP listeners
+	// Create an answer
 	answer, err := peerConnection.CreateAnswer(nil)
 	if err != nil {
 		panic(err)
 	}
 
+	// Sets the LocalDescription
+	if err := peerConnection.SetLocalDescription(answer); err != nil {
+		panic(err)
+	}
+
 	// Get the LocalDescription and take it to base64 so we can paste in browser
 	fmt.Println(base64.StdEncoding.EncodeToString([]byte(answer.Sdp)))
 	select {}
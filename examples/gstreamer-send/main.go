@@ -10,7 +10,6 @@ import (
 
 	"github.com/pions/webrtc"
 	"github.com/pions/webrtc/examples/gstreamer-send/gst"
-	"github.com/pions/webrtc/pkg/ice"
 )
 
 func main() {
@@ -46,7 +45,7 @@ func main() {
 
 	// Set the handler for ICE connection state
 	// This will notify you when the peer has connected/disconnected
-	peerConnection.OnICEConnectionStateChange = func(connectionState ice.ConnectionState) {
+	peerConnection.OnICEConnectionStateChange = func(connectionState webrtc.RTCIceConnectionState) {
 		fmt.Printf("Connection State has changed %s \n", connectionState.String())
 	}
 
@@ -79,12 +78,17 @@ func main() {
 		panic(err)
 	}
 
-	// Sets the LocalDescription, and starts our UDP listeners
+	// Create an answer
 	answer, err := peerConnection.CreateAnswer(nil)
 	if err != nil {
 		panic(err)
 	}
 
+	// Sets the LocalDescription
+	if err := peerConnection.SetLocalDescription(answer); err != nil {
+		panic(err)
+	}
+
 	// Get the LocalDescription and take it to base64 so we can paste in browser
 	fmt.Println(base64.StdEncoding.EncodeToString([]byte(answer.Sdp)))
 
@@ -10,7 +10,6 @@ import (
 
 	"github.com/pions/webrtc"
 	"github.com/pions/webrtc/pkg/datachannel"
-	"github.com/pions/webrtc/pkg/ice"
 )
 
 func buildPeerConnection() *webrtc.RTCPeerConnection {
@@ -43,15 +42,8 @@ func buildPeerConnection() *webrtc.RTCPeerConnection {
 	}
 	d.Unlock()
 
-	peerConnection.OnICEConnectionStateChange = func(connectionState ice.ConnectionState) {
+	peerConnection.OnICEConnectionStateChange = func(connectionState webrtc.RTCIceConnectionState) {
 		fmt.Printf("Connection State has changed %s \n", connectionState.String())
-		if connectionState == ice.ConnectionStateConnected {
-			fmt.Println("sending openchannel")
-			err := d.SendOpenChannelMessage()
-			if err != nil {
-				fmt.Println("faild to send openchannel", err)
-			}
-		}
 	}
 
 	return peerConnection
@@ -98,6 +90,10 @@ func main() {
 		panic(err)
 	}
 
+	if err := peerConnection.SetLocalDescription(answer); err != nil {
+		panic(err)
+	}
+
 	if _, err := fd.Write([]byte(answer.Sdp)); err != nil {
 		log.Fatal("Writing client error: ", err)
 	}
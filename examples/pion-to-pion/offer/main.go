@@ -7,7 +7,6 @@ import (
 
 	"github.com/pions/webrtc"
 	"github.com/pions/webrtc/pkg/datachannel"
-	"github.com/pions/webrtc/pkg/ice"
 )
 
 func buildPeerConnection() *webrtc.RTCPeerConnection {
@@ -22,7 +21,7 @@ func buildPeerConnection() *webrtc.RTCPeerConnection {
 		panic(err)
 	}
 
-	peerConnection.OnICEConnectionStateChange = func(connectionState ice.ConnectionState) {
+	peerConnection.OnICEConnectionStateChange = func(connectionState webrtc.RTCIceConnectionState) {
 		fmt.Printf("Connection State has changed %s \n", connectionState.String())
 	}
 
@@ -59,6 +58,10 @@ func main() {
 		panic(err)
 	}
 
+	if err := peerConnection.SetLocalDescription(offer); err != nil {
+		panic(err)
+	}
+
 	if _, err = c.Write([]byte(offer.Sdp)); err != nil {
 		log.Fatal("Write error:", err)
 	}
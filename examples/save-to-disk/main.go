@@ -8,7 +8,6 @@ import (
 	"os"
 
 	"github.com/pions/webrtc"
-	"github.com/pions/webrtc/pkg/ice"
 	"github.com/pions/webrtc/pkg/media/ivfwriter"
 )
 
@@ -47,7 +46,7 @@ func main() {
 	// Set a handler for when a new remote track starts, this handler saves buffers to disk as
 	// an ivf file, since we could have multiple video tracks we provide a counter.
 	// In your application this is where you would handle/process video
-	peerConnection.Ontrack = func(track *webrtc.RTCTrack) {
+	peerConnection.OnTrack = func(track *webrtc.RTCTrack) {
 		if track.Codec.Name == webrtc.VP8 {
 			fmt.Println("Got VP8 track, saving to disk as output.ivf")
 			i, err := ivfwriter.New("output.ivf")
@@ -55,7 +54,11 @@ func main() {
 				panic(err)
 			}
 			for {
-				if err := i.AddPacket(<-track.Packets); err != nil {
+				packet, err := track.ReadRTP()
+				if err != nil {
+					return
+				}
+				if err := i.AddPacket(packet); err != nil {
 					panic(err)
 				}
 			}
@@ -64,7 +67,7 @@ func main() {
 
 	// Set the handler for ICE connection state
 	// This will notify you when the peer has connected/disconnected
-	peerConnection.OnICEConnectionStateChange = func(connectionState ice.ConnectionState) {
+	peerConnection.OnICEConnectionStateChange = func(connectionState webrtc.RTCIceConnectionState) {
 		fmt.Printf("Connection State has changed %s \n", connectionState.String())
 	}
 
@@ -77,12 +80,17 @@ func main() {
 		panic(err)
 	}
 
-	// Sets the LocalDescription, and starts our UDP listeners
+	// Create an answer
 	answer, err := peerConnection.CreateAnswer(nil)
 	if err != nil {
 		panic(err)
 	}
 
+	// Sets the LocalDescription
+	if err := peerConnection.SetLocalDescription(answer); err != nil {
+		panic(err)
+	}
+
 	// Get the LocalDescription and take it to base64 so we can paste in browser
 	fmt.Println(base64.StdEncoding.EncodeToString([]byte(answer.Sdp)))
 	select {}
@@ -0,0 +1,40 @@
+package dtls
+
+// ConnectionState indicates the progress of a DTLS session
+type ConnectionState int
+
+const (
+	// ConnectionStateNew indicates the handshake has not started yet
+	ConnectionStateNew ConnectionState = iota + 1
+
+	// ConnectionStateConnecting indicates the handshake is in progress
+	ConnectionStateConnecting
+
+	// ConnectionStateConnected indicates the handshake has completed and the
+	// peer's certificate matches its fingerprint
+	ConnectionStateConnected
+
+	// ConnectionStateClosed indicates the session has been closed
+	ConnectionStateClosed
+
+	// ConnectionStateFailed indicates the handshake failed, or the peer's
+	// certificate didn't match its fingerprint
+	ConnectionStateFailed
+)
+
+func (c ConnectionState) String() string {
+	switch c {
+	case ConnectionStateNew:
+		return "New"
+	case ConnectionStateConnecting:
+		return "Connecting"
+	case ConnectionStateConnected:
+		return "Connected"
+	case ConnectionStateClosed:
+		return "Closed"
+	case ConnectionStateFailed:
+		return "Failed"
+	default:
+		return "Invalid"
+	}
+}
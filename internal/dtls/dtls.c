@@ -1,7 +1,5 @@
 #include "dtls.h"
 
-#define ONE_YEAR 60 * 60 * 24 * 365
-
 // strdup is POSIX extension
 char *dtls_strdup(char *src) {
   char *str;
@@ -57,7 +55,14 @@ SSL_CTX *dtls_build_sslctx(tlscfg *cfg) {
   SSL_CTX_set_read_ahead(ctx, 1);
   SSL_CTX_set_verify(ctx, SSL_VERIFY_PEER | SSL_VERIFY_FAIL_IF_NO_PEER_CERT, dtls_trivial_verify_callback);
 
-  if (SSL_CTX_set_tlsext_use_srtp(ctx, "SRTP_AES128_CM_SHA1_32:SRTP_AES128_CM_SHA1_80") != 0) {
+  // https://tools.ietf.org/html/rfc5764#section-4.1.1
+  // Only offer the profiles the srtp package implements, in order of preference
+#ifdef SRTP_AEAD_AES_128_GCM
+  const char *srtp_profiles = "SRTP_AEAD_AES_128_GCM:SRTP_AEAD_AES_256_GCM:SRTP_AES128_CM_SHA1_80";
+#else
+  const char *srtp_profiles = "SRTP_AES128_CM_SHA1_80";
+#endif
+  if (SSL_CTX_set_tlsext_use_srtp(ctx, srtp_profiles) != 0) {
     goto error;
   }
 
@@ -82,12 +87,12 @@ error:
   return NULL;
 }
 
-dtls_sess *dtls_build_session(SSL_CTX *sslcfg, bool is_offer) {
+dtls_sess *dtls_build_session(SSL_CTX *sslcfg, bool is_server) {
   dtls_sess *sess = (dtls_sess *)calloc(1, sizeof(dtls_sess));
   BIO *rbio = NULL;
   BIO *wbio = NULL;
 
-  sess->state = is_offer;
+  sess->state = is_server ? DTLS_CONSTATE_PASS : DTLS_CONSTATE_ACT;
 
   if (NULL == (sess->ssl = SSL_new(sslcfg))) {
     goto error;
@@ -160,87 +165,32 @@ ptrdiff_t dtls_do_handshake(dtls_sess *sess, char *local, char *remote) {
   return dtls_sess_send_pending(sess, local, remote);
 }
 
-tlscfg *dtls_build_tlscfg() {
+// dtls_build_tlscfg loads the DER encoded certificate and private key DTLS
+// authenticates with
+tlscfg *dtls_build_tlscfg(const unsigned char *cert_der, int cert_len, const unsigned char *key_der, int key_len) {
   tlscfg *cfg = (tlscfg *)calloc(1, sizeof(tlscfg));
-
-  static const int num_bits = 2048;
-
-  BIGNUM *bne = BN_new();
-  if (bne == NULL) {
-    goto error;
-  }
-
-  if (!BN_set_word(bne, RSA_F4)) {
-    goto error;
-  }
-
-  RSA *rsa_key = RSA_new();
-  if (rsa_key == NULL) {
-    goto error;
-  }
-
-  if (!RSA_generate_key_ex(rsa_key, num_bits, bne, NULL)) {
-    goto error;
-  }
-
-  if ((cfg->pkey = EVP_PKEY_new()) == NULL) {
-    goto error;
-  }
-
-  if (!EVP_PKEY_assign_RSA(cfg->pkey, rsa_key)) {
-    goto error;
-  }
-
-  rsa_key = NULL;
-  if ((cfg->cert = X509_new()) == NULL) {
-    goto error;
-  }
-
-  X509_set_version(cfg->cert, 2);
-  ASN1_INTEGER_set(X509_get_serialNumber(cfg->cert), 1000); // TODO
-  X509_gmtime_adj(X509_get_notBefore(cfg->cert), -1 * ONE_YEAR);
-  X509_gmtime_adj(X509_get_notAfter(cfg->cert), ONE_YEAR);
-  if (!X509_set_pubkey(cfg->cert, cfg->pkey)) {
-    goto error;
-  }
-
-  X509_NAME *cert_name = cert_name = X509_get_subject_name(cfg->cert);
-  if (cert_name == NULL) {
-    goto error;
+  if (cfg == NULL) {
+    return NULL;
   }
 
-  const char *name = "pion-webrtc";
-  X509_NAME_add_entry_by_txt(cert_name, "O", MBSTRING_ASC, (const char unsigned *)name, -1, -1, 0);
-  X509_NAME_add_entry_by_txt(cert_name, "CN", MBSTRING_ASC, (const char unsigned *)name, -1, -1, 0);
-
-  if (!X509_set_issuer_name(cfg->cert, cert_name)) {
+  if ((cfg->cert = d2i_X509(NULL, &cert_der, cert_len)) == NULL) {
     goto error;
   }
 
-  if (!X509_sign(cfg->cert, cfg->pkey, EVP_sha1())) {
+  if ((cfg->pkey = d2i_AutoPrivateKey(NULL, &key_der, key_len)) == NULL) {
     goto error;
   }
 
-  BN_free(bne);
   return cfg;
 
 error:
-  if (bne) {
-    BN_free(bne);
-  }
-  if (rsa_key && !cfg && !cfg->pkey) {
-    RSA_free(rsa_key);
-  }
-  if (cfg && cfg->pkey) {
-    EVP_PKEY_free(cfg->pkey);
-  }
-  if (cfg && cfg->cert) {
+  if (cfg->cert) {
     X509_free(cfg->cert);
   }
+  free(cfg);
   return NULL;
 }
 
-
 void dtls_session_cleanup(SSL_CTX *ssl_ctx, dtls_sess *dtls_session, tlscfg *cfg) {
   if (dtls_session) {
     if (dtls_session->ssl != NULL) {
@@ -289,6 +239,7 @@ dtls_decrypted *dtls_handle_incoming(dtls_sess *sess, void *buf, int len, char *
   decrypted_len = SSL_read(sess->ssl, decrypted, len);
 
   if ((decrypted_len < 0) && SSL_get_error(sess->ssl, decrypted_len) == SSL_ERROR_SSL) {
+     sess->failed = true;
      fprintf(stderr, "DTLS failure occurred on dtls session %p due to reason '%s'\n", sess,
              ERR_reason_error_string(ERR_get_error()));
      free(decrypted);
@@ -331,18 +282,15 @@ bool dtls_handle_outgoing(dtls_sess *sess, void *buf, int len, char *local, char
   return true;
 }
 
-char *dtls_tlscfg_fingerprint(tlscfg *cfg) {
-  if (cfg == NULL) {
-    return NULL;
-  }
-
+// dtls_x509_fingerprint formats the digest of cert as colon separated hex
+char *dtls_x509_fingerprint(X509 *cert, const EVP_MD *md) {
   unsigned int size;
   unsigned char fingerprint[EVP_MAX_MD_SIZE];
-  if (X509_digest(cfg->cert, EVP_sha256(), (unsigned char *)fingerprint, &size) == 0) {
+  if (X509_digest(cert, md, (unsigned char *)fingerprint, &size) == 0) {
     return NULL;
   }
 
-  char *hex_fingeprint = calloc(1, sizeof(char) * 160);
+  char *hex_fingeprint = calloc(1, sizeof(char) * (EVP_MAX_MD_SIZE * 3 + 1));
   char *curr = hex_fingeprint;
   unsigned int i = 0;
   for (i = 0; i < size; i++) {
@@ -353,36 +301,88 @@ char *dtls_tlscfg_fingerprint(tlscfg *cfg) {
   return hex_fingeprint;
 }
 
+char *dtls_tlscfg_fingerprint(tlscfg *cfg) {
+  if (cfg == NULL) {
+    return NULL;
+  }
+  return dtls_x509_fingerprint(cfg->cert, EVP_sha256());
+}
+
+// dtls_peer_fingerprint returns the fingerprint of the certificate the peer
+// presented during the handshake, hash_name is an OpenSSL digest name
+char *dtls_peer_fingerprint(dtls_sess *sess, const char *hash_name) {
+  if (sess->ssl == NULL) {
+    return NULL;
+  }
+
+  const EVP_MD *md = EVP_get_digestbyname(hash_name);
+  if (md == NULL) {
+    return NULL;
+  }
+
+  X509 *cert = SSL_get_peer_certificate(sess->ssl);
+  if (cert == NULL) {
+    return NULL;
+  }
+
+  char *fingerprint = dtls_x509_fingerprint(cert, md);
+  X509_free(cert);
+  return fingerprint;
+}
+
+bool dtls_is_init_finished(dtls_sess *sess) { return sess->ssl != NULL && SSL_is_init_finished(sess->ssl); }
+
 dtls_cert_pair *dtls_get_certpair(dtls_sess *sess) {
   if (sess->type == DTLS_CONTYPE_EXISTING) {
-    unsigned char dtls_buffer[SRTP_MASTER_KEY_KEY_LEN * 2 + SRTP_MASTER_KEY_SALT_LEN * 2];
+    // The peer didn't negotiate use_srtp, there is no SRTP keying material
+    SRTP_PROTECTION_PROFILE *srtp_profile = SSL_get_selected_srtp_profile(sess->ssl);
+    if (srtp_profile == NULL) {
+      return NULL;
+    }
+
+    // https://tools.ietf.org/html/rfc7714#section-12
+    size_t key_len, salt_len;
+    switch (srtp_profile->id) {
+    case SRTP_AES128_CM_SHA1_80:
+      key_len = 16;
+      salt_len = 14;
+      break;
+#ifdef SRTP_AEAD_AES_128_GCM
+    case SRTP_AEAD_AES_128_GCM:
+      key_len = 16;
+      salt_len = 12;
+      break;
+    case SRTP_AEAD_AES_256_GCM:
+      key_len = 32;
+      salt_len = 12;
+      break;
+#endif
+    default:
+      return NULL;
+    }
+
+    unsigned char dtls_buffer[SRTP_MAX_MASTER_KEY_LEN * 2 + SRTP_MAX_MASTER_SALT_LEN * 2];
+    size_t dtls_buffer_len = key_len * 2 + salt_len * 2;
 
     const char *label = "EXTRACTOR-dtls_srtp";
-    if (!SSL_export_keying_material(sess->ssl, dtls_buffer, sizeof(dtls_buffer), label, strlen(label), NULL, 0, 0)) {
+    if (!SSL_export_keying_material(sess->ssl, dtls_buffer, dtls_buffer_len, label, strlen(label), NULL, 0, 0)) {
       fprintf(stderr, "SSL_export_keying_material failed");
       return NULL;
     }
 
     size_t offset = 0;
     dtls_cert_pair *ret = calloc(1, sizeof(dtls_cert_pair));
-    ret->key_length = SRTP_MASTER_KEY_KEY_LEN + SRTP_MASTER_KEY_SALT_LEN;
+    ret->key_length = key_len + salt_len;
 
-    memcpy(&ret->client_write_key[0], &dtls_buffer[offset], SRTP_MASTER_KEY_KEY_LEN);
-    offset += SRTP_MASTER_KEY_KEY_LEN;
-    memcpy(&ret->server_write_key[0], &dtls_buffer[offset], SRTP_MASTER_KEY_KEY_LEN);
-    offset += SRTP_MASTER_KEY_KEY_LEN;
-    memcpy(&ret->client_write_key[SRTP_MASTER_KEY_KEY_LEN], &dtls_buffer[offset], SRTP_MASTER_KEY_SALT_LEN);
-    offset += SRTP_MASTER_KEY_SALT_LEN;
-    memcpy(&ret->server_write_key[SRTP_MASTER_KEY_KEY_LEN], &dtls_buffer[offset], SRTP_MASTER_KEY_SALT_LEN);
+    memcpy(&ret->client_write_key[0], &dtls_buffer[offset], key_len);
+    offset += key_len;
+    memcpy(&ret->server_write_key[0], &dtls_buffer[offset], key_len);
+    offset += key_len;
+    memcpy(&ret->client_write_key[key_len], &dtls_buffer[offset], salt_len);
+    offset += salt_len;
+    memcpy(&ret->server_write_key[key_len], &dtls_buffer[offset], salt_len);
 
-    switch (SSL_get_selected_srtp_profile(sess->ssl)->id) {
-    case SRTP_AES128_CM_SHA1_80:
-      memcpy(&ret->profile, "SRTP_AES128_CM_SHA1_80", strlen("SRTP_AES128_CM_SHA1_80"));
-      break;
-    case SRTP_AES128_CM_SHA1_32:
-      memcpy(&ret->profile, "SRTP_AES128_CM_SHA1_32", strlen("SRTP_AES128_CM_SHA1_32"));
-      break;
-    }
+    strncpy(ret->profile, srtp_profile->name, PROFILE_STRING_LENGTH - 1);
 
     return ret;
   }
@@ -12,9 +12,14 @@ package dtls
 */
 import "C"
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 	"sync"
 	"unsafe"
 
@@ -28,7 +33,12 @@ func init() {
 	}
 }
 
-var listenerMap = make(map[string]*ipv4.PacketConn)
+// PacketWriter is what DTLS records are sent with, the socket of a listener
+type PacketWriter interface {
+	WriteTo(b []byte, cm *ipv4.ControlMessage, dst net.Addr) (int, error)
+}
+
+var listenerMap = make(map[string]PacketWriter)
 var listenerMapLock = &sync.Mutex{}
 
 //export go_handle_sendto
@@ -56,10 +66,20 @@ func go_handle_sendto(rawLocal *C.char, rawRemote *C.char, rawBuf *C.char, rawBu
 			fmt.Println(err)
 		}
 	} else {
-		fmt.Printf("Could not find ipv4.PacketConn for %s \n", local)
+		fmt.Printf("Could not find PacketWriter for %s \n", local)
 	}
 }
 
+// fingerprintHashes maps the hash functions of https://tools.ietf.org/html/rfc4572#section-5
+// to their OpenSSL digest names
+var fingerprintHashes = map[string]string{
+	"sha-1":   "sha1",
+	"sha-224": "sha224",
+	"sha-256": "sha256",
+	"sha-384": "sha384",
+	"sha-512": "sha512",
+}
+
 // State represents all the state needed for a DTLS session
 type State struct {
 	sync.Mutex
@@ -67,27 +87,147 @@ type State struct {
 	tlscfg      *_Ctype_struct_tlscfg
 	sslctx      *_Ctype_struct_ssl_ctx_st
 	dtlsSession *_Ctype_struct_dtls_sess
+
+	// The certificate the peer presents has to match the fingerprint from
+	// its session description
+	remoteFingerprintHash string
+	remoteFingerprint     string
+
+	connectionState ConnectionState
+	notifier        func(ConnectionState)
 }
 
-// NewState creates a new DTLS session
-func NewState() (s *State, err error) {
+// NewState creates a new DTLS session that authenticates with certificate
+// and privateKey, notifier is called each time its ConnectionState changes
+func NewState(certificate *x509.Certificate, privateKey crypto.PrivateKey, notifier func(ConnectionState)) (s *State, err error) {
+	var keyDER []byte
+	switch k := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		if keyDER, err = x509.MarshalECPrivateKey(k); err != nil {
+			return nil, err
+		}
+	case *rsa.PrivateKey:
+		keyDER = x509.MarshalPKCS1PrivateKey(k)
+	default:
+		return nil, errors.Errorf("Unsupported DTLS private key type %T", privateKey)
+	}
+
 	s = &State{
-		tlscfg: C.dtls_build_tlscfg(),
+		connectionState: ConnectionStateNew,
+		notifier:        notifier,
+	}
+
+	certDER := C.CBytes(certificate.Raw)
+	defer C.free(certDER)
+	rawKeyDER := C.CBytes(keyDER)
+	defer C.free(rawKeyDER)
+
+	s.tlscfg = C.dtls_build_tlscfg((*C.uchar)(certDER), C.int(len(certificate.Raw)), (*C.uchar)(rawKeyDER), C.int(len(keyDER)))
+	if s.tlscfg == nil {
+		return nil, errors.New("Failed to load the DTLS certificate")
 	}
 
-	s.sslctx = C.dtls_build_sslctx(s.tlscfg)
+	if s.sslctx = C.dtls_build_sslctx(s.tlscfg); s.sslctx == nil {
+		C.dtls_session_cleanup(nil, nil, s.tlscfg)
+		return nil, errors.New("Failed to build the DTLS context, the private key may not match the certificate")
+	}
+
+	return s, nil
+}
+
+// SetRemoteFingerprint sets the fingerprint of the peer's certificate, as
+// announced in the a=fingerprint attribute of its session description
+func (s *State) SetRemoteFingerprint(algorithm, value string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	hash, ok := fingerprintHashes[strings.ToLower(algorithm)]
+	if !ok {
+		return errors.Errorf("Unsupported fingerprint hash function %s", algorithm)
+	}
 
-	return s, err
+	s.remoteFingerprintHash = hash
+	s.remoteFingerprint = value
+	return nil
 }
 
-// Start allocates DTLS/ICE state that is dependent on if we are offering or answering
-func (s *State) Start(isOffer bool) {
-	s.dtlsSession = C.dtls_build_session(s.sslctx, C.bool(isOffer))
+// Start allocates the DTLS session, isClient is whether we initiate the
+// handshake as negotiated by the a=setup attributes
+func (s *State) Start(isClient bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.dtlsSession = C.dtls_build_session(s.sslctx, C.bool(!isClient))
+	s.updateConnectionState(ConnectionStateConnecting)
 }
 
 // Close cleans up the associated OpenSSL resources
 func (s *State) Close() {
+	s.Lock()
+	defer s.Unlock()
+
 	C.dtls_session_cleanup(s.sslctx, s.dtlsSession, s.tlscfg)
+	s.dtlsSession = nil
+	s.updateConnectionState(ConnectionStateClosed)
+}
+
+func (s *State) updateConnectionState(newState ConnectionState) {
+	if s.connectionState == newState {
+		return
+	}
+	s.connectionState = newState
+
+	// Call handler async since we are holding the lock
+	// and the handler may call back into the State
+	if s.notifier != nil {
+		go s.notifier(newState)
+	}
+}
+
+// checkHandshake moves the session to ConnectionStateConnected once the
+// handshake has completed and the peer's certificate has been verified, or
+// to ConnectionStateFailed if either fails. The caller holds the lock.
+func (s *State) checkHandshake() error {
+	if s.connectionState != ConnectionStateConnecting {
+		return nil
+	}
+
+	if s.dtlsSession.failed {
+		s.updateConnectionState(ConnectionStateFailed)
+		return errors.New("DTLS handshake failed")
+	}
+	if !C.dtls_is_init_finished(s.dtlsSession) {
+		return nil
+	}
+
+	if err := s.verifyRemoteFingerprint(); err != nil {
+		s.updateConnectionState(ConnectionStateFailed)
+		return err
+	}
+	s.updateConnectionState(ConnectionStateConnected)
+	return nil
+}
+
+// verifyRemoteFingerprint checks the certificate the peer presented against
+// the fingerprint from its session description
+func (s *State) verifyRemoteFingerprint() error {
+	if s.remoteFingerprint == "" {
+		return errors.New("Unable to verify the DTLS peer, no remote fingerprint")
+	}
+
+	rawHash := C.CString(s.remoteFingerprintHash)
+	defer C.free(unsafe.Pointer(rawHash))
+
+	rawFingerprint := C.dtls_peer_fingerprint(s.dtlsSession, rawHash)
+	if rawFingerprint == nil {
+		return errors.New("Unable to verify the DTLS peer, no certificate")
+	}
+	defer C.free(unsafe.Pointer(rawFingerprint))
+
+	if fingerprint := C.GoString(rawFingerprint); !strings.EqualFold(fingerprint, s.remoteFingerprint) {
+		return errors.Errorf("DTLS peer certificate fingerprint %s does not match %s", fingerprint, s.remoteFingerprint)
+	}
+	return nil
 }
 
 // Fingerprint generates a SHA-256 fingerprint of the certificate
@@ -97,7 +237,9 @@ func (s *State) Fingerprint() string {
 	return C.GoString(rawFingerprint)
 }
 
-// CertPair is the client+server key and profile extracted for SRTP
+// CertPair is the client+server key and profile extracted for SRTP, as
+// described in https://tools.ietf.org/html/rfc5764#section-4.2. Each key is
+// the SRTP master key followed by the master salt.
 type CertPair struct {
 	ClientWriteKey []byte
 	ServerWriteKey []byte
@@ -113,6 +255,9 @@ func (s *State) HandleDTLSPacket(packet []byte, local, remote string) ([]byte, e
 	if s.dtlsSession == nil {
 		return nil, errors.Errorf("Unable to handle DTLS packet, session has not started")
 	}
+	if s.connectionState == ConnectionStateFailed {
+		return nil, errors.Errorf("Unable to handle DTLS packet, session has failed")
+	}
 
 	rawLocal := C.CString(local)
 	rawRemote := C.CString(remote)
@@ -123,11 +268,18 @@ func (s *State) HandleDTLSPacket(packet []byte, local, remote string) ([]byte, e
 		C.free(packetRaw)
 	}()
 
-	if ret := C.dtls_handle_incoming(s.dtlsSession, packetRaw, C.int(len(packet)), rawLocal, rawRemote); ret != nil {
+	ret := C.dtls_handle_incoming(s.dtlsSession, packetRaw, C.int(len(packet)), rawLocal, rawRemote)
+	if ret != nil {
 		defer func() {
 			C.free(ret.buf)
 			C.free(unsafe.Pointer(ret))
 		}()
+	}
+
+	if err := s.checkHandshake(); err != nil {
+		return nil, err
+	}
+	if ret != nil {
 		return C.GoBytes(ret.buf, ret.len), nil
 	}
 	return nil, nil
@@ -141,6 +293,9 @@ func (s *State) Send(packet []byte, local, remote string) (bool, error) {
 	if s.dtlsSession == nil {
 		return false, errors.Errorf("Unable to send via DTLS, session has not started")
 	}
+	if s.connectionState != ConnectionStateConnected {
+		return false, errors.Errorf("Unable to send via DTLS, session is %s", s.connectionState.String())
+	}
 
 	rawLocal := C.CString(local)
 	rawRemote := C.CString(remote)
@@ -154,12 +309,13 @@ func (s *State) Send(packet []byte, local, remote string) (bool, error) {
 	return bool(C.dtls_handle_outgoing(s.dtlsSession, packetRaw, C.int(len(packet)), rawLocal, rawRemote)), nil
 }
 
-// GetCertPair gets the current CertPair if DTLS has finished
+// GetCertPair gets the current CertPair if DTLS has finished, the peer has
+// been verified and it negotiated the use_srtp extension
 func (s *State) GetCertPair() *CertPair {
 	s.Lock()
 	defer s.Unlock()
 
-	if s.dtlsSession == nil {
+	if s.dtlsSession == nil || s.connectionState != ConnectionStateConnected {
 		return nil
 	}
 
@@ -194,7 +350,7 @@ func (s *State) DoHandshake(local, remote string) {
 
 // AddListener adds the socket to a map that can be accessed by OpenSSL for sending
 // This only needed until DTLS is rewritten in native Go
-func AddListener(src string, conn *ipv4.PacketConn) {
+func AddListener(src string, conn PacketWriter) {
 	listenerMapLock.Lock()
 	listenerMap[src] = conn
 	listenerMapLock.Unlock()
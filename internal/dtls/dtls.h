@@ -10,9 +10,6 @@
 #include <stdbool.h>
 #include <string.h>
 
-#define SRTP_MASTER_KEY_KEY_LEN 16
-#define SRTP_MASTER_KEY_SALT_LEN 14
-
 enum dtls_con_state {
   DTLS_CONSTATE_ACT,      // Endpoint is willing to inititate connections.
   DTLS_CONSTATE_PASS,     // Endpoint is willing to accept connections.
@@ -35,6 +32,7 @@ typedef struct dtls_sess {
 
   enum dtls_con_state state;
   enum dtls_con_type type;
+  bool failed; // A fatal error ended the session
 } dtls_sess;
 
 typedef struct dtls_decrypted {
@@ -43,21 +41,24 @@ typedef struct dtls_decrypted {
 } dtls_decrypted;
 
 #define PROFILE_STRING_LENGTH 23
-#define SRTP_MASTER_KEY_KEY_LEN 16
-#define SRTP_MASTER_KEY_SALT_LEN 14
+
+// Largest master key and salt of the offered profiles, AEAD_AES_256_GCM has
+// the longest key and AES128_CM_SHA1_80 the longest salt
+#define SRTP_MAX_MASTER_KEY_LEN 32
+#define SRTP_MAX_MASTER_SALT_LEN 14
 
 typedef struct dtls_cert_pair {
-  char client_write_key[SRTP_MASTER_KEY_KEY_LEN + SRTP_MASTER_KEY_SALT_LEN];
-  char server_write_key[SRTP_MASTER_KEY_KEY_LEN + SRTP_MASTER_KEY_SALT_LEN];
+  char client_write_key[SRTP_MAX_MASTER_KEY_LEN + SRTP_MAX_MASTER_SALT_LEN];
+  char server_write_key[SRTP_MAX_MASTER_KEY_LEN + SRTP_MAX_MASTER_SALT_LEN];
   char profile[PROFILE_STRING_LENGTH];
   int key_length;
 } dtls_cert_pair;
 
 bool openssl_global_init();
 
-tlscfg *dtls_build_tlscfg();
+tlscfg *dtls_build_tlscfg(const unsigned char *cert_der, int cert_len, const unsigned char *key_der, int key_len);
 SSL_CTX *dtls_build_sslctx(tlscfg *cfg);
-dtls_sess *dtls_build_session(SSL_CTX *cfg, bool is_offer);
+dtls_sess *dtls_build_session(SSL_CTX *cfg, bool is_server);
 
 ptrdiff_t dtls_do_handshake(dtls_sess *sess, char *local, char *remote);
 dtls_decrypted *dtls_handle_incoming(dtls_sess *sess, void *buf, int len, char *local, char *remote);
@@ -65,6 +66,8 @@ bool dtls_handle_outgoing(dtls_sess *sess, void *buf, int len, char *local, char
 
 dtls_cert_pair *dtls_get_certpair(dtls_sess *sess);
 char *dtls_tlscfg_fingerprint(tlscfg *cfg);
+char *dtls_peer_fingerprint(dtls_sess *sess, const char *hash_name);
+bool dtls_is_init_finished(dtls_sess *sess);
 
 void dtls_session_cleanup(SSL_CTX *ssl_ctx, dtls_sess *dtls_session, tlscfg *cfg);
 
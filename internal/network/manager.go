@@ -1,33 +1,44 @@
 package network
 
 import (
+	"crypto"
+	"crypto/x509"
 	"fmt"
 	"net"
-	"strconv"
+	"strings"
 	"sync"
+	"time"
 
-	"github.com/pions/pkg/stun"
 	"github.com/pions/webrtc/internal/dtls"
 	"github.com/pions/webrtc/internal/sctp"
 	"github.com/pions/webrtc/internal/srtp"
-	webrtcStun "github.com/pions/webrtc/internal/stun"
 	"github.com/pions/webrtc/pkg/datachannel"
 	"github.com/pions/webrtc/pkg/ice"
+	"github.com/pions/webrtc/pkg/mdns"
 	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pions/webrtc/pkg/stun"
 	"github.com/pkg/errors"
 )
 
+// mdnsQueryTimeout is how long the address of a remote .local candidate is
+// waited for
+const mdnsQueryTimeout = 10 * time.Second
+
 // Manager contains all network state (DTLS, SRTP) that is shared between ports
 // It is also used to perform operations that involve multiple ports
 type Manager struct {
-	IceAgent    *ice.Agent
-	iceNotifier ICENotifier
-	isOffer     bool
+	IceAgent      *ice.Agent
+	iceNotifier   ICENotifier
+	isControlling bool
 
 	dtlsState *dtls.State
 
+	sctpNotifier SCTPNotifier
+
+	// certPairLock also guards isDTLSClient, read by the ports
 	certPairLock sync.RWMutex
 	certPair     *dtls.CertPair
+	isDTLSClient bool
 
 	dataChannelEventHandler DataChannelEventHandler
 
@@ -42,88 +53,328 @@ type Manager struct {
 
 	sctpAssociation *sctp.Association
 
+	// DataChannels we opened that haven't been acknowledged yet, guarded by
+	// the lock of the sctpAssociation
+	pendingChannelOpens map[uint16]*datachannel.ChannelOpen
+
+	// The start of messages sent with the deprecated partial PPIDs, guarded
+	// by the lock of the sctpAssociation
+	partialMessages map[uint16][]byte
+
 	portsLock sync.RWMutex
 	ports     []*port
+
+	// udpMuxConn is the share of the UDPMux of the UDP host candidate, nil
+	// when the Manager has its own ports
+	udpMuxConn *udpMuxConn
+
+	// mdnsConn resolves the .local candidates of the peer, and answers for
+	// ours when host candidates are hidden. nil when the mDNS port could
+	// not be joined.
+	mdnsConn *mdns.Conn
+}
+
+// ManagerOptions configures the host candidates of a Manager and the mode
+// of its IceAgent
+type ManagerOptions struct {
+	// HideHostCandidates replaces the addresses of the host candidates by
+	// mDNS .local names, IPv6 interfaces are left out as mDNS only answers
+	// for IPv4 addresses
+	HideHostCandidates bool
+
+	// ICELite runs the IceAgent in lite mode
+	ICELite bool
+
+	// NetworkTypes are the network types host candidates are gathered for,
+	// ice.DefaultNetworkTypes when empty
+	NetworkTypes []ice.NetworkType
+
+	// InterfaceFilter is given the name of each interface, only those it
+	// accepts are gathered from. Every interface is when it is nil.
+	InterfaceFilter func(name string) bool
+
+	// NAT1To1IP is the public address a 1:1 NAT maps the host to, like the
+	// ones of cloud hosts. It is signaled in place of the addresses of the
+	// host candidates of its IP version.
+	NAT1To1IP string
+
+	// UDPMux is shared with other Managers, the only UDP host candidate is
+	// on it in place of a port on each interface
+	UDPMux *UDPMux
 }
 
-// NewManager creates a new network.Manager
-func NewManager(btg BufferTransportGenerator, dcet DataChannelEventHandler, ntf ICENotifier) (m *Manager, err error) {
+// NewManager creates a new network.Manager, DTLS authenticates with certificate and privateKey
+func NewManager(btg BufferTransportGenerator, dcet DataChannelEventHandler, ntf ICENotifier, dtlsNtf DTLSNotifier, sctpNtf SCTPNotifier, certificate *x509.Certificate, privateKey crypto.PrivateKey, opts ManagerOptions) (m *Manager, err error) {
+	var natIP net.IP
+	if opts.NAT1To1IP != "" {
+		if natIP = net.ParseIP(opts.NAT1To1IP); natIP == nil {
+			return nil, errors.Errorf("NAT 1:1 IP %s is not an IP address", opts.NAT1To1IP)
+		} else if opts.HideHostCandidates {
+			return nil, errors.Errorf("host candidates can't be both hidden and signaled with a NAT 1:1 IP")
+		}
+	}
+
 	m = &Manager{
 		iceNotifier:              ntf,
+		sctpNotifier:             sctpNtf,
 		bufferTransports:         make(map[uint32]chan<- *rtp.Packet),
 		bufferTransportGenerator: btg,
 		dataChannelEventHandler:  dcet,
+		pendingChannelOpens:      make(map[uint16]*datachannel.ChannelOpen),
+		partialMessages:          make(map[uint16][]byte),
 	}
-	m.dtlsState, err = dtls.NewState()
+	m.dtlsState, err = dtls.NewState(certificate, privateKey, dtlsNtf)
 	if err != nil {
 		return nil, err
 	}
 
 	m.sctpAssociation = sctp.NewAssocation(m.dataChannelOutboundHandler, m.dataChannelInboundHandler)
+	m.sctpAssociation.SetBufferedAmountLowHandler(func(streamIdentifier uint16) {
+		m.dataChannelEventHandler(&DataChannelBufferedAmountLow{streamIdentifier: streamIdentifier})
+	})
+	m.sctpAssociation.SetStreamResetHandler(func(streamIdentifier uint16) {
+		delete(m.pendingChannelOpens, streamIdentifier)
+		delete(m.partialMessages, streamIdentifier)
+		m.dataChannelEventHandler(&DataChannelClosed{streamIdentifier: streamIdentifier})
+	})
+	// The DataChannels are opened once established, which locks the
+	// association again
+	m.sctpAssociation.SetEstablishedHandler(func() {
+		go m.sctpNotifier()
+	})
+
+	// DTLS already protects the SCTP packets from corruption
+	m.sctpAssociation.EnableZeroChecksum()
+
+	if m.mdnsConn, err = mdns.Listen(); err != nil {
+		fmt.Printf("Failed to join mDNS, .local candidates won't be used: %s\n", err.Error())
+		if opts.HideHostCandidates {
+			return nil, err
+		}
+	}
 
 	m.IceAgent = ice.NewAgent(m.iceOutboundHandler, m.iceNotifier)
-	for _, i := range localInterfaces() {
-		p, portErr := newPort(i+":0", m)
-		if portErr != nil {
-			return nil, portErr
+	if err = m.IceAgent.SetLite(opts.ICELite); err != nil {
+		return nil, err
+	}
+	if opts.UDPMux != nil {
+		if err = m.addUDPMuxCandidate(opts, natIP); err != nil {
+			return nil, err
 		}
-
-		m.ports = append(m.ports, p)
-		m.IceAgent.AddLocalCandidate(&ice.CandidateHost{
-			CandidateBase: ice.CandidateBase{
-				Protocol: ice.ProtoTypeUDP,
-				Address:  p.listeningAddr.IP.String(),
-				Port:     p.listeningAddr.Port,
-			},
-		})
+	}
+	if err = m.gatherHostCandidates(opts, natIP); err != nil {
+		return nil, err
 	}
 
 	return m, err
 }
 
-// AddURL takes an ICE Url, allocates any state and adds the candidate
-func (m *Manager) AddURL(url *ice.URL) error {
-	m.portsLock.Lock()
-	defer m.portsLock.Unlock()
+// gatherHostCandidates binds a port on each local address for each network
+// type and adds it to the IceAgent as a host candidate. Each address gets
+// its own local preference, the first one is preferred. A passive and an
+// active ICE-TCP candidate are added for the TCP network types, for
+// networks that block UDP. When hidden every candidate of an address is
+// signaled with the same .local name. A lite agent only has passive TCP
+// candidates, it doesn't open connections. There are no UDP ones when the
+// Manager has a UDPMux.
+// https://tools.ietf.org/html/rfc8445#section-5.1.1.1
+// https://tools.ietf.org/html/rfc6544#section-5.1
+func (m *Manager) gatherHostCandidates(opts ManagerOptions, natIP net.IP) error {
+	networkTypes := opts.NetworkTypes
+	if len(networkTypes) == 0 {
+		networkTypes = ice.DefaultNetworkTypes
+	}
+
+	tcpTypes := []ice.TCPType{ice.TCPTypeActive, ice.TCPTypePassive}
+	if opts.ICELite {
+		tcpTypes = []ice.TCPType{ice.TCPTypePassive}
+	}
 
-	switch url.Scheme {
-	case ice.SchemeTypeSTUN:
-		c, err := webrtcStun.Allocate(url)
+	for i, ip := range localInterfaces(opts.InterfaceFilter) {
+		hostname, skip, err := m.hostCandidateName(opts, natIP, ip)
 		if err != nil {
 			return err
+		} else if skip {
+			continue
 		}
-		p, err := newPort(c.RemoteAddress+":"+strconv.Itoa(c.RemotePort), m)
-		if err != nil {
-			return err
+
+		for _, networkType := range networkTypes {
+			if !networkType.Matches(ip) {
+				continue
+			}
+
+			if networkType.Protocol() == ice.ProtoTypeUDP {
+				if opts.UDPMux != nil {
+					continue
+				}
+
+				p, err := newPort(networkType.String(), ip, m)
+				if err != nil {
+					return err
+				}
+
+				m.ports = append(m.ports, p)
+				m.IceAgent.AddLocalCandidate(&ice.CandidateHost{
+					CandidateBase: ice.CandidateBase{
+						Protocol:        ice.ProtoTypeUDP,
+						Address:         p.listeningAddr.IP.String(),
+						Port:            p.listeningAddr.Port,
+						LocalPreference: ice.DefaultLocalPreference - uint16(i),
+						Hostname:        hostname,
+					},
+				})
+				continue
+			}
+
+			for _, tcpType := range tcpTypes {
+				p, err := newTCPPort(networkType.String(), ip, tcpType, m)
+				if err != nil {
+					return err
+				}
+
+				m.ports = append(m.ports, p)
+				m.IceAgent.AddLocalCandidate(&ice.CandidateHost{
+					CandidateBase: ice.CandidateBase{
+						Protocol:        ice.ProtoTypeTCP,
+						Address:         p.listeningAddr.IP.String(),
+						Port:            p.listeningAddr.Port,
+						LocalPreference: ice.TCPLocalPreference(tcpType, 1<<13-1-uint16(i)),
+						TCPType:         tcpType,
+						Hostname:        hostname,
+					},
+				})
+			}
 		}
+	}
+	return nil
+}
 
-		m.ports = append(m.ports, p)
-		m.IceAgent.AddLocalCandidate(c)
+// hostCandidateName returns what the host candidates on ip are signaled with
+// in place of their address, if anything. Hidden IPv6 candidates are
+// skipped, mDNS only answers for IPv4 addresses.
+func (m *Manager) hostCandidateName(opts ManagerOptions, natIP, ip net.IP) (hostname string, skip bool, err error) {
+	switch {
+	case opts.HideHostCandidates && ip.To4() == nil:
+		return "", true, nil
+	case opts.HideHostCandidates:
+		if hostname, err = newMDNSHostname(); err != nil {
+			return "", false, err
+		}
+		return hostname, false, m.mdnsConn.Register(hostname, ip)
+	case natIP != nil && (natIP.To4() == nil) == (ip.To4() == nil):
+		return natIP.String(), false, nil
 	default:
-		return errors.Errorf("%s is not implemented", url.Scheme.String())
+		return "", false, nil
 	}
+}
 
+// addUDPMuxCandidate adds the host candidate on the UDPMux, it is preferred
+// to the others
+func (m *Manager) addUDPMuxCandidate(opts ManagerOptions, natIP net.IP) error {
+	addr := opts.UDPMux.LocalAddr()
+	hostname, skip, err := m.hostCandidateName(opts, natIP, addr.IP)
+	if err != nil || skip {
+		return err
+	}
+
+	c, err := opts.UDPMux.newConn(m.IceAgent.LocalUfrag)
+	if err != nil {
+		return err
+	}
+	m.udpMuxConn = c
+	m.ports = append(m.ports, startPort(c, addr, "udp", m))
+	m.IceAgent.AddLocalCandidate(&ice.CandidateHost{
+		CandidateBase: ice.CandidateBase{
+			Protocol: ice.ProtoTypeUDP,
+			Address:  addr.IP.String(),
+			Port:     addr.Port,
+			Hostname: hostname,
+		},
+	})
 	return nil
 }
 
-// Start allocates DTLS/ICE state that is dependent on if we are offering or answering
-func (m *Manager) Start(isOffer bool, remoteUfrag, remotePwd string) error {
-	m.isOffer = isOffer
-	if err := m.IceAgent.Start(isOffer, remoteUfrag, remotePwd); err != nil {
+// AddURL takes an ICE Url and gathers candidates from the server it points
+// to, from the ports of the host candidates
+func (m *Manager) AddURL(url *ice.URL) error {
+	return m.IceAgent.AddURL(url)
+}
+
+// AddRemoteCandidate passes a candidate of the peer to the IceAgent, the
+// address of a .local one is resolved first
+func (m *Manager) AddRemoteCandidate(c ice.Candidate) {
+	base := c.GetBase()
+	if !strings.HasSuffix(base.Address, ".local") {
+		m.IceAgent.AddRemoteCandidate(c)
+		return
+	} else if m.mdnsConn == nil {
+		fmt.Printf("Discarding candidate %s, mDNS is not available\n", base.Address)
+		return
+	}
+
+	go func() {
+		ip, err := m.mdnsConn.Query(base.Address, mdnsQueryTimeout)
+		if err != nil {
+			fmt.Printf("Failed to resolve candidate %s: %s\n", base.Address, err.Error())
+			return
+		}
+		base.Hostname = base.Address
+		base.Address = ip.String()
+		m.IceAgent.AddRemoteCandidate(c)
+	}()
+}
+
+// Start allocates ICE state that is dependent on if we control ICE, the
+// offerer does unless the answerer is a full agent and it is lite, and DTLS
+// state that is dependent on if we initiate the handshake
+func (m *Manager) Start(isControlling, isDTLSClient bool, remoteUfrag, remotePwd string) error {
+	m.isControlling = isControlling
+	m.certPairLock.Lock()
+	m.isDTLSClient = isDTLSClient
+	m.certPairLock.Unlock()
+	if err := m.IceAgent.Start(isControlling, remoteUfrag, remotePwd); err != nil {
 		return err
 	}
-	m.dtlsState.Start(isOffer)
+	m.dtlsState.Start(isDTLSClient)
 	return nil
 }
 
+// RestartICE performs an ICE restart, DTLS and SCTP keep running on the
+// pair selected before until ResumeICE finds a new one
+func (m *Manager) RestartICE() {
+	m.IceAgent.Restart()
+	if m.udpMuxConn != nil {
+		m.udpMuxConn.setUfrag(m.IceAgent.LocalUfrag)
+	}
+}
+
+// ResumeICE starts the checks of an ICE restart with the new credentials of
+// the peer, the agent keeps the role it had
+func (m *Manager) ResumeICE(remoteUfrag, remotePwd string) error {
+	return m.IceAgent.Start(m.isControlling, remoteUfrag, remotePwd)
+}
+
+// SetRemoteDTLSFingerprint sets the fingerprint the certificate of the
+// remote peer is verified against
+func (m *Manager) SetRemoteDTLSFingerprint(algorithm, value string) error {
+	return m.dtlsState.SetRemoteFingerprint(algorithm, value)
+}
+
 // Close cleans up all the allocated state
 func (m *Manager) Close() {
+	// The agent sends through the ports, it is stopped before they are locked
+	m.IceAgent.Close()
+
 	m.portsLock.Lock()
 	defer m.portsLock.Unlock()
 
 	err := m.sctpAssociation.Close()
 	m.dtlsState.Close()
-	m.IceAgent.Close()
+	if m.mdnsConn != nil {
+		if mdnsErr := m.mdnsConn.Close(); mdnsErr != nil && err == nil {
+			err = mdnsErr
+		}
+	}
 
 	for i := len(m.ports) - 1; i >= 0; i-- {
 		if portError := m.ports[i].close(); portError != nil {
@@ -153,7 +404,7 @@ func (m *Manager) SendRTP(packet *rtp.Packet) {
 		return
 	}
 	for _, p := range m.ports {
-		if p.listeningAddr.Equal(local) {
+		if p.sends(local, remote) {
 			p.sendRTP(packet, remote)
 		}
 	}
@@ -173,6 +424,15 @@ func (m *Manager) SendDataChannelMessage(payload datachannel.Payload, streamIden
 		message with one of these PPIDs, the receiver MUST ignore the SCTP
 		user message and process it as an empty message.
 	*/
+	// Messages handed to OnMessage are pointers, accept those to make
+	// echoing them back easy
+	switch p := payload.(type) {
+	case *datachannel.PayloadString:
+		payload = *p
+	case *datachannel.PayloadBinary:
+		payload = *p
+	}
+
 	switch p := payload.(type) {
 	case datachannel.PayloadString:
 		data = p.Data
@@ -205,6 +465,49 @@ func (m *Manager) SendDataChannelMessage(payload datachannel.Payload, streamIden
 	return nil
 }
 
+// SetSCTPParameters sets the SCTP ports of the DataChannels and the largest
+// message the remote accepts, zero when it has no limit
+func (m *Manager) SetSCTPParameters(localPort, remotePort uint16, maxMessageSize uint32) {
+	m.sctpAssociation.Lock()
+	defer m.sctpAssociation.Unlock()
+
+	m.sctpAssociation.SetPorts(localPort, remotePort)
+	m.sctpAssociation.SetMaxMessageSize(maxMessageSize)
+}
+
+// BufferedAmount returns how many bytes sent on a DataChannel the peer
+// hasn't acknowledged yet
+func (m *Manager) BufferedAmount(streamIdentifier uint16) uint64 {
+	m.sctpAssociation.Lock()
+	defer m.sctpAssociation.Unlock()
+
+	return m.sctpAssociation.BufferedAmount(streamIdentifier)
+}
+
+// SetBufferedAmountLowThreshold sets the BufferedAmount of a DataChannel at
+// or below which DataChannelBufferedAmountLow is emitted
+func (m *Manager) SetBufferedAmountLowThreshold(streamIdentifier uint16, threshold uint64) {
+	m.sctpAssociation.Lock()
+	defer m.sctpAssociation.Unlock()
+
+	m.sctpAssociation.SetBufferedAmountLowThreshold(streamIdentifier, threshold)
+}
+
+// CloseDataChannel closes a DataChannel by resetting the SCTP stream that
+// carries it, after which the peer resets its side of the stream as well
+func (m *Manager) CloseDataChannel(streamIdentifier uint16) error {
+	m.sctpAssociation.Lock()
+	delete(m.partialMessages, streamIdentifier)
+	err := m.sctpAssociation.ResetStream(streamIdentifier)
+	m.sctpAssociation.Unlock()
+
+	if err != nil {
+		return errors.Wrap(err, "SCTP Association failed resetting stream")
+	}
+
+	return nil
+}
+
 func (m *Manager) dataChannelInboundHandler(data []byte, streamIdentifier uint16, payloadType sctp.PayloadProtocolIdentifier) {
 	switch payloadType {
 	case sctp.PayloadTypeWebRTCDCEP:
@@ -226,25 +529,86 @@ func (m *Manager) dataChannelInboundHandler(data []byte, streamIdentifier uint16
 				fmt.Println("Error sending ChannelOpen ACK", err)
 				return
 			}
-			m.dataChannelEventHandler(&DataChannelCreated{streamIdentifier: streamIdentifier, Label: string(msg.Label)})
+
+			// The ACK is still sent reliably and in order, the channel's own
+			// reliability applies to the messages after it
+			unordered, relType := channelReliability(msg.ChannelType)
+			m.sctpAssociation.SetReliabilityParams(streamIdentifier, unordered, relType, msg.ReliabilityParameter)
+			m.dataChannelEventHandler(&DataChannelCreated{
+				streamIdentifier:     streamIdentifier,
+				Label:                string(msg.Label),
+				Protocol:             string(msg.Protocol),
+				ChannelType:          msg.ChannelType,
+				Priority:             msg.Priority,
+				ReliabilityParameter: msg.ReliabilityParameter,
+			})
+		case *datachannel.ChannelAck:
+			open, ok := m.pendingChannelOpens[streamIdentifier]
+			if !ok {
+				fmt.Printf("Unexpected ChannelAck for streamIdentifier %d \n", streamIdentifier)
+				return
+			}
+			delete(m.pendingChannelOpens, streamIdentifier)
+
+			unordered, relType := channelReliability(open.ChannelType)
+			m.sctpAssociation.SetReliabilityParams(streamIdentifier, unordered, relType, open.ReliabilityParameter)
+			m.dataChannelEventHandler(&DataChannelOpened{streamIdentifier: streamIdentifier})
 		default:
 			fmt.Println("Unhandled DataChannel message", m)
 		}
+	case sctp.PayloadTypeWebRTCStringPartial, sctp.PayloadTypeWebRTCBinaryPartial:
+		// https://tools.ietf.org/html/rfc8831#section-6.6 deprecates these,
+		// older peers split large messages into several SCTP messages that
+		// end with one sent with the regular PPID
+		m.partialMessages[streamIdentifier] = append(m.partialMessages[streamIdentifier], data...)
 	case sctp.PayloadTypeWebRTCString:
-		fallthrough
-	case sctp.PayloadTypeWebRTCStringEmpty:
-		payload := &datachannel.PayloadString{Data: data}
+		payload := &datachannel.PayloadString{Data: m.completeMessage(streamIdentifier, data)}
 		m.dataChannelEventHandler(&DataChannelMessage{streamIdentifier: streamIdentifier, Payload: payload})
 	case sctp.PayloadTypeWebRTCBinary:
-		fallthrough
+		payload := &datachannel.PayloadBinary{Data: m.completeMessage(streamIdentifier, data)}
+		m.dataChannelEventHandler(&DataChannelMessage{streamIdentifier: streamIdentifier, Payload: payload})
+	case sctp.PayloadTypeWebRTCStringEmpty:
+		// The single byte carried by an empty message is ignored
+		payload := &datachannel.PayloadString{Data: m.completeMessage(streamIdentifier, []byte{})}
+		m.dataChannelEventHandler(&DataChannelMessage{streamIdentifier: streamIdentifier, Payload: payload})
 	case sctp.PayloadTypeWebRTCBinaryEmpty:
-		payload := &datachannel.PayloadBinary{Data: data}
+		payload := &datachannel.PayloadBinary{Data: m.completeMessage(streamIdentifier, []byte{})}
 		m.dataChannelEventHandler(&DataChannelMessage{streamIdentifier: streamIdentifier, Payload: payload})
 	default:
 		fmt.Printf("Unhandled Payload Protocol Identifier %v \n", payloadType)
 	}
 }
 
+// completeMessage prepends the parts of a message sent with the partial PPIDs
+// to its last part
+func (m *Manager) completeMessage(streamIdentifier uint16, data []byte) []byte {
+	partial, ok := m.partialMessages[streamIdentifier]
+	if !ok {
+		return data
+	}
+	delete(m.partialMessages, streamIdentifier)
+	return append(partial, data...)
+}
+
+// channelReliability maps a DataChannel channel type to how the SCTP
+// stream carrying it delivers messages
+func channelReliability(channelType datachannel.ChannelType) (unordered bool, relType sctp.ReliabilityType) {
+	switch channelType {
+	case datachannel.ChannelTypeReliableUnordered:
+		return true, sctp.ReliabilityTypeReliable
+	case datachannel.ChannelTypePartialReliableRexmit:
+		return false, sctp.ReliabilityTypeRexmit
+	case datachannel.ChannelTypePartialReliableRexmitUnordered:
+		return true, sctp.ReliabilityTypeRexmit
+	case datachannel.ChannelTypePartialReliableTimed:
+		return false, sctp.ReliabilityTypeTimed
+	case datachannel.ChannelTypePartialReliableTimedUnordered:
+		return true, sctp.ReliabilityTypeTimed
+	default:
+		return false, sctp.ReliabilityTypeReliable
+	}
+}
+
 func (m *Manager) dataChannelOutboundHandler(raw []byte) {
 	local, remote := m.IceAgent.SelectedPair()
 	if remote == nil || local == nil {
@@ -255,7 +619,7 @@ func (m *Manager) dataChannelOutboundHandler(raw []byte) {
 
 	m.portsLock.RLock()
 	defer m.portsLock.RUnlock()
-	p, err := m.port(local)
+	p, err := m.port(local, remote)
 	if err != nil {
 		fmt.Println("dataChannelOutboundHandler: no valid port for candidate, dropping packet")
 		return
@@ -264,44 +628,43 @@ func (m *Manager) dataChannelOutboundHandler(raw []byte) {
 	p.sendSCTP(raw, remote)
 }
 
-func (m *Manager) port(local *stun.TransportAddr) (*port, error) {
+func (m *Manager) port(local *stun.TransportAddr, remote net.Addr) (*port, error) {
 	for _, p := range m.ports {
-		if p.listeningAddr.Equal(local) {
+		if p.sends(local, remote) {
 			return p, nil
 		}
 	}
 	return nil, errors.New("port not found")
 }
 
-func (m *Manager) iceOutboundHandler(raw []byte, local *stun.TransportAddr, remote *net.UDPAddr) {
+func (m *Manager) iceOutboundHandler(raw []byte, local *stun.TransportAddr, remote net.Addr) {
 	m.portsLock.RLock()
 	defer m.portsLock.RUnlock()
 
 	for _, p := range m.ports {
-		if p.listeningAddr.Equal(local) {
+		if p.sends(local, remote) {
 			p.sendICE(raw, remote)
 			return
 		}
 	}
 }
 
-// SendOpenChannelMessage sends the message to open a datachannel to the connected peer
-func (m *Manager) SendOpenChannelMessage(streamIdentifier uint16, label string) error {
-	msg := &datachannel.ChannelOpen{
-		ChannelType:          datachannel.ChannelTypeReliable,
-		Priority:             datachannel.ChannelPriorityNormal,
-		ReliabilityParameter: 0,
-
-		Label:    []byte(label),
-		Protocol: []byte(""),
-	}
-
+// SendOpenChannelMessage sends the DATA_CHANNEL_OPEN message to the connected
+// peer. Until the peer acknowledges it the stream stays reliable and ordered,
+// https://tools.ietf.org/html/rfc8832#section-6
+func (m *Manager) SendOpenChannelMessage(streamIdentifier uint16, msg *datachannel.ChannelOpen) error {
 	rawMsg, err := msg.Marshal()
 	if err != nil {
 		return fmt.Errorf("Error Marshaling ChannelOpen %v", err)
 	}
+
+	m.sctpAssociation.Lock()
+	defer m.sctpAssociation.Unlock()
+
+	m.sctpAssociation.SetReliabilityParams(streamIdentifier, false, sctp.ReliabilityTypeReliable, 0)
 	if err = m.sctpAssociation.HandleOutbound(rawMsg, streamIdentifier, sctp.PayloadTypeWebRTCDCEP); err != nil {
 		return fmt.Errorf("Error sending ChannelOpen %v", err)
 	}
+	m.pendingChannelOpens[streamIdentifier] = msg
 	return nil
 }
@@ -0,0 +1,38 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/pions/webrtc/internal/sctp"
+	"github.com/pions/webrtc/pkg/datachannel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataChannelInboundPayloads(t *testing.T) {
+	var payloads []datachannel.Payload
+	m := &Manager{
+		partialMessages: make(map[uint16][]byte),
+		dataChannelEventHandler: func(e DataChannelEvent) {
+			if msg, ok := e.(*DataChannelMessage); ok {
+				payloads = append(payloads, msg.Payload)
+			}
+		},
+	}
+
+	m.dataChannelInboundHandler([]byte("hello"), 1, sctp.PayloadTypeWebRTCString)
+	m.dataChannelInboundHandler([]byte{0}, 1, sctp.PayloadTypeWebRTCStringEmpty)
+	m.dataChannelInboundHandler([]byte{0}, 1, sctp.PayloadTypeWebRTCBinaryEmpty)
+	m.dataChannelInboundHandler([]byte{1, 2}, 1, sctp.PayloadTypeWebRTCBinaryPartial)
+	m.dataChannelInboundHandler([]byte{3}, 3, sctp.PayloadTypeWebRTCBinary)
+	m.dataChannelInboundHandler([]byte{4, 5}, 1, sctp.PayloadTypeWebRTCBinaryPartial)
+	m.dataChannelInboundHandler([]byte{6}, 1, sctp.PayloadTypeWebRTCBinary)
+
+	assert.Equal(t, []datachannel.Payload{
+		&datachannel.PayloadString{Data: []byte("hello")},
+		&datachannel.PayloadString{Data: []byte{}},
+		&datachannel.PayloadBinary{Data: []byte{}},
+		&datachannel.PayloadBinary{Data: []byte{3}},
+		&datachannel.PayloadBinary{Data: []byte{1, 2, 4, 5, 6}},
+	}, payloads)
+	assert.Empty(t, m.partialMessages)
+}
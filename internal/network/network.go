@@ -1,6 +1,7 @@
 package network
 
 import (
+	"github.com/pions/webrtc/internal/dtls"
 	"github.com/pions/webrtc/pkg/datachannel"
 	"github.com/pions/webrtc/pkg/ice"
 	"github.com/pions/webrtc/pkg/rtp"
@@ -13,6 +14,13 @@ type BufferTransportGenerator func(uint32, uint8) chan<- *rtp.Packet
 // ICENotifier notifies the RTCPeerConnection if ICE state has changed
 type ICENotifier func(ice.ConnectionState)
 
+// DTLSNotifier notifies the RTCPeerConnection if DTLS state has changed
+type DTLSNotifier func(dtls.ConnectionState)
+
+// SCTPNotifier notifies the RTCPeerConnection once the SCTP association is
+// established
+type SCTPNotifier func()
+
 // DataChannelEventHandler notifies the RTCPeerConnection of events relating to DataChannels
 type DataChannelEventHandler func(DataChannelEvent)
 
@@ -30,10 +38,14 @@ type DataChannelEvent interface {
 	StreamIdentifier() uint16
 }
 
-// DataChannelCreated is emitted when a new DataChannel is created
+// DataChannelCreated is emitted when the remote peer opens a new DataChannel
 type DataChannelCreated struct {
-	Label            string
-	streamIdentifier uint16
+	Label                string
+	Protocol             string
+	ChannelType          datachannel.ChannelType
+	Priority             uint16
+	ReliabilityParameter uint32
+	streamIdentifier     uint16
 }
 
 // StreamIdentifier returns the streamIdentifier
@@ -41,6 +53,39 @@ func (d *DataChannelCreated) StreamIdentifier() uint16 {
 	return d.streamIdentifier
 }
 
+// DataChannelOpened is emitted when the remote peer acknowledges a
+// DataChannel we opened
+type DataChannelOpened struct {
+	streamIdentifier uint16
+}
+
+// StreamIdentifier returns the streamIdentifier
+func (d *DataChannelOpened) StreamIdentifier() uint16 {
+	return d.streamIdentifier
+}
+
+// DataChannelBufferedAmountLow is emitted when the amount of data a
+// DataChannel has buffered falls to its threshold
+type DataChannelBufferedAmountLow struct {
+	streamIdentifier uint16
+}
+
+// StreamIdentifier returns the streamIdentifier
+func (d *DataChannelBufferedAmountLow) StreamIdentifier() uint16 {
+	return d.streamIdentifier
+}
+
+// DataChannelClosed is emitted when the stream of a DataChannel has been
+// reset, by either peer
+type DataChannelClosed struct {
+	streamIdentifier uint16
+}
+
+// StreamIdentifier returns the streamIdentifier
+func (d *DataChannelClosed) StreamIdentifier() uint16 {
+	return d.streamIdentifier
+}
+
 // DataChannelMessage is emitted when a DataChannel receives a message
 type DataChannelMessage struct {
 	Payload          datachannel.Payload
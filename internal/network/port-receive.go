@@ -1,20 +1,19 @@
 package network
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
 	"net"
 
 	"github.com/pions/webrtc/internal/dtls"
 	"github.com/pions/webrtc/internal/sctp"
 	"github.com/pions/webrtc/internal/srtp"
+	"github.com/pions/webrtc/pkg/demux"
 	"github.com/pions/webrtc/pkg/rtp"
 	"github.com/pkg/errors"
 )
 
 type incomingPacket struct {
-	srcAddr *net.UDPAddr
+	srcAddr net.Addr
 	buffer  []byte
 }
 
@@ -26,24 +25,14 @@ func (p *port) handleSRTP(buffer []byte) {
 		return
 	}
 
-	if len(buffer) > 4 {
-		var rtcpPacketType uint8
-
-		r := bytes.NewReader([]byte{buffer[1]})
-		if err := binary.Read(r, binary.BigEndian, &rtcpPacketType); err != nil {
-			fmt.Println("Failed to check packet for RTCP")
-			return
-		}
-
-		if rtcpPacketType >= 192 && rtcpPacketType <= 223 {
-			decrypted, err := p.m.srtpInboundContext.DecryptRTCP(buffer)
-			if err != nil {
-				fmt.Println(err)
-				fmt.Println(decrypted)
-				return
-			}
+	if demux.IsRTCP(buffer) {
+		decrypted, err := p.m.srtpInboundContext.DecryptRTCP(buffer)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println(decrypted)
 			return
 		}
+		return
 	}
 
 	packet := &rtp.Packet{}
@@ -83,7 +72,7 @@ func (p *port) handleSCTP(raw []byte, a *sctp.Association) {
 }
 
 func (p *port) handleDTLS(raw []byte, srcAddr string) {
-	decrypted, err := p.m.dtlsState.HandleDTLSPacket(raw, p.listeningAddr.String(), srcAddr)
+	decrypted, err := p.m.dtlsState.HandleDTLSPacket(raw, p.dtlsAddr(), srcAddr)
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -94,12 +83,28 @@ func (p *port) handleDTLS(raw []byte, srcAddr string) {
 	}
 
 	p.m.certPairLock.Lock()
+	defer p.m.certPairLock.Unlock()
 	if certPair := p.m.dtlsState.GetCertPair(); certPair != nil && p.m.certPair == nil {
-		var err error
 		p.m.certPair = certPair
 
+		// https://tools.ietf.org/html/rfc5764#section-4.2
+		// The DTLS client encrypts with the client write key and the server
+		// with the server write key
+		localKey, remoteKey := certPair.ClientWriteKey, certPair.ServerWriteKey
+		if !p.m.isDTLSClient {
+			localKey, remoteKey = remoteKey, localKey
+		}
+
+		// Each key is the master key followed by the master salt, the
+		// profile decides where one ends
+		keyLen, _, err := srtp.KeyLen(certPair.Profile)
+		if err != nil {
+			fmt.Println(errors.Wrap(err, "Failed to build SRTP context, this is fatal"))
+			return
+		}
+
 		p.m.srtpInboundContextLock.Lock()
-		p.m.srtpInboundContext, err = srtp.CreateContext(p.m.certPair.ServerWriteKey[0:16], p.m.certPair.ServerWriteKey[16:], p.m.certPair.Profile)
+		p.m.srtpInboundContext, err = srtp.CreateContext(remoteKey[0:keyLen], remoteKey[keyLen:], certPair.Profile)
 		p.m.srtpInboundContextLock.Unlock()
 		if err != nil {
 			fmt.Println("Failed to build SRTP context, this is fatal")
@@ -107,16 +112,13 @@ func (p *port) handleDTLS(raw []byte, srcAddr string) {
 		}
 
 		p.m.srtpOutboundContextLock.Lock()
-		p.m.srtpOutboundContext, err = srtp.CreateContext(p.m.certPair.ClientWriteKey[0:16], p.m.certPair.ClientWriteKey[16:], p.m.certPair.Profile)
+		p.m.srtpOutboundContext, err = srtp.CreateContext(localKey[0:keyLen], localKey[keyLen:], certPair.Profile)
 		p.m.srtpOutboundContextLock.Unlock()
 		if err != nil {
 			fmt.Println("Failed to build SRTP context, this is fatal")
 			return
 		}
-
 	}
-	p.m.certPairLock.Unlock()
-
 }
 
 const receiveMTU = 8192
@@ -136,7 +138,7 @@ func (p *port) networkLoop() {
 			copy(bufferCopy, buffer[:n])
 
 			select {
-			case incomingPackets <- &incomingPacket{buffer: bufferCopy, srcAddr: srcAddr.(*net.UDPAddr)}:
+			case incomingPackets <- &incomingPacket{buffer: bufferCopy, srcAddr: srcAddr}:
 			default:
 			}
 		}
@@ -146,7 +148,7 @@ func (p *port) networkLoop() {
 		in, socketOpen := <-incomingPackets
 		if !socketOpen {
 			// incomingPackets channel has closed, this port is finished processing
-			dtls.RemoveListener(p.listeningAddr.String())
+			dtls.RemoveListener(p.dtlsAddr())
 			return
 		}
 
@@ -157,16 +159,18 @@ func (p *port) networkLoop() {
 
 		// https://tools.ietf.org/html/rfc5764#page-14
 		if 127 < in.buffer[0] && in.buffer[0] < 192 {
+			p.m.IceAgent.PacketReceived(p.listeningAddr, in.srcAddr, len(in.buffer))
 			p.handleSRTP(in.buffer)
 		} else if 19 < in.buffer[0] && in.buffer[0] < 64 {
+			p.m.IceAgent.PacketReceived(p.listeningAddr, in.srcAddr, len(in.buffer))
 			p.handleDTLS(in.buffer, in.srcAddr.String())
 		} else if in.buffer[0] < 2 {
 			p.m.IceAgent.HandleInbound(in.buffer, p.listeningAddr, in.srcAddr)
 		}
 
 		p.m.certPairLock.RLock()
-		if !p.m.isOffer && p.m.certPair == nil {
-			p.m.dtlsState.DoHandshake(p.listeningAddr.String(), in.srcAddr.String())
+		if p.m.isDTLSClient && p.m.certPair == nil {
+			p.m.dtlsState.DoHandshake(p.dtlsAddr(), in.srcAddr.String())
 		}
 		p.m.certPairLock.RUnlock()
 	}
@@ -20,7 +20,7 @@ func (p *port) sendRTP(packet *rtp.Packet, dst net.Addr) {
 		if err != nil {
 			fmt.Printf("Failed to marshal packet: %s \n", err.Error())
 		}
-		if _, err := p.conn.WriteTo(raw, nil, dst); err != nil {
+		if _, err := p.WriteTo(raw, nil, dst); err != nil {
 			fmt.Printf("Failed to send packet: %s \n", err.Error())
 		}
 	} else {
@@ -28,6 +28,7 @@ func (p *port) sendRTP(packet *rtp.Packet, dst net.Addr) {
 	}
 }
 
+// sendICE sends what the IceAgent sends, it counts it itself
 func (p *port) sendICE(buf []byte, dst net.Addr) {
 	if _, err := p.conn.WriteTo(buf, nil, dst); err != nil {
 		fmt.Printf("Failed to send packet: %s \n", err.Error())
@@ -35,7 +36,7 @@ func (p *port) sendICE(buf []byte, dst net.Addr) {
 }
 
 func (p *port) sendSCTP(buf []byte, dst fmt.Stringer) {
-	_, err := p.m.dtlsState.Send(buf, p.listeningAddr.String(), dst.String())
+	_, err := p.m.dtlsState.Send(buf, p.dtlsAddr(), dst.String())
 	if err != nil {
 		fmt.Println(err)
 	}
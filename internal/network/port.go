@@ -3,20 +3,33 @@ package network
 import (
 	"net"
 
-	"github.com/pions/pkg/stun"
 	"github.com/pions/webrtc/internal/dtls"
+	"github.com/pions/webrtc/pkg/stun"
 	"golang.org/x/net/ipv4"
 )
 
+// packetConn is what a port sends and receives packets on, a UDP socket or
+// the TCP connections of an ICE-TCP candidate
+type packetConn interface {
+	ReadFrom(b []byte) (int, *ipv4.ControlMessage, net.Addr, error)
+	WriteTo(b []byte, cm *ipv4.ControlMessage, dst net.Addr) (int, error)
+	Close() error
+}
+
 type port struct {
-	conn          *ipv4.PacketConn
+	conn          packetConn
 	listeningAddr *stun.TransportAddr
 
+	// network is "udp" or "tcp", the network of the addresses the port
+	// sends to
+	network string
+
 	m *Manager
 }
 
-func newPort(address string, m *Manager) (*port, error) {
-	listener, err := net.ListenPacket("udp4", address)
+// newPort binds a UDP port on ip, network is "udp4" or "udp6"
+func newPort(network string, ip net.IP, m *Manager) (*port, error) {
+	listener, err := net.ListenPacket(network, net.JoinHostPort(ip.String(), "0"))
 	if err != nil {
 		return nil, err
 	}
@@ -26,17 +39,48 @@ func newPort(address string, m *Manager) (*port, error) {
 		return nil, err
 	}
 
-	conn := ipv4.NewPacketConn(listener)
-	dtls.AddListener(addr.String(), conn)
+	return startPort(ipv4.NewPacketConn(listener), addr, "udp", m), nil
+}
 
+func startPort(conn packetConn, addr *stun.TransportAddr, network string, m *Manager) *port {
 	p := &port{
 		listeningAddr: addr,
 		conn:          conn,
+		network:       network,
 		m:             m,
 	}
+	dtls.AddListener(p.dtlsAddr(), p)
 
 	go p.networkLoop()
-	return p, nil
+	return p
+}
+
+// dtlsAddr is the local address DTLS knows the port by, a TCP port may
+// have the same address as a UDP one and the ports on a UDPMux all have
+// its address
+func (p *port) dtlsAddr() string {
+	if c, ok := p.conn.(*udpMuxConn); ok {
+		return c.dtlsAddr()
+	} else if p.network == "tcp" {
+		return "tcp/" + p.listeningAddr.String()
+	}
+	return p.listeningAddr.String()
+}
+
+// sends reports whether the port sends from local to addresses of remote's
+// network
+func (p *port) sends(local *stun.TransportAddr, remote net.Addr) bool {
+	return p.listeningAddr.Equal(local) && remote.Network() == p.network
+}
+
+// WriteTo sends b to dst and counts it in the stats of the IceAgent, DTLS
+// sends its records with it
+func (p *port) WriteTo(b []byte, cm *ipv4.ControlMessage, dst net.Addr) (int, error) {
+	n, err := p.conn.WriteTo(b, cm, dst)
+	if err == nil {
+		p.m.IceAgent.PacketSent(p.listeningAddr, dst, n)
+	}
+	return n, err
 }
 
 func (p *port) close() error {
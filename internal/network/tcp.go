@@ -0,0 +1,252 @@
+package network
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pions/webrtc/pkg/ice"
+	"github.com/pions/webrtc/pkg/stun"
+	"github.com/pkg/errors"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	// Each packet sent over TCP is preceded by its length
+	// https://tools.ietf.org/html/rfc4571#section-2
+	tcpFrameHeaderLength = 2
+	tcpMaxFrameLength    = 1<<16 - 1
+
+	tcpDialTimeout = 5 * time.Second
+
+	// Active candidates are signaled with the discard port, the port they
+	// connect from isn't known beforehand
+	// https://tools.ietf.org/html/rfc6544#section-4.5
+	tcpActivePort = 9
+)
+
+var errTCPConnClosed = errors.New("tcp packet conn closed")
+
+// tcpConn is a connection of a tcpPacketConn, the packets sent while it is
+// being opened are queued
+type tcpConn struct {
+	conn    net.Conn
+	pending [][]byte
+}
+
+// tcpPacketConn carries the packets of an ICE-TCP candidate over the TCP
+// connections to each remote candidate. A passive candidate accepts the
+// connections, an active one opens them when a packet is first sent to a
+// remote address.
+type tcpPacketConn struct {
+	listener net.Listener
+	dialer   *net.Dialer
+
+	// network is "tcp4" or "tcp6", the network the dialer connects on
+	network string
+
+	lock  sync.Mutex
+	conns map[string]*tcpConn
+
+	incoming  chan *incomingPacket
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newTCPPacketConn(network string, listener net.Listener, dialer *net.Dialer) *tcpPacketConn {
+	t := &tcpPacketConn{
+		listener: listener,
+		dialer:   dialer,
+		network:  network,
+		conns:    make(map[string]*tcpConn),
+		incoming: make(chan *incomingPacket, 15),
+		closed:   make(chan struct{}),
+	}
+	if listener != nil {
+		go t.acceptLoop()
+	}
+	return t
+}
+
+// newTCPPort binds an ICE-TCP candidate of the given type on ip, network is
+// "tcp4" or "tcp6"
+func newTCPPort(network string, ip net.IP, tcpType ice.TCPType, m *Manager) (*port, error) {
+	switch tcpType {
+	case ice.TCPTypePassive:
+		listener, err := net.Listen(network, net.JoinHostPort(ip.String(), "0"))
+		if err != nil {
+			return nil, err
+		}
+		addr, err := stun.NewTransportAddr(listener.Addr())
+		if err != nil {
+			return nil, err
+		}
+		return startPort(newTCPPacketConn(network, listener, nil), addr, "tcp", m), nil
+	case ice.TCPTypeActive:
+		dialer := &net.Dialer{
+			LocalAddr: &net.TCPAddr{IP: ip},
+			Timeout:   tcpDialTimeout,
+		}
+		addr := &stun.TransportAddr{IP: ip, Port: tcpActivePort}
+		return startPort(newTCPPacketConn(network, nil, dialer), addr, "tcp", m), nil
+	default:
+		return nil, errors.Errorf("TCP candidates of type %s are not supported", tcpType)
+	}
+}
+
+func (t *tcpPacketConn) acceptLoop() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		t.addConn(conn)
+	}
+}
+
+// addConn starts reading an accepted connection. When both candidates
+// opened a connection to each other at the same time the first one is
+// kept.
+func (t *tcpPacketConn) addConn(conn net.Conn) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := conn.RemoteAddr().String()
+	if _, ok := t.conns[key]; ok {
+		_ = conn.Close()
+		return
+	}
+	t.conns[key] = &tcpConn{conn: conn}
+	go t.readLoop(conn)
+}
+
+func (t *tcpPacketConn) dial(dst string) {
+	conn, err := t.dialer.Dial(t.network, dst)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	c, ok := t.conns[dst]
+	if !ok || c.conn != nil {
+		// Closed, or the remote candidate opened a connection in the
+		// meantime
+		if err == nil {
+			_ = conn.Close()
+		}
+		return
+	}
+	if err != nil {
+		delete(t.conns, dst)
+		return
+	}
+
+	c.conn = conn
+	for _, frame := range c.pending {
+		if _, err := conn.Write(frame); err != nil {
+			break
+		}
+	}
+	c.pending = nil
+	go t.readLoop(conn)
+}
+
+func (t *tcpPacketConn) readLoop(conn net.Conn) {
+	defer t.removeConn(conn)
+
+	header := make([]byte, tcpFrameHeaderLength)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		buffer := make([]byte, binary.BigEndian.Uint16(header))
+		if _, err := io.ReadFull(conn, buffer); err != nil {
+			return
+		}
+
+		select {
+		case t.incoming <- &incomingPacket{buffer: buffer, srcAddr: conn.RemoteAddr()}:
+		case <-t.closed:
+			return
+		}
+	}
+}
+
+func (t *tcpPacketConn) removeConn(conn net.Conn) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := conn.RemoteAddr().String()
+	if c, ok := t.conns[key]; ok && c.conn == conn {
+		delete(t.conns, key)
+	}
+	_ = conn.Close()
+}
+
+// ReadFrom returns the next packet received on any of the connections
+func (t *tcpPacketConn) ReadFrom(b []byte) (int, *ipv4.ControlMessage, net.Addr, error) {
+	select {
+	case in := <-t.incoming:
+		return copy(b, in.buffer), nil, in.srcAddr, nil
+	case <-t.closed:
+		return 0, nil, nil, errTCPConnClosed
+	}
+}
+
+// WriteTo sends a packet on the connection to dst, an active candidate
+// opens it first
+func (t *tcpPacketConn) WriteTo(b []byte, cm *ipv4.ControlMessage, dst net.Addr) (int, error) {
+	if len(b) > tcpMaxFrameLength {
+		return 0, errors.Errorf("packet of %d bytes is too large to be framed", len(b))
+	}
+	frame := make([]byte, tcpFrameHeaderLength+len(b))
+	binary.BigEndian.PutUint16(frame, uint16(len(b)))
+	copy(frame[tcpFrameHeaderLength:], b)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	select {
+	case <-t.closed:
+		return 0, errTCPConnClosed
+	default:
+	}
+
+	key := dst.String()
+	c, ok := t.conns[key]
+	switch {
+	case ok && c.conn != nil:
+		if _, err := c.conn.Write(frame); err != nil {
+			return 0, err
+		}
+	case ok:
+		c.pending = append(c.pending, frame)
+	case t.dialer != nil:
+		t.conns[key] = &tcpConn{pending: [][]byte{frame}}
+		go t.dial(key)
+	default:
+		return 0, errors.Errorf("no TCP connection from %s", key)
+	}
+	return len(b), nil
+}
+
+// Close closes the listener and every connection
+func (t *tcpPacketConn) Close() (err error) {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		if t.listener != nil {
+			err = t.listener.Close()
+		}
+
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		for key, c := range t.conns {
+			if c.conn != nil {
+				_ = c.conn.Close()
+			}
+			delete(t.conns, key)
+		}
+	})
+	return err
+}
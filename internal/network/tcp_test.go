@@ -0,0 +1,52 @@
+package network
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTCPPacketConn(t *testing.T) {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	passive := newTCPPacketConn("tcp4", listener, nil)
+	defer func() {
+		assert.NoError(t, passive.Close())
+	}()
+	active := newTCPPacketConn("tcp4", nil, &net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1")}})
+	defer func() {
+		assert.NoError(t, active.Close())
+	}()
+
+	// A passive candidate doesn't open connections
+	_, err = passive.WriteTo([]byte{1}, nil, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9})
+	assert.Error(t, err)
+
+	// The packets sent while connecting are sent once connected, each
+	// one is received on its own
+	for _, packet := range [][]byte{{0, 1}, {2, 3, 4}, {}} {
+		n, err := active.WriteTo(packet, nil, listener.Addr())
+		assert.NoError(t, err)
+		assert.Equal(t, len(packet), n)
+	}
+
+	buffer := make([]byte, receiveMTU)
+	var src net.Addr
+	for _, expected := range [][]byte{{0, 1}, {2, 3, 4}, {}} {
+		var n int
+		n, _, src, err = passive.ReadFrom(buffer)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, buffer[:n])
+	}
+
+	// The passive candidate answers on the accepted connection
+	_, err = passive.WriteTo([]byte{5}, nil, src)
+	assert.NoError(t, err)
+	n, _, from, err := active.ReadFrom(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{5}, buffer[:n])
+	assert.Equal(t, listener.Addr().String(), from.String())
+}
@@ -0,0 +1,255 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pions/webrtc/pkg/stun"
+	"github.com/pkg/errors"
+	"golang.org/x/net/ipv4"
+)
+
+// udpMuxQueueLength is how many packets a connection of a UDPMux holds, the
+// ones it receives once it is full are dropped
+const udpMuxQueueLength = 128
+
+var errUDPMuxConnClosed = errors.New("udp mux conn closed")
+
+// UDPMux shares one UDP socket between the Managers of many PeerConnections,
+// for servers that run more of them than they can open ports for. A packet
+// is for the Manager whose IceAgent has the ufrag a Binding request is sent
+// to, the others for the Manager the address they are sent from last sent
+// such a request to.
+type UDPMux struct {
+	conn          *ipv4.PacketConn
+	listeningAddr *stun.TransportAddr
+
+	lock   sync.Mutex
+	nextID int
+	ufrags map[string]*udpMuxConn
+	addrs  map[string]*udpMuxConn
+	closed bool
+}
+
+// NewUDPMux listens on address, it must have an IP as the candidates of the
+// Managers that share it are signaled with it
+func NewUDPMux(address string) (*UDPMux, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	} else if addr.IP == nil || addr.IP.IsUnspecified() {
+		return nil, errors.Errorf("UDP mux address %s has no IP", address)
+	}
+
+	network := "udp4"
+	if addr.IP.To4() == nil {
+		network = "udp6"
+	}
+	socket, err := net.ListenUDP(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	listeningAddr, err := stun.NewTransportAddr(socket.LocalAddr())
+	if err != nil {
+		_ = socket.Close()
+		return nil, err
+	}
+
+	u := &UDPMux{
+		conn:          ipv4.NewPacketConn(socket),
+		listeningAddr: listeningAddr,
+		ufrags:        make(map[string]*udpMuxConn),
+		addrs:         make(map[string]*udpMuxConn),
+	}
+	go u.readLoop()
+	return u, nil
+}
+
+// LocalAddr returns the address the mux listens on
+func (u *UDPMux) LocalAddr() *stun.TransportAddr {
+	return u.listeningAddr
+}
+
+// Close closes the socket, and the connections of the Managers with it
+func (u *UDPMux) Close() error {
+	return u.conn.Close()
+}
+
+func (u *UDPMux) readLoop() {
+	buffer := make([]byte, receiveMTU)
+	for {
+		n, _, srcAddr, err := u.conn.ReadFrom(buffer)
+		if err != nil {
+			break
+		}
+
+		c := u.route(buffer[:n], srcAddr)
+		if c == nil {
+			continue
+		}
+		bufferCopy := make([]byte, n)
+		copy(bufferCopy, buffer[:n])
+
+		select {
+		case c.incoming <- &incomingPacket{buffer: bufferCopy, srcAddr: srcAddr}:
+		default:
+		}
+	}
+
+	u.lock.Lock()
+	u.closed = true
+	conns := u.ufrags
+	u.ufrags = make(map[string]*udpMuxConn)
+	u.addrs = make(map[string]*udpMuxConn)
+	u.lock.Unlock()
+
+	for _, c := range conns {
+		c.close()
+	}
+}
+
+// route returns the connection a packet from src is for, nil when it is for
+// none of them
+func (u *UDPMux) route(b []byte, src net.Addr) *udpMuxConn {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if c, ok := u.ufrags[requestUfrag(b)]; ok {
+		u.addrs[src.String()] = c
+		return c
+	}
+	return u.addrs[src.String()]
+}
+
+// requestUfrag returns the ufrag the USERNAME of a Binding request is for,
+// the one of the agent it is sent to. It is empty for the other packets.
+// https://tools.ietf.org/html/rfc8445#section-7.2.2
+func requestUfrag(b []byte) string {
+	if len(b) == 0 || b[0] >= 2 {
+		return ""
+	}
+	m, err := stun.NewMessage(b)
+	if err != nil || m.Class != stun.ClassRequest || m.Method != stun.MethodBinding {
+		return ""
+	}
+	attr, ok := m.GetOneAttribute(stun.AttrUsername)
+	if !ok {
+		return ""
+	}
+	var username stun.Username
+	if err := username.Unpack(m, attr); err != nil {
+		return ""
+	}
+	return strings.SplitN(username.Username, ":", 2)[0]
+}
+
+// newConn adds a connection for the agent with ufrag
+func (u *UDPMux) newConn(ufrag string) (*udpMuxConn, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.closed {
+		return nil, errUDPMuxConnClosed
+	} else if _, ok := u.ufrags[ufrag]; ok {
+		return nil, errors.Errorf("ufrag %s is already in use on the UDP mux", ufrag)
+	}
+
+	u.nextID++
+	c := &udpMuxConn{
+		mux:      u,
+		id:       u.nextID,
+		ufrag:    ufrag,
+		incoming: make(chan *incomingPacket, udpMuxQueueLength),
+		closed:   make(chan struct{}),
+	}
+	u.ufrags[ufrag] = c
+	return c, nil
+}
+
+// removeConn stops routing packets to c
+func (u *UDPMux) removeConn(c *udpMuxConn) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.ufrags[c.ufrag] == c {
+		delete(u.ufrags, c.ufrag)
+	}
+	for addr, addrConn := range u.addrs {
+		if addrConn == c {
+			delete(u.addrs, addr)
+		}
+	}
+}
+
+// udpMuxConn is the share of a UDPMux of one Manager
+type udpMuxConn struct {
+	mux *UDPMux
+
+	// id tells apart the connections of the mux, they all have its address
+	id int
+
+	// ufrag is guarded by the lock of the mux
+	ufrag string
+
+	incoming  chan *incomingPacket
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// setUfrag routes the Binding requests sent to ufrag to the connection in
+// place of the ones sent to its former ufrag, after an ICE restart
+func (c *udpMuxConn) setUfrag(ufrag string) {
+	c.mux.lock.Lock()
+	defer c.mux.lock.Unlock()
+
+	if c.mux.ufrags[c.ufrag] == c {
+		delete(c.mux.ufrags, c.ufrag)
+	}
+	c.ufrag = ufrag
+	select {
+	case <-c.closed:
+	default:
+		c.mux.ufrags[ufrag] = c
+	}
+}
+
+// dtlsAddr is the local address DTLS knows the connection by, unique
+// among the connections of the mux
+func (c *udpMuxConn) dtlsAddr() string {
+	return fmt.Sprintf("mux/%d/%s", c.id, c.mux.listeningAddr.String())
+}
+
+// ReadFrom returns the next packet routed to the connection
+func (c *udpMuxConn) ReadFrom(b []byte) (int, *ipv4.ControlMessage, net.Addr, error) {
+	select {
+	case in := <-c.incoming:
+		return copy(b, in.buffer), nil, in.srcAddr, nil
+	case <-c.closed:
+		return 0, nil, nil, errUDPMuxConnClosed
+	}
+}
+
+// WriteTo sends a packet from the socket of the mux
+func (c *udpMuxConn) WriteTo(b []byte, cm *ipv4.ControlMessage, dst net.Addr) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, errUDPMuxConnClosed
+	default:
+	}
+	return c.mux.conn.WriteTo(b, cm, dst)
+}
+
+func (c *udpMuxConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+}
+
+// Close stops routing packets to the connection, the mux stays open
+func (c *udpMuxConn) Close() error {
+	c.close()
+	c.mux.removeConn(c)
+	return nil
+}
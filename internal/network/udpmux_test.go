@@ -0,0 +1,99 @@
+package network
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pions/webrtc/pkg/stun"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUDPMux(t *testing.T) {
+	_, err := NewUDPMux(":0")
+	assert.Error(t, err)
+
+	mux, err := NewUDPMux("127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, mux.Close())
+	}()
+	a, err := mux.newConn("ufragA")
+	assert.NoError(t, err)
+	b, err := mux.newConn("ufragB")
+	assert.NoError(t, err)
+	_, err = mux.newConn("ufragA")
+	assert.Error(t, err)
+
+	peer, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, peer.Close())
+	}()
+	muxAddr := &net.UDPAddr{IP: mux.LocalAddr().IP, Port: mux.LocalAddr().Port}
+
+	request := func(username string) []byte {
+		transactionID, err := stun.GenerateTransactionID()
+		assert.NoError(t, err)
+		m, err := stun.Build(stun.ClassRequest, stun.MethodBinding, transactionID,
+			&stun.Username{Username: username},
+			&stun.Fingerprint{},
+		)
+		assert.NoError(t, err)
+		return m.Pack()
+	}
+	read := func(c *udpMuxConn) []byte {
+		select {
+		case in := <-c.incoming:
+			return in.buffer
+		case <-time.After(time.Second):
+			return nil
+		}
+	}
+
+	// Packets of unknown addresses are dropped, until a Binding request
+	// tells which connection the address is for
+	_, err = peer.WriteTo([]byte{128}, muxAddr)
+	assert.NoError(t, err)
+	checkB := request("ufragB:peer")
+	_, err = peer.WriteTo(checkB, muxAddr)
+	assert.NoError(t, err)
+	_, err = peer.WriteTo([]byte{129}, muxAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, checkB, read(b))
+	assert.Equal(t, []byte{129}, read(b))
+	assert.Empty(t, a.incoming)
+
+	// Connections send from the port of the mux
+	_, err = a.WriteTo([]byte{130}, nil, peer.LocalAddr())
+	assert.NoError(t, err)
+	buffer := make([]byte, receiveMTU)
+	assert.NoError(t, peer.SetReadDeadline(time.Now().Add(time.Second)))
+	n, from, err := peer.ReadFrom(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{130}, buffer[:n])
+	assert.Equal(t, muxAddr.String(), from.String())
+
+	// After an ICE restart requests are for the new ufrag
+	a.setUfrag("ufragC")
+	checkA := request("ufragC:peer")
+	_, err = peer.WriteTo(request("ufragA:peer"), muxAddr)
+	assert.NoError(t, err)
+	_, err = peer.WriteTo(checkA, muxAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, checkA, read(a))
+	assert.NotEqual(t, a.dtlsAddr(), b.dtlsAddr())
+
+	// A closed connection isn't routed to anymore
+	assert.NoError(t, a.Close())
+	mux.lock.Lock()
+	assert.NotContains(t, mux.ufrags, "ufragC")
+	assert.Empty(t, mux.addrs)
+	mux.lock.Unlock()
+	_, err = a.WriteTo([]byte{131}, nil, peer.LocalAddr())
+	assert.Equal(t, errUDPMuxConnClosed, err)
+}
@@ -1,8 +1,28 @@
 package network
 
-import "net"
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+)
 
-func localInterfaces() (ips []string) {
+// newMDNSHostname returns a random .local name to signal in place of the
+// address of a host candidate, a version 4 UUID
+// https://tools.ietf.org/html/draft-ietf-rtcweb-mdns-ice-candidates#section-3.1.1
+func newMDNSHostname() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x.local", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// localInterfaces returns the IPv4 and IPv6 addresses of the interfaces that
+// are up, and that filter accepts the name of when it is set. Loopback and
+// link-local addresses are left out.
+func localInterfaces(filter func(name string) bool) (ips []net.IP) {
 	ifaces, err := net.Interfaces()
 	if err != nil {
 		return ips
@@ -15,6 +35,9 @@ func localInterfaces() (ips []string) {
 		if iface.Flags&net.FlagLoopback != 0 {
 			continue // loopback interface
 		}
+		if filter != nil && !filter(iface.Name) {
+			continue // filtered out
+		}
 		addrs, err := iface.Addrs()
 		if err != nil {
 			return ips
@@ -30,11 +53,13 @@ func localInterfaces() (ips []string) {
 			if ip == nil || ip.IsLoopback() {
 				continue
 			}
-			ip = ip.To4()
-			if ip == nil {
-				continue // not an ipv4 address
+			if ip.IsLinkLocalUnicast() {
+				continue // needs a zone to be used
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
 			}
-			ips = append(ips, ip.String())
+			ips = append(ips, ip)
 		}
 	}
 	return ips
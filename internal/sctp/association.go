@@ -2,7 +2,10 @@ package sctp
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"io"
+	"strings"
 	"sync"
 
 	"math"
@@ -19,7 +22,7 @@ type AssociationState uint8
 
 // AssociationState enums
 const (
-	Open AssociationState = iota + 1
+	Closed AssociationState = iota + 1
 	CookieEchoed
 	CookieWait
 	Established
@@ -31,8 +34,8 @@ const (
 
 func (a AssociationState) String() string {
 	switch a {
-	case Open:
-		return "Open"
+	case Closed:
+		return "Closed"
 	case CookieEchoed:
 		return "CookieEchoed"
 	case CookieWait:
@@ -52,6 +55,90 @@ func (a AssociationState) String() string {
 	}
 }
 
+const (
+	// defaultPort is used when connecting before any port is known, it is
+	// the default sctp-port of https://tools.ietf.org/html/draft-ietf-mmusic-sctp-sdp-26
+	defaultPort = 5000
+
+	// defaultHeartbeatInterval is HB.interval, the recommended value of
+	// https://tools.ietf.org/html/rfc4960#section-15
+	defaultHeartbeatInterval = 30 * time.Second
+
+	// defaultMaxMessageSize is the a=max-message-size assumed when the SDP
+	// has none, https://tools.ietf.org/html/draft-ietf-mmusic-sctp-sdp-26#section-6
+	defaultMaxMessageSize = 65536
+
+	// defaultReceiverWindowCredit is the receive buffer, it holds many
+	// messages of the largest size
+	defaultReceiverWindowCredit = 1024 * 1024
+
+	// maxPMTUProbes is MAX_PROBES of https://tools.ietf.org/html/rfc4821#section-7.3,
+	// how many times a probe is sent before its size is considered too large
+	maxPMTUProbes uint = 3
+)
+
+// pmtuProbeSizes are the SCTP packet sizes probed for in turn, the largest
+// still fits a 1500 byte Ethernet MTU once the DTLS, UDP and IP headers are
+// added
+var pmtuProbeSizes = []int{1280, 1340, 1400}
+
+// Retransmission timers
+const (
+	timerT1Init = iota
+	timerT1Cookie
+	timerT3RTX
+	timerT2Shutdown
+	timerReconfig
+	timerPMTUProbe
+)
+
+// ReliabilityType determines how hard the messages of a stream are
+// retransmitted, https://tools.ietf.org/html/rfc3758
+type ReliabilityType byte
+
+// ReliabilityType enums, the values are those of the DataChannel channel
+// types https://tools.ietf.org/html/draft-ietf-rtcweb-data-protocol-09#section-5.1
+const (
+	// ReliabilityTypeReliable retransmits messages until they are acknowledged
+	ReliabilityTypeReliable ReliabilityType = 0
+
+	// ReliabilityTypeRexmit abandons messages after a number of
+	// retransmissions
+	ReliabilityTypeRexmit ReliabilityType = 1
+
+	// ReliabilityTypeTimed abandons messages after a number of milliseconds
+	ReliabilityTypeTimed ReliabilityType = 2
+)
+
+// streamReliability is how the messages of an outbound stream are sent
+type streamReliability struct {
+	unordered        bool
+	reliabilityType  ReliabilityType
+	reliabilityValue uint32
+}
+
+// AbortError is returned once the association has been aborted, either by
+// the peer or because the peer violated the protocol
+type AbortError struct {
+	// Remote is set when the peer sent the ABORT
+	Remote bool
+
+	// Causes describes the error causes the ABORT carried
+	Causes []string
+}
+
+// ErrMessageTooLarge is returned when sending a message larger than the
+// maximum message size
+var ErrMessageTooLarge = errors.New("Outbound message is larger than the maximum message size")
+
+func (e *AbortError) Error() string {
+	by := "locally"
+	if e.Remote {
+		by = "by peer"
+	}
+	return fmt.Sprintf("Association aborted %s: %s", by, strings.Join(e.Causes, ", "))
+}
+
 // Association represents an SCTP association
 // 13.2.  Parameters Necessary per Association (i.e., the TCB)
 // Peer        : Tag value to be sent in every packet and is received
@@ -77,8 +164,6 @@ type Association struct {
 	state               AssociationState
 	//peerTransportList
 	//primaryPath
-	//overallErrorCount
-	//overallErrorThreshold
 	//peerReceiverWindow (peerRwnd)
 	myNextTSN   uint32 // nextTSN
 	peerLastTSN uint32 // lastRcvdTSN
@@ -96,129 +181,1490 @@ type Association struct {
 	myMaxNumInboundStreams    uint16
 	myMaxNumOutboundStreams   uint16
 	myReceiverWindowCredit    uint32
+	maxMessageSize            uint32
 	myCookie                  *paramStateCookie
 	payloadQueue              *payloadQueue
 	inflightQueue             *payloadQueue
 	myMaxMTU                  uint16
-	firstSack                 bool
 	peerCumulativeTSNAckPoint uint32
 	reassemblyQueue           map[uint16]*reassemblyQueue
 	outboundStreams           map[uint16]uint16
 
+	// Handshake retransmission, the chunks are kept to be sent again
+	t1Init           *rtxTimer
+	t1Cookie         *rtxTimer
+	storedInit       *chunkInit
+	storedCookieEcho *chunkCookieEcho
+
+	// Retransmission of DATA and SHUTDOWN
+	t3RTX      *rtxTimer
+	t2Shutdown *rtxTimer
+	rtoMgr     *rtoManager
+
+	// Congestion control, https://tools.ietf.org/html/rfc4960#section-7.
+	// DATA waits in the pendingQueue until the congestion window allows it
+	// to be sent.
+	pendingQueue         []*chunkPayloadData
+	cwnd                 uint32
+	ssthresh             uint32
+	partialBytesAcked    uint32
+	peerRwnd             uint32
+	inFastRecovery       bool
+	fastRecoverExitPoint uint32
+
+	// Path verification, https://tools.ietf.org/html/rfc4960#section-8.
+	// overallErrorCount counts T3-rtx expiries and unanswered HEARTBEATs
+	// since the peer was last heard from.
+	heartbeatInterval     time.Duration
+	heartbeatTimer        *time.Timer
+	heartbeatGeneration   uint64
+	heartbeatSent         time.Time
+	heartbeatInfo         []byte
+	overallErrorCount     uint
+	overallErrorThreshold uint
+
+	// Partial reliability, https://tools.ietf.org/html/rfc3758. The messages
+	// of a stream that isn't fully reliable may be abandoned, the peer is
+	// then told to skip past them with a FORWARD TSN.
+	useForwardTSN           bool
+	streamReliability       map[uint16]streamReliability
+	advancedPeerTSNAckPoint uint32
+
+	// Stream reconfiguration, https://tools.ietf.org/html/rfc6525. One
+	// request to reset our outgoing streams is outstanding at a time, the
+	// streams reset after it wait in pendingStreamResets. The peer's
+	// requests wait in deferredStreamResets until all the DATA sent before
+	// them has arrived.
+	useReconfig          bool
+	myNextRSN            uint32
+	peerLastRSN          uint32
+	peerLastRSNResult    reconfigResult
+	tReconfig            *rtxTimer
+	storedReconfig       *paramOutgoingResetRequest
+	pendingStreamResets  []uint16
+	deferredStreamResets []*paramOutgoingResetRequest
+
+	// Packetization layer path MTU discovery, https://tools.ietf.org/html/rfc4821.
+	// myMaxMTU starts out conservative, once established HEARTBEATs padded
+	// up to each of the pmtuProbeSizes probe whether it can be raised.
+	tPMTUProbe     *rtxTimer
+	pmtuProbeIndex int
+	pmtuProbeInfo  []byte
+
+	// Zero checksum, https://tools.ietf.org/html/draft-ietf-tsvwg-sctp-zero-checksum-08.
+	// Over DTLS the CRC32c adds nothing, packets from the peer may carry a
+	// zero Checksum once we have announced it is acceptable, and ours do
+	// once the peer has.
+	zeroChecksumAcceptable bool
+	useZeroChecksum        bool
+
+	// Counters reported by Stats
+	nChunksSent          uint64
+	nChunksReceived      uint64
+	nDataRetransmissions uint64
+
+	// Streams opened locally or accepted, messages on any other stream go
+	// to the dataHandler if there is one or wait in acceptCh otherwise
+	streams       map[uint16]*Stream
+	acceptCh      chan *Stream
+	streamsClosed bool
+
+	// Set once the association has been aborted
+	abortErr *AbortError
+
+	// Bytes of user data handed to HandleOutbound on each stream that the
+	// peer hasn't acknowledged yet. The bufferedAmountLowHandler is called
+	// when the amount of a stream falls to its threshold.
+	bufferedAmount             map[uint16]uint64
+	bufferedAmountLowThreshold map[uint16]uint64
+	bufferedAmountLowHandler   func(uint16)
+
+	// Called once the handshake completes and whenever the peer resets one
+	// of its outgoing streams
+	establishedHandler func()
+	streamResetHandler func(uint16)
+
 	// TODO are these better as channels
 	// Put a blocking goroutine in port-receive (vs callbacks)
 	outboundHandler func([]byte)
 	dataHandler     func([]byte, uint16, PayloadProtocolIdentifier)
 }
 
-// HandleInbound parses incoming raw packets
-func (a *Association) HandleInbound(raw []byte) error {
-	p := &packet{}
-	if err := p.unmarshal(raw); err != nil {
-		return errors.Wrap(err, "Unable to parse SCTP packet")
+// HandleInbound parses incoming raw packets
+func (a *Association) HandleInbound(raw []byte) error {
+	if a.abortErr != nil {
+		return a.abortErr
+	}
+
+	p := &packet{zeroChecksum: a.zeroChecksumAcceptable}
+	if err := p.unmarshal(raw); err != nil {
+		return errors.Wrap(err, "Unable to parse SCTP packet")
+	}
+
+	if err := checkPacket(p); err != nil {
+		return errors.Wrap(err, "Failed validating packet")
+	}
+
+	// https://tools.ietf.org/html/rfc4960#section-8.5
+	// When receiving an SCTP packet, the endpoint MUST ensure that the
+	// value in the Verification Tag field of the received SCTP packet
+	// matches its own tag.  If the received Verification Tag value does not
+	// match the receiver's own tag value, the receiver shall silently
+	// discard the packet and shall not process it any further
+	if !a.isValidVerificationTag(p) {
+		return errors.Errorf("Verification tag %d does not match ours %d", p.verificationTag, a.myVerificationTag)
+	}
+
+	ackNeeded := false
+	for _, c := range p.chunks {
+		a.nChunksReceived++
+		if err := a.handleChunk(p, c); err != nil {
+			return errors.Wrap(err, "Failed handling chunk")
+		}
+		switch c.(type) {
+		case *chunkPayloadData, *chunkForwardTSN:
+			ackNeeded = true
+		}
+	}
+
+	// https://tools.ietf.org/html/rfc4960#section-6.2
+	// Acknowledge every packet carrying DATA with a single SACK, which also
+	// reports any duplicates and gaps among the DATA received. A FORWARD
+	// TSN is answered with a SACK as well.
+	var replies []chunk
+	if ackNeeded {
+		replies = append(replies, a.createSelectiveAck().chunks...)
+	}
+
+	// The DATA may have completed a stream reset of the peer, which we
+	// follow by resetting our side of the stream
+	replies = append(replies, a.createReconfigs()...)
+	return a.sendChunks(replies...)
+}
+
+func (a *Association) isValidVerificationTag(p *packet) bool {
+	if len(p.chunks) == 0 {
+		return true
+	}
+
+	switch c := p.chunks[0].(type) {
+	case *chunkInit:
+		// Checked by checkPacket, the tag of an INIT is always 0
+		return true
+	case *chunkAbort:
+		// https://tools.ietf.org/html/rfc4960#section-8.5.1
+		// B) The receiver of an ABORT MUST accept the packet if the
+		// Verification Tag field of the packet matches its own tag and the
+		// T bit is not set OR if it is set to its peer's tag and the T bit
+		// is set in the Chunk Flags.
+		if c.isReflected() {
+			return p.verificationTag == a.peerVerificationTag
+		}
+	}
+
+	return p.verificationTag == a.myVerificationTag
+}
+
+func (a *Association) packetizeOutbound(raw []byte, streamIdentifier uint16, payloadType PayloadProtocolIdentifier) ([]*chunkPayloadData, error) {
+	seqNum, ok := a.outboundStreams[streamIdentifier]
+
+	if !ok {
+		seqNum = 0
+	}
+
+	rel := a.streamReliability[streamIdentifier]
+	created := time.Now()
+
+	i := 0
+	remaining := len(raw)
+
+	var chunks []*chunkPayloadData
+	for remaining != 0 {
+		l := int(a.myMaxMTU)
+		if remaining < l {
+			l = remaining
+		}
+		chunks = append(chunks, &chunkPayloadData{
+			streamIdentifier:     streamIdentifier,
+			userData:             raw[i : i+l],
+			beginingFragment:     i == 0,
+			endingFragment:       remaining-l == 0,
+			immediateSack:        false,
+			unordered:            rel.unordered,
+			payloadType:          payloadType,
+			streamSequenceNumber: seqNum,
+			tsn:                  a.myNextTSN,
+			created:              created,
+		})
+		a.myNextTSN++
+		remaining -= l
+		i += l
+	}
+
+	// Unordered messages take no stream sequence number
+	if !rel.unordered {
+		a.outboundStreams[streamIdentifier] = seqNum + 1
+	}
+
+	return chunks, nil
+}
+
+// SetReliabilityParams sets how the messages sent on a stream from now on
+// are delivered. relVal is the number of retransmissions for
+// ReliabilityTypeRexmit and the lifetime in milliseconds for
+// ReliabilityTypeTimed. Messages are only abandoned if the peer supports
+// partial reliability, otherwise they are retransmitted until acknowledged.
+func (a *Association) SetReliabilityParams(streamIdentifier uint16, unordered bool, relType ReliabilityType, relVal uint32) {
+	a.streamReliability[streamIdentifier] = streamReliability{
+		unordered:        unordered,
+		reliabilityType:  relType,
+		reliabilityValue: relVal,
+	}
+}
+
+// BufferedAmount returns how many bytes of the user data sent on a stream
+// the peer hasn't acknowledged yet
+func (a *Association) BufferedAmount(streamIdentifier uint16) uint64 {
+	return a.bufferedAmount[streamIdentifier]
+}
+
+// SetBufferedAmountLowThreshold sets the BufferedAmount of a stream at or
+// below which the handler set with SetBufferedAmountLowHandler is called
+func (a *Association) SetBufferedAmountLowThreshold(streamIdentifier uint16, threshold uint64) {
+	a.bufferedAmountLowThreshold[streamIdentifier] = threshold
+}
+
+// SetBufferedAmountLowHandler sets the handler called with the stream
+// identifier when the BufferedAmount of a stream falls from above its
+// threshold to at or below it. It is called with the Association locked.
+func (a *Association) SetBufferedAmountLowHandler(handler func(streamIdentifier uint16)) {
+	a.bufferedAmountLowHandler = handler
+}
+
+// SetEstablishedHandler sets the handler called when the association
+// becomes established. It is called with the Association locked.
+func (a *Association) SetEstablishedHandler(handler func()) {
+	a.establishedHandler = handler
+}
+
+// SetStreamResetHandler sets the handler called with the stream identifier
+// when the peer resets a stream, which closes it. It is called with the
+// Association locked.
+func (a *Association) SetStreamResetHandler(handler func(streamIdentifier uint16)) {
+	a.streamResetHandler = handler
+}
+
+// releaseBufferedAmount removes a chunk the peer is done with from the
+// BufferedAmount of its stream
+func (a *Association) releaseBufferedAmount(c *chunkPayloadData) {
+	streamIdentifier := c.streamIdentifier
+	before := a.bufferedAmount[streamIdentifier]
+
+	after := uint64(0)
+	if n := uint64(len(c.userData)); n < before {
+		after = before - n
+		a.bufferedAmount[streamIdentifier] = after
+	} else {
+		delete(a.bufferedAmount, streamIdentifier)
+	}
+
+	threshold := a.bufferedAmountLowThreshold[streamIdentifier]
+	if before > threshold && after <= threshold && a.bufferedAmountLowHandler != nil {
+		a.bufferedAmountLowHandler(streamIdentifier)
+	}
+}
+
+// SetMaxMessageSize sets the largest message HandleOutbound sends, usually
+// the a=max-message-size of the remote description. Zero means no limit.
+func (a *Association) SetMaxMessageSize(size uint32) {
+	a.maxMessageSize = size
+}
+
+// SetPorts sets the SCTP ports of the association, usually the a=sctp-port
+// of the local and remote descriptions. It has to be called before the
+// association is established.
+func (a *Association) SetPorts(sourcePort, destinationPort uint16) {
+	a.sourcePort = sourcePort
+	a.destinationPort = destinationPort
+}
+
+// HandleOutbound parses incoming raw packets
+func (a *Association) HandleOutbound(raw []byte, streamIdentifier uint16, payloadType PayloadProtocolIdentifier) error {
+	if a.abortErr != nil {
+		return a.abortErr
+	}
+
+	// https://tools.ietf.org/html/rfc4960#section-9.2
+	// Upon receipt of the SHUTDOWN primitive from its upper layer, the
+	// endpoint enters the SHUTDOWN-PENDING state and remains there until
+	// all outstanding data has been acknowledged by its peer.  The endpoint
+	// accepts no new data from its upper layer
+	switch a.state {
+	case ShutdownPending, ShutdownSent, ShutdownReceived, ShutdownAckSent:
+		return errors.Errorf("Unable to send data in state %s", a.state.String())
+	}
+
+	if a.isResettingStream(streamIdentifier) {
+		return errors.Errorf("Unable to send data on stream %d while it is reset", streamIdentifier)
+	}
+
+	if a.maxMessageSize != 0 && uint32(len(raw)) > a.maxMessageSize {
+		return errors.Wrapf(ErrMessageTooLarge, "Unable to send %d bytes, at most %d", len(raw), a.maxMessageSize)
+	}
+
+	chunks, err := a.packetizeOutbound(raw, streamIdentifier, payloadType)
+	if err != nil {
+		return errors.Wrap(err, "Unable to packetize outbound packet")
+	}
+
+	a.pendingQueue = append(a.pendingQueue, chunks...)
+	a.bufferedAmount[streamIdentifier] += uint64(len(raw))
+
+	if err := a.sendPendingData(); err != nil {
+		return errors.Wrap(err, "Unable to send outbound packet")
+	}
+	return nil
+}
+
+// sendPendingData sends the DATA chunks waiting in the pendingQueue, moving
+// them to the inflightQueue, as far as the congestion window and the peer's
+// receiver window allow
+func (a *Association) sendPendingData() error {
+	skipped := false
+	var chunks []chunk
+	for len(a.pendingQueue) > 0 {
+		c := a.pendingQueue[0]
+		dataLen := uint32(len(c.userData))
+
+		// A message that outlived its lifetime before it could be sent is
+		// never sent, its TSNs are skipped with a FORWARD TSN instead
+		if !c.abandoned && a.isAbandonable(c) {
+			a.abandon(c)
+		}
+		if c.abandoned {
+			a.pendingQueue = a.pendingQueue[1:]
+			a.inflightQueue.pushNoCheck(c)
+			skipped = true
+			continue
+		}
+		flightSize := a.flightSize()
+
+		// https://tools.ietf.org/html/rfc4960#section-6.1
+		// A) At any given time, the data sender MUST NOT transmit new data
+		// to any destination transport address if its peer's rwnd indicates
+		// that the peer has no buffer space (i.e., rwnd is 0; see Section
+		// 6.2.1).  However, regardless of the value of rwnd (including if it
+		// is 0), the data sender can always have one DATA chunk in flight to
+		// the receiver if allowed by cwnd.
+		//
+		// B) At any given time, the sender MUST NOT transmit new data to a
+		// given transport address if it has cwnd or more bytes of data
+		// outstanding to that transport address.
+		if flightSize >= a.cwnd || (flightSize > 0 && a.peerRwnd < dataLen) {
+			break
+		}
+
+		a.pendingQueue = a.pendingQueue[1:]
+		// TODO: FIX THIS HACK, inflightQueue uses PayloadQueue which is really meant for inbound SACK generation
+		a.inflightQueue.pushNoCheck(c)
+		if a.peerRwnd < dataLen {
+			a.peerRwnd = 0
+		} else {
+			a.peerRwnd -= dataLen
+		}
+
+		chunks = append(chunks, a.markSent(c))
+	}
+
+	if len(chunks) > 0 {
+		// All the DATA released at once is bundled
+		if err := a.sendChunks(chunks...); err != nil {
+			return err
+		}
+
+		// https://tools.ietf.org/html/rfc4960#section-6.3.2
+		// R1) Every time a DATA chunk is sent to any address (including a
+		// retransmission), if the T3-rtx timer of that address is not
+		// running, start it running so that it will expire after the RTO of
+		// that address.
+		a.t3RTX.start(a.rtoMgr.getRTO())
+	}
+
+	if skipped {
+		return a.advancePeerTSNAckPoint()
+	}
+	return nil
+}
+
+// flightSize returns the number of bytes of DATA sent but not yet
+// acknowledged
+func (a *Association) flightSize() uint32 {
+	var flightSize uint32
+	for _, c := range a.inflightQueue.orderedPackets {
+		if !c.acked && !c.abandoned {
+			flightSize += uint32(len(c.userData))
+		}
+	}
+	return flightSize
+}
+
+// markSent records when a DATA chunk went out for the RTT measurement, just
+// before it is sent
+func (a *Association) markSent(c *chunkPayloadData) chunk {
+	c.since = time.Now()
+	c.nSent++
+	if c.nSent > 1 {
+		a.nDataRetransmissions++
+	}
+	return c
+}
+
+// Connect starts the four-way handshake by sending an INIT to the peer,
+// the association is established once the peer's COOKIE ACK is received
+func (a *Association) Connect() error {
+	if a.state != Closed {
+		return errors.Errorf("Unable to connect in state %s", a.state.String())
+	}
+
+	if a.sourcePort == 0 {
+		a.sourcePort = defaultPort
+	}
+	if a.destinationPort == 0 {
+		a.destinationPort = defaultPort
+	}
+
+	init := &chunkInit{}
+	init.initialTSN = a.myNextTSN
+	init.numOutboundStreams = a.myMaxNumOutboundStreams
+	init.numInboundStreams = a.myMaxNumInboundStreams
+	init.initiateTag = a.myVerificationTag
+	init.advertisedReceiverWindowCredit = a.myReceiverWindowCredit
+	init.params = []param{
+		&paramForwardTSNSupported{},
+		&paramSupportedExtensions{ChunkTypes: []chunkType{RECONFIG, FORWARDTSN}},
+	}
+	if a.zeroChecksumAcceptable {
+		init.params = append(init.params, &paramZeroChecksumAcceptable{edmid: errorDetectionMethodDTLS})
+	}
+	a.storedInit = init
+
+	if err := a.sendInit(); err != nil {
+		return err
+	}
+
+	// https://tools.ietf.org/html/rfc4960#section-5.1 (A)
+	// After sending the INIT, "A" starts the T1-init timer and enters the
+	// COOKIE-WAIT state.
+	a.state = CookieWait
+	a.t1Init.start(rtoInitial)
+	return nil
+}
+
+func (a *Association) sendInit() error {
+	if a.storedInit == nil {
+		return errors.New("INIT chunk has not been created")
+	}
+
+	// A packet containing an INIT chunk MUST have a zero Verification Tag
+	return a.send(&packet{
+		verificationTag: 0,
+		sourcePort:      a.sourcePort,
+		destinationPort: a.destinationPort,
+		chunks:          []chunk{a.storedInit},
+	})
+}
+
+func (a *Association) sendCookieEcho() error {
+	if a.storedCookieEcho == nil {
+		return errors.New("COOKIE ECHO chunk has not been created")
+	}
+
+	return a.send(&packet{
+		verificationTag: a.peerVerificationTag,
+		sourcePort:      a.sourcePort,
+		destinationPort: a.destinationPort,
+		chunks:          []chunk{a.storedCookieEcho},
+	})
+}
+
+// onRetransmissionTimeout resends the chunk of the handshake step the
+// expired timer guards
+func (a *Association) onRetransmissionTimeout(timerID int, nRtos uint) {
+	a.Lock()
+	defer a.Unlock()
+
+	var err error
+	switch {
+	case timerID == timerT1Init && a.state == CookieWait:
+		err = a.sendInit()
+	case timerID == timerT1Cookie && a.state == CookieEchoed:
+		err = a.sendCookieEcho()
+	case timerID == timerT3RTX && a.isEstablished():
+		// https://tools.ietf.org/html/rfc3758#section-3.5
+		// Before retransmitting, the outstanding DATA chunks whose lifetime
+		// or retransmissions are used up are abandoned, along with all the
+		// other fragments of their message
+		for _, c := range a.inflightQueue.orderedPackets {
+			if !c.acked && !c.abandoned && a.isAbandonable(c) {
+				a.abandon(c)
+			}
+		}
+
+		// https://tools.ietf.org/html/rfc4960#section-6.3.3
+		// E3) Determine how many of the earliest (i.e., lowest TSN)
+		// outstanding DATA chunks for the address for which the T3-rtx has
+		// expired will fit into a single packet, subject to the MTU
+		// constraint for the path corresponding to the destination transport
+		// address to which the retransmission is being sent
+		var retransmit []*chunkPayloadData
+		size := packetHeaderSize
+		for _, c := range a.inflightQueue.orderedPackets {
+			if c.acked || c.abandoned {
+				continue
+			}
+			chunkSize := chunkHeaderSize + payloadDataHeaderSize + len(c.userData)
+			chunkSize += getPadding(chunkSize)
+			if len(retransmit) > 0 && size+chunkSize > a.maxPacketSize() {
+				break
+			}
+			retransmit = append(retransmit, c)
+			size += chunkSize
+		}
+		if len(retransmit) > 0 {
+			// https://tools.ietf.org/html/rfc4960#section-7.2.3
+			// When the T3-rtx timer expires on an address, SCTP should
+			// perform slow start by:
+			//    ssthresh = max(cwnd/2, 4*MTU)
+			//    cwnd = 1*MTU
+			a.ssthresh = max32(a.cwnd/2, 4*a.mtu())
+			a.cwnd = a.mtu()
+			a.partialBytesAcked = 0
+			a.inFastRecovery = false
+
+			if a.onPathError() {
+				return
+			}
+
+			var chunks []chunk
+			for _, c := range retransmit {
+				chunks = append(chunks, a.markSent(c))
+			}
+			err = a.sendChunks(chunks...)
+		}
+
+		// Any time the T3-rtx timer expires the sender tries to advance the
+		// Advanced.Peer.Ack.Point, a FORWARD TSN that was lost is sent again
+		// this way
+		if err == nil {
+			err = a.advancePeerTSNAckPoint()
+		}
+
+	case timerID == timerReconfig && a.storedReconfig != nil:
+		err = a.sendReconfig(a.storedReconfig)
+	case timerID == timerPMTUProbe && a.isEstablished() && a.pmtuProbeInfo != nil:
+		err = a.sendPMTUProbe()
+	case timerID == timerT2Shutdown && a.state == ShutdownSent:
+		err = a.sendShutdown()
+	case timerID == timerT2Shutdown && a.state == ShutdownAckSent:
+		err = a.sendShutdownAck()
+	}
+
+	if err != nil {
+		fmt.Println(errors.Wrapf(err, "Failed to retransmit after %d timeouts", nRtos))
+	}
+}
+
+// onRetransmissionFailure gives up on the handshake, the shutdown or the
+// path MTU probe once the peer has not answered within the retransmission
+// limit
+func (a *Association) onRetransmissionFailure(timerID int) {
+	a.Lock()
+	defer a.Unlock()
+
+	switch {
+	case timerID == timerT1Init && a.state == CookieWait,
+		timerID == timerT1Cookie && a.state == CookieEchoed,
+		timerID == timerT2Shutdown && (a.state == ShutdownSent || a.state == ShutdownAckSent):
+		a.state = Closed
+	case timerID == timerPMTUProbe:
+		// The path doesn't carry packets of the probed size, the current
+		// one is kept and the search ends
+		a.pmtuProbeInfo = nil
+		a.pmtuProbeIndex = len(pmtuProbeSizes)
+	}
+}
+
+// isAbandonable reports whether a DATA chunk has used up the
+// retransmissions or the lifetime its stream allows
+func (a *Association) isAbandonable(c *chunkPayloadData) bool {
+	if !a.useForwardTSN {
+		return false
+	}
+
+	rel := a.streamReliability[c.streamIdentifier]
+	switch rel.reliabilityType {
+	case ReliabilityTypeRexmit:
+		return c.nSent > rel.reliabilityValue
+	case ReliabilityTypeTimed:
+		return time.Since(c.created) >= time.Duration(rel.reliabilityValue)*time.Millisecond
+	default:
+		return false
+	}
+}
+
+// abandon gives up on the message c is a fragment of, all its fragments
+// are abandoned along with it
+func (a *Association) abandon(c *chunkPayloadData) {
+	f := c
+	for {
+		f.abandoned = true
+		if f.beginingFragment {
+			break
+		}
+		prev, ok := a.outboundChunk(f.tsn - 1)
+		if !ok {
+			break
+		}
+		f = prev
+	}
+
+	for f = c; !f.endingFragment; {
+		next, ok := a.outboundChunk(f.tsn + 1)
+		if !ok {
+			break
+		}
+		next.abandoned = true
+		f = next
+	}
+}
+
+// outboundChunk returns the DATA chunk with the given TSN, whether it was
+// sent or is still waiting to be
+func (a *Association) outboundChunk(tsn uint32) (*chunkPayloadData, bool) {
+	if c, ok := a.inflightQueue.get(tsn); ok {
+		return c, true
+	}
+	for _, c := range a.pendingQueue {
+		if c.tsn == tsn {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// advancePeerTSNAckPoint moves the Advanced.Peer.Ack.Point over the
+// abandoned chunks following the Cumulative TSN Ack Point, and tells the
+// peer to skip them with a FORWARD TSN
+func (a *Association) advancePeerTSNAckPoint() error {
+	// https://tools.ietf.org/html/rfc3758#section-3.5
+	// C1) Let SackCumAck be the Cumulative TSN ACK carried in the received
+	// SACK chunk.  If SackCumAck > Advanced.Peer.Ack.Point, update
+	// Advanced.Peer.Ack.Point to be equal to SackCumAck.
+	if sna32LT(a.advancedPeerTSNAckPoint, a.peerCumulativeTSNAckPoint) {
+		a.advancedPeerTSNAckPoint = a.peerCumulativeTSNAckPoint
+	}
+
+	// C2) Try to further advance the "Advanced.Peer.Ack.Point" locally,
+	// that is, to move "Advanced.Peer.Ack.Point" up as long as the chunk
+	// next in the out-queue space is marked as "abandoned".
+	for {
+		c, ok := a.inflightQueue.get(a.advancedPeerTSNAckPoint + 1)
+		if !ok || !c.abandoned {
+			break
+		}
+		a.advancedPeerTSNAckPoint++
+	}
+
+	// C3) If, after step C1 and C2, the "Advanced.Peer.Ack.Point" is greater
+	// than the Cumulative TSN ACK carried in the received SACK, the data
+	// sender MUST send the data receiver a FORWARD TSN chunk containing the
+	// latest value of the "Advanced.Peer.Ack.Point".
+	if !sna32GT(a.advancedPeerTSNAckPoint, a.peerCumulativeTSNAckPoint) {
+		return nil
+	}
+
+	if err := a.send(a.createForwardTSN()); err != nil {
+		return err
+	}
+
+	// C5) If a FORWARD TSN is sent, the sender MUST assure that at least
+	// one T3-rtx timer is running.
+	a.t3RTX.start(a.rtoMgr.getRTO())
+	return nil
+}
+
+func (a *Association) createForwardTSN() *packet {
+	// The highest stream sequence number skipped on each stream, unordered
+	// messages have none
+	streams := map[uint16]uint16{}
+	for tsn := a.peerCumulativeTSNAckPoint + 1; sna32LTE(tsn, a.advancedPeerTSNAckPoint); tsn++ {
+		c, ok := a.inflightQueue.get(tsn)
+		if !ok || c.unordered {
+			continue
+		}
+		if ssn, ok := streams[c.streamIdentifier]; !ok || sna16LT(ssn, c.streamSequenceNumber) {
+			streams[c.streamIdentifier] = c.streamSequenceNumber
+		}
+	}
+
+	fwd := &chunkForwardTSN{newCumulativeTSN: a.advancedPeerTSNAckPoint}
+	for si, ssn := range streams {
+		fwd.streams = append(fwd.streams, chunkForwardTSNStream{identifier: si, sequence: ssn})
+	}
+
+	return &packet{
+		verificationTag: a.peerVerificationTag,
+		sourcePort:      a.sourcePort,
+		destinationPort: a.destinationPort,
+		chunks:          []chunk{fwd},
+	}
+}
+
+// ResetStream resets an outgoing stream, as is done when closing a
+// DataChannel. Its stream sequence numbers start over, and the peer resets
+// its incoming stream once all the data sent on it before has arrived.
+func (a *Association) ResetStream(streamIdentifier uint16) error {
+	if a.state != Established {
+		return errors.Errorf("Unable to reset stream in state %s", a.state.String())
+	}
+	if !a.useReconfig {
+		return errors.New("Unable to reset stream, peer does not support RE-CONFIG")
+	}
+
+	if !a.isResettingStream(streamIdentifier) {
+		a.pendingStreamResets = append(a.pendingStreamResets, streamIdentifier)
+	}
+	return a.sendReconfig()
+}
+
+// isResettingStream reports whether a reset of the outgoing stream is
+// pending or outstanding
+func (a *Association) isResettingStream(streamIdentifier uint16) bool {
+	for _, sID := range a.pendingStreamResets {
+		if sID == streamIdentifier {
+			return true
+		}
+	}
+	if a.storedReconfig != nil {
+		for _, sID := range a.storedReconfig.streamIdentifiers {
+			if sID == streamIdentifier {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nextStreamResetRequest turns the streams waiting to be reset into the
+// outstanding request, unless one is outstanding already
+func (a *Association) nextStreamResetRequest() *paramOutgoingResetRequest {
+	if a.storedReconfig != nil || len(a.pendingStreamResets) == 0 {
+		return nil
+	}
+
+	a.storedReconfig = &paramOutgoingResetRequest{
+		reconfigRequestSequenceNumber: a.myNextRSN,
+		senderLastTSN:                 a.myNextTSN - 1,
+		streamIdentifiers:             a.pendingStreamResets,
+	}
+	a.pendingStreamResets = nil
+	a.myNextRSN++
+	a.tReconfig.start(a.rtoMgr.getRTO())
+	return a.storedReconfig
+}
+
+// sendReconfig sends re-configuration parameters to the peer, along with a
+// request for the streams waiting to be reset if none is outstanding
+func (a *Association) sendReconfig(params ...param) error {
+	return a.sendChunks(a.createReconfigs(params...)...)
+}
+
+// createReconfigs puts re-configuration parameters into RE-CONFIG chunks,
+// along with a request for the streams waiting to be reset if none is
+// outstanding
+func (a *Association) createReconfigs(params ...param) []chunk {
+	if r := a.nextStreamResetRequest(); r != nil {
+		params = append(params, r)
+	}
+
+	// A RE-CONFIG chunk carries at most two parameters
+	var chunks []chunk
+	for len(params) > 0 {
+		c := &chunkReconfig{paramA: params[0]}
+		params = params[1:]
+		if len(params) > 0 {
+			c.paramB = params[0]
+			params = params[1:]
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks
+}
+
+func (a *Association) handleReconfig(c *chunkReconfig) error {
+	var params []param
+	for _, p := range []param{c.paramA, c.paramB} {
+		switch p := p.(type) {
+		case *paramReconfigResponse:
+			a.handleReconfigResponse(p)
+		case *paramOutgoingResetRequest:
+			params = append(params, a.handleOutgoingResetRequest(p))
+		case *paramIncomingResetRequest:
+			params = append(params, a.handleIncomingResetRequest(p))
+		}
+	}
+
+	return a.sendReconfig(params...)
+}
+
+func (a *Association) handleReconfigResponse(p *paramReconfigResponse) {
+	if a.storedReconfig == nil || p.reconfigResponseSequenceNumber != a.storedReconfig.reconfigRequestSequenceNumber {
+		return
+	}
+
+	switch p.result {
+	case reconfigResultInProgress:
+		// The peer still waits for DATA sent before the request, which is
+		// sent again once the timer expires
+		return
+	case reconfigResultSuccessPerformed, reconfigResultSuccessNOP:
+		// The streams start over, as new streams
+		for _, sID := range a.storedReconfig.streamIdentifiers {
+			delete(a.outboundStreams, sID)
+			delete(a.streamReliability, sID)
+		}
+	default:
+		fmt.Printf("Failed to reset streams %v: %s\n", a.storedReconfig.streamIdentifiers, p.result.String())
+	}
+
+	a.tReconfig.stop()
+	a.storedReconfig = nil
+}
+
+func (a *Association) handleOutgoingResetRequest(p *paramOutgoingResetRequest) *paramReconfigResponse {
+	response := &paramReconfigResponse{reconfigResponseSequenceNumber: p.reconfigRequestSequenceNumber}
+
+	// https://tools.ietf.org/html/rfc6525#section-5.2.1
+	// A request with the next sequence number is performed, the previous
+	// one is a retransmission and is answered again
+	switch p.reconfigRequestSequenceNumber {
+	case a.peerLastRSN + 1:
+		a.peerLastRSN++
+
+		// https://tools.ietf.org/html/rfc6525#section-5.2.2
+		// If the Sender's Last Assigned TSN is greater than the cumulative
+		// acknowledgment point, then the endpoint MUST enter "deferred reset
+		// processing".  In this mode, any data arriving with a TSN larger
+		// than the Sender's Last Assigned TSN for the affected stream(s) MUST
+		// be queued locally and held until the cumulative acknowledgment point
+		// reaches the Sender's Last Assigned TSN.
+		a.peerLastRSNResult = reconfigResultInProgress
+		a.deferredStreamResets = append(a.deferredStreamResets, p)
+		a.resetIncomingStreams()
+		response.result = a.peerLastRSNResult
+	case a.peerLastRSN:
+		response.result = a.peerLastRSNResult
+	default:
+		response.result = reconfigResultErrorBadSequenceNumber
+	}
+
+	return response
+}
+
+func (a *Association) handleIncomingResetRequest(p *paramIncomingResetRequest) param {
+	response := &paramReconfigResponse{reconfigResponseSequenceNumber: p.reconfigRequestSequenceNumber}
+
+	switch p.reconfigRequestSequenceNumber {
+	case a.peerLastRSN + 1:
+		a.peerLastRSN++
+	case a.peerLastRSN:
+		response.result = a.peerLastRSNResult
+		return response
+	default:
+		response.result = reconfigResultErrorBadSequenceNumber
+		return response
+	}
+
+	if a.storedReconfig != nil {
+		a.peerLastRSNResult = reconfigResultErrorRequestAlreadyInProgress
+		response.result = a.peerLastRSNResult
+		return response
+	}
+
+	// https://tools.ietf.org/html/rfc6525#section-5.2.3
+	// The peer's incoming streams are reset by resetting our outgoing ones,
+	// with a request that answers the peer's. No streams means all of them.
+	streams := p.streamIdentifiers
+	if len(streams) == 0 {
+		for sID := range a.outboundStreams {
+			streams = append(streams, sID)
+		}
+	}
+	a.pendingStreamResets = append(a.pendingStreamResets, streams...)
+
+	r := a.nextStreamResetRequest()
+	if r == nil {
+		a.peerLastRSNResult = reconfigResultSuccessNOP
+		response.result = a.peerLastRSNResult
+		return response
+	}
+	a.peerLastRSNResult = reconfigResultSuccessPerformed
+	r.reconfigResponseSequenceNumber = p.reconfigRequestSequenceNumber
+	return r
+}
+
+// resetIncomingStreams performs the peer's stream resets whose DATA has all
+// arrived
+func (a *Association) resetIncomingStreams() {
+	for len(a.deferredStreamResets) > 0 {
+		r := a.deferredStreamResets[0]
+		if sna32LT(a.peerLastTSN, r.senderLastTSN) {
+			return
+		}
+		a.deferredStreamResets = a.deferredStreamResets[1:]
+
+		streams := r.streamIdentifiers
+		if len(streams) == 0 {
+			for sID := range a.reassemblyQueue {
+				streams = append(streams, sID)
+			}
+		}
+
+		for _, sID := range streams {
+			delete(a.reassemblyQueue, sID)
+			if s, ok := a.streams[sID]; ok {
+				delete(a.streams, sID)
+				s.close()
+			}
+
+			// https://tools.ietf.org/html/draft-ietf-rtcweb-data-channel-13#section-6.7
+			// When one side wants to close a channel, it resets the
+			// corresponding outgoing stream.  When the peer sees that an
+			// incoming stream was reset, it also resets its corresponding
+			// outgoing stream.
+			if _, ok := a.outboundStreams[sID]; ok && !a.isResettingStream(sID) {
+				a.pendingStreamResets = append(a.pendingStreamResets, sID)
+			}
+
+			if a.streamResetHandler != nil {
+				a.streamResetHandler(sID)
+			}
+		}
+
+		if r.reconfigRequestSequenceNumber == a.peerLastRSN {
+			a.peerLastRSNResult = reconfigResultSuccessPerformed
+		}
+	}
+}
+
+// Shutdown gracefully closes the association. Data already queued is
+// still delivered, after which the peer is sent a SHUTDOWN.
+func (a *Association) Shutdown() error {
+	if a.state != Established {
+		return errors.Errorf("Unable to shutdown in state %s", a.state.String())
+	}
+
+	a.state = ShutdownPending
+	return a.checkShutdownProgress()
+}
+
+// checkShutdownProgress moves a pending shutdown on once all outstanding
+// data has been acknowledged
+func (a *Association) checkShutdownProgress() error {
+	if len(a.pendingQueue) != 0 || a.inflightQueue.size() != 0 {
+		return nil
+	}
+
+	switch a.state {
+	case ShutdownPending:
+		// https://tools.ietf.org/html/rfc4960#section-9.2
+		// Once all its outstanding data has been acknowledged, the endpoint
+		// shall send a SHUTDOWN chunk to its peer including in the
+		// Cumulative TSN Ack field the last sequential TSN it has received
+		// from the peer.  It shall then start the T2-shutdown timer and
+		// enter the SHUTDOWN-SENT state.
+		a.state = ShutdownSent
+		a.t2Shutdown.start(a.rtoMgr.getRTO())
+		return a.sendShutdown()
+	case ShutdownReceived:
+		// If there are no more outstanding DATA chunks, the SHUTDOWN
+		// receiver MUST send a SHUTDOWN ACK and start a T2-shutdown timer of
+		// its own, entering the SHUTDOWN-ACK-SENT state.
+		a.state = ShutdownAckSent
+		a.t2Shutdown.start(a.rtoMgr.getRTO())
+		return a.sendShutdownAck()
+	}
+
+	return nil
+}
+
+func (a *Association) sendShutdown() error {
+	return a.send(&packet{
+		verificationTag: a.peerVerificationTag,
+		sourcePort:      a.sourcePort,
+		destinationPort: a.destinationPort,
+		chunks:          []chunk{&chunkShutdown{cumulativeTSNAck: a.peerLastTSN}},
+	})
+}
+
+func (a *Association) sendShutdownAck() error {
+	return a.send(&packet{
+		verificationTag: a.peerVerificationTag,
+		sourcePort:      a.sourcePort,
+		destinationPort: a.destinationPort,
+		chunks:          []chunk{&chunkShutdownAck{}},
+	})
+}
+
+func (a *Association) handleShutdown(c *chunkShutdown) error {
+	switch a.state {
+	case Established, ShutdownPending:
+		// https://tools.ietf.org/html/rfc4960#section-9.2
+		// Upon reception of the SHUTDOWN, the peer endpoint shall enter the
+		// SHUTDOWN-RECEIVED state, stop accepting new data from its SCTP
+		// user, and verify, by checking the Cumulative TSN Ack field of the
+		// chunk, that all its outstanding DATA chunks have been received by
+		// the SHUTDOWN sender.
+		a.state = ShutdownReceived
+	case ShutdownSent:
+		// If an endpoint is in the SHUTDOWN-SENT state and receives a
+		// SHUTDOWN chunk from its peer, the endpoint shall respond
+		// immediately with a SHUTDOWN ACK to its peer, and move into the
+		// SHUTDOWN-ACK-SENT state restarting its T2-shutdown timer.
+		a.state = ShutdownAckSent
+		a.t2Shutdown.stop()
+		a.t2Shutdown.start(a.rtoMgr.getRTO())
+		return a.sendShutdownAck()
+	default:
+		return nil
+	}
+
+	// The Cumulative TSN Ack acknowledges DATA like a SACK without gaps
+	if sna32GT(c.cumulativeTSNAck, a.peerCumulativeTSNAckPoint) {
+		if _, err := a.handleSack(&chunkSelectiveAck{
+			cumulativeTSNAck:               c.cumulativeTSNAck,
+			advertisedReceiverWindowCredit: a.peerRwnd,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Whatever is still pending is sent before the SHUTDOWN ACK
+	if err := a.sendPendingData(); err != nil {
+		return err
+	}
+	return a.checkShutdownProgress()
+}
+
+func (a *Association) handleShutdownAck() error {
+	switch a.state {
+	case ShutdownSent, ShutdownAckSent:
+		// https://tools.ietf.org/html/rfc4960#section-9.2
+		// Upon the receipt of the SHUTDOWN ACK, the SHUTDOWN sender shall
+		// stop the T2-shutdown timer, send a SHUTDOWN COMPLETE chunk to its
+		// peer, and remove all record of the association.
+		a.t2Shutdown.stop()
+		a.state = Closed
+		return a.send(&packet{
+			verificationTag: a.peerVerificationTag,
+			sourcePort:      a.sourcePort,
+			destinationPort: a.destinationPort,
+			chunks:          []chunk{&chunkShutdownComplete{}},
+		})
+	}
+
+	return nil
+}
+
+func (a *Association) handleShutdownComplete() {
+	// Upon reception of the SHUTDOWN COMPLETE chunk, the endpoint will
+	// verify that it is in the SHUTDOWN-ACK-SENT state; if it is not, the
+	// chunk should be discarded.  If the endpoint is in the
+	// SHUTDOWN-ACK-SENT state, the endpoint should stop the T2-shutdown
+	// timer and remove all knowledge of the association
+	if a.state != ShutdownAckSent {
+		return
+	}
+
+	a.t2Shutdown.stop()
+	a.state = Closed
+}
+
+// abort tears the association down at once, dropping any data still
+// queued, and records why
+func (a *Association) abort(err *AbortError) {
+	a.stopTimers()
+	a.state = Closed
+	a.pendingQueue = nil
+	a.inflightQueue = &payloadQueue{}
+	a.abortErr = err
+	a.closeStreams()
+}
+
+// sendAbort tells the sender of p that the association is aborted
+func (a *Association) sendAbort(p *packet, causes ...errorCause) error {
+	abort := &chunkAbort{errorCauses: causes}
+	tag := a.peerVerificationTag
+
+	// https://tools.ietf.org/html/rfc4960#section-8.4
+	// If the peer's Verification Tag is not known yet, the receiver's tag
+	// is reflected and the T bit is set
+	if tag == 0 {
+		abort.flags = reflectedTagBitmask
+		tag = a.myVerificationTag
+	}
+
+	return a.send(&packet{
+		verificationTag: tag,
+		sourcePort:      p.destinationPort,
+		destinationPort: p.sourcePort,
+		chunks:          []chunk{abort},
+	})
+}
+
+func (a *Association) handleAbort(c *chunkAbort) error {
+	a.abort(&AbortError{Remote: true, Causes: describeErrorCauses(c.errorCauses)})
+	return a.abortErr
+}
+
+// handleError reports the error causes of an ERROR chunk. An Operation
+// Error is not fatal in itself, so the association carries on.
+func (a *Association) handleError(c *chunkError) {
+	for _, cause := range describeErrorCauses(c.errorCauses) {
+		fmt.Printf("SCTP peer reported error: %s\n", cause)
+	}
+}
+
+func describeErrorCauses(errorCauses []errorCause) []string {
+	causes := make([]string, 0, len(errorCauses))
+	for _, e := range errorCauses {
+		if s, ok := e.(fmt.Stringer); ok {
+			causes = append(causes, s.String())
+		} else {
+			causes = append(causes, e.errorCauseCode().String())
+		}
+	}
+	return causes
+}
+
+func (a *Association) stopTimers() {
+	a.t1Init.stop()
+	a.t1Cookie.stop()
+	a.t3RTX.stop()
+	a.t2Shutdown.stop()
+	a.tReconfig.stop()
+	a.tPMTUProbe.stop()
+	a.stopHeartbeat()
+}
+
+// scheduleHeartbeat (re)starts the heartbeat timer, it expires after the
+// RTO plus HB.interval
+func (a *Association) scheduleHeartbeat() {
+	a.stopHeartbeat()
+
+	generation := a.heartbeatGeneration
+	interval := a.heartbeatInterval + time.Duration(a.rtoMgr.getRTO()*float64(time.Millisecond))
+	a.heartbeatTimer = time.AfterFunc(interval, func() { a.onHeartbeatTimeout(generation) })
+}
+
+func (a *Association) stopHeartbeat() {
+	if a.heartbeatTimer != nil {
+		a.heartbeatTimer.Stop()
+		a.heartbeatTimer = nil
 	}
+	a.heartbeatGeneration++
+}
 
-	if err := checkPacket(p); err != nil {
-		return errors.Wrap(err, "Failed validating packet")
+func (a *Association) onHeartbeatTimeout(generation uint64) {
+	a.Lock()
+	defer a.Unlock()
+
+	// Stopped or rescheduled since
+	if generation != a.heartbeatGeneration || !a.isEstablished() {
+		return
 	}
 
-	for _, c := range p.chunks {
-		if err := a.handleChunk(p, c); err != nil {
-			return errors.Wrap(err, "Failed handling chunk")
+	// https://tools.ietf.org/html/rfc4960#section-8.3
+	// When the value of this counter reaches the protocol parameter
+	// 'Path.Max.Retrans', the endpoint should mark the corresponding
+	// destination address as inactive ... Each time a HEARTBEAT is sent
+	// and not acknowledged within one RTO, the error counter is
+	// incremented
+	if a.heartbeatInfo != nil && a.onPathError() {
+		return
+	}
+
+	// Only idle paths are probed, while DATA is outstanding the T3-rtx
+	// timer detects failures instead
+	if a.inflightQueue.size() == 0 {
+		if err := a.sendHeartbeat(); err != nil {
+			fmt.Println(errors.Wrap(err, "Failed to send HEARTBEAT"))
 		}
 	}
 
-	return nil
+	a.scheduleHeartbeat()
 }
 
-func (a *Association) packetizeOutbound(raw []byte, streamIdentifier uint16, payloadType PayloadProtocolIdentifier) ([]*chunkPayloadData, error) {
+func (a *Association) sendHeartbeat() error {
+	// The Heartbeat Information is opaque to the peer, it carries the send
+	// time so a stale or forged HEARTBEAT ACK can be told apart
+	a.heartbeatSent = time.Now()
+	a.heartbeatInfo = make([]byte, 8)
+	binary.BigEndian.PutUint64(a.heartbeatInfo, uint64(a.heartbeatSent.UnixNano()))
+
+	return a.send(&packet{
+		verificationTag: a.peerVerificationTag,
+		sourcePort:      a.sourcePort,
+		destinationPort: a.destinationPort,
+		chunks: []chunk{&chunkHeartbeat{
+			params: []param{&paramHeartbeatInfo{heartbeatInformation: a.heartbeatInfo}},
+		}},
+	})
+}
 
-	if len(raw) > math.MaxUint16 {
-		return nil, errors.Errorf("Outbound packet larger than maximum message size %v", math.MaxUint16)
+func (a *Association) handleHeartbeatAck(c *chunkHeartbeatAck) error {
+	hbi, ok := c.params[0].(*paramHeartbeatInfo)
+	if !ok {
+		return nil
+	}
+	if a.pmtuProbeInfo != nil && bytes.Equal(hbi.heartbeatInformation, a.pmtuProbeInfo) {
+		return a.handlePMTUProbeAck()
+	}
+	if a.heartbeatInfo == nil || !bytes.Equal(hbi.heartbeatInformation, a.heartbeatInfo) {
+		return nil
 	}
 
-	seqNum, ok := a.outboundStreams[streamIdentifier]
+	// https://tools.ietf.org/html/rfc4960#section-8.3
+	// Upon the receipt of the HEARTBEAT ACK, the sender of the HEARTBEAT
+	// should clear the error counter of the destination transport address
+	// to which the HEARTBEAT was sent, and mark the destination transport
+	// address as active if it is not so marked.  The endpoint may
+	// optionally report to the upper layer when an inactive destination
+	// address is marked as active due to the reception of the latest
+	// HEARTBEAT ACK.  The receiver of the HEARTBEAT ACK must also clear the
+	// association overall error count as well (as defined in Section 8.1).
+	//
+	// The receiver of the HEARTBEAT ACK should also perform an RTT
+	// measurement for that destination transport address using the time
+	// value carried in the HEARTBEAT ACK chunk.
+	a.rtoMgr.setNewRTT(float64(time.Since(a.heartbeatSent)) / float64(time.Millisecond))
+	a.heartbeatInfo = nil
+	a.overallErrorCount = 0
+	return nil
+}
 
-	if !ok {
-		seqNum = 0
+// startPMTUProbe probes whether the path carries packets of the next size
+// in pmtuProbeSizes, if any is left
+func (a *Association) startPMTUProbe() error {
+	if a.pmtuProbeIndex >= len(pmtuProbeSizes) {
+		return nil
 	}
 
-	i := uint16(0)
-	remaining := uint16(len(raw))
+	// The Heartbeat Information tells the acknowledgement of a probe apart
+	// from that of a regular HEARTBEAT
+	a.pmtuProbeInfo = make([]byte, 8)
+	copy(a.pmtuProbeInfo, "pmtu")
+	binary.BigEndian.PutUint32(a.pmtuProbeInfo[4:], uint32(pmtuProbeSizes[a.pmtuProbeIndex]))
 
-	var chunks []*chunkPayloadData
-	for remaining != 0 {
-		l := min(a.myMaxMTU, remaining)
-		chunks = append(chunks, &chunkPayloadData{
-			streamIdentifier:     streamIdentifier,
-			userData:             raw[i : i+l],
-			beginingFragment:     i == 0,
-			endingFragment:       remaining-l == 0,
-			immediateSack:        false,
-			payloadType:          payloadType,
-			streamSequenceNumber: seqNum,
-			tsn:                  a.myNextTSN,
-		})
-		a.myNextTSN++
-		remaining -= l
-		i += l
+	a.tPMTUProbe.start(a.rtoMgr.getRTO())
+	return a.sendPMTUProbe()
+}
+
+// sendPMTUProbe sends a HEARTBEAT padded with a PAD chunk up to the size
+// being probed
+func (a *Association) sendPMTUProbe() error {
+	heartbeatLength := chunkHeaderSize + paramHeaderLength + len(a.pmtuProbeInfo)
+	paddingLength := pmtuProbeSizes[a.pmtuProbeIndex] - packetHeaderSize - heartbeatLength - chunkHeaderSize
+
+	return a.send(&packet{
+		verificationTag: a.peerVerificationTag,
+		sourcePort:      a.sourcePort,
+		destinationPort: a.destinationPort,
+		chunks: []chunk{
+			&chunkHeartbeat{
+				params: []param{&paramHeartbeatInfo{heartbeatInformation: a.pmtuProbeInfo}},
+			},
+			&chunkPadding{paddingLength: paddingLength},
+		},
+	})
+}
+
+func (a *Association) handlePMTUProbeAck() error {
+	// https://tools.ietf.org/html/rfc4821#section-7.6.1
+	// A probe is considered successful when the acknowledgement for it
+	// arrives, the path MTU is then raised to the probe size. DATA is
+	// fragmented to fill packets of that size from now on.
+	size := pmtuProbeSizes[a.pmtuProbeIndex]
+	a.myMaxMTU = uint16(size - packetHeaderSize - chunkHeaderSize - payloadDataHeaderSize)
+
+	a.tPMTUProbe.stop()
+	a.pmtuProbeInfo = nil
+	a.pmtuProbeIndex++
+	return a.startPMTUProbe()
+}
+
+// onPathError counts a T3-rtx expiry or an unanswered HEARTBEAT, and
+// reports whether the peer is now considered unreachable
+func (a *Association) onPathError() bool {
+	a.overallErrorCount++
+
+	// https://tools.ietf.org/html/rfc4960#section-8.1
+	// If the value of this counter exceeds the limit indicated in the
+	// protocol parameter 'Association.Max.Retrans', the endpoint shall
+	// consider the peer endpoint unreachable and shall stop transmitting
+	// any more data to it (and thus the association enters the CLOSED
+	// state).
+	if a.overallErrorCount <= a.overallErrorThreshold {
+		return false
 	}
 
-	a.outboundStreams[streamIdentifier] = seqNum + 1
+	a.abort(&AbortError{Causes: []string{fmt.Sprintf("Peer unreachable after %d errors", a.overallErrorCount)}})
+	return true
+}
 
-	return chunks, nil
+// Close ends the SCTP Association and cleans up any state
+func (a *Association) Close() error {
+	a.Lock()
+	defer a.Unlock()
+
+	a.stopTimers()
+	a.closeStreams()
+	return nil
 }
 
-// HandleOutbound parses incoming raw packets
-func (a *Association) HandleOutbound(raw []byte, streamIdentifier uint16, payloadType PayloadProtocolIdentifier) error {
-	chunks, err := a.packetizeOutbound(raw, streamIdentifier, payloadType)
-	if err != nil {
-		return errors.Wrap(err, "Unable to packetize outbound packet")
+// AssociationStats is a snapshot of the state of an Association
+type AssociationStats struct {
+	ChunksSent          uint64
+	ChunksReceived      uint64
+	DataRetransmissions uint64 // DATA chunks sent again, by T3-rtx or fast retransmit
+
+	BytesInFlight uint32 // DATA sent but not yet acknowledged
+	Cwnd          uint32
+	Ssthresh      uint32
+	SRTT          time.Duration // 0 until the first RTT measurement
+	RTO           time.Duration
+	PeerRwnd      uint32 // The peer's receiver window, less BytesInFlight
+	MyRwnd        uint32 // The receiver window we advertise
+}
+
+// Stats returns the association's counters and congestion control state
+func (a *Association) Stats() AssociationStats {
+	a.Lock()
+	defer a.Unlock()
+
+	return AssociationStats{
+		ChunksSent:          a.nChunksSent,
+		ChunksReceived:      a.nChunksReceived,
+		DataRetransmissions: a.nDataRetransmissions,
+		BytesInFlight:       a.flightSize(),
+		Cwnd:                a.cwnd,
+		Ssthresh:            a.ssthresh,
+		SRTT:                time.Duration(a.rtoMgr.srtt * float64(time.Millisecond)),
+		RTO:                 time.Duration(a.rtoMgr.getRTO() * float64(time.Millisecond)),
+		PeerRwnd:            a.peerRwnd,
+		MyRwnd:              a.getMyReceiverWindowCredit(),
 	}
+}
 
-	for _, c := range chunks {
-		// TODO: FIX THIS HACK, inflightQueue uses PayloadQueue which is really meant for inbound SACK generation
-		a.inflightQueue.pushNoCheck(c)
+// EnableZeroChecksum announces during the handshake that packets with a
+// zero Checksum are accepted, as the association runs over DTLS. It has
+// to be called before the association is established.
+func (a *Association) EnableZeroChecksum() {
+	a.zeroChecksumAcceptable = true
+}
 
-		p := &packet{
-			sourcePort:      a.sourcePort,
-			destinationPort: a.destinationPort,
-			verificationTag: a.peerVerificationTag,
-			chunks:          []chunk{c}}
-		if err := a.send(p); err != nil {
-			return errors.Wrap(err, "Unable to send outbound packet")
-		}
+// OpenStream opens the outgoing stream streamIdentifier, Write sends
+// messages on it with defaultPayloadType
+func (a *Association) OpenStream(streamIdentifier uint16, defaultPayloadType PayloadProtocolIdentifier) (*Stream, error) {
+	a.Lock()
+	defer a.Unlock()
 
+	if a.streamsClosed {
+		return nil, errors.New("Unable to open stream, association is closed")
 	}
-	return nil
+	if _, ok := a.streams[streamIdentifier]; ok {
+		return nil, errors.Errorf("Stream %d is already open", streamIdentifier)
+	}
+
+	s := newStream(a, streamIdentifier, defaultPayloadType)
+	a.streams[streamIdentifier] = s
+	return s, nil
 }
 
-// Close ends the SCTP Association and cleans up any state
-func (a *Association) Close() error {
-	return nil
+// AcceptStream blocks until the peer sends the first message on a stream
+// that isn't open yet, and returns that stream. Once the association is
+// closed io.EOF is returned.
+func (a *Association) AcceptStream() (*Stream, error) {
+	s, ok := <-a.acceptCh
+	if !ok {
+		return nil, io.EOF
+	}
+	return s, nil
+}
+
+// closeStreams closes all the streams and stops accepting new ones
+func (a *Association) closeStreams() {
+	if a.streamsClosed {
+		return
+	}
+	a.streamsClosed = true
+
+	for sID, s := range a.streams {
+		delete(a.streams, sID)
+		s.close()
+	}
+	if a.acceptCh != nil {
+		close(a.acceptCh)
+	}
 }
 
-// NewAssocation creates a new Association and the state needed to manage it
+// NewAssocation creates a new Association and the state needed to manage it.
+// Without a dataHandler messages are read from Streams instead.
 func NewAssocation(outboundHandler func([]byte), dataHandler func([]byte, uint16, PayloadProtocolIdentifier)) *Association {
 	rs := rand.NewSource(time.Now().UnixNano())
 	r := rand.New(rs)
 
-	return &Association{
-		myMaxNumOutboundStreams: math.MaxUint16,
-		myMaxNumInboundStreams:  math.MaxUint16,
-		myReceiverWindowCredit:  10 * 1500, // 10 Max MTU packets buffer
-		payloadQueue:            &payloadQueue{},
-		inflightQueue:           &payloadQueue{},
-		myMaxMTU:                1200,
-		firstSack:               true,
-		reassemblyQueue:         make(map[uint16]*reassemblyQueue),
-		outboundStreams:         make(map[uint16]uint16),
-		myVerificationTag:       r.Uint32(),
-		myNextTSN:               r.Uint32(),
-		outboundHandler:         outboundHandler,
-		dataHandler:             dataHandler,
-		state:                   Open,
+	a := &Association{
+		myMaxNumOutboundStreams:    math.MaxUint16,
+		myMaxNumInboundStreams:     math.MaxUint16,
+		myReceiverWindowCredit:     defaultReceiverWindowCredit,
+		maxMessageSize:             defaultMaxMessageSize,
+		payloadQueue:               &payloadQueue{},
+		inflightQueue:              &payloadQueue{},
+		myMaxMTU:                   1200,
+		reassemblyQueue:            make(map[uint16]*reassemblyQueue),
+		outboundStreams:            make(map[uint16]uint16),
+		streamReliability:          make(map[uint16]streamReliability),
+		bufferedAmount:             make(map[uint16]uint64),
+		bufferedAmountLowThreshold: make(map[uint16]uint64),
+		streams:                    make(map[uint16]*Stream),
+		acceptCh:                   make(chan *Stream, acceptQueueSize),
+		myVerificationTag:          r.Uint32(),
+		myNextTSN:                  r.Uint32(),
+		outboundHandler:            outboundHandler,
+		dataHandler:                dataHandler,
+		state:                      Closed,
 	}
+	// The ack point starts just before our first TSN, so the first SACK
+	// acknowledging anything is seen as advancing it
+	a.peerCumulativeTSNAckPoint = a.myNextTSN - 1
+	a.advancedPeerTSNAckPoint = a.peerCumulativeTSNAckPoint
+
+	// https://tools.ietf.org/html/rfc6525#section-5.1.1
+	// The request sequence numbers start at the initial TSN
+	a.myNextRSN = a.myNextTSN
+
+	a.t1Init = newRTXTimer(timerT1Init, a, maxInitRetrans)
+	a.t1Cookie = newRTXTimer(timerT1Cookie, a, maxInitRetrans)
+	a.t3RTX = newRTXTimer(timerT3RTX, a, 0)
+	a.t2Shutdown = newRTXTimer(timerT2Shutdown, a, maxAssocRetrans)
+	a.tReconfig = newRTXTimer(timerReconfig, a, 0)
+	a.tPMTUProbe = newRTXTimer(timerPMTUProbe, a, maxPMTUProbes-1)
+	a.heartbeatInterval = defaultHeartbeatInterval
+	a.overallErrorThreshold = maxAssocRetrans
+	a.rtoMgr = newRTOManager()
+
+	return a
 }
 
 func checkPacket(p *packet) error {
@@ -270,6 +1716,20 @@ func min(a, b uint16) uint16 {
 	return b
 }
 
+func min32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b uint32) uint32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 func (a *Association) handleInit(p *packet, i *chunkInit) *packet {
 
 	// Should we be setting any of these permanently until we've ACKed further?
@@ -278,12 +1738,17 @@ func (a *Association) handleInit(p *packet, i *chunkInit) *packet {
 	a.peerVerificationTag = i.initiateTag
 	a.sourcePort = p.destinationPort
 	a.destinationPort = p.sourcePort
+	a.setPeerRwnd(i.advertisedReceiverWindowCredit)
+	a.useForwardTSN = i.supportsForwardTSN()
+	a.useReconfig = i.supportsReconfig()
+	a.useZeroChecksum = a.zeroChecksumAcceptable && i.zeroChecksumAcceptable(errorDetectionMethodDTLS)
 
 	// 13.2 This is the last TSN received in sequence.  This value
 	// is set initially by taking the peer's initial TSN,
 	// received in the INIT or INIT ACK chunk, and
 	// subtracting one from it.
 	a.peerLastTSN = i.initialTSN - 1
+	a.peerLastRSN = i.initialTSN - 1
 
 	outbound := &packet{}
 	outbound.verificationTag = a.peerVerificationTag
@@ -302,40 +1767,266 @@ func (a *Association) handleInit(p *packet, i *chunkInit) *packet {
 		a.myCookie = newRandomStateCookie()
 	}
 
-	initAck.params = []param{a.myCookie}
+	initAck.params = []param{
+		a.myCookie,
+		&paramForwardTSNSupported{},
+		&paramSupportedExtensions{ChunkTypes: []chunkType{RECONFIG, FORWARDTSN}},
+	}
+	if a.zeroChecksumAcceptable {
+		initAck.params = append(initAck.params, &paramZeroChecksumAcceptable{edmid: errorDetectionMethodDTLS})
+	}
+	for _, u := range i.unrecognizedParams {
+		initAck.params = append(initAck.params, &paramUnrecognized{unrecognizedParam: u})
+	}
 
 	outbound.chunks = []chunk{initAck}
 
 	return outbound
 }
 
-func (a *Association) handleData(d *chunkPayloadData) *packet {
+func (a *Association) handleInitAck(p *packet, i *chunkInitAck) error {
+	// https://tools.ietf.org/html/rfc4960#section-5.2.3
+	// If an INIT ACK is received by an endpoint in any state other than
+	// the COOKIE-WAIT state, the endpoint should discard the INIT ACK chunk.
+	if a.state != CookieWait {
+		return nil
+	}
+
+	cookie := i.stateCookie()
+	if cookie == nil {
+		return errors.New("INIT ACK has no State Cookie")
+	}
+
+	a.myMaxNumInboundStreams = min(i.numInboundStreams, a.myMaxNumInboundStreams)
+	a.myMaxNumOutboundStreams = min(i.numOutboundStreams, a.myMaxNumOutboundStreams)
+	a.peerVerificationTag = i.initiateTag
+	a.peerLastTSN = i.initialTSN - 1
+	a.peerLastRSN = i.initialTSN - 1
+	a.sourcePort = p.destinationPort
+	a.destinationPort = p.sourcePort
+	a.setPeerRwnd(i.advertisedReceiverWindowCredit)
+	a.useForwardTSN = i.supportsForwardTSN()
+	a.useReconfig = i.supportsReconfig()
+	a.useZeroChecksum = a.zeroChecksumAcceptable && i.zeroChecksumAcceptable(errorDetectionMethodDTLS)
+
+	// https://tools.ietf.org/html/rfc4960#section-5.1 (C)
+	// Upon reception of the INIT ACK from "Z", "A" shall stop the T1-init
+	// timer and leave the COOKIE-WAIT state.  "A" shall then send the State
+	// Cookie received in the INIT ACK chunk in a COOKIE ECHO chunk, start
+	// the T1-cookie timer, and enter the COOKIE-ECHOED state.
+	a.t1Init.stop()
+	a.storedInit = nil
+
+	a.storedCookieEcho = &chunkCookieEcho{cookie: cookie.cookie}
+	if err := a.sendCookieEcho(); err != nil {
+		return err
+	}
+
+	a.state = CookieEchoed
+	a.t1Cookie.start(rtoInitial)
+	return nil
+}
+
+func (a *Association) handleCookieEcho(c *chunkCookieEcho) error {
+	// A COOKIE ECHO that doesn't carry the cookie we sent is discarded
+	if a.myCookie == nil || !bytes.Equal(a.myCookie.cookie, c.cookie) {
+		return errors.New("COOKIE ECHO does not match our State Cookie")
+	}
+
+	established := false
+	switch a.state {
+	case Closed, CookieWait, CookieEchoed:
+		// https://tools.ietf.org/html/rfc4960#section-5.2.4
+		// A COOKIE ECHO in the COOKIE-WAIT or COOKIE-ECHOED state means
+		// both ends opened at once, the association is now established
+		a.t1Init.stop()
+		a.t1Cookie.stop()
+		a.storedInit = nil
+		a.storedCookieEcho = nil
+		a.state = Established
+		a.scheduleHeartbeat()
+		established = true
+		if a.establishedHandler != nil {
+			a.establishedHandler()
+		}
+	case Established:
+		// Our COOKIE ACK was lost, acknowledge again
+	default:
+		return nil
+	}
+
+	if err := a.send(&packet{
+		verificationTag: a.peerVerificationTag,
+		sourcePort:      a.sourcePort,
+		destinationPort: a.destinationPort,
+		chunks:          []chunk{&chunkCookieAck{}},
+	}); err != nil {
+		return err
+	}
+
+	// Probing only starts once the peer has been told the association is
+	// established
+	if established {
+		return a.startPMTUProbe()
+	}
+	return nil
+}
+
+func (a *Association) handleCookieAck() error {
+	// https://tools.ietf.org/html/rfc4960#section-5.2.5
+	// At any state other than COOKIE-ECHOED, an endpoint should silently
+	// discard a received COOKIE ACK chunk.
+	if a.state != CookieEchoed {
+		return nil
+	}
+
+	a.t1Cookie.stop()
+	a.storedCookieEcho = nil
+	a.state = Established
+	a.scheduleHeartbeat()
+	if a.establishedHandler != nil {
+		a.establishedHandler()
+	}
+	return a.startPMTUProbe()
+}
+
+func (a *Association) handleData(d *chunkPayloadData) {
+	// https://tools.ietf.org/html/rfc4960#section-6.2
+	// When the receiver's advertised window is 0, the receiver MUST drop
+	// any new incoming DATA chunk with a TSN larger than the largest TSN
+	// received so far.
+	if a.getMyReceiverWindowCredit() == 0 {
+		largestTSN := a.peerLastTSN
+		if n := a.payloadQueue.size(); n > 0 {
+			largestTSN = a.payloadQueue.orderedPackets[n-1].tsn
+		}
+		if sna32GT(d.tsn, largestTSN) {
+			return
+		}
+	}
+
+	if !a.payloadQueue.push(d, a.peerLastTSN) {
+		return
+	}
+
+	// Unordered messages don't wait for the TSNs before them, they are
+	// delivered as soon as all their fragments have arrived. The chunk
+	// stays in the payloadQueue for the SACK.
+	if d.unordered {
+		a.pushReassembly(d)
+	}
+	a.processPayloadQueue()
+}
+
+// getMyReceiverWindowCredit returns the receive buffer space left, the
+// DATA waiting for missing TSNs or for the rest of its message takes up
+// the remainder
+func (a *Association) getMyReceiverWindowCredit() uint32 {
+	bytesQueued := uint32(a.payloadQueue.getNumBytes())
+	for _, rq := range a.reassemblyQueue {
+		bytesQueued += uint32(rq.getNumBytes())
+	}
 
-	a.payloadQueue.push(d, a.peerLastTSN)
+	if bytesQueued >= a.myReceiverWindowCredit {
+		return 0
+	}
+	return a.myReceiverWindowCredit - bytesQueued
+}
 
+// processPayloadQueue passes the DATA that is now in sequence on to
+// reassembly
+func (a *Association) processPayloadQueue() {
 	pd, popOk := a.payloadQueue.pop(a.peerLastTSN + 1)
 
 	for popOk {
-		rq, ok := a.reassemblyQueue[pd.streamIdentifier]
-		if !ok {
-			// If this is the first time we've seen a stream identifier
-			// Expected SeqNum == 0
-			rq = &reassemblyQueue{}
-			a.reassemblyQueue[pd.streamIdentifier] = rq
+		if !pd.unordered {
+			a.pushReassembly(pd)
 		}
 
-		rq.push(pd)
-		userData, ok := rq.pop()
-		if ok {
-			// We know the popped data will have the same stream
-			// identifier as the pushed data
-			a.dataHandler(userData, pd.streamIdentifier, pd.payloadType)
+		a.peerLastTSN++
+		a.resetIncomingStreams()
+		pd, popOk = a.payloadQueue.pop(a.peerLastTSN + 1)
+	}
+}
+
+func (a *Association) getReassemblyQueue(streamIdentifier uint16) *reassemblyQueue {
+	rq, ok := a.reassemblyQueue[streamIdentifier]
+	if !ok {
+		// If this is the first time we've seen a stream identifier
+		// Expected SeqNum == 0
+		rq = &reassemblyQueue{}
+		a.reassemblyQueue[streamIdentifier] = rq
+	}
+	return rq
+}
+
+func (a *Association) pushReassembly(pd *chunkPayloadData) {
+	rq := a.getReassemblyQueue(pd.streamIdentifier)
+	rq.push(pd)
+	a.deliver(rq, pd.streamIdentifier)
+}
+
+// deliver hands every message of the stream that is ready to the user
+func (a *Association) deliver(rq *reassemblyQueue, streamIdentifier uint16) {
+	for userData, ppi, ok := rq.pop(); ok; userData, ppi, ok = rq.pop() {
+		s, ok := a.streams[streamIdentifier]
+		switch {
+		case ok:
+			s.push(userData, ppi)
+		case a.dataHandler != nil:
+			a.dataHandler(userData, streamIdentifier, ppi)
+		case !a.streamsClosed && a.acceptCh != nil:
+			s = newStream(a, streamIdentifier, ppi)
+			select {
+			case a.acceptCh <- s:
+				a.streams[streamIdentifier] = s
+				s.push(userData, ppi)
+			default:
+				fmt.Printf("Dropping message on stream %d, too many streams waiting to be accepted\n", streamIdentifier)
+			}
 		}
+	}
+}
 
-		a.peerLastTSN++
-		pd, popOk = a.payloadQueue.pop(a.peerLastTSN)
+func (a *Association) handleForwardTSN(c *chunkForwardTSN) {
+	// https://tools.ietf.org/html/rfc3758#section-3.6
+	// Upon the reception of a new FORWARD TSN, the data receiver MUST
+	// update its cumulative TSN ... and then deliver ... A FORWARD TSN
+	// that doesn't move the cumulative TSN forward was only delayed, and
+	// is answered with a SACK all the same.
+	if !sna32GT(c.newCumulativeTSN, a.peerLastTSN) {
+		return
+	}
+
+	// DATA received between the gaps that are now skipped is in sequence
+	for {
+		pd, ok := a.payloadQueue.first()
+		if !ok || sna32GT(pd.tsn, c.newCumulativeTSN) {
+			break
+		}
+		a.payloadQueue.pop(pd.tsn)
+		if !pd.unordered {
+			a.pushReassembly(pd)
+		}
 	}
+	a.peerLastTSN = c.newCumulativeTSN
+	a.resetIncomingStreams()
 
+	// The fragments of the abandoned messages will never be completed
+	for _, s := range c.streams {
+		a.getReassemblyQueue(s.identifier).forwardTSNForOrdered(s.sequence)
+	}
+	for streamIdentifier, rq := range a.reassemblyQueue {
+		rq.forwardTSNForUnordered(c.newCumulativeTSN)
+		a.deliver(rq, streamIdentifier)
+	}
+
+	a.processPayloadQueue()
+}
+
+// createSelectiveAck acknowledges everything received up to peerLastTSN,
+// along with the gaps beyond it and any duplicate TSNs
+func (a *Association) createSelectiveAck() *packet {
 	outbound := &packet{}
 	outbound.verificationTag = a.peerVerificationTag
 	outbound.sourcePort = a.sourcePort
@@ -344,7 +2035,7 @@ func (a *Association) handleData(d *chunkPayloadData) *packet {
 	sack := &chunkSelectiveAck{}
 
 	sack.cumulativeTSNAck = a.peerLastTSN
-	sack.advertisedReceiverWindowCredit = a.myReceiverWindowCredit
+	sack.advertisedReceiverWindowCredit = a.getMyReceiverWindowCredit()
 	sack.duplicateTSN = a.payloadQueue.popDuplicates()
 	sack.gapAckBlocks = a.payloadQueue.getGapAckBlocks(a.peerLastTSN)
 	outbound.chunks = []chunk{sack}
@@ -352,38 +2043,93 @@ func (a *Association) handleData(d *chunkPayloadData) *packet {
 	return outbound
 }
 
-func (a *Association) handleSack(d *chunkSelectiveAck) ([]*packet, error) {
+// setPeerRwnd records the receiver window the peer advertised in its INIT
+// or INIT ACK, and sets up the congestion window
+func (a *Association) setPeerRwnd(rwnd uint32) {
+	// https://tools.ietf.org/html/rfc4960#section-7.2.1
+	// o  The initial cwnd before DATA transmission or after a sufficiently
+	//    long idle period MUST be set to min(4*MTU, max (2*MTU, 4380
+	//    bytes)).
+	// o  The initial value of ssthresh MAY be arbitrarily high (for
+	//    example, implementations MAY use the size of the receiver
+	//    advertised window).
+	a.peerRwnd = rwnd
+	a.cwnd = min32(4*a.mtu(), max32(2*a.mtu(), 4380))
+	a.ssthresh = rwnd
+}
+
+func (a *Association) mtu() uint32 {
+	return uint32(a.myMaxMTU)
+}
+
+func (a *Association) handleSack(d *chunkSelectiveAck) ([]chunk, error) {
 	// i) If Cumulative TSN Ack is less than the Cumulative TSN Ack
 	// Point, then drop the SACK.  Since Cumulative TSN Ack is
 	// monotonically increasing, a SACK whose Cumulative TSN Ack is
 	// less than the Cumulative TSN Ack Point indicates an out-of-
 	// order SACK.
-	if a.firstSack {
-		a.firstSack = false
-		// We need the ack point to be 1 less than the real one so that when we get our first CumTSN
-		// we act like it is an "new" ack point
-		a.peerCumulativeTSNAckPoint = d.cumulativeTSNAck - 1
-	}
-
-	// This is an old SACK, toss
-	if a.peerCumulativeTSNAckPoint >= d.cumulativeTSNAck {
+	if sna32LT(d.cumulativeTSNAck, a.peerCumulativeTSNAckPoint) {
 		return nil, errors.Errorf("SACK Cumulative ACK %v is older than ACK point %v",
 			d.cumulativeTSNAck, a.peerCumulativeTSNAckPoint)
 	}
 
+	// Measured before anything is popped, cwnd only grows when it was in
+	// full use
+	flightSize := a.flightSize()
+
 	// New ack point, so pop all ACKed packets from inflightQueue
-	// We add 1 because the "currentAckPoint" has already been popped from the inflight queue
-	// For the first SACK we take care of this by setting the ackpoint to cumAck - 1
-	for i := a.peerCumulativeTSNAckPoint + 1; i <= d.cumulativeTSNAck; i++ {
-		_, ok := a.inflightQueue.pop(i)
+	var rttChunk *chunkPayloadData
+	var bytesAcked uint32
+	for i := a.peerCumulativeTSNAckPoint + 1; sna32LTE(i, d.cumulativeTSNAck); i++ {
+		c, ok := a.inflightQueue.pop(i)
 		if !ok {
 			return nil, errors.Errorf("TSN %v unable to be popped from inflight queue", i)
 		}
+
+		if !c.acked && !c.abandoned {
+			bytesAcked += uint32(len(c.userData))
+		}
+		a.releaseBufferedAmount(c)
+
+		// https://tools.ietf.org/html/rfc4960#section-6.3.1
+		// C5) Karn's algorithm: RTT measurements MUST NOT be made using
+		// packets that were retransmitted
+		if c.nSent == 1 && !c.acked {
+			rttChunk = c
+		}
+	}
+	if rttChunk != nil {
+		a.rtoMgr.setNewRTT(float64(time.Since(rttChunk.since)) / float64(time.Millisecond))
+	}
+
+	if sna32GT(d.cumulativeTSNAck, a.peerCumulativeTSNAckPoint) {
+		// https://tools.ietf.org/html/rfc4960#section-6.3.2
+		// R2) Whenever all outstanding data sent to an address have been
+		// acknowledged, turn off the T3-rtx timer of that address.
+		// R3) Whenever a SACK is received that acknowledges the DATA chunk
+		// with the earliest outstanding TSN for that address, restart the
+		// T3-rtx timer for that address with its current RTO
+		a.t3RTX.stop()
+		if a.inflightQueue.size() > 0 {
+			a.t3RTX.start(a.rtoMgr.getRTO())
+		}
+
+		a.onCumulativeTSNAckPointAdvanced(bytesAcked, flightSize)
+
+		// https://tools.ietf.org/html/rfc4960#section-8.1
+		// The counter shall be reset each time a DATA chunk sent to that
+		// peer endpoint is acknowledged (by the reception of a SACK)
+		a.overallErrorCount = 0
 	}
 
 	a.peerCumulativeTSNAckPoint = d.cumulativeTSNAck
+	if a.inFastRecovery && sna32GTE(d.cumulativeTSNAck, a.fastRecoverExitPoint) {
+		a.inFastRecovery = false
+	}
 
-	var sackDataPackets []*packet
+	// Chunks inside a Gap Ack Block have arrived and must not be sent
+	// again, the ones in the holes between the blocks are missing
+	var fastRetransmit []*chunkPayloadData
 	var prevEnd uint16
 	for _, g := range d.gapAckBlocks {
 		for i := prevEnd + 1; i < g.start; i++ {
@@ -391,62 +2137,243 @@ func (a *Association) handleSack(d *chunkSelectiveAck) ([]*packet, error) {
 			if !ok {
 				return nil, errors.Errorf("Requested non-existent TSN %v", d.cumulativeTSNAck+uint32(i))
 			}
+			if pp.acked || pp.abandoned {
+				continue
+			}
 
-			sackDataPackets = append(sackDataPackets, &packet{
-				verificationTag: a.peerVerificationTag,
-				sourcePort:      a.sourcePort,
-				destinationPort: a.destinationPort,
-				chunks:          []chunk{pp},
-			})
+			// https://tools.ietf.org/html/rfc4960#section-7.2.4
+			// Whenever an endpoint receives a SACK that indicates that some
+			// TSNs are missing, it SHOULD wait for two further miss
+			// indications (via subsequent SACKs for a total of three missing
+			// reports) on the same TSNs before taking action with regard to
+			// Fast Retransmit.
+			pp.missIndicator++
+			if pp.missIndicator != 3 {
+				continue
+			}
+
+			// https://tools.ietf.org/html/rfc3758#section-3.5
+			// A message whose retransmissions are used up is abandoned
+			// instead of retransmitted
+			if a.isAbandonable(pp) {
+				a.abandon(pp)
+			} else {
+				fastRetransmit = append(fastRetransmit, pp)
+			}
+		}
+
+		for i := uint32(g.start); i <= uint32(g.end); i++ {
+			if pp, ok := a.inflightQueue.get(d.cumulativeTSNAck + i); ok {
+				pp.acked = true
+			}
 		}
 		prevEnd = g.end
 	}
 
-	return sackDataPackets, nil
+	a.updatePeerRwnd(d.advertisedReceiverWindowCredit)
+
+	if len(fastRetransmit) == 0 {
+		return nil, nil
+	}
+
+	// 2) If not in Fast Recovery, adjust the ssthresh and cwnd of the
+	// destination address(es) to which the missing DATA chunks were last
+	// sent, according to the formula described in Section 7.2.3, and
+	// enter Fast Recovery, recording the highest outstanding TSN as the
+	// Fast Recovery exit point
+	if !a.inFastRecovery {
+		a.inFastRecovery = true
+		a.fastRecoverExitPoint = a.inflightQueue.orderedPackets[a.inflightQueue.size()-1].tsn
+		a.ssthresh = max32(a.cwnd/2, 4*a.mtu())
+		a.cwnd = a.ssthresh
+		a.partialBytesAcked = 0
+	}
+
+	var retransmit []chunk
+	for _, c := range fastRetransmit {
+		retransmit = append(retransmit, a.markSent(c))
+	}
+	return retransmit, nil
+}
+
+// onCumulativeTSNAckPointAdvanced grows the congestion window as new data
+// is acknowledged, flightSize is what was outstanding before the SACK
+func (a *Association) onCumulativeTSNAckPointAdvanced(bytesAcked, flightSize uint32) {
+	// The congestion window is left alone while recovering from a loss
+	if a.inFastRecovery {
+		return
+	}
+
+	if a.cwnd <= a.ssthresh {
+		// https://tools.ietf.org/html/rfc4960#section-7.2.1
+		// When cwnd is less than or equal to ssthresh, an SCTP endpoint MUST
+		// use the slow-start algorithm to increase cwnd only if the current
+		// congestion window is being fully utilized, an incoming SACK
+		// advances the Cumulative TSN Ack Point, and the data sender is not
+		// in Fast Recovery.  Only when these three conditions are met can
+		// the cwnd be increased; otherwise, the cwnd MUST not be increased.
+		// If these conditions are met, then cwnd MUST be increased by, at
+		// most, the lesser of 1) the total size of the previously
+		// outstanding DATA chunk(s) acknowledged, and 2) the destination's
+		// path MTU.
+		if flightSize >= a.cwnd {
+			a.cwnd += min32(bytesAcked, a.mtu())
+		}
+		return
+	}
+
+	// https://tools.ietf.org/html/rfc4960#section-7.2.2
+	// Whenever cwnd is greater than ssthresh, upon each SACK arrival that
+	// advances the Cumulative TSN Ack Point, increase partial_bytes_acked
+	// by the total number of bytes of all new chunks acknowledged in that
+	// SACK including chunks acknowledged by the new Cumulative TSN Ack and
+	// by Gap Ack Blocks.
+	//
+	// When partial_bytes_acked is equal to or greater than cwnd and before
+	// the arrival of the SACK the sender had cwnd or more bytes of data
+	// outstanding (i.e., before arrival of the SACK, flightsize was greater
+	// than or equal to cwnd), increase cwnd by MTU, and reset
+	// partial_bytes_acked to (partial_bytes_acked - cwnd).
+	a.partialBytesAcked += bytesAcked
+	if a.partialBytesAcked >= a.cwnd && flightSize >= a.cwnd {
+		a.partialBytesAcked -= a.cwnd
+		a.cwnd += a.mtu()
+	}
+}
+
+// updatePeerRwnd takes the receiver window advertised in a SACK, less what
+// is still in flight
+func (a *Association) updatePeerRwnd(advertised uint32) {
+	// https://tools.ietf.org/html/rfc4960#section-6.2.1
+	// B) Any time a SACK arrives, the endpoint performs the following:
+	// ...
+	//   ii) Set rwnd equal to the newly received a_rwnd minus the number of
+	//       bytes still outstanding after processing the Cumulative TSN Ack
+	//       and the Gap Ack Blocks.
+	flightSize := a.flightSize()
+	if advertised < flightSize {
+		a.peerRwnd = 0
+		return
+	}
+	a.peerRwnd = advertised - flightSize
+}
+
+// isEstablished reports whether the handshake has completed, DATA and SACK
+// are only exchanged from then on until the association shuts down
+func (a *Association) isEstablished() bool {
+	switch a.state {
+	case Established, ShutdownPending, ShutdownSent, ShutdownReceived:
+		return true
+	default:
+		return false
+	}
 }
 
 func (a *Association) send(p *packet) error {
+	p.zeroChecksum = a.zeroChecksumAllowed(p.chunks)
 	raw, err := p.marshal()
 	if err != nil {
 		return errors.Wrap(err, "Failed to send packet to outbound handler")
 	}
 
+	a.nChunksSent += uint64(len(p.chunks))
 	a.outboundHandler(raw)
 
 	return nil
 }
 
+// sendChunks sends chunks to the peer, bundled into as few packets as the
+// path MTU allows
+func (a *Association) sendChunks(chunks ...chunk) error {
+	p := &packet{
+		verificationTag: a.peerVerificationTag,
+		sourcePort:      a.sourcePort,
+		destinationPort: a.destinationPort,
+		chunks:          chunks,
+		zeroChecksum:    a.zeroChecksumAllowed(chunks),
+	}
+
+	raws, err := p.bundle(a.maxPacketSize())
+	if err != nil {
+		return errors.Wrap(err, "Failed to send packet to outbound handler")
+	}
+
+	a.nChunksSent += uint64(len(chunks))
+	for _, raw := range raws {
+		a.outboundHandler(raw)
+	}
+	return nil
+}
+
+// zeroChecksumAllowed reports whether chunks may be sent with a zero
+// Checksum
+func (a *Association) zeroChecksumAllowed(chunks []chunk) bool {
+	if !a.useZeroChecksum {
+		return false
+	}
+
+	// https://tools.ietf.org/html/draft-ietf-tsvwg-sctp-zero-checksum-08#section-5.1
+	// The handshake, and the ABORT and SHUTDOWN COMPLETE that may answer
+	// out of the blue packets, always carry the CRC32c
+	for _, c := range chunks {
+		switch c.(type) {
+		case *chunkInit, *chunkInitAck, *chunkCookieEcho, *chunkAbort, *chunkShutdownComplete:
+			return false
+		}
+	}
+	return true
+}
+
+// maxPacketSize is the size of the packets carrying a DATA chunk filled up
+// to myMaxMTU
+func (a *Association) maxPacketSize() int {
+	return packetHeaderSize + chunkHeaderSize + payloadDataHeaderSize + int(a.myMaxMTU)
+}
+
 func (a *Association) handleChunk(p *packet, c chunk) error {
-	if _, err := c.check(); err != nil {
-		return errors.Wrap(err, "Failed validating chunk")
-		// TODO: Create ABORT
+	if abort, err := c.check(); err != nil {
+		if !abort {
+			return errors.Wrap(err, "Failed validating chunk")
+		}
+
+		// Only an association that exists is torn down, an out of the blue
+		// chunk is merely answered
+		if a.state != Closed {
+			a.abort(&AbortError{Causes: []string{err.Error()}})
+		}
+		if sendErr := a.sendAbort(p, &errorCauseProtocolViolation{additionalInformation: []byte(err.Error())}); sendErr != nil {
+			return errors.Wrap(sendErr, "Failed sending ABORT")
+		}
+		return errors.Wrap(err, "Failed validating chunk, sent ABORT")
 	}
 
 	switch c := c.(type) {
 	case *chunkInit:
 		switch a.state {
-		case Open:
+		case Closed:
 			return a.send(a.handleInit(p, c))
-		case CookieEchoed:
+		case CookieWait, CookieEchoed:
 			// https://tools.ietf.org/html/rfc4960#section-5.2.1
-			// Upon receipt of an INIT in the COOKIE-ECHOED state, an endpoint MUST
-			// respond with an INIT ACK using the same parameters it sent in its
-			// original INIT chunk (including its Initiate Tag, unchanged)
-			return errors.Errorf("TODO respond with original cookie %s", a.state.String())
+			// Upon receipt of an INIT in the COOKIE-WAIT or COOKIE-ECHOED
+			// state, an endpoint MUST respond with an INIT ACK using the same
+			// parameters it sent in its original INIT chunk (including its
+			// Initiate Tag, unchanged).  The state is left as it is.
+			return a.send(a.handleInit(p, c))
 		default:
 			// 5.2.2.  Unexpected INIT in States Other than CLOSED, COOKIE-ECHOED,
 			//        COOKIE-WAIT, and SHUTDOWN-ACK-SENT
 			return errors.Errorf("TODO Handle Init when in state %s", a.state.String())
 		}
+	case *chunkInitAck:
+		return a.handleInitAck(p, c)
 	case *chunkAbort:
-		fmt.Println("Abort chunk, with errors")
-		for _, e := range c.errorCauses {
-			fmt.Println(e.errorCauseCode())
-		}
+		return a.handleAbort(c)
+	case *chunkError:
+		a.handleError(c)
 	case *chunkHeartbeat:
 		hbi, ok := c.params[0].(*paramHeartbeatInfo)
 		if !ok {
-			fmt.Println("Failed to handle Heartbeat, no ParamHeartbeatInfo")
+			return errors.New("Failed to handle Heartbeat, no ParamHeartbeatInfo")
 		}
 
 		return a.send(&packet{
@@ -461,30 +2388,63 @@ func (a *Association) handleChunk(p *packet, c chunk) error {
 				},
 			}},
 		})
-	case *chunkCookieEcho:
-		if bytes.Equal(a.myCookie.cookie, c.cookie) {
-			return a.send(&packet{
-				verificationTag: a.peerVerificationTag,
-				sourcePort:      a.sourcePort,
-				destinationPort: a.destinationPort,
-				chunks:          []chunk{&chunkCookieAck{}},
-			})
+	case *chunkHeartbeatAck:
+		if err := a.handleHeartbeatAck(c); err != nil {
+			return errors.Wrap(err, "Failure handling HEARTBEAT ACK")
 		}
-
-		// TODO Abort
+	case *chunkCookieEcho:
+		return a.handleCookieEcho(c)
+	case *chunkCookieAck:
+		return a.handleCookieAck()
 	case *chunkPayloadData:
-		return a.send(a.handleData(c))
+		if !a.isEstablished() {
+			return errors.Errorf("DATA chunk received in state %s", a.state.String())
+		}
+		a.handleData(c)
 	case *chunkSelectiveAck:
-		p, err := a.handleSack(c)
+		if !a.isEstablished() {
+			return errors.Errorf("SACK chunk received in state %s", a.state.String())
+		}
+		retransmit, err := a.handleSack(c)
 		if err != nil {
 			return errors.Wrap(err, "Failure handling SACK")
 		}
-		for _, pp := range p {
-			err := a.send(pp)
-			if err != nil {
-				return errors.Wrap(err, "Failure handling SACK")
-			}
+		if err := a.sendChunks(retransmit...); err != nil {
+			return errors.Wrap(err, "Failure handling SACK")
+		}
+
+		// The SACK may have opened up the windows for more DATA
+		if err := a.sendPendingData(); err != nil {
+			return errors.Wrap(err, "Failure handling SACK")
+		}
+		if err := a.advancePeerTSNAckPoint(); err != nil {
+			return errors.Wrap(err, "Failure handling SACK")
+		}
+		if err := a.checkShutdownProgress(); err != nil {
+			return errors.Wrap(err, "Failure handling SACK")
+		}
+	case *chunkForwardTSN:
+		if !a.isEstablished() {
+			return errors.Errorf("FORWARD TSN chunk received in state %s", a.state.String())
+		}
+		a.handleForwardTSN(c)
+	case *chunkReconfig:
+		if !a.isEstablished() {
+			return errors.Errorf("RE-CONFIG chunk received in state %s", a.state.String())
+		}
+		if err := a.handleReconfig(c); err != nil {
+			return errors.Wrap(err, "Failure handling RE-CONFIG")
+		}
+	case *chunkShutdown:
+		if err := a.handleShutdown(c); err != nil {
+			return errors.Wrap(err, "Failure handling SHUTDOWN")
+		}
+	case *chunkShutdownAck:
+		if err := a.handleShutdownAck(); err != nil {
+			return errors.Wrap(err, "Failure handling SHUTDOWN ACK")
 		}
+	case *chunkShutdownComplete:
+		a.handleShutdownComplete()
 	}
 
 	return nil
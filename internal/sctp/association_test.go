@@ -3,6 +3,10 @@ package sctp
 import (
 	"fmt"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAssociationInit(t *testing.T) {
@@ -19,3 +23,699 @@ func TestAssociationInit(t *testing.T) {
 		// t.Error(errors.Wrap(err, "Failed to HandleInbound"))
 	}
 }
+
+// pipe collects the packets each association sends so they can be delivered
+// to the other one step by step
+type pipe struct {
+	toA, toB [][]byte
+}
+
+func newAssociationPair(p *pipe) (a, b *Association) {
+	noData := func([]byte, uint16, PayloadProtocolIdentifier) {}
+	a = NewAssocation(func(raw []byte) { p.toB = append(p.toB, raw) }, noData)
+	b = NewAssocation(func(raw []byte) { p.toA = append(p.toA, raw) }, noData)
+	return a, b
+}
+
+// flush delivers packets until neither side has anything left to send
+func (p *pipe) flush(t *testing.T, a, b *Association) {
+	for len(p.toA) > 0 || len(p.toB) > 0 {
+		toA, toB := p.toA, p.toB
+		p.toA, p.toB = nil, nil
+		for _, raw := range toB {
+			assert.NoError(t, b.HandleInbound(raw))
+		}
+		for _, raw := range toA {
+			assert.NoError(t, a.HandleInbound(raw))
+		}
+	}
+}
+
+func TestAssociationHandshake(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	established := 0
+	a.SetEstablishedHandler(func() { established++ })
+	b.SetEstablishedHandler(func() { established++ })
+
+	assert.NoError(t, a.Connect())
+	assert.Equal(t, CookieWait, a.state)
+	assert.True(t, a.t1Init.isRunning())
+	assert.Error(t, a.Connect(), "Connect should fail once connecting")
+
+	p.flush(t, a, b)
+
+	assert.Equal(t, Established, a.state)
+	assert.Equal(t, Established, b.state)
+	assert.False(t, a.t1Init.isRunning())
+	assert.False(t, a.t1Cookie.isRunning())
+	assert.Equal(t, b.myVerificationTag, a.peerVerificationTag)
+	assert.Equal(t, a.myVerificationTag, b.peerVerificationTag)
+	assert.Equal(t, 2, established)
+}
+
+func TestAssociationSimultaneousOpen(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	assert.NoError(t, b.Connect())
+	p.flush(t, a, b)
+
+	assert.Equal(t, Established, a.state)
+	assert.Equal(t, Established, b.state)
+	assert.Equal(t, b.myVerificationTag, a.peerVerificationTag)
+	assert.Equal(t, a.myVerificationTag, b.peerVerificationTag)
+}
+
+func TestAssociationUnexpectedChunks(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	// A COOKIE ACK outside of COOKIE-ECHOED is discarded
+	a.handleCookieAck()
+	assert.Equal(t, Closed, a.state)
+
+	// DATA before the handshake is refused
+	assert.Error(t, a.handleChunk(&packet{}, &chunkPayloadData{userData: []byte{0}}))
+
+	// A packet with someone else's verification tag is refused
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+	raw, err := (&packet{
+		sourcePort:      defaultPort,
+		destinationPort: defaultPort,
+		verificationTag: a.myVerificationTag + 1,
+		chunks:          []chunk{&chunkCookieAck{}},
+	}).marshal()
+	assert.NoError(t, err)
+	assert.Error(t, a.HandleInbound(raw))
+}
+
+func TestAssociationSelectiveAck(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	var received []string
+	b.dataHandler = func(data []byte, streamIdentifier uint16, ppi PayloadProtocolIdentifier) {
+		received = append(received, string(data))
+	}
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		assert.NoError(t, a.HandleOutbound([]byte(msg), 0, PayloadTypeWebRTCString))
+	}
+	assert.Len(t, p.toB, 4)
+
+	// Lose "two", and deliver "three" twice
+	sent := p.toB
+	p.toB = nil
+	assert.NoError(t, b.HandleInbound(sent[0]))
+	assert.NoError(t, b.HandleInbound(sent[2]))
+	assert.NoError(t, b.HandleInbound(sent[2]))
+	assert.NoError(t, b.HandleInbound(sent[3]))
+	assert.Equal(t, []string{"one"}, received)
+
+	assert.Len(t, p.toA, 4)
+	sacks := make([]*chunkSelectiveAck, len(p.toA))
+	for i, raw := range p.toA {
+		pkt := &packet{}
+		assert.NoError(t, pkt.unmarshal(raw))
+		sacks[i] = pkt.chunks[0].(*chunkSelectiveAck)
+	}
+
+	firstTSN := a.peerCumulativeTSNAckPoint + 1
+	assert.Equal(t, firstTSN, sacks[0].cumulativeTSNAck)
+	assert.Empty(t, sacks[0].gapAckBlocks)
+	assert.Equal(t, []gapAckBlock{{start: 2, end: 2}}, sacks[1].gapAckBlocks)
+	assert.Empty(t, sacks[1].duplicateTSN)
+	assert.Equal(t, []uint32{firstTSN + 2}, sacks[2].duplicateTSN)
+	assert.Equal(t, []gapAckBlock{{start: 2, end: 3}}, sacks[3].gapAckBlocks)
+
+	// The third SACK reporting "two" missing makes a fast retransmit it,
+	// after which everything is delivered and acknowledged
+	cwnd := a.cwnd
+	p.flush(t, a, b)
+	assert.Equal(t, []string{"one", "two", "three", "four"}, received)
+	assert.Equal(t, firstTSN+3, a.peerCumulativeTSNAckPoint)
+	assert.Equal(t, a.peerCumulativeTSNAckPoint, b.peerLastTSN)
+	assert.Equal(t, max32(cwnd/2, 4*a.mtu()), a.ssthresh)
+	assert.False(t, a.inFastRecovery)
+}
+
+func TestAssociationCongestionWindow(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+	assert.Equal(t, uint32(4380), a.cwnd)
+	assert.Equal(t, b.myReceiverWindowCredit, a.ssthresh)
+
+	// Only as much as the congestion window allows is sent at once, the
+	// rest waits for SACKs
+	msg := make([]byte, a.mtu())
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, a.HandleOutbound(msg, 0, PayloadTypeWebRTCBinary))
+	}
+	assert.Len(t, p.toB, 4)
+	assert.Len(t, a.pendingQueue, 2)
+
+	// Slow start grows cwnd by at most an MTU per SACK while it is full
+	p.flush(t, a, b)
+	assert.Empty(t, a.pendingQueue)
+	assert.Equal(t, 0, a.inflightQueue.size())
+	assert.Equal(t, uint32(4380)+2*a.mtu(), a.cwnd)
+}
+
+func TestAssociationT3RTX(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	retransmitted := make(chan []byte, 8)
+	a.outboundHandler = func(raw []byte) { retransmitted <- raw }
+	a.rtoMgr.rto = 10
+
+	a.Lock()
+	assert.NoError(t, a.HandleOutbound([]byte("lost"), 0, PayloadTypeWebRTCString))
+	assert.True(t, a.t3RTX.isRunning())
+	a.Unlock()
+	first := <-retransmitted
+
+	// Nothing is acknowledged, so the DATA is sent again once the RTO expires
+	select {
+	case raw := <-retransmitted:
+		assert.Equal(t, first, raw)
+	case <-time.After(time.Second):
+		t.Fatal("DATA was not retransmitted")
+	}
+
+	// Acknowledging everything stops the timer
+	a.Lock()
+	defer a.Unlock()
+	a.outboundHandler = func([]byte) {}
+	assert.NoError(t, b.HandleInbound(first))
+	assert.Len(t, p.toA, 1)
+	assert.NoError(t, a.HandleInbound(p.toA[0]))
+	assert.False(t, a.t3RTX.isRunning())
+	assert.Equal(t, 0, a.inflightQueue.size())
+}
+
+func TestAssociationShutdown(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	var received []string
+	b.dataHandler = func(data []byte, streamIdentifier uint16, ppi PayloadProtocolIdentifier) {
+		received = append(received, string(data))
+	}
+
+	assert.Error(t, a.Shutdown(), "Shutdown should fail before the association is established")
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	// The SHUTDOWN waits for the queued data to be acknowledged
+	assert.NoError(t, a.HandleOutbound([]byte("last words"), 0, PayloadTypeWebRTCString))
+	assert.NoError(t, a.Shutdown())
+	assert.Equal(t, ShutdownPending, a.state)
+	assert.Error(t, a.HandleOutbound([]byte("too late"), 0, PayloadTypeWebRTCString))
+
+	p.flush(t, a, b)
+	assert.Equal(t, []string{"last words"}, received)
+	assert.Equal(t, Closed, a.state)
+	assert.Equal(t, Closed, b.state)
+	assert.False(t, a.t2Shutdown.isRunning())
+	assert.False(t, b.t2Shutdown.isRunning())
+}
+
+func TestAssociationSimultaneousShutdown(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	assert.NoError(t, a.Shutdown())
+	assert.NoError(t, b.Shutdown())
+	assert.Equal(t, ShutdownSent, a.state)
+	assert.Equal(t, ShutdownSent, b.state)
+
+	p.flush(t, a, b)
+	assert.Equal(t, Closed, a.state)
+	assert.Equal(t, Closed, b.state)
+}
+
+func TestAssociationAbort(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	// An INIT with an Initiate Tag of 0 is a protocol violation that
+	// aborts the association
+	raw, err := (&packet{
+		sourcePort:      defaultPort,
+		destinationPort: defaultPort,
+		chunks: []chunk{&chunkInit{chunkInitCommon: chunkInitCommon{
+			numOutboundStreams: 1,
+			numInboundStreams:  1,
+		}}},
+	}).marshal()
+	assert.NoError(t, err)
+	assert.Error(t, b.HandleInbound(raw))
+	assert.Equal(t, Closed, b.state)
+	assert.False(t, b.abortErr.Remote)
+	assert.Error(t, b.HandleOutbound([]byte("data"), 0, PayloadTypeWebRTCString))
+
+	// a learns why through the ABORT's error cause
+	assert.Len(t, p.toA, 1)
+	err = a.HandleInbound(p.toA[0])
+	abortErr, ok := errors.Cause(err).(*AbortError)
+	assert.True(t, ok, "HandleInbound should return an AbortError, got %v", err)
+	assert.True(t, abortErr.Remote)
+	assert.Len(t, abortErr.Causes, 1)
+	assert.Contains(t, abortErr.Causes[0], "InitiateTag must not be 0")
+	assert.Equal(t, Closed, a.state)
+	assert.Equal(t, abortErr, errors.Cause(a.HandleOutbound([]byte("data"), 0, PayloadTypeWebRTCString)))
+}
+
+func TestAssociationHeartbeat(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+	assert.NotNil(t, a.heartbeatTimer, "Heartbeats should start once established")
+
+	assert.NoError(t, a.sendHeartbeat())
+	assert.Len(t, p.toB, 1)
+
+	// The HEARTBEAT ACK echoes the Heartbeat Info, clearing the error
+	// counter and measuring the RTT
+	a.overallErrorCount = 1
+	p.flush(t, a, b)
+	assert.Equal(t, uint(0), a.overallErrorCount)
+	assert.Nil(t, a.heartbeatInfo)
+	assert.NotZero(t, a.rtoMgr.srtt)
+}
+
+func TestAssociationHeartbeatFailure(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	// Nobody answers, so after too many unanswered HEARTBEATs the peer is
+	// considered unreachable
+	a.Lock()
+	a.outboundHandler = func([]byte) {}
+	a.heartbeatInterval = time.Millisecond
+	a.rtoMgr.rto = 1
+	a.overallErrorThreshold = 2
+	a.scheduleHeartbeat()
+	a.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		a.Lock()
+		abortErr := a.abortErr
+		a.Unlock()
+
+		if abortErr != nil {
+			assert.False(t, abortErr.Remote)
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatal("Association was not aborted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	a.Lock()
+	assert.Equal(t, Closed, a.state)
+	a.Unlock()
+}
+
+func TestAssociationForwardTSN(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	var received []string
+	b.dataHandler = func(data []byte, streamIdentifier uint16, ppi PayloadProtocolIdentifier) {
+		received = append(received, string(data))
+	}
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+	assert.True(t, a.useForwardTSN)
+	assert.True(t, b.useForwardTSN)
+
+	// Messages on stream 1 are never retransmitted
+	a.SetReliabilityParams(1, false, ReliabilityTypeRexmit, 0)
+	assert.NoError(t, a.HandleOutbound([]byte("lost"), 1, PayloadTypeWebRTCString))
+	assert.NoError(t, a.HandleOutbound([]byte("kept"), 1, PayloadTypeWebRTCString))
+	assert.Len(t, p.toB, 2)
+
+	// "kept" waits behind "lost" on the receiver
+	sent := p.toB
+	p.toB = nil
+	assert.NoError(t, b.HandleInbound(sent[1]))
+	p.flush(t, a, b)
+	assert.Empty(t, received)
+
+	// Instead of retransmitting "lost" once T3-rtx expires it is abandoned,
+	// and b is told to skip it
+	a.onRetransmissionTimeout(timerT3RTX, 1)
+	assert.Len(t, p.toB, 1)
+	pkt := &packet{}
+	assert.NoError(t, pkt.unmarshal(p.toB[0]))
+	fwd, ok := pkt.chunks[0].(*chunkForwardTSN)
+	assert.True(t, ok, "T3-rtx expiry should send a FORWARD TSN")
+	assert.Equal(t, a.peerCumulativeTSNAckPoint+1, fwd.newCumulativeTSN)
+	assert.Equal(t, []chunkForwardTSNStream{{identifier: 1, sequence: 0}}, fwd.streams)
+
+	p.flush(t, a, b)
+	assert.Equal(t, []string{"kept"}, received)
+	assert.Equal(t, a.peerCumulativeTSNAckPoint, b.peerLastTSN)
+	assert.Equal(t, 0, a.inflightQueue.size())
+
+	// The stream carries on in order
+	assert.NoError(t, a.HandleOutbound([]byte("next"), 1, PayloadTypeWebRTCString))
+	p.flush(t, a, b)
+	assert.Equal(t, []string{"kept", "next"}, received)
+}
+
+func TestAssociationResetStream(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+	assert.True(t, a.useReconfig)
+	assert.True(t, b.useReconfig)
+
+	var resetA, resetB []uint16
+	a.SetStreamResetHandler(func(streamIdentifier uint16) { resetA = append(resetA, streamIdentifier) })
+	b.SetStreamResetHandler(func(streamIdentifier uint16) { resetB = append(resetB, streamIdentifier) })
+
+	assert.NoError(t, a.HandleOutbound([]byte("ping"), 1, PayloadTypeWebRTCString))
+	p.flush(t, a, b)
+	assert.NoError(t, b.HandleOutbound([]byte("pong"), 1, PayloadTypeWebRTCString))
+	p.flush(t, a, b)
+	assert.Contains(t, b.reassemblyQueue, uint16(1))
+
+	assert.NoError(t, a.ResetStream(1))
+	assert.Error(t, a.HandleOutbound([]byte("too soon"), 1, PayloadTypeWebRTCString))
+	p.flush(t, a, b)
+
+	// b resets its incoming stream and mirrors the reset on its outgoing one
+	assert.NotContains(t, b.reassemblyQueue, uint16(1))
+	assert.NotContains(t, a.reassemblyQueue, uint16(1))
+	assert.NotContains(t, a.outboundStreams, uint16(1))
+	assert.NotContains(t, b.outboundStreams, uint16(1))
+	assert.Nil(t, a.storedReconfig)
+	assert.Nil(t, b.storedReconfig)
+	assert.Equal(t, []uint16{1}, resetA)
+	assert.Equal(t, []uint16{1}, resetB)
+
+	// The stream starts over
+	assert.NoError(t, a.HandleOutbound([]byte("again"), 1, PayloadTypeWebRTCString))
+	p.flush(t, a, b)
+	assert.Equal(t, uint16(1), b.reassemblyQueue[1].nextSSN)
+}
+
+func TestAssociationPMTUProbe(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	// Every probe size is acknowledged, the fragment size fills the largest
+	largest := pmtuProbeSizes[len(pmtuProbeSizes)-1]
+	for _, assoc := range []*Association{a, b} {
+		assert.Equal(t, uint16(largest-packetHeaderSize-chunkHeaderSize-payloadDataHeaderSize), assoc.myMaxMTU)
+		assert.False(t, assoc.tPMTUProbe.isRunning())
+	}
+
+	assert.NoError(t, a.HandleOutbound(make([]byte, 4000), 1, PayloadTypeWebRTCBinary))
+	for _, raw := range p.toB {
+		assert.True(t, len(raw) <= largest)
+	}
+	p.flush(t, a, b)
+}
+
+func TestAssociationPMTUProbeLost(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	// INIT, INIT ACK and COOKIE ECHO
+	assert.NoError(t, a.Connect())
+	assert.NoError(t, b.HandleInbound(p.toB[0]))
+	assert.NoError(t, a.HandleInbound(p.toA[0]))
+	assert.NoError(t, b.HandleInbound(p.toB[1]))
+
+	// b's COOKIE ACK is followed by its first probe, which is lost
+	assert.Len(t, p.toA, 3)
+	assert.NoError(t, a.HandleInbound(p.toA[1]))
+	assert.True(t, b.tPMTUProbe.isRunning())
+	initial := b.myMaxMTU
+
+	b.onRetransmissionTimeout(timerPMTUProbe, 1)
+	b.onRetransmissionTimeout(timerPMTUProbe, 2)
+	b.onRetransmissionFailure(timerPMTUProbe)
+	assert.Equal(t, initial, b.myMaxMTU)
+	assert.Nil(t, b.pmtuProbeInfo)
+	assert.Len(t, p.toA, 5)
+}
+
+func TestAssociationZeroChecksum(t *testing.T) {
+	for _, bothEnabled := range []bool{true, false} {
+		p := &pipe{}
+		a, b := newAssociationPair(p)
+
+		var received []string
+		b.dataHandler = func(data []byte, streamIdentifier uint16, ppi PayloadProtocolIdentifier) {
+			received = append(received, string(data))
+		}
+
+		a.EnableZeroChecksum()
+		if bothEnabled {
+			b.EnableZeroChecksum()
+		}
+		assert.NoError(t, a.Connect())
+
+		// The INIT always carries the CRC32c
+		assert.NotEqual(t, []byte{0, 0, 0, 0}, p.toB[0][8:12])
+		p.flush(t, a, b)
+		// Each side only leaves the Checksum out when both run over DTLS
+		assert.Equal(t, bothEnabled, a.useZeroChecksum)
+		assert.Equal(t, bothEnabled, b.useZeroChecksum)
+
+		assert.NoError(t, a.HandleOutbound([]byte("hello"), 1, PayloadTypeWebRTCString))
+		assert.Len(t, p.toB, 1)
+		if bothEnabled {
+			assert.Equal(t, []byte{0, 0, 0, 0}, p.toB[0][8:12])
+		} else {
+			assert.NotEqual(t, []byte{0, 0, 0, 0}, p.toB[0][8:12])
+		}
+		p.flush(t, a, b)
+		assert.Equal(t, []string{"hello"}, received)
+
+		a.Close()
+		b.Close()
+	}
+}
+
+func TestAssociationReceiveWindow(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+	b.myReceiverWindowCredit = 2000
+
+	// Two fragments of an incomplete message take up b's window
+	assert.NoError(t, a.HandleOutbound(make([]byte, 3*int(a.myMaxMTU)), 1, PayloadTypeWebRTCBinary))
+	assert.Len(t, p.toB, 3)
+	sent := p.toB
+	p.toB = nil
+
+	assert.NoError(t, b.HandleInbound(sent[0]))
+	assert.NoError(t, b.HandleInbound(sent[1]))
+	assert.Equal(t, uint32(0), b.getMyReceiverWindowCredit())
+
+	sack := &packet{}
+	assert.NoError(t, sack.unmarshal(p.toA[len(p.toA)-1]))
+	assert.Equal(t, uint32(0), sack.chunks[0].(*chunkSelectiveAck).advertisedReceiverWindowCredit)
+
+	// With the window closed, DATA beyond the largest TSN is dropped
+	assert.NoError(t, b.HandleInbound(sent[2]))
+	assert.Equal(t, 0, b.payloadQueue.size())
+	_, ok := b.reassemblyQueue[1]
+	assert.True(t, ok)
+	assert.Equal(t, 2*int(a.myMaxMTU), b.reassemblyQueue[1].getNumBytes())
+}
+
+func TestAssociationMaxMessageSize(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	err := a.HandleOutbound(make([]byte, defaultMaxMessageSize+1), 1, PayloadTypeWebRTCBinary)
+	assert.Equal(t, ErrMessageTooLarge, errors.Cause(err))
+	assert.Empty(t, a.pendingQueue)
+
+	a.SetMaxMessageSize(0)
+	assert.NoError(t, a.HandleOutbound(make([]byte, defaultMaxMessageSize+1), 1, PayloadTypeWebRTCBinary))
+	p.flush(t, a, b)
+}
+
+func TestAssociationUnordered(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	var received []string
+	b.dataHandler = func(data []byte, streamIdentifier uint16, ppi PayloadProtocolIdentifier) {
+		received = append(received, string(data))
+	}
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	a.SetReliabilityParams(1, true, ReliabilityTypeReliable, 0)
+	assert.NoError(t, a.HandleOutbound([]byte("late"), 1, PayloadTypeWebRTCString))
+	assert.NoError(t, a.HandleOutbound([]byte("early"), 1, PayloadTypeWebRTCString))
+	assert.Len(t, p.toB, 2)
+
+	// "early" doesn't wait for the TSN before it
+	sent := p.toB
+	p.toB = nil
+	assert.NoError(t, b.HandleInbound(sent[1]))
+	assert.Equal(t, []string{"early"}, received)
+	assert.Equal(t, 1, b.payloadQueue.size())
+
+	// The SACK still reports the gap
+	sack := &packet{}
+	assert.NoError(t, sack.unmarshal(p.toA[len(p.toA)-1]))
+	assert.Len(t, sack.chunks[0].(*chunkSelectiveAck).gapAckBlocks, 1)
+
+	// Filling the gap delivers "late" only
+	assert.NoError(t, b.HandleInbound(sent[0]))
+	p.flush(t, a, b)
+	assert.Equal(t, []string{"early", "late"}, received)
+	assert.Equal(t, 0, b.payloadQueue.size())
+	assert.Equal(t, a.myNextTSN-1, b.peerLastTSN)
+}
+
+func TestAssociationStats(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	before := a.Stats()
+	assert.NotZero(t, before.ChunksSent)
+	assert.Equal(t, before.ChunksSent, b.Stats().ChunksReceived)
+	assert.Equal(t, a.cwnd, before.Cwnd)
+	assert.Equal(t, a.ssthresh, before.Ssthresh)
+	assert.Equal(t, b.getMyReceiverWindowCredit(), b.Stats().MyRwnd)
+
+	assert.NoError(t, a.HandleOutbound([]byte("lost"), 1, PayloadTypeWebRTCString))
+	p.toB = nil
+	stats := a.Stats()
+	assert.Equal(t, before.ChunksSent+1, stats.ChunksSent)
+	assert.Equal(t, uint32(4), stats.BytesInFlight)
+	assert.Equal(t, uint64(0), stats.DataRetransmissions)
+
+	// Retransmitted once T3-rtx expires, then acknowledged
+	a.onRetransmissionTimeout(timerT3RTX, 1)
+	p.flush(t, a, b)
+	stats = a.Stats()
+	assert.Equal(t, before.ChunksSent+2, stats.ChunksSent)
+	assert.Equal(t, before.ChunksReceived+1, stats.ChunksReceived)
+	assert.Equal(t, uint64(1), stats.DataRetransmissions)
+	assert.Equal(t, uint32(0), stats.BytesInFlight)
+}
+
+func TestAssociationBufferedAmount(t *testing.T) {
+	p := &pipe{}
+	a, b := newAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	var low []uint16
+	a.SetBufferedAmountLowHandler(func(streamIdentifier uint16) {
+		low = append(low, streamIdentifier)
+	})
+	a.SetBufferedAmountLowThreshold(1, 100)
+
+	assert.NoError(t, a.HandleOutbound(make([]byte, 3000), 1, PayloadTypeWebRTCBinary))
+	assert.NoError(t, a.HandleOutbound(make([]byte, 50), 2, PayloadTypeWebRTCBinary))
+	assert.Equal(t, uint64(3000), a.BufferedAmount(1))
+	assert.Equal(t, uint64(50), a.BufferedAmount(2))
+
+	// Stream 2 falls to its default threshold of zero
+	p.flush(t, a, b)
+	assert.Equal(t, uint64(0), a.BufferedAmount(1))
+	assert.Equal(t, uint64(0), a.BufferedAmount(2))
+	assert.Equal(t, []uint16{1, 2}, low)
+
+	// Staying at or below the threshold doesn't call the handler again
+	low = nil
+	assert.NoError(t, a.HandleOutbound(make([]byte, 100), 1, PayloadTypeWebRTCBinary))
+	p.flush(t, a, b)
+	assert.Empty(t, low)
+}
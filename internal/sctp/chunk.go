@@ -28,6 +28,9 @@ const (
 	COOKIEACK        chunkType = 11
 	CWR              chunkType = 13
 	SHUTDOWNCOMPLETE chunkType = 14
+	RECONFIG         chunkType = 130
+	PAD              chunkType = 132
+	FORWARDTSN       chunkType = 192
 )
 
 func (c chunkType) String() string {
@@ -60,6 +63,12 @@ func (c chunkType) String() string {
 		return "Congestion Window Reduced"
 	case SHUTDOWNCOMPLETE:
 		return "Shutdown Complete"
+	case RECONFIG:
+		return "Re-configuration"
+	case PAD:
+		return "Padding"
+	case FORWARDTSN:
+		return "Forward TSN"
 	default:
 		return fmt.Sprintf("Unknown ChunkType: %d", c)
 	}
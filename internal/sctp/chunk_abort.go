@@ -30,6 +30,10 @@ type chunkAbort struct {
 	errorCauses []errorCause
 }
 
+// reflectedTagBitmask is set when the sender of an ABORT or SHUTDOWN COMPLETE had
+// no TCB and reflected the receiver's Verification Tag instead
+const reflectedTagBitmask = 1
+
 func (a *chunkAbort) unmarshal(raw []byte) error {
 	if err := a.chunkHeader.unmarshal(raw); err != nil {
 		return err
@@ -39,24 +43,29 @@ func (a *chunkAbort) unmarshal(raw []byte) error {
 		return errors.Errorf("ChunkType is not of type ABORT, actually is %s", a.typ.String())
 	}
 
-	offset := chunkHeaderSize
-	for {
-		if len(raw)-offset < 4 {
-			break
-		}
-
-		e, err := buildErrorCause(raw[offset:])
-		if err != nil {
-			return errors.Wrap(err, "Failed build Abort Chunk")
-		}
-
-		offset += int(e.length())
-		a.errorCauses = append(a.errorCauses, e)
+	causes, err := unmarshalErrorCauses(a.raw)
+	if err != nil {
+		return errors.Wrap(err, "Failed build Abort Chunk")
 	}
+	a.errorCauses = causes
+
 	return nil
 }
+
 func (a *chunkAbort) marshal() ([]byte, error) {
-	return nil, errors.Errorf("Unimplemented")
+	out, err := marshalErrorCauses(a.errorCauses)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to marshal error cause for Abort")
+	}
+
+	a.chunkHeader.typ = ABORT
+	a.chunkHeader.raw = out
+	return a.chunkHeader.marshal()
+}
+
+// isReflected reports whether the T bit is set
+func (a *chunkAbort) isReflected() bool {
+	return a.flags&reflectedTagBitmask != 0
 }
 
 func (a *chunkAbort) check() (abort bool, err error) {
@@ -0,0 +1,64 @@
+package sctp
+
+import (
+	"github.com/pkg/errors"
+)
+
+/*
+chunkError represents an SCTP Chunk of type ERROR
+
+An endpoint sends this chunk to its peer endpoint to notify it of
+certain error conditions.  It contains one or more error causes.  An
+Operation Error is not considered fatal in and of itself, but may be
+used with an ABORT chunk to report a fatal condition.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|   Type = 9    | Chunk  Flags  |           Length              |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+\                                                               \
+/                    one or more Error Causes                   /
+\                                                               \
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type chunkError struct {
+	chunkHeader
+	errorCauses []errorCause
+}
+
+func (e *chunkError) unmarshal(raw []byte) error {
+	if err := e.chunkHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	if e.typ != ERROR {
+		return errors.Errorf("ChunkType is not of type ERROR, actually is %s", e.typ.String())
+	}
+
+	causes, err := unmarshalErrorCauses(e.raw)
+	if err != nil {
+		return errors.Wrap(err, "Failed build Error Chunk")
+	}
+	e.errorCauses = causes
+
+	return nil
+}
+
+func (e *chunkError) marshal() ([]byte, error) {
+	out, err := marshalErrorCauses(e.errorCauses)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to marshal error cause for Error")
+	}
+
+	e.chunkHeader.typ = ERROR
+	e.chunkHeader.raw = out
+	return e.chunkHeader.marshal()
+}
+
+func (e *chunkError) check() (abort bool, err error) {
+	if len(e.errorCauses) == 0 {
+		return false, errors.New("ERROR chunk must contain at least one error cause")
+	}
+	return false, nil
+}
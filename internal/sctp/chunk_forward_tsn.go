@@ -0,0 +1,102 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+/*
+chunkForwardTSN represents an SCTP Chunk of type FORWARD TSN, defined in
+https://tools.ietf.org/html/rfc3758#section-3.2
+
+This chunk shall be used by the data sender to inform the data
+receiver to adjust its cumulative received TSN point forward because
+some missing TSNs are associated with data chunks that SHOULD NOT be
+transmitted or retransmitted by the sender.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|   Type = 192  |  Flags = 0x00 |        Length = Variable      |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                      New Cumulative TSN                       |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|         Stream-1              |       Stream Sequence-1       |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+\                                                               /
+/                                                               \
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|         Stream-N              |       Stream Sequence-N       |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type chunkForwardTSN struct {
+	chunkHeader
+
+	// This indicates the new cumulative TSN to the data receiver.  Upon
+	// the reception of this value, the data receiver MUST consider
+	// any missing TSNs earlier than or equal to this value as received,
+	// and stop reporting them as gaps in any subsequent SACKs.
+	newCumulativeTSN uint32
+
+	streams []chunkForwardTSNStream
+}
+
+// chunkForwardTSNStream is the highest Stream Sequence Number of the
+// ordered messages skipped on a stream
+type chunkForwardTSNStream struct {
+	identifier uint16
+	sequence   uint16
+}
+
+const (
+	newCumulativeTSNLength = 4
+	forwardTSNStreamLength = 4
+)
+
+func (c *chunkForwardTSN) unmarshal(raw []byte) error {
+	if err := c.chunkHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	if c.typ != FORWARDTSN {
+		return errors.Errorf("ChunkType is not of type FORWARDTSN, actually is %s", c.typ.String())
+	}
+
+	if len(c.raw) < newCumulativeTSNLength || (len(c.raw)-newCumulativeTSNLength)%forwardTSNStreamLength != 0 {
+		return errors.Errorf("FORWARD TSN Chunk has an invalid value length %d", len(c.raw))
+	}
+
+	c.newCumulativeTSN = binary.BigEndian.Uint32(c.raw[0:])
+
+	c.streams = nil
+	for offset := newCumulativeTSNLength; offset < len(c.raw); offset += forwardTSNStreamLength {
+		c.streams = append(c.streams, chunkForwardTSNStream{
+			identifier: binary.BigEndian.Uint16(c.raw[offset:]),
+			sequence:   binary.BigEndian.Uint16(c.raw[offset+2:]),
+		})
+	}
+
+	return nil
+}
+
+func (c *chunkForwardTSN) marshal() ([]byte, error) {
+	out := make([]byte, newCumulativeTSNLength+forwardTSNStreamLength*len(c.streams))
+	binary.BigEndian.PutUint32(out[0:], c.newCumulativeTSN)
+
+	offset := newCumulativeTSNLength
+	for _, s := range c.streams {
+		binary.BigEndian.PutUint16(out[offset:], s.identifier)
+		binary.BigEndian.PutUint16(out[offset+2:], s.sequence)
+		offset += forwardTSNStreamLength
+	}
+
+	c.chunkHeader.typ = FORWARDTSN
+	c.chunkHeader.flags = 0
+	c.chunkHeader.raw = out
+	return c.chunkHeader.marshal()
+}
+
+func (c *chunkForwardTSN) check() (abort bool, err error) {
+	return false, nil
+}
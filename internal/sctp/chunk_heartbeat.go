@@ -47,16 +47,16 @@ func (h *chunkHeartbeat) unmarshal(raw []byte) error {
 		return errors.Errorf("ChunkType is not of type HEARTBEAT, actually is %s", h.typ.String())
 	}
 
-	if len(raw) <= chunkHeaderSize {
+	if len(h.raw) < paramHeaderLength {
 		return errors.Errorf("Heartbeat is not long enough to contain Heartbeat Info %d", len(raw))
 	}
 
-	pType := paramType(binary.BigEndian.Uint16(raw[chunkHeaderSize:]))
+	pType := paramType(binary.BigEndian.Uint16(h.raw))
 	if pType != heartbeatInfo {
 		return errors.Errorf("Heartbeat should only have HEARTBEAT param, instead have %s", pType.String())
 	}
 
-	p, err := buildParam(pType, raw[chunkHeaderSize:])
+	p, err := buildParam(pType, h.raw)
 	if err != nil {
 		return errors.Wrap(err, "Failed unmarshalling param in Heartbeat Chunk")
 	}
@@ -65,8 +65,19 @@ func (h *chunkHeartbeat) unmarshal(raw []byte) error {
 	return nil
 }
 
-func (h *chunkHeartbeat) Marshal() ([]byte, error) {
-	return nil, errors.Errorf("Unimplemented")
+func (h *chunkHeartbeat) marshal() ([]byte, error) {
+	if len(h.params) != 1 {
+		return nil, errors.Errorf("Heartbeat must have one param")
+	}
+
+	pp, err := h.params[0].marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to marshal parameter for Heartbeat")
+	}
+
+	h.chunkHeader.typ = HEARTBEAT
+	h.chunkHeader.raw = pp
+	return h.chunkHeader.marshal()
 }
 
 func (h *chunkHeartbeat) check() (abort bool, err error) {
@@ -1,6 +1,8 @@
 package sctp
 
 import (
+	"encoding/binary"
+
 	"github.com/pkg/errors"
 )
 
@@ -39,7 +41,28 @@ type chunkHeartbeatAck struct {
 }
 
 func (h *chunkHeartbeatAck) unmarshal(raw []byte) error {
-	return errors.Errorf("Unimplemented")
+	if err := h.chunkHeader.unmarshal(raw); err != nil {
+		return err
+	} else if h.typ != HEARTBEATACK {
+		return errors.Errorf("ChunkType is not of type HEARTBEATACK, actually is %s", h.typ.String())
+	}
+
+	if len(h.raw) < paramHeaderLength {
+		return errors.Errorf("Heartbeat Ack is not long enough to contain Heartbeat Info %d", len(raw))
+	}
+
+	pType := paramType(binary.BigEndian.Uint16(h.raw))
+	if pType != heartbeatInfo {
+		return errors.Errorf("Heartbeat Ack should only have HEARTBEAT param, instead have %s", pType.String())
+	}
+
+	p, err := buildParam(pType, h.raw)
+	if err != nil {
+		return errors.Wrap(err, "Failed unmarshalling param in Heartbeat Ack Chunk")
+	}
+	h.params = append(h.params, p)
+
+	return nil
 }
 
 func (h *chunkHeartbeatAck) marshal() ([]byte, error) {
@@ -116,5 +116,12 @@ func (i *chunkInitAck) check() (abort bool, err error) {
 		return abort, errors.New("INIT ACK Advertised Receiver Window Credit (a_rwnd) must be >= 1500")
 	}
 
+	// The State Cookie is the only mandatory parameter of an INIT ACK,
+	// without it the handshake can't continue
+	if i.stateCookie() == nil {
+		abort = true
+		return abort, errors.New("INIT ACK must contain a State Cookie")
+	}
+
 	return false, nil
 }
@@ -46,6 +46,10 @@ type chunkInitCommon struct {
 	numInboundStreams              uint16
 	initialTSN                     uint32
 	params                         []param
+
+	// Parameters of unrecognized types that asked to be reported, as
+	// complete TLVs
+	unrecognizedParams [][]byte
 }
 
 const (
@@ -80,20 +84,33 @@ func (i *chunkInitCommon) unmarshal(raw []byte) error {
 
 	offset := initChunkMinLength
 	remaining := len(raw) - offset
-	for remaining > 0 {
-		if remaining > initOptionalVarHeaderLength {
-			pType := paramType(binary.BigEndian.Uint16(raw[offset:]))
-			p, err := buildParam(pType, raw[offset:])
-			if err != nil {
+	for remaining >= initOptionalVarHeaderLength {
+		pType := paramType(binary.BigEndian.Uint16(raw[offset:]))
+		p, err := buildParam(pType, raw[offset:])
+		if err == errParamTypeUnhandled {
+			var h paramHeader
+			if err := h.unmarshal(raw[offset:]); err != nil {
 				return errors.Wrap(err, "Failed unmarshalling param in Init Chunk")
 			}
-			i.params = append(i.params, p)
-			padding := getPadding(p.length())
-			offset += p.length() + padding
-			remaining -= p.length() + padding
+
+			action := pType.unrecognizedAction()
+			if action == paramUnrecognizedActionStopAndReport || action == paramUnrecognizedActionSkipAndReport {
+				i.unrecognizedParams = append(i.unrecognizedParams, raw[offset:offset+h.length()])
+			}
+			if action == paramUnrecognizedActionStop || action == paramUnrecognizedActionStopAndReport {
+				break
+			}
+
+			p = &h
+		} else if err != nil {
+			return errors.Wrap(err, "Failed unmarshalling param in Init Chunk")
 		} else {
-			break
+			i.params = append(i.params, p)
 		}
+
+		padding := getPadding(p.length())
+		offset += p.length() + padding
+		remaining -= p.length() + padding
 	}
 
 	return nil
@@ -129,3 +146,57 @@ func (i *chunkInitCommon) marshal() ([]byte, error) {
 
 	return out, nil
 }
+
+// stateCookie returns the State Cookie parameter, if there is one
+func (i *chunkInitCommon) stateCookie() *paramStateCookie {
+	for _, p := range i.params {
+		if c, ok := p.(*paramStateCookie); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// supportsForwardTSN reports whether the sender supports the FORWARD TSN
+// chunk of RFC 3758
+func (i *chunkInitCommon) supportsForwardTSN() bool {
+	for _, p := range i.params {
+		switch p := p.(type) {
+		case *paramForwardTSNSupported:
+			return true
+		case *paramSupportedExtensions:
+			for _, t := range p.ChunkTypes {
+				if t == FORWARDTSN {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// supportsReconfig reports whether the sender supports the RE-CONFIG chunk
+// of RFC 6525
+func (i *chunkInitCommon) supportsReconfig() bool {
+	for _, p := range i.params {
+		if p, ok := p.(*paramSupportedExtensions); ok {
+			for _, t := range p.ChunkTypes {
+				if t == RECONFIG {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// zeroChecksumAcceptable reports whether the sender accepts packets with a
+// zero Checksum, protected by the error detection method edmid instead
+func (i *chunkInitCommon) zeroChecksumAcceptable(edmid errorDetectionMethod) bool {
+	for _, p := range i.params {
+		if p, ok := p.(*paramZeroChecksumAcceptable); ok && p.edmid == edmid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package sctp
+
+import (
+	"github.com/pkg/errors"
+)
+
+/*
+chunkPadding represents an SCTP Chunk of type PAD, defined in
+https://tools.ietf.org/html/rfc4820#section-3
+
+The PAD chunk MAY be used to pad an SCTP packet to an arbitrary
+length.  It is used for path MTU discovery, to make a probe packet
+as large as the path MTU being tested.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+| Type = 0x84   |   Flags=0     |             Length            |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                                                               |
+\                         Padding Data                          /
+/                                                               \
+|                                                               |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type chunkPadding struct {
+	chunkHeader
+
+	// The receiver of the PAD chunk MUST discard this chunk and continue
+	// processing the rest of the chunks in the packet, the Padding Data
+	// is only kept as its length.
+	paddingLength int
+}
+
+func (c *chunkPadding) unmarshal(raw []byte) error {
+	if err := c.chunkHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	if c.typ != PAD {
+		return errors.Errorf("ChunkType is not of type PAD, actually is %s", c.typ.String())
+	}
+
+	c.paddingLength = len(c.raw)
+	return nil
+}
+
+func (c *chunkPadding) marshal() ([]byte, error) {
+	c.chunkHeader.typ = PAD
+	c.chunkHeader.raw = make([]byte, c.paddingLength)
+	return c.chunkHeader.marshal()
+}
+
+func (c *chunkPadding) check() (abort bool, err error) {
+	return false, nil
+}
@@ -3,6 +3,9 @@ package sctp
 import (
 	"encoding/binary"
 	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 /*
@@ -54,6 +57,18 @@ type chunkPayloadData struct {
 	streamSequenceNumber uint16
 	payloadType          PayloadProtocolIdentifier
 	userData             []byte
+
+	// Sender side state, not part of the wire format. acked is set once a
+	// SACK has reported the chunk as received, since and nSent record when
+	// it was last sent and how many times, and missIndicator counts the
+	// SACKs that reported it missing. created is when the user handed over
+	// the message, abandoned is set once it is no longer retransmitted.
+	acked         bool
+	since         time.Time
+	nSent         uint32
+	missIndicator uint32
+	created       time.Time
+	abandoned     bool
 }
 
 const (
@@ -70,11 +85,13 @@ type PayloadProtocolIdentifier uint32
 
 // PayloadProtocolIdentifier enums
 const (
-	PayloadTypeWebRTCDCEP        PayloadProtocolIdentifier = 50
-	PayloadTypeWebRTCString      PayloadProtocolIdentifier = 51
-	PayloadTypeWebRTCBinary      PayloadProtocolIdentifier = 53
-	PayloadTypeWebRTCStringEmpty PayloadProtocolIdentifier = 56
-	PayloadTypeWebRTCBinaryEmpty PayloadProtocolIdentifier = 57
+	PayloadTypeWebRTCDCEP          PayloadProtocolIdentifier = 50
+	PayloadTypeWebRTCString        PayloadProtocolIdentifier = 51
+	PayloadTypeWebRTCStringPartial PayloadProtocolIdentifier = 52 // Deprecated
+	PayloadTypeWebRTCBinary        PayloadProtocolIdentifier = 53
+	PayloadTypeWebRTCBinaryPartial PayloadProtocolIdentifier = 54 // Deprecated
+	PayloadTypeWebRTCStringEmpty   PayloadProtocolIdentifier = 56
+	PayloadTypeWebRTCBinaryEmpty   PayloadProtocolIdentifier = 57
 )
 
 func (p PayloadProtocolIdentifier) String() string {
@@ -83,8 +100,12 @@ func (p PayloadProtocolIdentifier) String() string {
 		return "WebRTC DCEP"
 	case PayloadTypeWebRTCString:
 		return "WebRTC String"
+	case PayloadTypeWebRTCStringPartial:
+		return "WebRTC String (Partial)"
 	case PayloadTypeWebRTCBinary:
 		return "WebRTC Binary"
+	case PayloadTypeWebRTCBinaryPartial:
+		return "WebRTC Binary (Partial)"
 	case PayloadTypeWebRTCStringEmpty:
 		return "WebRTC String (Empty)"
 	case PayloadTypeWebRTCBinaryEmpty:
@@ -99,6 +120,13 @@ func (p *chunkPayloadData) unmarshal(raw []byte) error {
 		return err
 	}
 
+	if p.typ != PAYLOADDATA {
+		return errors.Errorf("ChunkType is not of type PAYLOADDATA, actually is %s", p.typ.String())
+	} else if len(p.raw) < payloadDataHeaderSize {
+		return errors.Errorf("DATA Chunk size is not large enough to contain header (%v remaining, needs %v bytes)",
+			len(p.raw), payloadDataHeaderSize)
+	}
+
 	p.immediateSack = p.flags&payloadDataImmediateSACK != 0
 	p.unordered = p.flags&payloadDataUnorderedBitmask != 0
 	p.beginingFragment = p.flags&payloadDataBeginingFragmentBitmask != 0
@@ -114,7 +142,6 @@ func (p *chunkPayloadData) unmarshal(raw []byte) error {
 }
 
 func (p *chunkPayloadData) marshal() ([]byte, error) {
-
 	payRaw := make([]byte, payloadDataHeaderSize+len(p.userData))
 
 	binary.BigEndian.PutUint32(payRaw[0:], p.tsn)
@@ -125,16 +152,16 @@ func (p *chunkPayloadData) marshal() ([]byte, error) {
 
 	flags := uint8(0)
 	if p.endingFragment {
-		flags = 1
+		flags |= payloadDataEndingFragmentBitmask
 	}
 	if p.beginingFragment {
-		flags |= 1 << 1
+		flags |= payloadDataBeginingFragmentBitmask
 	}
 	if p.unordered {
-		flags |= 1 << 2
+		flags |= payloadDataUnorderedBitmask
 	}
 	if p.immediateSack {
-		flags |= 1 << 3
+		flags |= payloadDataImmediateSACK
 	}
 
 	p.chunkHeader.flags = flags
@@ -144,5 +171,12 @@ func (p *chunkPayloadData) marshal() ([]byte, error) {
 }
 
 func (p *chunkPayloadData) check() (abort bool, err error) {
+	// If an endpoint receives a DATA chunk with no user data (i.e., the
+	// Length field is set to 16), it MUST send an ABORT with error cause
+	// set to "No User Data".
+	if len(p.userData) == 0 {
+		return true, errors.Errorf("DATA chunk with TSN %d has no user data", p.tsn)
+	}
+
 	return false, nil
 }
@@ -0,0 +1,99 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+/*
+chunkReconfig represents an SCTP Chunk of type RE-CONFIG, defined in
+https://tools.ietf.org/html/rfc6525#section-3.1
+
+This chunk is used to request and answer the reset of streams.  It
+carries one or two re-configuration parameters, a request and a
+response may be bundled together.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+| Type = 130    |  Chunk Flags  |      Chunk Length             |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+\                                                               \
+/                  Re-configuration Parameter                   /
+\                                                               \
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+\                                                               \
+/             Re-configuration Parameter (optional)             /
+\                                                               \
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type chunkReconfig struct {
+	chunkHeader
+	paramA param
+	paramB param
+}
+
+func (c *chunkReconfig) unmarshal(raw []byte) error {
+	if err := c.chunkHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	if c.typ != RECONFIG {
+		return errors.Errorf("ChunkType is not of type RECONFIG, actually is %s", c.typ.String())
+	}
+
+	if len(c.raw) < paramHeaderLength {
+		return errors.Errorf("RE-CONFIG is not long enough to contain a parameter %d", len(c.raw))
+	}
+
+	pType := paramType(binary.BigEndian.Uint16(c.raw))
+	p, err := buildParam(pType, c.raw)
+	if err != nil {
+		return errors.Wrap(err, "Failed unmarshalling param in RE-CONFIG Chunk")
+	}
+	c.paramA = p
+
+	offset := p.length() + getPadding(p.length())
+	if len(c.raw) < offset+paramHeaderLength {
+		return nil
+	}
+
+	pType = paramType(binary.BigEndian.Uint16(c.raw[offset:]))
+	p, err = buildParam(pType, c.raw[offset:])
+	if err != nil {
+		return errors.Wrap(err, "Failed unmarshalling param in RE-CONFIG Chunk")
+	}
+	c.paramB = p
+
+	return nil
+}
+
+func (c *chunkReconfig) marshal() ([]byte, error) {
+	out, err := c.paramA.marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to marshal parameter for RE-CONFIG")
+	}
+
+	if c.paramB != nil {
+		// The first parameter is padded when followed by another
+		out = append(out, make([]byte, getPadding(len(out)))...)
+
+		raw, err := c.paramB.marshal()
+		if err != nil {
+			return nil, errors.Wrap(err, "Unable to marshal parameter for RE-CONFIG")
+		}
+		out = append(out, raw...)
+	}
+
+	c.chunkHeader.typ = RECONFIG
+	c.chunkHeader.raw = out
+	return c.chunkHeader.marshal()
+}
+
+func (c *chunkReconfig) check() (abort bool, err error) {
+	if c.paramA == nil {
+		return false, errors.New("RE-CONFIG chunk must carry a re-configuration parameter")
+	}
+	return false, nil
+}
@@ -118,5 +118,19 @@ func (s *chunkSelectiveAck) marshal() ([]byte, error) {
 }
 
 func (s *chunkSelectiveAck) check() (abort bool, err error) {
+	// Gap Ack Blocks are given in ascending order and may not overlap, each
+	// covers the TSNs from Cumulative TSN Ack + Start to Cumulative TSN
+	// Ack + End, so Start is at least 1 and no greater than End
+	var prevEnd uint16
+	for i, g := range s.gapAckBlocks {
+		if g.start == 0 || g.start > g.end {
+			return false, errors.Errorf("SACK Gap Ack Block %d is invalid: start %d end %d", i, g.start, g.end)
+		}
+		if i != 0 && g.start <= prevEnd {
+			return false, errors.Errorf("SACK Gap Ack Block %d overlaps or precedes the previous one", i)
+		}
+		prevEnd = g.end
+	}
+
 	return false, nil
 }
@@ -0,0 +1,47 @@
+package sctp
+
+import (
+	"github.com/pkg/errors"
+)
+
+/*
+chunkShutdownComplete represents an SCTP Chunk of type chunkShutdownComplete
+
+This chunk MUST be used to acknowledge the receipt of the SHUTDOWN
+ACK chunk at the completion of the shutdown process.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|   Type = 14   |Reserved     |T|      Length = 4               |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+
+T bit:  1 bit
+	The T bit is set to 0 if the sender filled in the Verification
+	Tag expected by the peer.  If the Verification Tag is reflected,
+	the T bit MUST be set to 1.
+*/
+type chunkShutdownComplete struct {
+	chunkHeader
+}
+
+func (c *chunkShutdownComplete) unmarshal(raw []byte) error {
+	if err := c.chunkHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	if c.typ != SHUTDOWNCOMPLETE {
+		return errors.Errorf("ChunkType is not of type SHUTDOWNCOMPLETE, actually is %s", c.typ.String())
+	}
+
+	return nil
+}
+
+func (c *chunkShutdownComplete) marshal() ([]byte, error) {
+	c.chunkHeader.typ = SHUTDOWNCOMPLETE
+	return c.chunkHeader.marshal()
+}
+
+func (c *chunkShutdownComplete) check() (abort bool, err error) {
+	return false, nil
+}
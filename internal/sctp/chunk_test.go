@@ -161,3 +161,329 @@ func TestSelectAckChunk(t *testing.T) {
 		t.Error("Failed to cast Chunk -> SelectiveAck")
 	}
 }
+
+func TestPayloadDataRoundTrip(t *testing.T) {
+	d := &chunkPayloadData{
+		unordered:            true,
+		beginingFragment:     true,
+		tsn:                  0xfffffffe,
+		streamIdentifier:     3,
+		streamSequenceNumber: 7,
+		payloadType:          PayloadTypeWebRTCBinary,
+		userData:             []byte{0x01, 0x02, 0x03},
+	}
+
+	raw, err := d.marshal()
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Failed to marshal DATA chunk"))
+	}
+
+	parsed := &chunkPayloadData{}
+	if err := parsed.unmarshal(raw); err != nil {
+		t.Fatal(errors.Wrap(err, "Failed to unmarshal DATA chunk"))
+	}
+
+	assert.Equal(t, parsed.unordered, true)
+	assert.Equal(t, parsed.beginingFragment, true)
+	assert.Equal(t, parsed.endingFragment, false)
+	assert.Equal(t, parsed.immediateSack, false)
+	assert.Equal(t, parsed.tsn, uint32(0xfffffffe))
+	assert.Equal(t, parsed.streamIdentifier, uint16(3))
+	assert.Equal(t, parsed.streamSequenceNumber, uint16(7))
+	assert.Equal(t, parsed.payloadType, PayloadTypeWebRTCBinary)
+	assert.DeepEqual(t, parsed.userData, []byte{0x01, 0x02, 0x03})
+
+	if err := (&chunkPayloadData{}).unmarshal(raw[:chunkHeaderSize+8]); err == nil {
+		t.Error("Unmarshal of a truncated DATA chunk succeeded")
+	}
+
+	if abort, err := (&chunkPayloadData{}).check(); err == nil || !abort {
+		t.Error("DATA chunk with no user data passed check")
+	}
+}
+
+func TestInitOptionalParams(t *testing.T) {
+	init := &chunkInit{}
+	init.initiateTag = 1
+	init.advertisedReceiverWindowCredit = 1500
+	init.numOutboundStreams = 1
+	init.numInboundStreams = 1
+	init.initialTSN = 1
+	init.params = []param{
+		&paramSupportedAddrTypes{addrTypes: []paramType{ipV4Addr, ipV6Addr}},
+		&paramCookiePreservative{lifeSpanIncrement: 1000},
+		// Unrecognized, to be skipped and reported
+		&paramHeader{typ: 0xC0FF, raw: []byte{0x01, 0x02, 0x03}},
+		// Unrecognized, to be skipped silently
+		&paramHeader{typ: 0x80FF, raw: []byte{0x04}},
+		&paramForwardTSNSupported{},
+		// Unrecognized, to stop processing the parameters
+		&paramHeader{typ: 0x00FF},
+		&paramRandom{randomData: []byte{0x05, 0x06, 0x07, 0x08}},
+	}
+
+	raw, err := init.marshal()
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Failed to marshal INIT"))
+	}
+
+	parsed := &chunkInit{}
+	if err := parsed.unmarshal(raw); err != nil {
+		t.Fatal(errors.Wrap(err, "Failed to unmarshal INIT"))
+	}
+
+	assert.Equal(t, len(parsed.params), 3)
+	addrTypes, ok := parsed.params[0].(*paramSupportedAddrTypes)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, addrTypes.addrTypes, []paramType{ipV4Addr, ipV6Addr})
+	preservative, ok := parsed.params[1].(*paramCookiePreservative)
+	assert.Assert(t, ok)
+	assert.Equal(t, preservative.lifeSpanIncrement, uint32(1000))
+	assert.Assert(t, parsed.supportsForwardTSN())
+
+	assert.DeepEqual(t, parsed.unrecognizedParams, [][]byte{{0xC0, 0xFF, 0x00, 0x07, 0x01, 0x02, 0x03}})
+}
+
+func TestInitAckRequiresCookie(t *testing.T) {
+	initAck := &chunkInitAck{}
+	initAck.initiateTag = 1
+	initAck.advertisedReceiverWindowCredit = 1500
+	initAck.numOutboundStreams = 1
+	initAck.numInboundStreams = 1
+
+	if abort, err := initAck.check(); err == nil || !abort {
+		t.Error("INIT ACK without a State Cookie passed check")
+	}
+
+	initAck.params = []param{newRandomStateCookie()}
+	if _, err := initAck.check(); err != nil {
+		t.Error(errors.Wrap(err, "INIT ACK with a State Cookie failed check"))
+	}
+}
+
+func TestSelectiveAckCheck(t *testing.T) {
+	for _, test := range []struct {
+		blocks []gapAckBlock
+		valid  bool
+	}{
+		{nil, true},
+		{[]gapAckBlock{{start: 1, end: 1}, {start: 3, end: 5}}, true},
+		{[]gapAckBlock{{start: 0, end: 1}}, false},
+		{[]gapAckBlock{{start: 4, end: 2}}, false},
+		{[]gapAckBlock{{start: 3, end: 5}, {start: 5, end: 6}}, false},
+	} {
+		s := &chunkSelectiveAck{gapAckBlocks: test.blocks}
+		_, err := s.check()
+		assert.Equal(t, err == nil, test.valid, "%v", test.blocks)
+	}
+}
+
+func TestShutdownRoundTrip(t *testing.T) {
+	s := &chunkShutdown{cumulativeTSNAck: 0x12345678}
+	raw, err := s.marshal()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, raw, []byte{0x07, 0x00, 0x00, 0x08, 0x12, 0x34, 0x56, 0x78})
+
+	parsed := &chunkShutdown{}
+	assert.NilError(t, parsed.unmarshal(raw))
+	assert.Equal(t, parsed.cumulativeTSNAck, uint32(0x12345678))
+
+	if err := parsed.unmarshal([]byte{0x07, 0x00, 0x00, 0x04}); err == nil {
+		t.Error("SHUTDOWN without a Cumulative TSN Ack should fail to unmarshal")
+	}
+
+	raw, err = (&chunkShutdownComplete{}).marshal()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, raw, []byte{0x0e, 0x00, 0x00, 0x04})
+	if err := (&chunkShutdownAck{}).unmarshal(raw); err == nil {
+		t.Error("SHUTDOWN COMPLETE should not unmarshal as a SHUTDOWN ACK")
+	}
+}
+
+func TestAbortRoundTrip(t *testing.T) {
+	abort := &chunkAbort{errorCauses: []errorCause{
+		&errorCauseProtocolViolation{additionalInformation: []byte("bad")},
+		&errorCauseProtocolViolation{additionalInformation: []byte("worse")},
+	}}
+	abort.flags = reflectedTagBitmask
+
+	raw, err := abort.marshal()
+	assert.NilError(t, err)
+
+	parsed := &chunkAbort{}
+	assert.NilError(t, parsed.unmarshal(raw))
+	assert.Equal(t, parsed.isReflected(), true)
+	assert.Equal(t, len(parsed.errorCauses), 2)
+	for i, info := range []string{"bad", "worse"} {
+		pv, ok := parsed.errorCauses[i].(*errorCauseProtocolViolation)
+		assert.Assert(t, ok)
+		assert.Equal(t, string(pv.additionalInformation), info)
+	}
+}
+
+func TestHeartbeatRoundTrip(t *testing.T) {
+	hb := &chunkHeartbeat{params: []param{&paramHeartbeatInfo{heartbeatInformation: []byte{1, 2, 3, 4, 5}}}}
+	raw, err := hb.marshal()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, raw, []byte{0x04, 0x00, 0x00, 0x0d, 0x00, 0x01, 0x00, 0x09, 1, 2, 3, 4, 5})
+
+	parsed := &chunkHeartbeat{}
+	assert.NilError(t, parsed.unmarshal(append(raw, 0, 0, 0)))
+	assert.DeepEqual(t, parsed.params[0].(*paramHeartbeatInfo).heartbeatInformation, []byte{1, 2, 3, 4, 5})
+
+	raw, err = (&chunkHeartbeatAck{params: parsed.params}).marshal()
+	assert.NilError(t, err)
+
+	ack := &chunkHeartbeatAck{}
+	assert.NilError(t, ack.unmarshal(append(raw, 0, 0, 0)))
+	assert.DeepEqual(t, ack.params[0].(*paramHeartbeatInfo).heartbeatInformation, []byte{1, 2, 3, 4, 5})
+}
+
+func TestErrorCauseRoundTrip(t *testing.T) {
+	for _, e := range []errorCause{
+		&errorCauseInvalidStreamIdentifier{streamIdentifier: 7},
+		&errorCauseMissingMandatoryParameter{missingParams: []paramType{stateCookie, supportedExt}},
+		&errorCauseStaleCookie{measureOfStaleness: 1500},
+		&errorCauseOutOfResource{},
+		&errorCauseUnresolvableAddress{unresolvableAddress: []byte{0x00, 0x0b, 0x00, 0x08, 'h', 'o', 's', 't'}},
+		&errorCauseUnrecognizedChunkType{unrecognizedChunk: []byte{0xc0, 0x00, 0x00, 0x04}},
+		&errorCauseInvalidMandatoryParameter{},
+		&errorCauseUnrecognizedParameters{unrecognizedParameters: []byte{0x80, 0x08, 0x00, 0x04}},
+		&errorCauseNoUserData{tsn: 42},
+		&errorCauseCookieReceivedWhileShuttingDown{},
+		&errorCauseRestartWithNewAddresses{newAddresses: []byte{0x00, 0x05, 0x00, 0x08, 10, 0, 0, 1}},
+		&errorCauseUserInitiatedAbort{upperLayerAbortReason: []byte("bye")},
+		&errorCauseProtocolViolation{additionalInformation: []byte("bad")},
+	} {
+		raw, err := e.marshal()
+		assert.NilError(t, err)
+		assert.Equal(t, int(e.length()), len(raw))
+
+		parsed, err := buildErrorCause(raw)
+		assert.NilError(t, err)
+		assert.Equal(t, parsed.errorCauseCode(), e.errorCauseCode())
+
+		reraw, err := parsed.marshal()
+		assert.NilError(t, err)
+		assert.DeepEqual(t, reraw, raw)
+	}
+
+	raw, err := (&errorCauseStaleCookie{measureOfStaleness: 1500}).marshal()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, raw, []byte{0x00, 0x03, 0x00, 0x08, 0x00, 0x00, 0x05, 0xdc})
+
+	raw, err = (&errorCauseMissingMandatoryParameter{missingParams: []paramType{stateCookie}}).marshal()
+	assert.NilError(t, err)
+	parsed, err := buildErrorCause(raw)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, parsed.(*errorCauseMissingMandatoryParameter).missingParams, []paramType{stateCookie})
+
+	// A cause we don't know is kept with its code
+	parsed, err = buildErrorCause([]byte{0x00, 0xff, 0x00, 0x05, 0x01})
+	assert.NilError(t, err)
+	assert.Equal(t, parsed.errorCauseCode(), errorCauseCode(0xff))
+
+	if _, err := buildErrorCause([]byte{0x00, 0x01, 0x00, 0x04}); err == nil {
+		t.Error("Invalid Stream Identifier without a stream identifier should fail to unmarshal")
+	}
+}
+
+func TestErrorChunkRoundTrip(t *testing.T) {
+	c := &chunkError{errorCauses: []errorCause{
+		&errorCauseUnrecognizedChunkType{unrecognizedChunk: []byte{0xc0, 0x00, 0x00, 0x05, 0x01}},
+		&errorCauseNoUserData{tsn: 42},
+	}}
+
+	raw, err := c.marshal()
+	assert.NilError(t, err)
+	assert.Equal(t, chunkType(raw[0]), ERROR)
+
+	parsed := &chunkError{}
+	assert.NilError(t, parsed.unmarshal(raw))
+	abort, err := parsed.check()
+	assert.NilError(t, err)
+	assert.Equal(t, abort, false)
+	assert.Equal(t, len(parsed.errorCauses), 2)
+	assert.DeepEqual(t, parsed.errorCauses[0].(*errorCauseUnrecognizedChunkType).unrecognizedChunk, []byte{0xc0, 0x00, 0x00, 0x05, 0x01})
+	assert.Equal(t, parsed.errorCauses[1].(*errorCauseNoUserData).tsn, uint32(42))
+
+	if _, err := (&chunkError{}).check(); err == nil {
+		t.Error("ERROR chunk without error causes should fail check")
+	}
+}
+
+func TestForwardTSNRoundTrip(t *testing.T) {
+	fwd := &chunkForwardTSN{
+		newCumulativeTSN: 3,
+		streams:          []chunkForwardTSNStream{{identifier: 1, sequence: 2}, {identifier: 4, sequence: 5}},
+	}
+	raw, err := fwd.marshal()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, raw, []byte{0xc0, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00, 0x03, 0x00, 0x01, 0x00, 0x02, 0x00, 0x04, 0x00, 0x05})
+
+	parsed := &chunkForwardTSN{}
+	assert.NilError(t, parsed.unmarshal(raw))
+	assert.Equal(t, parsed.newCumulativeTSN, uint32(3))
+	assert.Equal(t, len(parsed.streams), 2)
+	for i, s := range fwd.streams {
+		assert.Equal(t, parsed.streams[i], s)
+	}
+
+	if err := parsed.unmarshal([]byte{0xc0, 0x00, 0x00, 0x06, 0x00, 0x00}); err == nil {
+		t.Error("FORWARD TSN without a New Cumulative TSN should fail to unmarshal")
+	}
+}
+
+func TestReconfigRoundTrip(t *testing.T) {
+	out := &paramOutgoingResetRequest{
+		reconfigRequestSequenceNumber:  1,
+		reconfigResponseSequenceNumber: 2,
+		senderLastTSN:                  3,
+		streamIdentifiers:              []uint16{4},
+	}
+	resp := &paramReconfigResponse{reconfigResponseSequenceNumber: 5, result: reconfigResultSuccessPerformed}
+	c := &chunkReconfig{paramA: out, paramB: resp}
+
+	raw, err := c.marshal()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, raw, []byte{
+		0x82, 0x00, 0x00, 0x24,
+		0x00, 0x0d, 0x00, 0x12, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x04, 0x00, 0x00,
+		0x00, 0x10, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x01,
+	})
+
+	parsed := &chunkReconfig{}
+	assert.NilError(t, parsed.unmarshal(raw))
+	parsedOut, ok := parsed.paramA.(*paramOutgoingResetRequest)
+	assert.Assert(t, ok)
+	assert.Equal(t, parsedOut.reconfigRequestSequenceNumber, uint32(1))
+	assert.Equal(t, parsedOut.reconfigResponseSequenceNumber, uint32(2))
+	assert.Equal(t, parsedOut.senderLastTSN, uint32(3))
+	assert.DeepEqual(t, parsedOut.streamIdentifiers, []uint16{4})
+	parsedResp, ok := parsed.paramB.(*paramReconfigResponse)
+	assert.Assert(t, ok)
+	assert.Equal(t, parsedResp.reconfigResponseSequenceNumber, uint32(5))
+	assert.Equal(t, parsedResp.result, reconfigResultSuccessPerformed)
+
+	in := &paramIncomingResetRequest{reconfigRequestSequenceNumber: 6, streamIdentifiers: []uint16{7, 8}}
+	raw, err = (&chunkReconfig{paramA: in}).marshal()
+	assert.NilError(t, err)
+	parsed = &chunkReconfig{}
+	assert.NilError(t, parsed.unmarshal(raw))
+	parsedIn, ok := parsed.paramA.(*paramIncomingResetRequest)
+	assert.Assert(t, ok)
+	assert.Equal(t, parsedIn.reconfigRequestSequenceNumber, uint32(6))
+	assert.DeepEqual(t, parsedIn.streamIdentifiers, []uint16{7, 8})
+	assert.Assert(t, parsed.paramB == nil)
+}
+
+func TestPaddingRoundTrip(t *testing.T) {
+	pad := &chunkPadding{paddingLength: 4}
+	raw, err := pad.marshal()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, raw, []byte{0x84, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00})
+
+	parsed := &chunkPadding{}
+	assert.NilError(t, parsed.unmarshal(raw))
+	assert.Equal(t, parsed.paddingLength, 4)
+}
@@ -20,18 +20,44 @@ type errorCause interface {
 
 // buildErrorCause delegates the building of a error cause from raw bytes to the correct structure
 func buildErrorCause(raw []byte) (errorCause, error) {
+	if len(raw) < errorCauseHeaderLength {
+		return nil, errors.Errorf("raw only %d bytes, %d is the minimum length for an error cause", len(raw), errorCauseHeaderLength)
+	}
+
 	var e errorCause
 
 	c := errorCauseCode(binary.BigEndian.Uint16(raw[0:]))
 	switch c {
-	case invalidMandatoryParameter:
-		e = &errorCauseInvalidMandatoryParameter{}
+	case invalidStreamIdentifier:
+		e = &errorCauseInvalidStreamIdentifier{}
+	case missingMandatoryParameter:
+		e = &errorCauseMissingMandatoryParameter{}
+	case staleCookieError:
+		e = &errorCauseStaleCookie{}
+	case outOfResource:
+		e = &errorCauseOutOfResource{}
+	case unresolvableAddress:
+		e = &errorCauseUnresolvableAddress{}
 	case unrecognizedChunkType:
 		e = &errorCauseUnrecognizedChunkType{}
+	case invalidMandatoryParameter:
+		e = &errorCauseInvalidMandatoryParameter{}
+	case unrecognizedParameters:
+		e = &errorCauseUnrecognizedParameters{}
+	case noUserData:
+		e = &errorCauseNoUserData{}
+	case cookieReceivedWhileShuttingDown:
+		e = &errorCauseCookieReceivedWhileShuttingDown{}
+	case restartOfAnAssociationWithNewAddresses:
+		e = &errorCauseRestartWithNewAddresses{}
+	case userInitiatedAbort:
+		e = &errorCauseUserInitiatedAbort{}
 	case protocolViolation:
 		e = &errorCauseProtocolViolation{}
 	default:
-		return nil, errors.Errorf("BuildErrorCause does not handle %s", c.String())
+		// Causes we don't know are kept as they are, only their code can be
+		// reported
+		e = &errorCauseHeader{}
 	}
 
 	if err := e.unmarshal(raw); err != nil {
@@ -40,6 +66,40 @@ func buildErrorCause(raw []byte) (errorCause, error) {
 	return e, nil
 }
 
+// unmarshalErrorCauses parses the error causes of an ERROR or ABORT chunk,
+// which are padded to 4 bytes like parameters
+func unmarshalErrorCauses(raw []byte) ([]errorCause, error) {
+	var causes []errorCause
+	offset := 0
+	for len(raw)-offset >= errorCauseHeaderLength {
+		e, err := buildErrorCause(raw[offset:])
+		if err != nil {
+			return nil, err
+		}
+
+		offset += int(e.length()) + getPadding(int(e.length()))
+		causes = append(causes, e)
+	}
+	return causes, nil
+}
+
+func marshalErrorCauses(causes []errorCause) ([]byte, error) {
+	out := make([]byte, 0)
+	for idx, e := range causes {
+		raw, err := e.marshal()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, raw...)
+
+		// Every error cause but the last is padded
+		if idx != len(causes)-1 {
+			out = append(out, make([]byte, getPadding(len(raw)))...)
+		}
+	}
+	return out, nil
+}
+
 const (
 	invalidStreamIdentifier                errorCauseCode = 1
 	missingMandatoryParameter              errorCauseCode = 2
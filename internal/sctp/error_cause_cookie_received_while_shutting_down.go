@@ -0,0 +1,28 @@
+package sctp
+
+/*
+errorCauseCookieReceivedWhileShuttingDown represents an SCTP error cause
+
+A COOKIE ECHO was received while the endpoint was in the
+SHUTDOWN-ACK-SENT state.  This error is usually returned in an ERROR
+chunk bundled with the retransmitted SHUTDOWN ACK.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=10              |      Cause Length=4          |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type errorCauseCookieReceivedWhileShuttingDown struct {
+	errorCauseHeader
+}
+
+func (e *errorCauseCookieReceivedWhileShuttingDown) marshal() ([]byte, error) {
+	e.code = cookieReceivedWhileShuttingDown
+	e.raw = nil
+	return e.errorCauseHeader.marshal()
+}
+
+func (e *errorCauseCookieReceivedWhileShuttingDown) unmarshal(raw []byte) error {
+	return e.errorCauseHeader.unmarshal(raw)
+}
@@ -18,14 +18,26 @@ const (
 )
 
 func (e *errorCauseHeader) marshal() ([]byte, error) {
-	return nil, errors.Errorf("Unimplemented")
+	e.len = uint16(len(e.raw)) + errorCauseHeaderLength
+	raw := make([]byte, e.len)
+	binary.BigEndian.PutUint16(raw[0:], uint16(e.code))
+	binary.BigEndian.PutUint16(raw[2:], e.len)
+	copy(raw[errorCauseHeaderLength:], e.raw)
+	return raw, nil
 }
 
 func (e *errorCauseHeader) unmarshal(raw []byte) error {
+	if len(raw) < errorCauseHeaderLength {
+		return errors.Errorf("raw only %d bytes, %d is the minimum length for an error cause", len(raw), errorCauseHeaderLength)
+	}
+
 	e.code = errorCauseCode(binary.BigEndian.Uint16(raw[0:]))
 	e.len = binary.BigEndian.Uint16(raw[2:])
-	valueLength := e.len - errorCauseHeaderLength
-	e.raw = raw[errorCauseHeaderLength : errorCauseHeaderLength+valueLength]
+	if e.len < errorCauseHeaderLength || int(e.len) > len(raw) {
+		return errors.Errorf("Error cause length %d is invalid, %d bytes remaining", e.len, len(raw))
+	}
+
+	e.raw = raw[errorCauseHeaderLength:e.len]
 	return nil
 }
 
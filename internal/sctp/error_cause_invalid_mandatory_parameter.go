@@ -1,11 +1,25 @@
 package sctp
 
-// errorCauseInvalidMandatoryParameter represents an SCTP error cause
+/*
+errorCauseInvalidMandatoryParameter represents an SCTP error cause
+
+This error cause is returned to the originator of an INIT or INIT ACK
+chunk when one of the mandatory parameters is set to an invalid
+value.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=7              |      Cause Length=4           |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
 type errorCauseInvalidMandatoryParameter struct {
 	errorCauseHeader
 }
 
 func (e *errorCauseInvalidMandatoryParameter) marshal() ([]byte, error) {
+	e.code = invalidMandatoryParameter
+	e.raw = nil
 	return e.errorCauseHeader.marshal()
 }
 
@@ -0,0 +1,45 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+/*
+errorCauseInvalidStreamIdentifier represents an SCTP error cause
+
+Indicates endpoint received a DATA chunk sent to a nonexistent
+stream.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=1              |      Cause Length=8           |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|        Stream Identifier      |         (Reserved)            |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type errorCauseInvalidStreamIdentifier struct {
+	errorCauseHeader
+	streamIdentifier uint16
+}
+
+func (e *errorCauseInvalidStreamIdentifier) marshal() ([]byte, error) {
+	e.code = invalidStreamIdentifier
+	e.raw = make([]byte, 4)
+	binary.BigEndian.PutUint16(e.raw, e.streamIdentifier)
+	return e.errorCauseHeader.marshal()
+}
+
+func (e *errorCauseInvalidStreamIdentifier) unmarshal(raw []byte) error {
+	if err := e.errorCauseHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	if len(e.raw) != 4 {
+		return errors.Errorf("Invalid Stream Identifier error cause must be 8 bytes, actually is %d", e.len)
+	}
+	e.streamIdentifier = binary.BigEndian.Uint16(e.raw)
+	return nil
+}
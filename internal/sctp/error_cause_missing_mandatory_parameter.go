@@ -0,0 +1,61 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+/*
+errorCauseMissingMandatoryParameter represents an SCTP error cause
+
+Indicates that one or more mandatory TLV parameters are missing in a
+received INIT or INIT ACK.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=2              |      Cause Length=8+N*2       |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                   Number of missing params=N                  |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|   Missing Param Type #1       |   Missing Param Type #2       |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|   Missing Param Type #N-1     |   Missing Param Type #N       |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type errorCauseMissingMandatoryParameter struct {
+	errorCauseHeader
+	missingParams []paramType
+}
+
+func (e *errorCauseMissingMandatoryParameter) marshal() ([]byte, error) {
+	e.code = missingMandatoryParameter
+	e.raw = make([]byte, 4+2*len(e.missingParams))
+	binary.BigEndian.PutUint32(e.raw, uint32(len(e.missingParams)))
+	for i, p := range e.missingParams {
+		binary.BigEndian.PutUint16(e.raw[4+2*i:], uint16(p))
+	}
+	return e.errorCauseHeader.marshal()
+}
+
+func (e *errorCauseMissingMandatoryParameter) unmarshal(raw []byte) error {
+	if err := e.errorCauseHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	if len(e.raw) < 4 {
+		return errors.Errorf("Missing Mandatory Parameter error cause is too short, %d bytes", e.len)
+	}
+
+	n := binary.BigEndian.Uint32(e.raw)
+	if uint64(len(e.raw)) != 4+2*uint64(n) {
+		return errors.Errorf("Missing Mandatory Parameter error cause lists %d parameters in %d bytes", n, len(e.raw)-4)
+	}
+
+	e.missingParams = make([]paramType, n)
+	for i := range e.missingParams {
+		e.missingParams[i] = paramType(binary.BigEndian.Uint16(e.raw[4+2*i:]))
+	}
+	return nil
+}
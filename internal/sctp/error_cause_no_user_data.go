@@ -0,0 +1,45 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+/*
+errorCauseNoUserData represents an SCTP error cause
+
+This error cause is returned to the originator of a DATA chunk if a
+received DATA chunk has no user data.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=9              |      Cause Length=8           |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+/                  TSN value                                    /
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type errorCauseNoUserData struct {
+	errorCauseHeader
+	tsn uint32
+}
+
+func (e *errorCauseNoUserData) marshal() ([]byte, error) {
+	e.code = noUserData
+	e.raw = make([]byte, 4)
+	binary.BigEndian.PutUint32(e.raw, e.tsn)
+	return e.errorCauseHeader.marshal()
+}
+
+func (e *errorCauseNoUserData) unmarshal(raw []byte) error {
+	if err := e.errorCauseHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	if len(e.raw) != 4 {
+		return errors.Errorf("No User Data error cause must be 8 bytes, actually is %d", e.len)
+	}
+	e.tsn = binary.BigEndian.Uint32(e.raw)
+	return nil
+}
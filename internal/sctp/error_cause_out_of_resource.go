@@ -0,0 +1,27 @@
+package sctp
+
+/*
+errorCauseOutOfResource represents an SCTP error cause
+
+Indicates that the sender is out of resource.  This is usually sent
+in combination with or within an ABORT.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=4              |      Cause Length=4           |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type errorCauseOutOfResource struct {
+	errorCauseHeader
+}
+
+func (e *errorCauseOutOfResource) marshal() ([]byte, error) {
+	e.code = outOfResource
+	e.raw = nil
+	return e.errorCauseHeader.marshal()
+}
+
+func (e *errorCauseOutOfResource) unmarshal(raw []byte) error {
+	return e.errorCauseHeader.unmarshal(raw)
+}
@@ -29,6 +29,7 @@ type errorCauseProtocolViolation struct {
 }
 
 func (e *errorCauseProtocolViolation) marshal() ([]byte, error) {
+	e.code = protocolViolation
 	e.raw = e.additionalInformation
 	return e.errorCauseHeader.marshal()
 }
@@ -40,8 +41,10 @@ func (e *errorCauseProtocolViolation) unmarshal(raw []byte) error {
 	}
 
 	e.additionalInformation = e.raw
-
-	fmt.Printf("%v", e.additionalInformation)
-	fmt.Printf("%s", string(e.additionalInformation))
 	return nil
 }
+
+// String makes errorCauseProtocolViolation printable
+func (e *errorCauseProtocolViolation) String() string {
+	return fmt.Sprintf("%s: %s", e.code.String(), string(e.additionalInformation))
+}
@@ -0,0 +1,37 @@
+package sctp
+
+/*
+errorCauseRestartWithNewAddresses represents an SCTP error cause
+
+An INIT was received on an existing association.  But the INIT added
+addresses to the association that were previously NOT part of the
+association.  The new addresses are listed in the error code.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=11             |      Cause Length=Variable    |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+/       TLV(s) containing the new IP address(es)                /
+\                                                               \
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type errorCauseRestartWithNewAddresses struct {
+	errorCauseHeader
+	newAddresses []byte
+}
+
+func (e *errorCauseRestartWithNewAddresses) marshal() ([]byte, error) {
+	e.code = restartOfAnAssociationWithNewAddresses
+	e.raw = e.newAddresses
+	return e.errorCauseHeader.marshal()
+}
+
+func (e *errorCauseRestartWithNewAddresses) unmarshal(raw []byte) error {
+	if err := e.errorCauseHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	e.newAddresses = e.raw
+	return nil
+}
@@ -0,0 +1,44 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+/*
+errorCauseStaleCookie represents an SCTP error cause
+
+Indicates the receipt of a valid State Cookie that has expired.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=3              |       Cause Length=8          |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                 Measure of Staleness (usec.)                  |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type errorCauseStaleCookie struct {
+	errorCauseHeader
+	measureOfStaleness uint32
+}
+
+func (e *errorCauseStaleCookie) marshal() ([]byte, error) {
+	e.code = staleCookieError
+	e.raw = make([]byte, 4)
+	binary.BigEndian.PutUint32(e.raw, e.measureOfStaleness)
+	return e.errorCauseHeader.marshal()
+}
+
+func (e *errorCauseStaleCookie) unmarshal(raw []byte) error {
+	if err := e.errorCauseHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	if len(e.raw) != 4 {
+		return errors.Errorf("Stale Cookie error cause must be 8 bytes, actually is %d", e.len)
+	}
+	e.measureOfStaleness = binary.BigEndian.Uint32(e.raw)
+	return nil
+}
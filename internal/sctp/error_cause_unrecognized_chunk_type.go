@@ -1,14 +1,37 @@
 package sctp
 
-// errorCauseUnrecognizedChunkType represents an SCTP error cause
+/*
+errorCauseUnrecognizedChunkType represents an SCTP error cause
+
+This error cause is returned to the originator of the chunk if the
+receiver does not understand the chunk and the upper bits of the
+'Chunk Type' are set to 01 or 11.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=6              |      Cause Length             |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+/                  Unrecognized Chunk                           /
+\                                                               \
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
 type errorCauseUnrecognizedChunkType struct {
 	errorCauseHeader
+	unrecognizedChunk []byte
 }
 
 func (e *errorCauseUnrecognizedChunkType) marshal() ([]byte, error) {
+	e.code = unrecognizedChunkType
+	e.raw = e.unrecognizedChunk
 	return e.errorCauseHeader.marshal()
 }
 
 func (e *errorCauseUnrecognizedChunkType) unmarshal(raw []byte) error {
-	return e.errorCauseHeader.unmarshal(raw)
+	if err := e.errorCauseHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	e.unrecognizedChunk = e.raw
+	return nil
 }
@@ -0,0 +1,37 @@
+package sctp
+
+/*
+errorCauseUnrecognizedParameters represents an SCTP error cause
+
+This error cause is returned to the originator of the INIT ACK chunk
+if the receiver does not recognize one or more Optional TLV
+parameters in the INIT ACK chunk.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=8              |      Cause Length             |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+/                  Unrecognized Parameters                      /
+\                                                               \
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type errorCauseUnrecognizedParameters struct {
+	errorCauseHeader
+	unrecognizedParameters []byte
+}
+
+func (e *errorCauseUnrecognizedParameters) marshal() ([]byte, error) {
+	e.code = unrecognizedParameters
+	e.raw = e.unrecognizedParameters
+	return e.errorCauseHeader.marshal()
+}
+
+func (e *errorCauseUnrecognizedParameters) unmarshal(raw []byte) error {
+	if err := e.errorCauseHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	e.unrecognizedParameters = e.raw
+	return nil
+}
@@ -0,0 +1,38 @@
+package sctp
+
+/*
+errorCauseUnresolvableAddress represents an SCTP error cause
+
+Indicates that the sender is not able to resolve the specified
+address parameter (e.g., type of address is not supported by the
+sender).  This is usually sent in combination with or within an
+ABORT.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Cause Code=5              |      Cause Length             |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+/                  Unresolvable Address                         /
+\                                                               \
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type errorCauseUnresolvableAddress struct {
+	errorCauseHeader
+	unresolvableAddress []byte
+}
+
+func (e *errorCauseUnresolvableAddress) marshal() ([]byte, error) {
+	e.code = unresolvableAddress
+	e.raw = e.unresolvableAddress
+	return e.errorCauseHeader.marshal()
+}
+
+func (e *errorCauseUnresolvableAddress) unmarshal(raw []byte) error {
+	if err := e.errorCauseHeader.unmarshal(raw); err != nil {
+		return err
+	}
+
+	e.unresolvableAddress = e.raw
+	return nil
+}
@@ -2,6 +2,7 @@ package sctp
 
 import (
 	"encoding/binary"
+	"fmt"
 	"hash/crc32"
 
 	"github.com/pkg/errors"
@@ -46,17 +47,45 @@ type packet struct {
 	destinationPort uint16
 	verificationTag uint32
 	chunks          []chunk
+
+	// https://tools.ietf.org/html/draft-ietf-tsvwg-sctp-zero-checksum-08
+	// When set the Checksum is left zero on marshal instead of holding the
+	// CRC32c, and a zero Checksum is accepted on unmarshal without
+	// verifying the packet.
+	zeroChecksum bool
 }
 
 const (
 	packetHeaderSize = 12
 )
 
+// ChecksumMismatchError is returned when the CRC32c checksum of a packet
+// doesn't match its contents. Such packets are silently discarded, see
+// https://tools.ietf.org/html/rfc4960#section-6.8
+type ChecksumMismatchError struct {
+	Theirs uint32
+	Ours   uint32
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("Checksum mismatch theirs: %d ours: %d", e.Theirs, e.Ours)
+}
+
 func (p *packet) unmarshal(raw []byte) error {
 	if len(raw) < packetHeaderSize {
 		return errors.Errorf("raw only %d bytes, %d is the minimum length for a SCTP packet", len(raw), packetHeaderSize)
 	}
 
+	// The checksum is verified first, no chunk of a corrupted packet is
+	// parsed
+	theirChecksum := binary.LittleEndian.Uint32(raw[8:])
+	if !p.zeroChecksum || theirChecksum != 0 {
+		ourChecksum := generatePacketChecksum(raw)
+		if theirChecksum != ourChecksum {
+			return &ChecksumMismatchError{Theirs: theirChecksum, Ours: ourChecksum}
+		}
+	}
+
 	p.sourcePort = binary.BigEndian.Uint16(raw[0:])
 	p.destinationPort = binary.BigEndian.Uint16(raw[2:])
 	p.verificationTag = binary.BigEndian.Uint32(raw[4:])
@@ -78,14 +107,32 @@ func (p *packet) unmarshal(raw []byte) error {
 			c = &chunkInitAck{}
 		case ABORT:
 			c = &chunkAbort{}
+		case ERROR:
+			c = &chunkError{}
 		case COOKIEECHO:
 			c = &chunkCookieEcho{}
+		case COOKIEACK:
+			c = &chunkCookieAck{}
+		case SHUTDOWN:
+			c = &chunkShutdown{}
+		case SHUTDOWNACK:
+			c = &chunkShutdownAck{}
+		case SHUTDOWNCOMPLETE:
+			c = &chunkShutdownComplete{}
 		case HEARTBEAT:
 			c = &chunkHeartbeat{}
+		case HEARTBEATACK:
+			c = &chunkHeartbeatAck{}
 		case PAYLOADDATA:
 			c = &chunkPayloadData{}
 		case SACK:
 			c = &chunkSelectiveAck{}
+		case FORWARDTSN:
+			c = &chunkForwardTSN{}
+		case RECONFIG:
+			c = &chunkReconfig{}
+		case PAD:
+			c = &chunkPadding{}
 		default:
 			return errors.Errorf("Failed to unmarshal, contains unknown chunk type %s", chunkType(raw[offset]).String())
 		}
@@ -97,23 +144,17 @@ func (p *packet) unmarshal(raw []byte) error {
 		p.chunks = append(p.chunks, c)
 		chunkValuePadding := getPadding(c.valueLength())
 		offset += chunkHeaderSize + c.valueLength() + chunkValuePadding
-	}
-	theirChecksum := binary.LittleEndian.Uint32(raw[8:])
-	ourChecksum := generatePacketChecksum(raw)
-	if theirChecksum != ourChecksum {
-		return errors.Errorf("Checksum mismatch theirs: %d ours: %d", theirChecksum, ourChecksum)
+
+		// The padding of the last chunk may have been left out
+		if offset > len(raw) {
+			break
+		}
 	}
 	return nil
 }
 
 func (p *packet) marshal() ([]byte, error) {
-	raw := make([]byte, packetHeaderSize)
-
-	// Populate static headers
-	// 8-12 is Checksum which will be populated when packet is complete
-	binary.BigEndian.PutUint16(raw[0:], p.sourcePort)
-	binary.BigEndian.PutUint16(raw[2:], p.destinationPort)
-	binary.BigEndian.PutUint32(raw[4:], p.verificationTag)
+	raw := p.marshalHeader()
 
 	// Populate chunks
 	for _, c := range p.chunks {
@@ -121,28 +162,110 @@ func (p *packet) marshal() ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		raw = append(raw, chunkRaw...)
+		raw = appendChunk(raw, chunkRaw)
+	}
+
+	return p.finishPacket(raw), nil
+}
+
+// bundle marshals the chunks of p into as few packets of at most
+// maxPacketSize bytes as possible, following the bundling rules of
+// https://tools.ietf.org/html/rfc4960#section-6.10
+//
+// Control chunks are placed before DATA chunks, and are otherwise kept in
+// order. INIT, INIT ACK and SHUTDOWN COMPLETE are never bundled, and a
+// COOKIE ECHO is always the first chunk of its packet. A chunk too large
+// for maxPacketSize is sent in a packet of its own.
+func (p *packet) bundle(maxPacketSize int) ([][]byte, error) {
+	var control, data []chunk
+	for _, c := range p.chunks {
+		switch c.(type) {
+		case *chunkPayloadData:
+			data = append(data, c)
+		case *chunkCookieEcho:
+			control = append([]chunk{c}, control...)
+		default:
+			control = append(control, c)
+		}
+	}
 
-		paddingNeeded := getPadding(len(raw))
-		if paddingNeeded != 0 {
-			raw = append(raw, make([]byte, paddingNeeded)...)
+	var packets [][]byte
+	var raw []byte
+	flush := func() {
+		if len(raw) > packetHeaderSize {
+			packets = append(packets, p.finishPacket(raw))
 		}
+		raw = p.marshalHeader()
+	}
+	flush()
+
+	for _, c := range append(control, data...) {
+		chunkRaw, err := c.marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		switch c.(type) {
+		case *chunkInit, *chunkInitAck, *chunkShutdownComplete:
+			flush()
+			raw = appendChunk(raw, chunkRaw)
+			flush()
+			continue
+		case *chunkCookieEcho:
+			flush()
+		}
+
+		if len(raw)+len(chunkRaw) > maxPacketSize {
+			flush()
+		}
+		raw = appendChunk(raw, chunkRaw)
+	}
+	flush()
+
+	return packets, nil
+}
+
+// marshalHeader starts a packet with the common header of p, the Checksum
+// is populated once the packet is complete
+func (p *packet) marshalHeader() []byte {
+	raw := make([]byte, packetHeaderSize)
+	binary.BigEndian.PutUint16(raw[0:], p.sourcePort)
+	binary.BigEndian.PutUint16(raw[2:], p.destinationPort)
+	binary.BigEndian.PutUint32(raw[4:], p.verificationTag)
+	return raw
+}
+
+// appendChunk adds a marshaled chunk to a packet, padded to a multiple of
+// four bytes
+func appendChunk(raw, chunkRaw []byte) []byte {
+	raw = append(raw, chunkRaw...)
+
+	paddingNeeded := getPadding(len(raw))
+	if paddingNeeded != 0 {
+		raw = append(raw, make([]byte, paddingNeeded)...)
+	}
+	return raw
+}
+
+// finishPacket populates the Checksum of a complete packet
+func (p *packet) finishPacket(raw []byte) []byte {
+	if p.zeroChecksum {
+		return raw
 	}
 
 	// Checksum is already in BigEndian
 	// Using LittleEndian.PutUint32 stops it from being flipped
 	binary.LittleEndian.PutUint32(raw[8:], generatePacketChecksum(raw))
-	return raw, nil
+	return raw
 }
 
-func generatePacketChecksum(raw []byte) uint32 {
-	rawCopy := make([]byte, len(raw))
-	copy(rawCopy, raw)
-
-	// Clear existing checksum
-	for offset := 8; offset <= 11; offset++ {
-		rawCopy[offset] = 0x00
-	}
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
 
-	return crc32.Checksum(rawCopy, crc32.MakeTable(crc32.Castagnoli))
+// generatePacketChecksum calculates the CRC32c checksum of a packet, defined
+// in https://tools.ietf.org/html/rfc4960#appendix-B. The Checksum field
+// itself is taken as zero.
+func generatePacketChecksum(raw []byte) uint32 {
+	checksum := crc32.Update(0, castagnoliTable, raw[:8])
+	checksum = crc32.Update(checksum, castagnoliTable, []byte{0, 0, 0, 0})
+	return crc32.Update(checksum, castagnoliTable, raw[packetHeaderSize:])
 }
@@ -52,3 +52,117 @@ func TestPacketMarshal(t *testing.T) {
 		t.Error(errors.Errorf("Unmarshal/Marshaled header only packet did not match \nheaderOnly: % 02x \nheaderOnlyMarshaled % 02x", headerOnly, headerOnlyMarshaled))
 	}
 }
+
+func TestPacketChecksumMismatch(t *testing.T) {
+	headerOnly := []byte{0x13, 0x88, 0x13, 0x88, 0x00, 0x00, 0x00, 0x00, 0x06, 0xa9, 0x00, 0xe1}
+	if generatePacketChecksum(headerOnly) != 0xe100a906 {
+		t.Errorf("Checksum of the header only packet should be 0xe100a906, got %#x", generatePacketChecksum(headerOnly))
+	}
+
+	corrupted := append([]byte{}, headerOnly...)
+	corrupted[7] = 0x01
+
+	pkt := &packet{}
+	err := pkt.unmarshal(corrupted)
+	mismatch, ok := err.(*ChecksumMismatchError)
+	if !ok {
+		t.Fatalf("Unmarshal of a corrupted packet should fail with ChecksumMismatchError, got %v", err)
+	}
+	if mismatch.Theirs != 0xe100a906 || mismatch.Ours == mismatch.Theirs {
+		t.Errorf("Unexpected checksums in %v", mismatch)
+	}
+}
+
+func TestPacketBundle(t *testing.T) {
+	data := func(tsn uint32, size int) *chunkPayloadData {
+		return &chunkPayloadData{tsn: tsn, beginingFragment: true, endingFragment: true, userData: make([]byte, size)}
+	}
+	chunkTypes := func(raw []byte) []chunkType {
+		pkt := &packet{}
+		if err := pkt.unmarshal(raw); err != nil {
+			t.Fatal(errors.Wrap(err, "Unmarshal failed for bundled packet"))
+		}
+		var types []chunkType
+		for _, c := range pkt.chunks {
+			switch c.(type) {
+			case *chunkPayloadData:
+				types = append(types, PAYLOADDATA)
+			case *chunkSelectiveAck:
+				types = append(types, SACK)
+			case *chunkCookieEcho:
+				types = append(types, COOKIEECHO)
+			case *chunkShutdown:
+				types = append(types, SHUTDOWN)
+			case *chunkShutdownComplete:
+				types = append(types, SHUTDOWNCOMPLETE)
+			}
+		}
+		return types
+	}
+	equal := func(a, b []chunkType) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// COOKIE ECHO goes first, then the other control chunks, then DATA
+	pkt := &packet{sourcePort: 5000, destinationPort: 5000, chunks: []chunk{
+		data(1, 100), &chunkSelectiveAck{}, &chunkCookieEcho{cookie: []byte{1, 2, 3}}, data(2, 100),
+	}}
+	raws, err := pkt.bundle(1200)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Bundle failed"))
+	} else if len(raws) != 1 {
+		t.Fatalf("Chunks should fit in a single packet, got %d", len(raws))
+	}
+	if types := chunkTypes(raws[0]); !equal(types, []chunkType{COOKIEECHO, SACK, PAYLOADDATA, PAYLOADDATA}) {
+		t.Errorf("Unexpected chunk order %v", types)
+	}
+
+	// SHUTDOWN COMPLETE is never bundled
+	pkt.chunks = []chunk{&chunkSelectiveAck{}, &chunkShutdownComplete{}, &chunkShutdown{}}
+	raws, err = pkt.bundle(1200)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Bundle failed"))
+	} else if len(raws) != 3 {
+		t.Fatalf("SHUTDOWN COMPLETE should be sent on its own, got %d packets", len(raws))
+	}
+	if types := chunkTypes(raws[1]); !equal(types, []chunkType{SHUTDOWNCOMPLETE}) {
+		t.Errorf("Unexpected chunks %v", types)
+	}
+
+	// Packets are split at the size limit, an oversized chunk goes alone
+	pkt.chunks = []chunk{data(1, 500), data(2, 500), data(3, 500), data(4, 2000)}
+	raws, err = pkt.bundle(1100)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Bundle failed"))
+	} else if len(raws) != 3 {
+		t.Fatalf("Chunks should be split over 3 packets, got %d", len(raws))
+	}
+	for i, expected := range []int{2, 1, 1} {
+		if types := chunkTypes(raws[i]); len(types) != expected {
+			t.Errorf("Packet %d should carry %d chunks, got %d", i, expected, len(types))
+		}
+	}
+}
+
+func TestPacketUnmarshalUnpaddedLastChunk(t *testing.T) {
+	pkt := &packet{sourcePort: 5000, destinationPort: 5000, chunks: []chunk{
+		&chunkPayloadData{tsn: 1, beginingFragment: true, endingFragment: true, userData: []byte{0x01}},
+	}}
+	raw, err := pkt.marshal()
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "Marshal failed"))
+	}
+
+	raw = pkt.finishPacket(raw[:len(raw)-3])
+	if err := (&packet{}).unmarshal(raw); err != nil {
+		t.Error(errors.Wrap(err, "Unmarshal failed for packet without padding after the last chunk"))
+	}
+}
@@ -30,8 +30,46 @@ func buildParam(t paramType, rawParam []byte) (param, error) {
 		return (&paramStateCookie{}).unmarshal(rawParam)
 	case heartbeatInfo:
 		return (&paramHeartbeatInfo{}).unmarshal(rawParam)
+	case supportedAddrTypes:
+		return (&paramSupportedAddrTypes{}).unmarshal(rawParam)
+	case cookiePreservative:
+		return (&paramCookiePreservative{}).unmarshal(rawParam)
+	case unrecognizedParam:
+		return (&paramUnrecognized{}).unmarshal(rawParam)
+	case outSSNResetReq:
+		return (&paramOutgoingResetRequest{}).unmarshal(rawParam)
+	case incSSNResetReq:
+		return (&paramIncomingResetRequest{}).unmarshal(rawParam)
+	case reconfigResp:
+		return (&paramReconfigResponse{}).unmarshal(rawParam)
+	case zeroChecksumAccept:
+		return (&paramZeroChecksumAcceptable{}).unmarshal(rawParam)
 	}
-	return nil, errors.Errorf("Unhandled ParamType %v", t)
+	return nil, errParamTypeUnhandled
+}
+
+var errParamTypeUnhandled = errors.New("unhandled ParamType")
+
+// paramUnrecognizedAction is how a parameter of an unrecognized type must
+// be handled, given by the two highest bits of its type
+// https://tools.ietf.org/html/rfc4960#section-3.2.1
+type paramUnrecognizedAction uint16
+
+const (
+	paramUnrecognizedActionMask = 0xC000
+
+	// Stop processing the chunk's parameters
+	paramUnrecognizedActionStop paramUnrecognizedAction = 0x0000
+	// Stop, and report the parameter
+	paramUnrecognizedActionStopAndReport paramUnrecognizedAction = 0x4000
+	// Skip the parameter and continue
+	paramUnrecognizedActionSkip paramUnrecognizedAction = 0x8000
+	// Skip, continue, and report the parameter
+	paramUnrecognizedActionSkipAndReport paramUnrecognizedAction = 0xC000
+)
+
+func (p paramType) unrecognizedAction() paramUnrecognizedAction {
+	return paramUnrecognizedAction(uint16(p) & paramUnrecognizedActionMask)
 }
 
 const (
@@ -49,6 +87,7 @@ const (
 	reconfigResp       paramType = 16    // Re-configuration Response Parameter	[RFC6525]
 	addOutStreamsReq   paramType = 17    // Add Outgoing Streams Request Parameter	[RFC6525]
 	addIncStreamsReq   paramType = 18    // Add Incoming Streams Request Parameter	[RFC6525]
+	zeroChecksumAccept paramType = 32769 // Zero Checksum Acceptable (0x8001)	[draft-ietf-tsvwg-sctp-zero-checksum]
 	random             paramType = 32770 // Random (0x8002)	[RFC4805]
 	chunkList          paramType = 32771 // Chunk List (0x8003)	[RFC4895]
 	reqHMACAlgo        paramType = 32772 // Requested HMAC Algorithm Parameter (0x8004)	[RFC4895]
@@ -93,6 +132,8 @@ func (p paramType) String() string {
 		return "Add Outgoing Streams Request Parameter"
 	case addIncStreamsReq:
 		return "Add Incoming Streams Request Parameter"
+	case zeroChecksumAccept:
+		return "Zero Checksum Acceptable"
 	case random:
 		return "Random"
 	case chunkList:
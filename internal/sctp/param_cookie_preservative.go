@@ -0,0 +1,46 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+/*
+paramCookiePreservative asks the receiver of an INIT for a longer lived
+State Cookie, after a previous one went stale.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|          Type = 9             |          Length = 8           |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|         Suggested Cookie Life-Span Increment (msec.)          |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type paramCookiePreservative struct {
+	paramHeader
+	lifeSpanIncrement uint32
+}
+
+const cookiePreservativeLength = 4
+
+func (c *paramCookiePreservative) marshal() ([]byte, error) {
+	c.typ = cookiePreservative
+	c.raw = make([]byte, cookiePreservativeLength)
+	binary.BigEndian.PutUint32(c.raw, c.lifeSpanIncrement)
+	return c.paramHeader.marshal()
+}
+
+func (c *paramCookiePreservative) unmarshal(raw []byte) (param, error) {
+	if err := c.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
+
+	if len(c.raw) != cookiePreservativeLength {
+		return nil, errors.Errorf("Cookie Preservative length %d, expected %d", len(c.raw), cookiePreservativeLength)
+	}
+	c.lifeSpanIncrement = binary.BigEndian.Uint32(c.raw)
+
+	return c, nil
+}
@@ -11,6 +11,8 @@ func (f *paramForwardTSNSupported) marshal() ([]byte, error) {
 }
 
 func (f *paramForwardTSNSupported) unmarshal(raw []byte) (param, error) {
-	f.paramHeader.unmarshal(raw)
+	if err := f.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
 	return f, nil
 }
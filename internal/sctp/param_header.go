@@ -1,6 +1,10 @@
 package sctp
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
 
 type paramHeader struct {
 	typ paramType
@@ -23,13 +27,20 @@ func (p *paramHeader) marshal() ([]byte, error) {
 	return rawParam, nil
 }
 
-func (p *paramHeader) unmarshal(raw []byte) {
-	paramLengthPlusHeader := binary.BigEndian.Uint16(raw[2:])
-	paramLength := paramLengthPlusHeader - initOptionalVarHeaderLength
+func (p *paramHeader) unmarshal(raw []byte) error {
+	if len(raw) < paramHeaderLength {
+		return errors.Errorf("param only %d bytes, %d is the minimum length", len(raw), paramHeaderLength)
+	}
+
+	paramLengthPlusHeader := int(binary.BigEndian.Uint16(raw[2:]))
+	if paramLengthPlusHeader < paramHeaderLength || paramLengthPlusHeader > len(raw) {
+		return errors.Errorf("param length %d is invalid, %d bytes remain", paramLengthPlusHeader, len(raw))
+	}
 
 	p.typ = paramType(binary.BigEndian.Uint16(raw[0:]))
-	p.raw = raw[paramHeaderLength : paramHeaderLength+paramLength]
-	p.len = int(paramLengthPlusHeader)
+	p.raw = raw[paramHeaderLength:paramLengthPlusHeader]
+	p.len = paramLengthPlusHeader
+	return nil
 }
 
 func (p *paramHeader) length() int {
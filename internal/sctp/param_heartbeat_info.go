@@ -12,7 +12,9 @@ func (h *paramHeartbeatInfo) marshal() ([]byte, error) {
 }
 
 func (h *paramHeartbeatInfo) unmarshal(raw []byte) (param, error) {
-	h.paramHeader.unmarshal(raw)
+	if err := h.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
 	h.heartbeatInformation = h.raw
 	return h, nil
 }
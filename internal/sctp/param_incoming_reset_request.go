@@ -0,0 +1,69 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	paramIncomingResetRequestStreamIdentifiersOffset = 4
+)
+
+/*
+paramIncomingResetRequest represents an Incoming SSN Reset Request
+Parameter, defined in https://tools.ietf.org/html/rfc6525#section-4.2
+
+This parameter is used by the sender to request that the peer reset
+the stream sequence numbers of its outgoing streams, the sender's
+incoming streams.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Parameter Type = 14       |  Parameter Length = 8 + 2 * N |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|          Re-configuration Request Sequence Number             |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|  Stream Number 1 (optional)   |    Stream Number 2 (optional) |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+/                            ......                             /
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|  Stream Number N-1 (optional) |    Stream Number N (optional) |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type paramIncomingResetRequest struct {
+	paramHeader
+	reconfigRequestSequenceNumber uint32
+	streamIdentifiers             []uint16
+}
+
+func (r *paramIncomingResetRequest) marshal() ([]byte, error) {
+	r.typ = incSSNResetReq
+	r.raw = make([]byte, paramIncomingResetRequestStreamIdentifiersOffset+2*len(r.streamIdentifiers))
+	binary.BigEndian.PutUint32(r.raw, r.reconfigRequestSequenceNumber)
+	for i, sID := range r.streamIdentifiers {
+		binary.BigEndian.PutUint16(r.raw[paramIncomingResetRequestStreamIdentifiersOffset+2*i:], sID)
+	}
+	return r.paramHeader.marshal()
+}
+
+func (r *paramIncomingResetRequest) unmarshal(raw []byte) (param, error) {
+	if err := r.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
+
+	if len(r.raw) < paramIncomingResetRequestStreamIdentifiersOffset || len(r.raw)%2 != 0 {
+		return nil, errors.Errorf("Incoming SSN Reset Request Parameter has an invalid length %d", len(r.raw))
+	}
+
+	r.reconfigRequestSequenceNumber = binary.BigEndian.Uint32(r.raw)
+
+	lim := (len(r.raw) - paramIncomingResetRequestStreamIdentifiersOffset) / 2
+	r.streamIdentifiers = make([]uint16, lim)
+	for i := 0; i < lim; i++ {
+		r.streamIdentifiers[i] = binary.BigEndian.Uint16(r.raw[paramIncomingResetRequestStreamIdentifiersOffset+2*i:])
+	}
+
+	return r, nil
+}
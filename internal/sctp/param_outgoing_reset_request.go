@@ -0,0 +1,92 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	paramOutgoingResetRequestStreamIdentifiersOffset = 12
+)
+
+/*
+paramOutgoingResetRequest represents an Outgoing SSN Reset Request
+Parameter, defined in https://tools.ietf.org/html/rfc6525#section-4.1
+
+This parameter is used by the sender to reset the stream sequence
+numbers of its outgoing streams.  The peer resets the corresponding
+incoming streams once all the data up to the Sender's Last Assigned TSN
+has arrived.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Parameter Type = 13       | Parameter Length = 16 + 2 * N |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|           Re-configuration Request Sequence Number            |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|           Re-configuration Response Sequence Number           |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                Sender's Last Assigned TSN                     |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|  Stream Number 1 (optional)   |    Stream Number 2 (optional) |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+/                            ......                             /
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|  Stream Number N-1 (optional) |    Stream Number N (optional) |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type paramOutgoingResetRequest struct {
+	paramHeader
+
+	// reconfigRequestSequenceNumber identifies the request, it is
+	// incremented for each new request
+	reconfigRequestSequenceNumber uint32
+
+	// reconfigResponseSequenceNumber is the request sequence number of the
+	// Incoming SSN Reset Request this request answers, if any
+	reconfigResponseSequenceNumber uint32
+
+	// senderLastTSN is the last TSN the sender assigned, the peer resets
+	// its incoming streams once everything up to it has arrived
+	senderLastTSN uint32
+
+	// streamIdentifiers lists the streams to reset, no streams means all
+	// of them
+	streamIdentifiers []uint16
+}
+
+func (r *paramOutgoingResetRequest) marshal() ([]byte, error) {
+	r.typ = outSSNResetReq
+	r.raw = make([]byte, paramOutgoingResetRequestStreamIdentifiersOffset+2*len(r.streamIdentifiers))
+	binary.BigEndian.PutUint32(r.raw, r.reconfigRequestSequenceNumber)
+	binary.BigEndian.PutUint32(r.raw[4:], r.reconfigResponseSequenceNumber)
+	binary.BigEndian.PutUint32(r.raw[8:], r.senderLastTSN)
+	for i, sID := range r.streamIdentifiers {
+		binary.BigEndian.PutUint16(r.raw[paramOutgoingResetRequestStreamIdentifiersOffset+2*i:], sID)
+	}
+	return r.paramHeader.marshal()
+}
+
+func (r *paramOutgoingResetRequest) unmarshal(raw []byte) (param, error) {
+	if err := r.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
+
+	if len(r.raw) < paramOutgoingResetRequestStreamIdentifiersOffset || len(r.raw)%2 != 0 {
+		return nil, errors.Errorf("Outgoing SSN Reset Request Parameter has an invalid length %d", len(r.raw))
+	}
+
+	r.reconfigRequestSequenceNumber = binary.BigEndian.Uint32(r.raw)
+	r.reconfigResponseSequenceNumber = binary.BigEndian.Uint32(r.raw[4:])
+	r.senderLastTSN = binary.BigEndian.Uint32(r.raw[8:])
+
+	lim := (len(r.raw) - paramOutgoingResetRequestStreamIdentifiersOffset) / 2
+	r.streamIdentifiers = make([]uint16, lim)
+	for i := 0; i < lim; i++ {
+		r.streamIdentifiers[i] = binary.BigEndian.Uint16(r.raw[paramOutgoingResetRequestStreamIdentifiersOffset+2*i:])
+	}
+
+	return r, nil
+}
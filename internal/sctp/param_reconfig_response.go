@@ -0,0 +1,101 @@
+package sctp
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+/*
+paramReconfigResponse represents a Re-configuration Response Parameter,
+defined in https://tools.ietf.org/html/rfc6525#section-4.4
+
+This parameter is used by the receiver of a Re-configuration Request
+Parameter to respond to the request.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|     Parameter Type = 16       |      Parameter Length         |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|         Re-configuration Response Sequence Number             |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                            Result                             |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                   Sender's Next TSN (optional)                |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                  Receiver's Next TSN (optional)               |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+
+The optional TSNs are only used to answer an SSN/TSN Reset Request,
+which is not supported.
+*/
+type paramReconfigResponse struct {
+	paramHeader
+
+	// reconfigResponseSequenceNumber is the request sequence number of the
+	// request being answered
+	reconfigResponseSequenceNumber uint32
+	result                         reconfigResult
+}
+
+const (
+	paramReconfigResponseLength = 8
+)
+
+// reconfigResult is the result of a stream re-configuration request
+type reconfigResult uint32
+
+const (
+	reconfigResultSuccessNOP                    reconfigResult = 0
+	reconfigResultSuccessPerformed              reconfigResult = 1
+	reconfigResultDenied                        reconfigResult = 2
+	reconfigResultErrorWrongSSN                 reconfigResult = 3
+	reconfigResultErrorRequestAlreadyInProgress reconfigResult = 4
+	reconfigResultErrorBadSequenceNumber        reconfigResult = 5
+	reconfigResultInProgress                    reconfigResult = 6
+)
+
+func (r reconfigResult) String() string {
+	switch r {
+	case reconfigResultSuccessNOP:
+		return "Success - Nothing to do"
+	case reconfigResultSuccessPerformed:
+		return "Success - Performed"
+	case reconfigResultDenied:
+		return "Denied"
+	case reconfigResultErrorWrongSSN:
+		return "Error - Wrong SSN"
+	case reconfigResultErrorRequestAlreadyInProgress:
+		return "Error - Request already in progress"
+	case reconfigResultErrorBadSequenceNumber:
+		return "Error - Bad Sequence Number"
+	case reconfigResultInProgress:
+		return "In progress"
+	default:
+		return fmt.Sprintf("Unknown reconfigResult: %d", uint32(r))
+	}
+}
+
+func (r *paramReconfigResponse) marshal() ([]byte, error) {
+	r.typ = reconfigResp
+	r.raw = make([]byte, paramReconfigResponseLength)
+	binary.BigEndian.PutUint32(r.raw, r.reconfigResponseSequenceNumber)
+	binary.BigEndian.PutUint32(r.raw[4:], uint32(r.result))
+	return r.paramHeader.marshal()
+}
+
+func (r *paramReconfigResponse) unmarshal(raw []byte) (param, error) {
+	if err := r.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
+
+	if len(r.raw) < paramReconfigResponseLength {
+		return nil, errors.Errorf("Re-configuration Response Parameter has an invalid length %d", len(r.raw))
+	}
+
+	r.reconfigResponseSequenceNumber = binary.BigEndian.Uint32(r.raw)
+	r.result = reconfigResult(binary.BigEndian.Uint32(r.raw[4:]))
+	return r, nil
+}
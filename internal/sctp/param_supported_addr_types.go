@@ -0,0 +1,51 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+/*
+paramSupportedAddrTypes lists the address types the sender of an INIT
+can use. An endpoint that supports every address type omits it.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|          Type = 12            |          Length               |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|        IP Type #1             |        IP Type #2             |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                            ......                             |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type paramSupportedAddrTypes struct {
+	paramHeader
+	addrTypes []paramType
+}
+
+func (s *paramSupportedAddrTypes) marshal() ([]byte, error) {
+	s.typ = supportedAddrTypes
+	s.raw = make([]byte, 2*len(s.addrTypes))
+	for i, t := range s.addrTypes {
+		binary.BigEndian.PutUint16(s.raw[2*i:], uint16(t))
+	}
+
+	return s.paramHeader.marshal()
+}
+
+func (s *paramSupportedAddrTypes) unmarshal(raw []byte) (param, error) {
+	if err := s.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
+
+	if len(s.raw)%2 != 0 {
+		return nil, errors.Errorf("Supported Address Types length %d is not a multiple of 2", len(s.raw))
+	}
+	for i := 0; i < len(s.raw); i += 2 {
+		s.addrTypes = append(s.addrTypes, paramType(binary.BigEndian.Uint16(s.raw[i:])))
+	}
+
+	return s, nil
+}
@@ -16,7 +16,9 @@ func (s *paramSupportedExtensions) marshal() ([]byte, error) {
 }
 
 func (s *paramSupportedExtensions) unmarshal(raw []byte) (param, error) {
-	s.paramHeader.unmarshal(raw)
+	if err := s.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
 
 	for _, t := range s.raw {
 		s.ChunkTypes = append(s.ChunkTypes, chunkType(t))
@@ -0,0 +1,33 @@
+package sctp
+
+/*
+paramUnrecognized is returned in an INIT ACK to report a parameter of the
+INIT that wasn't understood, and whose type asked for it to be reported.
+Its value is the complete unrecognized parameter.
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|          Type = 8             |          Length               |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|                   Unrecognized Parameter                      |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type paramUnrecognized struct {
+	paramHeader
+	unrecognizedParam []byte
+}
+
+func (u *paramUnrecognized) marshal() ([]byte, error) {
+	u.typ = unrecognizedParam
+	u.raw = u.unrecognizedParam
+	return u.paramHeader.marshal()
+}
+
+func (u *paramUnrecognized) unmarshal(raw []byte) (param, error) {
+	if err := u.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
+	u.unrecognizedParam = u.raw
+	return u, nil
+}
@@ -0,0 +1,55 @@
+package sctp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// errorDetectionMethod identifies what protects packets sent with a zero
+// Checksum from corruption
+type errorDetectionMethod uint32
+
+// errorDetectionMethodDTLS is used when SCTP runs over DTLS, as in WebRTC
+const errorDetectionMethodDTLS errorDetectionMethod = 1
+
+/*
+paramZeroChecksumAcceptable tells the peer it may send packets with a
+zero Checksum instead of the CRC32c, as the packets are protected by
+another error detection method, defined in
+https://tools.ietf.org/html/draft-ietf-tsvwg-sctp-zero-checksum-08#section-4
+
+ 0                   1                   2                   3
+ 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|          Type = 0x8001        |          Length = 8           |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+|           Error Detection Method Identifier (EDMID)           |
++-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+type paramZeroChecksumAcceptable struct {
+	paramHeader
+	edmid errorDetectionMethod
+}
+
+const zeroChecksumAcceptableLength = 4
+
+func (z *paramZeroChecksumAcceptable) marshal() ([]byte, error) {
+	z.typ = zeroChecksumAccept
+	z.raw = make([]byte, zeroChecksumAcceptableLength)
+	binary.BigEndian.PutUint32(z.raw, uint32(z.edmid))
+	return z.paramHeader.marshal()
+}
+
+func (z *paramZeroChecksumAcceptable) unmarshal(raw []byte) (param, error) {
+	if err := z.paramHeader.unmarshal(raw); err != nil {
+		return nil, err
+	}
+
+	if len(z.raw) != zeroChecksumAcceptableLength {
+		return nil, errors.Errorf("Zero Checksum Acceptable length %d, expected %d", len(z.raw), zeroChecksumAcceptableLength)
+	}
+	z.edmid = errorDetectionMethod(binary.BigEndian.Uint32(z.raw))
+
+	return z, nil
+}
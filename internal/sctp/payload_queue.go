@@ -6,7 +6,7 @@ type payloadDataArray []*chunkPayloadData
 
 func (s payloadDataArray) search(tsn uint32) (*chunkPayloadData, bool) {
 	i := sort.Search(len(s), func(i int) bool {
-		return s[i].tsn >= tsn
+		return !sna32LT(s[i].tsn, tsn)
 	})
 
 	if i < len(s) && s[i].tsn == tsn {
@@ -17,7 +17,7 @@ func (s payloadDataArray) search(tsn uint32) (*chunkPayloadData, bool) {
 }
 
 func (s payloadDataArray) sort() {
-	sort.Slice(s, func(i, j int) bool { return s[i].tsn < s[j].tsn })
+	sort.Slice(s, func(i, j int) bool { return sna32LT(s[i].tsn, s[j].tsn) })
 }
 
 type payloadQueue struct {
@@ -30,18 +30,20 @@ func (r *payloadQueue) pushNoCheck(p *chunkPayloadData) {
 	r.orderedPackets.sort()
 }
 
-func (r *payloadQueue) push(p *chunkPayloadData, cumulativeTSN uint32) {
+// push adds a received DATA chunk, and reports whether it is new
+func (r *payloadQueue) push(p *chunkPayloadData, cumulativeTSN uint32) bool {
 	_, ok := r.orderedPackets.search(p.tsn)
 
 	// If the Data payload is already in our queue or older than our cumulativeTSN marker
-	if ok || p.tsn <= cumulativeTSN {
+	if ok || sna32LTE(p.tsn, cumulativeTSN) {
 		// Found the packet, log in dups
 		r.dupTSN = append(r.dupTSN, p.tsn)
-		return
+		return false
 	}
 
 	r.orderedPackets = append(r.orderedPackets, p)
 	r.orderedPackets.sort()
+	return true
 }
 
 func (r *payloadQueue) pop(tsn uint32) (*chunkPayloadData, bool) {
@@ -54,16 +56,45 @@ func (r *payloadQueue) pop(tsn uint32) (*chunkPayloadData, bool) {
 	return nil, false
 }
 
+// first returns the chunk with the lowest TSN
+func (r *payloadQueue) first() (*chunkPayloadData, bool) {
+	if len(r.orderedPackets) == 0 {
+		return nil, false
+	}
+	return r.orderedPackets[0], true
+}
+
+func (r *payloadQueue) size() int {
+	return len(r.orderedPackets)
+}
+
+// getNumBytes returns how many bytes of ordered user data the queue holds,
+// unordered chunks are handed to reassembly as soon as they arrive and
+// are counted there
+func (r *payloadQueue) getNumBytes() int {
+	n := 0
+	for _, p := range r.orderedPackets {
+		if !p.unordered {
+			n += len(p.userData)
+		}
+	}
+	return n
+}
+
 func (r *payloadQueue) get(tsn uint32) (*chunkPayloadData, bool) {
 	return r.orderedPackets.search(tsn)
 }
 
+// popDuplicates returns the TSNs received more than once since it was last
+// called, to be reported in the next SACK
 func (r *payloadQueue) popDuplicates() []uint32 {
 	dups := r.dupTSN
 	r.dupTSN = []uint32{}
 	return dups
 }
 
+// getGapAckBlocks returns the runs of consecutive TSNs received beyond
+// cumulativeTSN, as offsets from it
 func (r *payloadQueue) getGapAckBlocks(cumulativeTSN uint32) (gapAckBlocks []gapAckBlock) {
 	var b gapAckBlock
 
@@ -1,6 +1,7 @@
 package sctp
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,3 +41,17 @@ func TestPayloadQueue_GetGapAckBlocks(t *testing.T) {
 	assert.Equal(t, gab1[1].start, gab2[1].start)
 	assert.Equal(t, gab1[1].end, gab2[1].end)
 }
+
+func TestPayloadQueue_Wraparound(t *testing.T) {
+	pq := &payloadQueue{}
+	pq.push(makePayload(1), math.MaxUint32-1)
+	pq.push(makePayload(math.MaxUint32), math.MaxUint32-1)
+	pq.push(makePayload(math.MaxUint32-1), math.MaxUint32-1)
+
+	assert.Equal(t, []uint32{math.MaxUint32 - 1}, pq.popDuplicates())
+	assert.Equal(t, []gapAckBlock{{start: 1, end: 1}, {start: 3, end: 3}}, pq.getGapAckBlocks(math.MaxUint32-1))
+
+	pd, ok := pq.pop(math.MaxUint32)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(math.MaxUint32), pd.tsn)
+}
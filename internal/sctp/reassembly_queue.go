@@ -2,113 +2,222 @@ package sctp
 
 import "sort"
 
-type dataChannelMessageArray []*dataChannelMessage
+// chunkSet holds the DATA chunks of a single user message, which may be
+// fragmented across several of them
+type chunkSet struct {
+	ssn    uint16
+	ppi    PayloadProtocolIdentifier
+	chunks []*chunkPayloadData
+}
 
-func (s dataChannelMessageArray) search(seqNum uint16) (*dataChannelMessage, bool) {
-	i := sort.Search(len(s), func(i int) bool {
-		return s[i].seqNum >= seqNum
+// push adds a fragment to the set, keeping the fragments in TSN order. A
+// fragment already in the set is ignored.
+func (set *chunkSet) push(c *chunkPayloadData) {
+	i := sort.Search(len(set.chunks), func(i int) bool {
+		return !sna32LT(set.chunks[i].tsn, c.tsn)
 	})
-
-	if i < len(s) && s[i].seqNum == seqNum {
-		return s[i], true
+	if i < len(set.chunks) && set.chunks[i].tsn == c.tsn {
+		return
 	}
 
-	return nil, false
+	set.chunks = append(set.chunks, nil)
+	copy(set.chunks[i+1:], set.chunks[i:])
+	set.chunks[i] = c
+
+	if c.beginingFragment {
+		set.ppi = c.payloadType
+	}
 }
 
-func (s dataChannelMessageArray) sort() {
-	sort.Slice(s, func(i, j int) bool { return s[i].seqNum < s[j].seqNum })
+// isComplete reports whether the set holds every fragment of its message,
+// from the first to the last with no TSN missing between them
+func (set *chunkSet) isComplete() bool {
+	n := len(set.chunks)
+	if n == 0 || !set.chunks[0].beginingFragment || !set.chunks[n-1].endingFragment {
+		return false
+	}
+
+	for i, c := range set.chunks {
+		if c.tsn != set.chunks[0].tsn+uint32(i) {
+			return false
+		}
+		// A complete message has no other beginning or end inside it
+		if (i != 0 && c.beginingFragment) || (i != n-1 && c.endingFragment) {
+			return false
+		}
+	}
+	return true
 }
 
-type dataChannelMessage struct {
-	seqNum        uint16
-	fragmentQueue []*chunkPayloadData
-	length        int
+func (set *chunkSet) assemble() []byte {
+	length := 0
+	for _, c := range set.chunks {
+		length += len(c.userData)
+	}
+
+	b := make([]byte, 0, length)
+	for _, c := range set.chunks {
+		b = append(b, c.userData...)
+	}
+	return b
 }
 
-func (m *dataChannelMessage) complete() bool {
+// reassemblyQueue rebuilds the user messages of a single stream from their
+// fragments. Ordered messages are delivered in stream sequence number
+// order, unordered ones as soon as they are complete.
+type reassemblyQueue struct {
+	// Ordered messages, sorted by stream sequence number
+	ordered []*chunkSet
+	nextSSN uint16
+
+	// Fragments of unordered messages sorted by TSN, as unordered messages
+	// can only be told apart by their TSNs
+	unorderedChunks []*chunkPayloadData
+	unordered       []*chunkSet
+}
 
-	if len(m.fragmentQueue) == 0 {
-		// this should be impossible
-		return false
+func (r *reassemblyQueue) push(c *chunkPayloadData) {
+	if c.unordered {
+		r.pushUnordered(c)
+		return
 	}
 
-	firstPacket := m.fragmentQueue[0]
-	if len(m.fragmentQueue) == 1 {
-		return firstPacket.beginingFragment && firstPacket.endingFragment
+	// The message was already delivered
+	if sna16LT(c.streamSequenceNumber, r.nextSSN) {
+		return
 	}
 
-	lastPacket := m.fragmentQueue[len(m.fragmentQueue)-1]
-	return firstPacket.beginingFragment && lastPacket.endingFragment
+	i := sort.Search(len(r.ordered), func(i int) bool {
+		return !sna16LT(r.ordered[i].ssn, c.streamSequenceNumber)
+	})
+	if i == len(r.ordered) || r.ordered[i].ssn != c.streamSequenceNumber {
+		r.ordered = append(r.ordered, nil)
+		copy(r.ordered[i+1:], r.ordered[i:])
+		r.ordered[i] = &chunkSet{ssn: c.streamSequenceNumber}
+	}
 
+	r.ordered[i].push(c)
 }
 
-func (m *dataChannelMessage) clear() {
-	m.length = 0
-	m.fragmentQueue = []*chunkPayloadData{}
-}
+func (r *reassemblyQueue) pushUnordered(c *chunkPayloadData) {
+	// Unfragmented messages need no reassembly
+	if c.beginingFragment && c.endingFragment {
+		r.unordered = append(r.unordered, &chunkSet{
+			ppi:    c.payloadType,
+			chunks: []*chunkPayloadData{c},
+		})
+		return
+	}
 
-func (m *dataChannelMessage) assemble() ([]byte, bool) {
-	if m.complete() {
-		b := make([]byte, m.length)
-		i := 0
-		for _, p := range m.fragmentQueue {
-			copy(b[i:], p.userData)
-			i += len(p.userData)
+	i := sort.Search(len(r.unorderedChunks), func(i int) bool {
+		return !sna32LT(r.unorderedChunks[i].tsn, c.tsn)
+	})
+	if i < len(r.unorderedChunks) && r.unorderedChunks[i].tsn == c.tsn {
+		return
+	}
+	r.unorderedChunks = append(r.unorderedChunks, nil)
+	copy(r.unorderedChunks[i+1:], r.unorderedChunks[i:])
+	r.unorderedChunks[i] = c
+
+	// Look for a run of consecutive TSNs from a beginning to an ending
+	// fragment
+	start := -1
+	for j, u := range r.unorderedChunks {
+		switch {
+		case u.beginingFragment:
+			start = j
+		case start < 0:
+			continue
+		case u.tsn != r.unorderedChunks[j-1].tsn+1:
+			start = -1
+			continue
 		}
 
-		return b, true
+		if start >= 0 && u.endingFragment {
+			set := &chunkSet{}
+			for _, f := range r.unorderedChunks[start : j+1] {
+				set.push(f)
+			}
+			r.unordered = append(r.unordered, set)
+			r.unorderedChunks = append(r.unorderedChunks[:start], r.unorderedChunks[j+1:]...)
+			return
+		}
 	}
-
-	return nil, false
 }
 
-type reassemblyQueue struct {
-	messageQueue     dataChannelMessageArray
-	unorderedMessage dataChannelMessage
-	expectedSeqNum   uint16
-}
+// pop returns the next user message ready for delivery and its payload
+// protocol identifier
+func (r *reassemblyQueue) pop() ([]byte, PayloadProtocolIdentifier, bool) {
+	if len(r.unordered) > 0 {
+		set := r.unordered[0]
+		r.unordered = r.unordered[1:]
+		return set.assemble(), set.ppi, true
+	}
 
-func (r *reassemblyQueue) push(p *chunkPayloadData) {
-	if p.unordered {
-		r.unorderedMessage.fragmentQueue = append(r.unorderedMessage.fragmentQueue, p)
-		r.unorderedMessage.length += len(p.userData)
-		return
+	if len(r.ordered) == 0 {
+		return nil, 0, false
 	}
 
-	m, ok := r.messageQueue.search(p.streamSequenceNumber)
-	if !ok {
-		m = &dataChannelMessage{seqNum: p.streamSequenceNumber}
-		r.messageQueue = append(r.messageQueue, m)
-		r.messageQueue.sort()
+	// Messages before nextSSN are only left once the sender has skipped
+	// past them, and are delivered as soon as they are complete
+	set := r.ordered[0]
+	if sna16GT(set.ssn, r.nextSSN) || !set.isComplete() {
+		return nil, 0, false
 	}
 
-	m.fragmentQueue = append(m.fragmentQueue, p)
-	m.length += len(p.userData)
+	r.ordered = r.ordered[1:]
+	if set.ssn == r.nextSSN {
+		r.nextSSN++
+	}
+	return set.assemble(), set.ppi, true
 }
 
-func (r *reassemblyQueue) pop() ([]byte, bool) {
-
-	b, ok := r.unorderedMessage.assemble()
-	if ok {
-		r.unorderedMessage.clear()
-		return b, true
-	}
-
-	// Is there any chance that if the message was in the queue, it wouldn't be
-	// the first message in the queue?
-	if len(r.messageQueue) > 0 {
-		m := r.messageQueue[0]
-		// Most likely to be true
-		if m.seqNum == r.expectedSeqNum {
-			b, ok := m.assemble()
-			if ok {
-				r.messageQueue = r.messageQueue[1:]
-				r.expectedSeqNum++
-				return b, true
-			}
+// forwardTSNForOrdered skips the ordered messages up to and including
+// lastSSN, which the sender has abandoned. Their fragments received so far
+// are dropped, unless the message is complete.
+func (r *reassemblyQueue) forwardTSNForOrdered(lastSSN uint16) {
+	kept := r.ordered[:0]
+	for _, set := range r.ordered {
+		if sna16LTE(set.ssn, lastSSN) && !set.isComplete() {
+			continue
+		}
+		kept = append(kept, set)
+	}
+	r.ordered = kept
+
+	if !sna16LT(lastSSN, r.nextSSN) {
+		r.nextSSN = lastSSN + 1
+	}
+}
+
+// forwardTSNForUnordered drops the fragments of unordered messages up to
+// and including newCumulativeTSN, as they can no longer be completed
+func (r *reassemblyQueue) forwardTSNForUnordered(newCumulativeTSN uint32) {
+	kept := r.unorderedChunks[:0]
+	for _, c := range r.unorderedChunks {
+		if sna32GT(c.tsn, newCumulativeTSN) {
+			kept = append(kept, c)
+		}
+	}
+	r.unorderedChunks = kept
+}
 
+// getNumBytes returns how many bytes of user data wait for reassembly or
+// delivery
+func (r *reassemblyQueue) getNumBytes() int {
+	n := 0
+	for _, set := range r.ordered {
+		for _, c := range set.chunks {
+			n += len(c.userData)
 		}
 	}
-	return nil, false
+	for _, set := range r.unordered {
+		for _, c := range set.chunks {
+			n += len(c.userData)
+		}
+	}
+	for _, c := range r.unorderedChunks {
+		n += len(c.userData)
+	}
+	return n
 }
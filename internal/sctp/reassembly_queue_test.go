@@ -14,7 +14,7 @@ func TestReassemblyQueue_push(t *testing.T) {
 	r.push(&chunkPayloadData{tsn: 3, streamSequenceNumber: 0, userData: []byte{2}})
 	r.push(&chunkPayloadData{endingFragment: true, tsn: 4, streamSequenceNumber: 0, userData: []byte{3}})
 
-	b, ok := r.pop()
+	b, _, ok := r.pop()
 	if ok {
 		assert.DeepEqual(t, b, []byte{0, 1, 2, 3})
 	} else {
@@ -30,14 +30,14 @@ func TestReassemblyQueue_push(t *testing.T) {
 	r.push(&chunkPayloadData{tsn: 3, streamSequenceNumber: 1, userData: []byte{2}})
 	r.push(&chunkPayloadData{endingFragment: true, tsn: 4, streamSequenceNumber: 1, userData: []byte{3}})
 
-	b, ok = r.pop()
+	b, _, ok = r.pop()
 	if ok {
 		assert.DeepEqual(t, b, []byte{0, 1})
 	} else {
 		t.Error("Unable to assemble unordered message")
 	}
 
-	b, ok = r.pop()
+	b, _, ok = r.pop()
 	if ok {
 		assert.DeepEqual(t, b, []byte{0, 1, 2, 3})
 	} else {
@@ -55,3 +55,100 @@ func TestReassemblyQueue_clear(t *testing.T) {
 	r.push(&chunkPayloadData{endingFragment: true, tsn: 4, streamSequenceNumber: 0, userData: []byte{3}})
 
 }
+
+func TestReassemblyQueue_outOfOrder(t *testing.T) {
+	r := &reassemblyQueue{}
+
+	// The second message is complete first, but must wait for the first
+	r.push(&chunkPayloadData{beginingFragment: true, endingFragment: true, tsn: 3, streamSequenceNumber: 1, userData: []byte{2}, payloadType: PayloadTypeWebRTCString})
+	r.push(&chunkPayloadData{endingFragment: true, tsn: 2, streamSequenceNumber: 0, userData: []byte{1}})
+	if _, _, ok := r.pop(); ok {
+		t.Error("Message delivered before the one preceding it")
+	}
+
+	r.push(&chunkPayloadData{beginingFragment: true, tsn: 1, streamSequenceNumber: 0, userData: []byte{0}, payloadType: PayloadTypeWebRTCBinary})
+	// A retransmitted fragment is ignored
+	r.push(&chunkPayloadData{beginingFragment: true, tsn: 1, streamSequenceNumber: 0, userData: []byte{0}, payloadType: PayloadTypeWebRTCBinary})
+
+	b, ppi, ok := r.pop()
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, b, []byte{0, 1})
+	assert.Equal(t, ppi, PayloadTypeWebRTCBinary)
+
+	b, ppi, ok = r.pop()
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, b, []byte{2})
+	assert.Equal(t, ppi, PayloadTypeWebRTCString)
+
+	// Messages already delivered are not delivered again
+	r.push(&chunkPayloadData{beginingFragment: true, endingFragment: true, tsn: 3, streamSequenceNumber: 1, userData: []byte{2}})
+	_, _, ok = r.pop()
+	assert.Assert(t, !ok)
+}
+
+func TestReassemblyQueue_unorderedInterleaved(t *testing.T) {
+	r := &reassemblyQueue{}
+
+	// Fragments of two unordered messages, arriving out of order
+	r.push(&chunkPayloadData{unordered: true, endingFragment: true, tsn: 12, userData: []byte{5}})
+	r.push(&chunkPayloadData{unordered: true, beginingFragment: true, tsn: 10, userData: []byte{3}})
+	r.push(&chunkPayloadData{unordered: true, endingFragment: true, tsn: 6, userData: []byte{1}})
+	_, _, ok := r.pop()
+	assert.Assert(t, !ok)
+
+	r.push(&chunkPayloadData{unordered: true, tsn: 11, userData: []byte{4}})
+	b, _, ok := r.pop()
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, b, []byte{3, 4, 5})
+
+	r.push(&chunkPayloadData{unordered: true, beginingFragment: true, tsn: 5, userData: []byte{0}})
+	b, _, ok = r.pop()
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, b, []byte{0, 1})
+}
+
+func TestReassemblyQueue_ssnWrap(t *testing.T) {
+	r := &reassemblyQueue{nextSSN: 65535}
+
+	r.push(&chunkPayloadData{beginingFragment: true, endingFragment: true, tsn: 2, streamSequenceNumber: 0, userData: []byte{1}})
+	r.push(&chunkPayloadData{beginingFragment: true, endingFragment: true, tsn: 1, streamSequenceNumber: 65535, userData: []byte{0}})
+
+	b, _, ok := r.pop()
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, b, []byte{0})
+
+	b, _, ok = r.pop()
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, b, []byte{1})
+}
+
+func TestReassemblyQueue_forwardTSN(t *testing.T) {
+	r := &reassemblyQueue{}
+
+	// ssn 0 is incomplete and ssn 1 complete when the sender skips past
+	// ssn 1, ssn 2 is not skipped
+	r.push(&chunkPayloadData{beginingFragment: true, tsn: 1, streamSequenceNumber: 0, userData: []byte{0}})
+	r.push(&chunkPayloadData{beginingFragment: true, endingFragment: true, tsn: 4, streamSequenceNumber: 1, userData: []byte{1}})
+	r.push(&chunkPayloadData{beginingFragment: true, tsn: 5, streamSequenceNumber: 2, userData: []byte{2}})
+	r.push(&chunkPayloadData{unordered: true, beginingFragment: true, tsn: 3, userData: []byte{3}})
+
+	_, _, ok := r.pop()
+	assert.Assert(t, !ok)
+
+	r.forwardTSNForOrdered(1)
+	r.forwardTSNForUnordered(4)
+	assert.Equal(t, len(r.unorderedChunks), 0)
+
+	b, _, ok := r.pop()
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, b, []byte{1})
+
+	_, _, ok = r.pop()
+	assert.Assert(t, !ok)
+
+	r.push(&chunkPayloadData{endingFragment: true, tsn: 6, streamSequenceNumber: 2, userData: []byte{2}})
+	b, _, ok = r.pop()
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, b, []byte{2, 2})
+	assert.Equal(t, r.nextSSN, uint16(3))
+}
@@ -0,0 +1,173 @@
+package sctp
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RTO.Initial, RTO.Min and RTO.Max in milliseconds, and the retransmission
+// limits, as recommended by https://tools.ietf.org/html/rfc4960#section-15
+const (
+	rtoInitial      float64 = 3.0 * 1000
+	rtoMin          float64 = 1.0 * 1000
+	rtoMax          float64 = 60.0 * 1000
+	maxInitRetrans  uint    = 8
+	maxAssocRetrans uint    = 10
+)
+
+// RTO.Alpha and RTO.Beta, the weights of new measurements in SRTT and
+// RTTVAR
+const (
+	rtoAlpha float64 = 0.125
+	rtoBeta  float64 = 0.25
+)
+
+// rtoManager calculates the retransmission timeout from round-trip time
+// measurements, as described in https://tools.ietf.org/html/rfc4960#section-6.3.1
+type rtoManager struct {
+	srtt   float64
+	rttvar float64
+	rto    float64
+}
+
+func newRTOManager() *rtoManager {
+	return &rtoManager{
+		rto: rtoInitial,
+	}
+}
+
+// setNewRTT updates SRTT, RTTVAR and the RTO with a new measurement in
+// milliseconds, and returns SRTT
+func (m *rtoManager) setNewRTT(rtt float64) float64 {
+	if m.srtt == 0 {
+		// C2) When the first RTT measurement R is made, set
+		// SRTT <- R, RTTVAR <- R/2
+		m.srtt = rtt
+		m.rttvar = rtt / 2
+	} else {
+		// C3) When a new RTT measurement R' is made, set
+		// RTTVAR <- (1 - RTO.Beta) * RTTVAR + RTO.Beta * |SRTT - R'|
+		// SRTT <- (1 - RTO.Alpha) * SRTT + RTO.Alpha * R'
+		m.rttvar = (1-rtoBeta)*m.rttvar + rtoBeta*math.Abs(m.srtt-rtt)
+		m.srtt = (1-rtoAlpha)*m.srtt + rtoAlpha*rtt
+	}
+
+	// RTO <- SRTT + 4 * RTTVAR, kept between RTO.Min and RTO.Max
+	m.rto = math.Min(math.Max(m.srtt+4*m.rttvar, rtoMin), rtoMax)
+	return m.srtt
+}
+
+// getRTO returns the current retransmission timeout in milliseconds
+func (m *rtoManager) getRTO() float64 {
+	return m.rto
+}
+
+// rtxTimerObserver is told when a retransmission timer expires
+type rtxTimerObserver interface {
+	// onRetransmissionTimeout is called each time the timer expires, with
+	// how many times it has so far
+	onRetransmissionTimeout(timerID int, nRtos uint)
+
+	// onRetransmissionFailure is called when the timer expires once more
+	// than its retransmission limit allows, after which it stops
+	onRetransmissionFailure(timerID int)
+}
+
+// rtxTimer is a retransmission timer. Once started it expires after the
+// RTO, then again after twice as long, and so on up to rtoMax, until it is
+// stopped or its retransmission limit is reached.
+type rtxTimer struct {
+	id         int
+	observer   rtxTimerObserver
+	maxRetrans uint // 0 means no limit
+
+	mutex      sync.Mutex
+	timer      *time.Timer
+	generation uint64
+	rto        float64
+	nRtos      uint
+}
+
+func newRTXTimer(id int, observer rtxTimerObserver, maxRetrans uint) *rtxTimer {
+	return &rtxTimer{
+		id:         id,
+		observer:   observer,
+		maxRetrans: maxRetrans,
+	}
+}
+
+// calculateNextTimeout returns the RTO backed off after nRtos expiries
+func calculateNextTimeout(rto float64, nRtos uint) float64 {
+	// Beyond this the timeout has long been capped
+	if nRtos > 31 {
+		return rtoMax
+	}
+	return math.Min(rto*float64(uint64(1)<<nRtos), rtoMax)
+}
+
+// start runs the timer with the given RTO in milliseconds, unless it is
+// already running, and reports whether it was started
+func (t *rtxTimer) start(rto float64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.timer != nil {
+		return false
+	}
+
+	t.rto = rto
+	t.nRtos = 0
+	t.schedule()
+	return true
+}
+
+// schedule arms the timer for its next expiry. The caller holds the mutex.
+func (t *rtxTimer) schedule() {
+	t.generation++
+	generation := t.generation
+	timeout := time.Duration(calculateNextTimeout(t.rto, t.nRtos) * float64(time.Millisecond))
+	t.timer = time.AfterFunc(timeout, func() { t.expire(generation) })
+}
+
+func (t *rtxTimer) expire(generation uint64) {
+	t.mutex.Lock()
+	// Stopped or restarted since
+	if generation != t.generation || t.timer == nil {
+		t.mutex.Unlock()
+		return
+	}
+
+	t.nRtos++
+	if t.maxRetrans == 0 || t.nRtos <= t.maxRetrans {
+		nRtos := t.nRtos
+		t.schedule()
+		t.mutex.Unlock()
+		t.observer.onRetransmissionTimeout(t.id, nRtos)
+		return
+	}
+
+	t.timer = nil
+	t.mutex.Unlock()
+	t.observer.onRetransmissionFailure(t.id)
+}
+
+// stop stops the timer, if it is running
+func (t *rtxTimer) stop() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.generation++
+}
+
+// isRunning reports whether the timer has been started and not stopped
+func (t *rtxTimer) isRunning() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.timer != nil
+}
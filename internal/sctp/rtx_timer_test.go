@@ -0,0 +1,90 @@
+package sctp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTimerObserver struct {
+	timeouts chan uint
+	failures chan int
+}
+
+func (o *testTimerObserver) onRetransmissionTimeout(timerID int, nRtos uint) {
+	o.timeouts <- nRtos
+}
+
+func (o *testTimerObserver) onRetransmissionFailure(timerID int) {
+	o.failures <- timerID
+}
+
+func TestCalculateNextTimeout(t *testing.T) {
+	assert.Equal(t, rtoInitial, calculateNextTimeout(rtoInitial, 0))
+	assert.Equal(t, 2*rtoInitial, calculateNextTimeout(rtoInitial, 1))
+	assert.Equal(t, 8*rtoInitial, calculateNextTimeout(rtoInitial, 3))
+	assert.Equal(t, rtoMax, calculateNextTimeout(rtoInitial, 5))
+	assert.Equal(t, rtoMax, calculateNextTimeout(rtoInitial, 100))
+}
+
+func TestRTXTimer(t *testing.T) {
+	o := &testTimerObserver{timeouts: make(chan uint, 8), failures: make(chan int, 1)}
+	timer := newRTXTimer(7, o, 2)
+
+	assert.True(t, timer.start(5))
+	assert.False(t, timer.start(5), "start should not restart a running timer")
+
+	for _, expected := range []uint{1, 2} {
+		select {
+		case nRtos := <-o.timeouts:
+			assert.Equal(t, expected, nRtos)
+		case <-time.After(time.Second):
+			t.Fatal("Timer did not expire")
+		}
+	}
+
+	select {
+	case id := <-o.failures:
+		assert.Equal(t, 7, id)
+	case <-time.After(time.Second):
+		t.Fatal("Timer did not fail")
+	}
+	assert.False(t, timer.isRunning())
+}
+
+func TestRTXTimerStop(t *testing.T) {
+	o := &testTimerObserver{timeouts: make(chan uint, 8), failures: make(chan int, 1)}
+	timer := newRTXTimer(0, o, 0)
+
+	assert.True(t, timer.start(20))
+	assert.True(t, timer.isRunning())
+	timer.stop()
+	assert.False(t, timer.isRunning())
+
+	select {
+	case <-o.timeouts:
+		t.Fatal("Stopped timer expired")
+	case <-time.After(60 * time.Millisecond):
+	}
+}
+
+func TestRTOManager(t *testing.T) {
+	m := newRTOManager()
+	assert.Equal(t, rtoInitial, m.getRTO())
+
+	// SRTT 600, RTTVAR 300, RTO 600 + 4*300
+	assert.Equal(t, 600.0, m.setNewRTT(600))
+	assert.Equal(t, 1800.0, m.getRTO())
+
+	// RTTVAR 0.75*300 + 0.25*|600-200| = 325, SRTT 0.875*600 + 0.125*200 = 550
+	assert.Equal(t, 550.0, m.setNewRTT(200))
+	assert.Equal(t, 550.0+4*325, m.getRTO())
+
+	// Never below RTO.Min or above RTO.Max
+	m = newRTOManager()
+	m.setNewRTT(10)
+	assert.Equal(t, rtoMin, m.getRTO())
+	m.setNewRTT(100000)
+	assert.Equal(t, rtoMax, m.getRTO())
+}
@@ -0,0 +1,158 @@
+package sctp
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// acceptQueueSize is how many streams opened by the peer may wait for
+// AcceptStream, the messages of any further ones are dropped
+const acceptQueueSize = 16
+
+// streamMessage is a user message received on a Stream
+type streamMessage struct {
+	data []byte
+	ppi  PayloadProtocolIdentifier
+}
+
+// Stream represents an SCTP stream of an Association. Reads and writes
+// carry whole user messages, as data channels require. Unlike
+// HandleOutbound, the Stream API locks the Association itself.
+type Stream struct {
+	association        *Association
+	streamIdentifier   uint16
+	defaultPayloadType PayloadProtocolIdentifier
+
+	lock     sync.Mutex
+	notify   *sync.Cond
+	messages []streamMessage
+	readErr  error
+}
+
+func newStream(a *Association, streamIdentifier uint16, defaultPayloadType PayloadProtocolIdentifier) *Stream {
+	s := &Stream{
+		association:        a,
+		streamIdentifier:   streamIdentifier,
+		defaultPayloadType: defaultPayloadType,
+	}
+	s.notify = sync.NewCond(&s.lock)
+	return s
+}
+
+// StreamIdentifier returns the Stream identifier of the stream
+func (s *Stream) StreamIdentifier() uint16 {
+	return s.streamIdentifier
+}
+
+// SetDefaultPayloadType sets the payload protocol identifier Write sends
+// messages with
+func (s *Stream) SetDefaultPayloadType(defaultPayloadType PayloadProtocolIdentifier) {
+	s.association.Lock()
+	defer s.association.Unlock()
+
+	s.defaultPayloadType = defaultPayloadType
+}
+
+// SetReliabilityParams sets how messages written to the stream are
+// delivered, see Association.SetReliabilityParams
+func (s *Stream) SetReliabilityParams(unordered bool, relType ReliabilityType, relVal uint32) {
+	s.association.Lock()
+	defer s.association.Unlock()
+
+	s.association.SetReliabilityParams(s.streamIdentifier, unordered, relType, relVal)
+}
+
+// Read reads the next user message into p, see ReadSCTP
+func (s *Stream) Read(p []byte) (int, error) {
+	n, _, err := s.ReadSCTP(p)
+	return n, err
+}
+
+// ReadSCTP blocks until a user message arrives, reads it into p and
+// returns its payload protocol identifier. A message larger than p is
+// left in place and io.ErrShortBuffer returned. Once the stream is closed
+// io.EOF is returned.
+func (s *Stream) ReadSCTP(p []byte) (int, PayloadProtocolIdentifier, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for len(s.messages) == 0 {
+		if s.readErr != nil {
+			return 0, 0, s.readErr
+		}
+		s.notify.Wait()
+	}
+
+	m := s.messages[0]
+	if len(p) < len(m.data) {
+		return 0, 0, io.ErrShortBuffer
+	}
+	s.messages = s.messages[1:]
+	return copy(p, m.data), m.ppi, nil
+}
+
+// Write sends p as a single user message with the default payload
+// protocol identifier
+func (s *Stream) Write(p []byte) (int, error) {
+	s.association.Lock()
+	ppi := s.defaultPayloadType
+	s.association.Unlock()
+
+	return s.WriteSCTP(p, ppi)
+}
+
+// WriteSCTP sends p as a single user message with the given payload
+// protocol identifier
+func (s *Stream) WriteSCTP(p []byte, ppi PayloadProtocolIdentifier) (int, error) {
+	s.association.Lock()
+	defer s.association.Unlock()
+
+	if _, ok := s.association.streams[s.streamIdentifier]; !ok {
+		return 0, errors.Errorf("Stream %d is closed", s.streamIdentifier)
+	}
+
+	if err := s.association.HandleOutbound(p, s.streamIdentifier, ppi); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the stream, resetting it if the peer supports stream
+// reconfiguration. Messages received before are still read.
+func (s *Stream) Close() error {
+	s.association.Lock()
+	defer s.association.Unlock()
+
+	a := s.association
+	if a.streams[s.streamIdentifier] != s {
+		return nil
+	}
+	delete(a.streams, s.streamIdentifier)
+	s.close()
+
+	if a.state == Established && a.useReconfig {
+		return a.ResetStream(s.streamIdentifier)
+	}
+	return nil
+}
+
+// push queues a message received for the stream
+func (s *Stream) push(data []byte, ppi PayloadProtocolIdentifier) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.messages = append(s.messages, streamMessage{data: data, ppi: ppi})
+	s.notify.Signal()
+}
+
+// close wakes up the readers, they get io.EOF once the messages left have
+// been read
+func (s *Stream) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.readErr = io.EOF
+	s.notify.Broadcast()
+}
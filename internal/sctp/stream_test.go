@@ -0,0 +1,86 @@
+package sctp
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStreamAssociationPair(p *pipe) (a, b *Association) {
+	a = NewAssocation(func(raw []byte) { p.toB = append(p.toB, raw) }, nil)
+	b = NewAssocation(func(raw []byte) { p.toA = append(p.toA, raw) }, nil)
+	return a, b
+}
+
+func TestStream(t *testing.T) {
+	p := &pipe{}
+	a, b := newStreamAssociationPair(p)
+	defer a.Close()
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	sa, err := a.OpenStream(1, PayloadTypeWebRTCBinary)
+	assert.NoError(t, err)
+	_, err = a.OpenStream(1, PayloadTypeWebRTCBinary)
+	assert.Error(t, err, "a stream can only be opened once")
+
+	n, err := sa.Write([]byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	p.flush(t, a, b)
+
+	sb, err := b.AcceptStream()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(1), sb.StreamIdentifier())
+
+	buf := make([]byte, 16)
+	_, _, err = sb.ReadSCTP(buf[:2])
+	assert.Equal(t, io.ErrShortBuffer, err)
+	n, ppi, err := sb.ReadSCTP(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(buf[:n]))
+	assert.Equal(t, PayloadTypeWebRTCBinary, ppi)
+
+	// A blocked reader is woken up by the next message
+	done := make(chan string)
+	go func() {
+		rbuf := make([]byte, 16)
+		n, _ := sa.Read(rbuf)
+		done <- string(rbuf[:n])
+	}()
+	_, err = sb.WriteSCTP([]byte("world"), PayloadTypeWebRTCString)
+	assert.NoError(t, err)
+	p.flush(t, a, b)
+	assert.Equal(t, "world", <-done)
+
+	// Closing resets the stream, the peer's reader sees the end of it
+	assert.NoError(t, sa.Close())
+	p.flush(t, a, b)
+	_, err = sb.Read(buf)
+	assert.Equal(t, io.EOF, err)
+	_, err = sa.Write([]byte("closed"))
+	assert.Error(t, err)
+}
+
+func TestStreamAssociationClose(t *testing.T) {
+	p := &pipe{}
+	a, b := newStreamAssociationPair(p)
+	defer b.Close()
+
+	assert.NoError(t, a.Connect())
+	p.flush(t, a, b)
+
+	s, err := a.OpenStream(2, PayloadTypeWebRTCString)
+	assert.NoError(t, err)
+
+	assert.NoError(t, a.Close())
+	_, err = s.Read(make([]byte, 16))
+	assert.Equal(t, io.EOF, err)
+	_, err = a.AcceptStream()
+	assert.Equal(t, io.EOF, err)
+	_, err = a.OpenStream(3, PayloadTypeWebRTCString)
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package sdp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Codecs returns the codecs of the media description from its rtpmap and
+// fmtp attributes, in the order of its formats, the one it prefers first
+func (d *MediaDescription) Codecs() ([]Codec, error) {
+	codecs := make(map[uint8]Codec)
+	for _, a := range d.Attributes {
+		attr := *a.String()
+		switch {
+		case strings.HasPrefix(attr, "rtpmap:"):
+			codec, err := parseRtpmap(attr[len("rtpmap:"):])
+			if err != nil {
+				return nil, err
+			}
+			codec.Fmtp = codecs[codec.PayloadType].Fmtp
+			codecs[codec.PayloadType] = codec
+		case strings.HasPrefix(attr, "fmtp:"):
+			payloadType, fmtp, err := parseFmtp(attr[len("fmtp:"):])
+			if err != nil {
+				return nil, err
+			}
+			codec := codecs[payloadType]
+			codec.PayloadType = payloadType
+			codec.Fmtp = fmtp
+			codecs[payloadType] = codec
+		}
+	}
+
+	var ordered []Codec
+	for _, format := range d.MediaName.Formats {
+		payloadType, err := strconv.ParseUint(format, 10, 8)
+		if err != nil {
+			return nil, errors.Errorf("sdp: invalid payload type `%v`", format)
+		}
+		// Formats without an rtpmap are static payload types, they are not
+		// used by WebRTC
+		if codec, ok := codecs[uint8(payloadType)]; ok && codec.Name != "" {
+			ordered = append(ordered, codec)
+		}
+	}
+	return ordered, nil
+}
+
+// parseRtpmap parses the value of an rtpmap attribute
+// a=rtpmap:<payload type> <encoding name>/<clock rate> [/<encoding parameters>]
+func parseRtpmap(rtpmap string) (Codec, error) {
+	split := strings.SplitN(rtpmap, " ", 2)
+	if len(split) != 2 {
+		return Codec{}, errors.Errorf("sdp: invalid rtpmap `%v`", rtpmap)
+	}
+	payloadType, err := strconv.ParseUint(split[0], 10, 8)
+	if err != nil {
+		return Codec{}, errors.Errorf("sdp: invalid rtpmap payload type `%v`", split[0])
+	}
+
+	encoding := strings.Split(split[1], "/")
+	if len(encoding) < 2 || len(encoding) > 3 {
+		return Codec{}, errors.Errorf("sdp: invalid rtpmap encoding `%v`", split[1])
+	}
+	clockRate, err := strconv.ParseUint(encoding[1], 10, 32)
+	if err != nil {
+		return Codec{}, errors.Errorf("sdp: invalid rtpmap clock rate `%v`", encoding[1])
+	}
+
+	codec := Codec{
+		PayloadType: uint8(payloadType),
+		Name:        encoding[0],
+		ClockRate:   uint32(clockRate),
+	}
+	if len(encoding) == 3 {
+		codec.EncodingParameters = encoding[2]
+	}
+	return codec, nil
+}
+
+// parseFmtp parses the value of an fmtp attribute
+// a=fmtp:<format> <format specific parameters>
+func parseFmtp(fmtp string) (uint8, string, error) {
+	split := strings.SplitN(fmtp, " ", 2)
+	if len(split) != 2 {
+		return 0, "", errors.Errorf("sdp: invalid fmtp `%v`", fmtp)
+	}
+	payloadType, err := strconv.ParseUint(split[0], 10, 8)
+	if err != nil {
+		return 0, "", errors.Errorf("sdp: invalid fmtp payload type `%v`", split[0])
+	}
+	return uint8(payloadType), split[1], nil
+}
+
+// FmtpParameters splits the format specific parameters of an fmtp
+// attribute, the names of the parameters are lower cased as they are case
+// insensitive
+func FmtpParameters(fmtp string) map[string]string {
+	parameters := make(map[string]string)
+	for _, parameter := range strings.Split(fmtp, ";") {
+		split := strings.SplitN(strings.TrimSpace(parameter), "=", 2)
+		if split[0] == "" {
+			continue
+		}
+		value := ""
+		if len(split) == 2 {
+			value = split[1]
+		}
+		parameters[strings.ToLower(split[0])] = value
+	}
+	return parameters
+}
+
+// fmtpValue returns the value of a format specific parameter, or its
+// default when it is not given
+func fmtpValue(parameters map[string]string, name, defaultValue string) string {
+	if value, ok := parameters[name]; ok {
+		return value
+	}
+	return defaultValue
+}
+
+// Matches reports whether c and other can be negotiated as the same codec,
+// their payload types don't matter as each side picks its own
+func (c Codec) Matches(other Codec) bool {
+	// https://tools.ietf.org/html/rfc4855#section-3
+	if !strings.EqualFold(c.Name, other.Name) || c.ClockRate != other.ClockRate {
+		return false
+	}
+
+	if c.channels() != other.channels() {
+		return false
+	}
+
+	switch strings.ToLower(c.Name) {
+	case "h264":
+		return h264Matches(FmtpParameters(c.Fmtp), FmtpParameters(other.Fmtp))
+	case "vp9":
+		// https://tools.ietf.org/html/draft-ietf-payload-vp9-06#section-6
+		return fmtpValue(FmtpParameters(c.Fmtp), "profile-id", "0") ==
+			fmtpValue(FmtpParameters(other.Fmtp), "profile-id", "0")
+	default:
+		// The parameters of the other codecs, like minptime, stereo or
+		// useinbandfec of Opus, tell what a receiver prefers. They don't
+		// have to agree.
+		// https://tools.ietf.org/html/rfc7587#section-7
+		return true
+	}
+}
+
+// channels returns the encoding parameters of an audio codec, it has one
+// channel unless told otherwise
+// https://tools.ietf.org/html/rfc4566#section-6
+func (c Codec) channels() string {
+	if c.EncodingParameters == "" {
+		return "1"
+	}
+	return c.EncodingParameters
+}
+
+// h264Matches reports whether the parameters of two H.264 codecs are for
+// the same packetization mode and profile, levels may differ as each
+// side tells the one it can receive
+// https://tools.ietf.org/html/rfc6184#section-8.2.2
+func h264Matches(a, b map[string]string) bool {
+	if fmtpValue(a, "packetization-mode", "0") != fmtpValue(b, "packetization-mode", "0") {
+		return false
+	}
+
+	profileA := fmtpValue(a, "profile-level-id", "420010")
+	profileB := fmtpValue(b, "profile-level-id", "420010")
+	if len(profileA) != 6 || len(profileB) != 6 {
+		return false
+	}
+	// profile_idc and profile-iop, the last byte is level_idc
+	return strings.EqualFold(profileA[:4], profileB[:4])
+}
@@ -0,0 +1,87 @@
+package sdp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMediaDescriptionCodecs(t *testing.T) {
+	d := &MediaDescription{
+		MediaName: MediaName{Formats: []string{"111", "0", "96"}},
+	}
+	d.WithValueAttribute("fmtp", "111 minptime=10;useinbandfec=1")
+	d.WithValueAttribute("rtpmap", "111 opus/48000/2")
+	d.WithValueAttribute("rtpmap", "96 VP8/90000")
+
+	codecs, err := d.Codecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Codec{
+		{PayloadType: 111, Name: "opus", ClockRate: 48000, EncodingParameters: "2", Fmtp: "minptime=10;useinbandfec=1"},
+		{PayloadType: 96, Name: "VP8", ClockRate: 90000},
+	}
+	if !reflect.DeepEqual(codecs, expected) {
+		t.Errorf("Codecs %v do not match %v", codecs, expected)
+	}
+
+	for _, rtpmap := range []string{"111", "x opus/48000", "111 opus", "111 opus/rate"} {
+		d := &MediaDescription{MediaName: MediaName{Formats: []string{"111"}}}
+		d.WithValueAttribute("rtpmap", rtpmap)
+		if _, err := d.Codecs(); err == nil {
+			t.Errorf("Invalid rtpmap %s was parsed", rtpmap)
+		}
+	}
+}
+
+func TestFmtpParameters(t *testing.T) {
+	parameters := FmtpParameters("Profile-Level-Id=42e01f; packetization-mode=1;;flag")
+	expected := map[string]string{
+		"profile-level-id":   "42e01f",
+		"packetization-mode": "1",
+		"flag":               "",
+	}
+	if !reflect.DeepEqual(parameters, expected) {
+		t.Errorf("Parameters %v do not match %v", parameters, expected)
+	}
+}
+
+func TestCodecMatches(t *testing.T) {
+	h264 := func(fmtp string) Codec {
+		return Codec{Name: "H264", ClockRate: 90000, Fmtp: fmtp}
+	}
+
+	testCases := []struct {
+		a, b    Codec
+		matches bool
+	}{
+		// Names are case insensitive, payload types and Opus parameters
+		// don't matter
+		{Codec{PayloadType: 111, Name: "opus", ClockRate: 48000, EncodingParameters: "2", Fmtp: "minptime=10"},
+			Codec{PayloadType: 109, Name: "OPUS", ClockRate: 48000, EncodingParameters: "2", Fmtp: "stereo=1"}, true},
+		{Codec{Name: "opus", ClockRate: 48000, EncodingParameters: "2"},
+			Codec{Name: "opus", ClockRate: 48000}, false},
+		{Codec{Name: "PCMU", ClockRate: 8000, EncodingParameters: "1"},
+			Codec{Name: "PCMU", ClockRate: 8000}, true},
+		{Codec{Name: "VP8", ClockRate: 90000}, Codec{Name: "VP8", ClockRate: 48000}, false},
+		{Codec{Name: "VP8", ClockRate: 90000}, Codec{Name: "VP9", ClockRate: 90000}, false},
+
+		// H.264 has to agree on the profile and packetization mode, not the
+		// level
+		{h264("packetization-mode=1;profile-level-id=42001f"), h264("profile-level-id=42001F;packetization-mode=1"), true},
+		{h264("packetization-mode=1;profile-level-id=42001f"), h264("packetization-mode=1;profile-level-id=420032"), true},
+		{h264("packetization-mode=1;profile-level-id=42001f"), h264("packetization-mode=0;profile-level-id=42001f"), false},
+		{h264("packetization-mode=1;profile-level-id=42001f"), h264("packetization-mode=1;profile-level-id=42e01f"), false},
+		{h264("packetization-mode=1;profile-level-id=42001f"), h264("packetization-mode=1;profile-level-id=64001f"), false},
+		{h264(""), h264("profile-level-id=42000b"), true},
+		{h264("profile-level-id=42"), h264("profile-level-id=42"), false},
+
+		{Codec{Name: "VP9", ClockRate: 90000}, Codec{Name: "VP9", ClockRate: 90000, Fmtp: "profile-id=0"}, true},
+		{Codec{Name: "VP9", ClockRate: 90000}, Codec{Name: "VP9", ClockRate: 90000, Fmtp: "profile-id=2"}, false},
+	}
+	for i, testCase := range testCases {
+		if testCase.a.Matches(testCase.b) != testCase.matches || testCase.b.Matches(testCase.a) != testCase.matches {
+			t.Errorf("testCase: %d %v and %v match should be %v", i, testCase.a, testCase.b, testCase.matches)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package sdp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AttrKeyExtMap is the key of the a=extmap attribute
+const AttrKeyExtMap = "extmap"
+
+// ExtMap is an a=extmap attribute, the ID an RTP header extension is sent
+// with in the session
+// https://tools.ietf.org/html/rfc8285#section-8
+type ExtMap struct {
+	ID int
+
+	// Direction is sendonly, recvonly, sendrecv or inactive. It is empty
+	// when not given, which is sendrecv.
+	Direction string
+
+	URI string
+
+	// ExtensionAttributes are the parameters of the extension, if any
+	ExtensionAttributes string
+}
+
+// String returns the value of the a=extmap attribute
+func (e ExtMap) String() string {
+	value := strconv.Itoa(e.ID)
+	if e.Direction != "" {
+		value += "/" + e.Direction
+	}
+	value += " " + e.URI
+	if e.ExtensionAttributes != "" {
+		value += " " + e.ExtensionAttributes
+	}
+	return value
+}
+
+// parseExtMap parses the value of an a=extmap attribute
+// a=extmap:<value>["/"<direction>] <URI> <extensionattributes>
+func parseExtMap(raw string) (ExtMap, error) {
+	split := strings.SplitN(raw, " ", 3)
+	if len(split) < 2 || split[1] == "" {
+		return ExtMap{}, errors.Errorf("sdp: invalid extmap `%v`", raw)
+	}
+
+	e := ExtMap{URI: split[1]}
+	value := strings.SplitN(split[0], "/", 2)
+	id, err := strconv.Atoi(value[0])
+	// 4096-4351 are the IDs an offer may use while their direction is
+	// negotiated
+	if err != nil || id < 1 || (id > 255 && (id < 4096 || id > 4351)) {
+		return ExtMap{}, errors.Errorf("sdp: invalid extmap id `%v`", value[0])
+	}
+	e.ID = id
+
+	if len(value) == 2 {
+		switch value[1] {
+		case "sendonly", "recvonly", "sendrecv", "inactive":
+			e.Direction = value[1]
+		default:
+			return ExtMap{}, errors.Errorf("sdp: invalid extmap direction `%v`", value[1])
+		}
+	}
+	if len(split) == 3 {
+		e.ExtensionAttributes = split[2]
+	}
+	return e, nil
+}
+
+// ExtMaps returns the a=extmap attributes of the media description
+func (d *MediaDescription) ExtMaps() ([]ExtMap, error) {
+	var extMaps []ExtMap
+	for _, a := range d.Attributes {
+		if !strings.HasPrefix(*a.String(), AttrKeyExtMap+":") {
+			continue
+		}
+		e, err := parseExtMap((*a.String())[len(AttrKeyExtMap+":"):])
+		if err != nil {
+			return nil, err
+		}
+		extMaps = append(extMaps, e)
+	}
+	return extMaps, nil
+}
+
+// WithExtMap adds an a=extmap attribute to the media description
+func (d *MediaDescription) WithExtMap(e ExtMap) *MediaDescription {
+	return d.WithValueAttribute(AttrKeyExtMap, e.String())
+}
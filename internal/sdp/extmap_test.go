@@ -0,0 +1,36 @@
+package sdp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtMap(t *testing.T) {
+	d := &MediaDescription{}
+	d.WithValueAttribute(AttrKeyExtMap, "1 urn:ietf:params:rtp-hdrext:ssrc-audio-level vad=on")
+	d.WithValueAttribute(AttrKeyExtMap, "4096/recvonly urn:ietf:params:rtp-hdrext:sdes:mid")
+
+	extMaps, err := d.ExtMaps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ExtMap{
+		{ID: 1, URI: "urn:ietf:params:rtp-hdrext:ssrc-audio-level", ExtensionAttributes: "vad=on"},
+		{ID: 4096, Direction: "recvonly", URI: "urn:ietf:params:rtp-hdrext:sdes:mid"},
+	}
+	if !reflect.DeepEqual(extMaps, expected) {
+		t.Errorf("ExtMaps %v do not match %v", extMaps, expected)
+	}
+
+	for i, e := range extMaps {
+		if raw := e.String(); raw != (*d.Attributes[i].String())[len(AttrKeyExtMap+":"):] {
+			t.Errorf("ExtMap marshaled as %s", raw)
+		}
+	}
+
+	for _, raw := range []string{"1", "0 urn:x", "256 urn:x", "x urn:x", "1/both urn:x"} {
+		if _, err := parseExtMap(raw); err == nil {
+			t.Errorf("Invalid extmap %s was parsed", raw)
+		}
+	}
+}
@@ -8,8 +8,11 @@ import (
 	"github.com/pions/webrtc/pkg/ice"
 )
 
-// ICECandidateUnmarshal takes a candidate strings and returns a ice.Candidate or nil if it fails to parse
+// ICECandidateUnmarshal takes a candidate strings and returns a ice.Candidate or nil if it fails to parse,
+// the a=candidate: prefix of the attribute is optional
+// https://tools.ietf.org/html/rfc8445#section-15.1
 func ICECandidateUnmarshal(raw string) ice.Candidate {
+	raw = strings.TrimPrefix(strings.TrimPrefix(raw, "a="), "candidate:")
 	split := strings.Fields(raw)
 	if len(split) < 8 {
 		fmt.Printf("Attribute not long enough to be ICE candidate (%d) %s \n", len(split), raw)
@@ -36,50 +39,127 @@ func ICECandidateUnmarshal(raw string) ice.Candidate {
 	// TODO verify valid address
 	address := split[4]
 
+	// The priority is kept as the local preference it was computed from,
+	// the pair priorities of the ICE checklist are derived from it
+	priority, err := strconv.ParseUint(split[3], 10, 32)
+	if err != nil {
+		return nil
+	}
+	base := ice.CandidateBase{
+		Protocol:        ice.NewProtoType(strings.ToLower(split[2])),
+		Address:         address,
+		Port:            port,
+		LocalPreference: uint16(priority >> 8),
+	}
+	if generation := getValue("generation"); generation != "" {
+		if base.Generation, err = strconv.Atoi(generation); err != nil {
+			return nil
+		}
+	}
+
+	// https://tools.ietf.org/html/rfc6544#section-4.5
+	switch base.Protocol {
+	case ice.ProtoTypeUDP:
+	case ice.ProtoTypeTCP:
+		if base.TCPType = ice.NewTCPType(getValue("tcptype")); base.TCPType == ice.TCPType(ice.Unknown) {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	// The related address of reflexive candidates is the one of their base
+	relatedAddress := getValue("raddr")
+	relatedPort := 0
+	if rport := getValue("rport"); rport != "" {
+		if relatedPort, err = strconv.Atoi(rport); err != nil {
+			return nil
+		}
+	}
+
 	switch getValue("typ") {
 	case "host":
 		return &ice.CandidateHost{
-			CandidateBase: ice.CandidateBase{
-				Protocol: ice.ProtoTypeUDP,
-				Address:  address,
-				Port:     port,
-			},
+			CandidateBase: base,
 		}
 	case "srflx":
 		return &ice.CandidateSrflx{
-			CandidateBase: ice.CandidateBase{
-				Protocol: ice.ProtoTypeUDP,
-				Address:  address,
-				Port:     port,
-			},
+			CandidateBase: base,
+			RemoteAddress: relatedAddress,
+			RemotePort:    relatedPort,
+		}
+	case "prflx":
+		return &ice.CandidatePrflx{
+			CandidateBase: base,
+			RemoteAddress: relatedAddress,
+			RemotePort:    relatedPort,
 		}
 	default:
 		return nil
 	}
 }
 
+// iceTCPTypeString is the tcptype extension of TCP candidates
+func iceTCPTypeString(c *ice.CandidateBase) string {
+	if c.Protocol != ice.ProtoTypeTCP {
+		return ""
+	}
+	return " tcptype " + c.TCPType.String()
+}
+
 func iceSrflxCandidateString(c *ice.CandidateSrflx, component int) string {
-	return fmt.Sprintf("udpcandidate %d udp %d %s %d typ srflx raddr %s rport %d generation 0",
-		component, c.CandidateBase.Priority(ice.SrflxCandidatePreference, uint16(component)), c.CandidateBase.Address, c.CandidateBase.Port, c.RemoteAddress, c.RemotePort)
+	return fmt.Sprintf("%s %d %s %d %s %d typ srflx raddr %s rport %d%s generation %d",
+		ice.Foundation(c), component, c.Protocol, ice.Priority(c, uint16(component)), c.CandidateBase.Address, c.CandidateBase.Port, c.RemoteAddress, c.RemotePort,
+		iceTCPTypeString(&c.CandidateBase), c.Generation)
 }
 
 func iceHostCandidateString(c *ice.CandidateHost, component int) string {
-	return fmt.Sprintf("udpcandidate %d udp %d %s %d typ host generation 0",
-		component, c.CandidateBase.Priority(ice.HostCandidatePreference, uint16(component)), c.CandidateBase.Address, c.CandidateBase.Port)
+	address := c.CandidateBase.Address
+	if c.Hostname != "" {
+		address = c.Hostname
+	}
+	return fmt.Sprintf("%s %d %s %d %s %d typ host%s generation %d",
+		ice.Foundation(c), component, c.Protocol, ice.Priority(c, uint16(component)), address, c.CandidateBase.Port,
+		iceTCPTypeString(&c.CandidateBase), c.Generation)
 }
 
-// ICECandidateMarshal takes a candidate and returns a string representation
+// ICECandidateMarshal takes a candidate and returns a string representation,
+// peer reflexive candidates are learned by the peer on its own
+// https://tools.ietf.org/html/rfc8445#section-7.2.5.3.1
+//
+// Only the RTP component is signaled, RTCP is multiplexed on it
+// https://tools.ietf.org/html/rfc5761#section-5.1.3
 func ICECandidateMarshal(c ice.Candidate) []string {
 	out := make([]string, 0)
 
 	switch c := c.(type) {
 	case *ice.CandidateSrflx:
 		out = append(out, iceSrflxCandidateString(c, 1))
-		out = append(out, iceSrflxCandidateString(c, 2))
 	case *ice.CandidateHost:
 		out = append(out, iceHostCandidateString(c, 1))
-		out = append(out, iceHostCandidateString(c, 2))
 	}
 
 	return out
 }
+
+// ICECredentials returns the a=ice-ufrag and a=ice-pwd of a session
+// description. The ones of its first media level that has them win over
+// the session level ones.
+// https://tools.ietf.org/html/draft-ietf-mmusic-ice-sip-sdp-21#section-5.4
+func (s *SessionDescription) ICECredentials() (ufrag, pwd string) {
+	parse := func(attributes []Attribute) {
+		for _, a := range attributes {
+			if strings.HasPrefix(*a.String(), "ice-ufrag:") && ufrag == "" {
+				ufrag = (*a.String())[len("ice-ufrag:"):]
+			} else if strings.HasPrefix(*a.String(), "ice-pwd:") && pwd == "" {
+				pwd = (*a.String())[len("ice-pwd:"):]
+			}
+		}
+	}
+
+	for _, m := range s.MediaDescriptions {
+		parse(m.Attributes)
+	}
+	parse(s.Attributes)
+	return ufrag, pwd
+}
@@ -0,0 +1,148 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/ice"
+)
+
+func TestICECandidatePriority(t *testing.T) {
+	local := &ice.CandidateHost{
+		CandidateBase: ice.CandidateBase{
+			Protocol:        ice.ProtoTypeUDP,
+			Address:         "192.168.0.1",
+			Port:            1234,
+			LocalPreference: 65000,
+		},
+	}
+
+	raw := ICECandidateMarshal(local)[0]
+	remote := ICECandidateUnmarshal(raw)
+	if remote == nil {
+		t.Fatalf("Failed to unmarshal %s", raw)
+	}
+
+	if ice.Priority(remote, 1) != ice.Priority(local, 1) {
+		t.Errorf("Priority %d of %s does not match %d", ice.Priority(remote, 1), raw, ice.Priority(local, 1))
+	}
+}
+
+func TestICECandidateTCP(t *testing.T) {
+	local := &ice.CandidateHost{
+		CandidateBase: ice.CandidateBase{
+			Protocol:        ice.ProtoTypeTCP,
+			Address:         "192.168.0.1",
+			Port:            9,
+			LocalPreference: ice.TCPLocalPreference(ice.TCPTypeActive, 8191),
+			TCPType:         ice.TCPTypeActive,
+		},
+	}
+
+	raw := ICECandidateMarshal(local)[0]
+	if !strings.Contains(raw, " tcp ") || !strings.Contains(raw, " tcptype active ") {
+		t.Errorf("TCP candidate marshaled as %s", raw)
+	}
+
+	remote := ICECandidateUnmarshal(raw)
+	if remote == nil {
+		t.Fatalf("Failed to unmarshal %s", raw)
+	}
+	if remote.GetBase().Protocol != ice.ProtoTypeTCP || remote.GetBase().TCPType != ice.TCPTypeActive {
+		t.Errorf("Unmarshaled %s as %s %s", raw, remote.GetBase().Protocol, remote.GetBase().TCPType)
+	}
+	if ice.Priority(remote, 1) != ice.Priority(local, 1) {
+		t.Errorf("Priority %d of %s does not match %d", ice.Priority(remote, 1), raw, ice.Priority(local, 1))
+	}
+
+	if c := ICECandidateUnmarshal("1 1 tcp 1518280447 192.168.0.1 9 typ host"); c != nil {
+		t.Errorf("TCP candidate without tcptype unmarshaled")
+	}
+}
+
+func TestICECandidateHostname(t *testing.T) {
+	local := &ice.CandidateHost{
+		CandidateBase: ice.CandidateBase{
+			Protocol: ice.ProtoTypeUDP,
+			Address:  "192.168.0.1",
+			Port:     1234,
+			Hostname: "1f1c4b1e-0fbb-4d5b-a34a-3a8b1a6c6b8e.local",
+		},
+	}
+
+	raw := ICECandidateMarshal(local)[0]
+	if strings.Contains(raw, "192.168.0.1") || !strings.Contains(raw, " 1f1c4b1e-0fbb-4d5b-a34a-3a8b1a6c6b8e.local 1234 ") {
+		t.Errorf("Hostname is not signaled in place of the address: %s", raw)
+	}
+
+	remote := ICECandidateUnmarshal(raw)
+	if remote == nil {
+		t.Fatalf("Failed to unmarshal %s", raw)
+	}
+	if remote.GetBase().Address != local.Hostname {
+		t.Errorf("Address %s does not match %s", remote.GetBase().Address, local.Hostname)
+	}
+}
+
+func TestICECandidatePrflx(t *testing.T) {
+	remote := ICECandidateUnmarshal("1 1 udp 1845501695 203.0.113.1 50000 typ prflx raddr 192.168.0.1 rport 50000")
+	if remote == nil {
+		t.Fatalf("Failed to unmarshal peer reflexive candidate")
+	}
+	if remote.Type() != ice.CandidateTypePeerReflexive {
+		t.Errorf("Unmarshaled peer reflexive candidate as %s", remote.Type())
+	}
+
+	if out := ICECandidateMarshal(remote); len(out) != 0 {
+		t.Errorf("Peer reflexive candidate marshaled as %v", out)
+	}
+}
+
+func TestICECandidateRelatedAddress(t *testing.T) {
+	local := &ice.CandidateSrflx{
+		CandidateBase: ice.CandidateBase{
+			Protocol:   ice.ProtoTypeUDP,
+			Address:    "203.0.113.1",
+			Port:       50000,
+			Generation: 2,
+		},
+		RemoteAddress: "192.168.0.1",
+		RemotePort:    1234,
+	}
+
+	raw := ICECandidateMarshal(local)[0]
+	if !strings.HasSuffix(raw, " typ srflx raddr 192.168.0.1 rport 1234 generation 2") {
+		t.Errorf("Server reflexive candidate marshaled as %s", raw)
+	}
+
+	remote, ok := ICECandidateUnmarshal("a=candidate:" + raw).(*ice.CandidateSrflx)
+	if !ok {
+		t.Fatalf("Failed to unmarshal %s", raw)
+	}
+	if remote.RemoteAddress != local.RemoteAddress || remote.RemotePort != local.RemotePort {
+		t.Errorf("Related address %s:%d does not match %s:%d", remote.RemoteAddress, remote.RemotePort, local.RemoteAddress, local.RemotePort)
+	}
+	if remote.Generation != local.Generation {
+		t.Errorf("Generation %d does not match %d", remote.Generation, local.Generation)
+	}
+
+	if c := ICECandidateUnmarshal("1 1 udp 1694498815 203.0.113.1 50000 typ srflx raddr 192.168.0.1 rport port"); c != nil {
+		t.Errorf("Candidate with an invalid rport unmarshaled")
+	}
+	if c := ICECandidateUnmarshal("1 1 udp 2130706431 192.168.0.1 50000 typ host generation x"); c != nil {
+		t.Errorf("Candidate with an invalid generation unmarshaled")
+	}
+}
+
+func TestICECredentials(t *testing.T) {
+	s := &SessionDescription{}
+	s.WithValueAttribute("ice-ufrag", "session").WithValueAttribute("ice-pwd", "sessionPassword")
+	if ufrag, pwd := s.ICECredentials(); ufrag != "session" || pwd != "sessionPassword" {
+		t.Errorf("Session level credentials %s %s", ufrag, pwd)
+	}
+
+	s.WithMedia((&MediaDescription{}).WithICECredentials("media", "mediaPassword"))
+	if ufrag, pwd := s.ICECredentials(); ufrag != "media" || pwd != "mediaPassword" {
+		t.Errorf("Media level credentials %s %s", ufrag, pwd)
+	}
+}
@@ -3,6 +3,8 @@ package sdp
 import (
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +17,7 @@ const (
 	AttrKeyMsidSemantic    = "msid-semantic"
 	AttrKeyConnectionSetup = "setup"
 	AttrKeyMID             = "mid"
+	AttrKeyMsid            = "msid"
 	AttrKeyICELite         = "ice-lite"
 	AttrKeyRtcpMux         = "rtcp-mux"
 	AttrKeyRtcpRsize       = "rtcp-rsize"
@@ -127,7 +130,7 @@ func (d *MediaDescription) WithICECredentials(username, password string) *MediaD
 
 // WithCodec adds codec information to the media description
 func (d *MediaDescription) WithCodec(payloadType uint8, name string, clockrate uint32, channels uint16, fmtp string) *MediaDescription {
-	d.MediaName.Formats = append(d.MediaName.Formats, int(payloadType))
+	d.MediaName.Formats = append(d.MediaName.Formats, strconv.Itoa(int(payloadType)))
 	rtpmap := fmt.Sprintf("%d %s/%d", payloadType, name, clockrate)
 	if channels > 0 {
 		rtpmap = rtpmap + fmt.Sprintf("/%d", channels)
@@ -152,3 +155,78 @@ func (d *MediaDescription) WithMediaSource(ssrc uint32, cname, streamLabel, labe
 func (d *MediaDescription) WithCandidate(value string) *MediaDescription {
 	return d.WithValueAttribute("candidate", value)
 }
+
+// BundleGroup returns the mids of the a=group:BUNDLE of the session
+// description, the first one is the section the others share the
+// transport of. It is empty when the session bundles nothing.
+// https://tools.ietf.org/html/draft-ietf-mmusic-sdp-bundle-negotiation-54#section-7.2
+func (s *SessionDescription) BundleGroup() []string {
+	for _, a := range s.Attributes {
+		fields := strings.Fields(*a.String())
+		if len(fields) > 0 && fields[0] == AttrKeyGroup+":BUNDLE" {
+			return fields[1:]
+		}
+	}
+	return nil
+}
+
+// HasRtcpMux reports whether the media description sends RTCP on the
+// transport of RTP
+// https://tools.ietf.org/html/rfc5761#section-5.1.1
+func (d *MediaDescription) HasRtcpMux() bool {
+	for _, a := range d.Attributes {
+		if *a.String() == AttrKeyRtcpMux {
+			return true
+		}
+	}
+	return false
+}
+
+// MID returns the a=mid of the media description, empty if it has none
+func (d *MediaDescription) MID() string {
+	for _, a := range d.Attributes {
+		if strings.HasPrefix(*a.String(), AttrKeyMID+":") {
+			return (*a.String())[len(AttrKeyMID+":"):]
+		}
+	}
+	return ""
+}
+
+// MediaForSSRC returns the media description that announces ssrc with an
+// a=ssrc attribute, nil if none does
+func (s *SessionDescription) MediaForSSRC(ssrc uint32) *MediaDescription {
+	prefix := AttrKeySsrc + ":" + strconv.FormatUint(uint64(ssrc), 10) + " "
+	for _, m := range s.MediaDescriptions {
+		for _, a := range m.Attributes {
+			if strings.HasPrefix(*a.String(), prefix) {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// Msid returns the stream and track identifiers of the media description,
+// from its a=msid or else the a=ssrc msid of ssrc
+// https://tools.ietf.org/html/draft-ietf-mmusic-msid-16#section-2
+func (d *MediaDescription) Msid(ssrc uint32) (streamLabel, label string) {
+	ssrcPrefix := AttrKeySsrc + ":" + strconv.FormatUint(uint64(ssrc), 10) + " " + AttrKeyMsid + ":"
+	for _, a := range d.Attributes {
+		var value string
+		switch attr := *a.String(); {
+		case strings.HasPrefix(attr, AttrKeyMsid+":"):
+			value = attr[len(AttrKeyMsid+":"):]
+		case strings.HasPrefix(attr, ssrcPrefix):
+			value = attr[len(ssrcPrefix):]
+		default:
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 2 {
+			return fields[0], fields[1]
+		} else if len(fields) == 1 {
+			return fields[0], ""
+		}
+	}
+	return "", ""
+}
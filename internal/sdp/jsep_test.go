@@ -0,0 +1,57 @@
+package sdp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBundleGroup(t *testing.T) {
+	s := &SessionDescription{}
+	if mids := s.BundleGroup(); len(mids) != 0 {
+		t.Errorf("Session without a group bundles %v", mids)
+	}
+
+	s.WithValueAttribute(AttrKeyGroup, "LS audio video").
+		WithValueAttribute(AttrKeyGroup, "BUNDLE audio video data")
+	if mids := s.BundleGroup(); !reflect.DeepEqual(mids, []string{"audio", "video", "data"}) {
+		t.Errorf("Session bundles %v", mids)
+	}
+}
+
+func TestHasRtcpMux(t *testing.T) {
+	d := NewJSEPMediaDescription("audio", []string{})
+	if d.HasRtcpMux() {
+		t.Errorf("Media without rtcp-mux multiplexes RTCP")
+	}
+	if !d.WithPropertyAttribute(AttrKeyRtcpMux).HasRtcpMux() {
+		t.Errorf("Media with rtcp-mux does not multiplex RTCP")
+	}
+}
+
+func TestMediaForSSRC(t *testing.T) {
+	audio := NewJSEPMediaDescription("audio", []string{}).
+		WithValueAttribute(AttrKeyMID, "audio").
+		WithValueAttribute(AttrKeyMsid, "stream audio-track")
+	video := NewJSEPMediaDescription("video", []string{}).
+		WithValueAttribute(AttrKeyMID, "video").
+		WithMediaSource(1234, "cname", "stream", "video-track")
+	s := &SessionDescription{}
+	s.WithMedia(audio).WithMedia(video)
+
+	if m := s.MediaForSSRC(1234); m != video {
+		t.Fatalf("MediaForSSRC(1234) = %v, want the video section", m)
+	}
+	if m := s.MediaForSSRC(123); m != nil {
+		t.Errorf("MediaForSSRC(123) = %v, want nil", m)
+	}
+	if mid := video.MID(); mid != "video" {
+		t.Errorf("MID() = %q, want video", mid)
+	}
+
+	if streamLabel, label := video.Msid(1234); streamLabel != "stream" || label != "video-track" {
+		t.Errorf("Msid(1234) = %q %q, want stream video-track", streamLabel, label)
+	}
+	if streamLabel, label := audio.Msid(1234); streamLabel != "stream" || label != "audio-track" {
+		t.Errorf("Msid(1234) = %q %q, want stream audio-track", streamLabel, label)
+	}
+}
@@ -0,0 +1,113 @@
+package sdp
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Constants for the SDP attributes of data channel media sections
+const (
+	AttrKeySctpPort       = "sctp-port"
+	AttrKeyMaxMessageSize = "max-message-size"
+	AttrKeySctpmap        = "sctpmap"
+)
+
+// SCTPParameters describe the SCTP association of a data channel media
+// section
+// https://tools.ietf.org/html/draft-ietf-mmusic-sctp-sdp-26#section-5
+type SCTPParameters struct {
+	Port uint16
+
+	// MaxMessageSize is the largest message the endpoint can receive, zero
+	// when there is no limit
+	MaxMessageSize uint32
+
+	// Sctpmap is set for the form of the sections before draft 06, with
+	// the port as format and an a=sctpmap attribute, that some endpoints
+	// still send
+	// https://tools.ietf.org/html/draft-ietf-mmusic-sctp-sdp-05#section-5
+	Sctpmap bool
+}
+
+// Defaults of the sections that don't give the SCTP parameters
+const (
+	DefaultSCTPPort           = 5000
+	DefaultSCTPMaxMessageSize = 65536
+)
+
+// sctpmapStreams is the number of streams an a=sctpmap announces
+const sctpmapStreams = 1024
+
+// NewJSEPDataMediaDescription creates the webrtc-datachannel media
+// section of an SCTP association, in the form the parameters are for
+func NewJSEPDataMediaDescription(params SCTPParameters) *MediaDescription {
+	d := &MediaDescription{
+		MediaName: MediaName{
+			Media:   "application",
+			Port:    RangedPort{Value: 9},
+			Protos:  []string{"UDP", "DTLS", "SCTP"},
+			Formats: []string{"webrtc-datachannel"},
+		},
+		ConnectionInformation: &ConnectionInformation{
+			NetworkType: "IN",
+			AddressType: "IP4",
+			Address: &Address{
+				IP: net.ParseIP("0.0.0.0"),
+			},
+		},
+	}
+
+	port := strconv.Itoa(int(params.Port))
+	if params.Sctpmap {
+		d.MediaName.Protos = []string{"DTLS", "SCTP"}
+		d.MediaName.Formats = []string{port}
+		d.WithValueAttribute(AttrKeySctpmap, port+" webrtc-datachannel "+strconv.Itoa(sctpmapStreams))
+	} else {
+		d.WithValueAttribute(AttrKeySctpPort, port)
+	}
+	return d.WithValueAttribute(AttrKeyMaxMessageSize, strconv.FormatUint(uint64(params.MaxMessageSize), 10))
+}
+
+// SCTPParameters returns the SCTP parameters of a data channel media
+// section, of either form
+func (d *MediaDescription) SCTPParameters() (SCTPParameters, error) {
+	params := SCTPParameters{Port: DefaultSCTPPort, MaxMessageSize: DefaultSCTPMaxMessageSize}
+	parsePort := func(raw string) error {
+		port, err := strconv.ParseUint(raw, 10, 16)
+		if err != nil || port == 0 {
+			return errors.Errorf("sdp: invalid sctp port `%v`", raw)
+		}
+		params.Port = uint16(port)
+		return nil
+	}
+
+	// The older form has the port as format, the newer webrtc-datachannel
+	if len(d.MediaName.Formats) != 0 && d.MediaName.Formats[0] != "webrtc-datachannel" {
+		params.Sctpmap = true
+		if err := parsePort(d.MediaName.Formats[0]); err != nil {
+			return SCTPParameters{}, err
+		}
+	}
+
+	for _, a := range d.Attributes {
+		attr := *a.String()
+		switch {
+		case strings.HasPrefix(attr, AttrKeySctpPort+":"):
+			if err := parsePort(attr[len(AttrKeySctpPort+":"):]); err != nil {
+				return SCTPParameters{}, err
+			}
+		case strings.HasPrefix(attr, AttrKeyMaxMessageSize+":"):
+			size, err := strconv.ParseUint(attr[len(AttrKeyMaxMessageSize+":"):], 10, 32)
+			if err != nil {
+				return SCTPParameters{}, errors.Errorf("sdp: invalid max-message-size `%v`", attr)
+			}
+			params.MaxMessageSize = uint32(size)
+		case strings.HasPrefix(attr, AttrKeySctpmap+":"):
+			params.Sctpmap = true
+		}
+	}
+	return params, nil
+}
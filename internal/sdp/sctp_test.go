@@ -0,0 +1,53 @@
+package sdp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSCTPParameters(t *testing.T) {
+	testCases := []struct {
+		params SCTPParameters
+		lines  []string
+	}{
+		{SCTPParameters{Port: 5000, MaxMessageSize: 262144},
+			[]string{"m=application 9 UDP/DTLS/SCTP webrtc-datachannel", "a=sctp-port:5000", "a=max-message-size:262144"}},
+		{SCTPParameters{Port: 5001, MaxMessageSize: 65536, Sctpmap: true},
+			[]string{"m=application 9 DTLS/SCTP 5001", "a=sctpmap:5001 webrtc-datachannel 1024", "a=max-message-size:65536"}},
+	}
+	for i, testCase := range testCases {
+		s := (&SessionDescription{}).WithMedia(NewJSEPDataMediaDescription(testCase.params))
+		raw := s.Marshal()
+		for _, line := range testCase.lines {
+			if !strings.Contains(raw, line+"\r\n") {
+				t.Errorf("testCase: %d %s has no %s", i, raw, line)
+			}
+		}
+
+		parsed := &SessionDescription{}
+		if err := parsed.Unmarshal("v=0\no=- 0 0 IN IP4 0.0.0.0\ns=-\nt=0 0\n" + raw[strings.Index(raw, "m="):]); err != nil {
+			t.Fatalf("testCase: %d %v", i, err)
+		}
+		params, err := parsed.MediaDescriptions[0].SCTPParameters()
+		if err != nil {
+			t.Fatalf("testCase: %d %v", i, err)
+		}
+		if params != testCase.params {
+			t.Errorf("testCase: %d parsed %v, expected %v", i, params, testCase.params)
+		}
+	}
+
+	// Sections without the parameters have the defaults
+	params, err := NewJSEPMediaDescription("application", []string{}).SCTPParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != (SCTPParameters{Port: DefaultSCTPPort, MaxMessageSize: DefaultSCTPMaxMessageSize}) {
+		t.Errorf("Default parameters %v", params)
+	}
+
+	invalid := NewJSEPMediaDescription("application", []string{}).WithValueAttribute(AttrKeySctpPort, "port")
+	if _, err := invalid.SCTPParameters(); err == nil {
+		t.Errorf("Invalid sctp-port was parsed")
+	}
+}
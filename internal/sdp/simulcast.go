@@ -0,0 +1,227 @@
+package sdp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Constants for the SDP attributes of simulcast
+const (
+	AttrKeyRID       = "rid"
+	AttrKeySimulcast = "simulcast"
+)
+
+// Directions of rids and simulcast streams
+const (
+	SimulcastDirectionSend = "send"
+	SimulcastDirectionRecv = "recv"
+)
+
+// RID is an RTP stream identifier, with the restrictions on the stream
+// it identifies
+// https://tools.ietf.org/html/draft-ietf-mmusic-rid-15#section-10
+type RID struct {
+	ID        string
+	Direction string
+
+	// PayloadTypes are the formats of the media section the stream may
+	// use, any of them when empty
+	PayloadTypes []uint8
+
+	// Restrictions are the other parameters, like max-width or max-fps.
+	// The ones without a value map to the empty string.
+	Restrictions map[string]string
+}
+
+// String returns the value of the a=rid attribute of the stream
+func (r RID) String() string {
+	value := r.ID + " " + r.Direction
+
+	var params []string
+	if len(r.PayloadTypes) != 0 {
+		payloadTypes := make([]string, len(r.PayloadTypes))
+		for i, payloadType := range r.PayloadTypes {
+			payloadTypes[i] = strconv.Itoa(int(payloadType))
+		}
+		params = append(params, "pt="+strings.Join(payloadTypes, ","))
+	}
+
+	names := make([]string, 0, len(r.Restrictions))
+	for name := range r.Restrictions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if r.Restrictions[name] == "" {
+			params = append(params, name)
+		} else {
+			params = append(params, name+"="+r.Restrictions[name])
+		}
+	}
+
+	if len(params) != 0 {
+		value += " " + strings.Join(params, ";")
+	}
+	return value
+}
+
+// parseRID parses the value of an a=rid attribute
+// a=rid:<rid-id> <direction> [pt=<fmt list>;]<restriction>=<value>...
+func parseRID(raw string) (RID, error) {
+	split := strings.SplitN(raw, " ", 3)
+	if len(split) < 2 || split[0] == "" {
+		return RID{}, errors.Errorf("sdp: invalid rid `%v`", raw)
+	}
+	rid := RID{ID: split[0], Direction: split[1]}
+	if rid.Direction != SimulcastDirectionSend && rid.Direction != SimulcastDirectionRecv {
+		return RID{}, errors.Errorf("sdp: invalid rid direction `%v`", rid.Direction)
+	}
+	if len(split) == 2 {
+		return rid, nil
+	}
+
+	for _, param := range strings.Split(split[2], ";") {
+		keyValue := strings.SplitN(param, "=", 2)
+		if keyValue[0] == "" {
+			continue
+		}
+		value := ""
+		if len(keyValue) == 2 {
+			value = keyValue[1]
+		}
+
+		if keyValue[0] != "pt" {
+			if rid.Restrictions == nil {
+				rid.Restrictions = make(map[string]string)
+			}
+			rid.Restrictions[keyValue[0]] = value
+			continue
+		}
+		for _, format := range strings.Split(value, ",") {
+			payloadType, err := strconv.ParseUint(format, 10, 8)
+			if err != nil {
+				return RID{}, errors.Errorf("sdp: invalid rid payload type `%v`", format)
+			}
+			rid.PayloadTypes = append(rid.PayloadTypes, uint8(payloadType))
+		}
+	}
+	return rid, nil
+}
+
+// RIDs returns the a=rid attributes of the media description
+func (d *MediaDescription) RIDs() ([]RID, error) {
+	var rids []RID
+	for _, a := range d.Attributes {
+		if !strings.HasPrefix(*a.String(), AttrKeyRID+":") {
+			continue
+		}
+		rid, err := parseRID((*a.String())[len(AttrKeyRID+":"):])
+		if err != nil {
+			return nil, err
+		}
+		rids = append(rids, rid)
+	}
+	return rids, nil
+}
+
+// WithRID adds an a=rid attribute to the media description
+func (d *MediaDescription) WithRID(rid RID) *MediaDescription {
+	return d.WithValueAttribute(AttrKeyRID, rid.String())
+}
+
+// SimulcastRID is a rid in a simulcast stream, a paused one is not sent
+// until it is resumed
+type SimulcastRID struct {
+	ID     string
+	Paused bool
+}
+
+// Simulcast is the a=simulcast attribute of a media section, its streams
+// in each direction. The rids of a stream are alternatives of it, the
+// first is the one preferred.
+// https://tools.ietf.org/html/draft-ietf-mmusic-sdp-simulcast-14#section-6.1
+type Simulcast struct {
+	Send [][]SimulcastRID
+	Recv [][]SimulcastRID
+}
+
+// String returns the value of the a=simulcast attribute
+func (s *Simulcast) String() string {
+	format := func(streams [][]SimulcastRID) string {
+		list := make([]string, len(streams))
+		for i, stream := range streams {
+			alternatives := make([]string, len(stream))
+			for j, rid := range stream {
+				alternatives[j] = rid.ID
+				if rid.Paused {
+					alternatives[j] = "~" + rid.ID
+				}
+			}
+			list[i] = strings.Join(alternatives, ",")
+		}
+		return strings.Join(list, ";")
+	}
+
+	var value []string
+	if len(s.Send) != 0 {
+		value = append(value, SimulcastDirectionSend+" "+format(s.Send))
+	}
+	if len(s.Recv) != 0 {
+		value = append(value, SimulcastDirectionRecv+" "+format(s.Recv))
+	}
+	return strings.Join(value, " ")
+}
+
+// parseSimulcast parses the value of an a=simulcast attribute
+// a=simulcast:<direction> <streams> [<direction> <streams>]
+func parseSimulcast(raw string) (*Simulcast, error) {
+	fields := strings.Fields(raw)
+	if len(fields) != 2 && len(fields) != 4 {
+		return nil, errors.Errorf("sdp: invalid simulcast `%v`", raw)
+	}
+
+	s := &Simulcast{}
+	for i := 0; i < len(fields); i += 2 {
+		var streams [][]SimulcastRID
+		for _, stream := range strings.Split(fields[i+1], ";") {
+			var alternatives []SimulcastRID
+			for _, id := range strings.Split(stream, ",") {
+				rid := SimulcastRID{ID: strings.TrimPrefix(id, "~"), Paused: strings.HasPrefix(id, "~")}
+				if rid.ID == "" {
+					return nil, errors.Errorf("sdp: invalid simulcast stream `%v`", stream)
+				}
+				alternatives = append(alternatives, rid)
+			}
+			streams = append(streams, alternatives)
+		}
+
+		switch {
+		case fields[i] == SimulcastDirectionSend && s.Send == nil:
+			s.Send = streams
+		case fields[i] == SimulcastDirectionRecv && s.Recv == nil:
+			s.Recv = streams
+		default:
+			return nil, errors.Errorf("sdp: invalid simulcast direction `%v`", fields[i])
+		}
+	}
+	return s, nil
+}
+
+// Simulcast returns the a=simulcast attribute of the media description,
+// nil when it has none
+func (d *MediaDescription) Simulcast() (*Simulcast, error) {
+	for _, a := range d.Attributes {
+		if strings.HasPrefix(*a.String(), AttrKeySimulcast+":") {
+			return parseSimulcast((*a.String())[len(AttrKeySimulcast+":"):])
+		}
+	}
+	return nil, nil
+}
+
+// WithSimulcast adds an a=simulcast attribute to the media description
+func (d *MediaDescription) WithSimulcast(s *Simulcast) *MediaDescription {
+	return d.WithValueAttribute(AttrKeySimulcast, s.String())
+}
@@ -0,0 +1,71 @@
+package sdp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRID(t *testing.T) {
+	d := &MediaDescription{}
+	d.WithValueAttribute(AttrKeyRID, "hi send pt=96,97;max-width=1280;max-height=720")
+	d.WithValueAttribute(AttrKeyRID, "lo recv")
+
+	rids, err := d.RIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []RID{
+		{ID: "hi", Direction: SimulcastDirectionSend, PayloadTypes: []uint8{96, 97},
+			Restrictions: map[string]string{"max-width": "1280", "max-height": "720"}},
+		{ID: "lo", Direction: SimulcastDirectionRecv},
+	}
+	if !reflect.DeepEqual(rids, expected) {
+		t.Errorf("RIDs %v do not match %v", rids, expected)
+	}
+
+	if raw := rids[0].String(); raw != "hi send pt=96,97;max-height=720;max-width=1280" {
+		t.Errorf("RID marshaled as %s", raw)
+	}
+	if raw := rids[1].String(); raw != "lo recv" {
+		t.Errorf("RID marshaled as %s", raw)
+	}
+
+	for _, raw := range []string{"hi", "hi both", "hi send pt=x"} {
+		if _, err := parseRID(raw); err == nil {
+			t.Errorf("Invalid rid %s was parsed", raw)
+		}
+	}
+}
+
+func TestSimulcast(t *testing.T) {
+	d := &MediaDescription{}
+	if s, err := d.Simulcast(); s != nil || err != nil {
+		t.Errorf("Media without simulcast has %v %v", s, err)
+	}
+
+	d.WithValueAttribute(AttrKeySimulcast, "send hi;mid,~mid2;~lo recv full")
+	s, err := d.Simulcast()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Simulcast{
+		Send: [][]SimulcastRID{
+			{{ID: "hi"}},
+			{{ID: "mid"}, {ID: "mid2", Paused: true}},
+			{{ID: "lo", Paused: true}},
+		},
+		Recv: [][]SimulcastRID{{{ID: "full"}}},
+	}
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("Simulcast %v does not match %v", s, expected)
+	}
+	if raw := s.String(); raw != "send hi;mid,~mid2;~lo recv full" {
+		t.Errorf("Simulcast marshaled as %s", raw)
+	}
+
+	for _, raw := range []string{"send", "send hi send lo", "both hi", "send hi;;lo", "send hi recv"} {
+		if _, err := parseSimulcast(raw); err == nil {
+			t.Errorf("Invalid simulcast %s was parsed", raw)
+		}
+	}
+}
@@ -19,22 +19,53 @@ const (
 	labelSRTCPAuthenticationTag = 0x04
 	labelSRTCPSalt              = 0x05
 
-	keyLen  = 16
-	saltLen = 14
-
-	maxROCDisorder    = 100
-	maxSequenceNumber = 65535
+	seqNumMedian = 1 << 15
 
 	authTagSize    = 10
+	authKeySize    = 20
 	srtcpIndexSize = 4
 )
 
+// SRTP protection profiles, as named by
+// https://tools.ietf.org/html/rfc5764#section-4.1.2 and
+// https://tools.ietf.org/html/rfc7714#section-14.2
+const (
+	ProtectionProfileAES128CMHMACSHA1_80 = "SRTP_AES128_CM_SHA1_80"
+	ProtectionProfileAEADAES128GCM       = "SRTP_AEAD_AES_128_GCM"
+	ProtectionProfileAEADAES256GCM       = "SRTP_AEAD_AES_256_GCM"
+)
+
+type protectionProfile struct {
+	keyLen  int
+	saltLen int
+	aead    bool
+}
+
+var protectionProfiles = map[string]protectionProfile{
+	ProtectionProfileAES128CMHMACSHA1_80: {keyLen: 16, saltLen: 14},
+	ProtectionProfileAEADAES128GCM:       {keyLen: 16, saltLen: 12, aead: true},
+	ProtectionProfileAEADAES256GCM:       {keyLen: 32, saltLen: 12, aead: true},
+}
+
+// KeyLen returns the length of the master key and master salt a protection
+// profile expects
+func KeyLen(profile string) (keyLen, saltLen int, err error) {
+	p, ok := protectionProfiles[profile]
+	if !ok {
+		return 0, 0, errors.Errorf("SRTP protection profile %s is not supported", profile)
+	}
+	return p.keyLen, p.saltLen, nil
+}
+
 // Encode/Decode state for a single SSRC
+// rolloverCounter and lastSequenceNumber are the ROC and s_l of the highest
+// packet index processed so far
 type ssrcState struct {
 	ssrc                 uint32
 	rolloverCounter      uint32
 	rolloverHasProcessed bool
 	lastSequenceNumber   uint16
+	replayWindow         replayWindow
 }
 
 // Context represents a SRTP cryptographic context
@@ -43,24 +74,31 @@ type ssrcState struct {
 type Context struct {
 	masterKey  []byte
 	masterSalt []byte
+	aead       bool
 
 	ssrcStates         map[uint32]*ssrcState
 	srtpSessionKey     []byte
 	srtpSessionSalt    []byte
 	srtpSessionAuthTag []byte
 	srtpBlock          cipher.Block
+	srtpGCM            cipher.AEAD
 
 	srtcpSessionKey     []byte
 	srtcpSessionSalt    []byte
 	srtcpSessionAuthTag []byte
 	srtcpIndex          uint32
 	srtcpBlock          cipher.Block
+	srtcpGCM            cipher.AEAD
+	srtcpReplayWindows  map[uint32]*replayWindow
 }
 
 // CreateContext creates a new SRTP Context
 func CreateContext(masterKey, masterSalt []byte, profile string) (c *Context, err error) {
-	if masterKeyLen := len(masterKey); masterKeyLen != keyLen {
-		return c, errors.Errorf("SRTP Master Key must be len %d, got %d", masterKey, keyLen)
+	keyLen, saltLen, err := KeyLen(profile)
+	if err != nil {
+		return c, err
+	} else if masterKeyLen := len(masterKey); masterKeyLen != keyLen {
+		return c, errors.Errorf("SRTP Master Key must be len %d, got %d", keyLen, masterKeyLen)
 	} else if masterSaltLen := len(masterSalt); masterSaltLen != saltLen {
 		return c, errors.Errorf("SRTP Salt must be len %d, got %d", saltLen, masterSaltLen)
 	}
@@ -68,15 +106,16 @@ func CreateContext(masterKey, masterSalt []byte, profile string) (c *Context, er
 	c = &Context{
 		masterKey:  masterKey,
 		masterSalt: masterSalt,
+		aead:       protectionProfiles[profile].aead,
 		ssrcStates: map[uint32]*ssrcState{},
+
+		srtcpReplayWindows: map[uint32]*replayWindow{},
 	}
 
 	if c.srtpSessionKey, err = c.generateSessionKey(labelSRTPEncryption); err != nil {
 		return nil, err
 	} else if c.srtpSessionSalt, err = c.generateSessionSalt(labelSRTPSalt); err != nil {
 		return nil, err
-	} else if c.srtpSessionAuthTag, err = c.generateSessionAuthTag(labelSRTPAuthenticationTag); err != nil {
-		return nil, err
 	} else if c.srtpBlock, err = aes.NewCipher(c.srtpSessionKey); err != nil {
 		return nil, err
 	}
@@ -85,87 +124,74 @@ func CreateContext(masterKey, masterSalt []byte, profile string) (c *Context, er
 		return nil, err
 	} else if c.srtcpSessionSalt, err = c.generateSessionSalt(labelSRTCPSalt); err != nil {
 		return nil, err
-	} else if c.srtcpSessionAuthTag, err = c.generateSessionAuthTag(labelSRTCPAuthenticationTag); err != nil {
-		return nil, err
 	} else if c.srtcpBlock, err = aes.NewCipher(c.srtcpSessionKey); err != nil {
 		return nil, err
 	}
 
-	return c, nil
-}
-
-func (c *Context) generateSessionKey(label byte) ([]byte, error) {
-	// https://tools.ietf.org/html/rfc3711#appendix-B.3
-	// The input block for AES-CM is generated by exclusive-oring the master salt with the
-	// concatenation of the encryption key label 0x00 with (index DIV kdr),
-	// - index is 'rollover count' and DIV is 'divided by'
-	sessionKey := make([]byte, len(c.masterSalt))
-	copy(sessionKey, c.masterSalt)
-
-	labelAndIndexOverKdr := []byte{label, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
-	for i, j := len(labelAndIndexOverKdr)-1, len(sessionKey)-1; i >= 0; i, j = i-1, j-1 {
-		sessionKey[j] = sessionKey[j] ^ labelAndIndexOverKdr[i]
+	// https://tools.ietf.org/html/rfc7714#section-11
+	// The AEAD profiles authenticate with the cipher itself, there is no
+	// session authentication key
+	if c.aead {
+		if c.srtpGCM, err = cipher.NewGCM(c.srtpBlock); err != nil {
+			return nil, err
+		} else if c.srtcpGCM, err = cipher.NewGCM(c.srtcpBlock); err != nil {
+			return nil, err
+		}
+		return c, nil
 	}
 
-	// then padding on the right with two null octets (which implements the multiply-by-2^16 operation, see Section 4.3.3).
-	sessionKey = append(sessionKey, []byte{0x00, 0x00}...)
-
-	//The resulting value is then AES-CM- encrypted using the master key to get the cipher key.
-	block, err := aes.NewCipher(c.masterKey)
-	if err != nil {
+	if c.srtpSessionAuthTag, err = c.generateSessionAuthTag(labelSRTPAuthenticationTag); err != nil {
+		return nil, err
+	} else if c.srtcpSessionAuthTag, err = c.generateSessionAuthTag(labelSRTCPAuthenticationTag); err != nil {
 		return nil, err
 	}
 
-	block.Encrypt(sessionKey, sessionKey)
-	return sessionKey, nil
+	return c, nil
 }
 
-func (c *Context) generateSessionSalt(label byte) ([]byte, error) {
-	// https://tools.ietf.org/html/rfc3711#appendix-B.3
-	// The input block for AES-CM is generated by exclusive-oring the master salt with
-	// the concatenation of the encryption salt label
-	sessionSalt := make([]byte, len(c.masterSalt))
-	copy(sessionSalt, c.masterSalt)
-
-	labelAndIndexOverKdr := []byte{label, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
-	for i, j := len(labelAndIndexOverKdr)-1, len(sessionSalt)-1; i >= 0; i, j = i-1, j-1 {
-		sessionSalt[j] = sessionSalt[j] ^ labelAndIndexOverKdr[i]
-	}
-
-	// That value is padded and encrypted as above.
-	sessionSalt = append(sessionSalt, []byte{0x00, 0x00}...)
-	block, err := aes.NewCipher(c.masterKey)
-	if err != nil {
-		return nil, err
-	}
+func (c *Context) generateSessionKey(label byte) ([]byte, error) {
+	return c.deriveSessionKey(label, len(c.masterKey))
+}
 
-	block.Encrypt(sessionSalt, sessionSalt)
-	return sessionSalt[0:saltLen], nil
+func (c *Context) generateSessionSalt(label byte) ([]byte, error) {
+	return c.deriveSessionKey(label, len(c.masterSalt))
 }
+
 func (c *Context) generateSessionAuthTag(label byte) ([]byte, error) {
+	return c.deriveSessionKey(label, authKeySize)
+}
+
+// deriveSessionKey runs the AES-CM key derivation function of
+// https://tools.ietf.org/html/rfc3711#section-4.3.3 with a key derivation
+// rate of 0. The AEAD profiles reuse it with their shorter master salt, which
+// is padded on the right with zeros https://tools.ietf.org/html/rfc7714#section-11
+func (c *Context) deriveSessionKey(label byte, length int) ([]byte, error) {
 	// https://tools.ietf.org/html/rfc3711#appendix-B.3
-	// We now show how the auth key is generated.  The input block for AES-
-	// CM is generated as above, but using the authentication key label.
-	sessionAuthTag := make([]byte, len(c.masterSalt))
-	copy(sessionAuthTag, c.masterSalt)
-
-	labelAndIndexOverKdr := []byte{label, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
-	for i, j := len(labelAndIndexOverKdr)-1, len(sessionAuthTag)-1; i >= 0; i, j = i-1, j-1 {
-		sessionAuthTag[j] = sessionAuthTag[j] ^ labelAndIndexOverKdr[i]
-	}
+	// The input block for AES-CM is generated by exclusive-oring the master salt with the
+	// concatenation of the label with (index DIV kdr), then padding on the right with
+	// two null octets (which implements the multiply-by-2^16 operation)
+	// - index is 'rollover count' and DIV is 'divided by'
+	// - the 14 octet salt places the label at octet 7
+	in := make([]byte, aes.BlockSize)
+	copy(in, c.masterSalt)
+	in[7] ^= label
 
-	// That value is padded and encrypted as above.
-	// - We need to do multiple runs at key size (20) is larger then source
-	firstRun := append(sessionAuthTag, []byte{0x00, 0x00}...)
-	secondRun := append(sessionAuthTag, []byte{0x00, 0x01}...)
+	// The resulting value is then AES-CM-encrypted using the master key,
+	// running the counter in the last two octets for as many blocks as needed
 	block, err := aes.NewCipher(c.masterKey)
 	if err != nil {
 		return nil, err
 	}
 
-	block.Encrypt(firstRun, firstRun)
-	block.Encrypt(secondRun, secondRun)
-	return append(firstRun, secondRun[:4]...), nil
+	out := make([]byte, 0, length+aes.BlockSize)
+	for i := 0; len(out) < length; i++ {
+		binary.BigEndian.PutUint16(in[aes.BlockSize-2:], uint16(i))
+
+		keystream := make([]byte, aes.BlockSize)
+		block.Encrypt(keystream, in)
+		out = append(out, keystream...)
+	}
+	return out[:length], nil
 }
 
 // Generate IV https://tools.ietf.org/html/rfc3711#section-4.1.1
@@ -211,5 +237,5 @@ func (c *Context) generateAuthTag(buf []byte, authTag []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	return mac.Sum(nil)[0:10], nil
+	return mac.Sum(nil)[0:authTagSize], nil
 }
@@ -0,0 +1,136 @@
+package srtp
+
+import (
+	"encoding/binary"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// gcmTagSize is the length of the authentication tag the AEAD profiles append
+// https://tools.ietf.org/html/rfc7714#section-12
+const gcmTagSize = 16
+
+// https://tools.ietf.org/html/rfc7714#section-8.1
+// IV = (0x0000 || SSRC || ROC || SEQ) XOR salt
+func (c *Context) rtpGCMIV(sequenceNumber uint16, rolloverCounter uint32, ssrc uint32) []byte {
+	iv := make([]byte, 12)
+	binary.BigEndian.PutUint32(iv[2:], ssrc)
+	binary.BigEndian.PutUint32(iv[6:], rolloverCounter)
+	binary.BigEndian.PutUint16(iv[10:], sequenceNumber)
+
+	for i := range iv {
+		iv[i] ^= c.srtpSessionSalt[i]
+	}
+	return iv
+}
+
+// https://tools.ietf.org/html/rfc7714#section-9.1
+// IV = (0x0000 || SSRC || 0x0000 || 0 || SRTCP index) XOR salt
+func (c *Context) rtcpGCMIV(srtcpIndex uint32, ssrc uint32) []byte {
+	iv := make([]byte, 12)
+	binary.BigEndian.PutUint32(iv[2:], ssrc)
+	binary.BigEndian.PutUint32(iv[8:], srtcpIndex&0x7fffffff)
+
+	for i := range iv {
+		iv[i] ^= c.srtcpSessionSalt[i]
+	}
+	return iv
+}
+
+// The header is the additional authenticated data, the payload is sealed
+// along with the tag https://tools.ietf.org/html/rfc7714#section-8.2
+func (c *Context) decryptRTPGCM(packet *rtp.Packet) bool {
+	if len(packet.Payload) < gcmTagSize || len(packet.Raw) < packet.PayloadOffset+gcmTagSize {
+		return false
+	}
+
+	s := c.getSSRCState(packet.SSRC)
+
+	// The rollover counter and replay window are only updated once the
+	// packet is authentic
+	rolloverCounter := s.nextRolloverCount(packet.SequenceNumber)
+	if s.replayWindow.isReplayed(packetIndex(packet.SequenceNumber, rolloverCounter)) {
+		return false
+	}
+
+	iv := c.rtpGCMIV(packet.SequenceNumber, rolloverCounter, s.ssrc)
+	payload, err := c.srtpGCM.Open(nil, iv, packet.Raw[packet.PayloadOffset:], packet.Raw[:packet.PayloadOffset])
+	if err != nil {
+		return false
+	}
+	s.updateRolloverCount(packet.SequenceNumber, rolloverCounter)
+
+	packet.Raw = append(packet.Raw[:packet.PayloadOffset], payload...)
+	packet.Payload = packet.Raw[packet.PayloadOffset:]
+	return true
+}
+
+func (c *Context) encryptRTPGCM(packet *rtp.Packet) bool {
+	s := c.getSSRCState(packet.SSRC)
+
+	rolloverCounter := s.nextRolloverCount(packet.SequenceNumber)
+	s.updateRolloverCount(packet.SequenceNumber, rolloverCounter)
+
+	header, err := packet.Header.Marshal()
+	if err != nil {
+		return false
+	}
+
+	iv := c.rtpGCMIV(packet.SequenceNumber, rolloverCounter, s.ssrc)
+	packet.Payload = c.srtpGCM.Seal(nil, iv, packet.Payload, header)
+	packet.Raw = append(header, packet.Payload...)
+	return true
+}
+
+// The RTCP header and the E flag with the SRTCP index are the additional
+// authenticated data https://tools.ietf.org/html/rfc7714#section-9.2
+func (c *Context) decryptRTCPGCM(encrypted []byte) ([]byte, error) {
+	if len(encrypted) < srtcpHeaderSize+gcmTagSize+srtcpIndexSize {
+		return nil, errors.Errorf("SRTCP packet is too short, %d bytes", len(encrypted))
+	}
+
+	tailOffset := len(encrypted) - srtcpIndexSize
+	if encrypted[tailOffset]>>7 == 0 {
+		return nil, errors.New("unencrypted SRTCP is not supported by the AEAD profiles")
+	}
+
+	replayWindow, index := c.getSRTCPReplayWindow(encrypted, tailOffset)
+	if replayWindow.isReplayed(index) {
+		return nil, errors.Errorf("SRTCP packet with index %d is a replay", index)
+	}
+	ssrc := binary.BigEndian.Uint32(encrypted[4:])
+
+	aad := append([]byte{}, encrypted[:srtcpHeaderSize]...)
+	aad = append(aad, encrypted[tailOffset:]...)
+
+	out := append([]byte{}, encrypted[:srtcpHeaderSize]...)
+	out, err := c.srtcpGCM.Open(out, c.rtcpGCMIV(uint32(index), ssrc), encrypted[srtcpHeaderSize:tailOffset], aad)
+	if err != nil {
+		return nil, errors.New("SRTCP packet failed authentication")
+	}
+	replayWindow.accept(index)
+	return out, nil
+}
+
+func (c *Context) encryptRTCPGCM(decrypted []byte) ([]byte, error) {
+	ssrc := binary.BigEndian.Uint32(decrypted[4:])
+
+	// We roll over early because MSB is used for marking as encrypted
+	c.srtcpIndex++
+	if c.srtcpIndex >= 2147483647 {
+		c.srtcpIndex = 0
+	}
+
+	// E flag and SRTCP index
+	trailer := make([]byte, srtcpIndexSize)
+	binary.BigEndian.PutUint32(trailer, c.srtcpIndex)
+	trailer[0] |= 0x80
+
+	aad := append([]byte{}, decrypted[:srtcpHeaderSize]...)
+	aad = append(aad, trailer...)
+
+	out := append([]byte{}, decrypted[:srtcpHeaderSize]...)
+	out = c.srtcpGCM.Seal(out, c.rtcpGCMIV(c.srtcpIndex, ssrc), decrypted[srtcpHeaderSize:], aad)
+	return append(out, trailer...), nil
+}
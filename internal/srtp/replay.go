@@ -0,0 +1,50 @@
+package srtp
+
+// replayWindowSize is the number of packet indexes behind the highest one
+// that are remembered, the minimum https://tools.ietf.org/html/rfc3711#section-3.3.2
+// allows
+const replayWindowSize = 64
+
+// replayWindow is the sliding window of
+// https://tools.ietf.org/html/rfc3711#section-3.3.2, it tracks which of the
+// most recent packet indexes have been received. An index older than the
+// window is always considered a replay.
+type replayWindow struct {
+	started bool
+	highest uint64
+	mask    uint64 // bit n is set if highest-n has been received
+}
+
+// isReplayed returns true if the index has already been received, or is too
+// old to tell
+func (w *replayWindow) isReplayed(index uint64) bool {
+	if !w.started || index > w.highest {
+		return false
+	}
+
+	diff := w.highest - index
+	if diff >= replayWindowSize {
+		return true
+	}
+	return w.mask&(1<<diff) != 0
+}
+
+// accept marks an authentic index as received, sliding the window forward
+// when it is the highest so far
+func (w *replayWindow) accept(index uint64) {
+	switch {
+	case !w.started:
+		w.started = true
+		w.highest = index
+		w.mask = 1
+	case index > w.highest:
+		if diff := index - w.highest; diff < replayWindowSize {
+			w.mask = w.mask<<diff | 1
+		} else {
+			w.mask = 1
+		}
+		w.highest = index
+	case w.highest-index < replayWindowSize:
+		w.mask |= 1 << (w.highest - index)
+	}
+}
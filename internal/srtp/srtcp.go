@@ -2,13 +2,44 @@ package srtp
 
 import (
 	"crypto/cipher"
+	"crypto/hmac"
 	"encoding/binary"
+
+	"github.com/pkg/errors"
 )
 
+// srtcpHeaderSize is the part of an SRTCP packet that is never encrypted,
+// the first RTCP header and the sender's SSRC
+const srtcpHeaderSize = 8
+
 // DecryptRTCP decrypts a buffer that contains a RTCP packet
 // We can't pass *rtcp.Packet as the encrypt will obscure significant fields
 func (c *Context) DecryptRTCP(encrypted []byte) ([]byte, error) {
+	if c.aead {
+		return c.decryptRTCPGCM(encrypted)
+	}
+
+	if len(encrypted) < srtcpHeaderSize+srtcpIndexSize+authTagSize {
+		return nil, errors.Errorf("SRTCP packet is too short, %d bytes", len(encrypted))
+	}
+
+	// https://tools.ietf.org/html/rfc3711#section-3.4
+	// The authentication tag covers the packet up to and including the
+	// E flag and SRTCP index
 	tailOffset := len(encrypted) - (authTagSize + srtcpIndexSize)
+	replayWindow, index := c.getSRTCPReplayWindow(encrypted, tailOffset)
+	if replayWindow.isReplayed(index) {
+		return nil, errors.Errorf("SRTCP packet with index %d is a replay", index)
+	}
+
+	authTag, err := c.generateAuthTag(encrypted[:len(encrypted)-authTagSize], c.srtcpSessionAuthTag)
+	if err != nil {
+		return nil, err
+	} else if !hmac.Equal(authTag, encrypted[len(encrypted)-authTagSize:]) {
+		return nil, errors.New("SRTCP packet failed authentication")
+	}
+	replayWindow.accept(index)
+
 	out := append([]byte{}, encrypted[0:tailOffset]...)
 
 	isEncrypted := encrypted[tailOffset] >> 7
@@ -16,20 +47,22 @@ func (c *Context) DecryptRTCP(encrypted []byte) ([]byte, error) {
 		return out, nil
 	}
 
-	srtcpIndexBuffer := append([]byte{}, encrypted[tailOffset:tailOffset+srtcpIndexSize]...)
-	srtcpIndexBuffer[0] &= 0x7f // unset Encryption bit
-
-	index := binary.BigEndian.Uint32(srtcpIndexBuffer)
 	ssrc := binary.BigEndian.Uint32(encrypted[4:])
 
-	stream := cipher.NewCTR(c.srtcpBlock, c.generateCounter(uint16(index&0xffff), index>>16, ssrc, c.srtcpSessionSalt))
-	stream.XORKeyStream(out[8:], out[8:])
+	stream := cipher.NewCTR(c.srtcpBlock, c.generateCounter(uint16(index&0xffff), uint32(index>>16), ssrc, c.srtcpSessionSalt))
+	stream.XORKeyStream(out[srtcpHeaderSize:], out[srtcpHeaderSize:])
 
 	return out, nil
 }
 
 // EncryptRTCP encrypts a buffer that contains a RTCP packet
 func (c *Context) EncryptRTCP(decrypted []byte) ([]byte, error) {
+	if len(decrypted) < srtcpHeaderSize {
+		return nil, errors.Errorf("RTCP packet is too short, %d bytes", len(decrypted))
+	} else if c.aead {
+		return c.encryptRTCPGCM(decrypted)
+	}
+
 	out := append([]byte{}, decrypted[:]...)
 	ssrc := binary.BigEndian.Uint32(decrypted[4:])
 
@@ -41,7 +74,7 @@ func (c *Context) EncryptRTCP(decrypted []byte) ([]byte, error) {
 
 	// Encrypt everything after header
 	stream := cipher.NewCTR(c.srtcpBlock, c.generateCounter(uint16(c.srtcpIndex&0xffff), c.srtcpIndex>>16, ssrc, c.srtcpSessionSalt))
-	stream.XORKeyStream(out[8:], out[8:])
+	stream.XORKeyStream(out[srtcpHeaderSize:], out[srtcpHeaderSize:])
 
 	// Add SRTCP Index and set Encryption bit
 	out = append(out, make([]byte, 4)...)
@@ -54,3 +87,17 @@ func (c *Context) EncryptRTCP(decrypted []byte) ([]byte, error) {
 	}
 	return append(out, authTag...), nil
 }
+
+// getSRTCPReplayWindow returns the replay window of the sender of a SRTCP
+// packet, along with the packet's SRTCP index without the E flag
+func (c *Context) getSRTCPReplayWindow(encrypted []byte, indexOffset int) (*replayWindow, uint64) {
+	index := binary.BigEndian.Uint32(encrypted[indexOffset:]) & 0x7fffffff
+	ssrc := binary.BigEndian.Uint32(encrypted[4:])
+
+	w, ok := c.srtcpReplayWindows[ssrc]
+	if !ok {
+		w = &replayWindow{}
+		c.srtcpReplayWindows[ssrc] = w
+	}
+	return w, uint64(index)
+}
@@ -2,22 +2,50 @@ package srtp
 
 import (
 	"crypto/cipher"
+	"crypto/hmac"
 	"encoding/binary"
 
 	"github.com/pions/webrtc/pkg/rtp"
 )
 
-// DecryptRTP decrypts a RTP packet with an encrypted payload
+// DecryptRTP decrypts a RTP packet with an encrypted payload, it returns
+// false if the packet fails authentication
 func (c *Context) DecryptRTP(packet *rtp.Packet) bool {
+	if c.aead {
+		return c.decryptRTPGCM(packet)
+	}
+
+	if len(packet.Payload) < authTagSize || len(packet.Raw) < packet.PayloadOffset+authTagSize {
+		return false
+	}
+
 	s := c.getSSRCState(packet.SSRC)
 
-	c.updateRolloverCount(packet.SequenceNumber, s)
+	// The rollover counter and replay window are only updated once the
+	// packet is authentic
+	rolloverCounter := s.nextRolloverCount(packet.SequenceNumber)
+	if s.replayWindow.isReplayed(packetIndex(packet.SequenceNumber, rolloverCounter)) {
+		return false
+	}
 
-	stream := cipher.NewCTR(c.srtpBlock, c.generateCounter(packet.SequenceNumber, s.rolloverCounter, s.ssrc, c.srtpSessionSalt))
-	stream.XORKeyStream(packet.Payload, packet.Payload)
+	// https://tools.ietf.org/html/rfc3711#section-3.3
+	// The authentication tag covers the header and the encrypted payload,
+	// followed by the ROC
+	tailOffset := len(packet.Raw) - authTagSize
+	authenticated := append([]byte{}, packet.Raw[:tailOffset]...)
+	authenticated = append(authenticated, make([]byte, 4)...)
+	binary.BigEndian.PutUint32(authenticated[tailOffset:], rolloverCounter)
 
-	// TODO remove tags, need to assert value
-	packet.Payload = packet.Payload[:len(packet.Payload)-10]
+	authTag, err := c.generateAuthTag(authenticated, c.srtpSessionAuthTag)
+	if err != nil || !hmac.Equal(authTag, packet.Raw[tailOffset:]) {
+		return false
+	}
+	s.updateRolloverCount(packet.SequenceNumber, rolloverCounter)
+
+	packet.Payload = packet.Payload[:len(packet.Payload)-authTagSize]
+
+	stream := cipher.NewCTR(c.srtpBlock, c.generateCounter(packet.SequenceNumber, rolloverCounter, s.ssrc, c.srtpSessionSalt))
+	stream.XORKeyStream(packet.Payload, packet.Payload)
 
 	// Replace payload with decrypted
 	packet.Raw = packet.Raw[0:packet.PayloadOffset]
@@ -28,11 +56,16 @@ func (c *Context) DecryptRTP(packet *rtp.Packet) bool {
 
 // EncryptRTP Encrypts a SRTP packet in place
 func (c *Context) EncryptRTP(packet *rtp.Packet) bool {
+	if c.aead {
+		return c.encryptRTPGCM(packet)
+	}
+
 	s := c.getSSRCState(packet.SSRC)
 
-	c.updateRolloverCount(packet.SequenceNumber, s)
+	rolloverCounter := s.nextRolloverCount(packet.SequenceNumber)
+	s.updateRolloverCount(packet.SequenceNumber, rolloverCounter)
 
-	stream := cipher.NewCTR(c.srtpBlock, c.generateCounter(packet.SequenceNumber, s.rolloverCounter, s.ssrc, c.srtpSessionSalt))
+	stream := cipher.NewCTR(c.srtpBlock, c.generateCounter(packet.SequenceNumber, rolloverCounter, s.ssrc, c.srtpSessionSalt))
 	stream.XORKeyStream(packet.Payload, packet.Payload)
 
 	fullPkt, err := packet.Marshal()
@@ -41,7 +74,7 @@ func (c *Context) EncryptRTP(packet *rtp.Packet) bool {
 	}
 
 	fullPkt = append(fullPkt, make([]byte, 4)...)
-	binary.BigEndian.PutUint32(fullPkt[len(fullPkt)-4:], s.rolloverCounter)
+	binary.BigEndian.PutUint32(fullPkt[len(fullPkt)-4:], rolloverCounter)
 
 	authTag, err := c.generateAuthTag(fullPkt, c.srtpSessionAuthTag)
 	if err != nil {
@@ -49,30 +82,45 @@ func (c *Context) EncryptRTP(packet *rtp.Packet) bool {
 	}
 
 	packet.Payload = append(packet.Payload, authTag...)
+	packet.Raw = append(packet.Raw, authTag...)
 	return true
 }
 
-// https://tools.ietf.org/html/rfc3550#appendix-A.1
-func (c *Context) updateRolloverCount(sequenceNumber uint16, s *ssrcState) {
+// nextRolloverCount estimates the ROC of a packet from the highest sequence
+// number processed so far, a packet from before a wrap gets the previous ROC
+// and one from after it the next https://tools.ietf.org/html/rfc3711#appendix-A
+func (s *ssrcState) nextRolloverCount(sequenceNumber uint16) uint32 {
 	if !s.rolloverHasProcessed {
-		s.rolloverHasProcessed = true
-	} else if sequenceNumber == 0 { // We exactly hit the rollover count
+		return s.rolloverCounter
+	}
 
-		// Only update rolloverCounter if lastSequenceNumber is greater then maxROCDisorder
-		// otherwise we already incremented for disorder
-		if s.lastSequenceNumber > maxROCDisorder {
-			s.rolloverCounter++
+	if s.lastSequenceNumber < seqNumMedian {
+		// There is no wrap before the first ROC to go back across
+		if int(sequenceNumber)-int(s.lastSequenceNumber) > seqNumMedian && s.rolloverCounter > 0 {
+			return s.rolloverCounter - 1
 		}
-	} else if s.lastSequenceNumber < maxROCDisorder && sequenceNumber > (maxSequenceNumber-maxROCDisorder) {
-		// Our last sequence number incremented because we crossed 0, but then our current number was within maxROCDisorder of the max
-		// So we fell behind, drop to account for jitter
-		s.rolloverCounter--
-	} else if sequenceNumber < maxROCDisorder && s.lastSequenceNumber > (maxSequenceNumber-maxROCDisorder) {
-		// our current is within a maxROCDisorder of 0
-		// and our last sequence number was a high sequence number, increment to account for jitter
-		s.rolloverCounter++
+	} else if int(s.lastSequenceNumber)-seqNumMedian > int(sequenceNumber) {
+		return s.rolloverCounter + 1
 	}
-	s.lastSequenceNumber = sequenceNumber
+	return s.rolloverCounter
+}
+
+// updateRolloverCount records a processed packet, ROC and s_l only move
+// forward so a late packet doesn't pull the estimate back across a wrap
+func (s *ssrcState) updateRolloverCount(sequenceNumber uint16, rolloverCounter uint32) {
+	index := packetIndex(sequenceNumber, rolloverCounter)
+	if !s.rolloverHasProcessed || index > packetIndex(s.lastSequenceNumber, s.rolloverCounter) {
+		s.rolloverHasProcessed = true
+		s.rolloverCounter = rolloverCounter
+		s.lastSequenceNumber = sequenceNumber
+	}
+	s.replayWindow.accept(index)
+}
+
+// packetIndex is the 48-bit SRTP packet index i = 2^16 * ROC + SEQ
+// https://tools.ietf.org/html/rfc3711#section-3.3.1
+func packetIndex(sequenceNumber uint16, rolloverCounter uint32) uint64 {
+	return uint64(rolloverCounter)<<16 | uint64(sequenceNumber)
 }
 
 func (c *Context) getSSRCState(ssrc uint32) *ssrcState {
@@ -85,3 +133,20 @@ func (c *Context) getSSRCState(ssrc uint32) *ssrcState {
 	c.ssrcStates[ssrc] = s
 	return s
 }
+
+// ROC returns the rollover counter of the highest packet processed for an
+// SSRC, it returns false if no packet has been processed for it yet
+func (c *Context) ROC(ssrc uint32) (uint32, bool) {
+	s, ok := c.ssrcStates[ssrc]
+	if !ok || !s.rolloverHasProcessed {
+		return 0, false
+	}
+	return s.rolloverCounter, true
+}
+
+// SetROC sets the rollover counter of an SSRC, so a Context can take over a
+// stream another Context has been processing. It resets the SSRC's replay
+// window, the next packet is estimated against the given ROC.
+func (c *Context) SetROC(ssrc uint32, rolloverCounter uint32) {
+	c.ssrcStates[ssrc] = &ssrcState{ssrc: ssrc, rolloverCounter: rolloverCounter}
+}
@@ -2,6 +2,8 @@ package srtp
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
 	"testing"
 
 	"github.com/pions/webrtc/pkg/rtp"
@@ -18,6 +20,11 @@ type rtpTestCase struct {
 }
 
 func TestKeyLen(t *testing.T) {
+	keyLen, saltLen, err := KeyLen(cipherContextAlgo)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "KeyLen failed"))
+	}
+
 	if _, err := CreateContext([]byte{}, make([]byte, saltLen), cipherContextAlgo); err == nil {
 		t.Errorf("CreateContext accepted a 0 length key")
 	}
@@ -26,9 +33,21 @@ func TestKeyLen(t *testing.T) {
 		t.Errorf("CreateContext accepted a 0 length salt")
 	}
 
+	if _, err := CreateContext(make([]byte, keyLen), make([]byte, saltLen), "SRTP_AES128_CM_SHA1_32"); err == nil {
+		t.Errorf("CreateContext accepted an unsupported protection profile")
+	}
+
 	if _, err := CreateContext(make([]byte, keyLen), make([]byte, saltLen), cipherContextAlgo); err != nil {
 		t.Error(errors.Wrap(err, "CreateContext failed with a valid length key and salt"))
 	}
+
+	if _, err := CreateContext(make([]byte, 16), make([]byte, 12), ProtectionProfileAEADAES256GCM); err == nil {
+		t.Errorf("CreateContext accepted a 16 byte key for AEAD_AES_256_GCM")
+	}
+
+	if _, err := CreateContext(make([]byte, 32), make([]byte, 12), ProtectionProfileAEADAES256GCM); err != nil {
+		t.Error(errors.Wrap(err, "CreateContext failed with a valid AEAD_AES_256_GCM key and salt"))
+	}
 }
 
 func TestValidSessionKeys(t *testing.T) {
@@ -85,41 +104,131 @@ func TestValidPacketCounter(t *testing.T) {
 }
 
 func TestRolloverCount(t *testing.T) {
-	masterKey := []byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89}
-	masterSalt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
-
-	c, err := CreateContext(masterKey, masterSalt, cipherContextAlgo)
-	if err != nil {
-		t.Error(errors.Wrap(err, "CreateContext failed"))
-	}
-
 	s := &ssrcState{ssrc: defaultSsrc}
+	process := func(sequenceNumber uint16) uint32 {
+		rolloverCounter := s.nextRolloverCount(sequenceNumber)
+		s.updateRolloverCount(sequenceNumber, rolloverCounter)
+		return rolloverCounter
+	}
 
 	// Set initial seqnum
-	c.updateRolloverCount(65530, s)
+	if process(65530) != 0 {
+		t.Errorf("rolloverCounter was not 0 for the first packet")
+	}
 
 	// We rolled over to 0
-	c.updateRolloverCount(0, s)
-	if s.rolloverCounter != 1 {
+	if process(0) != 1 || s.rolloverCounter != 1 {
 		t.Errorf("rolloverCounter was not updated after it crossed 0")
 	}
 
-	c.updateRolloverCount(65530, s)
-	if s.rolloverCounter != 0 {
-		t.Errorf("rolloverCounter was not updated when it rolled back, failed to handle out of order")
+	// A late packet from before the wrap
+	if process(65531) != 0 {
+		t.Errorf("rolloverCounter was not estimated for a packet from before the wrap, failed to handle out of order")
+	} else if s.rolloverCounter != 1 || s.lastSequenceNumber != 0 {
+		t.Errorf("A late packet moved the highest processed index back")
 	}
 
-	c.updateRolloverCount(5, s)
-	if s.rolloverCounter != 1 {
-		t.Errorf("rolloverCounter was not updated when it rolled over initial, to handle out of order")
+	if process(5) != 1 {
+		t.Errorf("rolloverCounter was not kept after a late packet")
 	}
 
-	c.updateRolloverCount(6, s)
-	c.updateRolloverCount(7, s)
-	c.updateRolloverCount(8, s)
+	process(6)
+	process(7)
+	process(8)
 	if s.rolloverCounter != 1 {
 		t.Errorf("rolloverCounter was improperly updated for non-significant packets")
 	}
+
+	// Jumps shorter than half the sequence space keep the ROC until the wrap
+	if process(30000) != 1 || process(60000) != 1 || process(10) != 2 {
+		t.Errorf("rolloverCounter was not estimated across a second wrap")
+	}
+}
+
+func TestReplayProtection(t *testing.T) {
+	assert := assert.New(t)
+	masterKey := []byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89}
+	masterSalt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
+
+	encryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+	decryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+
+	encrypted := map[uint16][]byte{}
+	for _, sequenceNumber := range []uint16{65500, 65535, 0, 100, 200} {
+		pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: sequenceNumber}, Payload: []byte{0x00, 0x01}}
+		assert.True(encryptContext.EncryptRTP(pkt))
+		encrypted[sequenceNumber] = append([]byte{}, pkt.Raw...)
+	}
+	decrypt := func(sequenceNumber uint16) bool {
+		pkt := &rtp.Packet{}
+		assert.NoError(pkt.Unmarshal(append([]byte{}, encrypted[sequenceNumber]...)))
+		return decryptContext.DecryptRTP(pkt)
+	}
+
+	assert.True(decrypt(65535))
+	assert.False(decrypt(65535), "a packet was accepted twice")
+	assert.True(decrypt(0))
+	assert.True(decrypt(65500), "a late packet inside the window was rejected")
+	assert.False(decrypt(65500), "a late packet was accepted twice")
+	assert.True(decrypt(200))
+	assert.False(decrypt(100), "a packet older than the window was accepted")
+
+	rtcp := []byte{0x80, 0xc8, 0x00, 0x01, 0x66, 0xef, 0x91, 0xff, 0x01, 0x02}
+	first, err := encryptContext.EncryptRTCP(rtcp)
+	assert.NoError(err)
+	second, err := encryptContext.EncryptRTCP(rtcp)
+	assert.NoError(err)
+
+	_, err = decryptContext.DecryptRTCP(second)
+	assert.NoError(err)
+	_, err = decryptContext.DecryptRTCP(second)
+	assert.Error(err, "a SRTCP packet was accepted twice")
+	_, err = decryptContext.DecryptRTCP(first)
+	assert.NoError(err, "a late SRTCP packet inside the window was rejected")
+}
+
+func TestROCExportImport(t *testing.T) {
+	assert := assert.New(t)
+	masterKey := []byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89}
+	masterSalt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
+
+	encryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+
+	_, ok := encryptContext.ROC(defaultSsrc)
+	assert.False(ok)
+
+	for _, sequenceNumber := range []uint16{65535, 0, 1} {
+		assert.True(encryptContext.EncryptRTP(&rtp.Packet{Header: rtp.Header{SequenceNumber: sequenceNumber}, Payload: []byte{0x00}}))
+	}
+	rolloverCounter, ok := encryptContext.ROC(defaultSsrc)
+	assert.True(ok)
+	assert.Equal(uint32(1), rolloverCounter)
+
+	pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: 2}, Payload: []byte{0x00, 0x01}}
+	assert.True(encryptContext.EncryptRTP(pkt))
+
+	// A Context that joins mid-stream can't authenticate without the ROC
+	decryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+	joined := &rtp.Packet{}
+	assert.NoError(joined.Unmarshal(append([]byte{}, pkt.Raw...)))
+	assert.False(decryptContext.DecryptRTP(joined))
+
+	decryptContext.SetROC(defaultSsrc, rolloverCounter)
+	assert.NoError(joined.Unmarshal(append([]byte{}, pkt.Raw...)))
+	assert.True(decryptContext.DecryptRTP(joined))
+	assert.Equal([]byte{0x00, 0x01}, joined.Payload)
 }
 
 func TestRTPLifecyle(t *testing.T) {
@@ -162,7 +271,7 @@ func TestRTPLifecyle(t *testing.T) {
 	}
 
 	for _, testCase := range testCases {
-		pkt := &rtp.Packet{Payload: append([]byte{}, decrypted...), SequenceNumber: testCase.sequenceNumber}
+		pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: testCase.sequenceNumber}, Payload: append([]byte{}, decrypted...)}
 		if !encryptContext.EncryptRTP(pkt) {
 			t.Errorf("Failed to encrypt RTP packet with SeqNum: %d", testCase.sequenceNumber)
 		}
@@ -207,3 +316,147 @@ func TestRTCPLifecycle(t *testing.T) {
 	assert.Equal(encryptResult, encrypted, "RTCP failed to encrypt")
 
 }
+
+func TestAuthentication(t *testing.T) {
+	assert := assert.New(t)
+	masterKey := []byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89}
+	masterSalt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
+
+	encryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+	decryptContext, err := CreateContext(masterKey, masterSalt, cipherContextAlgo)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "CreateContext failed"))
+	}
+
+	pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: 65535}, Payload: []byte{0x00, 0x01, 0x02, 0x03}}
+	assert.True(encryptContext.EncryptRTP(pkt))
+	raw := append([]byte{}, pkt.Raw...)
+
+	// A tampered packet is rejected
+	tampered := &rtp.Packet{}
+	assert.NoError(tampered.Unmarshal(append([]byte{}, raw...)))
+	tampered.Payload[0] ^= 0xff
+	assert.False(decryptContext.DecryptRTP(tampered))
+
+	// A packet cut short of its authentication tag as well
+	short := &rtp.Packet{}
+	assert.NoError(short.Unmarshal(append([]byte{}, raw[:len(raw)-authTagSize]...)))
+	assert.False(decryptContext.DecryptRTP(short))
+
+	authentic := &rtp.Packet{}
+	assert.NoError(authentic.Unmarshal(append([]byte{}, raw...)))
+	assert.True(decryptContext.DecryptRTP(authentic))
+	assert.Equal([]byte{0x00, 0x01, 0x02, 0x03}, authentic.Payload)
+
+	rtcpEncrypted, err := encryptContext.EncryptRTCP([]byte{0x80, 0xc8, 0x00, 0x01, 0x66, 0xef, 0x91, 0xff, 0x01, 0x02})
+	assert.NoError(err)
+	rtcpEncrypted[9] ^= 0xff
+	_, err = decryptContext.DecryptRTCP(rtcpEncrypted)
+	assert.Error(err)
+
+	_, err = decryptContext.DecryptRTCP(rtcpEncrypted[:srtcpHeaderSize+authTagSize])
+	assert.Error(err)
+}
+
+func TestGCMKnownAnswer(t *testing.T) {
+	assert := assert.New(t)
+
+	// https://tools.ietf.org/html/rfc7714#section-16.1.1
+	sessionKey := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	sessionSalt := []byte{0x51, 0x75, 0x69, 0x64, 0x20, 0x70, 0x72, 0x6f, 0x20, 0x71, 0x75, 0x6f}
+	encrypted := []byte{
+		0x80, 0x40, 0xf1, 0x7b, 0x80, 0x41, 0xf8, 0xd3, 0x55, 0x01, 0xa0, 0xb2,
+		0xf2, 0x4d, 0xe3, 0xa3, 0xfb, 0x34, 0xde, 0x6c, 0xac, 0xba, 0x86, 0x1c,
+		0x9d, 0x7e, 0x4b, 0xca, 0xbe, 0x63, 0x3b, 0xd5, 0x0d, 0x29, 0x4e, 0x6f,
+		0x42, 0xa5, 0xf4, 0x7a, 0x51, 0xc7, 0xd1, 0x9b, 0x36, 0xde, 0x3a, 0xdf,
+		0x88, 0x33, 0x89, 0x9d, 0x7f, 0x27, 0xbe, 0xb1, 0x6a, 0x91, 0x52, 0xcf,
+		0x76, 0x5e, 0xe4, 0x39, 0x0c, 0xce,
+	}
+	decrypted := []byte("Gallia est omnis divisa in partes tres")
+
+	newContext := func() *Context {
+		block, err := aes.NewCipher(sessionKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Context{aead: true, srtpSessionSalt: sessionSalt, srtpGCM: gcm, ssrcStates: map[uint32]*ssrcState{}}
+	}
+
+	pkt := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    0x40,
+			SequenceNumber: 0xf17b,
+			Timestamp:      0x8041f8d3,
+			SSRC:           0x5501a0b2,
+		},
+		Payload: append([]byte{}, decrypted...),
+	}
+	assert.True(newContext().EncryptRTP(pkt))
+	assert.Equal(encrypted, pkt.Raw)
+
+	received := &rtp.Packet{}
+	assert.NoError(received.Unmarshal(append([]byte{}, encrypted...)))
+	assert.True(newContext().DecryptRTP(received))
+	assert.Equal(decrypted, received.Payload)
+}
+
+func TestGCMLifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, profile := range []string{ProtectionProfileAEADAES128GCM, ProtectionProfileAEADAES256GCM} {
+		keyLen, saltLen, err := KeyLen(profile)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "KeyLen failed"))
+		}
+		masterKey := bytes.Repeat([]byte{0x0d}, keyLen)
+		masterSalt := bytes.Repeat([]byte{0x62}, saltLen)
+
+		encryptContext, err := CreateContext(masterKey, masterSalt, profile)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "CreateContext failed"))
+		}
+		decryptContext, err := CreateContext(masterKey, masterSalt, profile)
+		if err != nil {
+			t.Fatal(errors.Wrap(err, "CreateContext failed"))
+		}
+
+		for _, sequenceNumber := range []uint16{65534, 65535, 0, 1} {
+			pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: sequenceNumber}, Payload: []byte{0x00, 0x01, 0x02, 0x03}}
+			assert.True(encryptContext.EncryptRTP(pkt), profile)
+			assert.Len(pkt.Payload, 4+gcmTagSize, profile)
+			raw := append([]byte{}, pkt.Raw...)
+
+			tampered := &rtp.Packet{}
+			assert.NoError(tampered.Unmarshal(append([]byte{}, raw...)))
+			tampered.Raw[1] ^= 0x01
+			assert.False(decryptContext.DecryptRTP(tampered), profile)
+
+			authentic := &rtp.Packet{}
+			assert.NoError(authentic.Unmarshal(append([]byte{}, raw...)))
+			assert.True(decryptContext.DecryptRTP(authentic), profile)
+			assert.Equal([]byte{0x00, 0x01, 0x02, 0x03}, authentic.Payload, profile)
+		}
+
+		rtcp := []byte{0x80, 0xc8, 0x00, 0x01, 0x66, 0xef, 0x91, 0xff, 0x01, 0x02, 0x03, 0x04}
+		rtcpEncrypted, err := encryptContext.EncryptRTCP(rtcp)
+		assert.NoError(err, profile)
+		assert.Len(rtcpEncrypted, len(rtcp)+gcmTagSize+srtcpIndexSize, profile)
+
+		rtcpDecrypted, err := decryptContext.DecryptRTCP(rtcpEncrypted)
+		assert.NoError(err, profile)
+		assert.Equal(rtcp, rtcpDecrypted, profile)
+
+		// The SRTCP index is authenticated along with the header
+		rtcpEncrypted[len(rtcpEncrypted)-1] ^= 0x01
+		_, err = decryptContext.DecryptRTCP(rtcpEncrypted)
+		assert.Error(err, profile)
+	}
+}
@@ -1,10 +1,20 @@
 package webrtc
 
 import (
+	"io"
+	"strings"
+	"sync/atomic"
+
 	"github.com/pions/webrtc/pkg/media"
+	"github.com/pions/webrtc/pkg/media/samplebuilder"
 	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pions/webrtc/pkg/rtp/codecs"
 )
 
+// sampleBuilderMaxLate is how many packets ReadSample waits for the ones
+// missing from a sample
+const sampleBuilderMaxLate = 256
+
 // RTCSample contains media, and the amount of samples in it
 //
 // Deprecated: use RTCSample from github.com/pions/webrtc/pkg/media instead
@@ -20,4 +30,119 @@ type RTCTrack struct {
 	Codec       *RTCRtpCodec
 	Packets     <-chan *rtp.Packet
 	Samples     chan<- media.RTCSample
+
+	// HeaderExtensions are the RTP header extensions negotiated for the
+	// packets of a received track
+	HeaderExtensions rtp.HeaderExtensionMap
+
+	// sending is set while a sender of the connection sends the samples
+	// of a local track, they are dropped otherwise
+	sending int32
+
+	// done is closed when the connection a received track arrives on is
+	// closed
+	done chan struct{}
+
+	receiver *RTCRtpReceiver
+
+	sampleBuilder *samplebuilder.SampleBuilder
+}
+
+// Receiver returns the RTCRtpReceiver of a received track, nil for a local
+// track
+func (t *RTCTrack) Receiver() *RTCRtpReceiver {
+	return t.receiver
+}
+
+// ReadRTP blocks until the next RTP packet of a received track arrives. It
+// returns io.EOF once the connection is closed.
+func (t *RTCTrack) ReadRTP() (*rtp.Packet, error) {
+	if t.Packets == nil {
+		return nil, ErrNotReceivedTrack
+	}
+
+	select {
+	case p := <-t.Packets:
+		return p, nil
+	case <-t.done:
+		return nil, io.EOF
+	}
+}
+
+// Read reads the next RTP packet of a received track into b, as it was
+// sent but decrypted
+func (t *RTCTrack) Read(b []byte) (int, error) {
+	p, err := t.ReadRTP()
+	if err != nil {
+		return 0, err
+	}
+
+	raw := p.Raw
+	if raw == nil {
+		if raw, err = p.Marshal(); err != nil {
+			return 0, err
+		}
+	}
+	if len(b) < len(raw) {
+		return 0, io.ErrShortBuffer
+	}
+	return copy(b, raw), nil
+}
+
+// ReadSample blocks until the next sample of a received track is complete,
+// depacketized with its codec. The packets of samples that are lost in
+// part are dropped.
+func (t *RTCTrack) ReadSample() (*media.RTCSample, error) {
+	if t.sampleBuilder == nil {
+		t.sampleBuilder = newSampleBuilder(t.Codec)
+	}
+
+	for {
+		if sample := t.sampleBuilder.Pop(); sample != nil {
+			return sample, nil
+		}
+
+		p, err := t.ReadRTP()
+		if err != nil {
+			return nil, err
+		}
+		t.sampleBuilder.Push(p)
+	}
+}
+
+func (t *RTCTrack) setSending(sending bool) {
+	var value int32
+	if sending {
+		value = 1
+	}
+	atomic.StoreInt32(&t.sending, value)
+}
+
+func (t *RTCTrack) isSending() bool {
+	return atomic.LoadInt32(&t.sending) == 1
+}
+
+// newSampleBuilder returns a SampleBuilder that depacketizes the media of
+// the codec, the payloads are the media of codecs it doesn't know
+func newSampleBuilder(codec *RTCRtpCodec) *samplebuilder.SampleBuilder {
+	var depacketizer rtp.Depacketizer
+	for _, c := range []codecs.Codec{codecs.OpusCodec, codecs.VP8Codec, codecs.VP9Codec, codecs.AV1Codec, codecs.H264Codec} {
+		if strings.EqualFold(c.Name, codec.Name) && c.NewDepacketizer != nil {
+			depacketizer = c.NewDepacketizer()
+		}
+	}
+
+	// The first packets of video frames are told by their payloads
+	var opts []samplebuilder.Option
+	switch strings.ToLower(codec.Name) {
+	case "vp8":
+		opts = append(opts, samplebuilder.WithPartitionHeadChecker(&codecs.VP8PartitionHeadChecker{}))
+	case "vp9":
+		opts = append(opts, samplebuilder.WithPartitionHeadChecker(&codecs.VP9PartitionHeadChecker{}))
+	case "av1":
+		opts = append(opts, samplebuilder.WithPartitionHeadChecker(&codecs.AV1PartitionHeadChecker{}))
+	case "h264":
+		opts = append(opts, samplebuilder.WithPartitionHeadChecker(&codecs.H264PartitionHeadChecker{}))
+	}
+	return samplebuilder.New(sampleBuilderMaxLate, depacketizer, codec.ClockRate, opts...)
 }
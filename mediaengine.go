@@ -42,7 +42,15 @@ func NewMediaEngine() *MediaEngine {
 
 // MediaEngine defines the codecs supported by a RTCPeerConnection
 type MediaEngine struct {
-	codecs []*RTCRtpCodec
+	codecs           []*RTCRtpCodec
+	headerExtensions []mediaEngineHeaderExtension
+}
+
+// mediaEngineHeaderExtension is an RTP header extension registered for the
+// media sections of a kind
+type mediaEngineHeaderExtension struct {
+	RTCRtpHeaderExtensionCapability
+	kind RTCRtpCodecType
 }
 
 // RegisterCodec registers a codec to a media engine
@@ -52,6 +60,34 @@ func (m *MediaEngine) RegisterCodec(codec *RTCRtpCodec) uint8 {
 	return codec.PayloadType
 }
 
+// RegisterHeaderExtension registers an RTP header extension, like the ones
+// of the rtp package, to be negotiated for the media sections of kind
+func (m *MediaEngine) RegisterHeaderExtension(extension RTCRtpHeaderExtensionCapability, kind RTCRtpCodecType) {
+	m.headerExtensions = append(m.headerExtensions, mediaEngineHeaderExtension{extension, kind})
+}
+
+// getHeaderExtensionsByKind returns the extensions registered for kind,
+// along with the IDs an offer maps them to. An extension registered for
+// several kinds has the same ID in all of them, as BUNDLE requires.
+// https://tools.ietf.org/html/draft-ietf-mmusic-sdp-bundle-negotiation-54#section-9.1.2
+func (m *MediaEngine) getHeaderExtensionsByKind(kind RTCRtpCodecType) []sdp.ExtMap {
+	var extMaps []sdp.ExtMap
+	for i, extension := range m.headerExtensions {
+		if extension.kind != kind {
+			continue
+		}
+		id := i + 1
+		for j := 0; j < i; j++ {
+			if m.headerExtensions[j].URI == extension.URI {
+				id = j + 1
+				break
+			}
+		}
+		extMaps = append(extMaps, sdp.ExtMap{ID: id, URI: extension.URI})
+	}
+	return extMaps
+}
+
 func (m *MediaEngine) getCodec(payloadType uint8) (*RTCRtpCodec, error) {
 	for _, codec := range m.codecs {
 		if codec.PayloadType == payloadType {
@@ -63,17 +99,26 @@ func (m *MediaEngine) getCodec(payloadType uint8) (*RTCRtpCodec, error) {
 
 func (m *MediaEngine) getCodecSDP(sdpCodec sdp.Codec) (*RTCRtpCodec, error) {
 	for _, codec := range m.codecs {
-		if codec.Name == sdpCodec.Name &&
-			codec.ClockRate == sdpCodec.ClockRate &&
-			(sdpCodec.EncodingParameters == "" ||
-				strconv.Itoa(int(codec.Channels)) == sdpCodec.EncodingParameters) &&
-			codec.SdpFmtpLine == sdpCodec.Fmtp { // TODO: Protocol specific matching?
+		if codec.sdpCodec().Matches(sdpCodec) {
 			return codec, nil
 		}
 	}
 	return nil, errors.New("Codec not found")
 }
 
+// negotiateCodecs matches the codecs of a remote media section with the
+// registered ones. It maps the payload types the remote uses to the codecs
+// they are for, the ones no codec is registered for are left out.
+func (m *MediaEngine) negotiateCodecs(remote []sdp.Codec) map[uint8]*RTCRtpCodec {
+	payloadTypes := make(map[uint8]*RTCRtpCodec)
+	for _, remoteCodec := range remote {
+		if codec, err := m.getCodecSDP(remoteCodec); err == nil {
+			payloadTypes[remoteCodec.PayloadType] = codec
+		}
+	}
+	return payloadTypes
+}
+
 func (m *MediaEngine) getCodecsByKind(kind RTCRtpCodecType) []*RTCRtpCodec {
 	var codecs []*RTCRtpCodec
 	for _, codec := range m.codecs {
@@ -90,6 +135,7 @@ const (
 	VP8  = "VP8"
 	VP9  = "VP9"
 	H264 = "H264"
+	RED  = "red"
 )
 
 // NewRTCRtpOpusCodec is a helper to create an Opus codec
@@ -104,6 +150,21 @@ func NewRTCRtpOpusCodec(payloadType uint8, clockrate uint32, channels uint16) *R
 	return c
 }
 
+// NewRTCRtpREDCodec is a helper to create a RED codec, RFC 2198, carrying
+// audio of primaryPayloadType along with redundant copies of its previous
+// frames. The primary codec must be registered as well.
+func NewRTCRtpREDCodec(payloadType uint8, clockrate uint32, channels uint16, primaryPayloadType uint8) *RTCRtpCodec {
+	primary := strconv.Itoa(int(primaryPayloadType))
+	c := NewRTCRtpCodec(RTCRtpCodecTypeAudio,
+		RED,
+		clockrate,
+		channels,
+		primary+"/"+primary,
+		payloadType,
+		&codecs.REDPayloader{PayloadType: primaryPayloadType, Redundancy: 1})
+	return c
+}
+
 // NewRTCRtpVP8Codec is a helper to create an VP8 codec
 func NewRTCRtpVP8Codec(payloadType uint8, clockrate uint32) *RTCRtpCodec {
 	c := NewRTCRtpCodec(RTCRtpCodecTypeVideo,
@@ -124,7 +185,7 @@ func NewRTCRtpVP9Codec(payloadType uint8, clockrate uint32) *RTCRtpCodec {
 		0,
 		"",
 		payloadType,
-		nil) // TODO
+		&codecs.VP9Payloader{})
 	return c
 }
 
@@ -152,6 +213,19 @@ const (
 	RTCRtpCodecTypeVideo
 )
 
+// NewRTCRtpCodecType creates a RTCRtpCodecType from the media of a media
+// section
+func NewRTCRtpCodecType(raw string) RTCRtpCodecType {
+	switch raw {
+	case "audio":
+		return RTCRtpCodecTypeAudio
+	case "video":
+		return RTCRtpCodecTypeVideo
+	default:
+		return RTCRtpCodecType(Unknown)
+	}
+}
+
 func (t RTCRtpCodecType) String() string {
 	switch t {
 	case RTCRtpCodecTypeAudio:
@@ -196,6 +270,20 @@ func NewRTCRtpCodec(
 	}
 }
 
+// sdpCodec returns the codec as it is described in a media section
+func (c *RTCRtpCodec) sdpCodec() sdp.Codec {
+	codec := sdp.Codec{
+		PayloadType: c.PayloadType,
+		Name:        c.Name,
+		ClockRate:   c.ClockRate,
+		Fmtp:        c.SdpFmtpLine,
+	}
+	if c.Channels > 0 {
+		codec.EncodingParameters = strconv.Itoa(int(c.Channels))
+	}
+	return codec
+}
+
 // RTCRtpCodecCapability provides information about codec capabilities.
 type RTCRtpCodecCapability struct {
 	MimeType    string
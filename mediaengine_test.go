@@ -0,0 +1,26 @@
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pions/webrtc/internal/sdp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateCodecs(t *testing.T) {
+	m := NewMediaEngine()
+	opus := NewRTCRtpOpusCodec(DefaultPayloadTypeOpus, 48000, 2)
+	h264 := NewRTCRtpH264Codec(DefaultPayloadTypeH264, 90000)
+	m.RegisterCodec(opus)
+	m.RegisterCodec(h264)
+
+	// The remote payload types are mapped to the registered codecs that
+	// match them, whatever payload types they are registered with
+	payloadTypes := m.negotiateCodecs([]sdp.Codec{
+		{PayloadType: 109, Name: "OPUS", ClockRate: 48000, EncodingParameters: "2", Fmtp: "stereo=1"},
+		{PayloadType: 102, Name: "H264", ClockRate: 90000, Fmtp: "profile-level-id=42e01f;packetization-mode=1"},
+		{PayloadType: 125, Name: "H264", ClockRate: 90000, Fmtp: "profile-level-id=420032;packetization-mode=1"},
+		{PayloadType: 120, Name: "VP8", ClockRate: 90000},
+	})
+	assert.Equal(t, map[uint8]*RTCRtpCodec{109: opus, 125: h264}, payloadTypes)
+}
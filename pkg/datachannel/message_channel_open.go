@@ -2,6 +2,7 @@ package datachannel
 
 import (
 	"encoding/binary"
+	"math"
 
 	"github.com/pkg/errors"
 )
@@ -84,6 +85,9 @@ const (
 func (c *ChannelOpen) Marshal() ([]byte, error) {
 	labelLength := len(c.Label)
 	protocolLength := len(c.Protocol)
+	if labelLength > math.MaxUint16 || protocolLength > math.MaxUint16 {
+		return nil, errors.Errorf("Label (%d bytes) and Protocol (%d bytes) must fit in 65535 bytes", labelLength, protocolLength)
+	}
 
 	totalLen := channelOpenHeaderLength + labelLength + protocolLength
 	raw := make([]byte, totalLen)
@@ -110,10 +114,10 @@ func (c *ChannelOpen) Unmarshal(raw []byte) error {
 	c.Priority = binary.BigEndian.Uint16(raw[2:])
 	c.ReliabilityParameter = binary.BigEndian.Uint32(raw[4:])
 
-	labelLength := binary.BigEndian.Uint16(raw[8:])
-	protocolLength := binary.BigEndian.Uint16(raw[10:])
+	labelLength := int(binary.BigEndian.Uint16(raw[8:]))
+	protocolLength := int(binary.BigEndian.Uint16(raw[10:]))
 
-	if len(raw) != int(channelOpenHeaderLength+labelLength+protocolLength) {
+	if len(raw) != channelOpenHeaderLength+labelLength+protocolLength {
 		return errors.Errorf("Label + Protocol length don't match full packet length")
 	}
 
@@ -96,3 +96,36 @@ func TestChannelAckUnmarshal(t *testing.T) {
 		t.Error(errors.Errorf("Failed to cast to ChannelAck"))
 	}
 }
+
+func TestChannelOpenLongLabelAndProtocol(t *testing.T) {
+	// Label and Protocol lengths add up to more than fits in a uint16
+	msg := ChannelOpen{
+		ChannelType: ChannelTypePartialReliableTimedUnordered,
+		Label:       make([]byte, 40000),
+		Protocol:    make([]byte, 40000),
+	}
+
+	rawMsg, err := msg.Marshal()
+	if err != nil {
+		t.Errorf("Failed to marshal: %v", err)
+		return
+	}
+
+	msgUncast, err := Parse(rawMsg)
+	if err != nil {
+		t.Errorf("Failed to parse: %v", err)
+		return
+	}
+
+	parsed, ok := msgUncast.(*ChannelOpen)
+	if !ok {
+		t.Error(errors.Errorf("Failed to cast to ChannelOpen"))
+	} else if parsed.ChannelType != msg.ChannelType || len(parsed.Label) != 40000 || len(parsed.Protocol) != 40000 {
+		t.Error(errors.Errorf("ChannelOpen did not survive a round trip"))
+	}
+
+	msg.Label = make([]byte, 70000)
+	if _, err := msg.Marshal(); err == nil {
+		t.Error(errors.Errorf("Marshal should fail for a Label longer than 65535 bytes"))
+	}
+}
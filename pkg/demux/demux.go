@@ -0,0 +1,62 @@
+// Package demux separates RTP and RTCP packets multiplexed on a single
+// port, as described in RFC 5761
+package demux
+
+import (
+	"github.com/pions/webrtc/pkg/rtcp"
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+const (
+	headerLength = 2
+	versionShift = 6
+	versionMask  = 0x3
+	rtpVersion   = 2
+
+	// RTCP packet types 192 to 223 overlap the RTP payload types 64 to 95
+	// with the marker bit set, which RFC 5761 Section 4 reserves for RTCP
+	rtcpTypeMin = 192
+	rtcpTypeMax = 223
+)
+
+// isVersion2 reports whether buf could hold an RTP or RTCP packet
+func isVersion2(buf []byte) bool {
+	return len(buf) >= headerLength && (buf[0]>>versionShift)&versionMask == rtpVersion
+}
+
+// IsRTCP reports whether buf holds an RTCP packet. SRTCP packets are
+// recognised too, as their header isn't encrypted.
+func IsRTCP(buf []byte) bool {
+	return isVersion2(buf) && buf[1] >= rtcpTypeMin && buf[1] <= rtcpTypeMax
+}
+
+// IsRTP reports whether buf holds an RTP packet. SRTP packets are
+// recognised too, as their header isn't encrypted.
+func IsRTP(buf []byte) bool {
+	return isVersion2(buf) && !IsRTCP(buf)
+}
+
+// Unmarshal classifies buf as RTP or RTCP and decodes it. Exactly one of
+// the results is set when no error is returned. RTCP is decoded as a
+// compound packet, which may be reduced-size.
+func Unmarshal(buf []byte) (*rtp.Packet, rtcp.CompoundPacket, error) {
+	switch {
+	case IsRTCP(buf):
+		var c rtcp.CompoundPacket
+		if err := c.UnmarshalReducedSize(buf); err != nil {
+			return nil, nil, err
+		}
+		return nil, c, nil
+
+	case IsRTP(buf):
+		p := &rtp.Packet{}
+		if err := p.Unmarshal(buf); err != nil {
+			return nil, nil, err
+		}
+		return p, nil, nil
+
+	default:
+		return nil, nil, errors.Errorf("packet is neither RTP nor RTCP")
+	}
+}
@@ -0,0 +1,65 @@
+package demux
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtcp"
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestUnmarshal(t *testing.T) {
+	rtpPacket := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 1,
+			SSRC:           0x1234,
+		},
+		Payload: []byte{0x01, 0x02},
+	}
+	rawRTP, err := rtpPacket.Marshal()
+	if err != nil {
+		t.Fatalf("RTP Marshal error: %v", err)
+	}
+
+	rtcpPacket := &rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 0x1234}
+	rawRTCP, err := rtcpPacket.Marshal()
+	if err != nil {
+		t.Fatalf("RTCP Marshal error: %v", err)
+	}
+
+	if !IsRTP(rawRTP) || IsRTCP(rawRTP) {
+		t.Errorf("RTP packet misclassified")
+	}
+	if !IsRTCP(rawRTCP) || IsRTP(rawRTCP) {
+		t.Errorf("RTCP packet misclassified")
+	}
+
+	p, c, err := Unmarshal(rawRTP)
+	if err != nil || c != nil {
+		t.Fatalf("Unmarshal(RTP) = %v, %v", c, err)
+	}
+	if p.SequenceNumber != 1 || p.PayloadType != 96 || !reflect.DeepEqual(p.Payload, []byte{0x01, 0x02}) {
+		t.Errorf("Unmarshal(RTP) = %#v", p)
+	}
+
+	p, c, err = Unmarshal(rawRTCP)
+	if err != nil || p != nil {
+		t.Fatalf("Unmarshal(RTCP) = %v, %v", p, err)
+	}
+	if !reflect.DeepEqual(c, rtcp.CompoundPacket{rtcpPacket}) {
+		t.Errorf("Unmarshal(RTCP) = %#v", c)
+	}
+
+	// Neither version 2, nor long enough to tell
+	for _, buf := range [][]byte{nil, {0x80}, {0x00, 0xc8}, {0x16, 0xfe, 0xfd}} {
+		if IsRTP(buf) || IsRTCP(buf) {
+			t.Errorf("%v classified as RTP or RTCP", buf)
+		}
+		if _, _, err := Unmarshal(buf); err == nil {
+			t.Errorf("Unmarshal(%v) succeeded, want error", buf)
+		}
+	}
+}
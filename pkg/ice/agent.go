@@ -6,11 +6,12 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/pions/pkg/stun"
 	"github.com/pions/webrtc/internal/util"
+	"github.com/pions/webrtc/pkg/stun"
 	"github.com/pkg/errors"
 )
 
@@ -19,40 +20,7 @@ import (
 const Unknown = iota
 
 // OutboundCallback is the user defined Callback that is called when ICE traffic needs to sent
-type OutboundCallback func(raw []byte, local *stun.TransportAddr, remote *net.UDPAddr)
-
-func newCandidatePair(local, remote Candidate) CandidatePair {
-	return CandidatePair{
-		remote: remote,
-		local:  local,
-	}
-}
-
-// CandidatePair represents a combination of a local and remote candidate
-type CandidatePair struct {
-	// lastUpdateTime ?
-	remote Candidate
-	local  Candidate
-}
-
-func (c CandidatePair) getAddrs() (local *stun.TransportAddr, remote *net.UDPAddr) {
-	localIP := net.ParseIP(c.local.GetBase().Address)
-	localPort := c.local.GetBase().Port
-
-	switch c := c.local.(type) {
-	case *CandidateSrflx:
-		localIP = net.ParseIP(c.RemoteAddress)
-		localPort = c.RemotePort
-	}
-
-	return &stun.TransportAddr{
-			IP:   localIP,
-			Port: localPort,
-		}, &net.UDPAddr{
-			IP:   net.ParseIP(c.remote.GetBase().Address),
-			Port: c.remote.GetBase().Port,
-		}
-}
+type OutboundCallback func(raw []byte, local *stun.TransportAddr, remote net.Addr)
 
 // Agent represents the ICE agent
 type Agent struct {
@@ -60,15 +28,36 @@ type Agent struct {
 
 	outboundCallback OutboundCallback
 	iceNotifier      func(ConnectionState)
+	onCandidate      func(Candidate)
+
+	// notifications are the calls of the handlers that are yet to be made,
+	// one at a time in the order they were queued
+	notifications []func()
+	isNotifying   bool
 
 	tieBreaker      uint64
 	connectionState ConnectionState
 	gatheringState  GatheringState
 
+	// gatheringDone is set once the user of the Agent added every local
+	// candidate, gathering completes when no STUN server is waited for
+	gatheringDone      bool
+	isGathering        bool
+	gatherTransactions map[string]*gatherRequest
+	stunServers        []*net.UDPAddr
+
+	// haveStarted is cleared by an ICE restart until the credentials of the
+	// peer are known again, the task loop keeps running
 	haveStarted   bool
+	isRunning     bool
 	isControlling bool
 	taskLoopChan  chan bool
 
+	// A lite agent only has host candidates and answers the checks of the
+	// peer, it never sends any and is always controlled
+	// https://tools.ietf.org/html/rfc8445#section-2.5
+	isLite bool
+
 	LocalUfrag      string
 	LocalPwd        string
 	LocalCandidates []Candidate
@@ -77,13 +66,63 @@ type Agent struct {
 	remotePwd        string
 	remoteCandidates []Candidate
 
-	selectedPair CandidatePair
-	validPairs   []CandidatePair
+	// The checklist holds every pair sorted by priority, the highest first
+	// https://tools.ietf.org/html/rfc8445#section-6.1.2
+	checklist      []*CandidatePair
+	triggeredQueue []*CandidatePair
+	transactions   map[string]*bindingRequest
+
+	// outbound is sent by unlock, never while holding the agent lock
+	outbound []outboundPacket
+
+	selectedPair *CandidatePair
+
+	// Consent to send on the selected pair is checked every consentInterval,
+	// it expires once consentFailureThreshold checks in a row go unanswered
+	// https://tools.ietf.org/html/rfc7675
+	consentInterval         time.Duration
+	consentFailureThreshold int
+	consentMisses           int
+	isConsentPending        bool
+	nextConsentCheck        time.Time
+
+	// restartPair is the pair selected before an ICE restart, data is sent
+	// on it until a new pair is selected
+	restartPair *CandidatePair
+
+	// statsLock guards traffic only, the user of the Agent counts what it
+	// sends from within the OutboundCallback
+	statsLock sync.Mutex
+	traffic   map[string]*pairTraffic
+}
+
+// bindingRequest is a connectivity check waiting for its response
+type bindingRequest struct {
+	pair          *CandidatePair
+	raw           []byte
+	useCandidate  bool
+	isControlling bool
+	sent          time.Time
+	retransmits   int
 }
 
 const (
 	agentTickerBaseInterval = 3 * time.Second
-	stunTimeout             = 10 * time.Second
+
+	// Consent is checked every 5 seconds and expires after 30 seconds
+	// without a response by default
+	// https://tools.ietf.org/html/rfc7675#section-5.1
+	defaultConsentInterval         = 5 * time.Second
+	defaultConsentFailureThreshold = 6
+
+	// checkInterval is Ta, the pacing of new connectivity checks
+	// https://tools.ietf.org/html/rfc8445#section-14.2
+	checkInterval = 50 * time.Millisecond
+
+	// A check that gets no response is sent again after checkRTO, up to
+	// maxBindingRequests times in total
+	checkRTO           = 500 * time.Millisecond
+	maxBindingRequests = 7
 )
 
 // NewAgent creates a new Agent
@@ -93,15 +132,57 @@ func NewAgent(outboundCallback OutboundCallback, iceNotifier func(ConnectionStat
 		iceNotifier:      iceNotifier,
 
 		tieBreaker:      rand.New(rand.NewSource(time.Now().UnixNano())).Uint64(),
-		gatheringState:  GatheringStateComplete, // TODO trickle-ice
+		gatheringState:  GatheringStateNew,
 		connectionState: ConnectionStateNew,
+		taskLoopChan:    make(chan bool),
+		transactions:    make(map[string]*bindingRequest),
+
+		gatherTransactions: make(map[string]*gatherRequest),
+		traffic:            make(map[string]*pairTraffic),
+
+		consentInterval:         defaultConsentInterval,
+		consentFailureThreshold: defaultConsentFailureThreshold,
 
 		LocalUfrag: util.RandSeq(16),
 		LocalPwd:   util.RandSeq(32),
 	}
 }
 
-// Start starts the agent
+// SetConsentFreshness sets how often the consent of the peer to receive
+// data on the selected pair is checked, and after how many checks in a row
+// that go unanswered it expires. The connection is Disconnected once a
+// check goes unanswered, and Failed when consent expires.
+func (a *Agent) SetConsentFreshness(interval time.Duration, failureThreshold int) error {
+	if interval <= 0 {
+		return errors.Errorf("consent interval must be positive, got %s", interval)
+	} else if failureThreshold < 1 {
+		return errors.Errorf("consent failure threshold must be at least 1, got %d", failureThreshold)
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	a.consentInterval = interval
+	a.consentFailureThreshold = failureThreshold
+	return nil
+}
+
+// SetLite runs the agent in lite mode, it must be set before the agent is
+// started
+func (a *Agent) SetLite(lite bool) error {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.haveStarted {
+		return errors.Errorf("Attempted to change the mode of a started agent")
+	} else if lite && len(a.stunServers) != 0 {
+		return errors.Errorf("lite agents only have host candidates")
+	}
+	a.isLite = lite
+	return nil
+}
+
+// Start starts the agent, a lite agent is controlled whatever isControlling
+// is
 func (a *Agent) Start(isControlling bool, remoteUfrag, remotePwd string) error {
 	a.Lock()
 	defer a.Unlock()
@@ -114,116 +195,363 @@ func (a *Agent) Start(isControlling bool, remoteUfrag, remotePwd string) error {
 		return errors.Errorf("remotePwd is empty")
 	}
 
-	a.isControlling = isControlling
+	a.haveStarted = true
+	a.isControlling = isControlling && !a.isLite
 	a.remoteUfrag = remoteUfrag
 	a.remotePwd = remotePwd
 
-	go a.agentTaskLoop()
+	for _, local := range a.LocalCandidates {
+		for _, remote := range a.remoteCandidates {
+			a.addPair(local, remote)
+		}
+	}
+	a.unfreezeInitialPairs()
+
+	// Checks after an ICE restart run while data is still sent on the pair
+	// selected before
+	if a.restartPair == nil {
+		a.updateConnectionState(ConnectionStateChecking)
+	}
+	if !a.isRunning {
+		a.isRunning = true
+		go a.agentTaskLoop()
+	}
 	return nil
 }
 
-func (a *Agent) pingCandidate(local, remote Candidate) {
-	var msg *stun.Message
-	var err error
+// Restart performs an ICE restart. New local credentials are picked, the
+// remote candidates and the checklist are forgotten and the server
+// reflexive candidates are gathered again, the host candidates are kept.
+// Checks resume once Start is called with the new credentials of the peer,
+// data is sent on the pair selected before until then.
+// https://tools.ietf.org/html/rfc8445#section-9
+func (a *Agent) Restart() {
+	a.Lock()
+	defer a.unlock()
 
-	if a.isControlling {
-		msg, err = stun.Build(stun.ClassRequest, stun.MethodBinding, stun.GenerateTransactionId(),
-			&stun.Username{Username: a.remoteUfrag + ":" + a.LocalUfrag},
-			&stun.UseCandidate{},
-			&stun.IceControlling{TieBreaker: a.tieBreaker},
-			&stun.Priority{Priority: uint32(local.GetBase().Priority(HostCandidatePreference, 1))},
-			&stun.MessageIntegrity{
-				Key: []byte(a.remotePwd),
-			},
-			&stun.Fingerprint{},
-		)
+	a.LocalUfrag = util.RandSeq(16)
+	a.LocalPwd = util.RandSeq(32)
+
+	a.haveStarted = false
+	a.remoteUfrag = ""
+	a.remotePwd = ""
+	a.remoteCandidates = nil
+	a.checklist = nil
+	a.triggeredQueue = nil
+	a.transactions = make(map[string]*bindingRequest)
+	if a.selectedPair != nil {
+		a.restartPair = a.selectedPair
+		a.selectedPair = nil
+	}
+
+	var hosts []Candidate
+	for _, c := range a.LocalCandidates {
+		if c.Type() == CandidateTypeHost {
+			hosts = append(hosts, c)
+		}
+	}
+	a.LocalCandidates = hosts
+
+	a.gatheringState = GatheringStateGathering
+	a.gatherTransactions = make(map[string]*gatherRequest)
+	for _, server := range a.stunServers {
+		if err := a.gatherFrom(server); err != nil {
+			fmt.Printf("Failed to gather server reflexive candidates from %s: %s\n", server.String(), err.Error())
+		}
+	}
+	a.updateGatheringState()
+}
+
+// addPair forms a pair and inserts it into the checklist by priority. A
+// pair with the same addresses as one already in the checklist is pruned.
+// https://tools.ietf.org/html/rfc8445#section-6.1.2.4
+func (a *Agent) addPair(local, remote Candidate) *CandidatePair {
+	if !canPair(local, remote) {
+		return nil
+	}
+
+	p := newCandidatePair(local, remote, a.isControlling)
+	if a.getPair(p.getAddrs()) != nil {
+		return nil
+	}
+
+	i := sort.Search(len(a.checklist), func(i int) bool {
+		return a.checklist[i].Priority() < p.Priority()
+	})
+	a.checklist = append(a.checklist, nil)
+	copy(a.checklist[i+1:], a.checklist[i:])
+	a.checklist[i] = p
+	return p
+}
+
+// unfreezeInitialPairs sets the highest priority pair of each foundation
+// Waiting, the others stay Frozen until a pair of their foundation succeeds
+// https://tools.ietf.org/html/rfc8445#section-6.1.2.6
+func (a *Agent) unfreezeInitialPairs() {
+	foundations := map[string]bool{}
+	for _, p := range a.checklist {
+		if foundations[p.Foundation()] {
+			continue
+		}
+		foundations[p.Foundation()] = true
+		if p.state == CandidatePairStateFrozen {
+			p.state = CandidatePairStateWaiting
+		}
+	}
+}
+
+// unfreezeFoundation sets the Frozen pairs with the foundation of a pair
+// that just succeeded Waiting
+// https://tools.ietf.org/html/rfc8445#section-7.2.5.3.3
+func (a *Agent) unfreezeFoundation(foundation string) {
+	for _, p := range a.checklist {
+		if p.state == CandidatePairStateFrozen && p.Foundation() == foundation {
+			p.state = CandidatePairStateWaiting
+		}
+	}
+}
+
+// getPair returns the pair of the checklist that sends from local to remote
+func (a *Agent) getPair(local *stun.TransportAddr, remote net.Addr) *CandidatePair {
+	for _, p := range a.checklist {
+		pairLocal, pairRemote := p.getAddrs()
+		if pairLocal.Equal(local) && pairRemote.Network() == remote.Network() && pairRemote.String() == remote.String() {
+			return p
+		}
+	}
+	return nil
+}
 
+func (a *Agent) sendBindingRequest(p *CandidatePair, useCandidate bool) {
+	attributes := []stun.Attribute{
+		&stun.Username{Username: a.remoteUfrag + ":" + a.LocalUfrag},
+	}
+	if useCandidate {
+		attributes = append(attributes, &stun.UseCandidate{})
+	}
+	if a.isControlling {
+		attributes = append(attributes, &stun.IceControlling{TieBreaker: a.tieBreaker})
 	} else {
-		msg, err = stun.Build(stun.ClassRequest, stun.MethodBinding, stun.GenerateTransactionId(),
-			&stun.Username{Username: a.remoteUfrag + ":" + a.LocalUfrag},
-			&stun.UseCandidate{},
-			&stun.IceControlled{TieBreaker: a.tieBreaker},
-			&stun.Priority{Priority: uint32(local.GetBase().Priority(HostCandidatePreference, 1))},
-			&stun.MessageIntegrity{
-				Key: []byte(a.remotePwd),
-			},
-			&stun.Fingerprint{},
-		)
+		attributes = append(attributes, &stun.IceControlled{TieBreaker: a.tieBreaker})
 	}
 
+	// The priority a peer reflexive candidate learned from the check would
+	// have https://tools.ietf.org/html/rfc8445#section-7.1.1
+	attributes = append(attributes,
+		&stun.Priority{Priority: p.local.GetBase().Priority(PrflxCandidatePreference, 1)},
+		&stun.MessageIntegrity{
+			Key: []byte(a.remotePwd),
+		},
+		&stun.Fingerprint{},
+	)
+
+	transactionID, err := stun.GenerateTransactionID()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	msg, err := stun.Build(stun.ClassRequest, stun.MethodBinding, transactionID, attributes...)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	a.outboundCallback(msg.Pack(), &stun.TransportAddr{
-		IP:   net.ParseIP(local.GetBase().Address),
-		Port: local.GetBase().Port,
-	}, &net.UDPAddr{
-		IP:   net.ParseIP(remote.GetBase().Address),
-		Port: remote.GetBase().Port,
-	})
+	request := &bindingRequest{
+		pair:          p,
+		raw:           msg.Pack(),
+		useCandidate:  useCandidate,
+		isControlling: a.isControlling,
+		sent:          time.Now(),
+	}
+	a.transactions[string(msg.TransactionID)] = request
+	p.state = CandidatePairStateInProgress
+
+	local, remote := p.getAddrs()
+	a.send(request.raw, local, remote)
 }
 
 func (a *Agent) updateConnectionState(newState ConnectionState) {
+	if a.connectionState == newState {
+		return
+	}
+
 	a.connectionState = newState
-	// Call handler async since we may be holding the agent lock
-	// and the handler may also require it
-	go a.iceNotifier(a.connectionState)
+	a.notify(func() { a.iceNotifier(newState) })
 }
 
-func (a *Agent) setValidPair(local, remote Candidate, selected bool) {
-	p := newCandidatePair(local, remote)
+// setSelectedPair selects a nominated pair, the one data is sent on from
+// now on
+func (a *Agent) setSelectedPair(p *CandidatePair) {
+	p.nominated = true
+	a.selectedPair = p
+	a.restartPair = nil
+	a.consentMisses = 0
+	a.isConsentPending = false
+	a.nextConsentCheck = time.Now().Add(a.randomConsentInterval())
+	a.updateConnectionState(ConnectionStateConnected)
+}
+
+// randomConsentInterval spreads the consent checks over 0.8 to 1.2 times
+// the consent interval
+// https://tools.ietf.org/html/rfc7675#section-5.1
+func (a *Agent) randomConsentInterval() time.Duration {
+	return time.Duration(float64(a.consentInterval) * (0.8 + 0.4*rand.Float64()))
+}
 
-	if selected {
-		a.selectedPair = p
-		a.validPairs = nil
-		// TODO: only set state to connected on selecting final pair?
+// consentTick sends a consent check on the selected pair. Once too many
+// were left unanswered consent has expired and no more data is sent on it.
+// https://tools.ietf.org/html/rfc7675#section-5.1
+func (a *Agent) consentTick() {
+	if a.isConsentPending {
+		a.consentMisses++
+		if a.consentMisses >= a.consentFailureThreshold {
+			a.selectedPair = nil
+			a.isConsentPending = false
+			a.updateConnectionState(ConnectionStateFailed)
+			return
+		}
+		a.updateConnectionState(ConnectionStateDisconnected)
+	}
+
+	a.isConsentPending = true
+	a.nextConsentCheck = time.Now().Add(a.randomConsentInterval())
+	a.sendBindingRequest(a.selectedPair, false)
+}
+
+// refreshConsent notes the peer answered a check on the selected pair
+func (a *Agent) refreshConsent() {
+	a.consentMisses = 0
+	a.isConsentPending = false
+	if a.connectionState == ConnectionStateDisconnected {
 		a.updateConnectionState(ConnectionStateConnected)
-	} else {
-		// keep track of pairs with succesfull bindings since any of them
-		// can be used for communication until the final pair is selected:
-		// https://tools.ietf.org/html/draft-ietf-ice-rfc5245bis-20#section-12
-		a.validPairs = append(a.validPairs, p)
 	}
 }
 
-func (a *Agent) agentTaskLoop() {
-	// TODO this should be dynamic, and grow when the connection is stable
-	t := time.NewTicker(agentTickerBaseInterval)
-	a.updateConnectionState(ConnectionStateChecking)
+// nominate sends the check with USE-CANDIDATE for the highest priority
+// valid pair, once the controlling agent has one
+// https://tools.ietf.org/html/rfc8445#section-8.1.1
+func (a *Agent) nominate() {
+	if !a.isControlling || a.selectedPair != nil {
+		return
+	}
+	for _, request := range a.transactions {
+		if request.useCandidate {
+			return
+		}
+	}
 
-	assertSelectedPairValid := func() bool {
-		if a.selectedPair.remote == nil || a.selectedPair.local == nil {
-			return false
-		} else if time.Since(a.selectedPair.remote.GetBase().LastSeen) > stunTimeout {
-			a.selectedPair.remote = nil
-			a.selectedPair.local = nil
-			a.updateConnectionState(ConnectionStateDisconnected)
-			return false
+	for _, p := range a.checklist {
+		if p.state == CandidatePairStateSucceeded {
+			a.sendBindingRequest(p, true)
+			return
+		}
+	}
+}
+
+// checkTick performs the check that is due every Ta, a triggered check if
+// there is one and the highest priority Waiting pair otherwise. Checks that
+// were not answered are retransmitted or given up.
+// https://tools.ietf.org/html/rfc8445#section-6.1.4.2
+func (a *Agent) checkTick() {
+	for id, request := range a.transactions {
+		if time.Since(request.sent) < checkRTO {
+			continue
 		}
 
+		delete(a.transactions, id)
+		if request.retransmits+1 >= maxBindingRequests {
+			request.pair.state = CandidatePairStateFailed
+			continue
+		}
+
+		request.retransmits++
+		request.sent = time.Now()
+		a.transactions[id] = request
+		local, remote := request.pair.getAddrs()
+		a.send(request.raw, local, remote)
+	}
+
+	if len(a.triggeredQueue) > 0 {
+		p := a.triggeredQueue[0]
+		a.triggeredQueue = a.triggeredQueue[1:]
+		a.sendBindingRequest(p, false)
+		return
+	}
+
+	for _, p := range a.checklist {
+		if p.state == CandidatePairStateWaiting && p.canCheck() {
+			a.sendBindingRequest(p, false)
+			return
+		}
+	}
+
+	// No pair is Waiting, one of the Frozen ones gets checked instead
+	for _, p := range a.checklist {
+		if p.state == CandidatePairStateFrozen && p.canCheck() {
+			a.sendBindingRequest(p, false)
+			return
+		}
+	}
+
+	a.updateFailedState()
+}
+
+// updateFailedState moves to ConnectionStateFailed once every check failed
+func (a *Agent) updateFailedState() {
+	if a.selectedPair != nil || len(a.checklist) == 0 || len(a.transactions) != 0 {
+		return
+	}
+	for _, p := range a.checklist {
+		if p.state != CandidatePairStateFailed && !p.isIdle() {
+			return
+		}
+	}
+	a.updateConnectionState(ConnectionStateFailed)
+}
+
+// isChecking reports whether connectivity checks are still being performed
+func (a *Agent) isChecking() bool {
+	if a.isLite {
+		return false
+	} else if len(a.transactions) != 0 || len(a.triggeredQueue) != 0 {
 		return true
 	}
+	if a.selectedPair != nil {
+		return false
+	}
+	for _, p := range a.checklist {
+		if (p.state == CandidatePairStateWaiting || p.state == CandidatePairStateFrozen) && !p.isIdle() {
+			return true
+		}
+	}
+	return false
+}
 
+func (a *Agent) agentTaskLoop() {
+	lastRecheck := time.Now()
 	for {
-		select {
-		case <-t.C:
-			a.Lock()
-			if assertSelectedPairValid() {
-				a.Unlock()
-				continue
+		a.Lock()
+		if a.isChecking() {
+			a.checkTick()
+		} else if a.selectedPair == nil && !a.isLite && time.Since(lastRecheck) >= agentTickerBaseInterval {
+			// Without a selected pair every pair is checked again
+			lastRecheck = time.Now()
+			for _, p := range a.checklist {
+				p.state = CandidatePairStateWaiting
 			}
+		}
 
-			for _, localCandidate := range a.LocalCandidates {
-				for _, remoteCandidate := range a.remoteCandidates {
-					a.pingCandidate(localCandidate, remoteCandidate)
-				}
-			}
+		// The full peer of a lite agent checks consent
+		// https://tools.ietf.org/html/rfc7675#section-4
+		if a.selectedPair != nil && !a.isLite && !time.Now().Before(a.nextConsentCheck) {
+			a.consentTick()
+		}
+		a.keepaliveTick()
+		a.unlock()
 
-			a.Unlock()
+		select {
+		case <-time.After(checkInterval):
 		case <-a.taskLoopChan:
-			t.Stop()
 			return
 		}
 	}
@@ -233,24 +561,127 @@ func (a *Agent) agentTaskLoop() {
 func (a *Agent) AddRemoteCandidate(c Candidate) {
 	a.Lock()
 	defer a.Unlock()
+
+	for _, existing := range a.remoteCandidates {
+		if isCandidateMatch(existing, c.GetBase().Protocol, c.GetBase().Address, c.GetBase().Port) {
+			return
+		}
+	}
 	a.remoteCandidates = append(a.remoteCandidates, c)
+
+	if !a.haveStarted {
+		return
+	}
+	for _, local := range a.LocalCandidates {
+		if p := a.addPair(local, c); p != nil {
+			p.state = CandidatePairStateWaiting
+		}
+	}
 }
 
-// AddLocalCandidate adds a new local candidate
+// AddLocalCandidate adds a new local candidate, gathered by the user of the
+// Agent, and passes it to the OnCandidate handler
 func (a *Agent) AddLocalCandidate(c Candidate) {
 	a.Lock()
 	defer a.Unlock()
+	a.addLocalCandidate(c)
+}
+
+func (a *Agent) addLocalCandidate(c Candidate) {
 	a.LocalCandidates = append(a.LocalCandidates, c)
+
+	if a.gatheringState == GatheringStateNew {
+		a.gatheringState = GatheringStateGathering
+	}
+	a.notifyCandidate(c)
+
+	if !a.haveStarted {
+		return
+	}
+	for _, remote := range a.remoteCandidates {
+		if p := a.addPair(c, remote); p != nil {
+			p.state = CandidatePairStateWaiting
+		}
+	}
+}
+
+// GatheringComplete is called once all the local candidates have been
+// added. When the server reflexive candidates have been gathered too the
+// OnCandidate handler is passed nil to tell so.
+func (a *Agent) GatheringComplete() {
+	a.Lock()
+	defer a.Unlock()
+
+	a.gatheringDone = true
+	a.updateGatheringState()
+}
+
+// GatheringState returns the state of the candidate gathering
+func (a *Agent) GatheringState() GatheringState {
+	a.RLock()
+	defer a.RUnlock()
+	return a.gatheringState
+}
+
+// OnCandidate sets a handler that is called with each local candidate as it
+// is gathered, and with nil once gathering is complete
+func (a *Agent) OnCandidate(handler func(Candidate)) {
+	a.Lock()
+	defer a.Unlock()
+	a.onCandidate = handler
+}
+
+// notifyCandidate passes c to the OnCandidate handler
+func (a *Agent) notifyCandidate(c Candidate) {
+	if handler := a.onCandidate; handler != nil {
+		a.notify(func() { handler(c) })
+	}
+}
+
+// notify queues a call of a handler. The agent lock is held and the handler
+// may also require it, so it is called from a goroutine that keeps the
+// order of the calls, a state change is never seen before the one it
+// follows.
+func (a *Agent) notify(call func()) {
+	a.notifications = append(a.notifications, call)
+	if a.isNotifying {
+		return
+	}
+	a.isNotifying = true
+
+	go func() {
+		for {
+			a.Lock()
+			if len(a.notifications) == 0 {
+				a.isNotifying = false
+				a.Unlock()
+				return
+			}
+			call := a.notifications[0]
+			a.notifications = a.notifications[1:]
+			a.Unlock()
+
+			call()
+		}
+	}()
 }
 
 // Close cleans up the Agent
 func (a *Agent) Close() {
-	if a.taskLoopChan != nil {
+	a.Lock()
+	defer a.Unlock()
+
+	select {
+	case <-a.taskLoopChan:
+	default:
 		close(a.taskLoopChan)
 	}
 }
 
-func isCandidateMatch(c Candidate, testAddress string, testPort int) bool {
+func isCandidateMatch(c Candidate, protocol ProtoType, testAddress string, testPort int) bool {
+	if c.GetBase().Protocol != protocol {
+		return false
+	}
 	if c.GetBase().Address == testAddress && c.GetBase().Port == testPort {
 		return true
 	}
@@ -260,35 +691,154 @@ func isCandidateMatch(c Candidate, testAddress string, testPort int) bool {
 		if c.RemoteAddress == testAddress && c.RemotePort == testPort {
 			return true
 		}
+	case *CandidatePrflx:
+		if c.RemoteAddress == testAddress && c.RemotePort == testPort {
+			return true
+		}
 	}
 
 	return false
 }
 
-func getTransportAddrCandidate(candidates []Candidate, addr *stun.TransportAddr) Candidate {
+// addrInfo returns the protocol, IP and port of a UDP or TCP address
+func addrInfo(addr net.Addr) (ProtoType, net.IP, int) {
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		return ProtoTypeUDP, addr.IP, addr.Port
+	case *net.TCPAddr:
+		return ProtoTypeTCP, addr.IP, addr.Port
+	default:
+		return ProtoType(Unknown), nil, 0
+	}
+}
+
+func getTransportAddrCandidate(candidates []Candidate, protocol ProtoType, addr *stun.TransportAddr) Candidate {
 	for _, c := range candidates {
-		if isCandidateMatch(c, addr.IP.String(), addr.Port) {
+		if isCandidateMatch(c, protocol, addr.IP.String(), addr.Port) {
 			return c
 		}
 	}
 	return nil
 }
 
-func getUDPAddrCandidate(candidates []Candidate, addr *net.UDPAddr) Candidate {
+func getAddrCandidate(candidates []Candidate, addr net.Addr) Candidate {
+	protocol, ip, port := addrInfo(addr)
 	for _, c := range candidates {
-		if isCandidateMatch(c, addr.IP.String(), addr.Port) {
+		if isCandidateMatch(c, protocol, ip.String(), port) {
 			return c
 		}
 	}
 	return nil
 }
 
-func (a *Agent) sendBindingSuccess(m *stun.Message, local *stun.TransportAddr, remote *net.UDPAddr) {
+// learnActiveCandidate returns the remote active TCP candidate that opened
+// a connection from addr, active candidates don't tell the port they
+// connect from. The candidate is added at the port it connected from.
+// https://tools.ietf.org/html/rfc6544#section-7.1
+func (a *Agent) learnActiveCandidate(addr net.Addr) Candidate {
+	protocol, ip, port := addrInfo(addr)
+	if protocol != ProtoTypeTCP {
+		return nil
+	}
+
+	for _, c := range a.remoteCandidates {
+		base := *c.GetBase()
+		if base.Protocol != ProtoTypeTCP || base.TCPType != TCPTypeActive || base.Address != ip.String() {
+			continue
+		}
+
+		base.Port = port
+		var learned Candidate
+		switch c := c.(type) {
+		case *CandidateSrflx:
+			learned = &CandidateSrflx{CandidateBase: base, RemoteAddress: c.RemoteAddress, RemotePort: c.RemotePort}
+		default:
+			learned = &CandidateHost{CandidateBase: base}
+		}
+		a.remoteCandidates = append(a.remoteCandidates, learned)
+		return learned
+	}
+	return nil
+}
+
+// learnRemotePrflxCandidate adds the address a check of the peer came from
+// as a peer reflexive candidate, with the priority the check tells
+// https://tools.ietf.org/html/rfc8445#section-7.3.1.3
+func (a *Agent) learnRemotePrflxCandidate(m *stun.Message, addr net.Addr) Candidate {
+	protocol, ip, port := addrInfo(addr)
+	attr, ok := m.GetOneAttribute(stun.AttrPriority)
+	if !ok {
+		return nil
+	}
+	var priority stun.Priority
+	if err := priority.Unpack(m, attr); err != nil {
+		return nil
+	}
+
+	// The priority is kept as the local preference it was computed from,
+	// like the one of a signaled candidate
+	learned := &CandidatePrflx{
+		CandidateBase: CandidateBase{
+			Protocol:        protocol,
+			Address:         ip.String(),
+			Port:            port,
+			LocalPreference: uint16(priority.Priority >> 8),
+		},
+	}
+	if protocol == ProtoTypeTCP {
+		// The peer opened the connection
+		learned.TCPType = TCPTypeActive
+	}
+	a.remoteCandidates = append(a.remoteCandidates, learned)
+	return learned
+}
+
+// learnLocalPrflxCandidate adds the address the peer saw a check of p come
+// from as a peer reflexive candidate, when it is none of ours. It has the
+// priority the check was sent with, and is not signaled to the peer. The
+// pair keeps sending from its base.
+// https://tools.ietf.org/html/rfc8445#section-7.2.5.3.1
+func (a *Agent) learnLocalPrflxCandidate(m *stun.Message, p *CandidatePair) {
+	base := p.local.GetBase()
+	if base.Protocol != ProtoTypeUDP {
+		// TCP checks are seen from the port of the connection
+		return
+	}
+
+	attr, ok := m.GetOneAttribute(stun.AttrXORMappedAddress)
+	if !ok {
+		return
+	}
+	var addr stun.XorAddress
+	if err := addr.Unpack(m, attr); err != nil {
+		return
+	}
+	for _, c := range a.LocalCandidates {
+		if c.GetBase().Protocol == base.Protocol && c.GetBase().Address == addr.IP.String() && c.GetBase().Port == addr.Port {
+			return
+		}
+	}
+
+	local, _ := p.getAddrs()
+	a.LocalCandidates = append(a.LocalCandidates, &CandidatePrflx{
+		CandidateBase: CandidateBase{
+			Protocol:        base.Protocol,
+			Address:         addr.IP.String(),
+			Port:            addr.Port,
+			LocalPreference: base.LocalPreference,
+		},
+		RemoteAddress: local.IP.String(),
+		RemotePort:    local.Port,
+	})
+}
+
+func (a *Agent) sendBindingSuccess(m *stun.Message, local *stun.TransportAddr, remote net.Addr) {
+	_, ip, port := addrInfo(remote)
 	if out, err := stun.Build(stun.ClassSuccessResponse, stun.MethodBinding, m.TransactionID,
 		&stun.XorMappedAddress{
 			XorAddress: stun.XorAddress{
-				IP:   remote.IP,
-				Port: remote.Port,
+				IP:   ip,
+				Port: port,
 			},
 		},
 		&stun.MessageIntegrity{
@@ -298,84 +848,278 @@ func (a *Agent) sendBindingSuccess(m *stun.Message, local *stun.TransportAddr, r
 	); err != nil {
 		fmt.Printf("Failed to handle inbound ICE from: %s to: %s error: %s", local.String(), remote.String(), err.Error())
 	} else {
-		a.outboundCallback(out.Pack(), local, remote)
+		a.send(out.Pack(), local, remote)
 	}
+}
 
+func (a *Agent) sendRoleConflict(m *stun.Message, local *stun.TransportAddr, remote net.Addr) {
+	if out, err := stun.Build(stun.ClassErrorResponse, stun.MethodBinding, m.TransactionID,
+		&stun.ErrorCode{
+			Code:   stun.CodeRoleConflict,
+			Reason: "Role Conflict",
+		},
+		&stun.MessageIntegrity{
+			Key: []byte(a.LocalPwd),
+		},
+		&stun.Fingerprint{},
+	); err != nil {
+		fmt.Printf("Failed to handle inbound ICE from: %s to: %s error: %s", local.String(), remote.String(), err.Error())
+	} else {
+		a.send(out.Pack(), local, remote)
+	}
+}
+
+// setControlling switches the role of the agent after a role conflict, the
+// pair priorities depend on it
+func (a *Agent) setControlling(isControlling bool) {
+	a.isControlling = isControlling
+	for _, p := range a.checklist {
+		p.controlling = isControlling
+	}
+	sort.SliceStable(a.checklist, func(i, j int) bool {
+		return a.checklist[i].Priority() > a.checklist[j].Priority()
+	})
 }
 
-func (a *Agent) handleInboundControlled(m *stun.Message, local *stun.TransportAddr, remote *net.UDPAddr, localCandidate, remoteCandidate Candidate) {
-	if _, isControlled := m.GetOneAttribute(stun.AttrIceControlled); isControlled && !a.isControlling {
-		fmt.Println("inbound isControlled && a.isControlling == false")
+// resolveRoleConflict settles the role when the peer claims the same one,
+// the agent with the larger tie breaker is controlling. It reports whether
+// we keep our role and the check is to be answered with a role conflict.
+// https://tools.ietf.org/html/rfc8445#section-7.3.1.1
+func (a *Agent) resolveRoleConflict(m *stun.Message) bool {
+	if a.isLite {
+		return false
+	} else if attr, ok := m.GetOneAttribute(stun.AttrIceControlling); ok && a.isControlling {
+		var controlling stun.IceControlling
+		if err := controlling.Unpack(m, attr); err != nil {
+			return false
+		}
+		if a.tieBreaker >= controlling.TieBreaker {
+			return true
+		}
+		a.setControlling(false)
+	} else if attr, ok := m.GetOneAttribute(stun.AttrIceControlled); ok && !a.isControlling {
+		var controlled stun.IceControlled
+		if err := controlled.Unpack(m, attr); err != nil {
+			return false
+		}
+		if a.tieBreaker < controlled.TieBreaker {
+			return true
+		}
+		a.setControlling(true)
+	}
+	return false
+}
+
+// handleBindingRequest answers a connectivity check of the peer and
+// schedules a triggered check for its pair
+// https://tools.ietf.org/html/rfc8445#section-7.3.1.4
+func (a *Agent) handleBindingRequest(m *stun.Message, local *stun.TransportAddr, remote net.Addr, p *CandidatePair) {
+	if a.resolveRoleConflict(m) {
+		a.sendRoleConflict(m, local, remote)
 		return
 	}
 
-	_, useCandidateFound := m.GetOneAttribute(stun.AttrUseCandidate)
-	a.setValidPair(localCandidate, remoteCandidate, useCandidateFound)
 	a.sendBindingSuccess(m, local, remote)
+
+	// A lite agent doesn't check the pair, the check of the peer is enough
+	// for it to be used
+	// https://tools.ietf.org/html/rfc8445#section-7.3.1.5
+	if a.isLite {
+		p.state = CandidatePairStateSucceeded
+		if _, useCandidate := m.GetOneAttribute(stun.AttrUseCandidate); useCandidate {
+			a.setSelectedPair(p)
+		}
+		return
+	}
+
+	switch p.state {
+	case CandidatePairStateSucceeded:
+	case CandidatePairStateInProgress:
+		// The check in progress is answered like a triggered one would be
+	default:
+		p.state = CandidatePairStateWaiting
+		a.triggeredQueue = append(a.triggeredQueue, p)
+	}
+
+	// https://tools.ietf.org/html/rfc8445#section-7.3.1.5
+	// The controlled agent uses the pair the controlling agent nominated,
+	// once its own check for it has succeeded
+	if _, useCandidate := m.GetOneAttribute(stun.AttrUseCandidate); useCandidate && !a.isControlling {
+		if p.state == CandidatePairStateSucceeded {
+			a.setSelectedPair(p)
+		} else {
+			p.nominateOnSuccess = true
+		}
+	}
 }
 
-func (a *Agent) handleInboundControlling(m *stun.Message, local *stun.TransportAddr, remote *net.UDPAddr, localCandidate, remoteCandidate Candidate) {
-	if _, isControlling := m.GetOneAttribute(stun.AttrIceControlling); isControlling && a.isControlling {
-		fmt.Println("inbound isControlling && a.isControlling == true")
+// handleBindingSuccess processes the response to one of our checks
+// https://tools.ietf.org/html/rfc8445#section-7.2.5
+func (a *Agent) handleBindingSuccess(m *stun.Message, local *stun.TransportAddr, remote net.Addr) {
+	request, ok := a.transactions[string(m.TransactionID)]
+	if !ok {
+		return
+	}
+	delete(a.transactions, string(m.TransactionID))
+
+	// The response must come from where the request was sent to, and
+	// arrive where it was sent from
+	p := request.pair
+	expectedLocal, expectedRemote := p.getAddrs()
+	if !expectedLocal.Equal(local) || expectedRemote.Network() != remote.Network() || expectedRemote.String() != remote.String() {
+		p.state = CandidatePairStateFailed
 		return
-	} else if _, useCandidate := m.GetOneAttribute(stun.AttrUseCandidate); useCandidate && a.isControlling {
-		fmt.Println("useCandidate && a.isControlling == true")
+	}
+
+	// The response to a retransmitted check may answer any of them
+	if request.retransmits == 0 {
+		p.rtt = time.Since(request.sent)
+	}
+
+	p.state = CandidatePairStateSucceeded
+	a.learnLocalPrflxCandidate(m, p)
+	a.unfreezeFoundation(p.Foundation())
+	if p == a.selectedPair {
+		a.refreshConsent()
+	}
+
+	switch {
+	case request.useCandidate && a.isControlling:
+		a.setSelectedPair(p)
+	case p.nominateOnSuccess && !a.isControlling:
+		a.setSelectedPair(p)
+	default:
+		a.nominate()
+	}
+}
+
+// handleBindingError processes an error response to one of our checks. On
+// a role conflict the role is switched, unless it already was, and the
+// check is sent again.
+// https://tools.ietf.org/html/rfc8445#section-7.2.5.1
+func (a *Agent) handleBindingError(m *stun.Message) {
+	request, ok := a.transactions[string(m.TransactionID)]
+	if !ok {
 		return
 	}
+	delete(a.transactions, string(m.TransactionID))
 
-	final := m.Class == stun.ClassSuccessResponse && m.Method == stun.MethodBinding
-	a.setValidPair(localCandidate, remoteCandidate, final)
+	p := request.pair
+	var code stun.ErrorCode
+	if attr, ok := m.GetOneAttribute(stun.AttrErrorCode); !ok || code.Unpack(m, attr) != nil || code.Code != stun.CodeRoleConflict {
+		p.state = CandidatePairStateFailed
+		return
+	}
 
-	if !final {
-		a.sendBindingSuccess(m, local, remote)
+	if request.isControlling == a.isControlling {
+		a.setControlling(!request.isControlling)
 	}
+	p.state = CandidatePairStateWaiting
+	a.triggeredQueue = append(a.triggeredQueue, p)
 }
 
 // HandleInbound processes traffic from a remote candidate
-func (a *Agent) HandleInbound(buf []byte, local *stun.TransportAddr, remote *net.UDPAddr) {
+func (a *Agent) HandleInbound(buf []byte, local *stun.TransportAddr, remote net.Addr) {
+	a.PacketReceived(local, remote, len(buf))
+
 	a.Lock()
-	defer a.Unlock()
+	defer a.unlock()
+
+	m, err := stun.NewMessage(buf)
+	if err != nil {
+		fmt.Println(fmt.Sprintf("Failed to handle decode ICE from: %s to: %s error: %s", local.String(), remote.String(), err.Error()))
+		return
+	}
 
-	localCandidate := getTransportAddrCandidate(a.LocalCandidates, local)
+	// Responses of STUN servers aren't signed, they are matched to the
+	// request instead
+	if _, ok := a.gatherTransactions[string(m.TransactionID)]; ok && m.Class != stun.ClassRequest {
+		a.handleGatherResponse(m, remote)
+		return
+	}
+
+	protocol, _, _ := addrInfo(remote)
+	localCandidate := getTransportAddrCandidate(a.LocalCandidates, protocol, local)
 	if localCandidate == nil {
 		// TODO debug
 		// fmt.Printf("Could not find local candidate for %s:%d ", local.IP.String(), local.Port)
 		return
 	}
 
-	remoteCandidate := getUDPAddrCandidate(a.remoteCandidates, remote)
-	if remoteCandidate == nil {
+	// A check from an unknown address is from a peer reflexive candidate,
+	// learned once the check is authenticated
+	remoteCandidate := getAddrCandidate(a.remoteCandidates, remote)
+	if remoteCandidate == nil && m.Class == stun.ClassRequest {
+		remoteCandidate = a.learnActiveCandidate(remote)
+	}
+	if remoteCandidate == nil && m.Class != stun.ClassRequest {
 		// TODO debug
 		// fmt.Printf("Could not find remote candidate for %s:%d ", remote.IP.String(), remote.Port)
 		return
+	} else if remoteCandidate != nil {
+		remoteCandidate.GetBase().LastSeen = time.Now()
 	}
-	remoteCandidate.GetBase().LastSeen = time.Now()
 
-	m, err := stun.NewMessage(buf)
-	if err != nil {
-		fmt.Println(fmt.Sprintf("Failed to handle decode ICE from: %s to: %s error: %s", local.String(), remote.String(), err.Error()))
+	// Binding indications are keepalives, nothing answers them
+	if m.Method != stun.MethodBinding || m.Class == stun.ClassIndication {
 		return
 	}
 
-	if a.isControlling {
-		a.handleInboundControlling(m, local, remote, localCandidate, remoteCandidate)
-	} else {
-		a.handleInboundControlled(m, local, remote, localCandidate, remoteCandidate)
+	// Requests are signed with our password, responses with the one of the
+	// peer https://tools.ietf.org/html/rfc8445#section-7.2.2
+	integrity := &stun.MessageIntegrity{Key: []byte(a.LocalPwd)}
+	if m.Class == stun.ClassSuccessResponse || m.Class == stun.ClassErrorResponse {
+		integrity.Key = []byte(a.remotePwd)
+	}
+	if err := integrity.Check(m); err != nil {
+		fmt.Printf("Discarding ICE from: %s to: %s error: %s\n", remote.String(), local.String(), err.Error())
+		return
 	}
 
+	switch m.Class {
+	case stun.ClassRequest:
+		if remoteCandidate == nil {
+			if remoteCandidate = a.learnRemotePrflxCandidate(m, remote); remoteCandidate == nil {
+				return
+			}
+			remoteCandidate.GetBase().LastSeen = time.Now()
+		}
+
+		p := a.getPair(local, remote)
+		if p == nil {
+			if !a.haveStarted {
+				return
+			}
+			p = a.addPair(localCandidate, remoteCandidate)
+			if p == nil {
+				return
+			}
+		}
+		a.handleBindingRequest(m, local, remote, p)
+	case stun.ClassSuccessResponse:
+		a.handleBindingSuccess(m, local, remote)
+	case stun.ClassErrorResponse:
+		a.handleBindingError(m)
+	}
 }
 
 // SelectedPair gets the current selected pair's Addresses (or returns nil)
-func (a *Agent) SelectedPair() (local *stun.TransportAddr, remote *net.UDPAddr) {
+func (a *Agent) SelectedPair() (local *stun.TransportAddr, remote net.Addr) {
 	a.RLock()
 	defer a.RUnlock()
 
-	if a.selectedPair.remote == nil || a.selectedPair.local == nil {
-		for _, p := range a.validPairs {
+	if a.selectedPair != nil {
+		return a.selectedPair.getAddrs()
+	} else if a.restartPair != nil {
+		return a.restartPair.getAddrs()
+	}
+
+	// Any valid pair can be used until one is selected
+	// https://tools.ietf.org/html/rfc8445#section-12
+	for _, p := range a.checklist {
+		if p.state == CandidatePairStateSucceeded {
 			return p.getAddrs()
 		}
-		return nil, nil
 	}
-
-	return a.selectedPair.getAddrs()
+	return nil, nil
 }
@@ -1,7 +1,12 @@
 package ice
 
 import (
+	"net"
 	"testing"
+	"time"
+
+	"github.com/pions/webrtc/pkg/stun"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestTimeConsuming(t *testing.T) {
@@ -21,3 +26,530 @@ func TestTimeConsuming(t *testing.T) {
 // Output:
 // [a a b]
 // }
+
+func TestCandidatePairPriority(t *testing.T) {
+	host := &CandidateHost{CandidateBase{Address: "192.168.0.1", Port: 1000}}
+	srflx := &CandidateSrflx{CandidateBase: CandidateBase{Address: "1.2.3.4", Port: 1000}}
+
+	// G is the priority of the controlling agent's candidate, D the
+	// controlled one's, 2^32*MIN(G,D) + 2*MAX(G,D) + (G>D?1:0)
+	g, d := uint64(Priority(host, 1)), uint64(Priority(srflx, 1))
+	assert.Equal(t, (1<<32)*d+2*g+1, newCandidatePair(host, srflx, true).Priority())
+	assert.Equal(t, (1<<32)*d+2*g, newCandidatePair(srflx, host, true).Priority())
+
+	// Both agents compute the same priority for the same pair
+	assert.Equal(t,
+		newCandidatePair(host, srflx, true).Priority(),
+		newCandidatePair(srflx, host, false).Priority(),
+	)
+}
+
+// startWithoutTaskLoop forms the checklist like Start does, the checks are
+// then driven by calling checkTick
+func startWithoutTaskLoop(a *Agent, isControlling bool) {
+	a.haveStarted = true
+	a.isControlling = isControlling
+	a.remoteUfrag = "ufrag"
+	a.remotePwd = "pwd"
+	for _, local := range a.LocalCandidates {
+		for _, remote := range a.remoteCandidates {
+			a.addPair(local, remote)
+		}
+	}
+	a.unfreezeInitialPairs()
+}
+
+// flush sends the traffic queued while a is locked, a stays locked
+func flush(a *Agent) {
+	a.unlock()
+	a.Lock()
+}
+
+func TestAgentChecklist(t *testing.T) {
+	var sent []net.Addr
+	a := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		sent = append(sent, remote)
+	}, func(ConnectionState) {})
+	defer a.Close()
+
+	a.AddLocalCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}})
+	a.AddRemoteCandidate(&CandidateSrflx{CandidateBase: CandidateBase{Protocol: ProtoTypeUDP, Address: "1.2.3.4", Port: 2000}})
+	a.AddRemoteCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 2000}})
+	// The same address again is ignored
+	a.AddRemoteCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 2000}})
+
+	a.Lock()
+	defer a.Unlock()
+	startWithoutTaskLoop(a, true)
+
+	// The host pair has the higher priority, both are of a distinct
+	// foundation and can be checked at once
+	assert.Len(t, a.checklist, 2)
+	assert.Equal(t, "192.168.0.2", a.checklist[0].remote.GetBase().Address)
+	for _, p := range a.checklist {
+		assert.Equal(t, CandidatePairStateWaiting, p.state)
+	}
+
+	// A check is sent every Ta, highest priority first
+	a.checkTick()
+	a.checkTick()
+	flush(a)
+	assert.Len(t, sent, 2)
+	assert.Equal(t, "192.168.0.2:2000", sent[0].String())
+	assert.Equal(t, "1.2.3.4:2000", sent[1].String())
+	for _, p := range a.checklist {
+		assert.Equal(t, CandidatePairStateInProgress, p.state)
+	}
+
+	// Unanswered checks are retransmitted until they fail
+	for i := 1; i < maxBindingRequests; i++ {
+		for _, request := range a.transactions {
+			request.sent = request.sent.Add(-checkRTO)
+		}
+		a.checkTick()
+	}
+	flush(a)
+	assert.Len(t, sent, 2*maxBindingRequests)
+	for _, request := range a.transactions {
+		request.sent = request.sent.Add(-checkRTO)
+	}
+	a.checkTick()
+	assert.Len(t, sent, 2*maxBindingRequests)
+	for _, p := range a.checklist {
+		assert.Equal(t, CandidatePairStateFailed, p.state)
+	}
+	assert.Equal(t, ConnectionState(ConnectionStateFailed), a.connectionState)
+}
+
+func TestAgentUnfreeze(t *testing.T) {
+	a := NewAgent(func([]byte, *stun.TransportAddr, net.Addr) {}, func(ConnectionState) {})
+	defer a.Close()
+
+	// Both pairs share a foundation, only the first is checked at first
+	a.AddLocalCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}})
+	a.AddLocalCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1001, LocalPreference: 1}})
+	a.AddRemoteCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 2000}})
+
+	a.Lock()
+	defer a.Unlock()
+	startWithoutTaskLoop(a, false)
+	assert.Equal(t, CandidatePairStateWaiting, a.checklist[0].state)
+	assert.Equal(t, CandidatePairStateFrozen, a.checklist[1].state)
+
+	a.checklist[0].state = CandidatePairStateSucceeded
+	a.unfreezeFoundation(a.checklist[0].Foundation())
+	assert.Equal(t, CandidatePairStateWaiting, a.checklist[1].state)
+}
+
+type testPacket struct {
+	raw    []byte
+	local  *stun.TransportAddr
+	remote net.Addr
+}
+
+// reversePacket is how a packet sent from local to remote is received
+func reversePacket(raw []byte, local *stun.TransportAddr, remote net.Addr) testPacket {
+	protocol, ip, port := addrInfo(remote)
+	var from net.Addr = &net.UDPAddr{IP: local.IP, Port: local.Port}
+	if protocol == ProtoTypeTCP {
+		from = &net.TCPAddr{IP: local.IP, Port: local.Port}
+	}
+	return testPacket{raw, &stun.TransportAddr{IP: ip, Port: port}, from}
+}
+
+func TestAgentConnectivityCheck(t *testing.T) {
+	var toControlled, toControlling []testPacket
+	controlling := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		toControlled = append(toControlled, reversePacket(raw, local, remote))
+	}, func(ConnectionState) {})
+	defer controlling.Close()
+	controlled := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		toControlling = append(toControlling, reversePacket(raw, local, remote))
+	}, func(ConnectionState) {})
+	defer controlled.Close()
+
+	controllingHost := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}}
+	controlledHost := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 2000}}
+	for _, a := range []*Agent{controlling, controlled} {
+		a.LocalUfrag, a.LocalPwd = "ufrag", "pwd"
+	}
+	controlling.AddLocalCandidate(controllingHost)
+	controlling.AddRemoteCandidate(controlledHost)
+	controlled.AddLocalCandidate(controlledHost)
+	controlled.AddRemoteCandidate(controllingHost)
+
+	for a, isControlling := range map[*Agent]bool{controlling: true, controlled: false} {
+		a.Lock()
+		startWithoutTaskLoop(a, isControlling)
+		a.Unlock()
+	}
+
+	for i := 0; i < 5; i++ {
+		for _, a := range []*Agent{controlling, controlled} {
+			a.Lock()
+			a.checkTick()
+			a.unlock()
+		}
+
+		// Deliver until both sides have nothing more to say
+		for len(toControlled) > 0 || len(toControlling) > 0 {
+			packets := toControlled
+			toControlled = nil
+			for _, p := range packets {
+				controlled.HandleInbound(p.raw, p.local, p.remote)
+			}
+			packets = toControlling
+			toControlling = nil
+			for _, p := range packets {
+				controlling.HandleInbound(p.raw, p.local, p.remote)
+			}
+		}
+	}
+
+	for _, a := range []*Agent{controlling, controlled} {
+		a.RLock()
+		assert.NotNil(t, a.selectedPair)
+		assert.Equal(t, ConnectionState(ConnectionStateConnected), a.connectionState)
+		a.RUnlock()
+	}
+
+	local, remote := controlling.SelectedPair()
+	if assert.NotNil(t, local) {
+		assert.Equal(t, "192.168.0.1:1000", local.String())
+		assert.Equal(t, "192.168.0.2:2000", remote.String())
+	}
+
+	// A check signed with the wrong password is discarded
+	controlled.Lock()
+	controlled.remotePwd = "wrong"
+	controlled.sendBindingRequest(controlled.checklist[0], false)
+	controlled.unlock()
+	assert.Len(t, toControlling, 1)
+	controlling.HandleInbound(toControlling[0].raw, toControlling[0].local, toControlling[0].remote)
+	assert.Len(t, toControlled, 0)
+}
+
+func TestAgentRestart(t *testing.T) {
+	a := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {}, func(ConnectionState) {})
+	defer a.Close()
+
+	host := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}}
+	srflx := &CandidateSrflx{
+		CandidateBase: CandidateBase{Protocol: ProtoTypeUDP, Address: "1.2.3.4", Port: 1000},
+		RemoteAddress: "192.168.0.1",
+		RemotePort:    1000,
+	}
+	remote := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 2000}}
+	a.AddLocalCandidate(host)
+	a.AddLocalCandidate(srflx)
+	a.AddRemoteCandidate(remote)
+	a.GatheringComplete()
+
+	a.Lock()
+	startWithoutTaskLoop(a, true)
+	a.setSelectedPair(a.checklist[0])
+	a.Unlock()
+
+	ufrag, pwd := a.LocalUfrag, a.LocalPwd
+	a.Restart()
+
+	a.RLock()
+	assert.NotEqual(t, ufrag, a.LocalUfrag)
+	assert.NotEqual(t, pwd, a.LocalPwd)
+	assert.Equal(t, []Candidate{host}, a.LocalCandidates)
+	assert.Empty(t, a.remoteCandidates)
+	assert.Empty(t, a.checklist)
+	assert.Nil(t, a.selectedPair)
+	assert.Equal(t, GatheringState(GatheringStateComplete), a.gatheringState)
+	a.RUnlock()
+
+	// Data keeps flowing on the pair selected before the restart
+	local, selected := a.SelectedPair()
+	if assert.NotNil(t, local) {
+		assert.Equal(t, "192.168.0.1:1000", local.String())
+		assert.Equal(t, "192.168.0.2:2000", selected.String())
+	}
+
+	// Checks resume with the new credentials of the peer
+	a.AddRemoteCandidate(remote)
+	assert.Nil(t, a.Start(true, "ufrag2", "pwd2"))
+	a.RLock()
+	assert.Len(t, a.checklist, 1)
+	assert.Equal(t, "ufrag2", a.remoteUfrag)
+	assert.Equal(t, ConnectionState(ConnectionStateConnected), a.connectionState)
+	a.RUnlock()
+	assert.NotNil(t, a.Start(true, "ufrag2", "pwd2"))
+}
+
+func TestAgentConsentFreshness(t *testing.T) {
+	var sent [][]byte
+	a := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		sent = append(sent, raw)
+	}, func(ConnectionState) {})
+	defer a.Close()
+	assert.NotNil(t, a.SetConsentFreshness(0, 2))
+	assert.NotNil(t, a.SetConsentFreshness(time.Second, 0))
+	assert.Nil(t, a.SetConsentFreshness(time.Second, 2))
+
+	a.AddLocalCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}})
+	a.AddRemoteCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 2000}})
+
+	a.Lock()
+	startWithoutTaskLoop(a, true)
+	a.setSelectedPair(a.checklist[0])
+	a.consentTick()
+	a.unlock()
+	assert.Len(t, sent, 1)
+
+	// The peer answering a consent check refreshes consent
+	answer := func(raw []byte) {
+		request, err := stun.NewMessage(raw)
+		assert.Nil(t, err)
+		response, err := stun.Build(stun.ClassSuccessResponse, stun.MethodBinding, request.TransactionID,
+			&stun.XorMappedAddress{XorAddress: stun.XorAddress{IP: net.ParseIP("192.168.0.1"), Port: 1000}},
+			&stun.MessageIntegrity{Key: []byte("pwd")},
+			&stun.Fingerprint{},
+		)
+		assert.Nil(t, err)
+		a.HandleInbound(response.Pack(), &stun.TransportAddr{IP: net.ParseIP("192.168.0.1"), Port: 1000}, &net.UDPAddr{IP: net.ParseIP("192.168.0.2"), Port: 2000})
+	}
+	answer(sent[0])
+
+	a.Lock()
+	assert.False(t, a.isConsentPending)
+	a.consentTick()
+	a.consentTick()
+	assert.Equal(t, ConnectionState(ConnectionStateDisconnected), a.connectionState)
+	assert.NotNil(t, a.selectedPair)
+	a.unlock()
+
+	answer(sent[2])
+	a.Lock()
+	assert.Equal(t, ConnectionState(ConnectionStateConnected), a.connectionState)
+
+	// Consent expires once failureThreshold checks go unanswered
+	a.consentTick()
+	a.consentTick()
+	a.consentTick()
+	assert.Equal(t, ConnectionState(ConnectionStateFailed), a.connectionState)
+	assert.Nil(t, a.selectedPair)
+	a.Unlock()
+
+	local, remote := a.SelectedPair()
+	assert.Nil(t, local)
+	assert.Nil(t, remote)
+}
+
+func TestAgentRoleConflict(t *testing.T) {
+	var toB, toA []testPacket
+	a := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		toB = append(toB, reversePacket(raw, local, remote))
+	}, func(ConnectionState) {})
+	defer a.Close()
+	b := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		toA = append(toA, reversePacket(raw, local, remote))
+	}, func(ConnectionState) {})
+	defer b.Close()
+
+	hostA := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}}
+	hostB := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 2000}}
+	a.AddLocalCandidate(hostA)
+	a.AddRemoteCandidate(hostB)
+	b.AddLocalCandidate(hostB)
+	b.AddRemoteCandidate(hostA)
+
+	// Both agents believe they are controlling, the larger tie breaker wins
+	a.tieBreaker, b.tieBreaker = 1, 2
+	for _, agent := range []*Agent{a, b} {
+		agent.LocalUfrag, agent.LocalPwd = "ufrag", "pwd"
+		agent.Lock()
+		startWithoutTaskLoop(agent, true)
+		agent.Unlock()
+	}
+
+	for i := 0; i < 5; i++ {
+		for _, agent := range []*Agent{a, b} {
+			agent.Lock()
+			agent.checkTick()
+			agent.unlock()
+		}
+
+		for len(toA) > 0 || len(toB) > 0 {
+			packets := toB
+			toB = nil
+			for _, p := range packets {
+				b.HandleInbound(p.raw, p.local, p.remote)
+			}
+			packets = toA
+			toA = nil
+			for _, p := range packets {
+				a.HandleInbound(p.raw, p.local, p.remote)
+			}
+		}
+	}
+
+	a.RLock()
+	assert.False(t, a.isControlling)
+	assert.False(t, a.checklist[0].controlling)
+	assert.NotNil(t, a.selectedPair)
+	a.RUnlock()
+
+	b.RLock()
+	assert.True(t, b.isControlling)
+	assert.NotNil(t, b.selectedPair)
+	b.RUnlock()
+}
+
+func TestAgentLite(t *testing.T) {
+	var toLite, toFull []testPacket
+	full := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		toLite = append(toLite, reversePacket(raw, local, remote))
+	}, func(ConnectionState) {})
+	defer full.Close()
+	lite := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		toFull = append(toFull, reversePacket(raw, local, remote))
+	}, func(ConnectionState) {})
+	defer lite.Close()
+
+	assert.Nil(t, lite.SetLite(true))
+	assert.NotNil(t, lite.AddURL(&URL{Scheme: SchemeTypeSTUN, Host: "127.0.0.1", Port: 3478, Proto: ProtoTypeUDP}))
+
+	fullHost := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}}
+	liteHost := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "1.2.3.4", Port: 2000}}
+	for _, a := range []*Agent{full, lite} {
+		a.LocalUfrag, a.LocalPwd = "ufrag", "pwd"
+	}
+	full.AddLocalCandidate(fullHost)
+	full.AddRemoteCandidate(liteHost)
+	lite.AddLocalCandidate(liteHost)
+	lite.AddRemoteCandidate(fullHost)
+
+	full.Lock()
+	startWithoutTaskLoop(full, true)
+	full.Unlock()
+	lite.Lock()
+	startWithoutTaskLoop(lite, false)
+	assert.False(t, lite.isChecking())
+	lite.Unlock()
+
+	for i := 0; i < 5; i++ {
+		full.Lock()
+		full.checkTick()
+		full.unlock()
+
+		for len(toLite) > 0 || len(toFull) > 0 {
+			packets := toLite
+			toLite = nil
+			for _, p := range packets {
+				lite.HandleInbound(p.raw, p.local, p.remote)
+			}
+			packets = toFull
+			toFull = nil
+			for _, p := range packets {
+				// The lite agent only answers
+				m, err := stun.NewMessage(p.raw)
+				if assert.Nil(t, err) {
+					assert.Equal(t, stun.ClassSuccessResponse, m.Class)
+				}
+				full.HandleInbound(p.raw, p.local, p.remote)
+			}
+		}
+	}
+
+	for _, a := range []*Agent{full, lite} {
+		a.RLock()
+		assert.NotNil(t, a.selectedPair)
+		assert.Equal(t, ConnectionState(ConnectionStateConnected), a.connectionState)
+		a.RUnlock()
+	}
+
+	local, remote := lite.SelectedPair()
+	if assert.NotNil(t, local) {
+		assert.Equal(t, "1.2.3.4:2000", local.String())
+		assert.Equal(t, "192.168.0.1:1000", remote.String())
+	}
+}
+
+func TestAgentPeerReflexive(t *testing.T) {
+	// b is behind a NAT that maps 192.168.0.2:2000 to 203.0.113.2:3000,
+	// a doesn't know either address
+	mapped := &net.UDPAddr{IP: net.ParseIP("203.0.113.2"), Port: 3000}
+	var toA, toB []testPacket
+	a := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		toB = append(toB, testPacket{raw, &stun.TransportAddr{IP: net.ParseIP("192.168.0.2"), Port: 2000}, &net.UDPAddr{IP: local.IP, Port: local.Port}})
+	}, func(ConnectionState) {})
+	defer a.Close()
+	b := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		packet := reversePacket(raw, local, remote)
+		packet.remote = mapped
+		toA = append(toA, packet)
+	}, func(ConnectionState) {})
+	defer b.Close()
+
+	hostA := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}}
+	hostB := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 2000}}
+	a.AddLocalCandidate(hostA)
+	b.AddLocalCandidate(hostB)
+	b.AddRemoteCandidate(hostA)
+
+	for agent, isControlling := range map[*Agent]bool{a: false, b: true} {
+		agent.LocalUfrag, agent.LocalPwd = "ufrag", "pwd"
+		agent.Lock()
+		startWithoutTaskLoop(agent, isControlling)
+		agent.Unlock()
+	}
+
+	for i := 0; i < 5; i++ {
+		for _, agent := range []*Agent{a, b} {
+			agent.Lock()
+			agent.checkTick()
+			agent.unlock()
+		}
+
+		for len(toA) > 0 || len(toB) > 0 {
+			packets := toA
+			toA = nil
+			for _, p := range packets {
+				a.HandleInbound(p.raw, p.local, p.remote)
+			}
+			packets = toB
+			toB = nil
+			for _, p := range packets {
+				b.HandleInbound(p.raw, p.local, p.remote)
+			}
+		}
+	}
+
+	// a learns the mapped address from the checks of b, with the priority
+	// they tell
+	a.RLock()
+	if assert.Len(t, a.remoteCandidates, 1) {
+		prflx := a.remoteCandidates[0]
+		assert.Equal(t, CandidateTypePeerReflexive, prflx.Type())
+		assert.Equal(t, "203.0.113.2", prflx.GetBase().Address)
+		assert.Equal(t, 3000, prflx.GetBase().Port)
+		assert.Equal(t, hostB.Priority(PrflxCandidatePreference, 1), Priority(prflx, 1))
+	}
+	assert.NotNil(t, a.selectedPair)
+	a.RUnlock()
+
+	// b learns it from the responses of a, and keeps sending from its base
+	b.RLock()
+	if assert.Len(t, b.LocalCandidates, 2) {
+		prflx, ok := b.LocalCandidates[1].(*CandidatePrflx)
+		if assert.True(t, ok) {
+			assert.Equal(t, "203.0.113.2", prflx.Address)
+			assert.Equal(t, "192.168.0.2", prflx.RemoteAddress)
+			assert.Equal(t, 2000, prflx.RemotePort)
+		}
+	}
+	assert.Len(t, b.checklist, 1)
+	assert.NotNil(t, b.selectedPair)
+	b.RUnlock()
+
+	local, remote := a.SelectedPair()
+	if assert.NotNil(t, local) {
+		assert.Equal(t, "192.168.0.1:1000", local.String())
+		assert.Equal(t, mapped.String(), remote.String())
+	}
+}
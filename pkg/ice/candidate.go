@@ -1,19 +1,65 @@
 package ice
 
 import (
-	"math/rand"
+	"fmt"
+	"hash/crc32"
 	"time"
 )
 
 // Preference enums when generate Priority
+// https://tools.ietf.org/html/rfc8445#section-5.1.2.2
 const (
 	HostCandidatePreference  uint16 = 126
+	PrflxCandidatePreference uint16 = 110
 	SrflxCandidatePreference uint16 = 100
 )
 
+// DefaultLocalPreference is the local preference of a candidate whose
+// LocalPreference is left zero, the value RFC 8445 recommends for an agent
+// with a single IP address
+const DefaultLocalPreference uint16 = 65535
+
+// CandidateType represents the type of an ICE candidate
+type CandidateType int
+
+// CandidateType enums
+const (
+	CandidateTypeHost CandidateType = iota + 1
+	CandidateTypeServerReflexive
+	CandidateTypePeerReflexive
+)
+
+func (c CandidateType) String() string {
+	switch c {
+	case CandidateTypeHost:
+		return "host"
+	case CandidateTypeServerReflexive:
+		return "srflx"
+	case CandidateTypePeerReflexive:
+		return "prflx"
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// Preference returns the type preference of the candidate type
+func (c CandidateType) Preference() uint16 {
+	switch c {
+	case CandidateTypeHost:
+		return HostCandidatePreference
+	case CandidateTypeServerReflexive:
+		return SrflxCandidatePreference
+	case CandidateTypePeerReflexive:
+		return PrflxCandidatePreference
+	default:
+		return 0
+	}
+}
+
 // Candidate represents an ICE candidate
 type Candidate interface {
 	GetBase() *CandidateBase
+	Type() CandidateType
 }
 
 // CandidateBase represents an ICE candidate, a base with enough attributes
@@ -23,14 +69,55 @@ type CandidateBase struct {
 	Address  string
 	Port     int
 	LastSeen time.Time
+
+	// LocalPreference tells apart the candidates of the same type an agent
+	// has on different interfaces, DefaultLocalPreference when zero. TCP
+	// candidates compute it with TCPLocalPreference.
+	LocalPreference uint16
+
+	// TCPType is set for TCP candidates only
+	TCPType TCPType
+
+	// Hostname is signaled in place of the address of a host candidate, the
+	// mDNS .local name that keeps the address private or the public address
+	// a 1:1 NAT maps it to
+	// https://tools.ietf.org/html/draft-ietf-rtcweb-mdns-ice-candidates
+	Hostname string
+
+	// Generation is the generation extension of a signaled candidate,
+	// browsers count their ICE restarts with it
+	Generation int
 }
 
 // Priority computes the priority for this ICE Candidate
-func (c *CandidateBase) Priority(typePreference uint16, component uint16) uint16 {
-	localPreference := uint16(rand.New(rand.NewSource(time.Now().UnixNano())).Uint32() / 2)
-	return (2^24)*typePreference +
-		(2^8)*localPreference +
-		(2^0)*(256-component)
+// https://tools.ietf.org/html/rfc8445#section-5.1.2.1
+func (c *CandidateBase) Priority(typePreference uint16, component uint16) uint32 {
+	localPreference := c.LocalPreference
+	if localPreference == 0 {
+		localPreference = DefaultLocalPreference
+	}
+
+	return (1<<24)*uint32(typePreference) +
+		(1<<8)*uint32(localPreference) +
+		(1<<0)*(256-uint32(component))
+}
+
+// Foundation computes the foundation of a candidate of the given type with
+// this base. Candidates of the same type, from the same base IP address
+// and transport protocol share it.
+// https://tools.ietf.org/html/rfc8445#section-5.1.1.3
+func (c *CandidateBase) Foundation(typ CandidateType) string {
+	return fmt.Sprint(crc32.ChecksumIEEE([]byte(typ.String() + c.Address + c.Protocol.String())))
+}
+
+// Priority computes the priority of the candidate for the given component
+func Priority(c Candidate, component uint16) uint32 {
+	return c.GetBase().Priority(c.Type().Preference(), component)
+}
+
+// Foundation computes the foundation of the candidate
+func Foundation(c Candidate) string {
+	return c.GetBase().Foundation(c.Type())
 }
 
 // CandidateHost is a Candidate of typ Host
@@ -43,6 +130,11 @@ func (c *CandidateHost) GetBase() *CandidateBase {
 	return &c.CandidateBase
 }
 
+// Type returns CandidateTypeHost
+func (c *CandidateHost) Type() CandidateType {
+	return CandidateTypeHost
+}
+
 // Address for CandidateHost
 func (c *CandidateHost) Address() string {
 	return c.CandidateBase.Address
@@ -64,3 +156,30 @@ type CandidateSrflx struct {
 func (c *CandidateSrflx) GetBase() *CandidateBase {
 	return &c.CandidateBase
 }
+
+// Type returns CandidateTypeServerReflexive
+func (c *CandidateSrflx) Type() CandidateType {
+	return CandidateTypeServerReflexive
+}
+
+// CandidatePrflx is a Candidate of typ Peer-Reflexive, an address learned
+// from a connectivity check. A remote one is the address a check of the
+// peer came from, a local one the address the peer saw one of ours come
+// from, RemoteAddress and RemotePort are then its base.
+// https://tools.ietf.org/html/rfc8445#section-7.2.5.3.1
+// https://tools.ietf.org/html/rfc8445#section-7.3.1.3
+type CandidatePrflx struct {
+	CandidateBase
+	RemoteAddress string
+	RemotePort    int
+}
+
+// GetBase returns the CandidateBase, attributes shared between all Candidates
+func (c *CandidatePrflx) GetBase() *CandidateBase {
+	return &c.CandidateBase
+}
+
+// Type returns CandidateTypePeerReflexive
+func (c *CandidatePrflx) Type() CandidateType {
+	return CandidateTypePeerReflexive
+}
@@ -0,0 +1,59 @@
+package ice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCandidatePriority(t *testing.T) {
+	testCases := []struct {
+		candidate Candidate
+		component uint16
+		priority  uint32
+	}{
+		{&CandidateHost{CandidateBase{Address: "192.168.0.1"}}, 1, 2130706431},
+		{&CandidateHost{CandidateBase{Address: "192.168.0.1"}}, 2, 2130706430},
+		{&CandidateHost{CandidateBase{Address: "192.168.0.1", LocalPreference: 65534}}, 1, 2130706175},
+		{&CandidateSrflx{CandidateBase: CandidateBase{Address: "1.2.3.4"}}, 1, 1694498815},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.priority,
+			Priority(testCase.candidate, testCase.component),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestCandidateFoundation(t *testing.T) {
+	host := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}}
+	samePort := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 2000}}
+	otherAddress := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 1000}}
+	otherProtocol := &CandidateHost{CandidateBase{Protocol: ProtoTypeTCP, Address: "192.168.0.1", Port: 1000}}
+	srflx := &CandidateSrflx{CandidateBase: CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}}
+
+	assert.Equal(t, Foundation(host), Foundation(samePort))
+	assert.NotEqual(t, Foundation(host), Foundation(otherAddress))
+	assert.NotEqual(t, Foundation(host), Foundation(otherProtocol))
+	assert.NotEqual(t, Foundation(host), Foundation(srflx))
+}
+
+func TestAgentGathering(t *testing.T) {
+	a := NewAgent(nil, nil)
+	candidates := make(chan Candidate, 2)
+	a.OnCandidate(func(c Candidate) {
+		candidates <- c
+	})
+	assert.Equal(t, GatheringStateNew, a.GatheringState())
+
+	host := &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}}
+	a.AddLocalCandidate(host)
+	assert.Equal(t, GatheringStateGathering, a.GatheringState())
+	assert.Equal(t, host, <-candidates)
+
+	a.GatheringComplete()
+	assert.Equal(t, GatheringStateComplete, a.GatheringState())
+	assert.Nil(t, <-candidates)
+}
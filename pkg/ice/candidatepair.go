@@ -0,0 +1,166 @@
+package ice
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pions/webrtc/pkg/stun"
+)
+
+// CandidatePairState represents the state of a CandidatePair in the checklist
+// https://tools.ietf.org/html/rfc8445#section-6.1.2.6
+type CandidatePairState int
+
+// CandidatePairState enums
+const (
+	// CandidatePairStateFrozen means a check for the pair hasn't been
+	// performed, and it can't be until it is Waiting
+	CandidatePairStateFrozen CandidatePairState = iota + 1
+
+	// CandidatePairStateWaiting means a check has not been sent for the pair
+	CandidatePairStateWaiting
+
+	// CandidatePairStateInProgress means a check has been sent for the pair,
+	// but the transaction is in progress
+	CandidatePairStateInProgress
+
+	// CandidatePairStateFailed means a check for the pair was already done
+	// and failed
+	CandidatePairStateFailed
+
+	// CandidatePairStateSucceeded means a check for the pair was already done
+	// and produced a successful result
+	CandidatePairStateSucceeded
+)
+
+func (c CandidatePairState) String() string {
+	switch c {
+	case CandidatePairStateFrozen:
+		return "frozen"
+	case CandidatePairStateWaiting:
+		return "waiting"
+	case CandidatePairStateInProgress:
+		return "in-progress"
+	case CandidatePairStateFailed:
+		return "failed"
+	case CandidatePairStateSucceeded:
+		return "succeeded"
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+func newCandidatePair(local, remote Candidate, controlling bool) *CandidatePair {
+	return &CandidatePair{
+		remote:      remote,
+		local:       local,
+		controlling: controlling,
+		state:       CandidatePairStateFrozen,
+	}
+}
+
+// CandidatePair represents a combination of a local and remote candidate
+type CandidatePair struct {
+	remote Candidate
+	local  Candidate
+
+	// controlling is the role of the agent the pair belongs to, which the
+	// pair priority depends on
+	controlling bool
+
+	state     CandidatePairState
+	nominated bool
+
+	// rtt is the round trip time of the last check answered
+	rtt time.Duration
+
+	// The controlled agent was told to use the pair before its own check
+	// for it succeeded, the pair is nominated once it does
+	nominateOnSuccess bool
+}
+
+func (c *CandidatePair) String() string {
+	return fmt.Sprintf("%s %s:%d <-> %s %s:%d (%s)",
+		c.local.Type(), c.local.GetBase().Address, c.local.GetBase().Port,
+		c.remote.Type(), c.remote.GetBase().Address, c.remote.GetBase().Port,
+		c.state)
+}
+
+// Priority computes the priority of the pair, G is the priority of the
+// candidate of the controlling agent and D the one of the controlled agent
+// https://tools.ietf.org/html/rfc8445#section-6.1.2.3
+func (c *CandidatePair) Priority() uint64 {
+	g, d := Priority(c.local, 1), Priority(c.remote, 1)
+	if !c.controlling {
+		g, d = d, g
+	}
+
+	min, max := g, d
+	if min > max {
+		min, max = max, min
+	}
+
+	var tieBreak uint64
+	if g > d {
+		tieBreak = 1
+	}
+	return (1<<32)*uint64(min) + 2*uint64(max) + tieBreak
+}
+
+// Foundation is the combination of the foundations of both candidates,
+// pairs with the same one are likely to have the same outcome
+func (c *CandidatePair) Foundation() string {
+	return Foundation(c.local) + Foundation(c.remote)
+}
+
+// canCheck reports whether the agent can send checks for the pair, passive
+// TCP candidates only answer the checks of connections opened to them
+// https://tools.ietf.org/html/rfc6544#section-6.2
+func (c *CandidatePair) canCheck() bool {
+	return c.local.GetBase().TCPType != TCPTypePassive
+}
+
+// isIdle reports whether the pair waits for a check that won't be sent,
+// the one of a connection opened to a passive candidate
+func (c *CandidatePair) isIdle() bool {
+	return !c.canCheck() && (c.state == CandidatePairStateWaiting || c.state == CandidatePairStateFrozen)
+}
+
+// canPair reports whether a local and remote candidate can form a pair,
+// they must use the same transport protocol and IP version
+// https://tools.ietf.org/html/rfc8445#section-6.1.2.2
+func canPair(local, remote Candidate) bool {
+	l, r := local.GetBase(), remote.GetBase()
+	if l.Protocol != r.Protocol || isIPv6(l.Address) != isIPv6(r.Address) {
+		return false
+	}
+	return l.Protocol != ProtoTypeTCP || canPairTCP(l.TCPType, r.TCPType)
+}
+
+func (c *CandidatePair) getAddrs() (local *stun.TransportAddr, remote net.Addr) {
+	localIP := net.ParseIP(c.local.GetBase().Address)
+	localPort := c.local.GetBase().Port
+
+	switch c := c.local.(type) {
+	case *CandidateSrflx:
+		localIP = net.ParseIP(c.RemoteAddress)
+		localPort = c.RemotePort
+	case *CandidatePrflx:
+		localIP = net.ParseIP(c.RemoteAddress)
+		localPort = c.RemotePort
+	}
+
+	remoteIP := net.ParseIP(c.remote.GetBase().Address)
+	remotePort := c.remote.GetBase().Port
+	if c.remote.GetBase().Protocol == ProtoTypeTCP {
+		remote = &net.TCPAddr{IP: remoteIP, Port: remotePort}
+	} else {
+		remote = &net.UDPAddr{IP: remoteIP, Port: remotePort}
+	}
+
+	return &stun.TransportAddr{
+		IP:   localIP,
+		Port: localPort,
+	}, remote
+}
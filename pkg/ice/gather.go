@@ -0,0 +1,206 @@
+package ice
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pions/webrtc/pkg/stun"
+	"github.com/pkg/errors"
+)
+
+const (
+	// A Binding request to a STUN server that gets no response is sent
+	// again after stunRTO, doubled each time, up to stunMaxRequests times.
+	// The last one is given stunRm times stunRTO to be answered.
+	// https://tools.ietf.org/html/rfc5389#section-7.2.1
+	stunRTO         = 500 * time.Millisecond
+	stunMaxRequests = 7
+	stunRm          = 16
+)
+
+// gatherRequest is a Binding request to a STUN server, sent from a host
+// candidate, waiting for its response
+type gatherRequest struct {
+	base   Candidate
+	server *net.UDPAddr
+	raw    []byte
+	sent   time.Time
+	count  int
+}
+
+// timeout is how long the request last sent is waited for
+func (r *gatherRequest) timeout() time.Duration {
+	if r.count >= stunMaxRequests {
+		return stunRm * stunRTO
+	}
+	return stunRTO << uint(r.count-1)
+}
+
+func (a *Agent) sendGatherRequest(r *gatherRequest) {
+	local := &stun.TransportAddr{
+		IP:   net.ParseIP(r.base.GetBase().Address),
+		Port: r.base.GetBase().Port,
+	}
+	r.sent = time.Now()
+	r.count++
+	a.send(r.raw, local, r.server)
+}
+
+// AddURL gathers a server reflexive candidate for each host candidate, with
+// Binding requests to the STUN server url points to. The candidates are
+// added once the server answers.
+// https://tools.ietf.org/html/rfc8445#section-5.1.1.2
+func (a *Agent) AddURL(url *URL) error {
+	if url.Scheme != SchemeTypeSTUN {
+		return errors.Errorf("%s is not implemented", url.Scheme.String())
+	} else if url.Proto != ProtoTypeUDP {
+		return errors.Errorf("STUN over %s is not implemented", url.Proto.String())
+	}
+
+	server, err := net.ResolveUDPAddr("udp4", fmt.Sprintf("%s:%d", url.Host, url.Port))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to resolve STUN server %s", url.Host)
+	}
+
+	a.Lock()
+	defer a.unlock()
+
+	if a.isLite {
+		return errors.Errorf("lite agents only have host candidates")
+	}
+
+	// The server is kept to gather from again on an ICE restart
+	a.stunServers = append(a.stunServers, server)
+	return a.gatherFrom(server)
+}
+
+// gatherFrom sends a Binding request to server from each UDP host candidate,
+// the IPv4 ones as the server is resolved to an IPv4 address
+func (a *Agent) gatherFrom(server *net.UDPAddr) error {
+	for _, c := range a.LocalCandidates {
+		if c.Type() != CandidateTypeHost || c.GetBase().Protocol != ProtoTypeUDP || isIPv6(c.GetBase().Address) {
+			continue
+		}
+
+		transactionID, err := stun.GenerateTransactionID()
+		if err != nil {
+			return err
+		}
+		msg, err := stun.Build(stun.ClassRequest, stun.MethodBinding, transactionID, &stun.Fingerprint{})
+		if err != nil {
+			return err
+		}
+
+		r := &gatherRequest{
+			base:   c,
+			server: server,
+			raw:    msg.Pack(),
+		}
+		a.gatherTransactions[string(msg.TransactionID)] = r
+		a.sendGatherRequest(r)
+	}
+
+	if !a.isGathering && len(a.gatherTransactions) != 0 {
+		a.isGathering = true
+		go a.gatherTaskLoop()
+	}
+	return nil
+}
+
+// gatherTick retransmits the requests that were not answered in time, and
+// gives up on those that were sent too many times
+func (a *Agent) gatherTick() {
+	for id, r := range a.gatherTransactions {
+		if time.Since(r.sent) < r.timeout() {
+			continue
+		}
+
+		if r.count >= stunMaxRequests {
+			delete(a.gatherTransactions, id)
+			continue
+		}
+		a.sendGatherRequest(r)
+	}
+	a.updateGatheringState()
+}
+
+func (a *Agent) gatherTaskLoop() {
+	for {
+		a.Lock()
+		a.gatherTick()
+		if len(a.gatherTransactions) == 0 {
+			a.isGathering = false
+			a.unlock()
+			return
+		}
+		a.unlock()
+
+		select {
+		case <-time.After(checkInterval):
+		case <-a.taskLoopChan:
+			return
+		}
+	}
+}
+
+// handleGatherResponse adds the server reflexive candidate the STUN server
+// answered with, unless it is the same as the host candidate
+func (a *Agent) handleGatherResponse(m *stun.Message, remote net.Addr) {
+	r := a.gatherTransactions[string(m.TransactionID)]
+	if remote.Network() != r.server.Network() || remote.String() != r.server.String() {
+		return
+	}
+	delete(a.gatherTransactions, string(m.TransactionID))
+	defer a.updateGatheringState()
+
+	if m.Class != stun.ClassSuccessResponse {
+		fmt.Printf("Failed to gather server reflexive candidate from: %s got %s\n", remote.String(), m.Class)
+		return
+	}
+
+	attr, ok := m.GetOneAttribute(stun.AttrXORMappedAddress)
+	if !ok {
+		fmt.Printf("Got response from STUN server %s that did not contain XORAddress\n", remote.String())
+		return
+	}
+	var addr stun.XorAddress
+	if err := addr.Unpack(m, attr); err != nil {
+		fmt.Printf("Failed to unpack STUN XorAddress response: %s\n", err.Error())
+		return
+	}
+
+	// https://tools.ietf.org/html/rfc8445#section-5.1.3
+	base := r.base.GetBase()
+	if addr.IP.String() == base.Address && addr.Port == base.Port {
+		return
+	}
+	for _, c := range a.LocalCandidates {
+		if srflx, ok := c.(*CandidateSrflx); ok && srflx.Address == addr.IP.String() && srflx.Port == addr.Port &&
+			srflx.RemoteAddress == base.Address && srflx.RemotePort == base.Port {
+			return
+		}
+	}
+
+	a.addLocalCandidate(&CandidateSrflx{
+		CandidateBase: CandidateBase{
+			Protocol:        base.Protocol,
+			Address:         addr.IP.String(),
+			Port:            addr.Port,
+			LocalPreference: base.LocalPreference,
+		},
+		RemoteAddress: base.Address,
+		RemotePort:    base.Port,
+	})
+}
+
+// updateGatheringState completes gathering once the user of the Agent has
+// added every candidate and no STUN server is waited for anymore
+func (a *Agent) updateGatheringState() {
+	if !a.gatheringDone || len(a.gatherTransactions) != 0 || a.gatheringState == GatheringStateComplete {
+		return
+	}
+
+	a.gatheringState = GatheringStateComplete
+	a.notifyCandidate(nil)
+}
@@ -0,0 +1,89 @@
+package ice
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pions/webrtc/pkg/stun"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatherRequestTimeout(t *testing.T) {
+	// Requests are sent at 0, 500, 1500, 3500, 7500, 15500 and 31500ms
+	// and given up at 39500ms
+	r := &gatherRequest{}
+	var elapsed time.Duration
+	var sentAt []time.Duration
+	for r.count < stunMaxRequests {
+		if r.count > 0 {
+			elapsed += r.timeout()
+		}
+		sentAt = append(sentAt, elapsed)
+		r.count++
+	}
+	elapsed += r.timeout()
+
+	assert.Equal(t, []time.Duration{
+		0, 500 * time.Millisecond, 1500 * time.Millisecond, 3500 * time.Millisecond,
+		7500 * time.Millisecond, 15500 * time.Millisecond, 31500 * time.Millisecond,
+	}, sentAt)
+	assert.Equal(t, 39500*time.Millisecond, elapsed)
+}
+
+func TestGatherServerReflexive(t *testing.T) {
+	var sent []testPacket
+	a := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		sent = append(sent, testPacket{raw, local, remote})
+	}, func(ConnectionState) {})
+	defer a.Close()
+
+	candidates := make(chan Candidate, 2)
+	a.OnCandidate(func(c Candidate) {
+		candidates <- c
+	})
+
+	a.AddLocalCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}})
+	assert.Equal(t, "192.168.0.1", (<-candidates).GetBase().Address)
+
+	url, err := ParseURL("stun:127.0.0.1:3478")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, a.AddURL(url))
+	a.GatheringComplete()
+
+	a.Lock()
+	if !assert.Len(t, sent, 1) {
+		a.Unlock()
+		return
+	}
+	assert.Equal(t, "192.168.0.1:1000", sent[0].local.String())
+	assert.Equal(t, "127.0.0.1:3478", sent[0].remote.String())
+	assert.Equal(t, GatheringStateGathering, a.gatheringState)
+	a.Unlock()
+
+	request, err := stun.NewMessage(sent[0].raw)
+	if !assert.NoError(t, err) {
+		return
+	}
+	response, err := stun.Build(stun.ClassSuccessResponse, stun.MethodBinding, request.TransactionID,
+		&stun.XorMappedAddress{
+			XorAddress: stun.XorAddress{IP: net.ParseIP("1.2.3.4"), Port: 5000},
+		},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	a.HandleInbound(response.Pack(), sent[0].local, sent[0].remote)
+
+	srflx, ok := (<-candidates).(*CandidateSrflx)
+	if assert.True(t, ok) {
+		assert.Equal(t, "1.2.3.4", srflx.Address)
+		assert.Equal(t, 5000, srflx.Port)
+		assert.Equal(t, "192.168.0.1", srflx.RemoteAddress)
+		assert.Equal(t, 1000, srflx.RemotePort)
+	}
+	assert.Nil(t, <-candidates)
+	assert.Equal(t, GatheringStateComplete, a.GatheringState())
+}
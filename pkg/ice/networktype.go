@@ -0,0 +1,70 @@
+package ice
+
+import "net"
+
+// NetworkType is a transport protocol and IP version host candidates can be
+// gathered for
+type NetworkType int
+
+const (
+	// NetworkTypeUDP4 is UDP over IPv4
+	NetworkTypeUDP4 NetworkType = iota + 1
+
+	// NetworkTypeUDP6 is UDP over IPv6
+	NetworkTypeUDP6
+
+	// NetworkTypeTCP4 is ICE-TCP over IPv4
+	NetworkTypeTCP4
+
+	// NetworkTypeTCP6 is ICE-TCP over IPv6
+	NetworkTypeTCP6
+)
+
+// DefaultNetworkTypes are the network types gathered for when none are
+// given, IPv6 has to be asked for
+var DefaultNetworkTypes = []NetworkType{NetworkTypeUDP4, NetworkTypeTCP4}
+
+// String returns the name of the network the Go net package knows it by
+func (t NetworkType) String() string {
+	switch t {
+	case NetworkTypeUDP4:
+		return "udp4"
+	case NetworkTypeUDP6:
+		return "udp6"
+	case NetworkTypeTCP4:
+		return "tcp4"
+	case NetworkTypeTCP6:
+		return "tcp6"
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// Protocol returns the transport protocol of the network type
+func (t NetworkType) Protocol() ProtoType {
+	switch t {
+	case NetworkTypeUDP4, NetworkTypeUDP6:
+		return ProtoTypeUDP
+	case NetworkTypeTCP4, NetworkTypeTCP6:
+		return ProtoTypeTCP
+	default:
+		return ProtoType(Unknown)
+	}
+}
+
+// IsIPv6 reports whether the network type is over IPv6
+func (t NetworkType) IsIPv6() bool {
+	return t == NetworkTypeUDP6 || t == NetworkTypeTCP6
+}
+
+// Matches reports whether ip is an address of the IP version of the
+// network type
+func (t NetworkType) Matches(ip net.IP) bool {
+	return ip != nil && (ip.To4() == nil) == t.IsIPv6()
+}
+
+// isIPv6 reports whether address is an IPv6 address
+func isIPv6(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && ip.To4() == nil
+}
@@ -0,0 +1,42 @@
+package ice
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkType(t *testing.T) {
+	testCases := []struct {
+		networkType NetworkType
+		name        string
+		protocol    ProtoType
+		ip          string
+	}{
+		{NetworkTypeUDP4, "udp4", ProtoTypeUDP, "192.168.0.1"},
+		{NetworkTypeUDP6, "udp6", ProtoTypeUDP, "2001:db8::1"},
+		{NetworkTypeTCP4, "tcp4", ProtoTypeTCP, "192.168.0.1"},
+		{NetworkTypeTCP6, "tcp6", ProtoTypeTCP, "2001:db8::1"},
+	}
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.name, testCase.networkType.String(), "testCase: %d %v", i, testCase)
+		assert.Equal(t, testCase.protocol, testCase.networkType.Protocol(), "testCase: %d %v", i, testCase)
+		assert.True(t, testCase.networkType.Matches(net.ParseIP(testCase.ip)), "testCase: %d %v", i, testCase)
+	}
+	assert.False(t, NetworkTypeUDP4.Matches(net.ParseIP("2001:db8::1")))
+	assert.False(t, NetworkTypeUDP6.Matches(net.ParseIP("192.168.0.1")))
+	assert.False(t, NetworkTypeUDP4.Matches(nil))
+}
+
+func TestCanPairIPVersion(t *testing.T) {
+	host := func(address string) *CandidateHost {
+		return &CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: address, Port: 1000}}
+	}
+
+	// Candidates only pair with candidates of the same IP version
+	assert.True(t, canPair(host("192.168.0.1"), host("192.168.0.2")))
+	assert.True(t, canPair(host("2001:db8::1"), host("2001:db8::2")))
+	assert.False(t, canPair(host("192.168.0.1"), host("2001:db8::2")))
+	assert.False(t, canPair(host("2001:db8::1"), host("192.168.0.2")))
+}
@@ -0,0 +1,187 @@
+package ice
+
+import (
+	"net"
+	"time"
+
+	"github.com/pions/webrtc/pkg/stun"
+)
+
+// keepaliveInterval is Tr, the time without anything sent on the pair in use
+// after which a keepalive is sent
+// https://tools.ietf.org/html/rfc8445#section-11
+const keepaliveInterval = 15 * time.Second
+
+// CandidatePairStats is a snapshot of the state and the activity of a
+// candidate pair
+type CandidatePairStats struct {
+	Local     Candidate
+	Remote    Candidate
+	State     CandidatePairState
+	Nominated bool
+
+	// RoundTripTime is measured by the last check of the pair that was
+	// answered without being retransmitted, zero until one is
+	RoundTripTime time.Duration
+
+	LastPacketSentTimestamp     time.Time
+	LastPacketReceivedTimestamp time.Time
+	BytesSent                   uint64
+	BytesReceived               uint64
+}
+
+// pairTraffic counts what was sent and received between two addresses, it
+// is guarded by the statsLock of the Agent
+type pairTraffic struct {
+	lastSent      time.Time
+	lastReceived  time.Time
+	bytesSent     uint64
+	bytesReceived uint64
+}
+
+// trafficKey identifies the traffic between two addresses. DTLS isn't told
+// the network of the addresses it sends to, so it is left out.
+func trafficKey(local *stun.TransportAddr, remote net.Addr) string {
+	return local.String() + "/" + remote.String()
+}
+
+func (a *Agent) getTraffic(local *stun.TransportAddr, remote net.Addr) *pairTraffic {
+	key := trafficKey(local, remote)
+	t, ok := a.traffic[key]
+	if !ok {
+		t = &pairTraffic{}
+		a.traffic[key] = t
+	}
+	return t
+}
+
+// PacketSent counts n bytes the user of the Agent sent from local to remote.
+// It doesn't take the lock of the Agent, and can be called while the
+// OutboundCallback is.
+func (a *Agent) PacketSent(local *stun.TransportAddr, remote net.Addr, n int) {
+	a.statsLock.Lock()
+	defer a.statsLock.Unlock()
+
+	t := a.getTraffic(local, remote)
+	t.lastSent = time.Now()
+	t.bytesSent += uint64(n)
+}
+
+// PacketReceived counts n bytes the user of the Agent received on local from
+// remote, other than those passed to HandleInbound
+func (a *Agent) PacketReceived(local *stun.TransportAddr, remote net.Addr, n int) {
+	a.statsLock.Lock()
+	defer a.statsLock.Unlock()
+
+	t := a.getTraffic(local, remote)
+	t.lastReceived = time.Now()
+	t.bytesReceived += uint64(n)
+}
+
+// outboundPacket is traffic queued while the agent lock is held
+type outboundPacket struct {
+	raw    []byte
+	local  *stun.TransportAddr
+	remote net.Addr
+}
+
+// send queues raw, it is passed to the OutboundCallback once the agent lock
+// is released by unlock
+func (a *Agent) send(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+	a.outbound = append(a.outbound, outboundPacket{raw, local, remote})
+}
+
+// unlock releases the agent lock, then passes the queued traffic to the
+// OutboundCallback and counts it. The user of the Agent may hold its own
+// locks while calling into the Agent, and take them again when sending.
+func (a *Agent) unlock() {
+	outbound := a.outbound
+	a.outbound = nil
+	a.Unlock()
+
+	for _, p := range outbound {
+		a.PacketSent(p.local, p.remote, len(p.raw))
+		a.outboundCallback(p.raw, p.local, p.remote)
+	}
+}
+
+func (a *Agent) pairStats(p *CandidatePair) CandidatePairStats {
+	stats := CandidatePairStats{
+		Local:         p.local,
+		Remote:        p.remote,
+		State:         p.state,
+		Nominated:     p.nominated,
+		RoundTripTime: p.rtt,
+	}
+
+	a.statsLock.Lock()
+	defer a.statsLock.Unlock()
+	if t, ok := a.traffic[trafficKey(p.getAddrs())]; ok {
+		stats.LastPacketSentTimestamp = t.lastSent
+		stats.LastPacketReceivedTimestamp = t.lastReceived
+		stats.BytesSent = t.bytesSent
+		stats.BytesReceived = t.bytesReceived
+	}
+	return stats
+}
+
+// GetCandidatePairsStats returns the stats of every pair of the checklist
+func (a *Agent) GetCandidatePairsStats() []CandidatePairStats {
+	a.RLock()
+	defer a.RUnlock()
+
+	stats := make([]CandidatePairStats, 0, len(a.checklist))
+	for _, p := range a.checklist {
+		stats = append(stats, a.pairStats(p))
+	}
+	return stats
+}
+
+// GetSelectedPairStats returns the stats of the pair data is sent on, false
+// when there is none
+func (a *Agent) GetSelectedPairStats() (CandidatePairStats, bool) {
+	a.RLock()
+	defer a.RUnlock()
+
+	if p := a.usedPair(); p != nil {
+		return a.pairStats(p), true
+	}
+	return CandidatePairStats{}, false
+}
+
+// usedPair is the pair data is sent on, the one selected before an ICE
+// restart until a new one is
+func (a *Agent) usedPair() *CandidatePair {
+	if a.selectedPair != nil {
+		return a.selectedPair
+	}
+	return a.restartPair
+}
+
+// keepaliveTick sends a Binding indication on the pair in use once nothing
+// was sent on it for keepaliveInterval, to keep NAT bindings open
+// https://tools.ietf.org/html/rfc8445#section-11
+func (a *Agent) keepaliveTick() {
+	p := a.usedPair()
+	if p == nil {
+		return
+	}
+
+	local, remote := p.getAddrs()
+	a.statsLock.Lock()
+	lastSent := a.getTraffic(local, remote).lastSent
+	a.statsLock.Unlock()
+	if time.Since(lastSent) < keepaliveInterval {
+		return
+	}
+
+	transactionID, err := stun.GenerateTransactionID()
+	if err != nil {
+		return
+	}
+	msg, err := stun.Build(stun.ClassIndication, stun.MethodBinding, transactionID, &stun.Fingerprint{})
+	if err != nil {
+		return
+	}
+	a.send(msg.Pack(), local, remote)
+}
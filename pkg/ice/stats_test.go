@@ -0,0 +1,78 @@
+package ice
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pions/webrtc/pkg/stun"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgentPairStats(t *testing.T) {
+	var sent []*stun.Message
+	a := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		m, err := stun.NewMessage(raw)
+		assert.Nil(t, err)
+		sent = append(sent, m)
+	}, func(ConnectionState) {})
+	defer a.Close()
+
+	a.AddLocalCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.1", Port: 1000}})
+	a.AddRemoteCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 2000}})
+	local := &stun.TransportAddr{IP: net.ParseIP("192.168.0.1"), Port: 1000}
+	remote := &net.UDPAddr{IP: net.ParseIP("192.168.0.2"), Port: 2000}
+
+	_, ok := a.GetSelectedPairStats()
+	assert.False(t, ok)
+
+	a.Lock()
+	startWithoutTaskLoop(a, false)
+	a.checkTick()
+	a.unlock()
+	if !assert.Len(t, sent, 1) {
+		return
+	}
+
+	// The round trip time is measured by the check
+	time.Sleep(10 * time.Millisecond)
+	response, err := stun.Build(stun.ClassSuccessResponse, stun.MethodBinding, sent[0].TransactionID,
+		&stun.XorMappedAddress{XorAddress: stun.XorAddress{IP: local.IP, Port: local.Port}},
+		&stun.MessageIntegrity{Key: []byte("pwd")},
+		&stun.Fingerprint{},
+	)
+	assert.Nil(t, err)
+	raw := response.Pack()
+	a.HandleInbound(raw, local, remote)
+
+	a.PacketSent(local, remote, 100)
+	a.PacketReceived(local, remote, 200)
+
+	stats := a.GetCandidatePairsStats()
+	if assert.Len(t, stats, 1) {
+		assert.Equal(t, CandidatePairStateSucceeded, stats[0].State)
+		assert.True(t, stats[0].RoundTripTime >= 10*time.Millisecond)
+		assert.Equal(t, uint64(len(sent[0].Raw)+100), stats[0].BytesSent)
+		assert.Equal(t, uint64(len(raw)+200), stats[0].BytesReceived)
+		assert.False(t, stats[0].LastPacketSentTimestamp.IsZero())
+		assert.False(t, stats[0].LastPacketReceivedTimestamp.IsZero())
+	}
+
+	a.Lock()
+	a.setSelectedPair(a.checklist[0])
+	a.Unlock()
+	selected, ok := a.GetSelectedPairStats()
+	assert.True(t, ok)
+	assert.True(t, selected.Nominated)
+
+	// A keepalive is only sent once the pair has been idle for a while
+	a.Lock()
+	a.keepaliveTick()
+	assert.Len(t, sent, 1)
+	a.traffic[trafficKey(local, remote)].lastSent = time.Now().Add(-keepaliveInterval)
+	a.keepaliveTick()
+	a.unlock()
+	if assert.Len(t, sent, 2) {
+		assert.Equal(t, stun.ClassIndication, sent[1].Class)
+	}
+}
@@ -0,0 +1,89 @@
+package ice
+
+// TCPType is the type of an ICE-TCP candidate, it tells how the TCP
+// connections of the candidate are established
+// https://tools.ietf.org/html/rfc6544#section-4.5
+type TCPType int
+
+const (
+	// TCPTypeActive is a candidate that opens outbound connections and
+	// doesn't accept any
+	TCPTypeActive TCPType = iota + 1
+
+	// TCPTypePassive is a candidate that accepts inbound connections and
+	// doesn't open any
+	TCPTypePassive
+
+	// TCPTypeSimultaneousOpen is a candidate that opens connections to the
+	// remote candidates that open connections to it at the same time
+	TCPTypeSimultaneousOpen
+)
+
+// NewTCPType creates a TCPType from the value of the tcptype attribute of
+// a candidate
+func NewTCPType(raw string) TCPType {
+	switch raw {
+	case "active":
+		return TCPTypeActive
+	case "passive":
+		return TCPTypePassive
+	case "so":
+		return TCPTypeSimultaneousOpen
+	default:
+		return TCPType(Unknown)
+	}
+}
+
+func (t TCPType) String() string {
+	switch t {
+	case TCPTypeActive:
+		return "active"
+	case TCPTypePassive:
+		return "passive"
+	case TCPTypeSimultaneousOpen:
+		return "so"
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// directionPreference ranks the TCP types of host candidates, active ones
+// are preferred as they get through the most NATs
+// https://tools.ietf.org/html/rfc6544#section-4.2
+func (t TCPType) directionPreference() uint16 {
+	switch t {
+	case TCPTypeActive:
+		return 6
+	case TCPTypePassive:
+		return 4
+	case TCPTypeSimultaneousOpen:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// TCPLocalPreference computes the local preference of a TCP candidate, the
+// other preference tells apart the candidates of the same TCP type and
+// must be below 2^13
+// https://tools.ietf.org/html/rfc6544#section-4.2
+func TCPLocalPreference(t TCPType, otherPreference uint16) uint16 {
+	return (1<<13)*t.directionPreference() + otherPreference&(1<<13-1)
+}
+
+// canPairTCP reports whether a TCP connection can be established between the
+// local and remote candidate, an active candidate connects to a passive one
+// and simultaneous-open candidates connect to each other
+// https://tools.ietf.org/html/rfc6544#section-6.2
+func canPairTCP(local, remote TCPType) bool {
+	switch local {
+	case TCPTypeActive:
+		return remote == TCPTypePassive
+	case TCPTypePassive:
+		return remote == TCPTypeActive
+	case TCPTypeSimultaneousOpen:
+		return remote == TCPTypeSimultaneousOpen
+	default:
+		return false
+	}
+}
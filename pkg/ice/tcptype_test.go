@@ -0,0 +1,86 @@
+package ice
+
+import (
+	"net"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/stun"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTCPLocalPreference(t *testing.T) {
+	// Active candidates are preferred, all TCP candidates rank below UDP
+	// ones of the same type
+	assert.Equal(t, uint16(6<<13+8191), TCPLocalPreference(TCPTypeActive, 8191))
+	assert.Equal(t, uint16(4<<13+1), TCPLocalPreference(TCPTypePassive, 1))
+	assert.Equal(t, uint16(2<<13), TCPLocalPreference(TCPTypeSimultaneousOpen, 1<<13))
+	assert.True(t, TCPLocalPreference(TCPTypeActive, 8191) < DefaultLocalPreference)
+}
+
+func TestAgentTCPCandidates(t *testing.T) {
+	var sent []testPacket
+	a := NewAgent(func(raw []byte, local *stun.TransportAddr, remote net.Addr) {
+		sent = append(sent, testPacket{raw, local, remote})
+	}, func(ConnectionState) {})
+	defer a.Close()
+
+	tcpCandidate := func(address string, port int, tcpType TCPType) *CandidateHost {
+		return &CandidateHost{CandidateBase{Protocol: ProtoTypeTCP, Address: address, Port: port, TCPType: tcpType}}
+	}
+	a.AddLocalCandidate(tcpCandidate("192.168.0.1", 9, TCPTypeActive))
+	a.AddLocalCandidate(tcpCandidate("192.168.0.1", 1000, TCPTypePassive))
+	a.AddRemoteCandidate(tcpCandidate("192.168.0.2", 9, TCPTypeActive))
+	a.AddRemoteCandidate(tcpCandidate("192.168.0.2", 2000, TCPTypePassive))
+	a.AddRemoteCandidate(&CandidateHost{CandidateBase{Protocol: ProtoTypeUDP, Address: "192.168.0.2", Port: 3000}})
+
+	a.Lock()
+	defer a.Unlock()
+	startWithoutTaskLoop(a, true)
+	a.LocalPwd = "pwd"
+
+	// Active candidates pair with passive ones, TCP ones with TCP ones only
+	if !assert.Len(t, a.checklist, 2) {
+		return
+	}
+	for _, p := range a.checklist {
+		assert.True(t, canPairTCP(p.local.GetBase().TCPType, p.remote.GetBase().TCPType))
+	}
+
+	// Only the active candidate sends checks, opening a connection to the
+	// passive one
+	a.checkTick()
+	a.checkTick()
+	flush(a)
+	if !assert.Len(t, sent, 1) {
+		return
+	}
+	assert.Equal(t, "192.168.0.1:9", sent[0].local.String())
+	assert.Equal(t, "tcp", sent[0].remote.Network())
+	assert.Equal(t, "192.168.0.2:2000", sent[0].remote.String())
+	assert.True(t, a.isChecking())
+
+	// The remote active candidate connects to the passive one from a port
+	// it didn't tell, it is learned from the check
+	transactionID, err := stun.GenerateTransactionID()
+	assert.NoError(t, err)
+	request, err := stun.Build(stun.ClassRequest, stun.MethodBinding, transactionID,
+		&stun.Username{Username: a.LocalUfrag + ":ufrag"},
+		&stun.IceControlled{TieBreaker: 1},
+		&stun.MessageIntegrity{Key: []byte(a.LocalPwd)},
+		&stun.Fingerprint{},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	from := &net.TCPAddr{IP: net.ParseIP("192.168.0.2"), Port: 50000}
+	a.Unlock()
+	a.HandleInbound(request.Pack(), &stun.TransportAddr{IP: net.ParseIP("192.168.0.1"), Port: 1000}, from)
+	a.Lock()
+
+	if !assert.Len(t, sent, 2) {
+		return
+	}
+	assert.Equal(t, "192.168.0.1:1000", sent[1].local.String())
+	assert.Equal(t, from.String(), sent[1].remote.String())
+	assert.NotNil(t, a.getPair(&stun.TransportAddr{IP: net.ParseIP("192.168.0.1"), Port: 1000}, from))
+}
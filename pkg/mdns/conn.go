@@ -0,0 +1,274 @@
+// Package mdns implements the subset of multicast DNS defined in RFC 6762
+// that ICE candidates need to be signaled with .local hostnames in place of
+// their addresses
+// https://tools.ietf.org/html/draft-ietf-rtcweb-mdns-ice-candidates
+package mdns
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	// https://tools.ietf.org/html/rfc6762#section-3
+	multicastAddress = "224.0.0.251:5353"
+
+	// https://tools.ietf.org/html/rfc6762#section-17
+	maxMessageSize = 9000
+
+	// responseTTL is how long, in seconds, an answer may be cached, the one
+	// RFC 6762 recommends for records with a host name
+	responseTTL = 120
+
+	// cacheFlush tells the answer replaces the ones cached for the name
+	// https://tools.ietf.org/html/rfc6762#section-10.2
+	cacheFlush = 1 << 15
+
+	// A query that is not answered is sent again after queryInterval
+	queryInterval = time.Second
+)
+
+// Conn answers the queries for the names registered with it, and resolves
+// the names registered by other hosts
+type Conn struct {
+	socket  *net.UDPConn
+	dstAddr *net.UDPAddr
+
+	lock    sync.Mutex
+	names   map[string]net.IP
+	queries map[string][]chan net.IP
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Listen joins the mDNS group on every interface that supports multicast
+func Listen() (*Conn, error) {
+	dstAddr, err := net.ResolveUDPAddr("udp4", multicastAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// The port is shared with the mDNS responder of the system, if any
+	socket, err := net.ListenMulticastUDP("udp4", nil, dstAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		_ = socket.Close()
+		return nil, err
+	}
+	group := ipv4.NewPacketConn(socket)
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagUp == 0 || ifaces[i].Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		// The default interface was joined already
+		_ = group.JoinGroup(&ifaces[i], dstAddr)
+	}
+
+	return newConn(socket, dstAddr), nil
+}
+
+func newConn(socket *net.UDPConn, dstAddr *net.UDPAddr) *Conn {
+	c := &Conn{
+		socket:  socket,
+		dstAddr: dstAddr,
+		names:   make(map[string]net.IP),
+		queries: make(map[string][]chan net.IP),
+		closed:  make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+// fqdn returns name the way it is compared to the names of messages
+func fqdn(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, ".")) + "."
+}
+
+// Register answers the queries for name with ip
+func (c *Conn) Register(name string, ip net.IP) error {
+	if ip.To4() == nil {
+		return ErrNotIPv4
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.names[fqdn(name)] = ip.To4()
+	return nil
+}
+
+// Query resolves name, the question is sent again every queryInterval
+// until it is answered or timeout has passed
+func (c *Conn) Query(name string, timeout time.Duration) (net.IP, error) {
+	name = fqdn(name)
+	answer := make(chan net.IP, 1)
+
+	c.lock.Lock()
+	c.queries[name] = append(c.queries[name], answer)
+	c.lock.Unlock()
+	defer c.removeQuery(name, answer)
+
+	deadline := time.After(timeout)
+	for {
+		if err := c.sendQuestion(name); err != nil {
+			return nil, err
+		}
+
+		select {
+		case ip := <-answer:
+			return ip, nil
+		case <-time.After(queryInterval):
+		case <-deadline:
+			return nil, ErrQueryTimeout
+		case <-c.closed:
+			return nil, ErrConnClosed
+		}
+	}
+}
+
+func (c *Conn) removeQuery(name string, answer chan net.IP) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	queries := c.queries[name]
+	for i := range queries {
+		if queries[i] == answer {
+			queries = append(queries[:i], queries[i+1:]...)
+			break
+		}
+	}
+	if len(queries) == 0 {
+		delete(c.queries, name)
+	} else {
+		c.queries[name] = queries
+	}
+}
+
+func (c *Conn) sendQuestion(name string) error {
+	n, err := dnsmessage.NewName(name)
+	if err != nil {
+		return errors.Wrapf(err, "invalid mdns name %s", name)
+	}
+
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{
+			Name:  n,
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	return c.send(&msg)
+}
+
+// sendAnswer multicasts the answer so every host caches it
+// https://tools.ietf.org/html/rfc6762#section-6
+func (c *Conn) sendAnswer(name dnsmessage.Name, ip net.IP) error {
+	var a [4]byte
+	copy(a[:], ip)
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			Response:      true,
+			Authoritative: true,
+		},
+		Answers: []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{
+				Name:  name,
+				Type:  dnsmessage.TypeA,
+				Class: dnsmessage.ClassINET | cacheFlush,
+				TTL:   responseTTL,
+			},
+			Body: &dnsmessage.AResource{A: a},
+		}},
+	}
+	return c.send(&msg)
+}
+
+func (c *Conn) send(msg *dnsmessage.Message) error {
+	raw, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+	_, err = c.socket.WriteTo(raw, c.dstAddr)
+	return err
+}
+
+func (c *Conn) readLoop() {
+	b := make([]byte, maxMessageSize)
+	for {
+		n, _, err := c.socket.ReadFrom(b)
+		if err != nil {
+			return
+		}
+
+		var msg dnsmessage.Message
+		if err := msg.Unpack(b[:n]); err != nil {
+			continue
+		}
+		if msg.Header.Response {
+			c.handleAnswers(msg.Answers)
+		} else {
+			c.handleQuestions(msg.Questions)
+		}
+	}
+}
+
+// handleQuestions answers the questions for the registered names
+func (c *Conn) handleQuestions(questions []dnsmessage.Question) {
+	for _, q := range questions {
+		if q.Type != dnsmessage.TypeA && q.Type != dnsmessage.TypeALL {
+			continue
+		}
+
+		c.lock.Lock()
+		ip, ok := c.names[fqdn(q.Name.String())]
+		c.lock.Unlock()
+		if !ok {
+			continue
+		}
+
+		if err := c.sendAnswer(q.Name, ip); err != nil {
+			return
+		}
+	}
+}
+
+// handleAnswers passes the addresses answered to the queries waiting for
+// them
+func (c *Conn) handleAnswers(answers []dnsmessage.Resource) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, a := range answers {
+		body, ok := a.Body.(*dnsmessage.AResource)
+		if !ok {
+			continue
+		}
+
+		for _, answer := range c.queries[fqdn(a.Header.Name.String())] {
+			select {
+			case answer <- net.IP(append([]byte{}, body.A[:]...)):
+			default:
+			}
+		}
+	}
+}
+
+// Close stops answering and fails the queries in progress
+func (c *Conn) Close() (err error) {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.socket.Close()
+	})
+	return err
+}
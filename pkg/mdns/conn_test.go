@@ -0,0 +1,46 @@
+package mdns
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pipeConns returns two Conns that send to each other over loopback
+func pipeConns(t *testing.T) (*Conn, *Conn) {
+	listen := func() *net.UDPConn {
+		socket, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return socket
+	}
+
+	a, b := listen(), listen()
+	return newConn(a, b.LocalAddr().(*net.UDPAddr)), newConn(b, a.LocalAddr().(*net.UDPAddr))
+}
+
+func TestConnQuery(t *testing.T) {
+	querier, responder := pipeConns(t)
+	defer func() {
+		assert.Nil(t, querier.Close())
+		assert.Nil(t, responder.Close())
+	}()
+
+	assert.Equal(t, ErrNotIPv4, responder.Register("ipv6.local", net.ParseIP("::1")))
+	assert.Nil(t, responder.Register("1f1c4b1e-0fbb-4d5b-a34a-3a8b1a6c6b8e.local", net.ParseIP("192.168.0.1")))
+
+	// Names are compared case insensitively
+	ip, err := querier.Query("1F1C4B1E-0FBB-4D5B-A34A-3A8B1A6C6B8E.local", time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.0.1", ip.String())
+
+	_, err = querier.Query("unknown.local", 100*time.Millisecond)
+	assert.Equal(t, ErrQueryTimeout, err)
+
+	querier.lock.Lock()
+	assert.Empty(t, querier.queries)
+	querier.lock.Unlock()
+}
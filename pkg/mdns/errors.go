@@ -0,0 +1,19 @@
+package mdns
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Errors returned while resolving names
+var (
+	// ErrConnClosed indicates the Conn was closed before the query was
+	// answered.
+	ErrConnClosed = errors.New("mdns conn closed")
+
+	// ErrQueryTimeout indicates no host answered a query in time.
+	ErrQueryTimeout = errors.New("mdns query timed out")
+
+	// ErrNotIPv4 indicates a name was registered with an address that is
+	// not IPv4, the only kind answered.
+	ErrNotIPv4 = errors.New("mdns names can only be registered with IPv4 addresses")
+)
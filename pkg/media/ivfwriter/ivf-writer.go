@@ -46,12 +46,12 @@ func New(fileName string) (*IVFWriter, error) {
 func (i *IVFWriter) AddPacket(packet *rtp.Packet) error {
 
 	vp8Packet := codecs.VP8Packet{}
-	err := vp8Packet.Unmarshal(packet)
+	payload, err := vp8Packet.Unmarshal(packet)
 	if err != nil {
 		return err
 	}
 
-	i.currentFrame = append(i.currentFrame, vp8Packet.Payload[0:]...)
+	i.currentFrame = append(i.currentFrame, payload...)
 
 	if !packet.Marker {
 		return nil
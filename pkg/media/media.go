@@ -1,7 +1,12 @@
 package media
 
+import "time"
+
 // RTCSample contains media, and the amount of samples in it
 type RTCSample struct {
 	Data    []byte
 	Samples uint32
+
+	// Duration is the length of the media, when the clock rate is known
+	Duration time.Duration
 }
@@ -1,6 +1,8 @@
 package samplebuilder
 
 import (
+	"time"
+
 	"github.com/pions/webrtc/pkg/media"
 	"github.com/pions/webrtc/pkg/rtp"
 )
@@ -12,85 +14,269 @@ type SampleBuilder struct {
 	maxLate uint16
 	buffer  [65536]*rtp.Packet
 
+	depacketizer         rtp.Depacketizer
+	partitionHeadChecker rtp.PartitionHeadChecker
+	clockRate            uint32
+
 	// Last seqnum that has been added to buffer
-	lastPush uint16
+	hasPushed bool
+	lastPush  uint16
 
 	// Last seqnum that has been successfully popped
 	hasPopped        bool
 	lastPopSeq       uint16
 	lastPopTimestamp uint32
+
+	// Set after a loss, when the packet following lastPopSeq can't be
+	// assumed to begin a frame
+	resync bool
+
+	// Packets dropped because a frame they belonged to was incomplete
+	droppedPackets uint64
 }
 
-// New constructs a new SampleBuilder
-func New(maxLate uint16) *SampleBuilder {
-	return &SampleBuilder{maxLate: maxLate}
+// Option configures a SampleBuilder
+type Option func(*SampleBuilder)
+
+// WithPartitionHeadChecker lets the SampleBuilder recognise the first
+// packet of a frame from its payload, so the first frame, and the frames
+// following a loss, can be emitted without waiting for the frame before
+// them.
+func WithPartitionHeadChecker(checker rtp.PartitionHeadChecker) Option {
+	return func(s *SampleBuilder) {
+		s.partitionHeadChecker = checker
+	}
+}
+
+// New constructs a new SampleBuilder. Packets are depacketized with
+// depacketizer, or used as they are if it is nil, and the durations of
+// samples computed from the clockRate of their timestamps, if it is known.
+func New(maxLate uint16, depacketizer rtp.Depacketizer, clockRate uint32, opts ...Option) *SampleBuilder {
+	s := &SampleBuilder{
+		maxLate:      maxLate,
+		depacketizer: depacketizer,
+		clockRate:    clockRate,
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
 }
 
-// Push adds a RTP Packet to the sample builder
+// Push adds a RTP Packet to the sample builder. Packets may be pushed in
+// any order, but those arriving after the sample they belong to has been
+// popped, or more than maxLate behind the newest packet, are ignored.
 func (s *SampleBuilder) Push(p *rtp.Packet) {
+	if s.hasPopped && !rtp.SequenceNumberAfter(p.SequenceNumber, s.lastPopSeq) {
+		return
+	}
+	if s.hasPushed && rtp.SequenceNumberDiff(p.SequenceNumber, s.lastPush) >= int(s.maxLate) {
+		return
+	}
+
 	s.buffer[p.SequenceNumber] = p
-	s.lastPush = p.SequenceNumber
-	s.buffer[p.SequenceNumber-s.maxLate] = nil
+	if !s.hasPushed || rtp.SequenceNumberAfter(p.SequenceNumber, s.lastPush) {
+		// Forget everything that has now fallen out of the window
+		for i := s.lastPush - s.maxLate; s.hasPushed && i != p.SequenceNumber-s.maxLate; i++ {
+			s.buffer[i] = nil
+		}
+		s.buffer[p.SequenceNumber-s.maxLate] = nil
 
+		s.lastPush = p.SequenceNumber
+		s.hasPushed = true
+	}
 }
 
-// We have a valid collection of RTP Packets
-// walk forwards building a sample if everything looks good clear and update buffer+values
-func (s *SampleBuilder) buildSample(firstBuffer uint16) *media.RTCSample {
-	data := []byte{}
+// DroppedPackets returns how many packets have been discarded so far
+// because the frame they belonged to was incomplete.
+func (s *SampleBuilder) DroppedPackets() uint64 {
+	return s.droppedPackets
+}
+
+// isLost reports whether the packet seq can no longer arrive in time
+func (s *SampleBuilder) isLost(seq uint16) bool {
+	return rtp.SequenceNumberDiff(seq, s.lastPush) >= int(s.maxLate)
+}
+
+// isFrameHead reports whether the packet at seq begins a frame
+func (s *SampleBuilder) isFrameHead(seq uint16) bool {
+	curr, prev := s.buffer[seq], s.buffer[seq-1]
+	if curr == nil {
+		return false
+	}
+
+	if s.partitionHeadChecker != nil {
+		if !s.partitionHeadChecker.IsPartitionHead(curr.Payload) {
+			return false
+		}
+		return prev == nil || prev.Timestamp != curr.Timestamp
+	}
+
+	// Without inspecting payloads a frame can only be known to begin when
+	// the packet before it is known to end another one
+	return prev != nil && (prev.Marker || prev.Timestamp != curr.Timestamp)
+}
+
+// frameEnd returns the last packet of the frame beginning at head, and
+// whether it is known yet
+func (s *SampleBuilder) frameEnd(head uint16) (uint16, bool) {
+	for i := head; ; i++ {
+		curr := s.buffer[i]
+		if curr == nil || curr.Timestamp != s.buffer[head].Timestamp {
+			return 0, false
+		}
+		if curr.Marker {
+			return i, true
+		}
+		if next := s.buffer[i+1]; next != nil && next.Timestamp != curr.Timestamp {
+			return i, true
+		}
+		if i == s.lastPush {
+			return 0, false
+		}
+	}
+}
 
-	for i := firstBuffer; s.buffer[i] != nil; i++ {
-		if s.buffer[i].Timestamp != s.buffer[firstBuffer].Timestamp {
-			lastTimeStamp := s.lastPopTimestamp
-			if !s.hasPopped && s.buffer[firstBuffer-1] != nil {
-				// firstBuffer-1 should always pass, but just to be safe if there is a bug in Pop()
-				lastTimeStamp = s.buffer[firstBuffer-1].Timestamp
+// isPaddingOnly reports whether p carries no media, as with the padding
+// packets sent to probe for bandwidth
+func isPaddingOnly(p *rtp.Packet) bool {
+	if len(p.Payload) == 0 {
+		return true
+	}
+	return p.Padding && int(p.Payload[len(p.Payload)-1]) == len(p.Payload)
+}
+
+// drop discards the packets from first up to but excluding end
+func (s *SampleBuilder) drop(first, end uint16) {
+	for i := first; i != end; i++ {
+		if s.buffer[i] != nil {
+			if !isPaddingOnly(s.buffer[i]) {
+				s.droppedPackets++
 			}
+			s.buffer[i] = nil
+		}
+	}
+}
 
-			samples := s.buffer[i-1].Timestamp - lastTimeStamp
-			s.lastPopSeq = i - 1
-			s.hasPopped = true
-			s.lastPopTimestamp = s.buffer[i-1].Timestamp
-			for j := firstBuffer; j < i; j++ {
-				s.buffer[j] = nil
+// buildSample depacketizes the frame from head to tail, releasing its
+// packets, and returns it as a sample. A frame that fails to depacketize
+// is dropped and nil is returned, as is one made up only of padding.
+func (s *SampleBuilder) buildSample(head, tail uint16) *media.RTCSample {
+	data := []byte{}
+	failed := false
+	mediaPackets := 0
+	for i := head; i != tail+1; i++ {
+		if isPaddingOnly(s.buffer[i]) {
+			continue
+		}
+		mediaPackets++
+
+		payload := s.buffer[i].Payload
+		if s.depacketizer != nil && !failed {
+			var err error
+			if payload, err = s.depacketizer.Unmarshal(s.buffer[i]); err != nil {
+				failed = true
 			}
-			return &media.RTCSample{Data: data, Samples: samples}
 		}
+		data = append(data, payload...)
+	}
 
-		data = append(data, s.buffer[i].Payload...)
+	// The sample lasts from the previous frame to this one, which is
+	// unknown for the first frame unless the packet before it was seen
+	timestamp := s.buffer[tail].Timestamp
+	var samples uint32
+	if s.hasPopped {
+		samples = timestamp - s.lastPopTimestamp
+	} else if prev := s.buffer[head-1]; prev != nil {
+		samples = timestamp - prev.Timestamp
 	}
-	return nil
+
+	for i := head; i != tail+1; i++ {
+		s.buffer[i] = nil
+	}
+	s.buffer[head-1] = nil
+
+	s.hasPopped = true
+	s.resync = false
+	s.lastPopSeq = tail
+	s.lastPopTimestamp = timestamp
+
+	if failed {
+		s.droppedPackets += uint64(mediaPackets)
+		return nil
+	} else if mediaPackets == 0 {
+		return nil
+	}
+
+	sample := &media.RTCSample{Data: data, Samples: samples}
+	if s.clockRate != 0 {
+		sample.Duration = time.Duration(samples) * time.Second / time.Duration(s.clockRate)
+	}
+	return sample
 }
 
 // Pop scans buffer for valid samples, returns nil when no valid samples have been found
 func (s *SampleBuilder) Pop() *media.RTCSample {
-	var i uint16
-	if !s.hasPopped {
-		i = s.lastPush - s.maxLate
-	} else {
-		i = s.lastPopSeq + 1
-	}
+	for s.hasPushed {
+		head, ok := s.nextHead()
+		if !ok {
+			return nil
+		}
 
-	for ; i != s.lastPush; i++ {
-		curr := s.buffer[i]
-		if curr == nil {
-			if s.buffer[i-1] != nil {
-				break // there is a gap, we can't proceed
+		tail, ok := s.frameEnd(head)
+		if !ok {
+			// A missing packet that can still arrive is waited for,
+			// otherwise the frame can never be completed
+			missing := head
+			for s.buffer[missing] != nil && missing != s.lastPush {
+				missing++
+			}
+			if s.buffer[missing] != nil || !s.isLost(missing) {
+				return nil
 			}
 
-			continue // we haven't hit a buffer yet, keep moving
+			s.drop(head, missing)
+			s.hasPopped = true
+			s.resync = true
+			s.lastPopSeq = missing
+			continue
 		}
 
-		if !s.hasPopped {
-			if s.buffer[i-1] == nil {
-				continue // We have never popped a buffer, so we can't assert that the first RTP packet we encounter is valid
-			} else if s.buffer[i-1].Timestamp == curr.Timestamp {
-				continue // We have the same timestamps, so it is data that spans multiple RTP packets
-			}
+		if sample := s.buildSample(head, tail); sample != nil {
+			return sample
 		}
-
-		// Initial validity checks have passed, walk forward
-		return s.buildSample(i)
 	}
 	return nil
 }
+
+// nextHead returns the first packet of the next frame to emit, dropping
+// any packets before it that can no longer form a complete frame
+func (s *SampleBuilder) nextHead() (uint16, bool) {
+	if s.hasPopped {
+		next := s.lastPopSeq + 1
+		if s.buffer[next] == nil && !s.isLost(next) {
+			return 0, false
+		}
+
+		// Following a complete frame, the next packet begins another
+		if s.buffer[next] != nil && !s.resync && (s.partitionHeadChecker == nil || s.isFrameHead(next)) {
+			return next, true
+		}
+	}
+
+	// The frame following the last one popped was lost, or nothing has
+	// been popped yet, so look for one that begins later
+	first := s.lastPush - s.maxLate
+	if s.hasPopped {
+		first = s.lastPopSeq + 1
+	}
+	for i := first; i != s.lastPush+1; i++ {
+		if s.isFrameHead(i) {
+			if s.hasPopped {
+				s.drop(first, i)
+			}
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -2,6 +2,7 @@ package samplebuilder
 
 import (
 	"testing"
+	"time"
 
 	"github.com/pions/webrtc/pkg/media"
 	"github.com/pions/webrtc/pkg/rtp"
@@ -19,7 +20,7 @@ var testCases = []sampleBuilderTest{
 	{
 		message: "SampleBuilder shouldn't emit anything if only one RTP packet has been pushed",
 		packets: []*rtp.Packet{
-			{SequenceNumber: 5000, Timestamp: 5, Payload: []byte{0x01}},
+			{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 5}, Payload: []byte{0x01}},
 		},
 		samples:    []*media.RTCSample{},
 		bufferSize: 50,
@@ -27,9 +28,9 @@ var testCases = []sampleBuilderTest{
 	{
 		message: "SampleBuilder should emit one packet, we had three packets with unique timestamps",
 		packets: []*rtp.Packet{
-			{SequenceNumber: 5000, Timestamp: 5, Payload: []byte{0x01}},
-			{SequenceNumber: 5001, Timestamp: 6, Payload: []byte{0x02}},
-			{SequenceNumber: 5002, Timestamp: 7, Payload: []byte{0x03}},
+			{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 5}, Payload: []byte{0x01}},
+			{Header: rtp.Header{SequenceNumber: 5001, Timestamp: 6}, Payload: []byte{0x02}},
+			{Header: rtp.Header{SequenceNumber: 5002, Timestamp: 7}, Payload: []byte{0x03}},
 		},
 		samples: []*media.RTCSample{
 			{Data: []byte{0x02}, Samples: 1},
@@ -39,10 +40,10 @@ var testCases = []sampleBuilderTest{
 	{
 		message: "SampleBuilder should emit one packet, we had two packets but two with duplicate timestamps",
 		packets: []*rtp.Packet{
-			{SequenceNumber: 5000, Timestamp: 5, Payload: []byte{0x01}},
-			{SequenceNumber: 5001, Timestamp: 6, Payload: []byte{0x02}},
-			{SequenceNumber: 5002, Timestamp: 6, Payload: []byte{0x03}},
-			{SequenceNumber: 5003, Timestamp: 7, Payload: []byte{0x04}},
+			{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 5}, Payload: []byte{0x01}},
+			{Header: rtp.Header{SequenceNumber: 5001, Timestamp: 6}, Payload: []byte{0x02}},
+			{Header: rtp.Header{SequenceNumber: 5002, Timestamp: 6}, Payload: []byte{0x03}},
+			{Header: rtp.Header{SequenceNumber: 5003, Timestamp: 7}, Payload: []byte{0x04}},
 		},
 		samples: []*media.RTCSample{
 			{Data: []byte{0x02, 0x03}, Samples: 1},
@@ -52,9 +53,9 @@ var testCases = []sampleBuilderTest{
 	{
 		message: "SampleBuilder shouldn't emit a packet because we have a gap before a valid one",
 		packets: []*rtp.Packet{
-			{SequenceNumber: 5000, Timestamp: 5, Payload: []byte{0x01}},
-			{SequenceNumber: 5007, Timestamp: 6, Payload: []byte{0x02}},
-			{SequenceNumber: 5008, Timestamp: 7, Payload: []byte{0x03}},
+			{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 5}, Payload: []byte{0x01}},
+			{Header: rtp.Header{SequenceNumber: 5007, Timestamp: 6}, Payload: []byte{0x02}},
+			{Header: rtp.Header{SequenceNumber: 5008, Timestamp: 7}, Payload: []byte{0x03}},
 		},
 		samples:    []*media.RTCSample{},
 		bufferSize: 50,
@@ -62,12 +63,12 @@ var testCases = []sampleBuilderTest{
 	{
 		message: "SampleBuilder should emit multiple valid packets",
 		packets: []*rtp.Packet{
-			{SequenceNumber: 5000, Timestamp: 1, Payload: []byte{0x01}},
-			{SequenceNumber: 5001, Timestamp: 2, Payload: []byte{0x02}},
-			{SequenceNumber: 5002, Timestamp: 3, Payload: []byte{0x03}},
-			{SequenceNumber: 5003, Timestamp: 4, Payload: []byte{0x04}},
-			{SequenceNumber: 5004, Timestamp: 5, Payload: []byte{0x05}},
-			{SequenceNumber: 5005, Timestamp: 6, Payload: []byte{0x06}},
+			{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 1}, Payload: []byte{0x01}},
+			{Header: rtp.Header{SequenceNumber: 5001, Timestamp: 2}, Payload: []byte{0x02}},
+			{Header: rtp.Header{SequenceNumber: 5002, Timestamp: 3}, Payload: []byte{0x03}},
+			{Header: rtp.Header{SequenceNumber: 5003, Timestamp: 4}, Payload: []byte{0x04}},
+			{Header: rtp.Header{SequenceNumber: 5004, Timestamp: 5}, Payload: []byte{0x05}},
+			{Header: rtp.Header{SequenceNumber: 5005, Timestamp: 6}, Payload: []byte{0x06}},
 		},
 		samples: []*media.RTCSample{
 			{Data: []byte{0x02}, Samples: 1},
@@ -77,13 +78,53 @@ var testCases = []sampleBuilderTest{
 		},
 		bufferSize: 50,
 	},
+	{
+		message: "SampleBuilder should reorder packets that arrive out of order",
+		packets: []*rtp.Packet{
+			{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 5}, Payload: []byte{0x01}},
+			{Header: rtp.Header{SequenceNumber: 5002, Timestamp: 7}, Payload: []byte{0x03}},
+			{Header: rtp.Header{SequenceNumber: 5001, Timestamp: 6}, Payload: []byte{0x02}},
+			{Header: rtp.Header{SequenceNumber: 5003, Timestamp: 8}, Payload: []byte{0x04}},
+		},
+		samples: []*media.RTCSample{
+			{Data: []byte{0x02}, Samples: 1},
+			{Data: []byte{0x03}, Samples: 1},
+		},
+		bufferSize: 50,
+	},
+	{
+		message: "SampleBuilder should emit a packet with the marker bit without waiting for the next timestamp",
+		packets: []*rtp.Packet{
+			{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 5, Marker: true}, Payload: []byte{0x01}},
+			{Header: rtp.Header{SequenceNumber: 5001, Timestamp: 6}, Payload: []byte{0x02}},
+			{Header: rtp.Header{SequenceNumber: 5002, Timestamp: 6, Marker: true}, Payload: []byte{0x03}},
+		},
+		samples: []*media.RTCSample{
+			{Data: []byte{0x02, 0x03}, Samples: 1},
+		},
+		bufferSize: 50,
+	},
+	{
+		message: "SampleBuilder should handle sequence numbers wrapping",
+		packets: []*rtp.Packet{
+			{Header: rtp.Header{SequenceNumber: 65534, Timestamp: 5}, Payload: []byte{0x01}},
+			{Header: rtp.Header{SequenceNumber: 65535, Timestamp: 6}, Payload: []byte{0x02}},
+			{Header: rtp.Header{SequenceNumber: 0, Timestamp: 7}, Payload: []byte{0x03}},
+			{Header: rtp.Header{SequenceNumber: 1, Timestamp: 8}, Payload: []byte{0x04}},
+		},
+		samples: []*media.RTCSample{
+			{Data: []byte{0x02}, Samples: 1},
+			{Data: []byte{0x03}, Samples: 1},
+		},
+		bufferSize: 50,
+	},
 }
 
 func TestSampleBuilder(t *testing.T) {
 	assert := assert.New(t)
 
 	for _, t := range testCases {
-		s := New(t.bufferSize)
+		s := New(t.bufferSize, nil, 0)
 		samples := []*media.RTCSample{}
 
 		for _, p := range t.packets {
@@ -96,3 +137,87 @@ func TestSampleBuilder(t *testing.T) {
 		assert.Equal(samples, t.samples, t.message)
 	}
 }
+
+func TestSampleBuilderLoss(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(5, nil, 0)
+	samples := []*media.RTCSample{}
+
+	// Frames of two packets each, the second with the marker bit set.
+	// Packet 4 is lost, so the frame it began is dropped.
+	for seq := uint16(0); seq < 10; seq++ {
+		if seq == 4 {
+			continue
+		}
+		s.Push(&rtp.Packet{
+			Header:  rtp.Header{SequenceNumber: seq, Timestamp: uint32(1 + seq/2), Marker: seq%2 == 1},
+			Payload: []byte{byte(seq)},
+		})
+		for sample := s.Pop(); sample != nil; sample = s.Pop() {
+			samples = append(samples, sample)
+		}
+	}
+
+	assert.Equal([]*media.RTCSample{
+		{Data: []byte{2, 3}, Samples: 1},
+		{Data: []byte{6, 7}, Samples: 2},
+		{Data: []byte{8, 9}, Samples: 1},
+	}, samples)
+	assert.Equal(uint64(1), s.DroppedPackets())
+
+	// Packets of frames already popped are ignored
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 4, Timestamp: 3}, Payload: []byte{4}})
+	assert.Nil(s.Pop())
+}
+
+// headDepacketizer removes the first payload byte, which is 1 when the
+// packet begins a frame
+type headDepacketizer struct{}
+
+func (d *headDepacketizer) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	return packet.Payload[1:], nil
+}
+
+func (d *headDepacketizer) IsPartitionHead(payload []byte) bool {
+	return payload[0] == 1
+}
+
+func TestSampleBuilderDepacketizer(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(50, &headDepacketizer{}, 90000, WithPartitionHeadChecker(&headDepacketizer{}))
+	for _, p := range []*rtp.Packet{
+		{Header: rtp.Header{SequenceNumber: 10, Timestamp: 3000}, Payload: []byte{1, 0x0a}},
+		{Header: rtp.Header{SequenceNumber: 11, Timestamp: 3000, Marker: true}, Payload: []byte{0, 0x0b}},
+		{Header: rtp.Header{SequenceNumber: 12, Timestamp: 6000, Marker: true}, Payload: []byte{1, 0x0c}},
+	} {
+		s.Push(p)
+	}
+
+	// The first frame is recognised from its payload, though its duration
+	// is unknown
+	assert.Equal(&media.RTCSample{Data: []byte{0x0a, 0x0b}}, s.Pop())
+	assert.Equal(&media.RTCSample{Data: []byte{0x0c}, Samples: 3000, Duration: time.Second / 30}, s.Pop())
+	assert.Nil(s.Pop())
+}
+
+func TestSampleBuilderPadding(t *testing.T) {
+	assert := assert.New(t)
+
+	// A padding-only packet, as sent to probe for bandwidth, between frames
+	s := New(10, nil, 0)
+	for _, p := range []*rtp.Packet{
+		{Header: rtp.Header{SequenceNumber: 0, Timestamp: 1, Marker: true}, Payload: []byte{0x01}},
+		{Header: rtp.Header{SequenceNumber: 1, Timestamp: 2, Marker: true}, Payload: []byte{0x02}},
+		{Header: rtp.Header{SequenceNumber: 2, Timestamp: 2, Padding: true}, Payload: []byte{0x00, 0x02}},
+		{Header: rtp.Header{SequenceNumber: 3, Timestamp: 3, Marker: true}, Payload: []byte{0x03}},
+	} {
+		s.Push(p)
+	}
+
+	assert.Equal(&media.RTCSample{Data: []byte{0x02}, Samples: 1}, s.Pop())
+	assert.Equal(&media.RTCSample{Data: []byte{0x03}, Samples: 1}, s.Pop())
+	assert.Nil(s.Pop())
+	assert.Equal(uint64(0), s.DroppedPackets())
+}
@@ -0,0 +1,153 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ApplicationDefined packets are intended for experimental use as new
+// applications and new features are developed, without requiring packet
+// type value registration.
+type ApplicationDefined struct {
+	// A subtype that allows a set of APP packets to be defined under one
+	// unique name, or for any application-dependent data.
+	Subtype uint8
+	// The synchronization source identifier for the originator of this packet.
+	SSRC uint32
+	// A name chosen by the person defining the set of APP packets to be
+	// unique with respect to other APP packets this application might
+	// receive. It must be exactly four ASCII characters long.
+	Name string
+	// Application-dependent data, its length must be a multiple of 32 bits.
+	Data []byte
+}
+
+const (
+	appSSRCOffset = 0
+	appNameOffset = appSSRCOffset + ssrcLength
+	appNameLength = 4
+	appDataOffset = appNameOffset + appNameLength
+)
+
+// Marshal encodes the ApplicationDefined packet in binary
+func (a ApplicationDefined) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, a.len())
+	if _, err := a.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the ApplicationDefined in binary into buf, returning
+// the number of bytes written.
+func (a ApplicationDefined) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P| subtype |   PT=APP=204  |             length            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                           SSRC/CSRC                           |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                          name (ASCII)                         |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                   application-dependent data                ...
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	if a.Subtype > countMax {
+		return 0, ErrInvalidSubtype
+	}
+
+	if len(a.Name) != appNameLength {
+		return 0, ErrInvalidAppName
+	}
+
+	if len(a.Data)%4 != 0 {
+		return 0, ErrInvalidAppDataLen
+	}
+
+	n := a.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := a.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody[appSSRCOffset:], a.SSRC)
+	copy(packetBody[appNameOffset:], a.Name)
+	copy(packetBody[appDataOffset:], a.Data)
+
+	return n, nil
+}
+
+// Unmarshal decodes the ApplicationDefined packet from binary
+func (a *ApplicationDefined) Unmarshal(rawPacket []byte) error {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P| subtype |   PT=APP=204  |             length            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                           SSRC/CSRC                           |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                          name (ASCII)                         |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                   application-dependent data                ...
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < headerLength+appDataOffset {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypeApplicationDefined {
+		return ErrWrongType
+	}
+
+	packetBody := rawPacket[headerLength:]
+
+	a.Subtype = h.ReportCount
+	a.SSRC = binary.BigEndian.Uint32(packetBody[appSSRCOffset:])
+	a.Name = string(packetBody[appNameOffset:appDataOffset])
+	a.Data = append([]byte{}, packetBody[appDataOffset:]...)
+
+	return nil
+}
+
+func (a ApplicationDefined) len() int {
+	return headerLength + appDataOffset + len(a.Data)
+}
+
+// Header returns the Header associated with this packet.
+func (a ApplicationDefined) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: a.Subtype,
+		Type:        TypeApplicationDefined,
+		Length:      uint16((a.len() / 4) - 1),
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (a ApplicationDefined) DestinationSSRC() []uint32 {
+	return []uint32{a.SSRC}
+}
+
+func (a ApplicationDefined) String() string {
+	return fmt.Sprintf("ApplicationDefined from %x\n\tName: %q\n\tSubtype: %d\n\tData: %x\n",
+		a.SSRC, a.Name, a.Subtype, a.Data)
+}
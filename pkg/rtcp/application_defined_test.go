@@ -0,0 +1,141 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplicationDefinedUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      ApplicationDefined
+		WantError error
+	}{
+		{
+			Name:      "nil",
+			Data:      nil,
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "valid",
+			Data: []byte{
+				// v=2, p=0, subtype=3, APP, len=3
+				0x83, 0xcc, 0x00, 0x03,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// name=PION
+				0x50, 0x49, 0x4f, 0x4e,
+				// data
+				0xde, 0xad, 0xbe, 0xef,
+			},
+			Want: ApplicationDefined{
+				Subtype: 3,
+				SSRC:    0x902f9e2e,
+				Name:    "PION",
+				Data:    []byte{0xde, 0xad, 0xbe, 0xef},
+			},
+		},
+		{
+			Name: "wrong type",
+			Data: []byte{
+				// v=2, p=0, subtype=3, BYE, len=2
+				0x83, 0xcb, 0x00, 0x02,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// name=PION
+				0x50, 0x49, 0x4f, 0x4e,
+			},
+			WantError: ErrWrongType,
+		},
+		{
+			Name: "missing name",
+			Data: []byte{
+				// v=2, p=0, subtype=3, APP, len=1
+				0x83, 0xcc, 0x00, 0x01,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			WantError: ErrPacketTooShort,
+		},
+	} {
+		var app ApplicationDefined
+		err := app.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q app: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := app, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q app: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestApplicationDefinedRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		App       ApplicationDefined
+		WantError error
+	}{
+		{
+			Name: "valid",
+			App: ApplicationDefined{
+				Subtype: 31,
+				SSRC:    0x01020304,
+				Name:    "TEST",
+				Data:    []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			},
+		},
+		{
+			Name: "no data",
+			App: ApplicationDefined{
+				SSRC: 0x01020304,
+				Name: "TEST",
+				Data: []byte{},
+			},
+		},
+		{
+			Name: "invalid subtype",
+			App: ApplicationDefined{
+				Subtype: 32,
+				Name:    "TEST",
+			},
+			WantError: ErrInvalidSubtype,
+		},
+		{
+			Name: "invalid name",
+			App: ApplicationDefined{
+				Name: "TOOLONG",
+			},
+			WantError: ErrInvalidAppName,
+		},
+		{
+			Name: "unaligned data",
+			App: ApplicationDefined{
+				Name: "TEST",
+				Data: []byte{0x01, 0x02, 0x03},
+			},
+			WantError: ErrInvalidAppDataLen,
+		},
+	} {
+		data, err := test.App.Marshal()
+		if got, want := err, test.WantError; got != want {
+			t.Fatalf("Marshal %q: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		var decoded ApplicationDefined
+		if err := decoded.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+
+		if got, want := decoded, test.App; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q app round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package rtcp
+
+// A CompoundPacket is a collection of RTCP packets transmitted as a single packet with
+// the underlying protocol (for example UDP).
+//
+// To maximize the resolution of reception statistics, the first Packet in a CompoundPacket
+// must always be either a SenderReport or a ReceiverReport. This is true even if no data
+// has been sent or received, in which case an empty ReceiverReport must be sent, and even
+// if the only other RTCP packet in the compound packet is a Goodbye.
+//
+// Next, a SourceDescription containing a CNAME item must be included in each CompoundPacket
+// to identify the source and to begin associating media for purposes such as lip-sync.
+//
+// Other RTCP packet types may follow in any order. Packet types may appear more than once.
+type CompoundPacket []Packet
+
+// Validate returns an error if this is not an RFC-compliant CompoundPacket.
+func (c CompoundPacket) Validate() error {
+	if len(c) == 0 {
+		return ErrEmptyCompound
+	}
+
+	// SenderReport and ReceiverReport are the only types that
+	// are allowed to be the first packet in a compound datagram
+	switch c[0].(type) {
+	case *SenderReport, *ReceiverReport:
+		// ok
+	default:
+		return ErrBadFirstPacket
+	}
+
+	for _, pkt := range c[1:] {
+		switch p := pkt.(type) {
+		// If the number of ReceptionReports exceeds 31 additional ReceiverReports
+		// can be included here.
+		case *ReceiverReport:
+			continue
+
+		// A SourceDescription containing a CNAME must be included in every
+		// CompoundPacket.
+		case *SourceDescription:
+			if !p.hasCNAME() {
+				return ErrMissingCNAME
+			}
+
+			return nil
+
+		// Other packets are not permitted before the CNAME
+		default:
+			return ErrPacketBeforeCNAME
+		}
+	}
+
+	// CNAME never reached
+	return ErrMissingCNAME
+}
+
+// ValidateReducedSize returns an error if this is neither an RFC-compliant
+// CompoundPacket nor a valid reduced-size RTCP packet. Once rtcp-rsize has
+// been negotiated (RFC 5506) packets such as a standalone NACK or PLI may
+// be sent on their own, without the leading report and CNAME. A packet
+// that does begin with a report is still held to the compound rules.
+func (c CompoundPacket) ValidateReducedSize() error {
+	if len(c) == 0 {
+		return ErrEmptyCompound
+	}
+
+	switch c[0].(type) {
+	case *SenderReport, *ReceiverReport:
+		return c.Validate()
+	}
+
+	return nil
+}
+
+// Marshal encodes the CompoundPacket as binary, validating it first.
+func (c CompoundPacket) Marshal() ([]byte, error) {
+	rawData := make([]byte, c.len())
+	n, err := c.MarshalTo(rawData)
+	if err != nil {
+		return nil, err
+	}
+	return rawData[:n], nil
+}
+
+// MarshalTo encodes the CompoundPacket as binary into buf, validating it
+// first. It returns the number of bytes written, letting a caller
+// serialize into a pooled buffer.
+func (c CompoundPacket) MarshalTo(buf []byte) (int, error) {
+	if err := c.Validate(); err != nil {
+		return 0, err
+	}
+	return c.marshalTo(buf)
+}
+
+// MarshalReducedSize encodes the CompoundPacket as binary like Marshal,
+// but validates it with ValidateReducedSize, so it may be sent as a
+// reduced-size RTCP packet.
+func (c CompoundPacket) MarshalReducedSize() ([]byte, error) {
+	if err := c.ValidateReducedSize(); err != nil {
+		return nil, err
+	}
+
+	rawData := make([]byte, c.len())
+	n, err := c.marshalTo(rawData)
+	if err != nil {
+		return nil, err
+	}
+	return rawData[:n], nil
+}
+
+func (c CompoundPacket) marshalTo(buf []byte) (int, error) {
+	offset := 0
+	for _, p := range c {
+		n, err := p.MarshalTo(buf[offset:])
+		if err != nil {
+			return 0, err
+		}
+		offset += n
+	}
+
+	return offset, nil
+}
+
+// Unmarshal decodes a CompoundPacket from binary. Each packet is delimited
+// using the length in its header. The result is validated before returning.
+func (c *CompoundPacket) Unmarshal(rawData []byte) error {
+	if err := c.unmarshal(rawData); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// UnmarshalReducedSize decodes a CompoundPacket from binary like Unmarshal,
+// but validates the result with ValidateReducedSize, accepting reduced-size
+// RTCP packets as well as compound ones.
+func (c *CompoundPacket) UnmarshalReducedSize(rawData []byte) error {
+	if err := c.unmarshal(rawData); err != nil {
+		return err
+	}
+	return c.ValidateReducedSize()
+}
+
+func (c *CompoundPacket) unmarshal(rawData []byte) error {
+	out := make(CompoundPacket, 0)
+	for len(rawData) != 0 {
+		var h Header
+		if err := h.Unmarshal(rawData); err != nil {
+			return err
+		}
+
+		packetLen := (int(h.Length) + 1) * 4
+		if packetLen > len(rawData) {
+			return ErrPacketTooShort
+		}
+
+		p, err := Unmarshal(rawData[:packetLen])
+		if err != nil {
+			return err
+		}
+
+		out = append(out, p)
+		rawData = rawData[packetLen:]
+	}
+
+	*c = out
+	return nil
+}
+
+func (c CompoundPacket) len() int {
+	n := 0
+	for _, p := range c {
+		// A RawPacket is copied verbatim, whatever its header claims
+		if raw, ok := p.(*RawPacket); ok {
+			n += len(*raw)
+			continue
+		}
+		n += (int(p.Header().Length) + 1) * 4
+	}
+	return n
+}
+
+// CNAME returns the CNAME that *must* be present in every CompoundPacket
+func (c CompoundPacket) CNAME() (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+
+	for _, pkt := range c[1:] {
+		sdes, ok := pkt.(*SourceDescription)
+		if !ok {
+			continue
+		}
+
+		for _, chunk := range sdes.Chunks {
+			for _, it := range chunk.Items {
+				if it.Type == SDESCNAME {
+					return it.Text, nil
+				}
+			}
+		}
+	}
+
+	return "", ErrMissingCNAME
+}
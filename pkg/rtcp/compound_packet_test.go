@@ -0,0 +1,302 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadEOF(t *testing.T) {
+	shortHeader := []byte{
+		0x81, 0xc9, // missing type & len
+	}
+
+	var c CompoundPacket
+	if err := c.Unmarshal(shortHeader); err != ErrHeaderTooShort {
+		t.Errorf("Unmarshal short header: err = %v, want %v", err, ErrHeaderTooShort)
+	}
+}
+
+func TestCompoundPacketUnmarshal(t *testing.T) {
+	var c CompoundPacket
+	if err := c.Unmarshal(realPacket); err != nil {
+		t.Fatalf("Unmarshal realPacket: %v", err)
+	}
+
+	if got, want := len(c), 3; got != want {
+		t.Fatalf("Unmarshal realPacket: got %d packets, want %d", got, want)
+	}
+
+	if _, ok := c[0].(*ReceiverReport); !ok {
+		t.Errorf("packet 0 is %T, want *ReceiverReport", c[0])
+	}
+	if _, ok := c[1].(*SourceDescription); !ok {
+		t.Errorf("packet 1 is %T, want *SourceDescription", c[1])
+	}
+	if _, ok := c[2].(*Goodbye); !ok {
+		t.Errorf("packet 2 is %T, want *Goodbye", c[2])
+	}
+
+	cname, err := c.CNAME()
+	if err != nil {
+		t.Fatalf("CNAME: %v", err)
+	}
+	if want := "{9c00eb92-1afb-9d49-a47d-91f64eee69f5}"; cname != want {
+		t.Errorf("CNAME = %q, want %q", cname, want)
+	}
+
+	truncated := realPacket[:len(realPacket)-1]
+	if err := c.Unmarshal(truncated); err != ErrPacketTooShort {
+		t.Errorf("Unmarshal truncated: err = %v, want %v", err, ErrPacketTooShort)
+	}
+}
+
+func TestValidPacket(t *testing.T) {
+	cname := &SourceDescription{
+		Chunks: []SourceDescriptionChunk{{
+			Source: 1234,
+			Items: []SourceDescriptionItem{{
+				Type: SDESCNAME,
+				Text: "cname",
+			}},
+		}},
+	}
+
+	for _, test := range []struct {
+		Name   string
+		Packet CompoundPacket
+		Err    error
+	}{
+		{
+			Name:   "empty",
+			Packet: CompoundPacket{},
+			Err:    ErrEmptyCompound,
+		},
+		{
+			Name: "no cname",
+			Packet: CompoundPacket{
+				&SenderReport{},
+			},
+			Err: ErrMissingCNAME,
+		},
+		{
+			Name: "just BYE",
+			Packet: CompoundPacket{
+				&Goodbye{},
+			},
+			Err: ErrBadFirstPacket,
+		},
+		{
+			Name: "SDES / no cname",
+			Packet: CompoundPacket{
+				&SenderReport{},
+				&SourceDescription{},
+			},
+			Err: ErrMissingCNAME,
+		},
+		{
+			Name: "just SR",
+			Packet: CompoundPacket{
+				&SenderReport{},
+				cname,
+			},
+			Err: nil,
+		},
+		{
+			Name: "multiple SRs",
+			Packet: CompoundPacket{
+				&SenderReport{},
+				&SenderReport{},
+				cname,
+			},
+			Err: ErrPacketBeforeCNAME,
+		},
+		{
+			Name: "just RR",
+			Packet: CompoundPacket{
+				&ReceiverReport{},
+				cname,
+			},
+			Err: nil,
+		},
+		{
+			Name: "multiple RRs",
+			Packet: CompoundPacket{
+				&ReceiverReport{},
+				&ReceiverReport{},
+				cname,
+			},
+			Err: nil,
+		},
+		{
+			Name: "goodbye",
+			Packet: CompoundPacket{
+				&ReceiverReport{},
+				cname,
+				&Goodbye{},
+			},
+			Err: nil,
+		},
+	} {
+		if got, want := test.Packet.Validate(), test.Err; got != want {
+			t.Errorf("Valid(%s) = %v, want %v", test.Name, got, want)
+		}
+	}
+}
+
+func TestCompoundPacketRoundTrip(t *testing.T) {
+	cname := &SourceDescription{
+		Chunks: []SourceDescriptionChunk{{
+			Source: 1234,
+			Items: []SourceDescriptionItem{{
+				Type: SDESCNAME,
+				Text: "cname",
+			}},
+		}},
+	}
+
+	for _, test := range []struct {
+		Name   string
+		Packet CompoundPacket
+		Err    error
+	}{
+		{
+			Name: "bye",
+			Packet: CompoundPacket{
+				&ReceiverReport{},
+				cname,
+				&Goodbye{
+					Sources: []uint32{1234},
+				},
+			},
+		},
+		{
+			Name: "app",
+			Packet: CompoundPacket{
+				&SenderReport{SSRC: 1234},
+				cname,
+				&ApplicationDefined{
+					SSRC: 1234,
+					Name: "PION",
+					Data: []byte{0x01, 0x02, 0x03, 0x04},
+				},
+			},
+		},
+		{
+			Name: "no cname",
+			Packet: CompoundPacket{
+				&ReceiverReport{},
+			},
+			Err: ErrMissingCNAME,
+		},
+	} {
+		data, err := test.Packet.Marshal()
+		if got, want := err, test.Err; got != want {
+			t.Fatalf("Marshal(%v) err = %v, want nil", test.Name, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		var c CompoundPacket
+		if err = c.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal(%v) err = %v, want nil", test.Name, err)
+		}
+
+		data2, err := c.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal(%v) err = %v, want nil", test.Name, err)
+		}
+
+		if !reflect.DeepEqual(data, data2) {
+			t.Errorf("Unmarshal(Marshal(%v)) = %v, want %v", test.Name, data2, data)
+		}
+	}
+}
+
+func TestValidReducedSizePacket(t *testing.T) {
+	cname := &SourceDescription{
+		Chunks: []SourceDescriptionChunk{{
+			Source: 1234,
+			Items: []SourceDescriptionItem{{
+				Type: SDESCNAME,
+				Text: "cname",
+			}},
+		}},
+	}
+
+	for _, test := range []struct {
+		Name   string
+		Packet CompoundPacket
+		Err    error
+	}{
+		{
+			Name:   "empty",
+			Packet: CompoundPacket{},
+			Err:    ErrEmptyCompound,
+		},
+		{
+			Name: "just PLI",
+			Packet: CompoundPacket{
+				&PictureLossIndication{MediaSSRC: 1234},
+			},
+			Err: nil,
+		},
+		{
+			Name: "NACK and PLI",
+			Packet: CompoundPacket{
+				&TransportLayerNack{MediaSSRC: 1234, Nacks: []NackPair{{PacketID: 1}}},
+				&PictureLossIndication{MediaSSRC: 1234},
+			},
+			Err: nil,
+		},
+		{
+			Name: "compound",
+			Packet: CompoundPacket{
+				&ReceiverReport{},
+				cname,
+				&PictureLossIndication{MediaSSRC: 1234},
+			},
+			Err: nil,
+		},
+		{
+			Name: "compound / no cname",
+			Packet: CompoundPacket{
+				&ReceiverReport{},
+				&PictureLossIndication{MediaSSRC: 1234},
+			},
+			Err: ErrPacketBeforeCNAME,
+		},
+	} {
+		if got, want := test.Packet.ValidateReducedSize(), test.Err; got != want {
+			t.Errorf("ValidateReducedSize(%s) = %v, want %v", test.Name, got, want)
+		}
+	}
+}
+
+func TestReducedSizePacketRoundTrip(t *testing.T) {
+	packet := CompoundPacket{
+		&TransportLayerNack{SenderSSRC: 1, MediaSSRC: 1234, Nacks: []NackPair{{PacketID: 1}}},
+		&PictureLossIndication{SenderSSRC: 1, MediaSSRC: 1234},
+	}
+
+	if _, err := packet.Marshal(); err != ErrBadFirstPacket {
+		t.Errorf("Marshal reduced-size: err = %v, want %v", err, ErrBadFirstPacket)
+	}
+
+	data, err := packet.MarshalReducedSize()
+	if err != nil {
+		t.Fatalf("MarshalReducedSize: %v", err)
+	}
+
+	var c CompoundPacket
+	if err := c.Unmarshal(data); err != ErrBadFirstPacket {
+		t.Errorf("Unmarshal reduced-size: err = %v, want %v", err, ErrBadFirstPacket)
+	}
+
+	if err := c.UnmarshalReducedSize(data); err != nil {
+		t.Fatalf("UnmarshalReducedSize: %v", err)
+	}
+	if !reflect.DeepEqual(c, packet) {
+		t.Errorf("UnmarshalReducedSize(MarshalReducedSize()) = %#v, want %#v", c, packet)
+	}
+}
@@ -0,0 +1,138 @@
+package rtcp
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Errors returned while encoding and decoding RTCP packets. Malformed
+// input is always reported with one of these, never by panicking.
+var (
+	// ErrInvalidVersion indicates the header carries an RTP version other
+	// than 2.
+	ErrInvalidVersion = errors.New("invalid version")
+
+	// ErrInvalidReportCount indicates a report count does not fit in the 5
+	// bit header field, or is larger than the packet can hold.
+	ErrInvalidReportCount = errors.New("invalid report count")
+
+	// ErrHeaderTooShort indicates there were fewer than 4 octets to decode a
+	// header from.
+	ErrHeaderTooShort = errors.New("rtcp header too short")
+
+	// ErrPacketTooShort indicates a packet, or a block within it, is shorter
+	// than its contents require or its length field declares.
+	ErrPacketTooShort = errors.New("rtcp packet too short")
+
+	// ErrWrongType indicates a packet or block was decoded with the wrong
+	// type or feedback message type.
+	ErrWrongType = errors.New("wrong packet type")
+
+	// ErrInvalidTotalLost indicates a cumulative loss count does not fit in
+	// 24 bits.
+	ErrInvalidTotalLost = errors.New("invalid total lost count")
+
+	// ErrTooManyReports indicates a packet holds more reception reports than
+	// the header can count.
+	ErrTooManyReports = errors.New("too many reports")
+
+	// ErrTooManyChunks indicates a SourceDescription holds more chunks than
+	// the header can count.
+	ErrTooManyChunks = errors.New("too many chunks")
+
+	// ErrSDESTextTooLong indicates a SourceDescription item is longer than
+	// 255 octets.
+	ErrSDESTextTooLong = errors.New("sdes must be < 255 octets long")
+
+	// ErrSDESMissingType indicates a SourceDescription item has no type.
+	ErrSDESMissingType = errors.New("sdes item missing type")
+
+	// ErrTooManySources indicates a Goodbye lists more sources than the
+	// header can count.
+	ErrTooManySources = errors.New("too many sources")
+
+	// ErrReasonTooLong indicates a Goodbye reason is longer than 255 octets.
+	ErrReasonTooLong = errors.New("reason must be < 255 octets long")
+
+	// ErrInvalidSubtype indicates an ApplicationDefined subtype does not fit
+	// in 5 bits.
+	ErrInvalidSubtype = errors.New("invalid subtype")
+
+	// ErrInvalidAppName indicates an ApplicationDefined name is not 4 octets
+	// long.
+	ErrInvalidAppName = errors.New("app name must be 4 octets long")
+
+	// ErrInvalidAppDataLen indicates ApplicationDefined data is not a
+	// multiple of 32 bits.
+	ErrInvalidAppDataLen = errors.New("app data must be a multiple of 32 bits")
+
+	// ErrEmptyCompound indicates a compound packet holds no packets.
+	ErrEmptyCompound = errors.New("empty compound packet")
+
+	// ErrBadFirstPacket indicates a compound packet does not begin with a
+	// SenderReport or ReceiverReport.
+	ErrBadFirstPacket = errors.New("first packet in compound must be SR or RR")
+
+	// ErrMissingCNAME indicates a compound packet has no SourceDescription
+	// with a CNAME.
+	ErrMissingCNAME = errors.New("compound missing SourceDescription with CNAME")
+
+	// ErrPacketBeforeCNAME indicates a compound packet has packets other
+	// than reports before its CNAME.
+	ErrPacketBeforeCNAME = errors.New("feedback packet seen before CNAME")
+
+	// ErrInvalidSLIEntry indicates a SliceLossIndication field is out of
+	// range.
+	ErrInvalidSLIEntry = errors.New("sli entry field out of range")
+
+	// ErrTooManySSRCs indicates a ReceiverEstimatedMaximumBitrate lists more
+	// than 255 SSRCs.
+	ErrTooManySSRCs = errors.New("too many ssrcs")
+
+	// ErrInvalidBitrate indicates a bitrate that overflows 64 bits when
+	// decoded.
+	ErrInvalidBitrate = errors.New("invalid bitrate")
+
+	// ErrSSRCNumAndLengthMismatch indicates the SSRC count of a
+	// ReceiverEstimatedMaximumBitrate disagrees with its length.
+	ErrSSRCNumAndLengthMismatch = errors.New("remb ssrc count does not match packet length")
+
+	// ErrMissingREMBIdentifier indicates an application layer feedback
+	// packet lacks the REMB identifier.
+	ErrMissingREMBIdentifier = errors.New("missing REMB identifier")
+
+	// ErrInvalidPacketStatusChunk indicates a TransportLayerCC packet status
+	// chunk is malformed.
+	ErrInvalidPacketStatusChunk = errors.New("invalid packet status chunk")
+
+	// ErrDeltaExceedLimit indicates a TransportLayerCC receive delta does
+	// not fit in its encoding.
+	ErrDeltaExceedLimit = errors.New("receive delta exceeds limit")
+
+	// ErrInvalidReferenceTime indicates a TransportLayerCC reference time
+	// does not fit in 24 bits.
+	ErrInvalidReferenceTime = errors.New("reference time must fit in 24 bits")
+
+	// ErrInvalidBlockSize indicates an ExtendedReport block has a size that
+	// doesn't match its type.
+	ErrInvalidBlockSize = errors.New("invalid xr block size")
+
+	// ErrInvalidTMMBEntry indicates a TMMBR or TMMBN entry field is out of
+	// range.
+	ErrInvalidTMMBEntry = errors.New("tmmb entry overhead out of range")
+
+	// ErrInvalidProfileExtensions indicates profile-specific extensions are
+	// not a multiple of 32 bits.
+	ErrInvalidProfileExtensions = errors.New("profile extensions must be a multiple of 32 bits")
+
+	// ErrBufferTooShort indicates the buffer passed to MarshalTo can't hold
+	// the packet.
+	ErrBufferTooShort = errors.New("buffer too short")
+
+	// ErrInvalidPadding indicates the padding count of a packet is zero or
+	// larger than the packet.
+	ErrInvalidPadding = errors.New("invalid padding")
+
+	// ErrInvalidBlockSizeMultiple indicates a padding block size is not a
+	// positive multiple of 4.
+	ErrInvalidBlockSizeMultiple = errors.New("padding block size must be a positive multiple of 4")
+)
@@ -0,0 +1,609 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Report block types registered with IANA. See: https://www.iana.org/assignments/rtcp-xr-block-types/rtcp-xr-block-types.xhtml
+const (
+	XRBlockTypeReceiverReferenceTime = 4 // RFC 3611, 4.4
+	XRBlockTypeDLRR                  = 5 // RFC 3611, 4.5
+	XRBlockTypeVoIPMetrics           = 7 // RFC 3611, 4.7
+)
+
+const (
+	xrBlockHeaderLength = 4
+	xrRRTLength         = xrBlockHeaderLength + 8
+	xrDLRRReportLength  = 12
+	xrVoIPMetricsLength = xrBlockHeaderLength + 32
+)
+
+// A ReportBlock is a single block carried by an ExtendedReport. New block
+// types can be sent by implementing this interface; blocks of a type that
+// is not understood are returned as an UnknownReportBlock.
+type ReportBlock interface {
+	// BlockType returns the block type (BT) identifying this block.
+	BlockType() uint8
+
+	// DestinationSSRC returns an array of SSRC values that this block refers to.
+	DestinationSSRC() []uint32
+
+	// Marshal encodes the block in binary, including its block header.
+	Marshal() ([]byte, error)
+
+	// MarshalTo encodes the block like Marshal, but into buf, returning
+	// the number of bytes written.
+	MarshalTo(buf []byte) (int, error)
+
+	// Unmarshal decodes the block from binary, including its block header.
+	Unmarshal(rawBlock []byte) error
+}
+
+// The ExtendedReport (XR) packet conveys information beyond that carried
+// by the SR and RR packets, as a sequence of report blocks.
+// https://tools.ietf.org/html/rfc3611
+type ExtendedReport struct {
+	// SSRC of the originator of this XR packet
+	SenderSSRC uint32
+
+	// Report blocks in the order they appear on the wire
+	Reports []ReportBlock
+}
+
+// Marshal encodes the ExtendedReport in binary
+func (x ExtendedReport) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, x.len())
+	n, err := x.MarshalTo(rawPacket)
+	if err != nil {
+		return nil, err
+	}
+	return rawPacket[:n], nil
+}
+
+// MarshalTo encodes the ExtendedReport in binary into buf, returning the
+// number of bytes written.
+func (x ExtendedReport) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P|reserved |   PT=XR=207   |             length            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                              SSRC                             |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * :                         report blocks                         :
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	if len(buf) < headerLength+ssrcLength {
+		return 0, ErrBufferTooShort
+	}
+
+	// Blocks are written first, as their size is only known once encoded
+	offset := headerLength + ssrcLength
+	for _, report := range x.Reports {
+		n, err := report.MarshalTo(buf[offset:])
+		if err != nil {
+			return 0, err
+		}
+		if n < xrBlockHeaderLength || n%4 != 0 {
+			return 0, ErrInvalidBlockSize
+		}
+		offset += n
+	}
+
+	h := Header{
+		Version: rtpVersion,
+		Type:    TypeExtendedReport,
+		Length:  uint16((offset / 4) - 1),
+	}
+	if _, err := h.MarshalTo(buf); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(buf[headerLength:], x.SenderSSRC)
+
+	return offset, nil
+}
+
+// Unmarshal decodes the ExtendedReport from binary
+func (x *ExtendedReport) Unmarshal(rawPacket []byte) error {
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < (headerLength + ssrcLength) {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypeExtendedReport {
+		return ErrWrongType
+	}
+
+	packetBody := rawPacket[headerLength:]
+
+	x.SenderSSRC = binary.BigEndian.Uint32(packetBody)
+
+	x.Reports = nil
+	for offset := ssrcLength; offset < len(packetBody); {
+		if offset+xrBlockHeaderLength > len(packetBody) {
+			return ErrPacketTooShort
+		}
+
+		blockLength := (int(binary.BigEndian.Uint16(packetBody[offset+2:])) + 1) * 4
+		if offset+blockLength > len(packetBody) {
+			return ErrPacketTooShort
+		}
+
+		var block ReportBlock
+		switch packetBody[offset] {
+		case XRBlockTypeReceiverReferenceTime:
+			block = new(ReceiverReferenceTimeReportBlock)
+		case XRBlockTypeDLRR:
+			block = new(DLRRReportBlock)
+		case XRBlockTypeVoIPMetrics:
+			block = new(VoIPMetricsReportBlock)
+		default:
+			block = new(UnknownReportBlock)
+		}
+
+		if err := block.Unmarshal(packetBody[offset : offset+blockLength]); err != nil {
+			return err
+		}
+		x.Reports = append(x.Reports, block)
+		offset += blockLength
+	}
+
+	return nil
+}
+
+func (x ExtendedReport) len() int {
+	// Report blocks may be of any type, so the only way to know their
+	// size is to marshal them
+	n := headerLength + ssrcLength
+	for _, report := range x.Reports {
+		if data, err := report.Marshal(); err == nil {
+			n += len(data)
+		}
+	}
+	return n
+}
+
+// Header returns the Header associated with this packet.
+func (x ExtendedReport) Header() Header {
+	return Header{
+		Version: rtpVersion,
+		Type:    TypeExtendedReport,
+		Length:  uint16((x.len() / 4) - 1),
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (x ExtendedReport) DestinationSSRC() []uint32 {
+	out := []uint32{}
+	for _, report := range x.Reports {
+		out = append(out, report.DestinationSSRC()...)
+	}
+	return out
+}
+
+// marshalBlockHeader writes the common XR block header into the start of rawBlock
+func marshalBlockHeader(rawBlock []byte, blockType, typeSpecific uint8) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |      BT       | type-specific |         block length          |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	rawBlock[0] = blockType
+	rawBlock[1] = typeSpecific
+	binary.BigEndian.PutUint16(rawBlock[2:], uint16((len(rawBlock)/4)-1))
+}
+
+// UnknownReportBlock is an unparsed XR report block, including its block
+// header. It's returned when a block with an unknown type is encountered.
+type UnknownReportBlock []byte
+
+// BlockType returns the block type (BT) identifying this block.
+func (b UnknownReportBlock) BlockType() uint8 {
+	if len(b) == 0 {
+		return 0
+	}
+	return b[0]
+}
+
+// DestinationSSRC returns an array of SSRC values that this block refers to.
+func (b UnknownReportBlock) DestinationSSRC() []uint32 {
+	return []uint32{}
+}
+
+// Marshal encodes the block in binary.
+func (b UnknownReportBlock) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, len(b))
+	if _, err := b.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the block in binary into buf, returning the number
+// of bytes written.
+func (b UnknownReportBlock) MarshalTo(buf []byte) (int, error) {
+	if len(buf) < len(b) {
+		return 0, ErrBufferTooShort
+	}
+	return copy(buf, b), nil
+}
+
+// Unmarshal decodes the block from binary.
+func (b *UnknownReportBlock) Unmarshal(rawBlock []byte) error {
+	if len(rawBlock) < xrBlockHeaderLength {
+		return ErrPacketTooShort
+	}
+	*b = append(UnknownReportBlock{}, rawBlock...)
+	return nil
+}
+
+// ReceiverReferenceTimeReportBlock carries the receiver's wallclock time,
+// letting a receive-only endpoint take part in round trip time measurement.
+// https://tools.ietf.org/html/rfc3611#section-4.4
+type ReceiverReferenceTimeReportBlock struct {
+	// Wallclock time in NTP timestamp format
+	NTPTimestamp uint64
+}
+
+// BlockType returns the block type (BT) identifying this block.
+func (b ReceiverReferenceTimeReportBlock) BlockType() uint8 {
+	return XRBlockTypeReceiverReferenceTime
+}
+
+// DestinationSSRC returns an array of SSRC values that this block refers to.
+func (b ReceiverReferenceTimeReportBlock) DestinationSSRC() []uint32 {
+	return []uint32{}
+}
+
+// Marshal encodes the ReceiverReferenceTimeReportBlock in binary
+func (b ReceiverReferenceTimeReportBlock) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, xrRRTLength)
+	if _, err := b.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the ReceiverReferenceTimeReportBlock in binary into
+// buf, returning the number of bytes written.
+func (b ReceiverReferenceTimeReportBlock) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |     BT=4      |   reserved    |       block length = 2        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |              NTP timestamp, most significant word             |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |             NTP timestamp, least significant word             |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	if len(buf) < xrRRTLength {
+		return 0, ErrBufferTooShort
+	}
+
+	marshalBlockHeader(buf[:xrRRTLength], XRBlockTypeReceiverReferenceTime, 0)
+	binary.BigEndian.PutUint64(buf[xrBlockHeaderLength:], b.NTPTimestamp)
+	return xrRRTLength, nil
+}
+
+// Unmarshal decodes the ReceiverReferenceTimeReportBlock from binary
+func (b *ReceiverReferenceTimeReportBlock) Unmarshal(rawBlock []byte) error {
+	if len(rawBlock) < xrBlockHeaderLength {
+		return ErrPacketTooShort
+	}
+	if rawBlock[0] != XRBlockTypeReceiverReferenceTime {
+		return ErrWrongType
+	}
+	if len(rawBlock) != xrRRTLength {
+		return ErrInvalidBlockSize
+	}
+
+	b.NTPTimestamp = binary.BigEndian.Uint64(rawBlock[xrBlockHeaderLength:])
+	return nil
+}
+
+// DLRRReport is a single sub-block of a DLRRReportBlock
+type DLRRReport struct {
+	// SSRC of the receiver whose RRT block is being answered
+	SSRC uint32
+
+	// Middle 32 bits of the NTP timestamp of the last RRT block received
+	LastRR uint32
+
+	// Delay since receiving that RRT block, in units of 1/65536 seconds
+	DLRR uint32
+}
+
+// DLRRReportBlock answers ReceiverReferenceTimeReportBlocks so that
+// receivers can calculate their round trip time.
+// https://tools.ietf.org/html/rfc3611#section-4.5
+type DLRRReportBlock struct {
+	Reports []DLRRReport
+}
+
+// BlockType returns the block type (BT) identifying this block.
+func (b DLRRReportBlock) BlockType() uint8 {
+	return XRBlockTypeDLRR
+}
+
+// DestinationSSRC returns an array of SSRC values that this block refers to.
+func (b DLRRReportBlock) DestinationSSRC() []uint32 {
+	out := make([]uint32, len(b.Reports))
+	for i, v := range b.Reports {
+		out[i] = v.SSRC
+	}
+	return out
+}
+
+// Marshal encodes the DLRRReportBlock in binary
+func (b DLRRReportBlock) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, b.len())
+	if _, err := b.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the DLRRReportBlock in binary into buf, returning
+// the number of bytes written.
+func (b DLRRReportBlock) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |     BT=5      |   reserved    |         block length          |
+	 * +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+	 * |                 SSRC_1 (SSRC of first receiver)               | sub-
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+ block
+	 * |                         last RR (LRR)                         |   1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                   delay since last RR (DLRR)                  |
+	 * +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+	 * :                               ...                             :   2
+	 * +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+	 */
+	n := b.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	marshalBlockHeader(buf[:n], XRBlockTypeDLRR, 0)
+
+	for i, r := range b.Reports {
+		offset := xrBlockHeaderLength + i*xrDLRRReportLength
+		binary.BigEndian.PutUint32(buf[offset:], r.SSRC)
+		binary.BigEndian.PutUint32(buf[offset+4:], r.LastRR)
+		binary.BigEndian.PutUint32(buf[offset+8:], r.DLRR)
+	}
+
+	return n, nil
+}
+
+func (b DLRRReportBlock) len() int {
+	return xrBlockHeaderLength + len(b.Reports)*xrDLRRReportLength
+}
+
+// Unmarshal decodes the DLRRReportBlock from binary
+func (b *DLRRReportBlock) Unmarshal(rawBlock []byte) error {
+	if len(rawBlock) < xrBlockHeaderLength {
+		return ErrPacketTooShort
+	}
+	if rawBlock[0] != XRBlockTypeDLRR {
+		return ErrWrongType
+	}
+	if (len(rawBlock)-xrBlockHeaderLength)%xrDLRRReportLength != 0 {
+		return ErrInvalidBlockSize
+	}
+
+	b.Reports = nil
+	for offset := xrBlockHeaderLength; offset < len(rawBlock); offset += xrDLRRReportLength {
+		b.Reports = append(b.Reports, DLRRReport{
+			SSRC:   binary.BigEndian.Uint32(rawBlock[offset:]),
+			LastRR: binary.BigEndian.Uint32(rawBlock[offset+4:]),
+			DLRR:   binary.BigEndian.Uint32(rawBlock[offset+8:]),
+		})
+	}
+
+	return nil
+}
+
+// VoIPMetricsReportBlock reports call quality metrics for a voice stream
+// https://tools.ietf.org/html/rfc3611#section-4.7
+type VoIPMetricsReportBlock struct {
+	// SSRC of the stream these metrics describe
+	SSRC uint32
+
+	// Packet loss and discard rates, as fractions of 256
+	LossRate    uint8
+	DiscardRate uint8
+
+	// Loss and discard density of bursts and gaps, as fractions of 256
+	BurstDensity uint8
+	GapDensity   uint8
+
+	// Mean duration of bursts and gaps, in milliseconds
+	BurstDuration uint16
+	GapDuration   uint16
+
+	// Round trip and end system delay, in milliseconds
+	RoundTripDelay uint16
+	EndSystemDelay uint16
+
+	// Signal and noise levels in dBm, and residual echo return loss in dB
+	SignalLevel int8
+	NoiseLevel  int8
+	RERL        uint8
+
+	// Gap threshold, in number of received packets
+	Gmin uint8
+
+	// Call quality scores
+	RFactor    uint8
+	ExtRFactor uint8
+	MOSLQ      uint8
+	MOSCQ      uint8
+
+	// Receiver configuration byte, describing PLC and jitter buffer type
+	RXConfig uint8
+
+	// Jitter buffer delays, in milliseconds
+	JBNominal uint16
+	JBMaximum uint16
+	JBAbsMax  uint16
+}
+
+// BlockType returns the block type (BT) identifying this block.
+func (b VoIPMetricsReportBlock) BlockType() uint8 {
+	return XRBlockTypeVoIPMetrics
+}
+
+// DestinationSSRC returns an array of SSRC values that this block refers to.
+func (b VoIPMetricsReportBlock) DestinationSSRC() []uint32 {
+	return []uint32{b.SSRC}
+}
+
+// Marshal encodes the VoIPMetricsReportBlock in binary
+func (b VoIPMetricsReportBlock) Marshal() ([]byte, error) {
+	rawBlock := make([]byte, xrVoIPMetricsLength)
+	if _, err := b.MarshalTo(rawBlock); err != nil {
+		return nil, err
+	}
+	return rawBlock, nil
+}
+
+// MarshalTo encodes the VoIPMetricsReportBlock in binary into buf,
+// returning the number of bytes written.
+func (b VoIPMetricsReportBlock) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |     BT=7      |   reserved    |       block length = 8        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                        SSRC of source                         |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |   loss rate   | discard rate  | burst density |  gap density  |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |       burst duration          |         gap duration          |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |     round trip delay          |       end system delay        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * | signal level  |  noise level  |     RERL      |     Gmin      |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |   R factor    | ext. R factor |    MOS-LQ     |    MOS-CQ     |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |   RX config   |   reserved    |          JB nominal           |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |          JB maximum           |          JB abs max           |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	if len(buf) < xrVoIPMetricsLength {
+		return 0, ErrBufferTooShort
+	}
+
+	marshalBlockHeader(buf[:xrVoIPMetricsLength], XRBlockTypeVoIPMetrics, 0)
+
+	body := buf[xrBlockHeaderLength:xrVoIPMetricsLength]
+	binary.BigEndian.PutUint32(body, b.SSRC)
+	body[4] = b.LossRate
+	body[5] = b.DiscardRate
+	body[6] = b.BurstDensity
+	body[7] = b.GapDensity
+	binary.BigEndian.PutUint16(body[8:], b.BurstDuration)
+	binary.BigEndian.PutUint16(body[10:], b.GapDuration)
+	binary.BigEndian.PutUint16(body[12:], b.RoundTripDelay)
+	binary.BigEndian.PutUint16(body[14:], b.EndSystemDelay)
+	body[16] = uint8(b.SignalLevel)
+	body[17] = uint8(b.NoiseLevel)
+	body[18] = b.RERL
+	body[19] = b.Gmin
+	body[20] = b.RFactor
+	body[21] = b.ExtRFactor
+	body[22] = b.MOSLQ
+	body[23] = b.MOSCQ
+	body[24] = b.RXConfig
+	body[25] = 0
+	binary.BigEndian.PutUint16(body[26:], b.JBNominal)
+	binary.BigEndian.PutUint16(body[28:], b.JBMaximum)
+	binary.BigEndian.PutUint16(body[30:], b.JBAbsMax)
+
+	return xrVoIPMetricsLength, nil
+}
+
+// Unmarshal decodes the VoIPMetricsReportBlock from binary
+func (b *VoIPMetricsReportBlock) Unmarshal(rawBlock []byte) error {
+	if len(rawBlock) < xrBlockHeaderLength {
+		return ErrPacketTooShort
+	}
+	if rawBlock[0] != XRBlockTypeVoIPMetrics {
+		return ErrWrongType
+	}
+	if len(rawBlock) != xrVoIPMetricsLength {
+		return ErrInvalidBlockSize
+	}
+
+	body := rawBlock[xrBlockHeaderLength:]
+	b.SSRC = binary.BigEndian.Uint32(body)
+	b.LossRate = body[4]
+	b.DiscardRate = body[5]
+	b.BurstDensity = body[6]
+	b.GapDensity = body[7]
+	b.BurstDuration = binary.BigEndian.Uint16(body[8:])
+	b.GapDuration = binary.BigEndian.Uint16(body[10:])
+	b.RoundTripDelay = binary.BigEndian.Uint16(body[12:])
+	b.EndSystemDelay = binary.BigEndian.Uint16(body[14:])
+	b.SignalLevel = int8(body[16])
+	b.NoiseLevel = int8(body[17])
+	b.RERL = body[18]
+	b.Gmin = body[19]
+	b.RFactor = body[20]
+	b.ExtRFactor = body[21]
+	b.MOSLQ = body[22]
+	b.MOSCQ = body[23]
+	b.RXConfig = body[24]
+	b.JBNominal = binary.BigEndian.Uint16(body[26:])
+	b.JBMaximum = binary.BigEndian.Uint16(body[28:])
+	b.JBAbsMax = binary.BigEndian.Uint16(body[30:])
+
+	return nil
+}
+
+func (x ExtendedReport) String() string {
+	out := fmt.Sprintf("ExtendedReport from %x\n", x.SenderSSRC)
+	for _, r := range x.Reports {
+		out += fmt.Sprintf("\t%v\n", r)
+	}
+	return out
+}
+
+func (b ReceiverReferenceTimeReportBlock) String() string {
+	return fmt.Sprintf("ReceiverReferenceTimeReportBlock: NTPTimestamp %d", b.NTPTimestamp)
+}
+
+func (b DLRRReportBlock) String() string {
+	out := "DLRRReportBlock:"
+	for _, r := range b.Reports {
+		out += fmt.Sprintf(" {SSRC: %x, LastRR: %x, DLRR: %d}", r.SSRC, r.LastRR, r.DLRR)
+	}
+	return out
+}
+
+func (b VoIPMetricsReportBlock) String() string {
+	return fmt.Sprintf("VoIPMetricsReportBlock for %x: loss %d/256, discard %d/256, RTT %dms, R %d, MOS-LQ %d, MOS-CQ %d",
+		b.SSRC, b.LossRate, b.DiscardRate, b.RoundTripDelay, b.RFactor, b.MOSLQ, b.MOSCQ)
+}
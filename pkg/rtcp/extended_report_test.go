@@ -0,0 +1,186 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtendedReportUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      ExtendedReport
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Data: []byte{
+				// v=2, p=0, XR, len=9
+				0x80, 0xcf, 0x00, 0x09,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// BT=4, reserved, block length=2
+				0x04, 0x00, 0x00, 0x02,
+				// ntp=0xda8bd1fcdddda05a
+				0xda, 0x8b, 0xd1, 0xfc,
+				0xdd, 0xdd, 0xa0, 0x5a,
+				// BT=5, reserved, block length=3
+				0x05, 0x00, 0x00, 0x03,
+				// ssrc=0xbc5e9a40
+				0xbc, 0x5e, 0x9a, 0x40,
+				// lrr=0xd1fcdddd
+				0xd1, 0xfc, 0xdd, 0xdd,
+				// dlrr=0x10000
+				0x00, 0x01, 0x00, 0x00,
+				// BT=42, type specific=0x1, block length=0
+				0x2a, 0x01, 0x00, 0x00,
+			},
+			Want: ExtendedReport{
+				SenderSSRC: 0x902f9e2e,
+				Reports: []ReportBlock{
+					&ReceiverReferenceTimeReportBlock{
+						NTPTimestamp: 0xda8bd1fcdddda05a,
+					},
+					&DLRRReportBlock{
+						Reports: []DLRRReport{{
+							SSRC:   0xbc5e9a40,
+							LastRR: 0xd1fcdddd,
+							DLRR:   0x10000,
+						}},
+					},
+					&UnknownReportBlock{0x2a, 0x01, 0x00, 0x00},
+				},
+			},
+		},
+		{
+			Name: "no blocks",
+			Data: []byte{
+				// v=2, p=0, XR, len=1
+				0x80, 0xcf, 0x00, 0x01,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			Want: ExtendedReport{
+				SenderSSRC: 0x902f9e2e,
+			},
+		},
+		{
+			Name: "short block",
+			Data: []byte{
+				// v=2, p=0, XR, len=3
+				0x80, 0xcf, 0x00, 0x03,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// BT=4, reserved, block length=2
+				0x04, 0x00, 0x00, 0x02,
+				// ntp, truncated
+				0xda, 0x8b, 0xd1, 0xfc,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "wrong block size",
+			Data: []byte{
+				// v=2, p=0, XR, len=3
+				0x80, 0xcf, 0x00, 0x03,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// BT=4, reserved, block length=1
+				0x04, 0x00, 0x00, 0x01,
+				0xda, 0x8b, 0xd1, 0xfc,
+			},
+			WantError: ErrInvalidBlockSize,
+		},
+		{
+			Name: "wrong type",
+			Data: []byte{
+				// v=2, p=0, RR, len=1
+				0x80, 0xc9, 0x00, 0x01,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			WantError: ErrWrongType,
+		},
+	} {
+		var xr ExtendedReport
+		err := xr.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q xr: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := xr, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q xr: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestExtendedReportRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Report ExtendedReport
+	}{
+		{
+			Name: "valid",
+			Report: ExtendedReport{
+				SenderSSRC: 0x902f9e2e,
+				Reports: []ReportBlock{
+					&ReceiverReferenceTimeReportBlock{
+						NTPTimestamp: 0xda8bd1fcdddda05a,
+					},
+					&DLRRReportBlock{
+						Reports: []DLRRReport{
+							{SSRC: 1, LastRR: 2, DLRR: 3},
+							{SSRC: 4, LastRR: 5, DLRR: 6},
+						},
+					},
+					&VoIPMetricsReportBlock{
+						SSRC:           0xbc5e9a40,
+						LossRate:       1,
+						DiscardRate:    2,
+						BurstDensity:   3,
+						GapDensity:     4,
+						BurstDuration:  500,
+						GapDuration:    600,
+						RoundTripDelay: 70,
+						EndSystemDelay: 80,
+						SignalLevel:    -15,
+						NoiseLevel:     -60,
+						RERL:           9,
+						Gmin:           16,
+						RFactor:        93,
+						ExtRFactor:     127,
+						MOSLQ:          42,
+						MOSCQ:          41,
+						RXConfig:       0x80,
+						JBNominal:      40,
+						JBMaximum:      80,
+						JBAbsMax:       120,
+					},
+				},
+			},
+		},
+		{
+			Name: "empty",
+			Report: ExtendedReport{
+				SenderSSRC: 0x902f9e2e,
+			},
+		},
+	} {
+		data, err := test.Report.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal %q: %v", test.Name, err)
+		}
+
+		var decoded ExtendedReport
+		if err := decoded.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+
+		if got, want := decoded, test.Report; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q xr round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
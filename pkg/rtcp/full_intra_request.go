@@ -0,0 +1,161 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// A FIREntry is a (SSRC, seqno) pair, as carried by FullIntraRequest.
+type FIREntry struct {
+	// The SSRC value of the media sender that is requested to send a
+	// decoder refresh point.
+	SSRC uint32
+
+	// Command sequence number. The sequence number space is unique for
+	// each pairing of the SSRC of command source and the SSRC of the
+	// command target. The sequence number SHALL be increased by 1 modulo
+	// 256 for each new command. A repetition SHALL NOT increase the
+	// sequence number.
+	SequenceNumber uint8
+}
+
+// The FullIntraRequest packet is used to reliably request an Intra frame
+// in a video stream. See RFC 5104 Section 3.5.1. This is not for loss
+// recovery, which should use PictureLossIndication (PLI) instead.
+type FullIntraRequest struct {
+	// SSRC of sender
+	SenderSSRC uint32
+
+	// SSRC of the media source, unused for FIR and SHALL be set to 0
+	MediaSSRC uint32
+
+	FIR []FIREntry
+}
+
+const (
+	firOffset      = 8
+	firEntryLength = 8
+)
+
+// Marshal encodes the FullIntraRequest in binary
+func (p FullIntraRequest) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, p.len())
+	if _, err := p.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the FullIntraRequest in binary into buf, returning
+// the number of bytes written.
+func (p FullIntraRequest) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P| FMT=4   |   PT=206      |          length               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                  SSRC of packet sender                        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |             SSRC of media source (unused) = 0                 |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                              SSRC                             |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * | Seq nr.       |    Reserved = 0                               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * :                              ...                              :
+	 */
+
+	n := p.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := p.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody, p.SenderSSRC)
+	binary.BigEndian.PutUint32(packetBody[4:], p.MediaSSRC)
+	for i, fir := range p.FIR {
+		entry := packetBody[firOffset+firEntryLength*i:]
+		binary.BigEndian.PutUint32(entry, fir.SSRC)
+		entry[4] = fir.SequenceNumber
+
+		// reserved
+		entry[5], entry[6], entry[7] = 0, 0, 0
+	}
+
+	return n, nil
+}
+
+// Unmarshal decodes the FullIntraRequest from binary
+func (p *FullIntraRequest) Unmarshal(rawPacket []byte) error {
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < (headerLength + ssrcLength*2) {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypePayloadSpecificFeedback || h.ReportCount != FormatFIR {
+		return ErrWrongType
+	}
+
+	// The FCI must be a whole number of FIR entries
+	if (len(rawPacket)-headerLength-firOffset)%firEntryLength != 0 {
+		return ErrPacketTooShort
+	}
+
+	p.SenderSSRC = binary.BigEndian.Uint32(rawPacket[headerLength:])
+	p.MediaSSRC = binary.BigEndian.Uint32(rawPacket[headerLength+ssrcLength:])
+
+	p.FIR = nil
+	for i := headerLength + firOffset; i < len(rawPacket); i += firEntryLength {
+		p.FIR = append(p.FIR, FIREntry{
+			SSRC:           binary.BigEndian.Uint32(rawPacket[i:]),
+			SequenceNumber: rawPacket[i+4],
+		})
+	}
+
+	return nil
+}
+
+func (p FullIntraRequest) len() int {
+	return headerLength + firOffset + firEntryLength*len(p.FIR)
+}
+
+// Header returns the Header associated with this packet.
+func (p FullIntraRequest) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: FormatFIR,
+		Type:        TypePayloadSpecificFeedback,
+		Length:      uint16((p.len() / 4) - 1),
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (p FullIntraRequest) DestinationSSRC() []uint32 {
+	out := make([]uint32, len(p.FIR))
+	for i, v := range p.FIR {
+		out[i] = v.SSRC
+	}
+	return out
+}
+
+func (p FullIntraRequest) String() string {
+	out := fmt.Sprintf("FullIntraRequest from %x for %x\n", p.SenderSSRC, p.MediaSSRC)
+	for _, e := range p.FIR {
+		out += fmt.Sprintf("\tSSRC: %x, SequenceNumber: %d\n", e.SSRC, e.SequenceNumber)
+	}
+	return out
+}
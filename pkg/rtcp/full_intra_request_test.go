@@ -0,0 +1,166 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFullIntraRequestUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      FullIntraRequest
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Data: []byte{
+				// v=2, p=0, FMT=4, PSFB, len=4
+				0x84, 0xce, 0x00, 0x04,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// ssrc=0x12345678
+				0x12, 0x34, 0x56, 0x78,
+				// Seqno=0x42
+				0x42, 0x00, 0x00, 0x00,
+			},
+			Want: FullIntraRequest{
+				SenderSSRC: 0x0,
+				MediaSSRC:  0x4bc4fcb4,
+				FIR: []FIREntry{{
+					SSRC:           0x12345678,
+					SequenceNumber: 0x42,
+				}},
+			},
+		},
+		{
+			Name: "also valid",
+			Data: []byte{
+				// v=2, p=0, FMT=4, PSFB, len=6
+				0x84, 0xce, 0x00, 0x06,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// ssrc=0x12345678
+				0x12, 0x34, 0x56, 0x78,
+				// Seqno=0x42
+				0x42, 0x00, 0x00, 0x00,
+				// ssrc=0x98765432
+				0x98, 0x76, 0x54, 0x32,
+				// Seqno=0x57
+				0x57, 0x00, 0x00, 0x00,
+			},
+			Want: FullIntraRequest{
+				SenderSSRC: 0x0,
+				MediaSSRC:  0x4bc4fcb4,
+				FIR: []FIREntry{
+					{
+						SSRC:           0x12345678,
+						SequenceNumber: 0x42,
+					},
+					{
+						SSRC:           0x98765432,
+						SequenceNumber: 0x57,
+					},
+				},
+			},
+		},
+		{
+			Name: "packet too short",
+			Data: []byte{
+				0x00, 0x00, 0x00, 0x00,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "wrong type",
+			Data: []byte{
+				// v=2, p=0, FMT=4, RR, len=3
+				0x84, 0xc9, 0x00, 0x03,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// ssrc=0x12345678
+				0x12, 0x34, 0x56, 0x78,
+				// Seqno=0x42
+				0x42, 0x00, 0x00, 0x00,
+			},
+			WantError: ErrWrongType,
+		},
+		{
+			Name: "truncated entry",
+			Data: []byte{
+				// v=2, p=0, FMT=4, PSFB, len=3
+				0x84, 0xce, 0x00, 0x03,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// ssrc=0x12345678
+				0x12, 0x34, 0x56, 0x78,
+			},
+			WantError: ErrPacketTooShort,
+		},
+	} {
+		var fir FullIntraRequest
+		err := fir.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q fir: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := fir, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q fir: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestFullIntraRequestRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Packet FullIntraRequest
+	}{
+		{
+			Name: "valid",
+			Packet: FullIntraRequest{
+				SenderSSRC: 1,
+				MediaSSRC:  2,
+				FIR: []FIREntry{{
+					SSRC:           3,
+					SequenceNumber: 42,
+				}},
+			},
+		},
+		{
+			Name: "also valid",
+			Packet: FullIntraRequest{
+				SenderSSRC: 5000,
+				MediaSSRC:  6000,
+				FIR: []FIREntry{{
+					SSRC:           3,
+					SequenceNumber: 57,
+				}},
+			},
+		},
+	} {
+		data, err := test.Packet.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal %q: %v", test.Name, err)
+		}
+
+		var decoded FullIntraRequest
+		if err := decoded.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+
+		if got, want := decoded, test.Packet; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q fir round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+//go:build gofuzz
+// +build gofuzz
+
+package rtcp
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Fuzz is the entry point for go-fuzz. Decoding arbitrary input must fail
+// with an error rather than panic, and whatever decodes successfully must
+// encode to a stable form.
+func Fuzz(data []byte) int {
+	var c CompoundPacket
+	compoundErr := c.UnmarshalReducedSize(data)
+
+	p, err := Unmarshal(data)
+	if err != nil {
+		if compoundErr == nil {
+			return 1
+		}
+		return 0
+	}
+
+	_ = fmt.Sprint(p)
+	_ = p.DestinationSSRC()
+
+	rawPacket, err := p.Marshal()
+	if err != nil {
+		return 0
+	}
+
+	decoded, err := Unmarshal(rawPacket)
+	if err != nil {
+		panic(err) // nolint
+	}
+
+	remarshaled, err := decoded.Marshal()
+	if err != nil {
+		panic(err) // nolint
+	}
+	if !bytes.Equal(rawPacket, remarshaled) {
+		panic("unstable round trip") // nolint
+	}
+
+	return 1
+}
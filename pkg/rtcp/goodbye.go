@@ -0,0 +1,174 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The Goodbye packet indicates that one or more sources are no longer active.
+type Goodbye struct {
+	// The SSRC/CSRC identifiers that are no longer active
+	Sources []uint32
+	// Optional text indicating the reason for leaving, e.g., "camera malfunction" or "RTP loop detected"
+	Reason string
+}
+
+// Marshal encodes the Goodbye packet in binary
+func (g Goodbye) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, g.len())
+	if _, err := g.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the Goodbye in binary into buf, returning the number
+// of bytes written.
+func (g Goodbye) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *        0                   1                   2                   3
+	 *        0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 *       +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *       |V=2|P|    SC   |   PT=BYE=203  |             length            |
+	 *       +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *       |                           SSRC/CSRC                           |
+	 *       +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *       :                              ...                              :
+	 *       +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+	 * (opt) |     length    |               reason for leaving            ...
+	 *       +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	if len(g.Sources) > countMax {
+		return 0, ErrTooManySources
+	}
+
+	if len(g.Reason) > sdesMaxOctetCount {
+		return 0, ErrReasonTooLong
+	}
+
+	n := g.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := g.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	for i, s := range g.Sources {
+		binary.BigEndian.PutUint32(packetBody[i*ssrcLength:], s)
+	}
+
+	if g.Reason != "" {
+		offset := len(g.Sources) * ssrcLength
+		packetBody[offset] = uint8(len(g.Reason))
+		offset++
+		offset += copy(packetBody[offset:], g.Reason)
+
+		// pad to the next 32-bit boundary with null octets
+		for ; offset < len(packetBody); offset++ {
+			packetBody[offset] = 0
+		}
+	}
+
+	return n, nil
+}
+
+// Unmarshal decodes the Goodbye packet from binary
+func (g *Goodbye) Unmarshal(rawPacket []byte) error {
+	/*
+	 *        0                   1                   2                   3
+	 *        0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 *       +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *       |V=2|P|    SC   |   PT=BYE=203  |             length            |
+	 *       +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *       |                           SSRC/CSRC                           |
+	 *       +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *       :                              ...                              :
+	 *       +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+	 * (opt) |     length    |               reason for leaving            ...
+	 *       +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypeGoodbye {
+		return ErrWrongType
+	}
+
+	reasonOffset := headerLength + int(h.ReportCount)*ssrcLength
+	if reasonOffset > len(rawPacket) {
+		return ErrPacketTooShort
+	}
+
+	g.Sources = make([]uint32, h.ReportCount)
+	for i := range g.Sources {
+		offset := headerLength + i*ssrcLength
+		g.Sources[i] = binary.BigEndian.Uint32(rawPacket[offset:])
+	}
+
+	g.Reason = ""
+	if reasonOffset < len(rawPacket) {
+		reasonLen := int(rawPacket[reasonOffset])
+		reasonEnd := reasonOffset + 1 + reasonLen
+
+		if reasonEnd > len(rawPacket) {
+			return ErrPacketTooShort
+		}
+
+		g.Reason = string(rawPacket[reasonOffset+1 : reasonEnd])
+	}
+
+	return nil
+}
+
+func (g Goodbye) len() int {
+	srcsLength := len(g.Sources) * ssrcLength
+	reasonLength := 0
+	if g.Reason != "" {
+		reasonLength = 1 + len(g.Reason)
+	}
+
+	l := headerLength + srcsLength + reasonLength
+
+	// align to 32-bit boundary
+	return l + getPadding(l)
+}
+
+// Header returns the Header associated with this packet.
+func (g Goodbye) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: uint8(len(g.Sources)),
+		Type:        TypeGoodbye,
+		Length:      uint16((g.len() / 4) - 1),
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (g Goodbye) DestinationSSRC() []uint32 {
+	out := make([]uint32, len(g.Sources))
+	copy(out, g.Sources)
+	return out
+}
+
+func (g Goodbye) String() string {
+	out := "Goodbye\n"
+	for _, s := range g.Sources {
+		out += fmt.Sprintf("\tSource: %x\n", s)
+	}
+	if g.Reason != "" {
+		out += fmt.Sprintf("\tReason: %q\n", g.Reason)
+	}
+	return out
+}
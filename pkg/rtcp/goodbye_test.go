@@ -0,0 +1,176 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGoodbyeUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      Goodbye
+		WantError error
+	}{
+		{
+			Name:      "nil",
+			Data:      nil,
+			WantError: ErrHeaderTooShort,
+		},
+		{
+			Name: "real packet",
+			Data: realPacket[84:],
+			Want: Goodbye{
+				Sources: []uint32{0x902f9e2e},
+			},
+		},
+		{
+			Name: "with reason",
+			Data: []byte{
+				// v=2, p=0, count=1, BYE, len=2
+				0x81, 0xcb, 0x00, 0x02,
+				// source=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// len=3, text=FOO
+				0x03, 0x46, 0x4f, 0x4f,
+			},
+			Want: Goodbye{
+				Sources: []uint32{0x902f9e2e},
+				Reason:  "FOO",
+			},
+		},
+		{
+			Name: "no sources",
+			Data: []byte{
+				// v=2, p=0, count=0, BYE, len=0
+				0x80, 0xcb, 0x00, 0x00,
+			},
+			Want: Goodbye{
+				Sources: []uint32{},
+			},
+		},
+		{
+			Name: "wrong type",
+			Data: []byte{
+				// v=2, p=0, count=1, SDES, len=1
+				0x81, 0xca, 0x00, 0x01,
+				// source=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			WantError: ErrWrongType,
+		},
+		{
+			Name: "too many sources",
+			Data: []byte{
+				// v=2, p=0, count=2, BYE, len=1
+				0x82, 0xcb, 0x00, 0x01,
+				// source=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "reason overflows packet",
+			Data: []byte{
+				// v=2, p=0, count=1, BYE, len=2
+				0x81, 0xcb, 0x00, 0x02,
+				// source=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// len=4, text=FOO
+				0x04, 0x46, 0x4f, 0x4f,
+			},
+			WantError: ErrPacketTooShort,
+		},
+	} {
+		var bye Goodbye
+		err := bye.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q bye: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := bye, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q bye: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestGoodbyeRoundTrip(t *testing.T) {
+	tooManySources := make([]uint32, countMax+1)
+
+	tooLongText := make([]byte, 256)
+	for i := range tooLongText {
+		tooLongText[i] = 'x'
+	}
+
+	for _, test := range []struct {
+		Name      string
+		Bye       Goodbye
+		WantError error
+	}{
+		{
+			Name: "empty",
+			Bye: Goodbye{
+				Sources: []uint32{},
+			},
+		},
+		{
+			Name: "valid",
+			Bye: Goodbye{
+				Sources: []uint32{
+					0x01020304,
+					0x05060708,
+				},
+				Reason: "because",
+			},
+		},
+		{
+			Name: "empty reason",
+			Bye: Goodbye{
+				Sources: []uint32{0x01020304},
+				Reason:  "",
+			},
+		},
+		{
+			Name: "reason no source",
+			Bye: Goodbye{
+				Sources: []uint32{},
+				Reason:  "foo",
+			},
+		},
+		{
+			Name: "count overflow",
+			Bye: Goodbye{
+				Sources: tooManySources,
+			},
+			WantError: ErrTooManySources,
+		},
+		{
+			Name: "reason too long",
+			Bye: Goodbye{
+				Sources: []uint32{},
+				Reason:  string(tooLongText),
+			},
+			WantError: ErrReasonTooLong,
+		},
+	} {
+		data, err := test.Bye.Marshal()
+		if got, want := err, test.WantError; got != want {
+			t.Fatalf("Marshal %q: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		var decoded Goodbye
+		if err := decoded.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+
+		if got, want := decoded, test.Bye; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q bye round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// RTCP packet types registered with IANA. See: https://www.iana.org/assignments/rtp-parameters/rtp-parameters.xhtml#rtp-parameters-4
+const (
+	TypeSenderReport              = 200 // RFC 3550, 6.4.1
+	TypeReceiverReport            = 201 // RFC 3550, 6.4.2
+	TypeSourceDescription         = 202 // RFC 3550, 6.5
+	TypeGoodbye                   = 203 // RFC 3550, 6.6
+	TypeApplicationDefined        = 204 // RFC 3550, 6.7
+	TypeTransportSpecificFeedback = 205 // RFC 4585, 6.2
+	TypePayloadSpecificFeedback   = 206 // RFC 4585, 6.3
+	TypeExtendedReport            = 207 // RFC 3611
+)
+
+// Feedback message types (FMT) carried in the count field of feedback packets.
+// See: https://www.iana.org/assignments/rtp-parameters/rtp-parameters.xhtml#rtp-parameters-8
+const (
+	FormatTLN   = 1 // Generic NACK, RFC 4585, 6.2.1
+	FormatTMMBR = 3 // Temporary Maximum Media Stream Bit Rate Request, RFC 5104, 4.2.1
+	FormatTMMBN = 4 // Temporary Maximum Media Stream Bit Rate Notification, RFC 5104, 4.2.2
+	FormatRRR   = 5 // Rapid Resynchronisation Request, RFC 6051, 3
+	FormatPLI   = 1 // Picture Loss Indication, RFC 4585, 6.3.1
+	FormatSLI   = 2 // Slice Loss Indication, RFC 4585, 6.3.2
+	FormatFIR   = 4 // Full Intra Request, RFC 5104, 4.3.1
+
+	// FormatTCC is the transport-wide congestion control feedback message
+	// type, draft-holmer-rmcat-transport-wide-cc-extensions-01
+	FormatTCC = 15
+
+	// FormatREMB is the Application Layer Feedback message type used by
+	// REMB, draft-alvestrand-rmcat-remb-03
+	FormatREMB = 15
+)
+
+// A Header is the common header shared by all RTCP packets
+type Header struct {
+	// Identifies the version of RTP, which is the same in RTCP packets
+	// as in RTP data packets.
+	Version uint8
+	// If the padding bit is set, this individual RTCP packet contains
+	// some additional padding octets at the end which are not part of
+	// the control information but are included in the length field.
+	Padding bool
+	// The number of reception report blocks contained in this packet.
+	ReportCount uint8
+	// The RTCP packet type for this packet
+	Type uint8
+	// The length of this RTCP packet in 32-bit words minus one,
+	// including the header and any padding.
+	Length uint16
+}
+
+const (
+	headerLength     = 4
+	versionShift     = 6
+	versionMask      = 0x3
+	paddingShift     = 5
+	paddingMask      = 0x1
+	reportCountShift = 0
+	reportCountMask  = 0x1f
+	countMax         = (1 << 5) - 1
+
+	rtpVersion = 2
+	ssrcLength = 4
+)
+
+// Marshal encodes the Header in binary
+func (h Header) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, headerLength)
+	if _, err := h.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the Header in binary into buf, returning the number
+// of bytes written.
+func (h Header) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P|    RC   |   PT=SR=200   |             length            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	if len(buf) < headerLength {
+		return 0, ErrBufferTooShort
+	}
+
+	if h.Version > 3 {
+		return 0, ErrInvalidVersion
+	}
+	buf[0] = h.Version << versionShift
+
+	if h.Padding {
+		buf[0] |= 1 << paddingShift
+	}
+
+	if h.ReportCount > countMax {
+		return 0, ErrInvalidReportCount
+	}
+	buf[0] |= h.ReportCount << reportCountShift
+
+	buf[1] = h.Type
+
+	binary.BigEndian.PutUint16(buf[2:], h.Length)
+
+	return headerLength, nil
+}
+
+// Unmarshal decodes the Header from binary
+func (h *Header) Unmarshal(rawPacket []byte) error {
+	if len(rawPacket) < headerLength {
+		return ErrHeaderTooShort
+	}
+
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P|    RC   |   PT=SR=200   |             length            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	h.Version = rawPacket[0] >> versionShift & versionMask
+	h.Padding = (rawPacket[0] >> paddingShift & paddingMask) > 0
+	h.ReportCount = rawPacket[0] >> reportCountShift & reportCountMask
+
+	h.Type = rawPacket[1]
+
+	h.Length = binary.BigEndian.Uint16(rawPacket[2:])
+
+	return nil
+}
+
+func (h Header) String() string {
+	return fmt.Sprintf("Header %s (v=%d, p=%t, count=%d, length=%d)",
+		typeString(h.Type), h.Version, h.Padding, h.ReportCount, h.Length)
+}
+
+func typeString(t uint8) string {
+	switch t {
+	case TypeSenderReport:
+		return "SR"
+	case TypeReceiverReport:
+		return "RR"
+	case TypeSourceDescription:
+		return "SDES"
+	case TypeGoodbye:
+		return "BYE"
+	case TypeApplicationDefined:
+		return "APP"
+	case TypeTransportSpecificFeedback:
+		return "RTPFB"
+	case TypePayloadSpecificFeedback:
+		return "PSFB"
+	case TypeExtendedReport:
+		return "XR"
+	default:
+		return fmt.Sprintf("type %d", t)
+	}
+}
@@ -1,107 +1,178 @@
 package rtcp
 
-import (
-	"encoding/binary"
-
-	"github.com/pkg/errors"
-)
-
-// RTCP packet types registered with IANA. See: https://www.iana.org/assignments/rtp-parameters/rtp-parameters.xhtml#rtp-parameters-4
-const (
-	TypeSenderReport       = 200 // RFC 3550, 6.4.1
-	TypeReceiverReport     = 201 // RFC 3550, 6.4.2
-	TypeSourceDescription  = 202 // RFC 3550, 6.5
-	TypeGoodbye            = 203 // RFC 3550, 6.6
-	TypeApplicationDefined = 204 // RFC 3550, 6.7
-)
-
-// A Header is the common header shared by all RTCP packets
-type Header struct {
-	// Identifies the version of RTP, which is the same in RTCP packets
-	// as in RTP data packets.
-	Version uint8
-	// If the padding bit is set, this individual RTCP packet contains
-	// some additional padding octets at the end which are not part of
-	// the control information but are included in the length field.
-	Padding bool
-	// The number of reception report blocks contained in this packet.
-	ReportCount uint8
-	// The RTCP packet type for this packet
-	Type uint8
-	// The length of this RTCP packet in 32-bit words minus one,
-	// including the header and any padding.
-	Length uint16
+// Packet represents an RTCP packet, a protocol used for out-of-band statistics and control information for an RTP session
+type Packet interface {
+	// Header returns the Header associated with this packet. The Length
+	// is always computed from the packet contents, so it never needs to
+	// be set by hand.
+	Header() Header
+
+	// DestinationSSRC returns an array of SSRC values that this packet refers to.
+	DestinationSSRC() []uint32
+
+	// Marshal encodes the packet, including a Header as returned by Header().
+	Marshal() ([]byte, error)
+
+	// MarshalTo encodes the packet like Marshal, but into buf, returning
+	// the number of bytes written. It allows a caller to reuse buffers.
+	MarshalTo(buf []byte) (int, error)
+
+	// Unmarshal decodes the packet. Any data beyond the length declared
+	// in the header is ignored, and ErrPacketTooShort is returned if
+	// rawPacket is shorter than declared. Padding, if the header says
+	// there is any, is validated and removed.
+	Unmarshal(rawPacket []byte) error
 }
 
-const (
-	headerLength     = 4
-	versionShift     = 6
-	versionMask      = 0x3
-	paddingShift     = 5
-	paddingMask      = 0x1
-	reportCountShift = 0
-	reportCountMask  = 0x1f
-)
-
-var (
-	errInvalidVersion     = errors.New("invalid version")
-	errInvalidReportCount = errors.New("invalid report count")
-	errHeaderTooShort     = errors.New("rtcp header too short")
-)
-
-// Marshal encodes the Header in binary
-func (h Header) Marshal() ([]byte, error) {
-	/*
-	 *  0                   1                   2                   3
-	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
-	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-	 * |V=2|P|    RC   |   PT=SR=200   |             length            |
-	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-	 */
-	rawPacket := make([]byte, headerLength)
-
-	if h.Version > 3 {
-		return nil, errInvalidVersion
+// trimToLength returns rawPacket cut down to the length declared in its
+// header, so packets never read past their own end. If the padding bit is
+// set the padding octets are removed as well. A rawPacket shorter than its
+// declared length has been truncated and is rejected.
+func trimToLength(rawPacket []byte) ([]byte, error) {
+	rawPacket, err := cutToLength(rawPacket)
+	if err != nil || len(rawPacket) < headerLength {
+		return rawPacket, err
 	}
-	rawPacket[0] |= h.Version << versionShift
 
-	if h.Padding {
-		rawPacket[0] |= 1 << paddingShift
+	if rawPacket[0]>>paddingShift&paddingMask == 0 {
+		return rawPacket, nil
 	}
 
-	if h.ReportCount > 31 {
-		return nil, errInvalidReportCount
+	// The last octet counts how many padding octets should be ignored,
+	// including itself
+	padLen := int(rawPacket[len(rawPacket)-1])
+	if padLen == 0 || len(rawPacket)-padLen < headerLength {
+		return nil, ErrInvalidPadding
 	}
-	rawPacket[0] |= h.ReportCount << reportCountShift
 
-	rawPacket[1] = h.Type
+	return rawPacket[:len(rawPacket)-padLen], nil
+}
+
+// cutToLength returns rawPacket cut down to the length declared in its
+// header, leaving any padding in place.
+func cutToLength(rawPacket []byte) ([]byte, error) {
+	// Too short to declare anything, the packet's own checks report this
+	if len(rawPacket) < headerLength {
+		return rawPacket, nil
+	}
 
-	binary.BigEndian.PutUint16(rawPacket[2:], h.Length)
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return nil, err
+	}
 
-	return rawPacket, nil
+	packetLen := (int(h.Length) + 1) * 4
+	if packetLen > len(rawPacket) {
+		return nil, ErrPacketTooShort
+	}
+
+	return rawPacket[:packetLen], nil
 }
 
-// Unmarshal decodes the Header from binary
-func (h *Header) Unmarshal(rawPacket []byte) error {
-	if len(rawPacket) < headerLength {
-		return errHeaderTooShort
+// MarshalWithPadding encodes p like p.Marshal, then pads it to a multiple
+// of blockSize octets, as an encryption algorithm with a fixed block size
+// may require. When padding is added the padding bit is set and the
+// header length covers it; a packet that is already a multiple of
+// blockSize is returned unpadded.
+func MarshalWithPadding(p Packet, blockSize int) ([]byte, error) {
+	if blockSize <= 0 || blockSize%4 != 0 {
+		return nil, ErrInvalidBlockSizeMultiple
 	}
 
-	/*
-	 *  0                   1                   2                   3
-	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
-	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-	 * |V=2|P|    RC   |   PT=SR=200   |             length            |
-	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-	 */
+	rawPacket, err := p.Marshal()
+	if err != nil {
+		return nil, err
+	}
 
-	h.Version = rawPacket[0] >> versionShift & versionMask
-	h.Padding = (rawPacket[0] >> paddingShift & paddingMask) > 0
-	h.ReportCount = rawPacket[0] >> reportCountShift & reportCountMask
+	padLen := (blockSize - len(rawPacket)%blockSize) % blockSize
+	if padLen == 0 {
+		return rawPacket, nil
+	}
 
-	h.Type = rawPacket[1]
+	// The padding count is a single octet
+	if padLen > 0xff {
+		return nil, ErrInvalidPadding
+	}
 
-	h.Length = binary.BigEndian.Uint16(rawPacket[2:])
+	h := p.Header()
+	h.Padding = true
+	h.Length = uint16(((len(rawPacket) + padLen) / 4) - 1)
+	if _, err := h.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+
+	rawPacket = append(rawPacket, make([]byte, padLen)...)
+	rawPacket[len(rawPacket)-1] = uint8(padLen)
+
+	return rawPacket, nil
+}
+
+// Unmarshal is a factory which decodes a single RTCP packet, returning the
+// concrete packet type that matches the type in its header. Packet types
+// that are not understood are returned as a RawPacket.
+func Unmarshal(rawPacket []byte) (Packet, error) {
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return nil, err
+	}
+
+	var p Packet
+	switch h.Type {
+	case TypeSenderReport:
+		p = new(SenderReport)
+
+	case TypeReceiverReport:
+		p = new(ReceiverReport)
+
+	case TypeSourceDescription:
+		p = new(SourceDescription)
+
+	case TypeGoodbye:
+		p = new(Goodbye)
+
+	case TypeApplicationDefined:
+		p = new(ApplicationDefined)
+
+	case TypeTransportSpecificFeedback:
+		switch h.ReportCount {
+		case FormatTLN:
+			p = new(TransportLayerNack)
+		case FormatTMMBR:
+			p = new(TemporaryMaximumMediaStreamBitrateRequest)
+		case FormatTMMBN:
+			p = new(TemporaryMaximumMediaStreamBitrateNotification)
+		case FormatRRR:
+			p = new(RapidResynchronizationRequest)
+		case FormatTCC:
+			p = new(TransportLayerCC)
+		default:
+			p = new(RawPacket)
+		}
+
+	case TypePayloadSpecificFeedback:
+		switch h.ReportCount {
+		case FormatPLI:
+			p = new(PictureLossIndication)
+		case FormatSLI:
+			p = new(SliceLossIndication)
+		case FormatFIR:
+			p = new(FullIntraRequest)
+		case FormatREMB:
+			p = new(ReceiverEstimatedMaximumBitrate)
+		default:
+			p = new(RawPacket)
+		}
+
+	case TypeExtendedReport:
+		p = new(ExtendedReport)
+
+	default:
+		p = new(RawPacket)
+	}
+
+	if err := p.Unmarshal(rawPacket); err != nil {
+		return nil, err
+	}
 
-	return nil
+	return p, nil
 }
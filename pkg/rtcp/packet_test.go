@@ -1,6 +1,8 @@
 package rtcp
 
 import (
+	"bytes"
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -8,91 +10,416 @@ import (
 // An RTCP packet from a packet dump
 var realPacket = []byte{129, 201, 0, 7, 144, 47, 158, 46, 188, 94, 154, 64, 0, 0, 0, 0, 0, 0, 70, 225, 0, 0, 1, 17, 9, 243, 100, 50, 0, 2, 74, 121, 129, 202, 0, 12, 144, 47, 158, 46, 1, 38, 123, 57, 99, 48, 48, 101, 98, 57, 50, 45, 49, 97, 102, 98, 45, 57, 100, 52, 57, 45, 97, 52, 55, 100, 45, 57, 49, 102, 54, 52, 101, 101, 101, 54, 57, 102, 53, 125, 0, 0, 0, 0, 129, 203, 0, 1, 144, 47, 158, 46}
 
-func TestHeaderUnmarshal(t *testing.T) {
-	data := make([]byte, headerLength)
-	copy(data, realPacket)
+func TestUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      Packet
+		WantError error
+	}{
+		{
+			Name:      "nil",
+			Data:      nil,
+			WantError: ErrHeaderTooShort,
+		},
+		{
+			Name: "receiver report",
+			Data: realPacket[:32],
+			Want: &ReceiverReport{
+				SSRC: 0x902f9e2e,
+				Reports: []ReceptionReport{{
+					SSRC:               0xbc5e9a40,
+					FractionLost:       0,
+					TotalLost:          0,
+					LastSequenceNumber: 0x46e1,
+					Jitter:             273,
+					LastSenderReport:   0x9f36432,
+					Delay:              150137,
+				}},
+			},
+		},
+		{
+			Name: "source description",
+			Data: realPacket[32:84],
+			Want: &SourceDescription{
+				Chunks: []SourceDescriptionChunk{{
+					Source: 0x902f9e2e,
+					Items: []SourceDescriptionItem{{
+						Type: SDESCNAME,
+						Text: "{9c00eb92-1afb-9d49-a47d-91f64eee69f5}",
+					}},
+				}},
+			},
+		},
+		{
+			Name: "goodbye",
+			Data: realPacket[84:],
+			Want: &Goodbye{
+				Sources: []uint32{0x902f9e2e},
+			},
+		},
+		{
+			Name: "unknown type",
+			Data: []byte{
+				// v=2, p=0, count=1, PT=210, len=1
+				0x81, 0xd2, 0x00, 0x01,
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			Want: &RawPacket{
+				0x81, 0xd2, 0x00, 0x01,
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+		},
+		{
+			Name: "rapid resynchronization request",
+			Data: []byte{
+				// v=2, p=0, FMT=5, RTPFB, len=2
+				0x85, 0xcd, 0x00, 0x02,
+				0x00, 0x00, 0x00, 0x01,
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			Want: &RapidResynchronizationRequest{
+				SenderSSRC: 1,
+				MediaSSRC:  0x902f9e2e,
+			},
+		},
+		{
+			Name: "truncated sender report",
+			Data: []byte{
+				// v=2, p=0, count=0, SR, len=1
+				0x80, 0xc8, 0x00, 0x01,
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "length exceeds data",
+			Data: []byte{
+				// v=2, p=0, count=0, RR, len=2
+				0x80, 0xc9, 0x00, 0x02,
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "trailing data",
+			Data: append(realPacket[84:], 0x90, 0x2f, 0x9e, 0x2e),
+			Want: &Goodbye{
+				Sources: []uint32{0x902f9e2e},
+			},
+		},
+		{
+			Name: "padded",
+			Data: []byte{
+				// v=2, p=1, count=1, BYE, len=2
+				0xa1, 0xcb, 0x00, 0x02,
+				0x90, 0x2f, 0x9e, 0x2e,
+				// padding, count=4
+				0x00, 0x00, 0x00, 0x04,
+			},
+			Want: &Goodbye{
+				Sources: []uint32{0x902f9e2e},
+			},
+		},
+		{
+			Name: "zero padding count",
+			Data: []byte{
+				// v=2, p=1, count=1, BYE, len=2
+				0xa1, 0xcb, 0x00, 0x02,
+				0x90, 0x2f, 0x9e, 0x2e,
+				0x00, 0x00, 0x00, 0x00,
+			},
+			WantError: ErrInvalidPadding,
+		},
+		{
+			Name: "padding overruns header",
+			Data: []byte{
+				// v=2, p=1, count=1, BYE, len=2
+				0xa1, 0xcb, 0x00, 0x02,
+				0x90, 0x2f, 0x9e, 0x2e,
+				0x00, 0x00, 0x00, 0x0c,
+			},
+			WantError: ErrInvalidPadding,
+		},
+	} {
+		got, err := Unmarshal(test.Data)
+		if err != test.WantError {
+			t.Errorf("Unmarshal %q: err = %v, want %v", test.Name, err, test.WantError)
+		}
+		if err != nil {
+			continue
+		}
 
-	want := Header{
-		Version:     2,
-		Padding:     false,
-		ReportCount: 1,
-		Type:        TypeReceiverReport,
-		Length:      7,
-	}
+		if !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("Unmarshal %q: got %#v, want %#v", test.Name, got, test.Want)
+		}
 
-	var got Header
-	if err := got.Unmarshal(data); err != nil {
-		t.Errorf("Unmarshal: %v", err)
+		if got.Header().Type != test.Data[1] {
+			t.Errorf("Unmarshal %q: header type = %d, want %d", test.Name, got.Header().Type, test.Data[1])
+		}
 	}
+}
 
-	if !reflect.DeepEqual(got, want) {
-		t.Errorf("Unmarshal: got %#v, want %#v", got, want)
+func TestPacketLength(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Packet Packet
+	}{
+		{"sender report", &SenderReport{Reports: []ReceptionReport{{}}, ProfileExtensions: []byte{1, 2, 3, 4}}},
+		{"receiver report", &ReceiverReport{Reports: []ReceptionReport{{}, {}}}},
+		{"source description", &SourceDescription{Chunks: []SourceDescriptionChunk{{Items: []SourceDescriptionItem{{Type: SDESCNAME, Text: "cname"}}}}}},
+		{"goodbye", &Goodbye{Sources: []uint32{1, 2}, Reason: "bye"}},
+		{"application defined", &ApplicationDefined{Name: "NAME", Data: []byte{1, 2, 3, 4}}},
+		{"transport layer nack", &TransportLayerNack{Nacks: []NackPair{{PacketID: 1}}}},
+		{"picture loss indication", &PictureLossIndication{}},
+		{"rapid resynchronization request", &RapidResynchronizationRequest{}},
+		{"slice loss indication", &SliceLossIndication{SLI: []SLIEntry{{First: 1}}}},
+		{"full intra request", &FullIntraRequest{FIR: []FIREntry{{SSRC: 1}}}},
+		{"remb", &ReceiverEstimatedMaximumBitrate{Bitrate: 1000, SSRCs: []uint32{1, 2, 3}}},
+		{"transport layer cc", &TransportLayerCC{PacketChunks: []PacketStatusChunk{&RunLengthChunk{}}, RecvDeltas: []RecvDelta{{Type: TypeTCCPacketReceivedSmallDelta}}}},
+		{"extended report", &ExtendedReport{Reports: []ReportBlock{&ReceiverReferenceTimeReportBlock{}}}},
+		{"tmmbr", &TemporaryMaximumMediaStreamBitrateRequest{Entries: []TMMBEntry{{SSRC: 1}}}},
+		{"tmmbn", &TemporaryMaximumMediaStreamBitrateNotification{}},
+	} {
+		data, err := test.Packet.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal %q: %v", test.Name, err)
+		}
+
+		var h Header
+		if err := h.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q header: %v", test.Name, err)
+		}
+
+		if got, want := h, test.Packet.Header(); got != want {
+			t.Errorf("%q marshaled header = %#v, want %#v", test.Name, got, want)
+		}
+		if got, want := (int(h.Length)+1)*4, len(data); got != want {
+			t.Errorf("%q length = %d octets, want %d", test.Name, got, want)
+		}
 	}
 }
 
-func TestHeaderUnmarshalNil(t *testing.T) {
-	var header Header
-	err := header.Unmarshal(nil)
-	if got, want := err, errHeaderTooShort; got != want {
-		t.Errorf("unmarshal nil header: err = %v, want %v", got, want)
+func TestMarshalTo(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Packet Packet
+	}{
+		{"sender report", &SenderReport{SSRC: 1, Reports: []ReceptionReport{{SSRC: 2}}, ProfileExtensions: []byte{1, 2, 3, 4}}},
+		{"receiver report", &ReceiverReport{SSRC: 1, Reports: []ReceptionReport{{SSRC: 2}, {SSRC: 3}}}},
+		{"source description", &SourceDescription{Chunks: []SourceDescriptionChunk{{Source: 1, Items: []SourceDescriptionItem{{Type: SDESCNAME, Text: "cname"}}}}}},
+		{"goodbye", &Goodbye{Sources: []uint32{1, 2}, Reason: "bye"}},
+		{"application defined", &ApplicationDefined{SSRC: 1, Name: "NAME", Data: []byte{1, 2, 3, 4}}},
+		{"raw packet", &RawPacket{0x80, 0xd2, 0x00, 0x00}},
+		{"transport layer nack", &TransportLayerNack{SenderSSRC: 1, MediaSSRC: 2, Nacks: []NackPair{{PacketID: 1}}}},
+		{"picture loss indication", &PictureLossIndication{SenderSSRC: 1, MediaSSRC: 2}},
+		{"rapid resynchronization request", &RapidResynchronizationRequest{SenderSSRC: 1, MediaSSRC: 2}},
+		{"slice loss indication", &SliceLossIndication{SenderSSRC: 1, MediaSSRC: 2, SLI: []SLIEntry{{First: 1}}}},
+		{"full intra request", &FullIntraRequest{SenderSSRC: 1, FIR: []FIREntry{{SSRC: 2, SequenceNumber: 3}}}},
+		{"remb", &ReceiverEstimatedMaximumBitrate{SenderSSRC: 1, Bitrate: 1000, SSRCs: []uint32{2, 3}}},
+		{"transport layer cc", &TransportLayerCC{SenderSSRC: 1, MediaSSRC: 2, PacketStatusCount: 1, PacketChunks: []PacketStatusChunk{&RunLengthChunk{PacketStatusSymbol: TypeTCCPacketReceivedSmallDelta, RunLength: 1}}, RecvDeltas: []RecvDelta{{Type: TypeTCCPacketReceivedSmallDelta, Delta: 1000}}}},
+		{"extended report", &ExtendedReport{SenderSSRC: 1, Reports: []ReportBlock{&ReceiverReferenceTimeReportBlock{}, &DLRRReportBlock{Reports: []DLRRReport{{SSRC: 2}}}, &VoIPMetricsReportBlock{SSRC: 3}}}},
+		{"tmmbr", &TemporaryMaximumMediaStreamBitrateRequest{SenderSSRC: 1, Entries: []TMMBEntry{{SSRC: 2, Bitrate: 1000}}}},
+		{"tmmbn", &TemporaryMaximumMediaStreamBitrateNotification{SenderSSRC: 1}},
+	} {
+		want, err := test.Packet.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal %q: %v", test.Name, err)
+		}
+
+		// A dirty buffer, as a reused one would be, must not leak into the output
+		buf := bytes.Repeat([]byte{0xff}, len(want)+4)
+		n, err := test.Packet.MarshalTo(buf)
+		if err != nil {
+			t.Fatalf("MarshalTo %q: %v", test.Name, err)
+		}
+		if got := buf[:n]; !bytes.Equal(got, want) {
+			t.Errorf("MarshalTo %q: got %#v, want %#v", test.Name, got, want)
+		}
+
+		if _, err := test.Packet.MarshalTo(buf[:len(want)-1]); err != ErrBufferTooShort {
+			t.Errorf("MarshalTo %q short buffer: err = %v, want %v", test.Name, err, ErrBufferTooShort)
+		}
 	}
 }
-func TestHeaderRoundTrip(t *testing.T) {
+
+func TestMarshalWithPadding(t *testing.T) {
+	bye := &Goodbye{Sources: []uint32{0x902f9e2e}}
+
 	for _, test := range []struct {
 		Name      string
-		Header    Header
+		BlockSize int
+		Want      []byte
 		WantError error
 	}{
 		{
-			Name: "valid",
-			Header: Header{
-				Version:     2,
-				Padding:     true,
-				ReportCount: 31,
-				Type:        TypeSenderReport,
-				Length:      4,
+			Name:      "word aligned",
+			BlockSize: 4,
+			Want: []byte{
+				// v=2, p=0, count=1, BYE, len=1
+				0x81, 0xcb, 0x00, 0x01,
+				0x90, 0x2f, 0x9e, 0x2e,
 			},
 		},
 		{
-			Name: "also valid",
-			Header: Header{
-				Version:     1,
-				Padding:     false,
-				ReportCount: 28,
-				Type:        TypeReceiverReport,
-				Length:      65535,
+			Name:      "block aligned",
+			BlockSize: 16,
+			Want: []byte{
+				// v=2, p=1, count=1, BYE, len=3
+				0xa1, 0xcb, 0x00, 0x03,
+				0x90, 0x2f, 0x9e, 0x2e,
+				// padding, count=8
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x08,
 			},
 		},
 		{
-			Name: "invalid version",
-			Header: Header{
-				Version: 99,
-			},
-			WantError: errInvalidVersion,
+			Name:      "unaligned block size",
+			BlockSize: 6,
+			WantError: ErrInvalidBlockSizeMultiple,
 		},
 		{
-			Name: "invalid report count",
-			Header: Header{
-				ReportCount: 40,
-			},
-			WantError: errInvalidReportCount,
+			Name:      "padding too long",
+			BlockSize: 512,
+			WantError: ErrInvalidPadding,
 		},
 	} {
-		data, err := test.Header.Marshal()
+		data, err := MarshalWithPadding(bye, test.BlockSize)
 		if got, want := err, test.WantError; got != want {
-			t.Errorf("Marshal %q: err = %v, want %v", test.Name, got, want)
+			t.Errorf("MarshalWithPadding %q: err = %v, want %v", test.Name, got, want)
 		}
 		if err != nil {
 			continue
 		}
 
-		var decoded Header
-		if err := decoded.Unmarshal(data); err != nil {
-			t.Errorf("Unmarshal %q: %v", test.Name, err)
+		if got, want := data, test.Want; !bytes.Equal(got, want) {
+			t.Errorf("MarshalWithPadding %q: got %#v, want %#v", test.Name, got, want)
+		}
+
+		decoded, err := Unmarshal(data)
+		if err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+		if got, want := decoded, bye; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q padding round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestUnmarshalTruncated(t *testing.T) {
+	for _, p := range []Packet{
+		&SenderReport{SSRC: 1, Reports: []ReceptionReport{{SSRC: 2}}, ProfileExtensions: []byte{1, 2, 3, 4}},
+		&SourceDescription{Chunks: []SourceDescriptionChunk{{Source: 1, Items: []SourceDescriptionItem{{Type: SDESCNAME, Text: "cname"}}}}},
+		&Goodbye{Sources: []uint32{1, 2}, Reason: "bye"},
+		&ApplicationDefined{SSRC: 1, Name: "NAME", Data: []byte{1, 2, 3, 4}},
+		&TransportLayerNack{SenderSSRC: 1, MediaSSRC: 2, Nacks: []NackPair{{PacketID: 1}}},
+		&SliceLossIndication{SenderSSRC: 1, MediaSSRC: 2, SLI: []SLIEntry{{First: 1}}},
+		&FullIntraRequest{SenderSSRC: 1, FIR: []FIREntry{{SSRC: 2, SequenceNumber: 3}}},
+		&ReceiverEstimatedMaximumBitrate{SenderSSRC: 1, Bitrate: 1000, SSRCs: []uint32{2, 3}},
+		&TransportLayerCC{SenderSSRC: 1, MediaSSRC: 2, PacketStatusCount: 1, PacketChunks: []PacketStatusChunk{&RunLengthChunk{PacketStatusSymbol: TypeTCCPacketReceivedSmallDelta, RunLength: 1}}, RecvDeltas: []RecvDelta{{Type: TypeTCCPacketReceivedSmallDelta, Delta: 1000}}},
+		&ExtendedReport{SenderSSRC: 1, Reports: []ReportBlock{&ReceiverReferenceTimeReportBlock{}, &DLRRReportBlock{Reports: []DLRRReport{{SSRC: 2}}}, &VoIPMetricsReportBlock{SSRC: 3}}},
+		&TemporaryMaximumMediaStreamBitrateRequest{SenderSSRC: 1, Entries: []TMMBEntry{{SSRC: 2, Bitrate: 1000}}},
+	} {
+		data, err := p.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal %T: %v", p, err)
 		}
 
-		if got, want := decoded, test.Header; !reflect.DeepEqual(got, want) {
-			t.Errorf("%q header round trip: got %#v, want %#v", test.Name, got, want)
+		// Every truncation, with the length field left claiming the whole
+		// packet or shrunk to match what remains, must fail cleanly
+		for i := 0; i < len(data); i++ {
+			if _, err := Unmarshal(data[:i]); err == nil {
+				t.Errorf("Unmarshal %T truncated to %d octets: err = nil", p, i)
+			}
+
+			if i < headerLength || i%4 != 0 {
+				continue
+			}
+			shrunk := append([]byte{}, data[:i]...)
+			shrunk[2], shrunk[3] = 0, uint8(i/4-1)
+			_, _ = Unmarshal(shrunk)
+		}
+	}
+}
+
+func TestDestinationSSRC(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Packet Packet
+		Want   []uint32
+	}{
+		{"sender report", &SenderReport{SSRC: 1, Reports: []ReceptionReport{{SSRC: 2}, {SSRC: 3}}}, []uint32{2, 3, 1}},
+		{"receiver report", &ReceiverReport{SSRC: 1, Reports: []ReceptionReport{{SSRC: 2}}}, []uint32{2}},
+		{"source description", &SourceDescription{Chunks: []SourceDescriptionChunk{{Source: 1}, {Source: 2}}}, []uint32{1, 2}},
+		{"goodbye", &Goodbye{Sources: []uint32{1, 2}}, []uint32{1, 2}},
+		{"application defined", &ApplicationDefined{SSRC: 1}, []uint32{1}},
+		{"raw packet", &RawPacket{0x80, 0xd2, 0x00, 0x00}, []uint32{}},
+		{"transport layer nack", &TransportLayerNack{SenderSSRC: 1, MediaSSRC: 2}, []uint32{2}},
+		{"picture loss indication", &PictureLossIndication{SenderSSRC: 1, MediaSSRC: 2}, []uint32{2}},
+		{"rapid resynchronization request", &RapidResynchronizationRequest{SenderSSRC: 1, MediaSSRC: 2}, []uint32{2}},
+		{"slice loss indication", &SliceLossIndication{SenderSSRC: 1, MediaSSRC: 2}, []uint32{2}},
+		{"full intra request", &FullIntraRequest{SenderSSRC: 1, FIR: []FIREntry{{SSRC: 2}, {SSRC: 3}}}, []uint32{2, 3}},
+		{"remb", &ReceiverEstimatedMaximumBitrate{SenderSSRC: 1, SSRCs: []uint32{2, 3}}, []uint32{2, 3}},
+		{"transport layer cc", &TransportLayerCC{SenderSSRC: 1, MediaSSRC: 2}, []uint32{2}},
+		{"tmmbr", &TemporaryMaximumMediaStreamBitrateRequest{SenderSSRC: 1, Entries: []TMMBEntry{{SSRC: 2}}}, []uint32{2}},
+		{"tmmbn", &TemporaryMaximumMediaStreamBitrateNotification{SenderSSRC: 1, Entries: []TMMBEntry{{SSRC: 2}}}, []uint32{2}},
+		{
+			"extended report",
+			&ExtendedReport{SenderSSRC: 1, Reports: []ReportBlock{
+				&ReceiverReferenceTimeReportBlock{},
+				&DLRRReportBlock{Reports: []DLRRReport{{SSRC: 2}, {SSRC: 3}}},
+				&VoIPMetricsReportBlock{SSRC: 4},
+				&UnknownReportBlock{0x2a, 0x00, 0x00, 0x00},
+			}},
+			[]uint32{2, 3, 4},
+		},
+	} {
+		if got, want := test.Packet.DestinationSSRC(), test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q DestinationSSRC() = %v, want %v", test.Name, got, want)
+		}
+	}
+}
+
+func TestPacketString(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Packet fmt.Stringer
+		Want   string
+	}{
+		{
+			"header",
+			Header{Version: 2, ReportCount: 1, Type: TypeReceiverReport, Length: 7},
+			"Header RR (v=2, p=false, count=1, length=7)",
+		},
+		{
+			"receiver report",
+			ReceiverReport{SSRC: 0x902f9e2e, Reports: []ReceptionReport{{SSRC: 0xbc5e9a40, FractionLost: 1, TotalLost: 2, LastSequenceNumber: 3, Jitter: 4, LastSenderReport: 5, Delay: 6}}},
+			"ReceiverReport from 902f9e2e\n" +
+				"\tReceptionReport for bc5e9a40: lost 1/256 (2 total), last sequence 3, jitter 4, LSR 5, DLSR 6\n",
+		},
+		{
+			"source description",
+			SourceDescription{Chunks: []SourceDescriptionChunk{{Source: 0x902f9e2e, Items: []SourceDescriptionItem{{Type: SDESCNAME, Text: "cname"}}}}},
+			"SourceDescription\n\t902f9e2e: CNAME=\"cname\"\n",
+		},
+		{
+			"goodbye",
+			Goodbye{Sources: []uint32{0x902f9e2e}, Reason: "bye"},
+			"Goodbye\n\tSource: 902f9e2e\n\tReason: \"bye\"\n",
+		},
+		{
+			"picture loss indication",
+			PictureLossIndication{SenderSSRC: 1, MediaSSRC: 0x902f9e2e},
+			"PictureLossIndication from 1 for 902f9e2e\n",
+		},
+		{
+			"raw packet",
+			RawPacket{0x80, 0xd2, 0x00, 0x00},
+			"RawPacket: Header type 210 (v=2, p=false, count=0, length=0)\n\t80d20000\n",
+		},
+	} {
+		if got, want := test.Packet.String(), test.Want; got != want {
+			t.Errorf("%q String() = %q, want %q", test.Name, got, want)
 		}
 	}
 }
@@ -0,0 +1,102 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The PictureLossIndication packet informs the encoder about the loss of an undefined amount of coded video data belonging to one or more pictures
+type PictureLossIndication struct {
+	// SSRC of sender
+	SenderSSRC uint32
+
+	// SSRC where the loss was experienced
+	MediaSSRC uint32
+}
+
+const (
+	pliLength = 2
+)
+
+// Marshal encodes the PictureLossIndication in binary
+func (p PictureLossIndication) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, p.len())
+	if _, err := p.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the PictureLossIndication in binary into buf,
+// returning the number of bytes written.
+func (p PictureLossIndication) MarshalTo(buf []byte) (int, error) {
+	/*
+	 * PLI does not require parameters.  Therefore, the length field MUST be
+	 * 2, and there MUST NOT be any Feedback Control Information.
+	 *
+	 * The semantics of this FB message is independent of the payload type.
+	 */
+
+	n := p.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := p.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody, p.SenderSSRC)
+	binary.BigEndian.PutUint32(packetBody[4:], p.MediaSSRC)
+
+	return n, nil
+}
+
+// Unmarshal decodes the PictureLossIndication from binary
+func (p *PictureLossIndication) Unmarshal(rawPacket []byte) error {
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < (headerLength + (ssrcLength * 2)) {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypePayloadSpecificFeedback || h.ReportCount != FormatPLI {
+		return ErrWrongType
+	}
+
+	p.SenderSSRC = binary.BigEndian.Uint32(rawPacket[headerLength:])
+	p.MediaSSRC = binary.BigEndian.Uint32(rawPacket[headerLength+ssrcLength:])
+	return nil
+}
+
+func (p PictureLossIndication) len() int {
+	return headerLength + ssrcLength*2
+}
+
+// Header returns the Header associated with this packet.
+func (p PictureLossIndication) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: FormatPLI,
+		Type:        TypePayloadSpecificFeedback,
+		Length:      pliLength,
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (p PictureLossIndication) DestinationSSRC() []uint32 {
+	return []uint32{p.MediaSSRC}
+}
+
+func (p PictureLossIndication) String() string {
+	return fmt.Sprintf("PictureLossIndication from %x for %x\n", p.SenderSSRC, p.MediaSSRC)
+}
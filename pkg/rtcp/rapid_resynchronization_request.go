@@ -0,0 +1,103 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The RapidResynchronizationRequest (RRR) packet asks a media sender to send
+// an SR as soon as possible, so the receiver can quickly synchronize a
+// stream it has just started receiving, such as after a switch in an SFU.
+// See RFC 6051 Section 3.
+type RapidResynchronizationRequest struct {
+	// SSRC of sender
+	SenderSSRC uint32
+
+	// SSRC of the media source that should send the SR
+	MediaSSRC uint32
+}
+
+const (
+	rrrLength = 2
+)
+
+// Marshal encodes the RapidResynchronizationRequest in binary
+func (p RapidResynchronizationRequest) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, p.len())
+	if _, err := p.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the RapidResynchronizationRequest in binary into buf,
+// returning the number of bytes written.
+func (p RapidResynchronizationRequest) MarshalTo(buf []byte) (int, error) {
+	/*
+	 * The RRR does not require parameters.  Therefore, the length field
+	 * MUST be 2, and there MUST NOT be any Feedback Control Information.
+	 */
+
+	n := p.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := p.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody, p.SenderSSRC)
+	binary.BigEndian.PutUint32(packetBody[4:], p.MediaSSRC)
+
+	return n, nil
+}
+
+// Unmarshal decodes the RapidResynchronizationRequest from binary
+func (p *RapidResynchronizationRequest) Unmarshal(rawPacket []byte) error {
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < (headerLength + (ssrcLength * 2)) {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypeTransportSpecificFeedback || h.ReportCount != FormatRRR {
+		return ErrWrongType
+	}
+
+	p.SenderSSRC = binary.BigEndian.Uint32(rawPacket[headerLength:])
+	p.MediaSSRC = binary.BigEndian.Uint32(rawPacket[headerLength+ssrcLength:])
+	return nil
+}
+
+func (p RapidResynchronizationRequest) len() int {
+	return headerLength + ssrcLength*2
+}
+
+// Header returns the Header associated with this packet.
+func (p RapidResynchronizationRequest) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: FormatRRR,
+		Type:        TypeTransportSpecificFeedback,
+		Length:      rrrLength,
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (p RapidResynchronizationRequest) DestinationSSRC() []uint32 {
+	return []uint32{p.MediaSSRC}
+}
+
+func (p RapidResynchronizationRequest) String() string {
+	return fmt.Sprintf("RapidResynchronizationRequest from %x for %x\n", p.SenderSSRC, p.MediaSSRC)
+}
@@ -0,0 +1,115 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRapidResynchronizationRequestUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      RapidResynchronizationRequest
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Data: []byte{
+				// v=2, p=0, FMT=5, RTPFB, len=2
+				0x85, 0xcd, 0x00, 0x02,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+			},
+			Want: RapidResynchronizationRequest{
+				SenderSSRC: 0x0,
+				MediaSSRC:  0x4bc4fcb4,
+			},
+		},
+		{
+			Name: "packet too short",
+			Data: []byte{
+				0x85, 0xcd, 0x00, 0x00,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "wrong type",
+			Data: []byte{
+				// v=2, p=0, FMT=5, RR, len=2
+				0x85, 0xc9, 0x00, 0x02,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+			},
+			WantError: ErrWrongType,
+		},
+		{
+			Name: "wrong fmt",
+			Data: []byte{
+				// v=2, p=0, FMT=1, RTPFB, len=2
+				0x81, 0xcd, 0x00, 0x02,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+			},
+			WantError: ErrWrongType,
+		},
+	} {
+		var rrr RapidResynchronizationRequest
+		err := rrr.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q rrr: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := rrr, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q rrr: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestRapidResynchronizationRequestRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Packet    RapidResynchronizationRequest
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Packet: RapidResynchronizationRequest{
+				SenderSSRC: 1,
+				MediaSSRC:  2,
+			},
+		},
+		{
+			Name: "also valid",
+			Packet: RapidResynchronizationRequest{
+				SenderSSRC: 5000,
+				MediaSSRC:  6000,
+			},
+		},
+	} {
+		data, err := test.Packet.Marshal()
+		if got, want := err, test.WantError; got != want {
+			t.Fatalf("Marshal %q: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		var decoded RapidResynchronizationRequest
+		if err := decoded.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+
+		if got, want := decoded, test.Packet; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q rrr round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package rtcp
+
+import (
+	"fmt"
+)
+
+// RawPacket represents an unparsed RTCP packet. It's returned by Unmarshal when
+// a packet with an unknown type is encountered.
+type RawPacket []byte
+
+// Marshal encodes the packet in binary.
+func (r RawPacket) Marshal() ([]byte, error) {
+	return r, nil
+}
+
+// MarshalTo copies the packet into buf, returning the number of bytes
+// written.
+func (r RawPacket) MarshalTo(buf []byte) (int, error) {
+	if len(buf) < len(r) {
+		return 0, ErrBufferTooShort
+	}
+	return copy(buf, r), nil
+}
+
+// Unmarshal decodes the packet from binary.
+func (r *RawPacket) Unmarshal(b []byte) error {
+	// The packet is kept as is, padding included
+	b, err := cutToLength(b)
+	if err != nil {
+		return err
+	}
+
+	if len(b) < (headerLength) {
+		return ErrPacketTooShort
+	}
+	*r = b
+
+	var h Header
+	return h.Unmarshal(b)
+}
+
+// Header returns the Header associated with this packet.
+func (r RawPacket) Header() Header {
+	var h Header
+	if err := h.Unmarshal(r); err != nil {
+		return Header{}
+	}
+	return h
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (r RawPacket) DestinationSSRC() []uint32 {
+	return []uint32{}
+}
+
+func (r RawPacket) String() string {
+	return fmt.Sprintf("RawPacket: %v\n\t%x\n", r.Header(), []byte(r))
+}
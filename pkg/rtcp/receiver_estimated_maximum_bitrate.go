@@ -0,0 +1,184 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ReceiverEstimatedMaximumBitrate contains the receiver's estimated maximum bitrate.
+// see: https://tools.ietf.org/html/draft-alvestrand-rmcat-remb-03
+type ReceiverEstimatedMaximumBitrate struct {
+	// SSRC of sender
+	SenderSSRC uint32
+
+	// Estimated maximum bitrate, in bits per second
+	Bitrate uint64
+
+	// SSRC entries which this packet applies to
+	SSRCs []uint32
+}
+
+const (
+	rembOffset        = 16
+	rembMediaSSRC     = 0
+	rembMantissaMax   = (1 << 18) - 1
+	rembNumSSRCOffset = 12
+	rembBitrateOffset = 13
+	rembUniqueOffset  = 8
+)
+
+var rembUniqueIdentifier = []byte{'R', 'E', 'M', 'B'}
+
+// Marshal encodes the ReceiverEstimatedMaximumBitrate in binary
+func (p ReceiverEstimatedMaximumBitrate) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, p.len())
+	if _, err := p.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the ReceiverEstimatedMaximumBitrate in binary into
+// buf, returning the number of bytes written.
+func (p ReceiverEstimatedMaximumBitrate) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P| FMT=15  |   PT=206      |             length            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                  SSRC of packet sender                        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                  SSRC of media source                         |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |  Unique identifier 'R' 'E' 'M' 'B'                            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |  Num SSRC     | BR Exp    |  BR Mantissa                      |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |   SSRC feedback                                               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |  ...                                                          |
+	 */
+
+	if len(p.SSRCs) > 0xff {
+		return 0, ErrTooManySSRCs
+	}
+
+	n := p.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := p.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	// Find the smallest exponent that lets the bitrate fit in the mantissa.
+	// Any precision lost is rounded down, which keeps the estimate conservative.
+	exp := uint(0)
+	mantissa := p.Bitrate
+	for mantissa > rembMantissaMax {
+		mantissa >>= 1
+		exp++
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody, p.SenderSSRC)
+	binary.BigEndian.PutUint32(packetBody[ssrcLength:], rembMediaSSRC)
+	copy(packetBody[rembUniqueOffset:], rembUniqueIdentifier)
+
+	packetBody[rembNumSSRCOffset] = uint8(len(p.SSRCs))
+	packetBody[rembBitrateOffset] = uint8(exp<<2) | uint8(mantissa>>16)
+	packetBody[rembBitrateOffset+1] = uint8(mantissa >> 8)
+	packetBody[rembBitrateOffset+2] = uint8(mantissa)
+
+	for i, ssrc := range p.SSRCs {
+		binary.BigEndian.PutUint32(packetBody[rembOffset+(i*ssrcLength):], ssrc)
+	}
+
+	return n, nil
+}
+
+// Unmarshal decodes the ReceiverEstimatedMaximumBitrate from binary
+func (p *ReceiverEstimatedMaximumBitrate) Unmarshal(rawPacket []byte) error {
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < headerLength+rembOffset {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypePayloadSpecificFeedback || h.ReportCount != FormatREMB {
+		return ErrWrongType
+	}
+
+	packetBody := rawPacket[headerLength:]
+
+	for i, b := range rembUniqueIdentifier {
+		if packetBody[rembUniqueOffset+i] != b {
+			return ErrMissingREMBIdentifier
+		}
+	}
+
+	numSSRCs := int(packetBody[rembNumSSRCOffset])
+	if rembOffset+(numSSRCs*ssrcLength) > len(packetBody) {
+		return ErrSSRCNumAndLengthMismatch
+	}
+
+	exp := uint(packetBody[rembBitrateOffset] >> 2)
+	mantissa := uint64(packetBody[rembBitrateOffset]&0x03)<<16 |
+		uint64(packetBody[rembBitrateOffset+1])<<8 |
+		uint64(packetBody[rembBitrateOffset+2])
+
+	bitrate := mantissa << exp
+	if bitrate>>exp != mantissa {
+		return ErrInvalidBitrate
+	}
+
+	p.SenderSSRC = binary.BigEndian.Uint32(packetBody)
+	p.Bitrate = bitrate
+
+	p.SSRCs = nil
+	for i := 0; i < numSSRCs; i++ {
+		p.SSRCs = append(p.SSRCs, binary.BigEndian.Uint32(packetBody[rembOffset+(i*ssrcLength):]))
+	}
+
+	return nil
+}
+
+func (p ReceiverEstimatedMaximumBitrate) len() int {
+	return headerLength + rembOffset + (len(p.SSRCs) * ssrcLength)
+}
+
+// Header returns the Header associated with this packet.
+func (p ReceiverEstimatedMaximumBitrate) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: FormatREMB,
+		Type:        TypePayloadSpecificFeedback,
+		Length:      uint16((p.len() / 4) - 1),
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (p ReceiverEstimatedMaximumBitrate) DestinationSSRC() []uint32 {
+	out := make([]uint32, len(p.SSRCs))
+	copy(out, p.SSRCs)
+	return out
+}
+
+func (p ReceiverEstimatedMaximumBitrate) String() string {
+	out := fmt.Sprintf("ReceiverEstimatedMaximumBitrate from %x\n", p.SenderSSRC)
+	out += fmt.Sprintf("\tBitrate: %d bps\n", p.Bitrate)
+	for _, s := range p.SSRCs {
+		out += fmt.Sprintf("\tSSRC: %x\n", s)
+	}
+	return out
+}
@@ -0,0 +1,159 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReceiverEstimatedMaximumBitrateUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      ReceiverEstimatedMaximumBitrate
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Data: []byte{
+				// v=2, p=0, FMT=15, PSFB, len=5
+				0x8f, 0xce, 0x00, 0x05,
+				// sender ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// media ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// 'R' 'E' 'M' 'B'
+				0x52, 0x45, 0x4d, 0x42,
+				// num=1, exp=6, mantissa=0x220db
+				0x01, 0x1a, 0x20, 0xdb,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			Want: ReceiverEstimatedMaximumBitrate{
+				SenderSSRC: 1,
+				Bitrate:    0x220db << 6,
+				SSRCs:      []uint32{0x902f9e2e},
+			},
+		},
+		{
+			Name: "missing identifier",
+			Data: []byte{
+				// v=2, p=0, FMT=15, PSFB, len=4
+				0x8f, 0xce, 0x00, 0x04,
+				// sender ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// media ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// 'R' 'E' 'M' 'X'
+				0x52, 0x45, 0x4d, 0x58,
+				// num=0, exp=6, mantissa=0x220db
+				0x00, 0x1a, 0x20, 0xdb,
+			},
+			WantError: ErrMissingREMBIdentifier,
+		},
+		{
+			Name: "ssrc count mismatch",
+			Data: []byte{
+				// v=2, p=0, FMT=15, PSFB, len=4
+				0x8f, 0xce, 0x00, 0x04,
+				// sender ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// media ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// 'R' 'E' 'M' 'B'
+				0x52, 0x45, 0x4d, 0x42,
+				// num=2, exp=6, mantissa=0x220db
+				0x02, 0x1a, 0x20, 0xdb,
+			},
+			WantError: ErrSSRCNumAndLengthMismatch,
+		},
+		{
+			Name: "bitrate overflow",
+			Data: []byte{
+				// v=2, p=0, FMT=15, PSFB, len=4
+				0x8f, 0xce, 0x00, 0x04,
+				// sender ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// media ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// 'R' 'E' 'M' 'B'
+				0x52, 0x45, 0x4d, 0x42,
+				// num=0, exp=63, mantissa=0x3ffff
+				0x00, 0xff, 0xff, 0xff,
+			},
+			WantError: ErrInvalidBitrate,
+		},
+		{
+			Name: "too short",
+			Data: []byte{
+				// v=2, p=0, FMT=15, PSFB, len=1
+				0x8f, 0xce, 0x00, 0x01,
+				// sender ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+			},
+			WantError: ErrPacketTooShort,
+		},
+	} {
+		var remb ReceiverEstimatedMaximumBitrate
+		err := remb.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q remb: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := remb, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q remb: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestReceiverEstimatedMaximumBitrateRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Remb ReceiverEstimatedMaximumBitrate
+		Want uint64
+	}{
+		{
+			Name: "small bitrate",
+			Remb: ReceiverEstimatedMaximumBitrate{
+				SenderSSRC: 1,
+				Bitrate:    8927,
+				SSRCs:      []uint32{1215622422},
+			},
+			Want: 8927,
+		},
+		{
+			Name: "large bitrate loses precision",
+			Remb: ReceiverEstimatedMaximumBitrate{
+				SenderSSRC: 1,
+				Bitrate:    8927168,
+				SSRCs:      []uint32{1215622422, 1215622423},
+			},
+			Want: 8927168 &^ 0x3f,
+		},
+		{
+			Name: "max bitrate",
+			Remb: ReceiverEstimatedMaximumBitrate{
+				Bitrate: ^uint64(0),
+			},
+			Want: 0x3ffff << 46,
+		},
+	} {
+		data, err := test.Remb.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal %q: %v", test.Name, err)
+		}
+
+		var decoded ReceiverEstimatedMaximumBitrate
+		if err := decoded.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+
+		want := test.Remb
+		want.Bitrate = test.Want
+		if got := decoded; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q remb round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
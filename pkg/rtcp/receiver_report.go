@@ -0,0 +1,181 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// A ReceiverReport (RR) packet provides reception quality feedback for an RTP stream
+type ReceiverReport struct {
+	// The synchronization source identifier for the originator of this RR packet.
+	SSRC uint32
+	// Zero or more reception report blocks depending on the number of other
+	// sources heard by this sender since the last report. Each reception report
+	// block conveys statistics on the reception of RTP packets from a
+	// single synchronization source.
+	Reports []ReceptionReport
+	// ProfileExtensions contains additional, payload-specific information that needs to
+	// be reported regularly about the receiver.
+	ProfileExtensions []byte
+}
+
+const (
+	rrSSRCOffset   = 0
+	rrReportOffset = rrSSRCOffset + ssrcLength
+)
+
+// Marshal encodes the ReceiverReport in binary
+func (r ReceiverReport) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, r.len())
+	if _, err := r.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the ReceiverReport in binary into buf, returning the
+// number of bytes written.
+func (r ReceiverReport) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *         0                   1                   2                   3
+	 *         0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 *        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * header |V=2|P|    RC   |   PT=RR=201   |             length            |
+	 *        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *        |                     SSRC of packet sender                     |
+	 *        +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+	 * report |                 SSRC_1 (SSRC of first source)                 |
+	 * block  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *   1    | fraction lost |       cumulative number of packets lost       |
+	 *        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *        |           extended highest sequence number received           |
+	 *        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *        |                      interarrival jitter                      |
+	 *        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *        |                         last SR (LSR)                         |
+	 *        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *        |                   delay since last SR (DLSR)                  |
+	 *        +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+	 * report |                 SSRC_2 (SSRC of second source)                |
+	 * block  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *   2    :                               ...                             :
+	 *        +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+	 *        |                  profile-specific extensions                  |
+	 *        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	if len(r.Reports) > countMax {
+		return 0, ErrTooManyReports
+	}
+
+	// The header length counts 32-bit words, so extensions must fill whole words
+	if len(r.ProfileExtensions)%4 != 0 {
+		return 0, ErrInvalidProfileExtensions
+	}
+
+	n := r.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := r.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody[rrSSRCOffset:], r.SSRC)
+
+	offset := rrReportOffset
+	for _, rp := range r.Reports {
+		if _, err := rp.MarshalTo(packetBody[offset:]); err != nil {
+			return 0, err
+		}
+		offset += receptionReportLength
+	}
+
+	copy(packetBody[offset:], r.ProfileExtensions)
+
+	return n, nil
+}
+
+// Unmarshal decodes the ReceiverReport from binary
+func (r *ReceiverReport) Unmarshal(rawPacket []byte) error {
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < (headerLength + ssrcLength) {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypeReceiverReport {
+		return ErrWrongType
+	}
+
+	packetBody := rawPacket[headerLength:]
+
+	// The report count in the header must fit within the body we were given
+	if rrReportOffset+int(h.ReportCount)*receptionReportLength > len(packetBody) {
+		return ErrInvalidReportCount
+	}
+
+	r.SSRC = binary.BigEndian.Uint32(packetBody[rrSSRCOffset:])
+
+	r.Reports = nil
+	offset := rrReportOffset
+	for i := 0; i < int(h.ReportCount); i++ {
+		var rr ReceptionReport
+		if err := rr.Unmarshal(packetBody[offset:]); err != nil {
+			return err
+		}
+		r.Reports = append(r.Reports, rr)
+		offset += receptionReportLength
+	}
+
+	r.ProfileExtensions = nil
+	if offset < len(packetBody) {
+		r.ProfileExtensions = append([]byte{}, packetBody[offset:]...)
+	}
+
+	return nil
+}
+
+func (r ReceiverReport) len() int {
+	return headerLength + ssrcLength + len(r.Reports)*receptionReportLength + len(r.ProfileExtensions)
+}
+
+// Header returns the Header associated with this packet.
+func (r ReceiverReport) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: uint8(len(r.Reports)),
+		Type:        TypeReceiverReport,
+		Length:      uint16((r.len() / 4) - 1),
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (r ReceiverReport) DestinationSSRC() []uint32 {
+	out := make([]uint32, len(r.Reports))
+	for i, v := range r.Reports {
+		out[i] = v.SSRC
+	}
+	return out
+}
+
+func (r ReceiverReport) String() string {
+	out := fmt.Sprintf("ReceiverReport from %x\n", r.SSRC)
+	for _, rp := range r.Reports {
+		out += fmt.Sprintf("\t%v\n", rp)
+	}
+	if len(r.ProfileExtensions) != 0 {
+		out += fmt.Sprintf("\tProfileExtensions: %x\n", r.ProfileExtensions)
+	}
+	return out
+}
@@ -0,0 +1,225 @@
+package rtcp
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// A source is only considered valid once this many packets have been
+	// received in sequence, RFC 3550 Appendix A.1
+	minSequential = 2
+	maxDropout    = 3000
+	maxMisorder   = 100
+	seqMod        = 1 << 16
+
+	totalLostMax = (1 << 23) - 1
+)
+
+// ReceptionStatistics tracks the RTP packets received from a single source
+// and produces the ReceptionReport blocks that describe them, following
+// the algorithms of RFC 3550 Appendix A. It is safe for concurrent use, so
+// packets can be recorded on one goroutine while reports are generated on
+// another.
+type ReceptionStatistics struct {
+	mu sync.Mutex
+
+	ssrc      uint32
+	clockRate uint32
+
+	maxSeq        uint16 // highest sequence number seen
+	cycles        uint32 // shifted count of sequence number cycles
+	baseSeq       uint32 // base sequence number
+	badSeq        uint32 // last 'bad' sequence number + 1
+	probation     int    // sequential packets till source is valid
+	initialized   bool
+	received      uint32 // packets received
+	expectedPrior uint32 // packets expected at last interval
+	receivedPrior uint32 // packets received at last interval
+
+	started     bool
+	epoch       time.Time
+	lastTransit uint32
+	jitter      float64
+
+	lastSenderReport  uint32
+	lastSenderArrival time.Time
+}
+
+// NewReceptionStatistics creates a ReceptionStatistics for the source with
+// the given SSRC, whose RTP timestamps run at clockRate Hz.
+func NewReceptionStatistics(ssrc, clockRate uint32) *ReceptionStatistics {
+	return &ReceptionStatistics{
+		ssrc:      ssrc,
+		clockRate: clockRate,
+	}
+}
+
+// RecordPacket records the arrival of an RTP packet with the given sequence
+// number and timestamp. Packets that fail sequence validation, such as
+// those from a source that restarted, are not counted.
+func (r *ReceptionStatistics) RecordPacket(sequenceNumber uint16, timestamp uint32, arrival time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.updateSeq(sequenceNumber) {
+		return
+	}
+
+	// Interarrival jitter, RFC 3550 Appendix A.8
+	if !r.started {
+		r.epoch = arrival
+	}
+	// Computed modulo 2^32, so a wrapping RTP timestamp is harmless
+	transit := uint32(r.toTimestamp(arrival)) - timestamp
+	if r.started {
+		d := int32(transit - r.lastTransit)
+		if d < 0 {
+			d = -d
+		}
+		r.jitter += (float64(d) - r.jitter) / 16
+	}
+	r.lastTransit = transit
+	r.started = true
+}
+
+// RecordSenderReport records the arrival of a SenderReport from the
+// source, so later reports can carry the LSR and DLSR fields used for
+// round trip time calculation.
+func (r *ReceptionStatistics) RecordSenderReport(sr SenderReport, arrival time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The middle 32 bits of the NTP timestamp
+	r.lastSenderReport = uint32(sr.NTPTime >> 16)
+	r.lastSenderArrival = arrival
+}
+
+// Report returns a ReceptionReport describing the packets received so far.
+// The fraction lost covers the interval since the previous call to Report,
+// so it should only be called when a report is actually sent.
+func (r *ReceptionStatistics) Report(now time.Time) ReceptionReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Packet loss, RFC 3550 Appendix A.3
+	extendedMax := r.cycles + uint32(r.maxSeq)
+	expected := extendedMax - r.baseSeq + 1
+	if r.received == 0 {
+		expected = 0
+	}
+
+	lost := int64(expected) - int64(r.received)
+	switch {
+	case lost > totalLostMax:
+		lost = totalLostMax
+	case lost < 0:
+		// Duplicates can make this negative, which the 24 bit field
+		// can't carry in this API
+		lost = 0
+	}
+
+	expectedInterval := expected - r.expectedPrior
+	receivedInterval := r.received - r.receivedPrior
+	r.expectedPrior = expected
+	r.receivedPrior = r.received
+
+	var fraction uint8
+	if lostInterval := int64(expectedInterval) - int64(receivedInterval); expectedInterval != 0 && lostInterval > 0 {
+		f := (lostInterval << 8) / int64(expectedInterval)
+		if f > 0xff {
+			f = 0xff
+		}
+		fraction = uint8(f)
+	}
+
+	var delay uint32
+	if r.lastSenderReport != 0 {
+		// Expressed in units of 1/65536 seconds
+		delay = uint32(now.Sub(r.lastSenderArrival).Seconds() * 65536)
+	}
+
+	return ReceptionReport{
+		SSRC:               r.ssrc,
+		FractionLost:       fraction,
+		TotalLost:          uint32(lost),
+		LastSequenceNumber: extendedMax,
+		Jitter:             uint32(r.jitter),
+		LastSenderReport:   r.lastSenderReport,
+		Delay:              delay,
+	}
+}
+
+func (r *ReceptionStatistics) initSeq(seq uint16) {
+	r.baseSeq = uint32(seq)
+	r.maxSeq = seq
+	r.badSeq = seqMod + 1 // so seq == badSeq is false
+	r.cycles = 0
+	r.received = 0
+	r.receivedPrior = 0
+	r.expectedPrior = 0
+}
+
+// updateSeq implements the sequence number validation of RFC 3550
+// Appendix A.1, returning whether the packet should be counted.
+func (r *ReceptionStatistics) updateSeq(seq uint16) bool {
+	// Source is not valid until minSequential packets with sequential
+	// sequence numbers have been received.
+	if !r.initialized {
+		r.initSeq(seq)
+		r.maxSeq = seq - 1
+		r.probation = minSequential
+		r.initialized = true
+	}
+
+	if r.probation > 0 {
+		if seq == r.maxSeq+1 {
+			r.probation--
+			r.maxSeq = seq
+			if r.probation == 0 {
+				r.initSeq(seq)
+				r.received++
+				return true
+			}
+		} else {
+			r.probation = minSequential - 1
+			r.maxSeq = seq
+		}
+		return false
+	}
+
+	switch udelta := seq - r.maxSeq; {
+	case udelta < maxDropout:
+		// in order, with permissible gap
+		if seq < r.maxSeq {
+			// Sequence number wrapped - count another 64K cycle.
+			r.cycles += seqMod
+		}
+		r.maxSeq = seq
+	case udelta <= seqMod-maxMisorder:
+		// the sequence number made a very large jump
+		if uint32(seq) == r.badSeq {
+			// Two sequential packets -- assume that the other side
+			// restarted without telling us so just re-sync
+			// (i.e., pretend this was the first packet).
+			r.initSeq(seq)
+		} else {
+			r.badSeq = (uint32(seq) + 1) & (seqMod - 1)
+			return false
+		}
+	default:
+		// duplicate or reordered packet
+	}
+
+	r.received++
+	return true
+}
+
+// toTimestamp converts an arrival time to the units of the RTP timestamp,
+// relative to the first packet's arrival. Only differences between
+// transit times are used, so the choice of epoch doesn't matter.
+func (r *ReceptionStatistics) toTimestamp(t time.Time) int64 {
+	d := t.Sub(r.epoch)
+	return int64(d/time.Second)*int64(r.clockRate) +
+		int64(d%time.Second)*int64(r.clockRate)/int64(time.Second)
+}
@@ -0,0 +1,112 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReceptionStatistics(t *testing.T) {
+	type packet struct {
+		SequenceNumber uint16
+		Timestamp      uint32
+		Arrival        time.Duration
+	}
+
+	// sequential returns packets with sequence numbers first..last, sent and
+	// received every 20ms with a 1kHz clock
+	sequential := func(first, last uint16) []packet {
+		var out []packet
+		for seq := first; seq != last+1; seq++ {
+			offset := uint32(seq - first)
+			out = append(out, packet{seq, offset * 20, time.Duration(offset) * 20 * time.Millisecond})
+		}
+		return out
+	}
+
+	for _, test := range []struct {
+		Name    string
+		Packets []packet
+		Want    ReceptionReport
+	}{
+		{
+			Name:    "in order",
+			Packets: sequential(100, 109),
+			Want:    ReceptionReport{SSRC: 1, LastSequenceNumber: 109},
+		},
+		{
+			Name:    "loss",
+			Packets: append(sequential(100, 104), sequential(107, 109)...),
+			Want:    ReceptionReport{SSRC: 1, FractionLost: 56, TotalLost: 2, LastSequenceNumber: 109},
+		},
+		{
+			Name:    "wrap",
+			Packets: sequential(65534, 1),
+			Want:    ReceptionReport{SSRC: 1, LastSequenceNumber: 1<<16 + 1},
+		},
+		{
+			Name: "jitter",
+			Packets: []packet{
+				{0, 0, 0},
+				{1, 20, 20 * time.Millisecond},
+				{2, 40, 56 * time.Millisecond},
+			},
+			Want: ReceptionReport{SSRC: 1, LastSequenceNumber: 2, Jitter: 1},
+		},
+		{
+			Name: "restarted source",
+			Packets: append(sequential(100, 104), []packet{
+				{40000, 0, 0},
+				{40001, 20, 20 * time.Millisecond},
+				{40002, 40, 40 * time.Millisecond},
+			}...),
+			Want: ReceptionReport{SSRC: 1, LastSequenceNumber: 40002},
+		},
+	} {
+		start := time.Unix(1500000000, 0)
+		stats := NewReceptionStatistics(1, 1000)
+		for _, p := range test.Packets {
+			stats.RecordPacket(p.SequenceNumber, p.Timestamp, start.Add(p.Arrival))
+		}
+
+		if got, want := stats.Report(start), test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q report: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestReceptionStatisticsInterval(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	stats := NewReceptionStatistics(1, 1000)
+	for _, seq := range []uint16{0, 1, 2, 4} {
+		stats.RecordPacket(seq, uint32(seq)*20, start.Add(time.Duration(seq)*20*time.Millisecond))
+	}
+
+	if got, want := stats.Report(start).FractionLost, uint8(64); got != want {
+		t.Errorf("first interval fraction lost = %d, want %d", got, want)
+	}
+
+	// Nothing was lost since the last report
+	stats.RecordPacket(5, 100, start.Add(100*time.Millisecond))
+	report := stats.Report(start)
+	if got, want := report.FractionLost, uint8(0); got != want {
+		t.Errorf("second interval fraction lost = %d, want %d", got, want)
+	}
+	if got, want := report.TotalLost, uint32(1); got != want {
+		t.Errorf("total lost = %d, want %d", got, want)
+	}
+}
+
+func TestReceptionStatisticsSenderReport(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	stats := NewReceptionStatistics(1, 1000)
+	stats.RecordSenderReport(SenderReport{NTPTime: 0xda8bd1fcdddda05a}, start)
+
+	report := stats.Report(start.Add(1500 * time.Millisecond))
+	if got, want := report.LastSenderReport, uint32(0xd1fcdddd); got != want {
+		t.Errorf("LastSenderReport = %#x, want %#x", got, want)
+	}
+	if got, want := report.Delay, uint32(98304); got != want {
+		t.Errorf("Delay = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,214 @@
+package rtcp
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// Fraction of the session bandwidth given to RTCP
+	rtcpBandwidthFraction = 0.05
+
+	// Shares of the RTCP bandwidth for senders and receivers, used when
+	// senders are no more than a quarter of the members
+	rtcpSenderBandwidthFraction   = 0.25
+	rtcpReceiverBandwidthFraction = 1 - rtcpSenderBandwidthFraction
+
+	rtcpMinTime = 5 * time.Second
+
+	// Compensates for the timer reconsideration algorithm converging to
+	// a value below the intended average, RFC 3550 Appendix A.7
+	rtcpCompensation = math.E - 1.5
+
+	// Above this many members a BYE is subject to reconsideration
+	rtcpByeReconsiderationMembers = 50
+)
+
+// A Scheduler decides when RTCP packets should be sent, following the
+// randomized interval and timer reconsideration rules of RFC 3550 Section
+// 6.3, so that RTCP traffic stays within its share of the session
+// bandwidth however many members the session has.
+//
+// The application arms a timer for Next, and when it fires calls Timeout.
+// If Timeout reports that a packet is due it sends one and calls Sent,
+// otherwise it re-arms the timer for the new Next.
+type Scheduler struct {
+	mu sync.Mutex
+
+	// RTCP bandwidth, in octets per second
+	rtcpBandwidth float64
+
+	members  int
+	pmembers int
+	senders  int
+	weSent   bool
+	initial  bool
+
+	// Average compound packet size, in octets including lower layer
+	// headers
+	avgRTCPSize float64
+
+	tp time.Time
+	tn time.Time
+
+	rand func() float64
+}
+
+// NewScheduler creates a Scheduler for a session using sessionBandwidth
+// bits per second. avgPacketSize is the expected size, in octets, of the
+// first RTCP packet sent, including UDP and IP headers. The first packet
+// is scheduled relative to now.
+func NewScheduler(sessionBandwidth uint64, avgPacketSize int, now time.Time) *Scheduler {
+	s := &Scheduler{
+		rtcpBandwidth: float64(sessionBandwidth) * rtcpBandwidthFraction / 8,
+		members:       1,
+		pmembers:      1,
+		initial:       true,
+		avgRTCPSize:   float64(avgPacketSize),
+		tp:            now,
+		rand:          rand.Float64,
+	}
+	s.tn = now.Add(s.interval())
+	return s
+}
+
+// Next returns the time the next RTCP packet is scheduled for.
+func (s *Scheduler) Next() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tn
+}
+
+// Timeout is called when the timer set for Next expires. The interval is
+// recomputed with the current membership, and if the packet is still due
+// true is returned. Otherwise Next is moved later and false is returned.
+func (s *Scheduler) Timeout(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tn = s.tp.Add(s.interval())
+	return !s.tn.After(now)
+}
+
+// Sent records that an RTCP compound packet of size octets, including
+// lower layer headers, was sent at now, and schedules the next one.
+func (s *Scheduler) Sent(size int, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateAverage(size)
+	s.tp = now
+	s.initial = false
+	s.pmembers = s.members
+	s.tn = now.Add(s.interval())
+}
+
+// Received records that an RTCP compound packet of size octets, including
+// lower layer headers, was received.
+func (s *Scheduler) Received(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateAverage(size)
+}
+
+// SetWeSent sets whether this participant has sent RTP data since the
+// second previous report was transmitted.
+func (s *Scheduler) SetWeSent(weSent bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.weSent = weSent
+}
+
+// UpdateMembers sets the number of session members, this participant
+// included, and how many of them are senders. When members leave, for
+// example after a BYE, the schedule is pulled in proportionally so the
+// remaining members don't under-use their bandwidth (reverse
+// reconsideration, RFC 3550 Section 6.3.4).
+func (s *Scheduler) UpdateMembers(members, senders int, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if members < 1 {
+		members = 1
+	}
+
+	if members < s.pmembers {
+		ratio := float64(members) / float64(s.pmembers)
+		s.tn = now.Add(time.Duration(ratio * float64(s.tn.Sub(now))))
+		s.tp = now.Add(-time.Duration(ratio * float64(now.Sub(s.tp))))
+		s.pmembers = members
+	}
+
+	s.members = members
+	s.senders = senders
+}
+
+// Leave schedules the BYE sent when leaving the session, of size octets
+// including lower layer headers, returning when it should be sent. Small
+// sessions may send it at once, larger ones reconsider it so that many
+// members leaving together don't flood the session (RFC 3550 Section
+// 6.3.7). The returned time is also available as Next, and Timeout
+// applies to it as for any other packet.
+func (s *Scheduler) Leave(size int, now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.members < rtcpByeReconsiderationMembers {
+		s.tn = now
+		return s.tn
+	}
+
+	// Members are counted by the BYEs received from here on
+	s.tp = now
+	s.members = 1
+	s.pmembers = 1
+	s.senders = 0
+	s.weSent = false
+	s.initial = true
+	s.avgRTCPSize = float64(size)
+	s.tn = now.Add(s.interval())
+	return s.tn
+}
+
+func (s *Scheduler) updateAverage(size int) {
+	s.avgRTCPSize = (1.0/16)*float64(size) + (15.0/16)*s.avgRTCPSize
+}
+
+// interval computes the randomized RTCP transmission interval, RFC 3550
+// Appendix A.7
+func (s *Scheduler) interval() time.Duration {
+	minTime := rtcpMinTime
+	if s.initial {
+		minTime /= 2
+	}
+
+	// Senders get their own share of the bandwidth when they are few, so
+	// their reports, which carry CNAMEs for synchronization, go out quickly
+	bandwidth := s.rtcpBandwidth
+	n := s.members
+	if float64(s.senders) <= float64(s.members)*rtcpSenderBandwidthFraction {
+		if s.weSent {
+			bandwidth *= rtcpSenderBandwidthFraction
+			n = s.senders
+		} else {
+			bandwidth *= rtcpReceiverBandwidthFraction
+			n -= s.senders
+		}
+	}
+
+	t := minTime
+	if bandwidth > 0 {
+		if d := time.Duration(s.avgRTCPSize * float64(n) / bandwidth * float64(time.Second)); d > t {
+			t = d
+		}
+	}
+
+	// Randomize to between 0.5 and 1.5 times the calculated interval, to
+	// avoid synchronization between members
+	return time.Duration(float64(t) * (s.rand() + 0.5) / rtcpCompensation)
+}
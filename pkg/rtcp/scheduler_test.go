@@ -0,0 +1,125 @@
+package rtcp
+
+import (
+	"testing"
+	"time"
+)
+
+// With no randomization the interval is only scaled by the compensation
+// factor
+func compensate(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / rtcpCompensation)
+}
+
+func TestSchedulerInterval(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+
+	for _, test := range []struct {
+		Name    string
+		Members int
+		Senders int
+		WeSent  bool
+		Sent    bool
+		Want    time.Duration
+	}{
+		{
+			Name:    "initial",
+			Members: 1,
+			Want:    compensate(rtcpMinTime / 2),
+		},
+		{
+			Name:    "minimum",
+			Members: 1,
+			Sent:    true,
+			Want:    compensate(rtcpMinTime),
+		},
+		{
+			// 100 octets * 990 receivers / 300 octets per second
+			Name:    "receivers",
+			Members: 1000,
+			Senders: 10,
+			Sent:    true,
+			Want:    compensate(time.Duration(100 * 990 * float64(time.Second) / 300)),
+		},
+		{
+			// 100 octets * 10 senders / 100 octets per second
+			Name:    "sender",
+			Members: 1000,
+			Senders: 10,
+			WeSent:  true,
+			Sent:    true,
+			Want:    compensate(10 * time.Second),
+		},
+		{
+			// 100 octets * 1000 members / 400 octets per second
+			Name:    "many senders",
+			Members: 1000,
+			Senders: 500,
+			WeSent:  true,
+			Sent:    true,
+			Want:    compensate(250 * time.Second),
+		},
+	} {
+		// 64kbps leaves 400 octets per second for RTCP
+		s := NewScheduler(64000, 100, start)
+		s.rand = func() float64 { return 0.5 }
+		s.UpdateMembers(test.Members, test.Senders, start)
+		s.SetWeSent(test.WeSent)
+		if test.Sent {
+			s.Sent(100, start)
+		}
+
+		if s.Timeout(start) {
+			t.Errorf("%q: timeout at start, want a packet scheduled later", test.Name)
+		}
+		if got, want := s.Next().Sub(start), test.Want; got != want {
+			t.Errorf("%q interval = %v, want %v", test.Name, got, want)
+		}
+		if !s.Timeout(s.Next()) {
+			t.Errorf("%q: no packet due at %v", test.Name, s.Next())
+		}
+	}
+}
+
+func TestSchedulerReconsideration(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	s := NewScheduler(64000, 100, start)
+	s.rand = func() float64 { return 0.5 }
+
+	// A timer set while the session was small fires early once many
+	// members have joined, so the packet is held back
+	first := s.Next()
+	s.UpdateMembers(1000, 0, start)
+	if s.Timeout(first) {
+		t.Fatal("packet due after members joined, want it rescheduled")
+	}
+	if !s.Next().After(first) {
+		t.Fatalf("next = %v, want after %v", s.Next(), first)
+	}
+
+	// Half of the members leaving halves the remaining wait
+	s.Sent(100, start)
+	now := start.Add(10 * time.Second)
+	want := now.Add(s.Next().Sub(now) / 2)
+	s.UpdateMembers(500, 0, now)
+	if got := s.Next(); got.Sub(want) > time.Microsecond || want.Sub(got) > time.Microsecond {
+		t.Errorf("after BYE next = %v, want %v", got, want)
+	}
+}
+
+func TestSchedulerLeave(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+
+	s := NewScheduler(64000, 100, start)
+	s.UpdateMembers(10, 0, start)
+	if got, want := s.Leave(100, start), start; !got.Equal(want) {
+		t.Errorf("small session BYE at %v, want %v", got, want)
+	}
+
+	s = NewScheduler(64000, 100, start)
+	s.rand = func() float64 { return 0.5 }
+	s.UpdateMembers(1000, 0, start)
+	if got, want := s.Leave(100, start), start.Add(compensate(rtcpMinTime/2)); !got.Equal(want) {
+		t.Errorf("large session BYE at %v, want %v", got, want)
+	}
+}
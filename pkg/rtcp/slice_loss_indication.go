@@ -0,0 +1,155 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// SLIEntry represents a single entry to the SLI packet's
+// list of lost slices.
+type SLIEntry struct {
+	// ID of first lost slice, 13 bits
+	First uint16
+
+	// Number of lost slices, 13 bits
+	Number uint16
+
+	// ID of related picture, 6 bits
+	Picture uint8
+}
+
+// The SliceLossIndication packet informs the encoder about the loss of a picture slice
+type SliceLossIndication struct {
+	// SSRC of sender
+	SenderSSRC uint32
+
+	// SSRC of the media source
+	MediaSSRC uint32
+
+	SLI []SLIEntry
+}
+
+const (
+	sliOffset      = 8
+	sliEntryLength = 4
+
+	sliFirstMax   = (1 << 13) - 1
+	sliNumberMax  = (1 << 13) - 1
+	sliPictureMax = (1 << 6) - 1
+)
+
+// Marshal encodes the SliceLossIndication in binary
+func (p SliceLossIndication) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, p.len())
+	if _, err := p.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the SliceLossIndication in binary into buf,
+// returning the number of bytes written.
+func (p SliceLossIndication) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P| FMT=2   |   PT=206      |          length               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                  SSRC of packet sender                        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                  SSRC of media source                         |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |            First        |        Number           | PictureID |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * :                              ...                              :
+	 */
+
+	for _, s := range p.SLI {
+		if s.First > sliFirstMax || s.Number > sliNumberMax || s.Picture > sliPictureMax {
+			return 0, ErrInvalidSLIEntry
+		}
+	}
+
+	n := p.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := p.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody, p.SenderSSRC)
+	binary.BigEndian.PutUint32(packetBody[4:], p.MediaSSRC)
+	for i, s := range p.SLI {
+		sli := (uint32(s.First) << 19) | (uint32(s.Number) << 6) | uint32(s.Picture)
+		binary.BigEndian.PutUint32(packetBody[sliOffset+(sliEntryLength*i):], sli)
+	}
+
+	return n, nil
+}
+
+// Unmarshal decodes the SliceLossIndication from binary
+func (p *SliceLossIndication) Unmarshal(rawPacket []byte) error {
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < (headerLength + ssrcLength*2) {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypePayloadSpecificFeedback || h.ReportCount != FormatSLI {
+		return ErrWrongType
+	}
+
+	p.SenderSSRC = binary.BigEndian.Uint32(rawPacket[headerLength:])
+	p.MediaSSRC = binary.BigEndian.Uint32(rawPacket[headerLength+ssrcLength:])
+
+	p.SLI = nil
+	for i := headerLength + sliOffset; i+sliEntryLength <= len(rawPacket); i += sliEntryLength {
+		sli := binary.BigEndian.Uint32(rawPacket[i:])
+		p.SLI = append(p.SLI, SLIEntry{
+			First:   uint16((sli >> 19) & sliFirstMax),
+			Number:  uint16((sli >> 6) & sliNumberMax),
+			Picture: uint8(sli & sliPictureMax),
+		})
+	}
+
+	return nil
+}
+
+func (p SliceLossIndication) len() int {
+	return headerLength + sliOffset + (len(p.SLI) * sliEntryLength)
+}
+
+// Header returns the Header associated with this packet.
+func (p SliceLossIndication) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: FormatSLI,
+		Type:        TypePayloadSpecificFeedback,
+		Length:      uint16((p.len() / 4) - 1),
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (p SliceLossIndication) DestinationSSRC() []uint32 {
+	return []uint32{p.MediaSSRC}
+}
+
+func (p SliceLossIndication) String() string {
+	out := fmt.Sprintf("SliceLossIndication from %x for %x\n", p.SenderSSRC, p.MediaSSRC)
+	for _, e := range p.SLI {
+		out += fmt.Sprintf("\tFirst: %d, Number: %d, Picture: %d\n", e.First, e.Number, e.Picture)
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSliceLossIndicationUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      SliceLossIndication
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Data: []byte{
+				// v=2, p=0, FMT=2, PSFB, len=3
+				0x82, 0xce, 0x00, 0x03,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// First=0x1, Number=0xaa, Picture=0x1f
+				0x00, 0x08, 0x2a, 0x9f,
+			},
+			Want: SliceLossIndication{
+				SenderSSRC: 0x902f9e2e,
+				MediaSSRC:  0x902f9e2e,
+				SLI:        []SLIEntry{{First: 0x1, Number: 0xaa, Picture: 0x1f}},
+			},
+		},
+		{
+			Name: "short report",
+			Data: []byte{
+				// v=2, p=0, FMT=2, PSFB, len=1
+				0x82, 0xce, 0x00, 0x01,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "wrong fmt",
+			Data: []byte{
+				// v=2, p=0, FMT=1, PSFB, len=2
+				0x81, 0xce, 0x00, 0x02,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			WantError: ErrWrongType,
+		},
+	} {
+		var sli SliceLossIndication
+		err := sli.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q sli: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := sli, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q sli: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestSliceLossIndicationRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Report    SliceLossIndication
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Report: SliceLossIndication{
+				SenderSSRC: 0x902f9e2e,
+				MediaSSRC:  0x902f9e2e,
+				SLI:        []SLIEntry{{0xaaa, 0, 0x2C}, {0xbbb, 0xccc, 0x3f}},
+			},
+		},
+		{
+			Name: "first out of range",
+			Report: SliceLossIndication{
+				SLI: []SLIEntry{{First: 1 << 13}},
+			},
+			WantError: ErrInvalidSLIEntry,
+		},
+		{
+			Name: "picture out of range",
+			Report: SliceLossIndication{
+				SLI: []SLIEntry{{Picture: 1 << 6}},
+			},
+			WantError: ErrInvalidSLIEntry,
+		},
+	} {
+		data, err := test.Report.Marshal()
+		if got, want := err, test.WantError; got != want {
+			t.Fatalf("Marshal %q: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		var decoded SliceLossIndication
+		if err := decoded.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+
+		if got, want := decoded, test.Report; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q sli round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
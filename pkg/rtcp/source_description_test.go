@@ -0,0 +1,224 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSourceDescriptionUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      SourceDescription
+		WantError error
+	}{
+		{
+			Name:      "nil",
+			Data:      nil,
+			WantError: ErrHeaderTooShort,
+		},
+		{
+			Name: "real packet",
+			Data: realPacket[32:84],
+			Want: SourceDescription{
+				Chunks: []SourceDescriptionChunk{{
+					Source: 0x902f9e2e,
+					Items: []SourceDescriptionItem{{
+						Type: SDESCNAME,
+						Text: "{9c00eb92-1afb-9d49-a47d-91f64eee69f5}",
+					}},
+				}},
+			},
+		},
+		{
+			Name: "wrong type",
+			Data: []byte{
+				// v=2, p=0, count=1, SR, len=2
+				0x81, 0xc8, 0x00, 0x02,
+				// ssrc=0x01020304
+				0x01, 0x02, 0x03, 0x04,
+				// END + padding
+				0x00, 0x00, 0x00, 0x00,
+			},
+			WantError: ErrWrongType,
+		},
+		{
+			Name: "item overflows packet",
+			Data: []byte{
+				// v=2, p=0, count=1, SDES, len=2
+				0x81, 0xca, 0x00, 0x02,
+				// ssrc=0x01020304
+				0x01, 0x02, 0x03, 0x04,
+				// CNAME, len=4
+				0x01, 0x04, 0x41, 0x42,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "missing terminator",
+			Data: []byte{
+				// v=2, p=0, count=1, SDES, len=2
+				0x81, 0xca, 0x00, 0x02,
+				// ssrc=0x01020304
+				0x01, 0x02, 0x03, 0x04,
+				// CNAME, len=2
+				0x01, 0x02, 0x41, 0x42,
+			},
+			Want: SourceDescription{
+				Chunks: []SourceDescriptionChunk{{
+					Source: 0x01020304,
+					Items: []SourceDescriptionItem{{
+						Type: SDESCNAME,
+						Text: "AB",
+					}},
+				}},
+			},
+		},
+		{
+			Name: "chunk count mismatch",
+			Data: []byte{
+				// v=2, p=0, count=2, SDES, len=2
+				0x82, 0xca, 0x00, 0x02,
+				// ssrc=0x01020304
+				0x01, 0x02, 0x03, 0x04,
+				// END + padding
+				0x00, 0x00, 0x00, 0x00,
+			},
+			WantError: ErrInvalidReportCount,
+		},
+	} {
+		var sdes SourceDescription
+		err := sdes.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q sdes: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := sdes, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q sdes: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestSourceDescriptionRoundTrip(t *testing.T) {
+	tooLongText := make([]byte, 256)
+	for i := range tooLongText {
+		tooLongText[i] = 'x'
+	}
+
+	for _, test := range []struct {
+		Name      string
+		Desc      SourceDescription
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Desc: SourceDescription{
+				Chunks: []SourceDescriptionChunk{
+					{
+						Source: 1,
+						Items: []SourceDescriptionItem{
+							{
+								Type: SDESCNAME,
+								Text: "test@example.com",
+							},
+						},
+					},
+					{
+						Source: 2,
+						Items: []SourceDescriptionItem{
+							{
+								Type: SDESNote,
+								Text: "some note",
+							},
+							{
+								Type: SDESNote,
+								Text: "another note",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: "item without type",
+			Desc: SourceDescription{
+				Chunks: []SourceDescriptionChunk{{
+					Source: 1,
+					Items: []SourceDescriptionItem{{
+						Text: "test@example.com",
+					}},
+				}},
+			},
+			WantError: ErrSDESMissingType,
+		},
+		{
+			Name: "zero items",
+			Desc: SourceDescription{
+				Chunks: []SourceDescriptionChunk{{
+					Source: 1,
+				}},
+			},
+		},
+		{
+			Name: "email item",
+			Desc: SourceDescription{
+				Chunks: []SourceDescriptionChunk{{
+					Source: 1,
+					Items: []SourceDescriptionItem{{
+						Type: SDESEmail,
+						Text: "test@example.com",
+					}},
+				}},
+			},
+		},
+		{
+			Name: "private item",
+			Desc: SourceDescription{
+				Chunks: []SourceDescriptionChunk{{
+					Source: 1,
+					Items: []SourceDescriptionItem{{
+						Type: SDESPrivate,
+						Text: "\x03foobar",
+					}},
+				}},
+			},
+		},
+		{
+			Name: "text too long",
+			Desc: SourceDescription{
+				Chunks: []SourceDescriptionChunk{{
+					Source: 1,
+					Items: []SourceDescriptionItem{{
+						Type: SDESCNAME,
+						Text: string(tooLongText),
+					}},
+				}},
+			},
+			WantError: ErrSDESTextTooLong,
+		},
+	} {
+		data, err := test.Desc.Marshal()
+		if got, want := err, test.WantError; got != want {
+			t.Fatalf("Marshal %q: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if len(data)%4 != 0 {
+			t.Fatalf("Marshal %q: length %d is not 32-bit aligned", test.Name, len(data))
+		}
+
+		var decoded SourceDescription
+		if err := decoded.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+
+		if got, want := decoded, test.Desc; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q sdes round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// A TMMBEntry is a bitrate limit for a single media sender, as carried by
+// TemporaryMaximumMediaStreamBitrateRequest and
+// TemporaryMaximumMediaStreamBitrateNotification.
+type TMMBEntry struct {
+	// The SSRC of the media sender the limit applies to
+	SSRC uint32
+
+	// Maximum total media bitrate, in bits per second. It is sent as a
+	// 17 bit mantissa and 6 bit exponent, so any precision that doesn't
+	// fit is rounded down.
+	Bitrate uint64
+
+	// Per packet overhead, in octets, that the requester observed when
+	// measuring the bitrate. 9 bits.
+	Overhead uint16
+}
+
+// The TemporaryMaximumMediaStreamBitrateRequest (TMMBR) packet asks media
+// senders to limit their bitrate. See RFC 5104 Section 4.2.1.
+type TemporaryMaximumMediaStreamBitrateRequest struct {
+	// SSRC of sender
+	SenderSSRC uint32
+
+	// SSRC of the media source, unused for TMMBR and SHALL be set to 0
+	MediaSSRC uint32
+
+	Entries []TMMBEntry
+}
+
+// The TemporaryMaximumMediaStreamBitrateNotification (TMMBN) packet
+// acknowledges a TMMBR, announcing the current bounding set of limits.
+// See RFC 5104 Section 4.2.2.
+type TemporaryMaximumMediaStreamBitrateNotification struct {
+	// SSRC of sender
+	SenderSSRC uint32
+
+	// SSRC of the media source, unused for TMMBN and SHALL be set to 0
+	MediaSSRC uint32
+
+	Entries []TMMBEntry
+}
+
+const (
+	tmmbOffset        = 8
+	tmmbEntryLength   = 8
+	tmmbMantissaMax   = (1 << 17) - 1
+	tmmbOverheadMax   = (1 << 9) - 1
+	tmmbExponentShift = 26
+	tmmbMantissaShift = 9
+)
+
+// Marshal encodes the TemporaryMaximumMediaStreamBitrateRequest in binary
+func (p TemporaryMaximumMediaStreamBitrateRequest) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, tmmbLen(p.Entries))
+	if _, err := p.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the TemporaryMaximumMediaStreamBitrateRequest in
+// binary into buf, returning the number of bytes written.
+func (p TemporaryMaximumMediaStreamBitrateRequest) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P| FMT=3   |   PT=205      |          length               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                  SSRC of packet sender                        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |             SSRC of media source (unused) = 0                 |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                              SSRC                             |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * | MxTBR Exp |  MxTBR Mantissa                 |Measured Overhead|
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * :                              ...                              :
+	 */
+	return marshalTMMBTo(buf, p.Header(), p.SenderSSRC, p.MediaSSRC, p.Entries)
+}
+
+// Unmarshal decodes the TemporaryMaximumMediaStreamBitrateRequest from binary
+func (p *TemporaryMaximumMediaStreamBitrateRequest) Unmarshal(rawPacket []byte) error {
+	sender, media, entries, err := unmarshalTMMB(rawPacket, FormatTMMBR)
+	if err != nil {
+		return err
+	}
+
+	p.SenderSSRC, p.MediaSSRC, p.Entries = sender, media, entries
+	return nil
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (p TemporaryMaximumMediaStreamBitrateRequest) DestinationSSRC() []uint32 {
+	return tmmbDestinationSSRC(p.Entries)
+}
+
+// Header returns the Header associated with this packet.
+func (p TemporaryMaximumMediaStreamBitrateRequest) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: FormatTMMBR,
+		Type:        TypeTransportSpecificFeedback,
+		Length:      uint16((tmmbLen(p.Entries) / 4) - 1),
+	}
+}
+
+// Marshal encodes the TemporaryMaximumMediaStreamBitrateNotification in binary
+func (p TemporaryMaximumMediaStreamBitrateNotification) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, tmmbLen(p.Entries))
+	if _, err := p.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the TemporaryMaximumMediaStreamBitrateNotification
+// in binary into buf, returning the number of bytes written.
+func (p TemporaryMaximumMediaStreamBitrateNotification) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P| FMT=4   |   PT=205      |          length               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                  SSRC of packet sender                        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |             SSRC of media source (unused) = 0                 |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                              SSRC                             |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * | MxTBR Exp |  MxTBR Mantissa                 |Measured Overhead|
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * :                              ...                              :
+	 */
+	return marshalTMMBTo(buf, p.Header(), p.SenderSSRC, p.MediaSSRC, p.Entries)
+}
+
+// Unmarshal decodes the TemporaryMaximumMediaStreamBitrateNotification from binary
+func (p *TemporaryMaximumMediaStreamBitrateNotification) Unmarshal(rawPacket []byte) error {
+	sender, media, entries, err := unmarshalTMMB(rawPacket, FormatTMMBN)
+	if err != nil {
+		return err
+	}
+
+	p.SenderSSRC, p.MediaSSRC, p.Entries = sender, media, entries
+	return nil
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (p TemporaryMaximumMediaStreamBitrateNotification) DestinationSSRC() []uint32 {
+	return tmmbDestinationSSRC(p.Entries)
+}
+
+// Header returns the Header associated with this packet.
+func (p TemporaryMaximumMediaStreamBitrateNotification) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: FormatTMMBN,
+		Type:        TypeTransportSpecificFeedback,
+		Length:      uint16((tmmbLen(p.Entries) / 4) - 1),
+	}
+}
+
+// TMMBR and TMMBN share a wire format, only the FMT differs
+func marshalTMMBTo(buf []byte, h Header, senderSSRC, mediaSSRC uint32, entries []TMMBEntry) (int, error) {
+	for _, e := range entries {
+		if e.Overhead > tmmbOverheadMax {
+			return 0, ErrInvalidTMMBEntry
+		}
+	}
+
+	n := tmmbLen(entries)
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := h.MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody, senderSSRC)
+	binary.BigEndian.PutUint32(packetBody[ssrcLength:], mediaSSRC)
+
+	for i, e := range entries {
+		exp := uint32(0)
+		mantissa := e.Bitrate
+		for mantissa > tmmbMantissaMax {
+			mantissa >>= 1
+			exp++
+		}
+
+		offset := tmmbOffset + i*tmmbEntryLength
+		binary.BigEndian.PutUint32(packetBody[offset:], e.SSRC)
+		binary.BigEndian.PutUint32(packetBody[offset+4:],
+			exp<<tmmbExponentShift|uint32(mantissa)<<tmmbMantissaShift|uint32(e.Overhead))
+	}
+
+	return n, nil
+}
+
+func unmarshalTMMB(rawPacket []byte, format uint8) (senderSSRC, mediaSSRC uint32, entries []TMMBEntry, err error) {
+	rawPacket, err = trimToLength(rawPacket)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	if len(rawPacket) < (headerLength + tmmbOffset) {
+		return 0, 0, nil, ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return 0, 0, nil, err
+	}
+
+	if h.Type != TypeTransportSpecificFeedback || h.ReportCount != format {
+		return 0, 0, nil, ErrWrongType
+	}
+
+	// The FCI must be a whole number of entries
+	if (len(rawPacket)-headerLength-tmmbOffset)%tmmbEntryLength != 0 {
+		return 0, 0, nil, ErrPacketTooShort
+	}
+
+	senderSSRC = binary.BigEndian.Uint32(rawPacket[headerLength:])
+	mediaSSRC = binary.BigEndian.Uint32(rawPacket[headerLength+ssrcLength:])
+
+	for i := headerLength + tmmbOffset; i < len(rawPacket); i += tmmbEntryLength {
+		word := binary.BigEndian.Uint32(rawPacket[i+4:])
+		exp := uint(word >> tmmbExponentShift)
+		mantissa := uint64(word>>tmmbMantissaShift) & tmmbMantissaMax
+
+		bitrate := mantissa << exp
+		if bitrate>>exp != mantissa {
+			return 0, 0, nil, ErrInvalidBitrate
+		}
+
+		entries = append(entries, TMMBEntry{
+			SSRC:     binary.BigEndian.Uint32(rawPacket[i:]),
+			Bitrate:  bitrate,
+			Overhead: uint16(word & tmmbOverheadMax),
+		})
+	}
+
+	return senderSSRC, mediaSSRC, entries, nil
+}
+
+func tmmbLen(entries []TMMBEntry) int {
+	return headerLength + tmmbOffset + tmmbEntryLength*len(entries)
+}
+
+func tmmbDestinationSSRC(entries []TMMBEntry) []uint32 {
+	out := make([]uint32, len(entries))
+	for i, v := range entries {
+		out[i] = v.SSRC
+	}
+	return out
+}
+
+func (e TMMBEntry) String() string {
+	return fmt.Sprintf("SSRC: %x, Bitrate: %d bps, Overhead: %d", e.SSRC, e.Bitrate, e.Overhead)
+}
+
+func (p TemporaryMaximumMediaStreamBitrateRequest) String() string {
+	return tmmbString("TemporaryMaximumMediaStreamBitrateRequest", p.SenderSSRC, p.Entries)
+}
+
+func (p TemporaryMaximumMediaStreamBitrateNotification) String() string {
+	return tmmbString("TemporaryMaximumMediaStreamBitrateNotification", p.SenderSSRC, p.Entries)
+}
+
+func tmmbString(name string, senderSSRC uint32, entries []TMMBEntry) string {
+	out := fmt.Sprintf("%s from %x\n", name, senderSSRC)
+	for _, e := range entries {
+		out += fmt.Sprintf("\t%v\n", e)
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemporaryMaximumMediaStreamBitrateRequestUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      TemporaryMaximumMediaStreamBitrateRequest
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Data: []byte{
+				// v=2, p=0, FMT=3, RTPFB, len=4
+				0x83, 0xcd, 0x00, 0x04,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// exp=3, mantissa=125000, overhead=40
+				0x0f, 0xd0, 0x90, 0x28,
+			},
+			Want: TemporaryMaximumMediaStreamBitrateRequest{
+				SenderSSRC: 1,
+				Entries:    []TMMBEntry{{SSRC: 0x902f9e2e, Bitrate: 1000000, Overhead: 40}},
+			},
+		},
+		{
+			Name: "partial entry",
+			Data: []byte{
+				// v=2, p=0, FMT=3, RTPFB, len=3
+				0x83, 0xcd, 0x00, 0x03,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "bitrate overflow",
+			Data: []byte{
+				// v=2, p=0, FMT=3, RTPFB, len=4
+				0x83, 0xcd, 0x00, 0x04,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+				// ssrc=0x902f9e2e
+				0x90, 0x2f, 0x9e, 0x2e,
+				// exp=63, mantissa=0x1ffff, overhead=0
+				0xff, 0xff, 0xfe, 0x00,
+			},
+			WantError: ErrInvalidBitrate,
+		},
+		{
+			Name: "notification",
+			Data: []byte{
+				// v=2, p=0, FMT=4, RTPFB, len=2
+				0x84, 0xcd, 0x00, 0x02,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x0
+				0x00, 0x00, 0x00, 0x00,
+			},
+			WantError: ErrWrongType,
+		},
+	} {
+		var tmmbr TemporaryMaximumMediaStreamBitrateRequest
+		err := tmmbr.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q tmmbr: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := tmmbr, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q tmmbr: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestTemporaryMaximumMediaStreamBitrateRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Entries   []TMMBEntry
+		Want      []TMMBEntry
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Entries: []TMMBEntry{
+				{SSRC: 0x902f9e2e, Bitrate: 1000000, Overhead: 40},
+				{SSRC: 0xbc5e9a40, Bitrate: 64000, Overhead: 511},
+			},
+		},
+		{
+			Name:    "rounded bitrate",
+			Entries: []TMMBEntry{{SSRC: 0x902f9e2e, Bitrate: 1000001}},
+			Want:    []TMMBEntry{{SSRC: 0x902f9e2e, Bitrate: 1000000}},
+		},
+		{
+			Name:      "overhead out of range",
+			Entries:   []TMMBEntry{{SSRC: 0x902f9e2e, Overhead: 512}},
+			WantError: ErrInvalidTMMBEntry,
+		},
+	} {
+		want := test.Want
+		if want == nil {
+			want = test.Entries
+		}
+
+		tmmbr := TemporaryMaximumMediaStreamBitrateRequest{SenderSSRC: 1, Entries: test.Entries}
+		data, err := tmmbr.Marshal()
+		if got, want := err, test.WantError; got != want {
+			t.Fatalf("Marshal %q tmmbr: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		var decodedTMMBR TemporaryMaximumMediaStreamBitrateRequest
+		if err := decodedTMMBR.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q tmmbr: %v", test.Name, err)
+		}
+		if got := decodedTMMBR.Entries; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q tmmbr round trip: got %#v, want %#v", test.Name, got, want)
+		}
+
+		tmmbn := TemporaryMaximumMediaStreamBitrateNotification{SenderSSRC: 1, Entries: test.Entries}
+		data, err = tmmbn.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal %q tmmbn: %v", test.Name, err)
+		}
+
+		var decodedTMMBN TemporaryMaximumMediaStreamBitrateNotification
+		if err := decodedTMMBN.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q tmmbn: %v", test.Name, err)
+		}
+		if got := decodedTMMBN.Entries; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q tmmbn round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
@@ -0,0 +1,542 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Packet status symbols carried by the packet status chunks of a TransportLayerCC
+const (
+	TypeTCCPacketNotReceived          = 0
+	TypeTCCPacketReceivedSmallDelta   = 1
+	TypeTCCPacketReceivedLargeDelta   = 2
+	TypeTCCPacketReceivedWithoutDelta = 3
+)
+
+// Packet status chunk types
+const (
+	TypeTCCRunLengthChunk    = 0
+	TypeTCCStatusVectorChunk = 1
+)
+
+// Symbol sizes of a StatusVectorChunk
+const (
+	TypeTCCSymbolSizeOneBit = 0
+	TypeTCCSymbolSizeTwoBit = 1
+)
+
+// TypeTCCDeltaScaleFactor is the resolution of receive deltas, in microseconds
+const TypeTCCDeltaScaleFactor = 250
+
+const (
+	tccBaseSequenceNumberOffset = 8
+	tccPacketStatusCountOffset  = 10
+	tccReferenceTimeOffset      = 12
+	tccFbPktCountOffset         = 15
+	tccPacketChunkOffset        = 16
+	tccPacketChunkLength        = 2
+
+	tccRunLengthMax       = (1 << 13) - 1
+	tccReferenceTimeMax   = (1 << 24) - 1
+	tccOneBitSymbolCount  = 14
+	tccTwoBitSymbolCount  = 7
+	tccSmallDeltaLength   = 1
+	tccLargeDeltaLength   = 2
+	tccSmallDeltaMax      = 0xff
+	tccLargeDeltaMin      = -1 << 15
+	tccLargeDeltaMax      = (1 << 15) - 1
+	tccChunkTypeShift     = 15
+	tccRunLengthSymShift  = 13
+	tccVectorSymSizeShift = 14
+)
+
+// PacketStatusChunk is a single packet status chunk of a TransportLayerCC,
+// either a RunLengthChunk or a StatusVectorChunk
+type PacketStatusChunk interface {
+	Marshal() ([]byte, error)
+	MarshalTo(buf []byte) (int, error)
+	Unmarshal(rawPacket []byte) error
+}
+
+// RunLengthChunk describes a run of packets which all share the same status
+// https://tools.ietf.org/html/draft-holmer-rmcat-transport-wide-cc-extensions-01#section-3.1.3
+type RunLengthChunk struct {
+	// Status symbol shared by every packet in the run
+	PacketStatusSymbol uint16
+
+	// Number of packets in the run, 13 bits
+	RunLength uint16
+}
+
+// Marshal encodes the RunLengthChunk in binary
+func (r RunLengthChunk) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, tccPacketChunkLength)
+	if _, err := r.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the RunLengthChunk in binary into buf, returning the
+// number of bytes written.
+func (r RunLengthChunk) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |T| S |       Run Length        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	if r.PacketStatusSymbol > TypeTCCPacketReceivedWithoutDelta || r.RunLength > tccRunLengthMax {
+		return 0, ErrInvalidPacketStatusChunk
+	}
+
+	if len(buf) < tccPacketChunkLength {
+		return 0, ErrBufferTooShort
+	}
+
+	binary.BigEndian.PutUint16(buf, r.PacketStatusSymbol<<tccRunLengthSymShift|r.RunLength)
+	return tccPacketChunkLength, nil
+}
+
+// Unmarshal decodes the RunLengthChunk from binary
+func (r *RunLengthChunk) Unmarshal(rawPacket []byte) error {
+	if len(rawPacket) < tccPacketChunkLength {
+		return ErrPacketTooShort
+	}
+
+	chunk := binary.BigEndian.Uint16(rawPacket)
+	if chunk>>tccChunkTypeShift != TypeTCCRunLengthChunk {
+		return ErrInvalidPacketStatusChunk
+	}
+
+	r.PacketStatusSymbol = chunk >> tccRunLengthSymShift & 0x3
+	r.RunLength = chunk & tccRunLengthMax
+	return nil
+}
+
+// StatusVectorChunk lists the status of up to 14 packets individually
+// https://tools.ietf.org/html/draft-holmer-rmcat-transport-wide-cc-extensions-01#section-3.1.4
+type StatusVectorChunk struct {
+	// TypeTCCSymbolSizeOneBit for 14 one bit symbols, which can only
+	// express received (small delta) or not received, or
+	// TypeTCCSymbolSizeTwoBit for 7 two bit symbols
+	SymbolSize uint16
+
+	// Status symbols in sequence number order
+	SymbolList []uint16
+}
+
+func (s StatusVectorChunk) symbolCapacity() int {
+	if s.SymbolSize == TypeTCCSymbolSizeOneBit {
+		return tccOneBitSymbolCount
+	}
+	return tccTwoBitSymbolCount
+}
+
+// Marshal encodes the StatusVectorChunk in binary
+func (s StatusVectorChunk) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, tccPacketChunkLength)
+	if _, err := s.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the StatusVectorChunk in binary into buf, returning
+// the number of bytes written.
+func (s StatusVectorChunk) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |T|S|       symbol list         |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	if s.SymbolSize > TypeTCCSymbolSizeTwoBit || len(s.SymbolList) > s.symbolCapacity() {
+		return 0, ErrInvalidPacketStatusChunk
+	}
+
+	if len(buf) < tccPacketChunkLength {
+		return 0, ErrBufferTooShort
+	}
+
+	bits := uint(1)
+	if s.SymbolSize == TypeTCCSymbolSizeTwoBit {
+		bits = 2
+	}
+
+	chunk := uint16(TypeTCCStatusVectorChunk<<tccChunkTypeShift) | s.SymbolSize<<tccVectorSymSizeShift
+	for i, symbol := range s.SymbolList {
+		if symbol >= 1<<bits {
+			return 0, ErrInvalidPacketStatusChunk
+		}
+		chunk |= symbol << (tccVectorSymSizeShift - bits*uint(i+1))
+	}
+
+	binary.BigEndian.PutUint16(buf, chunk)
+	return tccPacketChunkLength, nil
+}
+
+// Unmarshal decodes the StatusVectorChunk from binary. The SymbolList
+// always holds as many symbols as the chunk has room for.
+func (s *StatusVectorChunk) Unmarshal(rawPacket []byte) error {
+	if len(rawPacket) < tccPacketChunkLength {
+		return ErrPacketTooShort
+	}
+
+	chunk := binary.BigEndian.Uint16(rawPacket)
+	if chunk>>tccChunkTypeShift != TypeTCCStatusVectorChunk {
+		return ErrInvalidPacketStatusChunk
+	}
+
+	s.SymbolSize = chunk >> tccVectorSymSizeShift & 0x1
+
+	bits := uint(1)
+	if s.SymbolSize == TypeTCCSymbolSizeTwoBit {
+		bits = 2
+	}
+	mask := uint16(1<<bits) - 1
+
+	s.SymbolList = make([]uint16, s.symbolCapacity())
+	for i := range s.SymbolList {
+		s.SymbolList[i] = chunk >> (tccVectorSymSizeShift - bits*uint(i+1)) & mask
+	}
+	return nil
+}
+
+// RecvDelta is the arrival time of a packet relative to the previous
+// received packet, or the reference time for the first one
+type RecvDelta struct {
+	// TypeTCCPacketReceivedSmallDelta or TypeTCCPacketReceivedLargeDelta
+	Type uint16
+
+	// Delta in microseconds, truncated to a multiple of TypeTCCDeltaScaleFactor
+	Delta int64
+}
+
+// Marshal encodes the RecvDelta in binary
+func (r RecvDelta) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, r.len())
+	if _, err := r.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the RecvDelta in binary into buf, returning the
+// number of bytes written.
+func (r RecvDelta) MarshalTo(buf []byte) (int, error) {
+	delta := r.Delta / TypeTCCDeltaScaleFactor
+
+	switch r.Type {
+	case TypeTCCPacketReceivedSmallDelta:
+		if delta < 0 || delta > tccSmallDeltaMax {
+			return 0, ErrDeltaExceedLimit
+		}
+		if len(buf) < tccSmallDeltaLength {
+			return 0, ErrBufferTooShort
+		}
+		buf[0] = uint8(delta)
+		return tccSmallDeltaLength, nil
+
+	case TypeTCCPacketReceivedLargeDelta:
+		if delta < tccLargeDeltaMin || delta > tccLargeDeltaMax {
+			return 0, ErrDeltaExceedLimit
+		}
+		if len(buf) < tccLargeDeltaLength {
+			return 0, ErrBufferTooShort
+		}
+		binary.BigEndian.PutUint16(buf, uint16(int16(delta)))
+		return tccLargeDeltaLength, nil
+	}
+
+	return 0, ErrInvalidPacketStatusChunk
+}
+
+func (r RecvDelta) len() int {
+	if r.Type == TypeTCCPacketReceivedLargeDelta {
+		return tccLargeDeltaLength
+	}
+	return tccSmallDeltaLength
+}
+
+// Unmarshal decodes the RecvDelta from binary. The length of rawPacket
+// selects between a small (1 octet) and large (2 octet) delta.
+func (r *RecvDelta) Unmarshal(rawPacket []byte) error {
+	switch len(rawPacket) {
+	case tccSmallDeltaLength:
+		r.Type = TypeTCCPacketReceivedSmallDelta
+		r.Delta = int64(rawPacket[0]) * TypeTCCDeltaScaleFactor
+
+	case tccLargeDeltaLength:
+		r.Type = TypeTCCPacketReceivedLargeDelta
+		r.Delta = int64(int16(binary.BigEndian.Uint16(rawPacket))) * TypeTCCDeltaScaleFactor
+
+	default:
+		return ErrPacketTooShort
+	}
+
+	return nil
+}
+
+// TransportLayerCC is the transport-wide congestion control feedback message,
+// reporting the arrival time of packets tagged with a transport-wide sequence number
+// https://tools.ietf.org/html/draft-holmer-rmcat-transport-wide-cc-extensions-01#section-3.1
+type TransportLayerCC struct {
+	// SSRC of sender
+	SenderSSRC uint32
+
+	// SSRC of the media source
+	MediaSSRC uint32
+
+	// Transport-wide sequence number of the first packet in this feedback
+	BaseSequenceNumber uint16
+
+	// Number of packets whose status is reported in this feedback
+	PacketStatusCount uint16
+
+	// Signed 24 bit reference time, in multiples of 64ms
+	ReferenceTime uint32
+
+	// Incremented for each feedback packet sent, used to detect feedback loss
+	FbPktCount uint8
+
+	// Packet status chunks, covering at least PacketStatusCount packets
+	PacketChunks []PacketStatusChunk
+
+	// One receive delta for every packet with a small or large delta status
+	RecvDeltas []RecvDelta
+}
+
+// Marshal encodes the TransportLayerCC in binary
+func (p TransportLayerCC) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, p.len())
+	if _, err := p.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the TransportLayerCC in binary into buf, returning
+// the number of bytes written.
+func (p TransportLayerCC) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P|  FMT=15 |    PT=205     |           length              |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                     SSRC of packet sender                     |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                      SSRC of media source                     |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |      base sequence number     |      packet status count      |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                 reference time                | fb pkt. count |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |          packet chunk         |         packet chunk          |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * .                                                               .
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |         packet chunk          |  recv delta   |  recv delta   |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * .                                                               .
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |           recv delta          |  recv delta   | zero padding  |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	if p.ReferenceTime > tccReferenceTimeMax {
+		return 0, ErrInvalidReferenceTime
+	}
+
+	n := p.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := p.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody, p.SenderSSRC)
+	binary.BigEndian.PutUint32(packetBody[ssrcLength:], p.MediaSSRC)
+	binary.BigEndian.PutUint16(packetBody[tccBaseSequenceNumberOffset:], p.BaseSequenceNumber)
+	binary.BigEndian.PutUint16(packetBody[tccPacketStatusCountOffset:], p.PacketStatusCount)
+	binary.BigEndian.PutUint32(packetBody[tccReferenceTimeOffset:], p.ReferenceTime<<8|uint32(p.FbPktCount))
+
+	offset := tccPacketChunkOffset
+	for _, chunk := range p.PacketChunks {
+		written, err := chunk.MarshalTo(packetBody[offset:])
+		if err != nil {
+			return 0, err
+		}
+		offset += written
+	}
+
+	for _, delta := range p.RecvDeltas {
+		written, err := delta.MarshalTo(packetBody[offset:])
+		if err != nil {
+			return 0, err
+		}
+		offset += written
+	}
+
+	// zero padding to the next 32-bit boundary
+	for ; offset < len(packetBody); offset++ {
+		packetBody[offset] = 0
+	}
+
+	return n, nil
+}
+
+// Unmarshal decodes the TransportLayerCC from binary
+func (p *TransportLayerCC) Unmarshal(rawPacket []byte) error {
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < headerLength+tccPacketChunkOffset {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypeTransportSpecificFeedback || h.ReportCount != FormatTCC {
+		return ErrWrongType
+	}
+
+	packetBody := rawPacket[headerLength:]
+
+	p.SenderSSRC = binary.BigEndian.Uint32(packetBody)
+	p.MediaSSRC = binary.BigEndian.Uint32(packetBody[ssrcLength:])
+	p.BaseSequenceNumber = binary.BigEndian.Uint16(packetBody[tccBaseSequenceNumberOffset:])
+	p.PacketStatusCount = binary.BigEndian.Uint16(packetBody[tccPacketStatusCountOffset:])
+	p.ReferenceTime = binary.BigEndian.Uint32(packetBody[tccReferenceTimeOffset:]) >> 8
+	p.FbPktCount = packetBody[tccFbPktCountOffset]
+
+	// Walk the chunks until every packet has a status, remembering the
+	// statuses so we know how many receive deltas follow and how big they are.
+	p.PacketChunks = nil
+	statuses := make([]uint16, 0, p.PacketStatusCount)
+	offset := tccPacketChunkOffset
+	for len(statuses) < int(p.PacketStatusCount) {
+		if offset+tccPacketChunkLength > len(packetBody) {
+			return ErrPacketTooShort
+		}
+
+		remaining := int(p.PacketStatusCount) - len(statuses)
+		chunkType := binary.BigEndian.Uint16(packetBody[offset:]) >> tccChunkTypeShift
+		switch chunkType {
+		case TypeTCCRunLengthChunk:
+			chunk := &RunLengthChunk{}
+			if err := chunk.Unmarshal(packetBody[offset:]); err != nil {
+				return err
+			}
+			for i := 0; i < int(chunk.RunLength) && i < remaining; i++ {
+				statuses = append(statuses, chunk.PacketStatusSymbol)
+			}
+			p.PacketChunks = append(p.PacketChunks, chunk)
+
+		case TypeTCCStatusVectorChunk:
+			chunk := &StatusVectorChunk{}
+			if err := chunk.Unmarshal(packetBody[offset:]); err != nil {
+				return err
+			}
+			for i := 0; i < len(chunk.SymbolList) && i < remaining; i++ {
+				statuses = append(statuses, chunk.SymbolList[i])
+			}
+			p.PacketChunks = append(p.PacketChunks, chunk)
+		}
+
+		// A run length of zero would never make progress
+		if remaining == int(p.PacketStatusCount)-len(statuses) {
+			return ErrInvalidPacketStatusChunk
+		}
+
+		offset += tccPacketChunkLength
+	}
+
+	p.RecvDeltas = nil
+	for _, status := range statuses {
+		var deltaLength int
+		switch status {
+		case TypeTCCPacketReceivedSmallDelta:
+			deltaLength = tccSmallDeltaLength
+		case TypeTCCPacketReceivedLargeDelta:
+			deltaLength = tccLargeDeltaLength
+		default:
+			continue
+		}
+
+		if offset+deltaLength > len(packetBody) {
+			return ErrPacketTooShort
+		}
+
+		var delta RecvDelta
+		if err := delta.Unmarshal(packetBody[offset : offset+deltaLength]); err != nil {
+			return err
+		}
+		p.RecvDeltas = append(p.RecvDeltas, delta)
+		offset += deltaLength
+	}
+
+	return nil
+}
+
+func (p TransportLayerCC) len() int {
+	n := headerLength + tccPacketChunkOffset + len(p.PacketChunks)*tccPacketChunkLength
+	for _, delta := range p.RecvDeltas {
+		n += delta.len()
+	}
+
+	// Padded to a 32-bit boundary
+	return n + getPadding(n)
+}
+
+// Header returns the Header associated with this packet.
+func (p TransportLayerCC) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: FormatTCC,
+		Type:        TypeTransportSpecificFeedback,
+		Length:      uint16((p.len() / 4) - 1),
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (p TransportLayerCC) DestinationSSRC() []uint32 {
+	return []uint32{p.MediaSSRC}
+}
+
+func (r RunLengthChunk) String() string {
+	return fmt.Sprintf("RunLengthChunk: symbol %d x %d", r.PacketStatusSymbol, r.RunLength)
+}
+
+func (s StatusVectorChunk) String() string {
+	return fmt.Sprintf("StatusVectorChunk: %v", s.SymbolList)
+}
+
+func (r RecvDelta) String() string {
+	return fmt.Sprintf("RecvDelta: type %d, %dus", r.Type, r.Delta)
+}
+
+func (p TransportLayerCC) String() string {
+	out := fmt.Sprintf("TransportLayerCC from %x for %x\n", p.SenderSSRC, p.MediaSSRC)
+	out += fmt.Sprintf("\tBaseSequenceNumber: %d\n", p.BaseSequenceNumber)
+	out += fmt.Sprintf("\tPacketStatusCount: %d\n", p.PacketStatusCount)
+	out += fmt.Sprintf("\tReferenceTime: %d\n", p.ReferenceTime)
+	out += fmt.Sprintf("\tFbPktCount: %d\n", p.FbPktCount)
+	for _, c := range p.PacketChunks {
+		out += fmt.Sprintf("\t%v\n", c)
+	}
+	for _, d := range p.RecvDeltas {
+		out += fmt.Sprintf("\t%v\n", d)
+	}
+	return out
+}
@@ -0,0 +1,277 @@
+package rtcp
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTransportLayerCCUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      TransportLayerCC
+		WantError error
+	}{
+		{
+			Name: "run length chunk",
+			Data: []byte{
+				// v=2, p=0, FMT=15, RTPFB, len=6
+				0x8f, 0xcd, 0x00, 0x06,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// base sequence number=0x153, packet status count=3
+				0x01, 0x53, 0x00, 0x03,
+				// reference time=0x1ab4c9, fb pkt count=5
+				0x1a, 0xb4, 0xc9, 0x05,
+				// run length, small delta, length=3
+				0x20, 0x03,
+				// recv deltas=1000us, 0us, 2000us
+				0x04, 0x00, 0x08,
+				// padding
+				0x00, 0x00, 0x00,
+			},
+			Want: TransportLayerCC{
+				SenderSSRC:         1,
+				MediaSSRC:          0x4bc4fcb4,
+				BaseSequenceNumber: 0x153,
+				PacketStatusCount:  3,
+				ReferenceTime:      0x1ab4c9,
+				FbPktCount:         5,
+				PacketChunks: []PacketStatusChunk{
+					&RunLengthChunk{
+						PacketStatusSymbol: TypeTCCPacketReceivedSmallDelta,
+						RunLength:          3,
+					},
+				},
+				RecvDeltas: []RecvDelta{
+					{Type: TypeTCCPacketReceivedSmallDelta, Delta: 1000},
+					{Type: TypeTCCPacketReceivedSmallDelta, Delta: 0},
+					{Type: TypeTCCPacketReceivedSmallDelta, Delta: 2000},
+				},
+			},
+		},
+		{
+			Name: "two bit status vector chunk",
+			Data: []byte{
+				// v=2, p=0, FMT=15, RTPFB, len=6
+				0x8f, 0xcd, 0x00, 0x06,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// base sequence number=0xa, packet status count=3
+				0x00, 0x0a, 0x00, 0x03,
+				// reference time=0x1, fb pkt count=0
+				0x00, 0x00, 0x01, 0x00,
+				// status vector, two bit, [small, not received, large]
+				0xd2, 0x00,
+				// recv deltas=250us, -500us
+				0x01, 0xff, 0xfe,
+				// padding
+				0x00, 0x00, 0x00,
+			},
+			Want: TransportLayerCC{
+				SenderSSRC:         1,
+				MediaSSRC:          0x4bc4fcb4,
+				BaseSequenceNumber: 0xa,
+				PacketStatusCount:  3,
+				ReferenceTime:      1,
+				PacketChunks: []PacketStatusChunk{
+					&StatusVectorChunk{
+						SymbolSize: TypeTCCSymbolSizeTwoBit,
+						SymbolList: []uint16{1, 0, 2, 0, 0, 0, 0},
+					},
+				},
+				RecvDeltas: []RecvDelta{
+					{Type: TypeTCCPacketReceivedSmallDelta, Delta: 250},
+					{Type: TypeTCCPacketReceivedLargeDelta, Delta: -500},
+				},
+			},
+		},
+		{
+			Name: "one bit status vector chunk",
+			Data: []byte{
+				// v=2, p=0, FMT=15, RTPFB, len=6
+				0x8f, 0xcd, 0x00, 0x06,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// base sequence number=0xfffe, packet status count=5
+				0xff, 0xfe, 0x00, 0x05,
+				// reference time=0x1, fb pkt count=1
+				0x00, 0x00, 0x01, 0x01,
+				// status vector, one bit, [1, 1, 0, 0, 1]
+				0xb2, 0x00,
+				// recv deltas=1000us, 1000us, 1000us
+				0x04, 0x04, 0x04,
+				// padding
+				0x00, 0x00, 0x00,
+			},
+			Want: TransportLayerCC{
+				SenderSSRC:         1,
+				MediaSSRC:          0x4bc4fcb4,
+				BaseSequenceNumber: 0xfffe,
+				PacketStatusCount:  5,
+				ReferenceTime:      1,
+				FbPktCount:         1,
+				PacketChunks: []PacketStatusChunk{
+					&StatusVectorChunk{
+						SymbolSize: TypeTCCSymbolSizeOneBit,
+						SymbolList: []uint16{1, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+					},
+				},
+				RecvDeltas: []RecvDelta{
+					{Type: TypeTCCPacketReceivedSmallDelta, Delta: 1000},
+					{Type: TypeTCCPacketReceivedSmallDelta, Delta: 1000},
+					{Type: TypeTCCPacketReceivedSmallDelta, Delta: 1000},
+				},
+			},
+		},
+		{
+			Name: "missing recv delta",
+			Data: []byte{
+				// v=2, p=0, FMT=15, RTPFB, len=5
+				0x8f, 0xcd, 0x00, 0x05,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// base sequence number=0x153, packet status count=3
+				0x01, 0x53, 0x00, 0x03,
+				// reference time=0x1ab4c9, fb pkt count=5
+				0x1a, 0xb4, 0xc9, 0x05,
+				// run length, small delta, length=3
+				0x20, 0x03,
+				// recv deltas=1000us, 0us
+				0x04, 0x00,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "zero run length",
+			Data: []byte{
+				// v=2, p=0, FMT=15, RTPFB, len=5
+				0x8f, 0xcd, 0x00, 0x05,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// base sequence number=0x153, packet status count=1
+				0x01, 0x53, 0x00, 0x01,
+				// reference time=0x1ab4c9, fb pkt count=5
+				0x1a, 0xb4, 0xc9, 0x05,
+				// run length, not received, length=0
+				0x00, 0x00,
+				// padding
+				0x00, 0x00,
+			},
+			WantError: ErrInvalidPacketStatusChunk,
+		},
+		{
+			Name: "wrong format",
+			Data: []byte{
+				// v=2, p=0, FMT=1, RTPFB, len=4
+				0x81, 0xcd, 0x00, 0x04,
+				// ssrc=0x1
+				0x00, 0x00, 0x00, 0x01,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// base sequence number=0x153, packet status count=0
+				0x01, 0x53, 0x00, 0x00,
+				// reference time=0x1ab4c9, fb pkt count=5
+				0x1a, 0xb4, 0xc9, 0x05,
+			},
+			WantError: ErrWrongType,
+		},
+	} {
+		var tcc TransportLayerCC
+		err := tcc.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q tcc: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := tcc, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q tcc: got %#v, want %#v", test.Name, got, want)
+		}
+
+		data, err := test.Want.Marshal()
+		if err != nil {
+			t.Errorf("Marshal %q tcc: %v", test.Name, err)
+		}
+		if got, want := data, test.Data; !bytes.Equal(got, want) {
+			t.Errorf("Marshal %q tcc: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestTransportLayerCCMarshalErrors(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Packet    TransportLayerCC
+		WantError error
+	}{
+		{
+			Name: "small delta too large",
+			Packet: TransportLayerCC{
+				PacketStatusCount: 1,
+				PacketChunks:      []PacketStatusChunk{&RunLengthChunk{PacketStatusSymbol: TypeTCCPacketReceivedSmallDelta, RunLength: 1}},
+				RecvDeltas:        []RecvDelta{{Type: TypeTCCPacketReceivedSmallDelta, Delta: 256 * TypeTCCDeltaScaleFactor}},
+			},
+			WantError: ErrDeltaExceedLimit,
+		},
+		{
+			Name: "negative small delta",
+			Packet: TransportLayerCC{
+				PacketStatusCount: 1,
+				PacketChunks:      []PacketStatusChunk{&RunLengthChunk{PacketStatusSymbol: TypeTCCPacketReceivedSmallDelta, RunLength: 1}},
+				RecvDeltas:        []RecvDelta{{Type: TypeTCCPacketReceivedSmallDelta, Delta: -TypeTCCDeltaScaleFactor}},
+			},
+			WantError: ErrDeltaExceedLimit,
+		},
+		{
+			Name: "run length too large",
+			Packet: TransportLayerCC{
+				PacketChunks: []PacketStatusChunk{&RunLengthChunk{RunLength: 1 << 13}},
+			},
+			WantError: ErrInvalidPacketStatusChunk,
+		},
+		{
+			Name: "too many two bit symbols",
+			Packet: TransportLayerCC{
+				PacketChunks: []PacketStatusChunk{&StatusVectorChunk{
+					SymbolSize: TypeTCCSymbolSizeTwoBit,
+					SymbolList: make([]uint16, 8),
+				}},
+			},
+			WantError: ErrInvalidPacketStatusChunk,
+		},
+		{
+			Name: "two bit symbol in one bit vector",
+			Packet: TransportLayerCC{
+				PacketChunks: []PacketStatusChunk{&StatusVectorChunk{
+					SymbolSize: TypeTCCSymbolSizeOneBit,
+					SymbolList: []uint16{TypeTCCPacketReceivedLargeDelta},
+				}},
+			},
+			WantError: ErrInvalidPacketStatusChunk,
+		},
+		{
+			Name: "reference time too large",
+			Packet: TransportLayerCC{
+				ReferenceTime: 1 << 24,
+			},
+			WantError: ErrInvalidReferenceTime,
+		},
+	} {
+		if _, err := test.Packet.Marshal(); err != test.WantError {
+			t.Errorf("Marshal %q: err = %v, want %v", test.Name, err, test.WantError)
+		}
+	}
+}
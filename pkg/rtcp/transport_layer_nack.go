@@ -0,0 +1,189 @@
+package rtcp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// PacketBitmap shouldn't be used like a normal integral,
+// so it's type is masked here. Access it with PacketList().
+type PacketBitmap uint16
+
+// NackPair is a wire-representation of a collection of
+// Lost RTP packets
+type NackPair struct {
+	// ID of lost packets
+	PacketID uint16
+
+	// Bitmask of following lost packets
+	LostPackets PacketBitmap
+}
+
+// The TransportLayerNack packet informs the encoder about the loss of a transport packet
+// IETF RFC 4585, Section 6.2.1
+// https://tools.ietf.org/html/rfc4585#section-6.2.1
+type TransportLayerNack struct {
+	// SSRC of sender
+	SenderSSRC uint32
+
+	// SSRC of the media source
+	MediaSSRC uint32
+
+	Nacks []NackPair
+}
+
+const (
+	nackOffset = 8
+	nackLength = 4
+)
+
+// NackPairsFromSequenceNumbers generates a slice of NackPair from a list of
+// lost sequence numbers. The sequence numbers are expected in ascending
+// order, wrapping around at 65535.
+func NackPairsFromSequenceNumbers(sequenceNumbers []uint16) []NackPair {
+	pairs := []NackPair{}
+	if len(sequenceNumbers) == 0 {
+		return pairs
+	}
+
+	nackPair := NackPair{PacketID: sequenceNumbers[0]}
+	for _, m := range sequenceNumbers[1:] {
+		diff := m - nackPair.PacketID
+		if diff == 0 {
+			continue
+		}
+
+		if diff > 16 {
+			pairs = append(pairs, nackPair)
+			nackPair = NackPair{PacketID: m}
+			continue
+		}
+
+		nackPair.LostPackets |= 1 << (diff - 1)
+	}
+
+	return append(pairs, nackPair)
+}
+
+// PacketList returns a list of Nack'd packets that's referenced by a NackPair
+func (n NackPair) PacketList() []uint16 {
+	out := []uint16{n.PacketID}
+
+	b := n.LostPackets
+	for i := uint16(0); b != 0; i++ {
+		if (b & (1 << i)) != 0 {
+			b &^= (1 << i)
+			out = append(out, n.PacketID+i+1)
+		}
+	}
+
+	return out
+}
+
+// Marshal encodes the TransportLayerNack in binary
+func (p TransportLayerNack) Marshal() ([]byte, error) {
+	rawPacket := make([]byte, p.len())
+	if _, err := p.MarshalTo(rawPacket); err != nil {
+		return nil, err
+	}
+	return rawPacket, nil
+}
+
+// MarshalTo encodes the TransportLayerNack in binary into buf, returning
+// the number of bytes written.
+func (p TransportLayerNack) MarshalTo(buf []byte) (int, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |V=2|P| FMT=1   |   PT=205      |          length               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                  SSRC of packet sender                        |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                  SSRC of media source                         |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |            PID                |             BLP               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * :                              ...                              :
+	 */
+
+	n := p.len()
+	if len(buf) < n {
+		return 0, ErrBufferTooShort
+	}
+
+	if _, err := p.Header().MarshalTo(buf); err != nil {
+		return 0, err
+	}
+
+	packetBody := buf[headerLength:n]
+	binary.BigEndian.PutUint32(packetBody, p.SenderSSRC)
+	binary.BigEndian.PutUint32(packetBody[4:], p.MediaSSRC)
+	for i := 0; i < len(p.Nacks); i++ {
+		binary.BigEndian.PutUint16(packetBody[nackOffset+(nackLength*i):], p.Nacks[i].PacketID)
+		binary.BigEndian.PutUint16(packetBody[nackOffset+(nackLength*i)+2:], uint16(p.Nacks[i].LostPackets))
+	}
+
+	return n, nil
+}
+
+// Unmarshal decodes the TransportLayerNack from binary
+func (p *TransportLayerNack) Unmarshal(rawPacket []byte) error {
+	rawPacket, err := trimToLength(rawPacket)
+	if err != nil {
+		return err
+	}
+
+	if len(rawPacket) < (headerLength + ssrcLength*2) {
+		return ErrPacketTooShort
+	}
+
+	var h Header
+	if err := h.Unmarshal(rawPacket); err != nil {
+		return err
+	}
+
+	if h.Type != TypeTransportSpecificFeedback || h.ReportCount != FormatTLN {
+		return ErrWrongType
+	}
+
+	p.SenderSSRC = binary.BigEndian.Uint32(rawPacket[headerLength:])
+	p.MediaSSRC = binary.BigEndian.Uint32(rawPacket[headerLength+ssrcLength:])
+
+	p.Nacks = nil
+	for i := headerLength + nackOffset; i+nackLength <= len(rawPacket); i += nackLength {
+		p.Nacks = append(p.Nacks, NackPair{
+			PacketID:    binary.BigEndian.Uint16(rawPacket[i:]),
+			LostPackets: PacketBitmap(binary.BigEndian.Uint16(rawPacket[i+2:])),
+		})
+	}
+
+	return nil
+}
+
+func (p TransportLayerNack) len() int {
+	return headerLength + nackOffset + (len(p.Nacks) * nackLength)
+}
+
+// Header returns the Header associated with this packet.
+func (p TransportLayerNack) Header() Header {
+	return Header{
+		Version:     rtpVersion,
+		ReportCount: FormatTLN,
+		Type:        TypeTransportSpecificFeedback,
+		Length:      uint16((p.len() / 4) - 1),
+	}
+}
+
+// DestinationSSRC returns an array of SSRC values that this packet refers to.
+func (p TransportLayerNack) DestinationSSRC() []uint32 {
+	return []uint32{p.MediaSSRC}
+}
+
+func (p TransportLayerNack) String() string {
+	out := fmt.Sprintf("TransportLayerNack from %x for %x\n", p.SenderSSRC, p.MediaSSRC)
+	for _, n := range p.Nacks {
+		out += fmt.Sprintf("\tPacketID: %d, LostPackets: %016b\n", n.PacketID, n.LostPackets)
+	}
+	return out
+}
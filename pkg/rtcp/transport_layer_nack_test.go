@@ -0,0 +1,164 @@
+package rtcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTransportLayerNackUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      TransportLayerNack
+		WantError error
+	}{
+		{
+			Name: "valid",
+			Data: []byte{
+				// v=2, p=0, FMT=1, RTPFB, len=3
+				0x81, 0xcd, 0x0, 0x3,
+				// ssrc=0x0
+				0x0, 0x0, 0x0, 0x0,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+				// pid=0xaaf, blp=0x0
+				0xa, 0xaf, 0x0, 0x0,
+			},
+			Want: TransportLayerNack{
+				SenderSSRC: 0,
+				MediaSSRC:  0x4bc4fcb4,
+				Nacks:      []NackPair{{0xaaf, 0}},
+			},
+		},
+		{
+			Name: "short report",
+			Data: []byte{
+				// v=2, p=0, FMT=1, RTPFB, len=2
+				0x81, 0xcd, 0x0, 0x2,
+				// ssrc=0x0
+				0x0, 0x0, 0x0, 0x0,
+			},
+			WantError: ErrPacketTooShort,
+		},
+		{
+			Name: "wrong type",
+			Data: []byte{
+				// v=2, p=0, FMT=1, RR, len=2
+				0x81, 0xc9, 0x0, 0x2,
+				// ssrc=0x0
+				0x0, 0x0, 0x0, 0x0,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+			},
+			WantError: ErrWrongType,
+		},
+		{
+			Name: "wrong format",
+			Data: []byte{
+				// v=2, p=0, FMT=3, RTPFB, len=2
+				0x83, 0xcd, 0x0, 0x2,
+				// ssrc=0x0
+				0x0, 0x0, 0x0, 0x0,
+				// ssrc=0x4bc4fcb4
+				0x4b, 0xc4, 0xfc, 0xb4,
+			},
+			WantError: ErrWrongType,
+		},
+	} {
+		var tln TransportLayerNack
+		err := tln.Unmarshal(test.Data)
+		if got, want := err, test.WantError; got != want {
+			t.Errorf("Unmarshal %q tln: err = %v, want %v", test.Name, got, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := tln, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q tln: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestTransportLayerNackRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Report TransportLayerNack
+	}{
+		{
+			Name: "valid",
+			Report: TransportLayerNack{
+				SenderSSRC: 0x902f9e2e,
+				MediaSSRC:  0x902f9e2e,
+				Nacks:      []NackPair{{1, 0xAA}, {1034, 0x05}},
+			},
+		},
+	} {
+		data, err := test.Report.Marshal()
+		if err != nil {
+			t.Fatalf("Marshal %q: %v", test.Name, err)
+		}
+
+		var decoded TransportLayerNack
+		if err := decoded.Unmarshal(data); err != nil {
+			t.Fatalf("Unmarshal %q: %v", test.Name, err)
+		}
+
+		if got, want := decoded, test.Report; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q tln round trip: got %#v, want %#v", test.Name, got, want)
+		}
+	}
+}
+
+func TestNackPair(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Pair NackPair
+		Want []uint16
+	}{
+		{"single", NackPair{42, 0}, []uint16{42}},
+		{"first bit", NackPair{42, 1}, []uint16{42, 43}},
+		{"last bit", NackPair{42, 1 << 15}, []uint16{42, 58}},
+		{"wrap around", NackPair{65534, 0x3}, []uint16{65534, 65535, 0}},
+	} {
+		if got := test.Pair.PacketList(); !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("PacketList %q: got %v, want %v", test.Name, got, test.Want)
+		}
+	}
+}
+
+func TestNackPairsFromSequenceNumbers(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Seqs []uint16
+		Want []NackPair
+	}{
+		{"empty", []uint16{}, []NackPair{}},
+		{"single", []uint16{42}, []NackPair{{42, 0}}},
+		{"within bitmask", []uint16{42, 43, 58}, []NackPair{{42, 0x8001}}},
+		{"spills over", []uint16{42, 59, 60}, []NackPair{{42, 0}, {59, 1}}},
+		{"duplicates", []uint16{42, 42, 43}, []NackPair{{42, 1}}},
+		{"wrap around", []uint16{65535, 0, 1}, []NackPair{{65535, 0x3}}},
+	} {
+		got := NackPairsFromSequenceNumbers(test.Seqs)
+		if !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("NackPairsFromSequenceNumbers %q: got %v, want %v", test.Name, got, test.Want)
+		}
+
+		var roundTrip []uint16
+		for _, p := range got {
+			roundTrip = append(roundTrip, p.PacketList()...)
+		}
+		for _, s := range test.Seqs {
+			found := false
+			for _, r := range roundTrip {
+				if r == s {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("NackPairsFromSequenceNumbers %q: sequence %d lost in round trip", test.Name, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package rtp
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// AbsSendTimeURI identifies the abs-send-time header extension in SDP
+	AbsSendTimeURI = "http://www.webrtc.org/experiments/rtp-hdrext/abs-send-time"
+
+	absSendTimeExtensionSize = 3
+)
+
+// AbsSendTimeExtension is the absolute send time header extension, used by
+// receivers to estimate the available bandwidth. The time is a 24 bit, 6.18
+// fixed point number of seconds, wrapping every 64 seconds.
+type AbsSendTimeExtension struct {
+	Timestamp uint32
+}
+
+// NewAbsSendTimeExtension creates an AbsSendTimeExtension for a packet
+// sent at sendTime
+func NewAbsSendTimeExtension(sendTime time.Time) *AbsSendTimeExtension {
+	// The NTP time in 1/2^18 seconds, keeping only the low 6 bits of the
+	// seconds
+	ntp := toNTPTime(sendTime)
+	return &AbsSendTimeExtension{
+		Timestamp: uint32(ntp>>14) & 0xFFFFFF,
+	}
+}
+
+// Marshal encodes the extension payload
+func (a *AbsSendTimeExtension) Marshal() ([]byte, error) {
+	if a.Timestamp > 0xFFFFFF {
+		return nil, errors.Errorf("abs-send-time %#x is larger than 24 bits", a.Timestamp)
+	}
+
+	return []byte{
+		byte(a.Timestamp >> 16),
+		byte(a.Timestamp >> 8),
+		byte(a.Timestamp),
+	}, nil
+}
+
+// Unmarshal decodes the extension payload
+func (a *AbsSendTimeExtension) Unmarshal(rawData []byte) error {
+	if len(rawData) < absSendTimeExtensionSize {
+		return errors.Errorf("abs-send-time size insufficient; %d < %d", len(rawData), absSendTimeExtensionSize)
+	}
+
+	a.Timestamp = uint32(rawData[0])<<16 | uint32(rawData[1])<<8 | uint32(rawData[2])
+	return nil
+}
+
+// Estimate returns the send time of the packet, assuming it was sent less
+// than 64 seconds before receiveTime
+func (a *AbsSendTimeExtension) Estimate(receiveTime time.Time) time.Time {
+	receiveNTP := toNTPTime(receiveTime)
+	sendNTP := receiveNTP&^(1<<38-1) | uint64(a.Timestamp)<<14
+	if sendNTP > receiveNTP {
+		// The 6 bit seconds wrapped between sending and receiving
+		sendNTP -= 1 << 38
+	}
+	return fromNTPTime(sendNTP)
+}
+
+// ntpEpochOffset is the number of seconds between 1900, the NTP epoch,
+// and 1970, the Unix epoch
+const ntpEpochOffset = 2208988800
+
+func toNTPTime(t time.Time) uint64 {
+	seconds := uint64(t.Unix()) + ntpEpochOffset
+	fraction := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
+func fromNTPTime(ntp uint64) time.Time {
+	seconds := int64(ntp>>32) - ntpEpochOffset
+	nanoseconds := int64((ntp & 0xFFFFFFFF) * uint64(time.Second) >> 32)
+	return time.Unix(seconds, nanoseconds)
+}
@@ -0,0 +1,61 @@
+package rtp
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestAbsSendTimeExtension(t *testing.T) {
+	raw := []byte{0x12, 0x34, 0x56}
+
+	a := &AbsSendTimeExtension{}
+	if err := a.Unmarshal(raw); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if got, want := a.Timestamp, uint32(0x123456); got != want {
+		t.Errorf("Timestamp = %#x, want %#x", got, want)
+	}
+
+	if out, err := a.Marshal(); err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	} else if !bytes.Equal(out, raw) {
+		t.Errorf("Marshal = %#v, want %#v", out, raw)
+	}
+
+	if err := a.Unmarshal(raw[:2]); err == nil {
+		t.Error("Unmarshal accepted a short payload")
+	}
+	if _, err := (&AbsSendTimeExtension{Timestamp: 0x1000000}).Marshal(); err == nil {
+		t.Error("Marshal accepted a timestamp over 24 bits")
+	}
+}
+
+func TestAbsSendTimeExtensionEstimate(t *testing.T) {
+	for _, test := range []struct {
+		Name    string
+		Send    time.Time
+		Receive time.Time
+	}{
+		{
+			Name:    "no wrap",
+			Send:    time.Unix(1500000010, 125000000),
+			Receive: time.Unix(1500000011, 0),
+		},
+		{
+			// 1500000000 is a multiple of 64 seconds in NTP time, so the
+			// seconds wrap there
+			Name:    "wrap",
+			Send:    time.Unix(1499999999, 500000000),
+			Receive: time.Unix(1500000001, 0),
+		},
+	} {
+		a := NewAbsSendTimeExtension(test.Send)
+		estimate := a.Estimate(test.Receive)
+
+		// The timestamp resolution is about 4 microseconds
+		if diff := estimate.Sub(test.Send); diff < -10*time.Microsecond || diff > 10*time.Microsecond {
+			t.Errorf("%q: Estimate = %v, want %v", test.Name, estimate, test.Send)
+		}
+	}
+}
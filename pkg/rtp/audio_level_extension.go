@@ -0,0 +1,54 @@
+package rtp
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	// AudioLevelURI identifies the client-to-mixer audio level header
+	// extension in SDP, RFC 6464
+	AudioLevelURI = "urn:ietf:params:rtp-hdrext:ssrc-audio-level"
+
+	audioLevelExtensionSize = 1
+	audioLevelMax           = 127
+)
+
+// AudioLevelExtension is the client-to-mixer audio level header extension,
+// RFC 6464. Level is the audio level in -dBov, from 0 (loudest) to 127
+// (silence), and Voice reports whether the packet is believed to hold
+// speech.
+type AudioLevelExtension struct {
+	Level uint8
+	Voice bool
+}
+
+// Marshal encodes the extension payload
+func (a *AudioLevelExtension) Marshal() ([]byte, error) {
+	/*
+	 *  0                   1
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |  ID   | len=0 |V| level       |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+	if a.Level > audioLevelMax {
+		return nil, errors.Errorf("audio level %d out of range; > %d", a.Level, audioLevelMax)
+	}
+
+	buf := []byte{a.Level}
+	if a.Voice {
+		buf[0] |= 0x80
+	}
+	return buf, nil
+}
+
+// Unmarshal decodes the extension payload
+func (a *AudioLevelExtension) Unmarshal(rawData []byte) error {
+	if len(rawData) < audioLevelExtensionSize {
+		return errors.Errorf("audio level size insufficient; %d < %d", len(rawData), audioLevelExtensionSize)
+	}
+
+	a.Level = rawData[0] & audioLevelMax
+	a.Voice = rawData[0]&0x80 != 0
+	return nil
+}
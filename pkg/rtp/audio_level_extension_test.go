@@ -0,0 +1,36 @@
+package rtp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAudioLevelExtension(t *testing.T) {
+	for _, test := range []struct {
+		Raw  []byte
+		Want AudioLevelExtension
+	}{
+		{[]byte{0x88}, AudioLevelExtension{Level: 8, Voice: true}},
+		{[]byte{0x7f}, AudioLevelExtension{Level: 127}},
+	} {
+		var a AudioLevelExtension
+		if err := a.Unmarshal(test.Raw); err != nil {
+			t.Fatalf("Unmarshal error: %v", err)
+		} else if a != test.Want {
+			t.Errorf("Unmarshal(%#v) = %#v, want %#v", test.Raw, a, test.Want)
+		}
+
+		if out, err := a.Marshal(); err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		} else if !bytes.Equal(out, test.Raw) {
+			t.Errorf("Marshal = %#v, want %#v", out, test.Raw)
+		}
+	}
+
+	if err := (&AudioLevelExtension{}).Unmarshal(nil); err == nil {
+		t.Error("Unmarshal accepted an empty payload")
+	}
+	if _, err := (&AudioLevelExtension{Level: 128}).Marshal(); err == nil {
+		t.Error("Marshal accepted a level over 127")
+	}
+}
@@ -0,0 +1,313 @@
+package codecs
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// AV1Payloader payloads AV1 packets
+type AV1Payloader struct{}
+
+const (
+	av1AggregationHeaderSize = 1
+
+	av1ZMask     = 0x80
+	av1YMask     = 0x40
+	av1WMask     = 0x30
+	av1WShift    = 4
+	av1NMask     = 0x08
+	av1MaxLeb128 = 8
+
+	obuTypeMask           = 0x78
+	obuTypeShift          = 3
+	obuExtensionFlagMask  = 0x04
+	obuHasSizeFieldMask   = 0x02
+	obuTypeSequenceHeader = 1
+	obuTypeTemporalDelim  = 2
+	obuTypeTileList       = 8
+)
+
+var errAV1MalformedOBU = errors.New("malformed AV1 OBU")
+
+// Payload fragments an AV1 temporal unit across one or more byte arrays.
+// payload holds OBUs in the low overhead bitstream format. Temporal
+// delimiters and tile lists are dropped and the remaining OBUs are sent
+// without their size fields, each element prefixed with its length.
+func (p *AV1Payloader) Payload(mtu int, payload []byte) [][]byte {
+	/*
+	 * https://aomediacodec.github.io/av1-rtp-spec/#44-av1-aggregation-header
+	 *
+	 *  0 1 2 3 4 5 6 7
+	 * +-+-+-+-+-+-+-+-+
+	 * |Z|Y| W |N|-|-|-|
+	 * +-+-+-+-+-+-+-+-+
+	 *
+	 * Z: the first OBU element continues an OBU fragment from the previous
+	 *    packet
+	 * Y: the last OBU element will continue in the next packet
+	 * W: the number of OBU elements, or 0 if every element has a length
+	 * N: the packet is the first of a coded video sequence
+	 */
+
+	obus, newSequence, err := av1SplitOBUs(payload)
+	if err != nil || len(obus) == 0 || mtu <= av1AggregationHeaderSize+1 {
+		return nil
+	}
+
+	var payloads [][]byte
+	current := []byte{0}
+	continuation := false
+
+	flush := func(continues bool) {
+		if continuation {
+			current[0] |= av1ZMask
+		}
+		if continues {
+			current[0] |= av1YMask
+		}
+		if newSequence && len(payloads) == 0 {
+			current[0] |= av1NMask
+		}
+		payloads = append(payloads, current)
+		current = []byte{0}
+		continuation = continues
+	}
+
+	for _, obu := range obus {
+		for len(obu) > 0 {
+			space := mtu - len(current)
+			size := space - 1
+			for size > 0 && len(leb128Encode(uint(size)))+size > space {
+				size--
+			}
+			if size <= 0 {
+				flush(false)
+				continue
+			}
+			if size > len(obu) {
+				size = len(obu)
+			}
+
+			current = append(current, leb128Encode(uint(size))...)
+			current = append(current, obu[:size]...)
+			obu = obu[size:]
+
+			if len(obu) > 0 {
+				flush(true)
+			}
+		}
+	}
+	if len(current) > av1AggregationHeaderSize {
+		flush(false)
+	}
+
+	return payloads
+}
+
+// av1SplitOBUs splits a low overhead bitstream into OBUs with their size
+// fields removed, dropping those that must not be sent over RTP. It also
+// reports whether a sequence header is present.
+func av1SplitOBUs(payload []byte) ([][]byte, bool, error) {
+	var obus [][]byte
+	newSequence := false
+
+	for len(payload) > 0 {
+		header := payload[0]
+		headerSize := 1
+		if header&obuExtensionFlagMask != 0 {
+			headerSize++
+		}
+		if len(payload) < headerSize {
+			return nil, false, errAV1MalformedOBU
+		}
+
+		size := len(payload) - headerSize
+		sizeFieldLength := 0
+		if header&obuHasSizeFieldMask != 0 {
+			obuSize, n, err := leb128Decode(payload[headerSize:])
+			if err != nil {
+				return nil, false, err
+			}
+			size = int(obuSize)
+			sizeFieldLength = n
+		}
+
+		end := headerSize + sizeFieldLength + size
+		if size < 0 || end > len(payload) {
+			return nil, false, errAV1MalformedOBU
+		}
+
+		switch obuType := (header & obuTypeMask) >> obuTypeShift; obuType {
+		case obuTypeTemporalDelim, obuTypeTileList:
+		default:
+			if obuType == obuTypeSequenceHeader {
+				newSequence = true
+			}
+
+			obu := make([]byte, 0, headerSize+size)
+			obu = append(obu, header&^obuHasSizeFieldMask)
+			obu = append(obu, payload[1:headerSize]...)
+			obu = append(obu, payload[headerSize+sizeFieldLength:end]...)
+			obus = append(obus, obu)
+		}
+
+		payload = payload[end:]
+	}
+
+	return obus, newSequence, nil
+}
+
+// AV1Packet represents the AV1 aggregation header that is stored in the payload of an RTP Packet
+type AV1Packet struct {
+	Z bool  /* first OBU element is a continuation */
+	Y bool  /* last OBU element continues in the next packet */
+	W uint8 /* number of OBU elements, 0 if each has a length */
+	N bool  /* first packet of a coded video sequence */
+
+	// OBUElements are the OBU elements of this packet, which may be OBU
+	// fragments
+	OBUElements [][]byte
+
+	fragment []byte
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the AV1Packet this method is called upon.
+// The complete OBUs the packet holds are returned in the low overhead
+// bitstream format, with their size fields set. An OBU fragmented across
+// packets is returned with the packet holding its last fragment, and is
+// dropped if a fragment was lost.
+func (p *AV1Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	if packet == nil {
+		return nil, errNilPacket
+	}
+
+	payload := packet.Payload
+	if len(payload) < av1AggregationHeaderSize+1 {
+		return nil, errShortPacket
+	}
+
+	p.Z = payload[0]&av1ZMask != 0
+	p.Y = payload[0]&av1YMask != 0
+	p.W = (payload[0] & av1WMask) >> av1WShift
+	p.N = payload[0]&av1NMask != 0
+	p.OBUElements = nil
+
+	for currOffset := av1AggregationHeaderSize; currOffset < len(payload); {
+		elementSize := len(payload) - currOffset
+		if p.W == 0 || len(p.OBUElements) < int(p.W)-1 {
+			size, n, err := leb128Decode(payload[currOffset:])
+			if err != nil {
+				return nil, err
+			}
+			currOffset += n
+			elementSize = int(size)
+			if elementSize < 0 || currOffset+elementSize > len(payload) {
+				return nil, errShortPacket
+			}
+		}
+
+		p.OBUElements = append(p.OBUElements, payload[currOffset:currOffset+elementSize])
+		currOffset += elementSize
+	}
+
+	if p.W != 0 && len(p.OBUElements) != int(p.W) {
+		return nil, errors.Errorf("AV1 packet has %d OBU elements, aggregation header declares %d", len(p.OBUElements), p.W)
+	}
+
+	result := []byte{}
+	for i, element := range p.OBUElements {
+		obu := element
+
+		if i == 0 && p.Z {
+			// Without its start the fragment is useless
+			if p.fragment == nil {
+				if len(p.OBUElements) == 1 && p.Y {
+					return result, nil
+				}
+				continue
+			}
+			obu = append(p.fragment, element...)
+			p.fragment = nil
+		} else if i == 0 {
+			p.fragment = nil
+		}
+
+		if i == len(p.OBUElements)-1 && p.Y {
+			p.fragment = append([]byte{}, obu...)
+			break
+		}
+
+		if out, err := av1AddSizeField(obu); err == nil {
+			result = append(result, out...)
+		} else {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// av1AddSizeField returns obu in the low overhead bitstream format
+func av1AddSizeField(obu []byte) ([]byte, error) {
+	if len(obu) < 1 {
+		return nil, errAV1MalformedOBU
+	}
+
+	headerSize := 1
+	if obu[0]&obuExtensionFlagMask != 0 {
+		headerSize++
+	}
+	if len(obu) < headerSize {
+		return nil, errAV1MalformedOBU
+	}
+	if obu[0]&obuHasSizeFieldMask != 0 {
+		return obu, nil
+	}
+
+	out := make([]byte, 0, len(obu)+av1MaxLeb128)
+	out = append(out, obu[0]|obuHasSizeFieldMask)
+	out = append(out, obu[1:headerSize]...)
+	out = append(out, leb128Encode(uint(len(obu)-headerSize))...)
+	return append(out, obu[headerSize:]...), nil
+}
+
+// AV1PartitionHeadChecker checks AV1 partition head
+type AV1PartitionHeadChecker struct{}
+
+// IsPartitionHead checks whether payload begins with a new OBU rather
+// than the continuation of one from the previous packet
+func (*AV1PartitionHeadChecker) IsPartitionHead(payload []byte) bool {
+	if len(payload) < av1AggregationHeaderSize {
+		return false
+	}
+	return payload[0]&av1ZMask == 0
+}
+
+// leb128Encode encodes in as an unsigned LEB128 value
+func leb128Encode(in uint) []byte {
+	out := []byte{}
+	for {
+		b := byte(in & 0x7F)
+		in >>= 7
+		if in != 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if in == 0 {
+			return out
+		}
+	}
+}
+
+// leb128Decode decodes an unsigned LEB128 value from the start of in,
+// returning it and the number of bytes it used
+func leb128Decode(in []byte) (uint, int, error) {
+	var out uint
+	for i := 0; i < len(in) && i < av1MaxLeb128; i++ {
+		out |= uint(in[i]&0x7F) << (7 * uint(i))
+		if in[i]&0x80 == 0 {
+			return out, i + 1, nil
+		}
+	}
+	return 0, 0, errAV1MalformedOBU
+}
@@ -0,0 +1,139 @@
+package codecs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestLEB128(t *testing.T) {
+	for _, test := range []struct {
+		Value   uint
+		Encoded []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{300, []byte{0xac, 0x02}},
+	} {
+		if got := leb128Encode(test.Value); !bytes.Equal(got, test.Encoded) {
+			t.Errorf("leb128Encode(%d) = %#v, want %#v", test.Value, got, test.Encoded)
+		}
+		value, n, err := leb128Decode(append(test.Encoded, 0xff))
+		if err != nil || value != test.Value || n != len(test.Encoded) {
+			t.Errorf("leb128Decode(%#v) = %d, %d, %v", test.Encoded, value, n, err)
+		}
+	}
+
+	if _, _, err := leb128Decode([]byte{0x80, 0x80}); err != errAV1MalformedOBU {
+		t.Errorf("leb128Decode of a truncated value: err = %v, want %v", err, errAV1MalformedOBU)
+	}
+}
+
+func TestAV1PayloaderRoundTrip(t *testing.T) {
+	sequenceHeader := []byte{0x0a, 0x03, 0x01, 0x02, 0x03}
+	frame := append([]byte{0x32, 0x14}, make([]byte, 20)...)
+	for i := range frame[2:] {
+		frame[2+i] = byte(i)
+	}
+
+	var temporalUnit []byte
+	temporalUnit = append(temporalUnit, 0x12, 0x00) // temporal delimiter
+	temporalUnit = append(temporalUnit, sequenceHeader...)
+	temporalUnit = append(temporalUnit, frame...)
+
+	for _, mtu := range []int{8, 12, 100} {
+		payloads := (&AV1Payloader{}).Payload(mtu, temporalUnit)
+		if len(payloads) == 0 {
+			t.Fatalf("MTU %d: no payloads", mtu)
+		}
+
+		depacketizer := &AV1Packet{}
+		checker := &AV1PartitionHeadChecker{}
+		var reassembled []byte
+		for i, payload := range payloads {
+			if len(payload) > mtu {
+				t.Errorf("MTU %d: payload %d is %d octets", mtu, i, len(payload))
+			}
+			if got, want := payload[0]&av1NMask != 0, i == 0; got != want {
+				t.Errorf("MTU %d: payload %d N = %t, want %t", mtu, i, got, want)
+			}
+
+			obus, err := depacketizer.Unmarshal(&rtp.Packet{Payload: payload})
+			if err != nil {
+				t.Fatalf("MTU %d: payload %d Unmarshal error: %v", mtu, i, err)
+			}
+			if got, want := checker.IsPartitionHead(payload), !depacketizer.Z; got != want {
+				t.Errorf("MTU %d: payload %d IsPartitionHead = %t, want %t", mtu, i, got, want)
+			}
+			reassembled = append(reassembled, obus...)
+		}
+
+		want := append(append([]byte{}, sequenceHeader...), frame...)
+		if !bytes.Equal(reassembled, want) {
+			t.Errorf("MTU %d: reassembled %#v, want %#v", mtu, reassembled, want)
+		}
+	}
+}
+
+func TestAV1PacketUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Payloads  [][]byte
+		Want      []byte
+		WantError bool
+	}{
+		{
+			Name:     "W=2, last element without length",
+			Payloads: [][]byte{{0x20, 0x02, 0x08, 0xaa, 0x30, 0xbb}},
+			Want:     []byte{0x0a, 0x01, 0xaa, 0x32, 0x01, 0xbb},
+		},
+		{
+			Name:      "W disagrees with the elements",
+			Payloads:  [][]byte{{0x30, 0x01, 0x08, 0x01, 0x30}},
+			WantError: true,
+		},
+		{
+			Name:      "truncated element",
+			Payloads:  [][]byte{{0x00, 0x05, 0x08}},
+			WantError: true,
+		},
+		{
+			Name: "fragment with lost start is dropped",
+			Payloads: [][]byte{
+				{0x80, 0x02, 0x30, 0xaa, 0x02, 0x08, 0xbb},
+			},
+			Want: []byte{0x0a, 0x01, 0xbb},
+		},
+		{
+			Name: "fragmented OBU",
+			Payloads: [][]byte{
+				{0x40, 0x02, 0x30, 0xaa},
+				{0xc0, 0x01, 0xbb},
+				{0x80, 0x01, 0xcc},
+			},
+			Want: []byte{0x32, 0x03, 0xaa, 0xbb, 0xcc},
+		},
+	} {
+		p := &AV1Packet{}
+		var got []byte
+		var err error
+		for _, payload := range test.Payloads {
+			var out []byte
+			if out, err = p.Unmarshal(&rtp.Packet{Payload: payload}); err != nil {
+				break
+			}
+			got = append(got, out...)
+		}
+
+		if gotErr := err != nil; gotErr != test.WantError {
+			t.Errorf("Unmarshal %q: err = %v, want error %t", test.Name, err, test.WantError)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("Unmarshal %q: got %#v, want %#v", test.Name, got, test.Want)
+		}
+	}
+}
@@ -1,5 +1,14 @@
 package codecs
 
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	errShortPacket = errors.New("packet is not large enough")
+	errNilPacket   = errors.New("invalid nil packet")
+)
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -1,12 +1,35 @@
 package codecs
 
+import (
+	"encoding/binary"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
 // H264Payloader payloads H264 packets
 type H264Payloader struct{}
 
 const (
-	fuaHeaderSize = 2
+	stapaNALUType = 24
+	fuaNALUType   = 28
+
+	accessUnitDelimiterNALUType = 9
+	fillerDataNALUType          = 12
+
+	fuaHeaderSize       = 2
+	stapaHeaderSize     = 1
+	stapaNALULengthSize = 2
+
+	naluTypeBitmask   = 0x1F
+	naluRefIdcBitmask = 0x60
+	naluFBitmask      = 0x80
+	fuStartBitmask    = 0x80
+	fuEndBitmask      = 0x40
 )
 
+var annexbNALUStartCode = []byte{0x00, 0x00, 0x00, 0x01}
+
 func emitNalus(nals []byte, emit func([]byte)) {
 	nextInd := func(nalu []byte, start int) (indStart int, indLen int) {
 		zeroCount := 0
@@ -42,30 +65,83 @@ func emitNalus(nals []byte, emit func([]byte)) {
 	}
 }
 
-// Payload fragments a H264 packet across one or more byte arrays
+// Payload fragments a H264 packet across one or more byte arrays.
+// NAL units small enough are aggregated into STAP-A packets, so parameter
+// sets like SPS and PPS travel together with the slice that follows them,
+// and NAL units larger than the MTU are split into FU-A fragments.
 func (p *H264Payloader) Payload(mtu int, payload []byte) [][]byte {
 
 	var payloads [][]byte
 
+	// NAL units waiting to be sent, alone or aggregated
+	var pending [][]byte
+	pendingSize := stapaHeaderSize
+
+	flush := func() {
+		switch len(pending) {
+		case 0:
+			return
+		case 1:
+			out := make([]byte, len(pending[0]))
+			copy(out, pending[0])
+			payloads = append(payloads, out)
+		default:
+			// +---------------+
+			// |0|1|2|3|4|5|6|7|
+			// +-+-+-+-+-+-+-+-+
+			// |F|NRI|  Type   |
+			// +---------------+
+			//
+			// The F bit is set if any aggregated NAL unit has it set, and the
+			// NRI is the highest of the aggregated NAL units
+			out := make([]byte, stapaHeaderSize, pendingSize)
+			out[0] = stapaNALUType
+			for _, nalu := range pending {
+				out[0] |= nalu[0] & naluFBitmask
+				if refIdc := nalu[0] & naluRefIdcBitmask; refIdc > out[0]&naluRefIdcBitmask {
+					out[0] = out[0]&^naluRefIdcBitmask | refIdc
+				}
+
+				naluLength := make([]byte, stapaNALULengthSize)
+				binary.BigEndian.PutUint16(naluLength, uint16(len(nalu)))
+				out = append(out, naluLength...)
+				out = append(out, nalu...)
+			}
+			payloads = append(payloads, out)
+		}
+
+		pending = nil
+		pendingSize = stapaHeaderSize
+	}
+
 	emitNalus(payload, func(nalu []byte) {
-		naluType := nalu[0] & 0x1F
-		naluRefIdc := nalu[0] & 0x60
+		if len(nalu) == 0 {
+			return
+		}
+
+		naluType := nalu[0] & naluTypeBitmask
+		naluRefIdc := nalu[0] & naluRefIdcBitmask
 
-		if naluType == 9 || naluType == 12 {
+		if naluType == accessUnitDelimiterNALUType || naluType == fillerDataNALUType {
 			return
 		}
 
-		// Single NALU
+		// Aggregate with the NAL units before it if they all still fit,
+		// otherwise send those and start again from this one
+		if pendingSize+stapaNALULengthSize+len(nalu) > mtu {
+			flush()
+		}
 		if len(nalu) <= mtu {
-			out := make([]byte, len(nalu))
-			copy(out, nalu)
-			payloads = append(payloads, out)
+			pending = append(pending, nalu)
+			pendingSize += stapaNALULengthSize + len(nalu)
 			return
 		}
 
 		// FU-A
 		maxFragmentSize := mtu - fuaHeaderSize
-
+		if maxFragmentSize <= 0 {
+			return
+		}
 		// The FU payload consists of fragments of the payload of the fragmented
 		// NAL unit so that if the fragmentation unit payloads of consecutive
 		// FUs are sequentially concatenated, the payload of the fragmented NAL
@@ -92,7 +168,7 @@ func (p *H264Payloader) Payload(mtu int, payload []byte) [][]byte {
 			// +-+-+-+-+-+-+-+-+
 			// |F|NRI|  Type   |
 			// +---------------+
-			out[0] = 28
+			out[0] = fuaNALUType
 			out[0] |= naluRefIdc
 
 			// +---------------+
@@ -104,10 +180,10 @@ func (p *H264Payloader) Payload(mtu int, payload []byte) [][]byte {
 			out[1] = naluType
 			if naluDataRemaining == naluDataLength {
 				// Set start bit
-				out[1] |= 1 << 7
+				out[1] |= fuStartBitmask
 			} else if naluDataRemaining-currentFragmentSize == 0 {
 				// Set end bit
-				out[1] |= 1 << 6
+				out[1] |= fuEndBitmask
 			}
 
 			copy(out[fuaHeaderSize:], naluData[naluDataIndex:naluDataIndex+currentFragmentSize])
@@ -118,6 +194,98 @@ func (p *H264Payloader) Payload(mtu int, payload []byte) [][]byte {
 		}
 
 	})
+	flush()
 
 	return payloads
 }
+
+// H264Packet represents the H264 header that is stored in the payload of an RTP Packet
+type H264Packet struct {
+	fuaBuffer []byte
+}
+
+// Unmarshal parses the passed byte slice and returns the NAL units it
+// carries in Annex B form, each prefixed with a start code. Parameter sets
+// like SPS and PPS are passed through unchanged. FU-A fragments are
+// buffered until the final fragment arrives, so nothing is returned for
+// the ones before it.
+func (p *H264Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	if packet == nil {
+		return nil, errNilPacket
+	}
+
+	payload := packet.Payload
+	if len(payload) < 1 {
+		return nil, errShortPacket
+	}
+
+	naluType := payload[0] & naluTypeBitmask
+	switch {
+	case naluType > 0 && naluType < stapaNALUType:
+		return append(append([]byte{}, annexbNALUStartCode...), payload...), nil
+
+	case naluType == stapaNALUType:
+		var result []byte
+		for currOffset := stapaHeaderSize; currOffset < len(payload); {
+			if currOffset+stapaNALULengthSize > len(payload) {
+				return nil, errShortPacket
+			}
+			naluSize := int(binary.BigEndian.Uint16(payload[currOffset:]))
+			currOffset += stapaNALULengthSize
+
+			if currOffset+naluSize > len(payload) {
+				return nil, errors.Errorf("STAP-A declared size(%d) is larger than buffer(%d)", naluSize, len(payload)-currOffset)
+			}
+
+			result = append(result, annexbNALUStartCode...)
+			result = append(result, payload[currOffset:currOffset+naluSize]...)
+			currOffset += naluSize
+		}
+		return result, nil
+
+	case naluType == fuaNALUType:
+		if len(payload) < fuaHeaderSize {
+			return nil, errShortPacket
+		}
+
+		// A new NAL unit starts, dropping anything left from one whose end
+		// was lost
+		if payload[1]&fuStartBitmask != 0 {
+			p.fuaBuffer = nil
+		}
+		p.fuaBuffer = append(p.fuaBuffer, payload[fuaHeaderSize:]...)
+
+		if payload[1]&fuEndBitmask == 0 {
+			return []byte{}, nil
+		}
+
+		// Rebuild the NAL unit header from the FU indicator and FU header
+		naluRefIdc := payload[0] & (naluFBitmask | naluRefIdcBitmask)
+		fragmentedNaluType := payload[1] & naluTypeBitmask
+
+		result := append([]byte{}, annexbNALUStartCode...)
+		result = append(result, naluRefIdc|fragmentedNaluType)
+		result = append(result, p.fuaBuffer...)
+		p.fuaBuffer = nil
+		return result, nil
+	}
+
+	return nil, errors.Errorf("Unhandled NAL unit type %d", naluType)
+}
+
+// H264PartitionHeadChecker checks H264 partition head
+type H264PartitionHeadChecker struct{}
+
+// IsPartitionHead checks whether payload begins a H264 NAL unit, which is
+// true for every packet but the FU-A fragments after the first
+func (*H264PartitionHeadChecker) IsPartitionHead(payload []byte) bool {
+	if len(payload) < fuaHeaderSize {
+		return len(payload) > 0
+	}
+
+	if payload[0]&naluTypeBitmask == fuaNALUType {
+		return payload[1]&fuStartBitmask != 0
+	}
+
+	return true
+}
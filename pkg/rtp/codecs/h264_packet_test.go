@@ -0,0 +1,138 @@
+package codecs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestH264Payload(t *testing.T) {
+	p := &H264Payloader{}
+
+	// Small NAL units are aggregated, access unit delimiters dropped
+	sps := []byte{0x67, 0x42, 0x00, 0x1f}
+	pps := []byte{0x68, 0xce, 0x3c, 0x80}
+	idr := []byte{0x65, 0x88, 0x84, 0x00}
+	stream := []byte{0x00, 0x00, 0x00, 0x01, 0x09, 0xf0}
+	for _, nalu := range [][]byte{sps, pps, idr} {
+		stream = append(append(stream, 0x00, 0x00, 0x01), nalu...)
+	}
+
+	want := [][]byte{{
+		0x78,
+		0x00, 0x04, 0x67, 0x42, 0x00, 0x1f,
+		0x00, 0x04, 0x68, 0xce, 0x3c, 0x80,
+		0x00, 0x04, 0x65, 0x88, 0x84, 0x00,
+	}}
+	if got := p.Payload(100, stream); !reflect.DeepEqual(got, want) {
+		t.Errorf("STAP-A: got %#v, want %#v", got, want)
+	}
+
+	// Only the NAL units that fit are aggregated
+	want = [][]byte{
+		{0x78, 0x00, 0x04, 0x67, 0x42, 0x00, 0x1f, 0x00, 0x04, 0x68, 0xce, 0x3c, 0x80},
+		idr,
+	}
+	if got := p.Payload(13, stream); !reflect.DeepEqual(got, want) {
+		t.Errorf("partial STAP-A: got %#v, want %#v", got, want)
+	}
+
+	// A single NAL unit is sent as is
+	if got, want := p.Payload(100, idr), [][]byte{idr}; !reflect.DeepEqual(got, want) {
+		t.Errorf("single NAL unit: got %#v, want %#v", got, want)
+	}
+
+	// NAL units larger than the MTU are fragmented
+	large := []byte{0x65, 0x01, 0x02, 0x03, 0x04, 0x05}
+	want = [][]byte{
+		{0x7c, 0x85, 0x01, 0x02},
+		{0x7c, 0x05, 0x03, 0x04},
+		{0x7c, 0x45, 0x05},
+	}
+	if got := p.Payload(4, large); !reflect.DeepEqual(got, want) {
+		t.Errorf("FU-A: got %#v, want %#v", got, want)
+	}
+}
+
+func TestH264PacketUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Payloads  [][]byte
+		Want      []byte
+		WantError bool
+	}{
+		{
+			Name:     "single NAL unit",
+			Payloads: [][]byte{{0x67, 0x42, 0x00, 0x1f}},
+			Want:     []byte{0x00, 0x00, 0x00, 0x01, 0x67, 0x42, 0x00, 0x1f},
+		},
+		{
+			Name:     "STAP-A",
+			Payloads: [][]byte{{0x78, 0x00, 0x02, 0x67, 0x42, 0x00, 0x01, 0x68}},
+			Want:     []byte{0x00, 0x00, 0x00, 0x01, 0x67, 0x42, 0x00, 0x00, 0x00, 0x01, 0x68},
+		},
+		{
+			Name:      "STAP-A truncated",
+			Payloads:  [][]byte{{0x78, 0x00, 0x05, 0x67, 0x42}},
+			WantError: true,
+		},
+		{
+			Name: "FU-A",
+			Payloads: [][]byte{
+				{0x7c, 0x85, 0x01, 0x02},
+				{0x7c, 0x05, 0x03, 0x04},
+				{0x7c, 0x45, 0x05},
+			},
+			Want: []byte{0x00, 0x00, 0x00, 0x01, 0x65, 0x01, 0x02, 0x03, 0x04, 0x05},
+		},
+		{
+			Name:      "empty",
+			Payloads:  [][]byte{{}},
+			WantError: true,
+		},
+		{
+			Name:      "unhandled type",
+			Payloads:  [][]byte{{0x79, 0x00}},
+			WantError: true,
+		},
+	} {
+		p := &H264Packet{}
+		var got []byte
+		var err error
+		for _, payload := range test.Payloads {
+			var out []byte
+			if out, err = p.Unmarshal(&rtp.Packet{Payload: payload}); err != nil {
+				break
+			}
+			got = append(got, out...)
+		}
+
+		if gotErr := err != nil; gotErr != test.WantError {
+			t.Errorf("Unmarshal %q: err = %v, want error %t", test.Name, err, test.WantError)
+			continue
+		}
+		if err == nil && !bytes.Equal(got, test.Want) {
+			t.Errorf("Unmarshal %q: got %#v, want %#v", test.Name, got, test.Want)
+		}
+	}
+}
+
+func TestH264PartitionHeadChecker(t *testing.T) {
+	checker := &H264PartitionHeadChecker{}
+	for _, test := range []struct {
+		Payload []byte
+		Want    bool
+	}{
+		{[]byte{}, false},
+		{[]byte{0x65, 0x01}, true},
+		{[]byte{0x78, 0x00, 0x01, 0x67}, true},
+		{[]byte{0x7c, 0x85, 0x01}, true},
+		{[]byte{0x7c, 0x05, 0x01}, false},
+	} {
+		if got := checker.IsPartitionHead(test.Payload); got != test.Want {
+			t.Errorf("IsPartitionHead(%#v) = %t, want %t", test.Payload, got, test.Want)
+		}
+	}
+}
@@ -5,18 +5,87 @@ import "github.com/pions/webrtc/pkg/rtp"
 // OpusPayloader payloads Opus packets
 type OpusPayloader struct{}
 
+const (
+	// OpusClockRate is the RTP clock rate of Opus, whatever rate it was
+	// encoded at, RFC 7587 Section 4.1
+	OpusClockRate = 48000
+)
+
 // Payload fragments an Opus packet across one or more byte arrays
 func (p *OpusPayloader) Payload(mtu int, payload []byte) [][]byte {
-	return [][]byte{payload}
+	// An Opus packet is never fragmented, each one is sent in its own RTP
+	// packet
+	if len(payload) == 0 {
+		return nil
+	}
+
+	out := make([]byte, len(payload))
+	copy(out, payload)
+	return [][]byte{out}
 }
 
-// OpusPacket represents the VP8 header that is stored in the payload of an RTP Packet
+// OpusPacket represents the Opus header that is stored in the payload of an RTP Packet
 type OpusPacket struct {
 	Payload []byte
 }
 
-// Unmarshal parses the passed byte slice and stores the result in the OpusPacket this method is called upon
-func (p *OpusPacket) Unmarshal(packet *rtp.Packet) error {
+// Unmarshal parses the passed byte slice and stores the result in the OpusPacket this method is called upon.
+// The Opus packet is returned as well.
+func (p *OpusPacket) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	if packet == nil {
+		return nil, errNilPacket
+	} else if len(packet.Payload) == 0 {
+		return nil, errShortPacket
+	}
+
 	p.Payload = packet.Payload
-	return nil
+	return p.Payload, nil
+}
+
+// OpusSamples returns the number of samples at the 48kHz RTP clock rate
+// that an Opus packet holds, by which the timestamp of the following packet
+// should advance. The duration is read from the packet's TOC byte, RFC 6716
+// Section 3.1.
+func OpusSamples(payload []byte) (uint32, error) {
+	if len(payload) < 1 {
+		return 0, errShortPacket
+	}
+
+	/*
+	 *  0 1 2 3 4 5 6 7
+	 * +-+-+-+-+-+-+-+-+
+	 * | config  |s| c |
+	 * +-+-+-+-+-+-+-+-+
+	 */
+	config := payload[0] >> 3
+
+	// Frame sizes in 48kHz samples
+	var frameSamples uint32
+	switch {
+	case config < 12:
+		// SILK-only, 10, 20, 40 or 60 ms
+		frameSamples = []uint32{480, 960, 1920, 2880}[config%4]
+	case config < 16:
+		// Hybrid, 10 or 20 ms
+		frameSamples = []uint32{480, 960}[config%2]
+	default:
+		// CELT-only, 2.5, 5, 10 or 20 ms
+		frameSamples = []uint32{120, 240, 480, 960}[config%4]
+	}
+
+	var frames uint32
+	switch payload[0] & 0x3 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	default:
+		// An arbitrary number of frames, counted in the following byte
+		if len(payload) < 2 {
+			return 0, errShortPacket
+		}
+		frames = uint32(payload[1] & 0x3F)
+	}
+
+	return frameSamples * frames, nil
 }
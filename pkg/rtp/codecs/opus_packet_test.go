@@ -0,0 +1,60 @@
+package codecs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestOpusPacket(t *testing.T) {
+	payloader := &OpusPayloader{}
+	frame := []byte{0xfc, 0xff, 0xfe}
+
+	payloads := payloader.Payload(1200, frame)
+	if len(payloads) != 1 || !bytes.Equal(payloads[0], frame) {
+		t.Fatalf("Payload = %#v, want the frame unchanged", payloads)
+	}
+	if payloads := payloader.Payload(1200, nil); payloads != nil {
+		t.Errorf("Payload of an empty frame = %#v, want nil", payloads)
+	}
+
+	var p OpusPacket
+	if payload, err := p.Unmarshal(&rtp.Packet{Payload: payloads[0]}); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	} else if !bytes.Equal(payload, frame) {
+		t.Errorf("Unmarshal = %#v, want %#v", payload, frame)
+	}
+	if _, err := p.Unmarshal(&rtp.Packet{}); err != errShortPacket {
+		t.Errorf("Unmarshal empty: err = %v, want %v", err, errShortPacket)
+	}
+	if _, err := p.Unmarshal(nil); err != errNilPacket {
+		t.Errorf("Unmarshal nil: err = %v, want %v", err, errNilPacket)
+	}
+}
+
+func TestOpusSamples(t *testing.T) {
+	for _, test := range []struct {
+		Payload   []byte
+		Want      uint32
+		WantError error
+	}{
+		// CELT-only 20ms, one frame
+		{[]byte{0xf8}, 960, nil},
+		// CELT-only 2.5ms, two frames
+		{[]byte{0x81}, 240, nil},
+		// SILK-only 60ms, one frame
+		{[]byte{0x18}, 2880, nil},
+		// Hybrid 10ms, arbitrary count of 3 frames
+		{[]byte{0x63, 0x03}, 1440, nil},
+		{[]byte{0x63}, 0, errShortPacket},
+		{[]byte{}, 0, errShortPacket},
+	} {
+		got, err := OpusSamples(test.Payload)
+		if err != test.WantError {
+			t.Errorf("OpusSamples(%#v): err = %v, want %v", test.Payload, err, test.WantError)
+		} else if got != test.Want {
+			t.Errorf("OpusSamples(%#v) = %d, want %d", test.Payload, got, test.Want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package codecs
+
+import (
+	"encoding/binary"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+const (
+	redPrimaryHeaderSize   = 1
+	redRedundantHeaderSize = 4
+
+	redFMask                = 0x80
+	redPayloadTypeMask      = 0x7F
+	redTimestampOffsetShift = 10
+	redMaxTimestampOffset   = 1<<14 - 1
+	redMaxBlockLength       = 1<<10 - 1
+)
+
+// REDPayloader payloads audio frames with redundant copies of the frames
+// before them, RFC 2198, so a receiver can make up for lost packets.
+type REDPayloader struct {
+	// PayloadType is that of the audio frames carried
+	PayloadType uint8
+
+	// Redundancy is how many previous frames each packet repeats
+	Redundancy int
+
+	// Samples returns the duration of a frame in clock ticks, from which
+	// the timestamps of redundant frames are computed. OpusSamples is used
+	// if it is nil.
+	Samples func(payload []byte) (uint32, error)
+
+	history []redFrame
+}
+
+type redFrame struct {
+	payload []byte
+	samples uint32
+}
+
+// Payload returns the frame in payload with as many previous frames as
+// fit in the mtu. A frame is never fragmented.
+func (p *REDPayloader) Payload(mtu int, payload []byte) [][]byte {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |F|   block PT  |  timestamp offset         |   block length    |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 *
+	 * Each redundant block has a header as above, and the primary block,
+	 * which comes last, has a single octet header with F clear. The block
+	 * data follows the headers in the same order.
+	 */
+
+	if len(payload) == 0 {
+		return nil
+	}
+
+	samplesFunc := p.Samples
+	if samplesFunc == nil {
+		samplesFunc = OpusSamples
+	}
+
+	// The offset of each earlier frame is the duration of those after it
+	var redundant []redFrame
+	var offsets []uint32
+	offset := uint32(0)
+	size := redPrimaryHeaderSize + len(payload)
+	for i := len(p.history) - 1; i >= 0; i-- {
+		frame := p.history[i]
+		offset += frame.samples
+		if offset > redMaxTimestampOffset || len(frame.payload) > redMaxBlockLength ||
+			size+redRedundantHeaderSize+len(frame.payload) > mtu {
+			break
+		}
+		size += redRedundantHeaderSize + len(frame.payload)
+		redundant = append([]redFrame{frame}, redundant...)
+		offsets = append([]uint32{offset}, offsets...)
+	}
+
+	out := make([]byte, 0, size)
+	for i, frame := range redundant {
+		header := uint32(redFMask|p.PayloadType&redPayloadTypeMask)<<24 |
+			offsets[i]<<redTimestampOffsetShift | uint32(len(frame.payload))
+		out = append(out, make([]byte, redRedundantHeaderSize)...)
+		binary.BigEndian.PutUint32(out[len(out)-redRedundantHeaderSize:], header)
+	}
+	out = append(out, p.PayloadType&redPayloadTypeMask)
+	for _, frame := range redundant {
+		out = append(out, frame.payload...)
+	}
+	out = append(out, payload...)
+
+	// Without its duration the frame can't be repeated, nor the ones
+	// before it, as their offsets would be unknown
+	samples, err := samplesFunc(payload)
+	if err != nil || p.Redundancy <= 0 {
+		p.history = nil
+	} else {
+		p.history = append(p.history, redFrame{payload: append([]byte{}, payload...), samples: samples})
+		if len(p.history) > p.Redundancy {
+			p.history = p.history[len(p.history)-p.Redundancy:]
+		}
+	}
+
+	return [][]byte{out}
+}
+
+// REDBlock is a single frame carried in a RED packet
+type REDBlock struct {
+	PayloadType uint8
+
+	// TimestampOffset is how far the frame's timestamp is behind that of
+	// the packet, 0 for the primary block
+	TimestampOffset uint16
+
+	Payload []byte
+}
+
+// REDPacket represents the RED header that is stored in the payload of an RTP Packet
+type REDPacket struct {
+	// Blocks are the redundant frames, oldest first, followed by the
+	// primary frame
+	Blocks []REDBlock
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the REDPacket this method is called upon.
+// The primary frame is returned. Receivers recovering from loss can take
+// the redundant frames from Blocks.
+func (p *REDPacket) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	if packet == nil {
+		return nil, errNilPacket
+	}
+
+	payload := packet.Payload
+	var blocks []REDBlock
+	var lengths []int
+
+	offset := 0
+	for {
+		if len(payload) < offset+redPrimaryHeaderSize {
+			return nil, errShortPacket
+		}
+
+		if payload[offset]&redFMask == 0 {
+			blocks = append(blocks, REDBlock{PayloadType: payload[offset] & redPayloadTypeMask})
+			offset += redPrimaryHeaderSize
+			break
+		}
+
+		if len(payload) < offset+redRedundantHeaderSize {
+			return nil, errShortPacket
+		}
+		header := binary.BigEndian.Uint32(payload[offset:])
+		blocks = append(blocks, REDBlock{
+			PayloadType:     uint8(header>>24) & redPayloadTypeMask,
+			TimestampOffset: uint16(header>>redTimestampOffsetShift) & redMaxTimestampOffset,
+		})
+		lengths = append(lengths, int(header&redMaxBlockLength))
+		offset += redRedundantHeaderSize
+	}
+
+	for i, length := range lengths {
+		if len(payload) < offset+length {
+			return nil, errors.Errorf("RED block %d size insufficient; %d < %d", i, len(payload)-offset, length)
+		}
+		blocks[i].Payload = payload[offset : offset+length]
+		offset += length
+	}
+	blocks[len(blocks)-1].Payload = payload[offset:]
+
+	p.Blocks = blocks
+	return blocks[len(blocks)-1].Payload, nil
+}
@@ -0,0 +1,81 @@
+package codecs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestREDPacket(t *testing.T) {
+	// 20ms Opus frames, 960 samples each
+	frames := [][]byte{{0x78, 0x01}, {0x78, 0x02, 0x02}, {0x78, 0x03}}
+	payloader := &REDPayloader{PayloadType: 111, Redundancy: 1}
+
+	var payloads [][]byte
+	for _, frame := range frames {
+		out := payloader.Payload(1200, frame)
+		if len(out) != 1 {
+			t.Fatalf("Payload returned %d payloads, want 1", len(out))
+		}
+		payloads = append(payloads, out[0])
+	}
+
+	// The first packet has nothing to repeat
+	if want := []byte{0x6f, 0x78, 0x01}; !bytes.Equal(payloads[0], want) {
+		t.Errorf("first payload = %#v, want %#v", payloads[0], want)
+	}
+	want := []byte{0xef, 0x0f, 0x00, 0x02, 0x6f, 0x78, 0x01, 0x78, 0x02, 0x02}
+	if !bytes.Equal(payloads[1], want) {
+		t.Errorf("second payload = %#v, want %#v", payloads[1], want)
+	}
+
+	var p REDPacket
+	primary, err := p.Unmarshal(&rtp.Packet{Payload: payloads[2]})
+	if err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if !bytes.Equal(primary, frames[2]) {
+		t.Errorf("Unmarshal = %#v, want %#v", primary, frames[2])
+	}
+	if wantBlocks := []REDBlock{
+		{PayloadType: 111, TimestampOffset: 960, Payload: frames[1]},
+		{PayloadType: 111, Payload: frames[2]},
+	}; !reflect.DeepEqual(p.Blocks, wantBlocks) {
+		t.Errorf("Blocks = %#v, want %#v", p.Blocks, wantBlocks)
+	}
+
+	// Redundancy that doesn't fit in the MTU is left out
+	payloader = &REDPayloader{PayloadType: 111, Redundancy: 2}
+	payloader.Payload(1200, frames[0])
+	payloader.Payload(1200, frames[1])
+	out := payloader.Payload(12, frames[2])
+	if _, err := p.Unmarshal(&rtp.Packet{Payload: out[0]}); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if len(p.Blocks) != 2 || p.Blocks[0].TimestampOffset != 960 {
+		t.Errorf("Blocks = %#v, want only the latest frame repeated", p.Blocks)
+	}
+
+	if payloads := payloader.Payload(1200, nil); payloads != nil {
+		t.Errorf("Payload of an empty frame = %#v, want nil", payloads)
+	}
+}
+
+func TestREDPacketInvalid(t *testing.T) {
+	var p REDPacket
+	for _, payload := range [][]byte{
+		{},
+		{0xef, 0x0f},
+		// A redundant block longer than the packet
+		{0xef, 0x0f, 0x00, 0x08, 0x6f, 0x01},
+	} {
+		if _, err := p.Unmarshal(&rtp.Packet{Payload: payload}); err == nil {
+			t.Errorf("Unmarshal(%#v) succeeded, want error", payload)
+		}
+	}
+	if _, err := p.Unmarshal(nil); err != errNilPacket {
+		t.Errorf("Unmarshal nil: err = %v, want %v", err, errNilPacket)
+	}
+}
@@ -0,0 +1,140 @@
+package codecs
+
+import (
+	"sync"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// Codec describes how media of one codec is carried over RTP
+type Codec struct {
+	Name      string
+	ClockRate uint32
+
+	// NewPayloader and NewDepacketizer create the packetization state for
+	// a single stream, so streams sharing a codec don't interfere. Either
+	// may be nil if that direction isn't supported.
+	NewPayloader    func() rtp.Payloader
+	NewDepacketizer func() rtp.Depacketizer
+}
+
+// Codecs supported by this package, to be registered with the payload
+// types negotiated for them
+var (
+	OpusCodec = Codec{
+		Name:            "opus",
+		ClockRate:       OpusClockRate,
+		NewPayloader:    func() rtp.Payloader { return &OpusPayloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &OpusPacket{} },
+	}
+
+	VP8Codec = Codec{
+		Name:            "VP8",
+		ClockRate:       90000,
+		NewPayloader:    func() rtp.Payloader { return &VP8Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &VP8Packet{} },
+	}
+
+	VP9Codec = Codec{
+		Name:            "VP9",
+		ClockRate:       90000,
+		NewPayloader:    func() rtp.Payloader { return &VP9Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &VP9Packet{} },
+	}
+
+	AV1Codec = Codec{
+		Name:            "AV1",
+		ClockRate:       90000,
+		NewPayloader:    func() rtp.Payloader { return &AV1Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &AV1Packet{} },
+	}
+
+	H264Codec = Codec{
+		Name:            "H264",
+		ClockRate:       90000,
+		NewPayloader:    func() rtp.Payloader { return &H264Payloader{} },
+		NewDepacketizer: func() rtp.Depacketizer { return &H264Packet{} },
+	}
+)
+
+// Registry maps RTP payload types to the codecs they carry, so media can be
+// packetized and depacketized without knowing the codec in advance. It is
+// safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	codecs map[uint8]Codec
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{codecs: map[uint8]Codec{}}
+}
+
+// Register maps payloadType to codec. Payload types are 7 bits, and each
+// may only be registered once.
+func (r *Registry) Register(payloadType uint8, codec Codec) error {
+	if payloadType > 127 {
+		return errors.Errorf("invalid payload type %d", payloadType)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.codecs[payloadType]; ok {
+		return errors.Errorf("payload type %d already registered for %s", payloadType, existing.Name)
+	}
+	r.codecs[payloadType] = codec
+	return nil
+}
+
+// Unregister removes the codec mapped to payloadType, if any
+func (r *Registry) Unregister(payloadType uint8) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.codecs, payloadType)
+}
+
+// Codec returns the codec mapped to payloadType
+func (r *Registry) Codec(payloadType uint8) (Codec, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	codec, ok := r.codecs[payloadType]
+	if !ok {
+		return Codec{}, errors.Errorf("no codec registered for payload type %d", payloadType)
+	}
+	return codec, nil
+}
+
+// ClockRate returns the RTP clock rate of the codec mapped to payloadType
+func (r *Registry) ClockRate(payloadType uint8) (uint32, error) {
+	codec, err := r.Codec(payloadType)
+	if err != nil {
+		return 0, err
+	}
+	return codec.ClockRate, nil
+}
+
+// NewPayloader creates a Payloader for a stream of payloadType
+func (r *Registry) NewPayloader(payloadType uint8) (rtp.Payloader, error) {
+	codec, err := r.Codec(payloadType)
+	if err != nil {
+		return nil, err
+	} else if codec.NewPayloader == nil {
+		return nil, errors.Errorf("%s has no payloader", codec.Name)
+	}
+	return codec.NewPayloader(), nil
+}
+
+// NewDepacketizer creates a Depacketizer for a stream of payloadType
+func (r *Registry) NewDepacketizer(payloadType uint8) (rtp.Depacketizer, error) {
+	codec, err := r.Codec(payloadType)
+	if err != nil {
+		return nil, err
+	} else if codec.NewDepacketizer == nil {
+		return nil, errors.Errorf("%s has no depacketizer", codec.Name)
+	}
+	return codec.NewDepacketizer(), nil
+}
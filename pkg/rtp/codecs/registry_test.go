@@ -0,0 +1,62 @@
+package codecs
+
+import (
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Register(111, OpusCodec); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if err := r.Register(96, VP8Codec); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if err := r.Register(96, H264Codec); err == nil {
+		t.Error("Register accepted a payload type twice")
+	}
+	if err := r.Register(128, H264Codec); err == nil {
+		t.Error("Register accepted a payload type over 127")
+	}
+
+	if clockRate, err := r.ClockRate(111); err != nil {
+		t.Errorf("ClockRate error: %v", err)
+	} else if clockRate != 48000 {
+		t.Errorf("ClockRate(111) = %d, want 48000", clockRate)
+	}
+
+	if payloader, err := r.NewPayloader(96); err != nil {
+		t.Errorf("NewPayloader error: %v", err)
+	} else if _, ok := payloader.(*VP8Payloader); !ok {
+		t.Errorf("NewPayloader(96) = %T, want *VP8Payloader", payloader)
+	}
+
+	// Every stream gets its own state
+	first, err := r.NewDepacketizer(96)
+	if err != nil {
+		t.Fatalf("NewDepacketizer error: %v", err)
+	}
+	second, err := r.NewDepacketizer(96)
+	if err != nil {
+		t.Fatalf("NewDepacketizer error: %v", err)
+	}
+	if first == second {
+		t.Error("NewDepacketizer returned the same instance twice")
+	}
+
+	r.Unregister(96)
+	if _, err := r.Codec(96); err == nil {
+		t.Error("Codec found an unregistered payload type")
+	}
+	if _, err := r.NewDepacketizer(97); err == nil {
+		t.Error("NewDepacketizer found an unregistered payload type")
+	}
+
+	if err := r.Register(97, Codec{Name: "receive only", ClockRate: 90000}); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if _, err := r.NewPayloader(97); err == nil {
+		t.Error("NewPayloader succeeded for a codec without a payloader")
+	}
+}
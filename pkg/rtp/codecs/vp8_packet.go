@@ -3,10 +3,18 @@ package codecs
 import "github.com/pions/webrtc/pkg/rtp"
 
 // VP8Payloader payloads VP8 packets
-type VP8Payloader struct{}
+type VP8Payloader struct {
+	// EnablePictureID adds a 15 bit PictureID, incremented for every
+	// frame, to the payload descriptor of each packet
+	EnablePictureID bool
+
+	pictureID uint16
+}
 
 const (
 	vp8HeaderSize = 1
+
+	vp8PictureIDMask = 0x7FFF
 )
 
 // Payload fragments a VP8 packet across one or more byte arrays
@@ -33,7 +41,16 @@ func (p *VP8Payloader) Payload(mtu int, payload []byte) [][]byte {
 	 *     first packet of each encoded frame.
 	 */
 
-	maxFragmentSize := mtu - vp8HeaderSize
+	headerSize := vp8HeaderSize
+	if p.EnablePictureID {
+		// X octet and a 16 bit (M=1) PictureID
+		headerSize += 3
+	}
+
+	maxFragmentSize := mtu - headerSize
+	if maxFragmentSize <= 0 {
+		return nil
+	}
 
 	payloadData := payload
 	payloadDataRemaining := len(payload)
@@ -41,18 +58,27 @@ func (p *VP8Payloader) Payload(mtu int, payload []byte) [][]byte {
 	var payloads [][]byte
 	for payloadDataRemaining > 0 {
 		currentFragmentSize := min(maxFragmentSize, payloadDataRemaining)
-		out := make([]byte, vp8HeaderSize+currentFragmentSize)
+		out := make([]byte, headerSize+currentFragmentSize)
 		if payloadDataRemaining == len(payload) {
 			out[0] = 0x10
 		}
 
-		copy(out[vp8HeaderSize:], payloadData[payloadDataIndex:payloadDataIndex+currentFragmentSize])
+		if p.EnablePictureID {
+			out[0] |= 0x80
+			out[1] = 0x80
+			out[2] = 0x80 | uint8(p.pictureID>>8)
+			out[3] = uint8(p.pictureID)
+		}
+
+		copy(out[headerSize:], payloadData[payloadDataIndex:payloadDataIndex+currentFragmentSize])
 		payloads = append(payloads, out)
 
 		payloadDataRemaining -= currentFragmentSize
 		payloadDataIndex += currentFragmentSize
 	}
 
+	p.pictureID = (p.pictureID + 1) & vp8PictureIDMask
+
 	return payloads
 }
 
@@ -71,14 +97,26 @@ type VP8Packet struct {
 	K         uint8  /* 1 if KEYIDX is present */
 	PictureID uint16 /* 8 or 16 bits, picture ID */
 	TL0PICIDX uint8  /* 8 bits temporal level zero index */
+	TID       uint8  /* 2 bits temporal layer index */
+	Y         uint8  /* 1 if the frame depends only on the base layer */
+	KEYIDX    uint8  /* 5 bits temporal key frame index */
 
 	Payload []byte
 }
 
-// Unmarshal parses the passed byte slice and stores the result in the VP8Packet this method is called upon
-func (p *VP8Packet) Unmarshal(packet *rtp.Packet) error {
+// Unmarshal parses the passed byte slice and stores the result in the VP8Packet this method is called upon.
+// The VP8 data with the payload descriptor removed is returned as well.
+func (p *VP8Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	if packet == nil {
+		return nil, errNilPacket
+	}
+
 	payload := packet.Payload
+	if len(payload) < vp8HeaderSize {
+		return nil, errShortPacket
+	}
 
+	*p = VP8Packet{}
 	payloadIndex := 0
 
 	p.X = (payload[payloadIndex] & 0x80) >> 7
@@ -89,6 +127,9 @@ func (p *VP8Packet) Unmarshal(packet *rtp.Packet) error {
 	payloadIndex++
 
 	if p.X == 1 {
+		if payloadIndex >= len(payload) {
+			return nil, errShortPacket
+		}
 		p.I = (payload[payloadIndex] & 0x80) >> 7
 		p.L = (payload[payloadIndex] & 0x40) >> 6
 		p.T = (payload[payloadIndex] & 0x20) >> 5
@@ -97,22 +138,56 @@ func (p *VP8Packet) Unmarshal(packet *rtp.Packet) error {
 	}
 
 	if p.I == 1 { // PID present?
+		if payloadIndex >= len(payload) {
+			return nil, errShortPacket
+		}
 		if payload[payloadIndex]&0x80 > 0 { // M == 1, PID is 16bit
+			if payloadIndex+1 >= len(payload) {
+				return nil, errShortPacket
+			}
+			p.PictureID = uint16(payload[payloadIndex]&0x7F)<<8 | uint16(payload[payloadIndex+1])
 			payloadIndex += 2
 		} else {
+			p.PictureID = uint16(payload[payloadIndex])
 			payloadIndex++
 		}
 	}
 
 	if p.L == 1 {
+		if payloadIndex >= len(payload) {
+			return nil, errShortPacket
+		}
+		p.TL0PICIDX = payload[payloadIndex]
 		payloadIndex++
 	}
 
 	if p.T == 1 || p.K == 1 {
+		if payloadIndex >= len(payload) {
+			return nil, errShortPacket
+		}
+		if p.T == 1 {
+			p.TID = payload[payloadIndex] >> 6
+			p.Y = (payload[payloadIndex] >> 5) & 0x1
+		}
+		if p.K == 1 {
+			p.KEYIDX = payload[payloadIndex] & 0x1F
+		}
 		payloadIndex++
 	}
 
 	p.Payload = payload[payloadIndex:]
 
-	return nil
+	return p.Payload, nil
+}
+
+// VP8PartitionHeadChecker checks VP8 partition head
+type VP8PartitionHeadChecker struct{}
+
+// IsPartitionHead checks whether payload begins a VP8 frame, that is the
+// start of partition 0
+func (*VP8PartitionHeadChecker) IsPartitionHead(payload []byte) bool {
+	if len(payload) < vp8HeaderSize {
+		return false
+	}
+	return payload[0]&0x10 != 0 && payload[0]&0x07 == 0
 }
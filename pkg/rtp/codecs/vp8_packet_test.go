@@ -0,0 +1,124 @@
+package codecs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestVP8PacketUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      VP8Packet
+		WantError error
+	}{
+		{
+			Name:      "empty",
+			Data:      []byte{},
+			WantError: errShortPacket,
+		},
+		{
+			Name: "no extension",
+			Data: []byte{0x10, 0xaa, 0xbb},
+			Want: VP8Packet{S: 1, Payload: []byte{0xaa, 0xbb}},
+		},
+		{
+			Name: "7 bit PictureID",
+			Data: []byte{0x90, 0x80, 0x11, 0xaa},
+			Want: VP8Packet{X: 1, S: 1, I: 1, PictureID: 0x11, Payload: []byte{0xaa}},
+		},
+		{
+			Name: "15 bit PictureID, TL0PICIDX, TID and KEYIDX",
+			Data: []byte{0x82, 0xf0, 0x81, 0x23, 0x45, 0x65, 0xaa},
+			Want: VP8Packet{
+				X: 1, PID: 2, I: 1, L: 1, T: 1, K: 1,
+				PictureID: 0x0123, TL0PICIDX: 0x45, TID: 1, Y: 1, KEYIDX: 5,
+				Payload: []byte{0xaa},
+			},
+		},
+		{
+			Name:      "truncated extension",
+			Data:      []byte{0x90},
+			WantError: errShortPacket,
+		},
+		{
+			Name:      "truncated 15 bit PictureID",
+			Data:      []byte{0x90, 0x80, 0x81},
+			WantError: errShortPacket,
+		},
+		{
+			Name:      "truncated TL0PICIDX",
+			Data:      []byte{0x90, 0x40},
+			WantError: errShortPacket,
+		},
+	} {
+		var got VP8Packet
+		payload, err := got.Unmarshal(&rtp.Packet{Payload: test.Data})
+		if err != test.WantError {
+			t.Errorf("Unmarshal %q: err = %v, want %v", test.Name, err, test.WantError)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("Unmarshal %q: got %#v, want %#v", test.Name, got, test.Want)
+		}
+		if !bytes.Equal(payload, test.Want.Payload) {
+			t.Errorf("Unmarshal %q: returned payload %#v, want %#v", test.Name, payload, test.Want.Payload)
+		}
+	}
+
+	var p VP8Packet
+	if _, err := p.Unmarshal(nil); err != errNilPacket {
+		t.Errorf("Unmarshal nil: err = %v, want %v", err, errNilPacket)
+	}
+}
+
+func TestVP8PayloaderRoundTrip(t *testing.T) {
+	frame := make([]byte, 25)
+	for i := range frame {
+		frame[i] = byte(i)
+	}
+
+	for _, enablePictureID := range []bool{false, true} {
+		payloader := &VP8Payloader{EnablePictureID: enablePictureID}
+		checker := &VP8PartitionHeadChecker{}
+
+		for picture := uint16(0); picture < 2; picture++ {
+			payloads := payloader.Payload(10, frame)
+
+			var reassembled []byte
+			for i, payload := range payloads {
+				if len(payload) > 10 {
+					t.Errorf("payload %d is %d octets, larger than the MTU", i, len(payload))
+				}
+				if got, want := checker.IsPartitionHead(payload), i == 0; got != want {
+					t.Errorf("payload %d IsPartitionHead = %t, want %t", i, got, want)
+				}
+
+				var p VP8Packet
+				data, err := p.Unmarshal(&rtp.Packet{Payload: payload})
+				if err != nil {
+					t.Fatalf("payload %d Unmarshal error: %v", i, err)
+				}
+				if enablePictureID && p.PictureID != picture {
+					t.Errorf("payload %d PictureID = %d, want %d", i, p.PictureID, picture)
+				}
+				reassembled = append(reassembled, data...)
+			}
+
+			if !bytes.Equal(reassembled, frame) {
+				t.Errorf("reassembled frame %#v, want %#v", reassembled, frame)
+			}
+		}
+	}
+
+	if payloads := (&VP8Payloader{EnablePictureID: true}).Payload(4, frame); payloads != nil {
+		t.Errorf("Payload with an MTU smaller than the descriptor returned %d payloads", len(payloads))
+	}
+}
@@ -0,0 +1,381 @@
+package codecs
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+// VP9Payloader payloads VP9 packets
+type VP9Payloader struct {
+	pictureID uint16
+}
+
+const (
+	vp9HeaderSize = 3 // Flexible mode 15 bit picture ID
+
+	vp9PictureIDMask = 0x7FFF
+	vp9MaxPDiffs     = 3
+)
+
+// Payload fragments a VP9 packet across one or more byte arrays, using the
+// flexible mode of the payload descriptor. Inter frames are declared to
+// depend on the previous picture only.
+func (p *VP9Payloader) Payload(mtu int, payload []byte) [][]byte {
+	/*
+	 * https://tools.ietf.org/html/draft-ietf-payload-vp9-06#section-4.2
+	 *
+	 * Flexible mode (F=1)
+	 *        0 1 2 3 4 5 6 7
+	 *       +-+-+-+-+-+-+-+-+
+	 *       |I|P|L|F|B|E|V|Z| (REQUIRED)
+	 *       +-+-+-+-+-+-+-+-+
+	 *  I:   |M| PICTURE ID  | (REQUIRED)
+	 *       +-+-+-+-+-+-+-+-+
+	 *  M:   | EXTENDED PID  | (RECOMMENDED)
+	 *       +-+-+-+-+-+-+-+-+
+	 *  L:   |  T  |U|  S  |D| (CONDITIONALLY RECOMMENDED)
+	 *       +-+-+-+-+-+-+-+-+                             -\
+	 *  P,F: | P_DIFF      |N| (CONDITIONALLY REQUIRED)    - up to 3 times
+	 *       +-+-+-+-+-+-+-+-+                             -/
+	 *  V:   | SS            |
+	 *       | ..            |
+	 *       +-+-+-+-+-+-+-+-+
+	 */
+
+	if len(payload) == 0 {
+		return nil
+	}
+
+	interPicture := !vp9IsKeyFrame(payload)
+
+	headerSize := vp9HeaderSize
+	if interPicture {
+		headerSize++
+	}
+
+	maxFragmentSize := mtu - headerSize
+	if maxFragmentSize <= 0 {
+		return nil
+	}
+
+	payloadDataRemaining := len(payload)
+	payloadDataIndex := 0
+	var payloads [][]byte
+	for payloadDataRemaining > 0 {
+		currentFragmentSize := min(maxFragmentSize, payloadDataRemaining)
+		out := make([]byte, headerSize+currentFragmentSize)
+
+		out[0] = 0x90 // I=1, F=1
+		if interPicture {
+			out[0] |= 0x40
+		}
+		if payloadDataIndex == 0 {
+			out[0] |= 0x08 // B
+		}
+		if payloadDataRemaining == currentFragmentSize {
+			out[0] |= 0x04 // E
+		}
+		out[1] = 0x80 | uint8(p.pictureID>>8)
+		out[2] = uint8(p.pictureID)
+		if interPicture {
+			// P_DIFF=1, the previous picture
+			out[3] = 1 << 1
+		}
+
+		copy(out[headerSize:], payload[payloadDataIndex:payloadDataIndex+currentFragmentSize])
+		payloads = append(payloads, out)
+
+		payloadDataRemaining -= currentFragmentSize
+		payloadDataIndex += currentFragmentSize
+	}
+
+	p.pictureID = (p.pictureID + 1) & vp9PictureIDMask
+
+	return payloads
+}
+
+// vp9IsKeyFrame reads the frame type from the uncompressed header of a VP9
+// frame
+func vp9IsKeyFrame(frame []byte) bool {
+	// frame_marker(2) profile_low_bit(1) profile_high_bit(1)
+	// [reserved_zero(1) for profile 3] show_existing_frame(1) frame_type(1)
+	bit := uint(2)
+	profile := (frame[0]>>5)&0x1 | ((frame[0]>>4)&0x1)<<1
+	bit += 2
+	if profile == 3 {
+		bit++
+	}
+
+	// An existing frame is shown again, it references nothing new
+	if (frame[0]>>(7-bit))&0x1 != 0 {
+		return false
+	}
+	bit++
+
+	return (frame[0]>>(7-bit))&0x1 == 0
+}
+
+// VP9Packet represents the VP9 header that is stored in the payload of an RTP Packet
+type VP9Packet struct {
+	// Required Header
+	I bool /* PictureID is present */
+	P bool /* Inter-picture predicted frame */
+	L bool /* Layer indices is present */
+	F bool /* Flexible mode */
+	B bool /* Start of a frame */
+	E bool /* End of a frame */
+	V bool /* Scalability structure (SS) data present */
+	Z bool /* Not a reference frame for upper spatial layers */
+
+	// Recommended headers
+	PictureID uint16 /* 7 or 15 bits, picture ID */
+
+	// Conditionally recommended headers
+	TID uint8 /* Temporal layer ID */
+	U   bool  /* Switching up point */
+	SID uint8 /* Spatial layer ID */
+	D   bool  /* Inter-layer dependency used */
+
+	// Conditionally required headers
+	PDiff     []uint8 /* Reference index (F=1) */
+	TL0PICIDX uint8   /* Temporal layer zero index (F=0) */
+
+	// Scalability structure headers
+	NS      uint8     /* N_S + 1 indicates the number of spatial layers present in the VP9 stream */
+	Y       bool      /* Each spatial layer's frame resolution present */
+	G       bool      /* PG description present flag */
+	NG      uint8     /* N_G indicates the number of pictures in a Picture Group (PG) */
+	Width   []uint16  /* Width of each spatial layer */
+	Height  []uint16  /* Height of each spatial layer */
+	PGTID   []uint8   /* Temporal layer ID of pictures in a Picture Group */
+	PGU     []bool    /* Switching up point of pictures in a Picture Group */
+	PGPDiff [][]uint8 /* Reference indices of pictures in a Picture Group */
+
+	Payload []byte
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the VP9Packet this method is called upon.
+// The VP9 data with the payload descriptor removed is returned as well.
+func (p *VP9Packet) Unmarshal(packet *rtp.Packet) ([]byte, error) {
+	if packet == nil {
+		return nil, errNilPacket
+	}
+
+	payload := packet.Payload
+	if len(payload) < 1 {
+		return nil, errShortPacket
+	}
+
+	*p = VP9Packet{}
+
+	p.I = payload[0]&0x80 != 0
+	p.P = payload[0]&0x40 != 0
+	p.L = payload[0]&0x20 != 0
+	p.F = payload[0]&0x10 != 0
+	p.B = payload[0]&0x08 != 0
+	p.E = payload[0]&0x04 != 0
+	p.V = payload[0]&0x02 != 0
+	p.Z = payload[0]&0x01 != 0
+
+	payloadIndex := 1
+	var err error
+
+	if p.I {
+		if payloadIndex, err = p.parsePictureID(payload, payloadIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.L {
+		if payloadIndex, err = p.parseLayerInfo(payload, payloadIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.F && p.P {
+		if payloadIndex, err = p.parseRefIndices(payload, payloadIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.V {
+		if payloadIndex, err = p.parseSSData(payload, payloadIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	p.Payload = payload[payloadIndex:]
+	return p.Payload, nil
+}
+
+// parsePictureID parses the 7 or 15 bit picture ID
+func (p *VP9Packet) parsePictureID(payload []byte, payloadIndex int) (int, error) {
+	/*
+	 *      +-+-+-+-+-+-+-+-+
+	 * I:   |M| PICTURE ID  |   M:0 => picture id is 7 bits.
+	 *      +-+-+-+-+-+-+-+-+   M:1 => picture id is 15 bits.
+	 * M:   | EXTENDED PID  |
+	 *      +-+-+-+-+-+-+-+-+
+	 */
+
+	if len(payload) <= payloadIndex {
+		return payloadIndex, errShortPacket
+	}
+
+	p.PictureID = uint16(payload[payloadIndex] & 0x7F)
+	if payload[payloadIndex]&0x80 != 0 {
+		payloadIndex++
+		if len(payload) <= payloadIndex {
+			return payloadIndex, errShortPacket
+		}
+		p.PictureID = p.PictureID<<8 | uint16(payload[payloadIndex])
+	}
+	payloadIndex++
+
+	return payloadIndex, nil
+}
+
+// parseLayerInfo parses the layer indices, and TL0PICIDX in non-flexible mode
+func (p *VP9Packet) parseLayerInfo(payload []byte, payloadIndex int) (int, error) {
+	/*
+	 *      +-+-+-+-+-+-+-+-+
+	 * L:   |  T  |U|  S  |D|
+	 *      +-+-+-+-+-+-+-+-+
+	 *      |   TL0PICIDX   |   (non-flexible mode only)
+	 *      +-+-+-+-+-+-+-+-+
+	 */
+
+	if len(payload) <= payloadIndex {
+		return payloadIndex, errShortPacket
+	}
+
+	p.TID = payload[payloadIndex] >> 5
+	p.U = payload[payloadIndex]&0x10 != 0
+	p.SID = (payload[payloadIndex] >> 1) & 0x7
+	p.D = payload[payloadIndex]&0x01 != 0
+	payloadIndex++
+
+	if !p.F {
+		if len(payload) <= payloadIndex {
+			return payloadIndex, errShortPacket
+		}
+		p.TL0PICIDX = payload[payloadIndex]
+		payloadIndex++
+	}
+
+	return payloadIndex, nil
+}
+
+// parseRefIndices parses the reference indices of flexible mode
+func (p *VP9Packet) parseRefIndices(payload []byte, payloadIndex int) (int, error) {
+	/*
+	 *      +-+-+-+-+-+-+-+-+                P=1,F=1: At least one reference index
+	 * P,F: | P_DIFF      |N|  up to 3 times          has to be specified.
+	 *      +-+-+-+-+-+-+-+-+                    N=1: An additional P_DIFF follows
+	 *                                                current P_DIFF.
+	 */
+
+	for {
+		if len(payload) <= payloadIndex {
+			return payloadIndex, errShortPacket
+		}
+		p.PDiff = append(p.PDiff, payload[payloadIndex]>>1)
+		more := payload[payloadIndex]&0x01 != 0
+		payloadIndex++
+
+		if !more {
+			return payloadIndex, nil
+		}
+		if len(p.PDiff) >= vp9MaxPDiffs {
+			return payloadIndex, errors.Errorf("VP9 packet has more than %d reference indices", vp9MaxPDiffs)
+		}
+	}
+}
+
+// parseSSData parses the scalability structure
+func (p *VP9Packet) parseSSData(payload []byte, payloadIndex int) (int, error) {
+	/*
+	 *      +-+-+-+-+-+-+-+-+
+	 * V:   | N_S |Y|G|-|-|-|
+	 *      +-+-+-+-+-+-+-+-+              -|
+	 * Y:   |     WIDTH     | (OPTIONAL)    .
+	 *      +               +               .
+	 *      |               | (OPTIONAL)    .
+	 *      +-+-+-+-+-+-+-+-+               . N_S + 1 times
+	 *      |     HEIGHT    | (OPTIONAL)    .
+	 *      +               +               .
+	 *      |               | (OPTIONAL)    .
+	 *      +-+-+-+-+-+-+-+-+              -|
+	 * G:   |      N_G      | (OPTIONAL)
+	 *      +-+-+-+-+-+-+-+-+                           -|
+	 * N_G: |  T  |U| R |-|-| (OPTIONAL)                 .
+	 *      +-+-+-+-+-+-+-+-+              -|            . N_G times
+	 *      |    P_DIFF     | (OPTIONAL)    . R times    .
+	 *      +-+-+-+-+-+-+-+-+              -|           -|
+	 */
+
+	if len(payload) <= payloadIndex {
+		return payloadIndex, errShortPacket
+	}
+
+	p.NS = payload[payloadIndex] >> 5
+	p.Y = payload[payloadIndex]&0x10 != 0
+	p.G = payload[payloadIndex]&0x08 != 0
+	payloadIndex++
+
+	spatialLayers := int(p.NS) + 1
+
+	if p.Y {
+		if len(payload) < payloadIndex+4*spatialLayers {
+			return payloadIndex, errShortPacket
+		}
+
+		p.Width = make([]uint16, spatialLayers)
+		p.Height = make([]uint16, spatialLayers)
+		for i := 0; i < spatialLayers; i++ {
+			p.Width[i] = uint16(payload[payloadIndex])<<8 | uint16(payload[payloadIndex+1])
+			p.Height[i] = uint16(payload[payloadIndex+2])<<8 | uint16(payload[payloadIndex+3])
+			payloadIndex += 4
+		}
+	}
+
+	if p.G {
+		if len(payload) <= payloadIndex {
+			return payloadIndex, errShortPacket
+		}
+		p.NG = payload[payloadIndex]
+		payloadIndex++
+	}
+
+	for i := 0; i < int(p.NG); i++ {
+		if len(payload) <= payloadIndex {
+			return payloadIndex, errShortPacket
+		}
+		p.PGTID = append(p.PGTID, payload[payloadIndex]>>5)
+		p.PGU = append(p.PGU, payload[payloadIndex]&0x10 != 0)
+		references := int((payload[payloadIndex] >> 2) & 0x3)
+		payloadIndex++
+
+		if len(payload) < payloadIndex+references {
+			return payloadIndex, errShortPacket
+		}
+		pDiff := make([]uint8, references)
+		copy(pDiff, payload[payloadIndex:payloadIndex+references])
+		p.PGPDiff = append(p.PGPDiff, pDiff)
+		payloadIndex += references
+	}
+
+	return payloadIndex, nil
+}
+
+// VP9PartitionHeadChecker checks VP9 partition head
+type VP9PartitionHeadChecker struct{}
+
+// IsPartitionHead checks whether payload begins a VP9 frame, from the B bit
+// of its payload descriptor
+func (*VP9PartitionHeadChecker) IsPartitionHead(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+	return payload[0]&0x08 != 0
+}
@@ -0,0 +1,156 @@
+package codecs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func TestVP9PacketUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		Name      string
+		Data      []byte
+		Want      VP9Packet
+		WantError error
+	}{
+		{
+			Name:      "empty",
+			Data:      []byte{},
+			WantError: errShortPacket,
+		},
+		{
+			Name: "no headers",
+			Data: []byte{0x0c, 0xaa},
+			Want: VP9Packet{B: true, E: true, Payload: []byte{0xaa}},
+		},
+		{
+			Name: "non-flexible with layer indices",
+			Data: []byte{0xa8, 0x81, 0x23, 0x35, 0x07, 0xaa},
+			Want: VP9Packet{
+				I: true, L: true, B: true,
+				PictureID: 0x0123,
+				TID:       1, U: true, SID: 2, D: true,
+				TL0PICIDX: 0x07,
+				Payload:   []byte{0xaa},
+			},
+		},
+		{
+			Name: "flexible with reference indices",
+			Data: []byte{0xd8, 0x05, 0x03, 0x04, 0xaa},
+			Want: VP9Packet{
+				I: true, P: true, F: true, B: true,
+				PictureID: 0x05,
+				PDiff:     []uint8{1, 2},
+				Payload:   []byte{0xaa},
+			},
+		},
+		{
+			Name: "scalability structure",
+			Data: []byte{
+				0x0a,
+				// N_S=1, Y=1, G=1
+				0x38,
+				0x01, 0x40, 0x00, 0xb4, 0x02, 0x80, 0x01, 0x68,
+				// N_G=2: T=0 with no references, T=1 with one
+				0x02, 0x00, 0x34, 0x01,
+				0xaa,
+			},
+			Want: VP9Packet{
+				B: true, V: true,
+				NS: 1, Y: true, G: true, NG: 2,
+				Width:   []uint16{320, 640},
+				Height:  []uint16{180, 360},
+				PGTID:   []uint8{0, 1},
+				PGU:     []bool{false, true},
+				PGPDiff: [][]uint8{{}, {1}},
+				Payload: []byte{0xaa},
+			},
+		},
+		{
+			Name:      "truncated picture ID",
+			Data:      []byte{0x80, 0x81},
+			WantError: errShortPacket,
+		},
+		{
+			Name:      "truncated TL0PICIDX",
+			Data:      []byte{0x20, 0x00},
+			WantError: errShortPacket,
+		},
+		{
+			Name:      "truncated scalability structure",
+			Data:      []byte{0x02, 0x10, 0x01, 0x40},
+			WantError: errShortPacket,
+		},
+	} {
+		var got VP9Packet
+		payload, err := got.Unmarshal(&rtp.Packet{Payload: test.Data})
+		if err != test.WantError {
+			t.Errorf("Unmarshal %q: err = %v, want %v", test.Name, err, test.WantError)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("Unmarshal %q: got %#v, want %#v", test.Name, got, test.Want)
+		}
+		if !bytes.Equal(payload, test.Want.Payload) {
+			t.Errorf("Unmarshal %q: returned payload %#v, want %#v", test.Name, payload, test.Want.Payload)
+		}
+	}
+
+	var p VP9Packet
+	if _, err := p.Unmarshal(&rtp.Packet{Payload: []byte{0x58, 0x03, 0x03, 0x03, 0x02}}); err == nil {
+		t.Error("Unmarshal accepted more than 3 reference indices")
+	}
+}
+
+func TestVP9PayloaderRoundTrip(t *testing.T) {
+	keyFrame := append([]byte{0x80}, make([]byte, 20)...)
+	interFrame := append([]byte{0x84}, make([]byte, 20)...)
+
+	payloader := &VP9Payloader{}
+	checker := &VP9PartitionHeadChecker{}
+
+	for picture, frame := range [][]byte{keyFrame, interFrame} {
+		payloads := payloader.Payload(10, frame)
+
+		var reassembled []byte
+		for i, payload := range payloads {
+			if len(payload) > 10 {
+				t.Errorf("payload %d is %d octets, larger than the MTU", i, len(payload))
+			}
+			if got, want := checker.IsPartitionHead(payload), i == 0; got != want {
+				t.Errorf("payload %d IsPartitionHead = %t, want %t", i, got, want)
+			}
+
+			var p VP9Packet
+			data, err := p.Unmarshal(&rtp.Packet{Payload: payload})
+			if err != nil {
+				t.Fatalf("payload %d Unmarshal error: %v", i, err)
+			}
+			if !p.F || !p.I || int(p.PictureID) != picture {
+				t.Errorf("payload %d descriptor %#v", i, p)
+			}
+			if got, want := p.E, i == len(payloads)-1; got != want {
+				t.Errorf("payload %d E = %t, want %t", i, got, want)
+			}
+
+			isInter := picture == 1
+			if p.P != isInter {
+				t.Errorf("payload %d P = %t, want %t", i, p.P, isInter)
+			}
+			if isInter && !reflect.DeepEqual(p.PDiff, []uint8{1}) {
+				t.Errorf("payload %d PDiff = %v, want [1]", i, p.PDiff)
+			}
+			reassembled = append(reassembled, data...)
+		}
+
+		if !bytes.Equal(reassembled, frame) {
+			t.Errorf("reassembled frame %#v, want %#v", reassembled, frame)
+		}
+	}
+}
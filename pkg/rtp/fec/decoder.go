@@ -0,0 +1,111 @@
+package fec
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+// maxAge is how far behind the newest media packet a packet is kept, to
+// recover those protected with it
+const maxAge = 2 * MaxGroupSize
+
+// Decoder recovers lost media packets from the FEC packets received with
+// them
+type Decoder struct {
+	ssrc        uint32
+	payloadType uint8
+
+	hasNewest bool
+	newest    uint16
+	media     map[uint16][]byte
+	fec       []*fecPacket
+}
+
+// NewDecoder creates a Decoder for the media stream ssrc, protected by FEC
+// packets of payloadType
+func NewDecoder(ssrc uint32, payloadType uint8) *Decoder {
+	return &Decoder{
+		ssrc:        ssrc,
+		payloadType: payloadType,
+		media:       map[uint16][]byte{},
+	}
+}
+
+// Push adds a received media or FEC packet, told apart by payload type, and
+// returns any media packets recovered with it. Media packets must be
+// pushed as received, before any padding is trimmed.
+func (d *Decoder) Push(p *rtp.Packet) []*rtp.Packet {
+	if p.PayloadType == d.payloadType {
+		f, err := parseFECPacket(p.Payload)
+		if err != nil {
+			return nil
+		}
+		d.fec = append(d.fec, f)
+	} else {
+		if p.SSRC != d.ssrc {
+			return nil
+		}
+		raw, err := rawPacket(p)
+		if err != nil {
+			return nil
+		}
+		d.addMedia(p.SequenceNumber, raw)
+	}
+
+	return d.recoverPackets()
+}
+
+func (d *Decoder) addMedia(seq uint16, raw []byte) {
+	d.media[seq] = raw
+	if d.hasNewest && !rtp.SequenceNumberAfter(seq, d.newest) {
+		return
+	}
+	d.hasNewest = true
+	d.newest = seq
+
+	for s := range d.media {
+		if rtp.SequenceNumberDiff(s, d.newest) > maxAge {
+			delete(d.media, s)
+		}
+	}
+}
+
+// recoverPackets rebuilds every packet that can be, until no more can
+func (d *Decoder) recoverPackets() []*rtp.Packet {
+	var recovered []*rtp.Packet
+	for progress := true; progress; {
+		progress = false
+
+		kept := d.fec[:0]
+		for _, f := range d.fec {
+			// Too old for the packets it protects to be kept
+			if d.hasNewest && rtp.SequenceNumberDiff(f.snBase, d.newest) > maxAge {
+				continue
+			}
+
+			var others [][]byte
+			missing := []uint16{}
+			for _, seq := range f.protected {
+				if raw, ok := d.media[seq]; ok {
+					others = append(others, raw)
+				} else {
+					missing = append(missing, seq)
+				}
+			}
+
+			switch len(missing) {
+			case 0:
+			case 1:
+				if p, err := f.rebuild(missing[0], d.ssrc, others); err == nil {
+					d.addMedia(p.SequenceNumber, p.Raw)
+					recovered = append(recovered, p)
+					progress = true
+				}
+			default:
+				kept = append(kept, f)
+			}
+		}
+		d.fec = kept
+	}
+
+	return recovered
+}
@@ -0,0 +1,83 @@
+package fec
+
+import (
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+// Encoder generates FEC packets for a media stream as its packets are sent
+type Encoder struct {
+	payloadType uint8
+	ssrc        uint32
+	sequencer   rtp.Sequencer
+	groupSize   int
+
+	group []*rtp.Packet
+}
+
+// NewEncoder creates an Encoder sending FEC packets of payloadType on the
+// stream ssrc, each protecting groupSize media packets. A larger groupSize
+// costs less bandwidth but recovers fewer losses. It is limited to
+// MaxGroupSize.
+func NewEncoder(payloadType uint8, ssrc uint32, sequencer rtp.Sequencer, groupSize int) *Encoder {
+	if groupSize < 1 {
+		groupSize = 1
+	} else if groupSize > MaxGroupSize {
+		groupSize = MaxGroupSize
+	}
+
+	return &Encoder{
+		payloadType: payloadType,
+		ssrc:        ssrc,
+		sequencer:   sequencer,
+		groupSize:   groupSize,
+	}
+}
+
+// Protect records a media packet about to be sent, which must not be
+// changed afterwards, and returns a FEC packet to send after it once a
+// group is complete. Packets must be protected in the order they are sent.
+func (e *Encoder) Protect(p *rtp.Packet) (*rtp.Packet, error) {
+	var out *rtp.Packet
+
+	// A packet too far from the start of the group for the mask to cover
+	// ends it early
+	if len(e.group) > 0 && rtp.SequenceNumberDiff(e.group[0].SequenceNumber, p.SequenceNumber) >= MaxGroupSize {
+		var err error
+		if out, err = e.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	e.group = append(e.group, p)
+	if len(e.group) < e.groupSize {
+		return out, nil
+	}
+	return e.Flush()
+}
+
+// Flush returns a FEC packet for the media packets protected since the
+// last one, or nil if there are none
+func (e *Encoder) Flush() (*rtp.Packet, error) {
+	if len(e.group) == 0 {
+		return nil, nil
+	}
+	group := e.group
+	e.group = nil
+
+	payload, err := encode(group)
+	if err != nil {
+		return nil, err
+	}
+
+	// The timestamp is that of the media packet sent before it
+	return &rtp.Packet{
+		Header: rtp.Header{
+			Version:        rtpVersion,
+			PayloadType:    e.payloadType,
+			SequenceNumber: e.sequencer.NextSequenceNumber(),
+			Timestamp:      group[len(group)-1].Timestamp,
+			SSRC:           e.ssrc,
+		},
+		Payload: payload,
+	}, nil
+}
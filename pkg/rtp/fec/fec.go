@@ -0,0 +1,198 @@
+// Package fec implements forward error correction for RTP streams using
+// the ULPFEC format of RFC 5109. FEC packets are sent as a stream of their
+// own, each protecting a group of consecutive media packets, and any one
+// packet lost from a group can be rebuilt from the others.
+package fec
+
+import (
+	"encoding/binary"
+
+	"github.com/pions/webrtc/pkg/rtp"
+	"github.com/pkg/errors"
+)
+
+const (
+	rtpHeaderLength = 12
+	rtpVersion      = 2
+	rtpVersionShift = 6
+
+	fecHeaderLength        = 10
+	levelHeaderLengthShort = 4
+	levelHeaderLengthLong  = 8
+
+	// MaxGroupSize is the most media packets a FEC packet can protect,
+	// limited by the size of the long mask
+	MaxGroupSize  = 48
+	shortMaskBits = 16
+
+	eBit          = 0x80
+	lBit          = 0x40
+	recoveryMask0 = 0x3F
+)
+
+var errInvalidFECPacket = errors.New("invalid FEC packet")
+
+// rawPacket returns the encoding of p that was, or will be, sent
+func rawPacket(p *rtp.Packet) ([]byte, error) {
+	if p.Raw != nil {
+		return p.Raw, nil
+	}
+	return p.Marshal()
+}
+
+// xorInto XORs src into the start of dst, which must be at least as long
+func xorInto(dst, src []byte) {
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+}
+
+// fecPacket is a parsed FEC packet, holding what is needed to recover one
+// of the media packets it protects
+type fecPacket struct {
+	snBase    uint16
+	protected []uint16
+
+	header []byte
+	data   []byte
+}
+
+func parseFECPacket(payload []byte) (*fecPacket, error) {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |E|L|P|X|  CC   |M| PT recovery |            SN base            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                          TS recovery                          |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |        length recovery        |       Protection Length       |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |             mask              | mask cont. (present only when |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |  L = 1)                       |   protected data ...          |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	if len(payload) < fecHeaderLength+levelHeaderLengthShort || payload[0]&eBit != 0 {
+		return nil, errInvalidFECPacket
+	}
+
+	levelHeaderLength, maskBits := levelHeaderLengthShort, shortMaskBits
+	if payload[0]&lBit != 0 {
+		levelHeaderLength, maskBits = levelHeaderLengthLong, MaxGroupSize
+	}
+	if len(payload) < fecHeaderLength+levelHeaderLength {
+		return nil, errInvalidFECPacket
+	}
+
+	level := payload[fecHeaderLength:]
+	protectionLength := int(binary.BigEndian.Uint16(level))
+	data := payload[fecHeaderLength+levelHeaderLength:]
+	if len(data) < protectionLength {
+		return nil, errInvalidFECPacket
+	}
+
+	f := &fecPacket{
+		snBase: binary.BigEndian.Uint16(payload[2:]),
+		header: payload[:fecHeaderLength],
+		data:   data[:protectionLength],
+	}
+
+	mask := level[2:levelHeaderLength]
+	for i := 0; i < maskBits; i++ {
+		if mask[i/8]&(0x80>>uint(i%8)) != 0 {
+			f.protected = append(f.protected, f.snBase+uint16(i))
+		}
+	}
+	if len(f.protected) == 0 {
+		return nil, errInvalidFECPacket
+	}
+
+	return f, nil
+}
+
+// encode returns the payload of a FEC packet protecting packets, which
+// must be sorted by sequence number and span at most MaxGroupSize
+func encode(packets []*rtp.Packet) ([]byte, error) {
+	snBase := packets[0].SequenceNumber
+	long := rtp.SequenceNumberDiff(snBase, packets[len(packets)-1].SequenceNumber) >= shortMaskBits
+
+	raws := make([][]byte, len(packets))
+	protectionLength := 0
+	for i, p := range packets {
+		raw, err := rawPacket(p)
+		if err != nil {
+			return nil, err
+		}
+		raws[i] = raw
+		if l := len(raw) - rtpHeaderLength; l > protectionLength {
+			protectionLength = l
+		}
+	}
+
+	levelHeaderLength := levelHeaderLengthShort
+	if long {
+		levelHeaderLength = levelHeaderLengthLong
+	}
+
+	payload := make([]byte, fecHeaderLength+levelHeaderLength+protectionLength)
+	header, level := payload[:fecHeaderLength], payload[fecHeaderLength:]
+	data := level[levelHeaderLength:]
+
+	var lengthRecovery uint16
+	for i, raw := range raws {
+		xorInto(header[:2], raw[:2])
+		xorInto(header[4:8], raw[4:8])
+		lengthRecovery ^= uint16(len(raw) - rtpHeaderLength)
+		xorInto(data, raw[rtpHeaderLength:])
+
+		offset := rtp.SequenceNumberDiff(snBase, packets[i].SequenceNumber)
+		level[2+offset/8] |= 0x80 >> uint(offset%8)
+	}
+
+	header[0] &= recoveryMask0
+	if long {
+		header[0] |= lBit
+	}
+	binary.BigEndian.PutUint16(header[2:], snBase)
+	binary.BigEndian.PutUint16(header[8:], lengthRecovery)
+	binary.BigEndian.PutUint16(level, uint16(protectionLength))
+
+	return payload, nil
+}
+
+// rebuild returns the packet numbered seq protected by f, from the raw
+// encodings of the others it protects
+func (f *fecPacket) rebuild(seq uint16, ssrc uint32, others [][]byte) (*rtp.Packet, error) {
+	header := append([]byte{}, f.header...)
+	data := append([]byte{}, f.data...)
+
+	lengthRecovery := binary.BigEndian.Uint16(header[8:])
+	for _, raw := range others {
+		if len(raw) < rtpHeaderLength || len(raw)-rtpHeaderLength > len(data) {
+			return nil, errInvalidFECPacket
+		}
+		xorInto(header[:2], raw[:2])
+		xorInto(header[4:8], raw[4:8])
+		lengthRecovery ^= uint16(len(raw) - rtpHeaderLength)
+		xorInto(data, raw[rtpHeaderLength:])
+	}
+	if int(lengthRecovery) > len(data) {
+		return nil, errInvalidFECPacket
+	}
+
+	raw := make([]byte, rtpHeaderLength+int(lengthRecovery))
+	raw[0] = rtpVersion<<rtpVersionShift | header[0]&recoveryMask0
+	raw[1] = header[1]
+	binary.BigEndian.PutUint16(raw[2:], seq)
+	copy(raw[4:8], header[4:8])
+	binary.BigEndian.PutUint32(raw[8:], ssrc)
+	copy(raw[rtpHeaderLength:], data)
+
+	p := &rtp.Packet{}
+	if err := p.Unmarshal(raw); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
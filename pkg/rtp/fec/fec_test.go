@@ -0,0 +1,166 @@
+package fec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pions/webrtc/pkg/rtp"
+)
+
+func mediaPacket(seq uint16, payload []byte) *rtp.Packet {
+	return &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			Marker:         seq%3 == 0,
+			PayloadType:    96,
+			SequenceNumber: seq,
+			Timestamp:      uint32(seq/3) * 3000,
+			SSRC:           0x1234,
+			CSRC:           []uint32{},
+		},
+		Payload: payload,
+	}
+}
+
+// receive marshals and unmarshals p, as if it had been sent
+func receive(t *testing.T, p *rtp.Packet) *rtp.Packet {
+	raw, err := p.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	received := &rtp.Packet{}
+	if err := received.Unmarshal(raw); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	return received
+}
+
+func TestFEC(t *testing.T) {
+	for _, groupSize := range []int{4, 20} {
+		encoder := NewEncoder(127, 0x5678, rtp.NewFixedSequencer(100), groupSize)
+		decoder := NewDecoder(0x1234, 127)
+
+		// The first packet of each group is lost, and sequence numbers
+		// wrap in the middle
+		sent := map[uint16]*rtp.Packet{}
+		recovered := map[uint16]*rtp.Packet{}
+		for i := 0; i < 4*groupSize; i++ {
+			seq := uint16(65536 - 2*groupSize + i)
+			p := mediaPacket(seq, make([]byte, 1+i%7))
+			for j := range p.Payload {
+				p.Payload[j] = byte(i + j)
+			}
+			sent[seq] = p
+
+			f, err := encoder.Protect(p)
+			if err != nil {
+				t.Fatalf("Protect error: %v", err)
+			}
+
+			if i%groupSize != 0 {
+				for _, r := range decoder.Push(receive(t, p)) {
+					recovered[r.SequenceNumber] = r
+				}
+			}
+			if f != nil {
+				if f.SSRC != 0x5678 || f.PayloadType != 127 {
+					t.Errorf("FEC packet has header %#v", f.Header)
+				}
+				for _, r := range decoder.Push(receive(t, f)) {
+					recovered[r.SequenceNumber] = r
+				}
+			}
+		}
+
+		if got, want := len(recovered), 4; got != want {
+			t.Fatalf("groups of %d: recovered %d packets, want %d", groupSize, got, want)
+		}
+		for seq, r := range recovered {
+			want := sent[seq]
+			if !reflect.DeepEqual(r.Header, want.Header) || !reflect.DeepEqual(r.Payload, want.Payload) {
+				t.Errorf("recovered %#v %v, want %#v %v", r.Header, r.Payload, want.Header, want.Payload)
+			}
+		}
+	}
+}
+
+func TestFECOutOfOrder(t *testing.T) {
+	encoder := NewEncoder(127, 0x5678, rtp.NewFixedSequencer(100), 3)
+	decoder := NewDecoder(0x1234, 127)
+
+	var packets []*rtp.Packet
+	var fec *rtp.Packet
+	for seq := uint16(1); seq <= 3; seq++ {
+		p := mediaPacket(seq, []byte{byte(seq), 0xff})
+		packets = append(packets, p)
+
+		f, err := encoder.Protect(p)
+		if err != nil {
+			t.Fatalf("Protect error: %v", err)
+		}
+		fec = f
+	}
+	if fec == nil {
+		t.Fatalf("no FEC packet after a full group")
+	}
+
+	// The FEC packet arrives first, and two packets are lost
+	if r := decoder.Push(receive(t, fec)); len(r) != 0 {
+		t.Errorf("recovered %d packets with none received", len(r))
+	}
+	if r := decoder.Push(receive(t, packets[2])); len(r) != 0 {
+		t.Errorf("recovered %d packets with two lost", len(r))
+	}
+
+	// Once one turns up the other is recovered
+	r := decoder.Push(receive(t, packets[0]))
+	if len(r) != 1 || r[0].SequenceNumber != 2 || !reflect.DeepEqual(r[0].Payload, []byte{0x02, 0xff}) {
+		t.Errorf("recovered %v", r)
+	}
+}
+
+func TestEncoderFlush(t *testing.T) {
+	encoder := NewEncoder(127, 0x5678, rtp.NewFixedSequencer(100), 10)
+	if f, err := encoder.Flush(); f != nil || err != nil {
+		t.Errorf("Flush with nothing protected = %v, %v", f, err)
+	}
+
+	if f, err := encoder.Protect(mediaPacket(1, []byte{0x01})); f != nil || err != nil {
+		t.Fatalf("Protect = %v, %v", f, err)
+	}
+
+	// A packet beyond the reach of the mask ends the group
+	f, err := encoder.Protect(mediaPacket(1+MaxGroupSize, []byte{0x02}))
+	if err != nil || f == nil {
+		t.Fatalf("Protect = %v, %v, want a FEC packet", f, err)
+	}
+
+	parsed, err := parseFECPacket(f.Payload)
+	if err != nil {
+		t.Fatalf("parseFECPacket error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed.protected, []uint16{1}) {
+		t.Errorf("FEC packet protects %v, want [1]", parsed.protected)
+	}
+
+	if f, err := encoder.Flush(); f == nil || err != nil {
+		t.Errorf("Flush = %v, %v, want a FEC packet", f, err)
+	}
+}
+
+func TestParseFECPacketInvalid(t *testing.T) {
+	for _, payload := range [][]byte{
+		nil,
+		make([]byte, fecHeaderLength),
+		// E bit set
+		append([]byte{0x80}, make([]byte, 13)...),
+		// Protection length beyond the packet
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x00, 0x10, 0x80, 0x00},
+		// Empty mask
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x00, 0x00, 0x00, 0x00},
+	} {
+		if _, err := parseFECPacket(payload); err == nil {
+			t.Errorf("parseFECPacket(%v) succeeded, want error", payload)
+		}
+	}
+}
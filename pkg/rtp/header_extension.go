@@ -0,0 +1,192 @@
+package rtp
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Header extension profiles defined by RFC 8285. The low four bits of the
+// two-byte profile are application specific.
+const (
+	ExtensionProfileOneByte = 0xBEDE
+	ExtensionProfileTwoByte = 0x1000
+
+	extensionProfileTwoByteMask = 0xFFF0
+
+	oneByteExtensionIDMax      = 14
+	oneByteExtensionIDReserved = 15
+	oneByteExtensionLengthMax  = 16
+	twoByteExtensionLengthMax  = 255
+)
+
+// Extension is a single RFC 8285 header extension element
+type Extension struct {
+	ID      uint8
+	Payload []byte
+}
+
+// isRFC8285Profile reports whether the extension data is made of RFC 8285
+// elements rather than an opaque block
+func isRFC8285Profile(profile uint16) bool {
+	return profile == ExtensionProfileOneByte || profile&extensionProfileTwoByteMask == ExtensionProfileTwoByte
+}
+
+// unmarshalExtensions parses the elements of an RFC 8285 extension block
+func unmarshalExtensions(profile uint16, payload []byte) ([]Extension, error) {
+	var extensions []Extension
+
+	for i := 0; i < len(payload); {
+		// Padding may appear between and after elements
+		if payload[i] == 0 {
+			i++
+			continue
+		}
+
+		var id uint8
+		var length int
+		if profile == ExtensionProfileOneByte {
+			/*
+			 *  0 1 2 3 4 5 6 7
+			 * +-+-+-+-+-+-+-+-+
+			 * |  ID   |  len  |
+			 * +-+-+-+-+-+-+-+-+
+			 */
+			id = payload[i] >> 4
+			length = int(payload[i]&0x0F) + 1
+			i++
+
+			// The reserved ID ends processing of the block
+			if id == oneByteExtensionIDReserved {
+				break
+			}
+		} else {
+			/*
+			 *  0                   1
+			 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5
+			 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+			 * |       ID      |     length    |
+			 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+			 */
+			if i+1 >= len(payload) {
+				return nil, errors.Errorf("RTP header extension element truncated at offset %d", i)
+			}
+			id = payload[i]
+			length = int(payload[i+1])
+			i += 2
+		}
+
+		if i+length > len(payload) {
+			return nil, errors.Errorf("RTP header extension %d size insufficient; %d < %d", id, len(payload)-i, length)
+		}
+
+		extensions = append(extensions, Extension{ID: id, Payload: payload[i : i+length]})
+		i += length
+	}
+
+	return extensions, nil
+}
+
+// marshalExtensions encodes extensions as an RFC 8285 block of the given
+// profile, zero padded to a multiple of 32 bits
+func marshalExtensions(profile uint16, extensions []Extension) ([]byte, error) {
+	var payload []byte
+	for _, e := range extensions {
+		if profile == ExtensionProfileOneByte {
+			if e.ID == 0 || e.ID > oneByteExtensionIDMax {
+				return nil, errors.Errorf("RTP one-byte header extension ID out of range; %d", e.ID)
+			}
+			if len(e.Payload) == 0 || len(e.Payload) > oneByteExtensionLengthMax {
+				return nil, errors.Errorf("RTP one-byte header extension %d has invalid size; %d", e.ID, len(e.Payload))
+			}
+			payload = append(payload, e.ID<<4|uint8(len(e.Payload)-1))
+		} else {
+			if e.ID == 0 {
+				return nil, errors.Errorf("RTP two-byte header extension ID out of range; %d", e.ID)
+			}
+			if len(e.Payload) > twoByteExtensionLengthMax {
+				return nil, errors.Errorf("RTP two-byte header extension %d has invalid size; %d", e.ID, len(e.Payload))
+			}
+			payload = append(payload, e.ID, uint8(len(e.Payload)))
+		}
+		payload = append(payload, e.Payload...)
+	}
+
+	if padLen := (4 - len(payload)%4) % 4; padLen != 0 {
+		payload = append(payload, make([]byte, padLen)...)
+	}
+	return payload, nil
+}
+
+// GetExtension returns the payload of the RFC 8285 extension with the
+// given ID, or nil if the header doesn't carry it
+func (h *Header) GetExtension(id uint8) []byte {
+	for _, e := range h.Extensions {
+		if e.ID == id {
+			return e.Payload
+		}
+	}
+	return nil
+}
+
+// GetExtensionIDs returns the IDs of the RFC 8285 extensions in the header,
+// in the order they appear
+func (h *Header) GetExtensionIDs() []uint8 {
+	ids := make([]uint8, 0, len(h.Extensions))
+	for _, e := range h.Extensions {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+// SetExtension sets the payload of the RFC 8285 extension with the given
+// ID, replacing any existing value. The header is switched to the one-byte
+// profile if it carries no extensions yet, and to the two-byte profile if
+// id or payload don't fit in the one-byte form, so elements of both sizes
+// can be mixed in a single header.
+func (h *Header) SetExtension(id uint8, payload []byte) error {
+	if id == 0 {
+		return errors.Errorf("RTP header extension ID out of range; %d", id)
+	}
+	if len(payload) > twoByteExtensionLengthMax {
+		return errors.Errorf("RTP header extension %d has invalid size; %d", id, len(payload))
+	}
+
+	if !h.Extension || !isRFC8285Profile(h.ExtensionProfile) {
+		if h.Extension && len(h.ExtensionPayload) != 0 {
+			return errors.Errorf("RTP header already carries extension profile %#x", h.ExtensionProfile)
+		}
+		h.Extension = true
+		h.ExtensionProfile = ExtensionProfileOneByte
+		h.ExtensionPayload = nil
+	}
+
+	if h.ExtensionProfile == ExtensionProfileOneByte &&
+		(id > oneByteExtensionIDMax || len(payload) == 0 || len(payload) > oneByteExtensionLengthMax) {
+		h.ExtensionProfile = ExtensionProfileTwoByte
+	}
+
+	for i := range h.Extensions {
+		if h.Extensions[i].ID == id {
+			h.Extensions[i].Payload = payload
+			return nil
+		}
+	}
+	h.Extensions = append(h.Extensions, Extension{ID: id, Payload: payload})
+	return nil
+}
+
+// DelExtension removes the RFC 8285 extension with the given ID. When the
+// last extension is removed the header no longer carries an extension.
+func (h *Header) DelExtension(id uint8) error {
+	for i, e := range h.Extensions {
+		if e.ID == id {
+			h.Extensions = append(h.Extensions[:i], h.Extensions[i+1:]...)
+			if len(h.Extensions) == 0 {
+				h.Extensions = nil
+				h.Extension = false
+				h.ExtensionProfile = 0
+			}
+			return nil
+		}
+	}
+	return errors.Errorf("RTP header extension %d not found", id)
+}
@@ -0,0 +1,41 @@
+package rtp
+
+import (
+	"github.com/pkg/errors"
+)
+
+// HeaderExtensionMap holds the header extensions a session negotiated,
+// the URIs of the extensions keyed by the IDs they are sent with. Both
+// sides of the session agree on it through SDP a=extmap attributes.
+type HeaderExtensionMap map[uint8]string
+
+// ID returns the ID the extension with the given URI is sent with, false
+// if it wasn't negotiated
+func (m HeaderExtensionMap) ID(uri string) (uint8, bool) {
+	for id, mapped := range m {
+		if mapped == uri {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// GetExtension returns the payload of the extension with the given URI,
+// or nil if it wasn't negotiated or the header doesn't carry it
+func (m HeaderExtensionMap) GetExtension(h *Header, uri string) []byte {
+	id, ok := m.ID(uri)
+	if !ok {
+		return nil
+	}
+	return h.GetExtension(id)
+}
+
+// SetExtension sets the payload of the extension with the given URI, it
+// fails if the extension wasn't negotiated
+func (m HeaderExtensionMap) SetExtension(h *Header, uri string, payload []byte) error {
+	id, ok := m.ID(uri)
+	if !ok {
+		return errors.Errorf("RTP header extension %s was not negotiated", uri)
+	}
+	return h.SetExtension(id, payload)
+}
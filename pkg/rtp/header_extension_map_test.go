@@ -0,0 +1,34 @@
+package rtp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHeaderExtensionMap(t *testing.T) {
+	m := HeaderExtensionMap{1: MIDURI, 3: AudioLevelURI}
+	if id, ok := m.ID(AudioLevelURI); !ok || id != 3 {
+		t.Errorf("ID = %d %v, want 3 true", id, ok)
+	}
+	if _, ok := m.ID(TransportCCURI); ok {
+		t.Error("ID found for an extension that wasn't negotiated")
+	}
+
+	h := &Header{Version: 2}
+	if err := m.SetExtension(h, MIDURI, []byte("audio")); err != nil {
+		t.Fatalf("SetExtension error: %v", err)
+	}
+	if got := h.GetExtension(1); !bytes.Equal(got, []byte("audio")) {
+		t.Errorf("Extension 1 = %#v, want %#v", got, []byte("audio"))
+	}
+	if got := m.GetExtension(h, MIDURI); !bytes.Equal(got, []byte("audio")) {
+		t.Errorf("GetExtension = %#v, want %#v", got, []byte("audio"))
+	}
+	if got := m.GetExtension(h, AudioLevelURI); got != nil {
+		t.Errorf("GetExtension = %#v for an extension the header doesn't carry", got)
+	}
+
+	if err := m.SetExtension(h, TransportCCURI, []byte{0, 1}); err == nil {
+		t.Error("SetExtension accepted an extension that wasn't negotiated")
+	}
+}
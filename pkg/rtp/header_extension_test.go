@@ -0,0 +1,167 @@
+package rtp
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestHeaderExtensionUnmarshal(t *testing.T) {
+	fixedHeader := []byte{0x90, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03}
+
+	for _, test := range []struct {
+		Name      string
+		Extension []byte
+		Want      []Extension
+		WantError bool
+	}{
+		{
+			Name: "one-byte",
+			Extension: []byte{
+				0xbe, 0xde, 0x00, 0x02,
+				// ID 1, 3 octets; ID 2, 1 octet
+				0x12, 0xaa, 0xbb, 0xcc, 0x20, 0xdd, 0x00, 0x00,
+			},
+			Want: []Extension{
+				{ID: 1, Payload: []byte{0xaa, 0xbb, 0xcc}},
+				{ID: 2, Payload: []byte{0xdd}},
+			},
+		},
+		{
+			Name: "one-byte truncated element",
+			Extension: []byte{
+				0xbe, 0xde, 0x00, 0x01,
+				0x10, 0xaa, 0x00, 0x30,
+				// 0x30 is ID 3 with 1 octet, which is missing
+			},
+			WantError: true,
+		},
+		{
+			Name: "one-byte reserved ID stops parsing",
+			Extension: []byte{
+				0xbe, 0xde, 0x00, 0x01,
+				0x10, 0xaa, 0xf0, 0x20,
+			},
+			Want: []Extension{
+				{ID: 1, Payload: []byte{0xaa}},
+			},
+		},
+		{
+			Name: "two-byte",
+			Extension: []byte{
+				0x10, 0x00, 0x00, 0x02,
+				// ID 1, empty; ID 20, 3 octets
+				0x01, 0x00, 0x00, 0x14, 0x03, 0xaa, 0xbb, 0xcc,
+			},
+			Want: []Extension{
+				{ID: 1, Payload: []byte{}},
+				{ID: 20, Payload: []byte{0xaa, 0xbb, 0xcc}},
+			},
+		},
+		{
+			Name: "two-byte truncated element",
+			Extension: []byte{
+				0x10, 0x00, 0x00, 0x01,
+				0x01, 0x04, 0xaa, 0xbb,
+			},
+			WantError: true,
+		},
+	} {
+		h := &Header{}
+		err := h.Unmarshal(append(append([]byte{}, fixedHeader...), test.Extension...))
+		if got, want := err != nil, test.WantError; got != want {
+			t.Fatalf("Unmarshal %q: err = %v, want error %t", test.Name, err, want)
+		}
+		if err != nil {
+			continue
+		}
+
+		if got, want := h.Extensions, test.Want; !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal %q: got %v, want %v", test.Name, got, want)
+		}
+		if h.ExtensionPayload != nil {
+			t.Errorf("Unmarshal %q: ExtensionPayload set for an RFC 8285 profile", test.Name)
+		}
+	}
+}
+
+func TestHeaderExtensionSet(t *testing.T) {
+	h := &Header{Version: 2}
+
+	if err := h.SetExtension(1, []byte{0xaa, 0xbb, 0xcc}); err != nil {
+		t.Fatalf("SetExtension error: %v", err)
+	}
+	if err := h.SetExtension(2, []byte{0xdd}); err != nil {
+		t.Fatalf("SetExtension error: %v", err)
+	}
+	if got, want := h.ExtensionProfile, uint16(ExtensionProfileOneByte); got != want {
+		t.Errorf("ExtensionProfile = %#x, want %#x", got, want)
+	}
+
+	raw, err := h.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	want := []byte{
+		0x90, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xbe, 0xde, 0x00, 0x02,
+		0x12, 0xaa, 0xbb, 0xcc, 0x20, 0xdd, 0x00, 0x00,
+	}
+	if !bytes.Equal(raw, want) {
+		t.Errorf("Marshal one-byte: got %#v, want %#v", raw, want)
+	}
+
+	// An element too large for the one-byte form switches the whole header
+	// to two-byte elements
+	if err := h.SetExtension(20, make([]byte, 17)); err != nil {
+		t.Fatalf("SetExtension error: %v", err)
+	}
+	if got, want := h.ExtensionProfile, uint16(ExtensionProfileTwoByte); got != want {
+		t.Errorf("ExtensionProfile = %#x, want %#x", got, want)
+	}
+
+	raw, err = h.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	parsed := &Header{}
+	if err := parsed.Unmarshal(raw); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if got, want := parsed.GetExtensionIDs(), []uint8{1, 2, 20}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetExtensionIDs = %v, want %v", got, want)
+	}
+	if got, want := parsed.GetExtension(1), []byte{0xaa, 0xbb, 0xcc}; !bytes.Equal(got, want) {
+		t.Errorf("GetExtension(1) = %#v, want %#v", got, want)
+	}
+	if got := parsed.GetExtension(3); got != nil {
+		t.Errorf("GetExtension(3) = %#v, want nil", got)
+	}
+
+	for _, id := range []uint8{1, 2, 20} {
+		if err := h.DelExtension(id); err != nil {
+			t.Fatalf("DelExtension(%d) error: %v", id, err)
+		}
+	}
+	if h.Extension {
+		t.Error("Extension still set after deleting every element")
+	}
+	if err := h.DelExtension(1); err == nil {
+		t.Error("DelExtension of a missing ID did not error")
+	}
+}
+
+func TestHeaderExtensionSetInvalid(t *testing.T) {
+	h := &Header{}
+	if err := h.SetExtension(0, []byte{0x01}); err == nil {
+		t.Error("SetExtension accepted ID 0")
+	}
+	if err := h.SetExtension(1, make([]byte, 256)); err == nil {
+		t.Error("SetExtension accepted a payload longer than 255 octets")
+	}
+
+	h = &Header{Extension: true, ExtensionProfile: 1, ExtensionPayload: []byte{0x01, 0x02, 0x03, 0x04}}
+	if err := h.SetExtension(1, []byte{0x01}); err == nil {
+		t.Error("SetExtension replaced a non RFC 8285 extension")
+	}
+}
@@ -6,10 +6,8 @@ import (
 	"github.com/pkg/errors"
 )
 
-// Packet represents an RTP Packet
-// RTP is a network protocol for delivering audio and video over IP networks.
-type Packet struct {
-	Raw              []byte
+// Header represents an RTP packet header
+type Header struct {
 	Version          uint8
 	Padding          bool
 	Extension        bool
@@ -21,34 +19,47 @@ type Packet struct {
 	SSRC             uint32
 	CSRC             []uint32
 	ExtensionProfile uint16
+
+	// ExtensionPayload holds the extension data of profiles other than
+	// those of RFC 8285, whose elements are in Extensions instead
 	ExtensionPayload []byte
-	Payload          []byte
+	Extensions       []Extension
+}
+
+// Packet represents an RTP Packet
+// RTP is a network protocol for delivering audio and video over IP networks.
+type Packet struct {
+	Header
+	Raw     []byte
+	Payload []byte
 }
 
 const (
-	headerLength    = 4
-	versionShift    = 6
-	versionMask     = 0x3
-	paddingShift    = 5
-	paddingMask     = 0x1
-	extensionShift  = 4
-	extensionMask   = 0x1
-	ccMask          = 0xF
-	markerShift     = 7
-	markerMask      = 0x1
-	ptMask          = 0x7F
-	seqNumOffset    = 2
-	seqNumLength    = 2
-	timestampOffset = 4
-	timestampLength = 4
-	ssrcOffset      = 8
-	ssrcLength      = 4
-	csrcOffset      = 12
-	csrcLength      = 4
+	headerLength          = 12
+	versionShift          = 6
+	versionMask           = 0x3
+	paddingShift          = 5
+	paddingMask           = 0x1
+	extensionShift        = 4
+	extensionMask         = 0x1
+	ccMask                = 0xF
+	csrcMax               = 15
+	markerShift           = 7
+	markerMask            = 0x1
+	ptMask                = 0x7F
+	seqNumOffset          = 2
+	seqNumLength          = 2
+	timestampOffset       = 4
+	timestampLength       = 4
+	ssrcOffset            = 8
+	ssrcLength            = 4
+	csrcOffset            = 12
+	csrcLength            = 4
+	extensionHeaderLength = 4
 )
 
-// Unmarshal parses the passed byte slice and stores the result in the Packet this method is called upon
-func (p *Packet) Unmarshal(rawPacket []byte) error {
+// Unmarshal parses the passed byte slice and stores the result in the Header this method is called upon
+func (h *Header) Unmarshal(rawPacket []byte) error {
 	if len(rawPacket) < headerLength {
 		return errors.Errorf("RTP header size insufficient; %d < %d", len(rawPacket), headerLength)
 	}
@@ -68,46 +79,98 @@ func (p *Packet) Unmarshal(rawPacket []byte) error {
 	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
 	 */
 
-	p.Version = rawPacket[0] >> versionShift & versionMask
-	p.Padding = (rawPacket[0] >> paddingShift & paddingMask) > 0
-	p.Extension = (rawPacket[0] >> extensionShift & extensionMask) > 0
-	p.CSRC = make([]uint32, rawPacket[0]&ccMask)
+	h.Version = rawPacket[0] >> versionShift & versionMask
+	h.Padding = (rawPacket[0] >> paddingShift & paddingMask) > 0
+	h.Extension = (rawPacket[0] >> extensionShift & extensionMask) > 0
+	h.CSRC = make([]uint32, rawPacket[0]&ccMask)
 
-	p.Marker = (rawPacket[1] >> markerShift & markerMask) > 0
-	p.PayloadType = rawPacket[1] & ptMask
+	h.Marker = (rawPacket[1] >> markerShift & markerMask) > 0
+	h.PayloadType = rawPacket[1] & ptMask
 
-	p.SequenceNumber = binary.BigEndian.Uint16(rawPacket[seqNumOffset : seqNumOffset+seqNumLength])
-	p.Timestamp = binary.BigEndian.Uint32(rawPacket[timestampOffset : timestampOffset+timestampLength])
-	p.SSRC = binary.BigEndian.Uint32(rawPacket[ssrcOffset : ssrcOffset+ssrcLength])
+	h.SequenceNumber = binary.BigEndian.Uint16(rawPacket[seqNumOffset : seqNumOffset+seqNumLength])
+	h.Timestamp = binary.BigEndian.Uint32(rawPacket[timestampOffset : timestampOffset+timestampLength])
+	h.SSRC = binary.BigEndian.Uint32(rawPacket[ssrcOffset : ssrcOffset+ssrcLength])
 
-	currOffset := csrcOffset + (len(p.CSRC) * csrcLength)
+	currOffset := csrcOffset + (len(h.CSRC) * csrcLength)
 	if len(rawPacket) < currOffset {
 		return errors.Errorf("RTP header size insufficient; %d < %d", len(rawPacket), currOffset)
 	}
 
-	for i := range p.CSRC {
+	for i := range h.CSRC {
 		offset := csrcOffset + (i * csrcLength)
-		p.CSRC[i] = binary.BigEndian.Uint32(rawPacket[offset:offset])
+		h.CSRC[i] = binary.BigEndian.Uint32(rawPacket[offset:])
 	}
 
-	if p.Extension {
-		p.ExtensionProfile = binary.BigEndian.Uint16(rawPacket[currOffset:])
+	h.ExtensionProfile = 0
+	h.ExtensionPayload = nil
+	h.Extensions = nil
+	if h.Extension {
+		if len(rawPacket) < currOffset+extensionHeaderLength {
+			return errors.Errorf("RTP header size insufficient for extension; %d < %d", len(rawPacket), currOffset+extensionHeaderLength)
+		}
+
+		h.ExtensionProfile = binary.BigEndian.Uint16(rawPacket[currOffset:])
 		currOffset += 2
-		extensionLength := binary.BigEndian.Uint16(rawPacket[currOffset:])
+		// The extension length is counted in 32-bit words
+		extensionLength := int(binary.BigEndian.Uint16(rawPacket[currOffset:])) * 4
 		currOffset += 2
-		p.ExtensionPayload = rawPacket[currOffset : currOffset+int(extensionLength)]
-		currOffset += len(p.ExtensionPayload) * 4
+
+		if len(rawPacket) < currOffset+extensionLength {
+			return errors.Errorf("RTP header size insufficient for extension; %d < %d", len(rawPacket), currOffset+extensionLength)
+		}
+
+		if isRFC8285Profile(h.ExtensionProfile) {
+			extensions, err := unmarshalExtensions(h.ExtensionProfile, rawPacket[currOffset:currOffset+extensionLength])
+			if err != nil {
+				return err
+			}
+			h.Extensions = extensions
+		} else {
+			h.ExtensionPayload = rawPacket[currOffset : currOffset+extensionLength]
+		}
+		currOffset += extensionLength
+	}
+
+	h.PayloadOffset = currOffset
+	return nil
+}
+
+// Unmarshal parses the passed byte slice and stores the result in the Packet this method is called upon
+func (p *Packet) Unmarshal(rawPacket []byte) error {
+	if err := p.Header.Unmarshal(rawPacket); err != nil {
+		return err
 	}
 
-	p.Payload = rawPacket[currOffset:]
-	p.PayloadOffset = currOffset
+	p.Payload = rawPacket[p.PayloadOffset:]
 	p.Raw = rawPacket
 	return nil
 }
 
-// Marshal returns a raw RTP packet for the instance it is called upon
-func (p *Packet) Marshal() ([]byte, error) {
+// TrimPadding removes the padding octets from the end of the payload of a
+// packet with the padding bit set, and clears the bit. A packet protected
+// with SRTP must be decrypted first, as its padding is encrypted too.
+func (p *Packet) TrimPadding() error {
+	if !p.Padding {
+		return nil
+	}
 
+	if len(p.Payload) == 0 {
+		return errors.Errorf("RTP padding bit set on an empty payload")
+	}
+
+	// The last octet counts the padding octets, including itself
+	padLen := int(p.Payload[len(p.Payload)-1])
+	if padLen == 0 || padLen > len(p.Payload) {
+		return errors.Errorf("RTP padding size invalid; %d of %d octets", padLen, len(p.Payload))
+	}
+
+	p.Payload = p.Payload[:len(p.Payload)-padLen]
+	p.Padding = false
+	return nil
+}
+
+// Marshal returns the raw RTP header for the instance it is called upon
+func (h *Header) Marshal() ([]byte, error) {
 	/*
 	 *  0                   1                   2                   3
 	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
@@ -123,50 +186,70 @@ func (p *Packet) Marshal() ([]byte, error) {
 	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
 	 */
 
-	rawPacketLength := 12 + (len(p.CSRC) * csrcLength)
-	if p.Extension {
-		rawPacketLength += 4 + len(p.ExtensionPayload)
+	if len(h.CSRC) > csrcMax {
+		return nil, errors.Errorf("RTP header has too many CSRCs; %d > %d", len(h.CSRC), csrcMax)
 	}
-	rawPacket := make([]byte, rawPacketLength)
 
-	rawPacket[0] |= p.Version << versionShift
-	if p.Padding {
-		rawPacket[0] |= 1 << paddingShift
-	}
-	if p.Extension {
-		rawPacket[0] |= 1 << extensionShift
-	}
-	rawPacket[0] |= uint8(len(p.CSRC))
+	rawHeaderLength := csrcOffset + (len(h.CSRC) * csrcLength)
+	extensionPayload := h.ExtensionPayload
+	if h.Extension {
+		if isRFC8285Profile(h.ExtensionProfile) {
+			var err error
+			if extensionPayload, err = marshalExtensions(h.ExtensionProfile, h.Extensions); err != nil {
+				return nil, err
+			}
+		}
 
-	if p.Marker {
-		rawPacket[1] |= 1 << markerShift
+		// The extension length is counted in 32-bit words
+		if len(extensionPayload)%4 != 0 {
+			return nil, errors.Errorf("RTP header extension must be a multiple of 32 bits; %d octets", len(extensionPayload))
+		}
+		rawHeaderLength += extensionHeaderLength + len(extensionPayload)
 	}
-	rawPacket[1] |= p.PayloadType
+	rawHeader := make([]byte, rawHeaderLength)
 
-	binary.BigEndian.PutUint16(rawPacket[seqNumOffset:], p.SequenceNumber)
-	binary.BigEndian.PutUint32(rawPacket[timestampOffset:], p.Timestamp)
-	binary.BigEndian.PutUint32(rawPacket[ssrcOffset:], p.SSRC)
+	rawHeader[0] |= h.Version << versionShift
+	if h.Padding {
+		rawHeader[0] |= 1 << paddingShift
+	}
+	if h.Extension {
+		rawHeader[0] |= 1 << extensionShift
+	}
+	rawHeader[0] |= uint8(len(h.CSRC))
 
-	for i, csrc := range p.CSRC {
-		binary.BigEndian.PutUint32(rawPacket[csrcOffset+(i*csrcLength):], csrc)
+	if h.Marker {
+		rawHeader[1] |= 1 << markerShift
 	}
+	rawHeader[1] |= h.PayloadType
 
-	currOffset := csrcOffset + (len(p.CSRC) * csrcLength)
+	binary.BigEndian.PutUint16(rawHeader[seqNumOffset:], h.SequenceNumber)
+	binary.BigEndian.PutUint32(rawHeader[timestampOffset:], h.Timestamp)
+	binary.BigEndian.PutUint32(rawHeader[ssrcOffset:], h.SSRC)
 
-	for i := range p.CSRC {
-		offset := csrcOffset + (i * csrcLength)
-		p.CSRC[i] = binary.BigEndian.Uint32(rawPacket[offset:offset])
+	for i, csrc := range h.CSRC {
+		binary.BigEndian.PutUint32(rawHeader[csrcOffset+(i*csrcLength):], csrc)
 	}
 
-	if p.Extension {
-		binary.BigEndian.PutUint16(rawPacket[currOffset:], p.ExtensionProfile)
+	currOffset := csrcOffset + (len(h.CSRC) * csrcLength)
+
+	if h.Extension {
+		binary.BigEndian.PutUint16(rawHeader[currOffset:], h.ExtensionProfile)
 		currOffset += 2
-		binary.BigEndian.PutUint16(rawPacket[currOffset:], uint16(len(p.ExtensionPayload))/4)
+		binary.BigEndian.PutUint16(rawHeader[currOffset:], uint16(len(extensionPayload)/4))
 		currOffset += 2
-		copy(rawPacket[currOffset:], p.ExtensionPayload)
+		copy(rawHeader[currOffset:], extensionPayload)
 	}
 
-	p.PayloadOffset = csrcOffset + (len(p.CSRC) * csrcLength)
+	h.PayloadOffset = rawHeaderLength
+	return rawHeader, nil
+}
+
+// Marshal returns a raw RTP packet for the instance it is called upon
+func (p *Packet) Marshal() ([]byte, error) {
+	rawPacket, err := p.Header.Marshal()
+	if err != nil {
+		return nil, err
+	}
 
 	rawPacket = append(rawPacket, p.Payload...)
 	p.Raw = rawPacket
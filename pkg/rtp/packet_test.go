@@ -0,0 +1,140 @@
+package rtp
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBasic(t *testing.T) {
+	p := &Packet{}
+
+	if err := p.Unmarshal([]byte{}); err == nil {
+		t.Fatal("Unmarshal did not error on zero length packet")
+	}
+
+	rawPkt := []byte{
+		0x90, 0xe0, 0x69, 0x8f, 0xd9, 0xc2, 0x93, 0xda, 0x1c, 0x64,
+		0x27, 0x82, 0x00, 0x01, 0x00, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0x98, 0x36, 0xbe, 0x88, 0x9e,
+	}
+	parsedPacket := &Packet{
+		Header: Header{
+			Version:          2,
+			Padding:          false,
+			Extension:        true,
+			Marker:           true,
+			PayloadOffset:    20,
+			PayloadType:      96,
+			SequenceNumber:   27023,
+			Timestamp:        3653407706,
+			SSRC:             476325762,
+			CSRC:             []uint32{},
+			ExtensionProfile: 1,
+			ExtensionPayload: []byte{0xFF, 0xFF, 0xFF, 0xFF},
+		},
+		Payload: rawPkt[20:],
+		Raw:     rawPkt,
+	}
+
+	if err := p.Unmarshal(rawPkt); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	} else if !reflect.DeepEqual(p, parsedPacket) {
+		t.Errorf("TestBasic unmarshal: got %#v, want %#v", p, parsedPacket)
+	}
+
+	raw, err := p.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	} else if !bytes.Equal(raw, rawPkt) {
+		t.Errorf("TestBasic marshal: got %#v, want %#v", raw, rawPkt)
+	}
+}
+
+func TestCSRC(t *testing.T) {
+	rawPkt := []byte{
+		0x82, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03,
+		0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x05, 0xaa, 0xbb,
+	}
+
+	p := &Packet{}
+	if err := p.Unmarshal(rawPkt); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if got, want := p.CSRC, []uint32{4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CSRC: got %v, want %v", got, want)
+	}
+	if got, want := p.Payload, []byte{0xaa, 0xbb}; !bytes.Equal(got, want) {
+		t.Errorf("Payload: got %#v, want %#v", got, want)
+	}
+
+	raw, err := p.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	} else if !bytes.Equal(raw, rawPkt) {
+		t.Errorf("CSRC marshal: got %#v, want %#v", raw, rawPkt)
+	}
+}
+
+func TestUnmarshalTruncated(t *testing.T) {
+	for _, test := range []struct {
+		Name string
+		Data []byte
+	}{
+		{
+			Name: "short header",
+			Data: []byte{0x80, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00},
+		},
+		{
+			Name: "missing csrc",
+			Data: []byte{0x82, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x04},
+		},
+		{
+			Name: "missing extension header",
+			Data: []byte{0x90, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x01},
+		},
+		{
+			// The length is counted in 32-bit words, so 1 needs 4 octets
+			Name: "short extension",
+			Data: []byte{0x90, 0x60, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x01, 0x00, 0x01, 0xff, 0xff},
+		},
+	} {
+		p := &Packet{}
+		if err := p.Unmarshal(test.Data); err == nil {
+			t.Errorf("Unmarshal %q: expected an error", test.Name)
+		}
+	}
+}
+
+func TestMarshalInvalid(t *testing.T) {
+	p := &Packet{Header: Header{Version: 2, Extension: true, ExtensionPayload: []byte{0x01, 0x02}}}
+	if _, err := p.Marshal(); err == nil {
+		t.Error("Marshal accepted an extension that isn't a multiple of 32 bits")
+	}
+
+	p = &Packet{Header: Header{Version: 2, CSRC: make([]uint32, 16)}}
+	if _, err := p.Marshal(); err == nil {
+		t.Error("Marshal accepted more than 15 CSRCs")
+	}
+}
+
+func TestTrimPadding(t *testing.T) {
+	p := &Packet{Header: Header{Padding: true}, Payload: []byte{0xaa, 0xbb, 0x00, 0x00, 0x03}}
+	if err := p.TrimPadding(); err != nil {
+		t.Fatalf("TrimPadding error: %v", err)
+	}
+	if !reflect.DeepEqual(p.Payload, []byte{0xaa, 0xbb}) || p.Padding {
+		t.Errorf("TrimPadding left payload %v, padding %t", p.Payload, p.Padding)
+	}
+
+	// Without the padding bit the payload is left alone
+	if err := p.TrimPadding(); err != nil || len(p.Payload) != 2 {
+		t.Errorf("TrimPadding without padding bit: %v, payload %v", err, p.Payload)
+	}
+
+	for _, payload := range [][]byte{{}, {0x01, 0x00}, {0x01, 0x03}} {
+		p := &Packet{Header: Header{Padding: true}, Payload: payload}
+		if err := p.TrimPadding(); err == nil {
+			t.Errorf("TrimPadding of %v succeeded, want error", payload)
+		}
+	}
+}
@@ -10,9 +10,26 @@ type Payloader interface {
 	Payload(mtu int, payload []byte) [][]byte
 }
 
+// Depacketizer depacketizes a RTP payload, returning the codec data it
+// carries with any payload specific headers removed
+type Depacketizer interface {
+	Unmarshal(packet *Packet) ([]byte, error)
+}
+
+// PartitionHeadChecker checks whether a RTP payload is the first of a
+// frame, so frames can be reassembled without relying on timestamps alone
+type PartitionHeadChecker interface {
+	IsPartitionHead(payload []byte) bool
+}
+
+// maxPaddingSize is the most padding octets a packet can carry, as they are
+// counted by a single octet
+const maxPaddingSize = 255
+
 // Packetizer packetizes a payload
 type Packetizer interface {
 	Packetize(payload []byte, samples uint32) []*Packet
+	GeneratePadding(size int) []*Packet
 }
 
 type packetizer struct {
@@ -23,6 +40,10 @@ type packetizer struct {
 	Sequencer   Sequencer
 	Timestamp   uint32
 	ClockRate   uint32
+
+	// Timestamp of the last frame packetized, carried by padding packets
+	hasPacketized bool
+	lastTimestamp uint32
 }
 
 // NewPacketizer returns a new instance of a Packetizer for a specific payloader
@@ -41,25 +62,76 @@ func NewPacketizer(mtu int, pt uint8, ssrc uint32, payloader Payloader, sequence
 	}
 }
 
-// Packetize packetizes the payload of an RTP packet and returns one or more RTP packets
+// Packetize packetizes the payload of an RTP packet and returns one or more RTP packets.
+// Each packet fits in the MTU once its header is added, all of them carry the
+// timestamp of the frame, and the last one has the marker bit set. The
+// timestamp then advances by samples, even if nothing could be packetized.
 func (p *packetizer) Packetize(payload []byte, samples uint32) []*Packet {
-	payloads := p.Payloader.Payload(p.MTU-12, payload)
+	p.hasPacketized = true
+	p.lastTimestamp = p.Timestamp
+	defer func() { p.Timestamp += samples }()
+
+	// The packets carry no CSRCs or extensions, so only the fixed header
+	// needs to fit alongside the payload
+	if len(payload) == 0 || p.MTU <= headerLength {
+		return nil
+	}
+
+	payloads := p.Payloader.Payload(p.MTU-headerLength, payload)
 	packets := make([]*Packet, len(payloads))
 
 	for i, pp := range payloads {
 		packets[i] = &Packet{
-			Version:        2,
-			Padding:        false,
-			Extension:      false,
-			Marker:         i == len(payloads)-1,
-			PayloadType:    p.PayloadType,
-			SequenceNumber: p.Sequencer.NextSequenceNumber(),
-			Timestamp:      p.Timestamp, // Figure out how to do timestamps
-			SSRC:           p.SSRC,
-			Payload:        pp,
+			Header: Header{
+				Version:        2,
+				Padding:        false,
+				Extension:      false,
+				Marker:         i == len(payloads)-1,
+				PayloadType:    p.PayloadType,
+				SequenceNumber: p.Sequencer.NextSequenceNumber(),
+				Timestamp:      p.Timestamp,
+				SSRC:           p.SSRC,
+			},
+			Payload: pp,
 		}
 	}
-	p.Timestamp += samples
+
+	return packets
+}
+
+// GeneratePadding returns padding-only packets holding size padding octets
+// in total, at most 255 to a packet, as used to probe for bandwidth. They
+// take sequence numbers from the stream and carry the timestamp of the last
+// frame, so receivers see no gap or new frame in the media.
+func (p *packetizer) GeneratePadding(size int) []*Packet {
+	timestamp := p.Timestamp
+	if p.hasPacketized {
+		timestamp = p.lastTimestamp
+	}
+
+	var packets []*Packet
+	for size > 0 {
+		padLen := size
+		if padLen > maxPaddingSize {
+			padLen = maxPaddingSize
+		}
+		size -= padLen
+
+		payload := make([]byte, padLen)
+		payload[padLen-1] = byte(padLen)
+
+		packets = append(packets, &Packet{
+			Header: Header{
+				Version:        2,
+				Padding:        true,
+				PayloadType:    p.PayloadType,
+				SequenceNumber: p.Sequencer.NextSequenceNumber(),
+				Timestamp:      timestamp,
+				SSRC:           p.SSRC,
+			},
+			Payload: payload,
+		})
+	}
 
 	return packets
 }
@@ -0,0 +1,124 @@
+package rtp
+
+import (
+	"testing"
+)
+
+// chunkPayloader splits a payload into pieces of at most mtu octets
+type chunkPayloader struct{}
+
+func (c *chunkPayloader) Payload(mtu int, payload []byte) [][]byte {
+	var out [][]byte
+	for len(payload) > mtu {
+		out = append(out, payload[:mtu])
+		payload = payload[mtu:]
+	}
+	return append(out, payload)
+}
+
+func TestPacketizer(t *testing.T) {
+	p := NewPacketizer(100, 98, 0x1234abcd, &chunkPayloader{}, NewFixedSequencer(1234), 90000)
+	start := p.(*packetizer).Timestamp
+
+	packets := p.Packetize(make([]byte, 200), 3000)
+	if got, want := len(packets), 3; got != want {
+		t.Fatalf("Packetize returned %d packets, want %d", got, want)
+	}
+
+	for i, pkt := range packets {
+		raw, err := pkt.Marshal()
+		if err != nil {
+			t.Fatalf("packet %d Marshal error: %v", i, err)
+		}
+		if len(raw) > 100 {
+			t.Errorf("packet %d is %d octets, larger than the MTU", i, len(raw))
+		}
+
+		if got, want := pkt.SequenceNumber, uint16(1234+i); got != want {
+			t.Errorf("packet %d SequenceNumber = %d, want %d", i, got, want)
+		}
+		if got, want := pkt.Timestamp, start; got != want {
+			t.Errorf("packet %d Timestamp = %d, want %d", i, got, want)
+		}
+		if got, want := pkt.Marker, i == len(packets)-1; got != want {
+			t.Errorf("packet %d Marker = %t, want %t", i, got, want)
+		}
+		if pkt.PayloadType != 98 || pkt.SSRC != 0x1234abcd || pkt.Version != 2 {
+			t.Errorf("packet %d has header %#v", i, pkt.Header)
+		}
+	}
+
+	packets = p.Packetize([]byte{0x01}, 3000)
+	if got, want := len(packets), 1; got != want {
+		t.Fatalf("Packetize returned %d packets, want %d", got, want)
+	}
+	if got, want := packets[0].Timestamp, start+3000; got != want {
+		t.Errorf("second frame Timestamp = %d, want %d", got, want)
+	}
+	if got, want := packets[0].SequenceNumber, uint16(1237); got != want {
+		t.Errorf("second frame SequenceNumber = %d, want %d", got, want)
+	}
+}
+
+func TestPacketizerEmpty(t *testing.T) {
+	p := NewPacketizer(100, 98, 1, &chunkPayloader{}, NewFixedSequencer(1), 90000)
+	start := p.(*packetizer).Timestamp
+
+	if packets := p.Packetize(nil, 3000); len(packets) != 0 {
+		t.Errorf("Packetize of an empty frame returned %d packets", len(packets))
+	}
+
+	// The frame's duration still passes
+	packets := p.Packetize([]byte{0x01}, 3000)
+	if got, want := packets[0].Timestamp, start+3000; got != want {
+		t.Errorf("Timestamp = %d, want %d", got, want)
+	}
+}
+
+func TestPacketizerPadding(t *testing.T) {
+	p := NewPacketizer(100, 98, 0x1234abcd, &chunkPayloader{}, NewFixedSequencer(1234), 90000)
+	start := p.(*packetizer).Timestamp
+
+	p.Packetize([]byte{0x01}, 3000)
+	packets := p.GeneratePadding(300)
+	if got, want := len(packets), 2; got != want {
+		t.Fatalf("GeneratePadding returned %d packets, want %d", got, want)
+	}
+
+	total := 0
+	for i, pkt := range packets {
+		raw, err := pkt.Marshal()
+		if err != nil {
+			t.Fatalf("packet %d Marshal error: %v", i, err)
+		}
+
+		var parsed Packet
+		if err := parsed.Unmarshal(raw); err != nil {
+			t.Fatalf("packet %d Unmarshal error: %v", i, err)
+		}
+		if !parsed.Padding || parsed.Marker {
+			t.Errorf("packet %d has header %#v", i, parsed.Header)
+		}
+		if got, want := parsed.SequenceNumber, uint16(1235+i); got != want {
+			t.Errorf("packet %d SequenceNumber = %d, want %d", i, got, want)
+		}
+		if got, want := parsed.Timestamp, start; got != want {
+			t.Errorf("packet %d Timestamp = %d, want %d", i, got, want)
+		}
+		if parsed.PayloadType != 98 || parsed.SSRC != 0x1234abcd {
+			t.Errorf("packet %d has header %#v", i, parsed.Header)
+		}
+
+		total += len(parsed.Payload)
+		if err := parsed.TrimPadding(); err != nil || len(parsed.Payload) != 0 {
+			t.Errorf("packet %d TrimPadding: %v, payload %v", i, err, parsed.Payload)
+		}
+	}
+	if total != 300 {
+		t.Errorf("GeneratePadding produced %d padding octets, want 300", total)
+	}
+
+	if packets := p.GeneratePadding(0); len(packets) != 0 {
+		t.Errorf("GeneratePadding(0) returned %d packets", len(packets))
+	}
+}
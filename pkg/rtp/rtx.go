@@ -0,0 +1,54 @@
+package rtp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// rtxOSNLength is the size of the original sequence number that prefixes
+// the payload of an RTX packet
+const rtxOSNLength = 2
+
+// EncapsulateRTX wraps original in an RTX packet for retransmission, RFC
+// 4588 Section 4. The RTX stream has its own ssrc, payloadType and
+// sequenceNumber, and the original sequence number is carried at the start
+// of the payload. The rest of the header is copied from original.
+func EncapsulateRTX(original *Packet, ssrc uint32, payloadType uint8, sequenceNumber uint16) *Packet {
+	/*
+	 *  0                   1                   2                   3
+	 *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |                         RTP Header                            |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 * |            OSN                |                               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+                               |
+	 * |                  Original RTP Packet Payload                  |
+	 * |                                                               |
+	 * +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	 */
+
+	payload := make([]byte, rtxOSNLength+len(original.Payload))
+	binary.BigEndian.PutUint16(payload, original.SequenceNumber)
+	copy(payload[rtxOSNLength:], original.Payload)
+
+	rtx := &Packet{Header: original.Header, Payload: payload}
+	rtx.SSRC = ssrc
+	rtx.PayloadType = payloadType
+	rtx.SequenceNumber = sequenceNumber
+	return rtx
+}
+
+// DecapsulateRTX recovers the original packet from an RTX packet, given
+// the ssrc and payloadType of the stream it was retransmitted for.
+func DecapsulateRTX(rtx *Packet, ssrc uint32, payloadType uint8) (*Packet, error) {
+	if len(rtx.Payload) < rtxOSNLength {
+		return nil, errors.Errorf("RTX payload size insufficient; %d < %d", len(rtx.Payload), rtxOSNLength)
+	}
+
+	original := &Packet{Header: rtx.Header, Payload: rtx.Payload[rtxOSNLength:]}
+	original.SSRC = ssrc
+	original.PayloadType = payloadType
+	original.SequenceNumber = binary.BigEndian.Uint16(rtx.Payload)
+	return original, nil
+}
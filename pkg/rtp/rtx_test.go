@@ -0,0 +1,59 @@
+package rtp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRTX(t *testing.T) {
+	original := &Packet{
+		Header: Header{
+			Version:        2,
+			Marker:         true,
+			PayloadType:    96,
+			SequenceNumber: 0x1234,
+			Timestamp:      3000,
+			SSRC:           0xaaaa,
+			CSRC:           []uint32{},
+		},
+		Payload: []byte{0x01, 0x02, 0x03},
+	}
+
+	rtx := EncapsulateRTX(original, 0xbbbb, 97, 7)
+	if rtx.SSRC != 0xbbbb || rtx.PayloadType != 97 || rtx.SequenceNumber != 7 {
+		t.Errorf("RTX packet has header %#v", rtx.Header)
+	}
+	if rtx.Timestamp != 3000 || !rtx.Marker {
+		t.Errorf("RTX packet didn't keep the original timestamp and marker")
+	}
+	if want := []byte{0x12, 0x34, 0x01, 0x02, 0x03}; !reflect.DeepEqual(rtx.Payload, want) {
+		t.Errorf("RTX payload = %v, want %v", rtx.Payload, want)
+	}
+
+	// Through the wire and back
+	raw, err := rtx.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	received := &Packet{}
+	if err = received.Unmarshal(raw); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+
+	recovered, err := DecapsulateRTX(received, 0xaaaa, 96)
+	if err != nil {
+		t.Fatalf("DecapsulateRTX error: %v", err)
+	}
+	// The offset is where the payload began in the RTX packet
+	recovered.PayloadOffset = 0
+	if !reflect.DeepEqual(recovered.Header, original.Header) {
+		t.Errorf("recovered header %#v, want %#v", recovered.Header, original.Header)
+	}
+	if !reflect.DeepEqual(recovered.Payload, original.Payload) {
+		t.Errorf("recovered payload %v, want %v", recovered.Payload, original.Payload)
+	}
+
+	if _, err := DecapsulateRTX(&Packet{Payload: []byte{0x01}}, 0xaaaa, 96); err == nil {
+		t.Errorf("DecapsulateRTX of a short payload succeeded")
+	}
+}
@@ -0,0 +1,51 @@
+package rtp
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	// MIDURI identifies the media identification header extension in SDP,
+	// RFC 8843
+	MIDURI = "urn:ietf:params:rtp-hdrext:sdes:mid"
+
+	// RTPStreamIDURI identifies the RtpStreamId header extension in SDP,
+	// RFC 8852
+	RTPStreamIDURI = "urn:ietf:params:rtp-hdrext:sdes:rtp-stream-id"
+
+	// RepairedRTPStreamIDURI identifies the RepairedRtpStreamId header
+	// extension in SDP, RFC 8852
+	RepairedRTPStreamIDURI = "urn:ietf:params:rtp-hdrext:sdes:repaired-rtp-stream-id"
+
+	sdesExtensionLengthMax = 255
+)
+
+// SDESExtension is a header extension carrying an SDES item, such as the
+// MID of the media section a stream belongs to or the RtpStreamId of a
+// simulcast layer. The value is sent without a terminating null.
+type SDESExtension struct {
+	Value string
+}
+
+// Marshal encodes the extension payload
+func (s *SDESExtension) Marshal() ([]byte, error) {
+	if len(s.Value) == 0 || len(s.Value) > sdesExtensionLengthMax {
+		return nil, errors.Errorf("SDES header extension has invalid size; %d", len(s.Value))
+	}
+	return []byte(s.Value), nil
+}
+
+// Unmarshal decodes the extension payload
+func (s *SDESExtension) Unmarshal(rawData []byte) error {
+	if len(rawData) == 0 {
+		return errors.Errorf("SDES header extension is empty")
+	}
+
+	// Some senders pad the value with nulls
+	end := len(rawData)
+	for end > 0 && rawData[end-1] == 0 {
+		end--
+	}
+	s.Value = string(rawData[:end])
+	return nil
+}
@@ -0,0 +1,61 @@
+package rtp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSDESExtension(t *testing.T) {
+	s := &SDESExtension{}
+	if err := s.Unmarshal([]byte{'a', 'u', 'd', 'i', 'o', 0x00, 0x00}); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if got, want := s.Value, "audio"; got != want {
+		t.Errorf("Value = %q, want %q", got, want)
+	}
+
+	if out, err := s.Marshal(); err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	} else if !bytes.Equal(out, []byte("audio")) {
+		t.Errorf("Marshal = %#v, want %#v", out, []byte("audio"))
+	}
+
+	if err := s.Unmarshal(nil); err == nil {
+		t.Error("Unmarshal accepted an empty payload")
+	}
+	if _, err := (&SDESExtension{}).Marshal(); err == nil {
+		t.Error("Marshal accepted an empty value")
+	}
+	if _, err := (&SDESExtension{Value: strings.Repeat("a", 256)}).Marshal(); err == nil {
+		t.Error("Marshal accepted a value over 255 octets")
+	}
+}
+
+func TestSDESExtensionHeader(t *testing.T) {
+	// Typed extensions are carried with the RFC 8285 helpers
+	h := &Header{Version: 2}
+	mid, err := (&SDESExtension{Value: "0"}).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if err := h.SetExtension(1, mid); err != nil {
+		t.Fatalf("SetExtension error: %v", err)
+	}
+
+	raw, err := h.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	parsed := &Header{}
+	if err := parsed.Unmarshal(raw); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+
+	var s SDESExtension
+	if err := s.Unmarshal(parsed.GetExtension(1)); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	} else if s.Value != "0" {
+		t.Errorf("Value = %q, want %q", s.Value, "0")
+	}
+}
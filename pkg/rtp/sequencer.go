@@ -1,7 +1,6 @@
 package rtp
 
 import (
-	"math"
 	"math/rand"
 	"sync"
 	"time"
@@ -20,7 +19,7 @@ func NewRandomSequencer() Sequencer {
 	r := rand.New(rs)
 
 	return &sequencer{
-		sequenceNumber: uint16(r.Uint32() % math.MaxUint16),
+		sequenceNumber: uint16(r.Uint32()),
 	}
 }
 
@@ -0,0 +1,84 @@
+package rtp
+
+// Sequence numbers and timestamps wrap around, so they are compared using
+// serial number arithmetic, RFC 1982: a value is before another if it is
+// less than half the number space behind it.
+
+// SequenceNumberBefore reports whether sequence number a comes before b
+func SequenceNumberBefore(a, b uint16) bool {
+	return SequenceNumberDiff(a, b) > 0
+}
+
+// SequenceNumberAfter reports whether sequence number a comes after b
+func SequenceNumberAfter(a, b uint16) bool {
+	return SequenceNumberDiff(b, a) > 0
+}
+
+// SequenceNumberDiff returns how many sequence numbers to is ahead of from,
+// negative if it is behind
+func SequenceNumberDiff(from, to uint16) int {
+	return int(int16(to - from))
+}
+
+// TimestampBefore reports whether timestamp a comes before b
+func TimestampBefore(a, b uint32) bool {
+	return TimestampDiff(a, b) > 0
+}
+
+// TimestampAfter reports whether timestamp a comes after b
+func TimestampAfter(a, b uint32) bool {
+	return TimestampDiff(b, a) > 0
+}
+
+// TimestampDiff returns how many clock ticks timestamp to is ahead of from,
+// negative if it is behind
+func TimestampDiff(from, to uint32) int64 {
+	return int64(int32(to - from))
+}
+
+// SequenceNumberUnwrapper extends 16 bit sequence numbers to 64 bits,
+// counting how many times they have wrapped, so they can be used as keys
+// that keep increasing. Reordered sequence numbers from before a wrap are
+// extended using the previous cycle, which is negative for those from
+// before the first sequence number seen. The zero value is ready to use.
+type SequenceNumberUnwrapper struct {
+	started bool
+	last    int64
+}
+
+// Unwrap returns the extended sequence number for seq
+func (u *SequenceNumberUnwrapper) Unwrap(seq uint16) int64 {
+	if !u.started {
+		u.started = true
+		u.last = int64(seq)
+		return u.last
+	}
+
+	extended := u.last + int64(SequenceNumberDiff(uint16(u.last), seq))
+	if extended > u.last {
+		u.last = extended
+	}
+	return extended
+}
+
+// TimestampUnwrapper extends 32 bit timestamps to 64 bits, like
+// SequenceNumberUnwrapper. The zero value is ready to use.
+type TimestampUnwrapper struct {
+	started bool
+	last    int64
+}
+
+// Unwrap returns the extended timestamp for ts
+func (u *TimestampUnwrapper) Unwrap(ts uint32) int64 {
+	if !u.started {
+		u.started = true
+		u.last = int64(ts)
+		return u.last
+	}
+
+	extended := u.last + TimestampDiff(uint32(u.last), ts)
+	if extended > u.last {
+		u.last = extended
+	}
+	return extended
+}
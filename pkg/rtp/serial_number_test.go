@@ -0,0 +1,100 @@
+package rtp
+
+import (
+	"testing"
+)
+
+func TestSequenceNumberCompare(t *testing.T) {
+	for _, test := range []struct {
+		A, B   uint16
+		Diff   int
+		Before bool
+	}{
+		{1, 2, 1, true},
+		{2, 1, -1, false},
+		{65535, 0, 1, true},
+		{0, 65535, -1, false},
+		{65000, 100, 636, true},
+		{5, 5, 0, false},
+	} {
+		if got := SequenceNumberDiff(test.A, test.B); got != test.Diff {
+			t.Errorf("SequenceNumberDiff(%d, %d) = %d, want %d", test.A, test.B, got, test.Diff)
+		}
+		if got := SequenceNumberBefore(test.A, test.B); got != test.Before {
+			t.Errorf("SequenceNumberBefore(%d, %d) = %t, want %t", test.A, test.B, got, test.Before)
+		}
+		if got, want := SequenceNumberAfter(test.B, test.A), test.Before; got != want {
+			t.Errorf("SequenceNumberAfter(%d, %d) = %t, want %t", test.B, test.A, got, want)
+		}
+	}
+}
+
+func TestTimestampCompare(t *testing.T) {
+	for _, test := range []struct {
+		A, B   uint32
+		Diff   int64
+		Before bool
+	}{
+		{1000, 4000, 3000, true},
+		{4000, 1000, -3000, false},
+		{0xFFFFFF00, 0x100, 0x200, true},
+		{0x100, 0xFFFFFF00, -0x200, false},
+	} {
+		if got := TimestampDiff(test.A, test.B); got != test.Diff {
+			t.Errorf("TimestampDiff(%d, %d) = %d, want %d", test.A, test.B, got, test.Diff)
+		}
+		if got := TimestampBefore(test.A, test.B); got != test.Before {
+			t.Errorf("TimestampBefore(%d, %d) = %t, want %t", test.A, test.B, got, test.Before)
+		}
+		if got, want := TimestampAfter(test.B, test.A), test.Before; got != want {
+			t.Errorf("TimestampAfter(%d, %d) = %t, want %t", test.B, test.A, got, want)
+		}
+	}
+}
+
+func TestSequenceNumberUnwrapper(t *testing.T) {
+	var u SequenceNumberUnwrapper
+	for _, test := range []struct {
+		In   uint16
+		Want int64
+	}{
+		{65534, 65534},
+		{65535, 65535},
+		{1, 65537},
+		// Reordered from before the wrap
+		{65533, 65533},
+		{0, 65536},
+		{2, 65538},
+	} {
+		if got := u.Unwrap(test.In); got != test.Want {
+			t.Errorf("Unwrap(%d) = %d, want %d", test.In, got, test.Want)
+		}
+	}
+}
+
+func TestSequenceNumberUnwrapperBeforeStart(t *testing.T) {
+	var u SequenceNumberUnwrapper
+	if got, want := u.Unwrap(5), int64(5); got != want {
+		t.Errorf("Unwrap(5) = %d, want %d", got, want)
+	}
+	if got, want := u.Unwrap(65533), int64(-3); got != want {
+		t.Errorf("Unwrap(65533) = %d, want %d", got, want)
+	}
+}
+
+func TestTimestampUnwrapper(t *testing.T) {
+	var u TimestampUnwrapper
+	for _, test := range []struct {
+		In   uint32
+		Want int64
+	}{
+		{0xFFFFF000, 0xFFFFF000},
+		{0x00000800, 0x100000800},
+		{0xFFFFF800, 0xFFFFF800},
+		{0x00001000, 0x100001000},
+	} {
+		if got := u.Unwrap(test.In); got != test.Want {
+			t.Errorf("Unwrap(%#x) = %#x, want %#x", test.In, got, test.Want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package rtp
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// TransportCCURI identifies the transport-wide sequence number header
+	// extension in SDP
+	TransportCCURI = "http://www.ietf.org/id/draft-holmer-rmcat-transport-wide-cc-extensions-01"
+
+	transportCCExtensionSize = 2
+)
+
+// TransportCCExtension is the transport-wide sequence number header
+// extension. The number is shared by every stream of a transport, and the
+// receiver reports on it with rtcp.TransportLayerCC feedback.
+type TransportCCExtension struct {
+	TransportSequence uint16
+}
+
+// Marshal encodes the extension payload
+func (t *TransportCCExtension) Marshal() ([]byte, error) {
+	buf := make([]byte, transportCCExtensionSize)
+	binary.BigEndian.PutUint16(buf, t.TransportSequence)
+	return buf, nil
+}
+
+// Unmarshal decodes the extension payload
+func (t *TransportCCExtension) Unmarshal(rawData []byte) error {
+	if len(rawData) < transportCCExtensionSize {
+		return errors.Errorf("transport-cc size insufficient; %d < %d", len(rawData), transportCCExtensionSize)
+	}
+
+	t.TransportSequence = binary.BigEndian.Uint16(rawData)
+	return nil
+}
@@ -0,0 +1,28 @@
+package rtp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTransportCCExtension(t *testing.T) {
+	raw := []byte{0x01, 0x02}
+
+	e := &TransportCCExtension{}
+	if err := e.Unmarshal(raw); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if got, want := e.TransportSequence, uint16(0x0102); got != want {
+		t.Errorf("TransportSequence = %#x, want %#x", got, want)
+	}
+
+	if out, err := e.Marshal(); err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	} else if !bytes.Equal(out, raw) {
+		t.Errorf("Marshal = %#v, want %#v", out, raw)
+	}
+
+	if err := e.Unmarshal(raw[:1]); err == nil {
+		t.Error("Unmarshal accepted a short payload")
+	}
+}
@@ -0,0 +1,376 @@
+package stun
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" // #nosec, HMAC-SHA1 is mandated by RFC 5389
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"net"
+)
+
+// AttrType is the type of a STUN attribute
+type AttrType uint16
+
+// AttrType enums
+// https://tools.ietf.org/html/rfc5389#section-18.2
+// https://tools.ietf.org/html/rfc8445#section-16.1
+const (
+	AttrMappedAddress    AttrType = 0x0001
+	AttrUsername         AttrType = 0x0006
+	AttrMessageIntegrity AttrType = 0x0008
+	AttrErrorCode        AttrType = 0x0009
+	AttrXORMappedAddress AttrType = 0x0020
+	AttrPriority         AttrType = 0x0024
+	AttrUseCandidate     AttrType = 0x0025
+	AttrSoftware         AttrType = 0x8022
+	AttrFingerprint      AttrType = 0x8028
+	AttrIceControlled    AttrType = 0x8029
+	AttrIceControlling   AttrType = 0x802A
+)
+
+func (a AttrType) String() string {
+	switch a {
+	case AttrMappedAddress:
+		return "MAPPED-ADDRESS"
+	case AttrUsername:
+		return "USERNAME"
+	case AttrMessageIntegrity:
+		return "MESSAGE-INTEGRITY"
+	case AttrErrorCode:
+		return "ERROR-CODE"
+	case AttrXORMappedAddress:
+		return "XOR-MAPPED-ADDRESS"
+	case AttrPriority:
+		return "PRIORITY"
+	case AttrUseCandidate:
+		return "USE-CANDIDATE"
+	case AttrSoftware:
+		return "SOFTWARE"
+	case AttrFingerprint:
+		return "FINGERPRINT"
+	case AttrIceControlled:
+		return "ICE-CONTROLLED"
+	case AttrIceControlling:
+		return "ICE-CONTROLLING"
+	default:
+		return fmt.Sprintf("Unknown attribute %#x", uint16(a))
+	}
+}
+
+// Attribute is an attribute that can be packed into a Message
+type Attribute interface {
+	Pack(m *Message) error
+}
+
+// RawAttribute is an attribute as it was decoded, Offset is where its
+// header starts in the Raw of the message
+type RawAttribute struct {
+	Type   AttrType
+	Length uint16
+	Value  []byte
+	Offset int
+}
+
+const (
+	familyIPv4 = 0x01
+	familyIPv6 = 0x02
+
+	messageIntegrityLength = sha1.Size
+	fingerprintLength      = 4
+	fingerprintXOR         = 0x5354554e
+)
+
+// XorAddress is a transport address obfuscated with the magic cookie and
+// transaction ID of the message it is in
+// https://tools.ietf.org/html/rfc5389#section-15.2
+type XorAddress struct {
+	IP   net.IP
+	Port int
+}
+
+func (x *XorAddress) xorKey(m *Message) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint32(key, magicCookie)
+	copy(key[4:], m.TransactionID)
+	return key
+}
+
+// Pack adds the address as a XOR-MAPPED-ADDRESS attribute
+func (x *XorAddress) Pack(m *Message) error {
+	family, ip := familyIPv4, x.IP.To4()
+	if ip == nil {
+		family, ip = familyIPv6, x.IP.To16()
+		if ip == nil {
+			return ErrUnknownAddressFamily
+		}
+	}
+
+	value := make([]byte, 4+len(ip))
+	value[1] = byte(family)
+	binary.BigEndian.PutUint16(value[2:], uint16(x.Port)^uint16(magicCookie>>16))
+
+	key := x.xorKey(m)
+	for i := range ip {
+		value[4+i] = ip[i] ^ key[i]
+	}
+	return m.addAttribute(AttrXORMappedAddress, value)
+}
+
+// Unpack decodes the address from a XOR-MAPPED-ADDRESS attribute
+func (x *XorAddress) Unpack(m *Message, a *RawAttribute) error {
+	if len(a.Value) < 4 {
+		return ErrAttributeTooShort
+	}
+
+	var ipLength int
+	switch a.Value[1] {
+	case familyIPv4:
+		ipLength = net.IPv4len
+	case familyIPv6:
+		ipLength = net.IPv6len
+	default:
+		return ErrUnknownAddressFamily
+	}
+	if len(a.Value) < 4+ipLength {
+		return ErrAttributeTooShort
+	}
+
+	x.Port = int(binary.BigEndian.Uint16(a.Value[2:]) ^ uint16(magicCookie>>16))
+
+	key := x.xorKey(m)
+	x.IP = make(net.IP, ipLength)
+	for i := range x.IP {
+		x.IP[i] = a.Value[4+i] ^ key[i]
+	}
+	return nil
+}
+
+// XorMappedAddress is the reflexive transport address of the client, as
+// seen by the server
+type XorMappedAddress struct {
+	XorAddress XorAddress
+}
+
+// Pack adds the XOR-MAPPED-ADDRESS attribute
+func (x *XorMappedAddress) Pack(m *Message) error {
+	return x.XorAddress.Pack(m)
+}
+
+// Username identifies the credentials a message is signed with, for ICE
+// it is the remote and local ufrag separated by a colon
+// https://tools.ietf.org/html/rfc5389#section-15.3
+type Username struct {
+	Username string
+}
+
+// Pack adds the USERNAME attribute
+func (u *Username) Pack(m *Message) error {
+	return m.addAttribute(AttrUsername, []byte(u.Username))
+}
+
+// Unpack decodes the USERNAME attribute
+func (u *Username) Unpack(m *Message, a *RawAttribute) error {
+	u.Username = string(a.Value)
+	return nil
+}
+
+// Priority is the priority a peer reflexive candidate discovered by the
+// check would have
+// https://tools.ietf.org/html/rfc8445#section-7.1.1
+type Priority struct {
+	Priority uint32
+}
+
+// Pack adds the PRIORITY attribute
+func (p *Priority) Pack(m *Message) error {
+	value := make([]byte, 4)
+	binary.BigEndian.PutUint32(value, p.Priority)
+	return m.addAttribute(AttrPriority, value)
+}
+
+// Unpack decodes the PRIORITY attribute
+func (p *Priority) Unpack(m *Message, a *RawAttribute) error {
+	if len(a.Value) < 4 {
+		return ErrAttributeTooShort
+	}
+	p.Priority = binary.BigEndian.Uint32(a.Value)
+	return nil
+}
+
+// UseCandidate is set by the controlling agent to nominate the pair the
+// check is sent on
+// https://tools.ietf.org/html/rfc8445#section-7.1.2
+type UseCandidate struct{}
+
+// Pack adds the USE-CANDIDATE attribute
+func (u *UseCandidate) Pack(m *Message) error {
+	return m.addAttribute(AttrUseCandidate, nil)
+}
+
+// IceControlling tells the agent sending the check believes it is in the
+// controlling role, the tie breaker settles conflicts
+// https://tools.ietf.org/html/rfc8445#section-7.1.3
+type IceControlling struct {
+	TieBreaker uint64
+}
+
+// Pack adds the ICE-CONTROLLING attribute
+func (i *IceControlling) Pack(m *Message) error {
+	return packTieBreaker(m, AttrIceControlling, i.TieBreaker)
+}
+
+// Unpack decodes the ICE-CONTROLLING attribute
+func (i *IceControlling) Unpack(m *Message, a *RawAttribute) (err error) {
+	i.TieBreaker, err = unpackTieBreaker(a)
+	return err
+}
+
+// IceControlled tells the agent sending the check believes it is in the
+// controlled role, the tie breaker settles conflicts
+// https://tools.ietf.org/html/rfc8445#section-7.1.3
+type IceControlled struct {
+	TieBreaker uint64
+}
+
+// Pack adds the ICE-CONTROLLED attribute
+func (i *IceControlled) Pack(m *Message) error {
+	return packTieBreaker(m, AttrIceControlled, i.TieBreaker)
+}
+
+// Unpack decodes the ICE-CONTROLLED attribute
+func (i *IceControlled) Unpack(m *Message, a *RawAttribute) (err error) {
+	i.TieBreaker, err = unpackTieBreaker(a)
+	return err
+}
+
+func packTieBreaker(m *Message, t AttrType, tieBreaker uint64) error {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, tieBreaker)
+	return m.addAttribute(t, value)
+}
+
+func unpackTieBreaker(a *RawAttribute) (uint64, error) {
+	if len(a.Value) < 8 {
+		return 0, ErrAttributeTooShort
+	}
+	return binary.BigEndian.Uint64(a.Value), nil
+}
+
+// CodeRoleConflict is the error code an agent answers a check with when
+// both agents claim the same role and it keeps its own
+// https://tools.ietf.org/html/rfc8445#section-7.3.1.1
+const CodeRoleConflict = 487
+
+// ErrorCode is the error of an error response, Code is its class times 100
+// plus its number
+// https://tools.ietf.org/html/rfc5389#section-15.6
+type ErrorCode struct {
+	Code   int
+	Reason string
+}
+
+// Pack adds the ERROR-CODE attribute
+func (e *ErrorCode) Pack(m *Message) error {
+	if e.Code < 300 || e.Code > 699 {
+		return ErrInvalidErrorCode
+	}
+
+	value := make([]byte, 4+len(e.Reason))
+	value[2] = byte(e.Code / 100)
+	value[3] = byte(e.Code % 100)
+	copy(value[4:], e.Reason)
+	return m.addAttribute(AttrErrorCode, value)
+}
+
+// Unpack decodes the ERROR-CODE attribute
+func (e *ErrorCode) Unpack(m *Message, a *RawAttribute) error {
+	if len(a.Value) < 4 {
+		return ErrAttributeTooShort
+	}
+	e.Code = int(a.Value[2]&0x07)*100 + int(a.Value[3])
+	e.Reason = string(a.Value[4:])
+	return nil
+}
+
+// MessageIntegrity is an HMAC-SHA1 of the message, for ICE the key is the
+// password of the agent receiving the request
+// https://tools.ietf.org/html/rfc5389#section-15.4
+type MessageIntegrity struct {
+	Key []byte
+}
+
+// hmac computes the HMAC over the message up to offset, with the length
+// in the header covering the MESSAGE-INTEGRITY attribute itself
+func (i *MessageIntegrity) hmac(m *Message, offset int) []byte {
+	header := make([]byte, messageHeaderLength)
+	copy(header, m.Raw)
+	binary.BigEndian.PutUint16(header[2:], uint16(offset+attributeHeaderLength+messageIntegrityLength-messageHeaderLength))
+
+	mac := hmac.New(sha1.New, i.Key)
+	mac.Write(header)                            // #nosec
+	mac.Write(m.Raw[messageHeaderLength:offset]) // #nosec
+	return mac.Sum(nil)
+}
+
+// Pack adds the MESSAGE-INTEGRITY attribute
+func (i *MessageIntegrity) Pack(m *Message) error {
+	return m.addAttribute(AttrMessageIntegrity, i.hmac(m, len(m.Raw)))
+}
+
+// Check verifies the MESSAGE-INTEGRITY attribute of the message was
+// computed with Key
+func (i *MessageIntegrity) Check(m *Message) error {
+	a, ok := m.GetOneAttribute(AttrMessageIntegrity)
+	if !ok {
+		return ErrAttributeNotFound
+	}
+	if len(a.Value) != messageIntegrityLength {
+		return ErrAttributeTooShort
+	}
+
+	if !hmac.Equal(a.Value, i.hmac(m, a.Offset)) {
+		return ErrIntegrityMismatch
+	}
+	return nil
+}
+
+// Fingerprint is a CRC-32 of the message, it helps telling STUN apart
+// from other protocols multiplexed on the same port
+// https://tools.ietf.org/html/rfc5389#section-15.5
+type Fingerprint struct{}
+
+// crc computes the fingerprint over the message up to offset, with the
+// length in the header covering the FINGERPRINT attribute itself
+func (f *Fingerprint) crc(m *Message, offset int) []byte {
+	header := make([]byte, messageHeaderLength)
+	copy(header, m.Raw)
+	binary.BigEndian.PutUint16(header[2:], uint16(offset+attributeHeaderLength+fingerprintLength-messageHeaderLength))
+
+	crc := crc32.ChecksumIEEE(append(header, m.Raw[messageHeaderLength:offset]...)) ^ fingerprintXOR
+	value := make([]byte, fingerprintLength)
+	binary.BigEndian.PutUint32(value, crc)
+	return value
+}
+
+// Pack adds the FINGERPRINT attribute
+func (f *Fingerprint) Pack(m *Message) error {
+	return m.addAttribute(AttrFingerprint, f.crc(m, len(m.Raw)))
+}
+
+// Check verifies the FINGERPRINT attribute of the message matches its
+// contents
+func (f *Fingerprint) Check(m *Message) error {
+	a, ok := m.GetOneAttribute(AttrFingerprint)
+	if !ok {
+		return ErrAttributeNotFound
+	}
+	if len(a.Value) != fingerprintLength {
+		return ErrAttributeTooShort
+	}
+
+	if !hmac.Equal(a.Value, f.crc(m, a.Offset)) {
+		return ErrFingerprintMismatch
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package stun
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Errors returned while encoding and decoding STUN messages
+var (
+	// ErrMessageTooShort indicates there were fewer than 20 octets to decode
+	// a header from, or fewer than the header's length field declares.
+	ErrMessageTooShort = errors.New("stun message too short")
+
+	// ErrNotSTUNMessage indicates the first two bits aren't zero or the
+	// magic cookie is missing, the buffer holds some other protocol.
+	ErrNotSTUNMessage = errors.New("not a stun message")
+
+	// ErrInvalidLength indicates the message length is not a multiple of 4
+	// octets.
+	ErrInvalidLength = errors.New("stun message length must be a multiple of 4")
+
+	// ErrAttributeTooShort indicates an attribute is shorter than its
+	// length field declares, or than its type requires.
+	ErrAttributeTooShort = errors.New("stun attribute too short")
+
+	// ErrAttributeTooLong indicates an attribute value does not fit in the
+	// 16 bit length field.
+	ErrAttributeTooLong = errors.New("stun attribute too long")
+
+	// ErrInvalidTransactionID indicates a transaction ID is not 12 octets
+	// long.
+	ErrInvalidTransactionID = errors.New("transaction id must be 12 octets long")
+
+	// ErrUnknownAddressFamily indicates an address attribute is neither
+	// IPv4 nor IPv6.
+	ErrUnknownAddressFamily = errors.New("unknown address family")
+
+	// ErrIntegrityMismatch indicates the MESSAGE-INTEGRITY of a message
+	// wasn't computed with the expected key.
+	ErrIntegrityMismatch = errors.New("message integrity mismatch")
+
+	// ErrFingerprintMismatch indicates the FINGERPRINT of a message does
+	// not match its contents.
+	ErrFingerprintMismatch = errors.New("fingerprint mismatch")
+
+	// ErrInvalidErrorCode indicates an error code is not between 300 and
+	// 699, the classes of errors.
+	ErrInvalidErrorCode = errors.New("error code must be between 300 and 699")
+
+	// ErrAttributeNotFound indicates a message lacks an attribute it was
+	// expected to have.
+	ErrAttributeNotFound = errors.New("stun attribute not found")
+)
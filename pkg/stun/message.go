@@ -0,0 +1,236 @@
+// Package stun implements the STUN message format of RFC 5389 along with
+// the attributes ICE connectivity checks rely on, defined in RFC 8445
+package stun
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+)
+
+// MessageClass is the class of a STUN message
+// https://tools.ietf.org/html/rfc5389#section-6
+type MessageClass int
+
+// MessageClass enums
+const (
+	ClassRequest MessageClass = iota
+	ClassIndication
+	ClassSuccessResponse
+	ClassErrorResponse
+)
+
+func (c MessageClass) String() string {
+	switch c {
+	case ClassRequest:
+		return "request"
+	case ClassIndication:
+		return "indication"
+	case ClassSuccessResponse:
+		return "success response"
+	case ClassErrorResponse:
+		return "error response"
+	default:
+		return fmt.Sprintf("Unknown class %d", int(c))
+	}
+}
+
+// Method is the method of a STUN message
+type Method int
+
+// MethodBinding is the only method STUN itself defines
+const MethodBinding Method = 0x001
+
+func (m Method) String() string {
+	switch m {
+	case MethodBinding:
+		return "binding"
+	default:
+		return fmt.Sprintf("Unknown method %#x", int(m))
+	}
+}
+
+const (
+	messageHeaderLength   = 20
+	attributeHeaderLength = 4
+	transactionIDLength   = 12
+	magicCookie           = 0x2112A442
+)
+
+// Message is a STUN message, the attributes point into Raw which holds the
+// message as it is on the wire
+// https://tools.ietf.org/html/rfc5389#section-6
+type Message struct {
+	Class         MessageClass
+	Method        Method
+	TransactionID []byte
+	Attributes    []*RawAttribute
+
+	Raw []byte
+}
+
+func (m *Message) String() string {
+	return fmt.Sprintf("%s %s id: %x attributes: %d", m.Method, m.Class, m.TransactionID, len(m.Attributes))
+}
+
+// GenerateTransactionID returns a random transaction ID
+func GenerateTransactionID() ([]byte, error) {
+	id := make([]byte, transactionIDLength)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// messageType interleaves the class bits among the method bits
+//
+//	0                 1
+//	2  3  4 5 6 7 8 9 0 1 2 3 4 5
+//
+// +--+--+-+-+-+-+-+-+-+-+-+-+-+-+
+// |M |M |M|M|M|C|M|M|M|C|M|M|M|M|
+// |11|10|9|8|7|1|6|5|4|0|3|2|1|0|
+// +--+--+-+-+-+-+-+-+-+-+-+-+-+-+
+func messageType(c MessageClass, m Method) uint16 {
+	class, method := uint16(c), uint16(m)
+	return (method & 0x000f) | (class&0x1)<<4 |
+		(method&0x0070)<<1 | (class&0x2)<<7 |
+		(method&0x0f80)<<2
+}
+
+func parseMessageType(t uint16) (MessageClass, Method) {
+	class := (t>>4)&0x1 | (t>>7)&0x2
+	method := t&0x000f | (t>>1)&0x0070 | (t>>2)&0x0f80
+	return MessageClass(class), Method(method)
+}
+
+// IsMessage reports whether buf could hold a STUN message, useful to tell
+// it apart from other protocols multiplexed on the same port
+func IsMessage(buf []byte) bool {
+	return len(buf) >= messageHeaderLength &&
+		buf[0]>>6 == 0 &&
+		binary.BigEndian.Uint32(buf[4:]) == magicCookie
+}
+
+// Build creates a message and packs the attributes into it in order, so
+// MessageIntegrity and Fingerprint must come last
+func Build(class MessageClass, method Method, transactionID []byte, attributes ...Attribute) (*Message, error) {
+	if len(transactionID) != transactionIDLength {
+		return nil, ErrInvalidTransactionID
+	}
+
+	m := &Message{
+		Class:         class,
+		Method:        method,
+		TransactionID: transactionID,
+		Raw:           make([]byte, messageHeaderLength),
+	}
+	binary.BigEndian.PutUint16(m.Raw[0:], messageType(class, method))
+	binary.BigEndian.PutUint32(m.Raw[4:], magicCookie)
+	copy(m.Raw[8:], transactionID)
+
+	for _, a := range attributes {
+		if err := a.Pack(m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// NewMessage decodes a STUN message, attributes are kept raw until they
+// are unpacked by their type
+func NewMessage(buf []byte) (*Message, error) {
+	if len(buf) < messageHeaderLength {
+		return nil, ErrMessageTooShort
+	}
+	if !IsMessage(buf) {
+		return nil, ErrNotSTUNMessage
+	}
+
+	length := int(binary.BigEndian.Uint16(buf[2:]))
+	if length%4 != 0 {
+		return nil, ErrInvalidLength
+	}
+	if len(buf) < messageHeaderLength+length {
+		return nil, ErrMessageTooShort
+	}
+
+	raw := make([]byte, messageHeaderLength+length)
+	copy(raw, buf)
+
+	m := &Message{
+		TransactionID: raw[8:messageHeaderLength],
+		Raw:           raw,
+	}
+	m.Class, m.Method = parseMessageType(binary.BigEndian.Uint16(raw[0:]))
+
+	for offset := messageHeaderLength; offset < len(raw); {
+		if len(raw)-offset < attributeHeaderLength {
+			return nil, ErrAttributeTooShort
+		}
+		a := &RawAttribute{
+			Type:   AttrType(binary.BigEndian.Uint16(raw[offset:])),
+			Length: binary.BigEndian.Uint16(raw[offset+2:]),
+			Offset: offset,
+		}
+
+		valueStart := offset + attributeHeaderLength
+		valueEnd := valueStart + int(a.Length)
+		if valueEnd > len(raw) {
+			return nil, ErrAttributeTooShort
+		}
+		a.Value = raw[valueStart:valueEnd]
+		m.Attributes = append(m.Attributes, a)
+
+		offset = valueEnd + padding(int(a.Length))
+	}
+
+	return m, nil
+}
+
+// Pack returns the message as it is sent on the wire
+func (m *Message) Pack() []byte {
+	return m.Raw
+}
+
+// GetOneAttribute returns the first attribute of the given type
+func (m *Message) GetOneAttribute(t AttrType) (*RawAttribute, bool) {
+	for _, a := range m.Attributes {
+		if a.Type == t {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// addAttribute appends an attribute to Raw, padded to a multiple of 4
+// octets, and updates the length in the header
+func (m *Message) addAttribute(t AttrType, value []byte) error {
+	if len(value) > 0xffff {
+		return ErrAttributeTooLong
+	}
+
+	offset := len(m.Raw)
+	attr := make([]byte, attributeHeaderLength+len(value)+padding(len(value)))
+	binary.BigEndian.PutUint16(attr[0:], uint16(t))
+	binary.BigEndian.PutUint16(attr[2:], uint16(len(value)))
+	copy(attr[attributeHeaderLength:], value)
+
+	m.Raw = append(m.Raw, attr...)
+	m.setLength(len(m.Raw) - messageHeaderLength)
+	m.Attributes = append(m.Attributes, &RawAttribute{
+		Type:   t,
+		Length: uint16(len(value)),
+		Value:  m.Raw[offset+attributeHeaderLength : offset+attributeHeaderLength+len(value)],
+		Offset: offset,
+	})
+	return nil
+}
+
+func (m *Message) setLength(length int) {
+	binary.BigEndian.PutUint16(m.Raw[2:], uint16(length))
+}
+
+func padding(length int) int {
+	return (4 - length%4) % 4
+}
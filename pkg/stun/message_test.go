@@ -0,0 +1,173 @@
+package stun
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Sample request from https://tools.ietf.org/html/rfc5769#section-2.1
+var sampleRequest = []byte{
+	0x00, 0x01, 0x00, 0x58, 0x21, 0x12, 0xa4, 0x42,
+	0xb7, 0xe7, 0xa7, 0x01, 0xbc, 0x34, 0xd6, 0x86,
+	0xfa, 0x87, 0xdf, 0xae, 0x80, 0x22, 0x00, 0x10,
+	0x53, 0x54, 0x55, 0x4e, 0x20, 0x74, 0x65, 0x73,
+	0x74, 0x20, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74,
+	0x00, 0x24, 0x00, 0x04, 0x6e, 0x00, 0x01, 0xff,
+	0x80, 0x29, 0x00, 0x08, 0x93, 0x2f, 0xf9, 0xb1,
+	0x51, 0x26, 0x3b, 0x36, 0x00, 0x06, 0x00, 0x09,
+	0x65, 0x76, 0x74, 0x6a, 0x3a, 0x68, 0x36, 0x76,
+	0x59, 0x20, 0x20, 0x20, 0x00, 0x08, 0x00, 0x14,
+	0x9a, 0xea, 0xa7, 0x0c, 0xbf, 0xd8, 0xcb, 0x56,
+	0x78, 0x1e, 0xf2, 0xb5, 0xb2, 0xd3, 0xf2, 0x49,
+	0xc1, 0xb5, 0x71, 0xa2, 0x80, 0x28, 0x00, 0x04,
+	0xe5, 0x7a, 0x3b, 0xcf,
+}
+
+const samplePassword = "VOkJxbRl1RmTxUk/WvJxBt"
+
+func TestMessageUnpack(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewMessage(sampleRequest)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(ClassRequest, m.Class)
+	assert.Equal(MethodBinding, m.Method)
+	assert.Equal(sampleRequest[8:20], m.TransactionID)
+	assert.Len(m.Attributes, 6)
+
+	a, ok := m.GetOneAttribute(AttrUsername)
+	if assert.True(ok) {
+		var username Username
+		assert.NoError(username.Unpack(m, a))
+		assert.Equal("evtj:h6vY", username.Username)
+	}
+
+	a, ok = m.GetOneAttribute(AttrPriority)
+	if assert.True(ok) {
+		var priority Priority
+		assert.NoError(priority.Unpack(m, a))
+		assert.Equal(uint32(0x6e0001ff), priority.Priority)
+	}
+
+	a, ok = m.GetOneAttribute(AttrIceControlled)
+	if assert.True(ok) {
+		var controlled IceControlled
+		assert.NoError(controlled.Unpack(m, a))
+		assert.Equal(uint64(0x932ff9b151263b36), controlled.TieBreaker)
+	}
+
+	assert.NoError((&MessageIntegrity{Key: []byte(samplePassword)}).Check(m))
+	assert.Equal(ErrIntegrityMismatch, (&MessageIntegrity{Key: []byte("wrong")}).Check(m))
+	assert.NoError((&Fingerprint{}).Check(m))
+}
+
+func TestMessageUnpackInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewMessage(sampleRequest[:10])
+	assert.Equal(ErrMessageTooShort, err)
+
+	_, err = NewMessage(sampleRequest[:len(sampleRequest)-4])
+	assert.Equal(ErrMessageTooShort, err)
+
+	notSTUN := append([]byte{}, sampleRequest...)
+	notSTUN[4] = 0
+	_, err = NewMessage(notSTUN)
+	assert.Equal(ErrNotSTUNMessage, err)
+
+	tampered := append([]byte{}, sampleRequest...)
+	tampered[47] = 0
+	m, err := NewMessage(tampered)
+	if assert.NoError(err) {
+		assert.Equal(ErrIntegrityMismatch, (&MessageIntegrity{Key: []byte(samplePassword)}).Check(m))
+		assert.Equal(ErrFingerprintMismatch, (&Fingerprint{}).Check(m))
+	}
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, ip := range []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8:1234:5678:11:2233:4455:6677")} {
+		id, err := GenerateTransactionID()
+		assert.NoError(err)
+		out, err := Build(ClassSuccessResponse, MethodBinding, id,
+			&XorMappedAddress{
+				XorAddress: XorAddress{IP: ip, Port: 32853},
+			},
+			&UseCandidate{},
+			&IceControlling{TieBreaker: 42},
+			&MessageIntegrity{Key: []byte(samplePassword)},
+			&Fingerprint{},
+		)
+		if !assert.NoError(err) {
+			return
+		}
+
+		m, err := NewMessage(out.Pack())
+		if !assert.NoError(err) {
+			return
+		}
+		assert.Equal(ClassSuccessResponse, m.Class)
+		assert.Equal(MethodBinding, m.Method)
+		assert.Equal(id, m.TransactionID)
+
+		a, ok := m.GetOneAttribute(AttrXORMappedAddress)
+		if assert.True(ok) {
+			var addr XorAddress
+			assert.NoError(addr.Unpack(m, a))
+			assert.True(ip.Equal(addr.IP), "%s != %s", ip, addr.IP)
+			assert.Equal(32853, addr.Port)
+		}
+
+		_, ok = m.GetOneAttribute(AttrUseCandidate)
+		assert.True(ok)
+
+		assert.NoError((&MessageIntegrity{Key: []byte(samplePassword)}).Check(m))
+		assert.NoError((&Fingerprint{}).Check(m))
+	}
+}
+
+func TestMessageErrorCode(t *testing.T) {
+	assert := assert.New(t)
+
+	id, err := GenerateTransactionID()
+	assert.NoError(err)
+	_, err = Build(ClassErrorResponse, MethodBinding, id, &ErrorCode{Code: 200})
+	assert.Error(err)
+
+	out, err := Build(ClassErrorResponse, MethodBinding, id,
+		&ErrorCode{Code: CodeRoleConflict, Reason: "Role Conflict"},
+	)
+	if !assert.NoError(err) {
+		return
+	}
+
+	m, err := NewMessage(out.Pack())
+	if !assert.NoError(err) {
+		return
+	}
+	a, ok := m.GetOneAttribute(AttrErrorCode)
+	if assert.True(ok) {
+		var code ErrorCode
+		assert.NoError(code.Unpack(m, a))
+		assert.Equal(CodeRoleConflict, code.Code)
+		assert.Equal("Role Conflict", code.Reason)
+	}
+}
+
+func TestMessageType(t *testing.T) {
+	for _, class := range []MessageClass{ClassRequest, ClassIndication, ClassSuccessResponse, ClassErrorResponse} {
+		c, m := parseMessageType(messageType(class, MethodBinding))
+		if c != class || m != MethodBinding {
+			t.Errorf("messageType(%s, %s) parsed as %s %s", class, MethodBinding, c, m)
+		}
+	}
+
+	if got := messageType(ClassSuccessResponse, MethodBinding); got != 0x0101 {
+		t.Errorf("binding success response type = %#x, want 0x0101", got)
+	}
+}
@@ -0,0 +1,35 @@
+package stun
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// TransportAddr is the combination of an IP address and port
+type TransportAddr struct {
+	IP   net.IP
+	Port int
+}
+
+// NewTransportAddr creates a TransportAddr from a UDP or TCP address
+func NewTransportAddr(addr net.Addr) (*TransportAddr, error) {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return &TransportAddr{IP: a.IP, Port: a.Port}, nil
+	case *net.TCPAddr:
+		return &TransportAddr{IP: a.IP, Port: a.Port}, nil
+	default:
+		return nil, errors.Errorf("unsupported address type %T", addr)
+	}
+}
+
+// Equal reports whether both addresses have the same IP and port
+func (t *TransportAddr) Equal(other *TransportAddr) bool {
+	return other != nil && t.IP.Equal(other.IP) && t.Port == other.Port
+}
+
+func (t *TransportAddr) String() string {
+	return fmt.Sprintf("%s:%d", t.IP, t.Port)
+}
@@ -3,12 +3,16 @@ package webrtc
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/hex"
+	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/pions/webrtc/pkg/rtcerr"
@@ -54,6 +58,28 @@ func NewRTCCertificate(key crypto.PrivateKey, tpl x509.Certificate) (*RTCCertifi
 	return &RTCCertificate{privateKey: key, x509Cert: cert}, nil
 }
 
+// RTCCertificateFromX509 creates a RTCCertificate from an existing
+// x509.Certificate and its private key. Reusing the same certificate keeps
+// the fingerprint, and with it the identity, stable across sessions.
+func RTCCertificateFromX509(key crypto.PrivateKey, cert *x509.Certificate) (*RTCCertificate, error) {
+	switch sk := key.(type) {
+	case *rsa.PrivateKey:
+		pk, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok || pk.N.Cmp(sk.N) != 0 || pk.E != sk.E {
+			return nil, &rtcerr.InvalidAccessError{Err: ErrCertificateKeyMismatch}
+		}
+	case *ecdsa.PrivateKey:
+		pk, ok := cert.PublicKey.(*ecdsa.PublicKey)
+		if !ok || pk.X.Cmp(sk.X) != 0 || pk.Y.Cmp(sk.Y) != 0 {
+			return nil, &rtcerr.InvalidAccessError{Err: ErrCertificateKeyMismatch}
+		}
+	default:
+		return nil, &rtcerr.NotSupportedError{Err: ErrPrivateKeyType}
+	}
+
+	return &RTCCertificate{privateKey: key, x509Cert: cert}, nil
+}
+
 // Equals determines if two certificates are identical by comparing both the
 // secretKeys and x509Certificates.
 func (c RTCCertificate) Equals(o RTCCertificate) bool {
@@ -89,8 +115,33 @@ func (c RTCCertificate) Expires() time.Time {
 
 // GetFingerprints returns the list of certificate fingerprints, one of which
 // is computed with the digest algorithm used in the certificate signature.
-func (c RTCCertificate) GetFingerprints() {
-	panic("not implemented yet.") // nolint
+// Certificates are always signed with SHA-256, which is the only algorithm
+// returned.
+func (c RTCCertificate) GetFingerprints() []RTCDtlsFingerprint {
+	if c.x509Cert == nil {
+		return []RTCDtlsFingerprint{}
+	}
+
+	digest := sha256.Sum256(c.x509Cert.Raw)
+	value := make([]string, len(digest))
+	for i, b := range digest {
+		value[i] = fmt.Sprintf("%02x", b)
+	}
+
+	return []RTCDtlsFingerprint{{
+		Algorithm: "sha-256",
+		Value:     strings.Join(value, ":"),
+	}}
+}
+
+// GenerateECDSACertificate generates a self-signed certificate with a new
+// ECDSA P-256 private key, the key type browsers use by default.
+func GenerateECDSACertificate() (*RTCCertificate, error) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, &rtcerr.UnknownError{Err: err}
+	}
+	return GenerateCertificate(sk)
 }
 
 // GenerateCertificate causes the creation of an X.509 certificate and
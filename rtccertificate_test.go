@@ -5,12 +5,16 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/pions/webrtc/pkg/rtcerr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -86,3 +90,69 @@ func TestGenerateCertificateExpires(t *testing.T) {
 	now := time.Now()
 	assert.False(t, cert.Expires().IsZero() || now.After(cert.Expires()))
 }
+
+func TestGenerateECDSACertificate(t *testing.T) {
+	cert, err := GenerateECDSACertificate()
+	assert.Nil(t, err)
+
+	sk, ok := cert.privateKey.(*ecdsa.PrivateKey)
+	assert.True(t, ok)
+	assert.Equal(t, elliptic.P256(), sk.Curve)
+	assert.Equal(t, x509.ECDSAWithSHA256, cert.x509Cert.SignatureAlgorithm)
+	assert.Nil(t, cert.x509Cert.CheckSignature(cert.x509Cert.SignatureAlgorithm, cert.x509Cert.RawTBSCertificate, cert.x509Cert.Signature))
+}
+
+func TestGetFingerprints(t *testing.T) {
+	cert, err := GenerateECDSACertificate()
+	assert.Nil(t, err)
+
+	fingerprints := cert.GetFingerprints()
+	assert.Len(t, fingerprints, 1)
+	assert.Equal(t, "sha-256", fingerprints[0].Algorithm)
+
+	digest := sha256.Sum256(cert.x509Cert.Raw)
+	value, err := hex.DecodeString(strings.Replace(fingerprints[0].Value, ":", "", -1))
+	assert.Nil(t, err)
+	assert.Equal(t, digest[:], value)
+	assert.Equal(t, strings.ToLower(fingerprints[0].Value), fingerprints[0].Value)
+
+	assert.Empty(t, RTCCertificate{}.GetFingerprints())
+}
+
+func TestRTCCertificateFromX509(t *testing.T) {
+	generated, err := GenerateECDSACertificate()
+	assert.Nil(t, err)
+
+	cert, err := RTCCertificateFromX509(generated.privateKey, generated.x509Cert)
+	assert.Nil(t, err)
+	assert.True(t, cert.Equals(*generated))
+	assert.Equal(t, generated.GetFingerprints(), cert.GetFingerprints())
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	_, err = RTCCertificateFromX509(other, generated.x509Cert)
+	assert.EqualError(t, err, (&rtcerr.InvalidAccessError{Err: ErrCertificateKeyMismatch}).Error())
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	_, err = RTCCertificateFromX509(rsaKey, generated.x509Cert)
+	assert.EqualError(t, err, (&rtcerr.InvalidAccessError{Err: ErrCertificateKeyMismatch}).Error())
+}
+
+func TestCertificateFingerprintInOffer(t *testing.T) {
+	cert, err := GenerateECDSACertificate()
+	assert.Nil(t, err)
+
+	// The same certificate gives every session the same identity
+	for i := 0; i < 2; i++ {
+		pc, err := New(RTCConfiguration{Certificates: []RTCCertificate{*cert}})
+		assert.Nil(t, err)
+
+		offer, err := pc.CreateOffer(nil)
+		assert.Nil(t, err)
+
+		fingerprint := cert.GetFingerprints()[0]
+		assert.Contains(t, offer.Sdp, "a=fingerprint:"+fingerprint.Algorithm+" "+strings.ToUpper(fingerprint.Value))
+		assert.Nil(t, pc.Close())
+	}
+}
@@ -44,6 +44,39 @@ type RTCConfiguration struct {
 
 	// IceCandidatePoolSize describes the size of the prefetched ICE pool.
 	IceCandidatePoolSize uint8
+
+	// HideHostCandidates signals the host candidates with random mDNS
+	// .local names in place of their addresses, like browsers do to keep
+	// the local addresses private. It is not part of the W3C specification
+	// and can't be changed by SetConfiguration.
+	HideHostCandidates bool
+
+	// IceLite runs ICE in lite mode, for servers with a public address. A
+	// lite agent only has host candidates and answers the connectivity
+	// checks of the peer without sending any, the peer must be a full
+	// agent. It is not part of the W3C specification.
+	IceLite bool
+
+	// IceNetworkTypes restricts the host candidates to these network types,
+	// UDP and TCP over IPv4 when empty. It is not part of the W3C
+	// specification.
+	IceNetworkTypes []ice.NetworkType
+
+	// IceInterfaceFilter is given the name of each network interface, host
+	// candidates are only gathered on those it accepts. It is not part of
+	// the W3C specification.
+	IceInterfaceFilter func(name string) bool
+
+	// NAT1To1IP is the public address a 1:1 NAT maps the host to, as for
+	// cloud hosts. It is signaled in place of the addresses of the host
+	// candidates, which can't be hidden then. It is not part of the W3C
+	// specification.
+	NAT1To1IP string
+
+	// UDPMux is the port shared with other RTCPeerConnections the UDP host
+	// candidate is on, in place of a port on each interface. It is not part
+	// of the W3C specification.
+	UDPMux *UDPMux
 }
 
 func (c RTCConfiguration) getIceServers() (*[]*ice.URL, error) {
@@ -1,6 +1,7 @@
 package webrtc
 
 import (
+	"math"
 	"sync"
 
 	"github.com/pions/webrtc/pkg/datachannel"
@@ -57,24 +58,8 @@ type RTCDataChannel struct {
 	// ReadyState represents the state of the RTCDataChannel object.
 	ReadyState RTCDataChannelState
 
-	// BufferedAmount represents the number of bytes of application data
-	// (UTF-8 text and binary data) that have been queued using send(). Even
-	// though the data transmission can occur in parallel, the returned value
-	// MUST NOT be decreased before the current task yielded back to the event
-	// loop to prevent race conditions. The value does not include framing
-	// overhead incurred by the protocol, or buffering done by the operating
-	// system or network hardware. The value of BufferedAmount slot will only
-	// increase with each call to the send() method as long as the ReadyState is
-	// open; however, BufferedAmount does not reset to zero once the channel
-	// closes.
-	BufferedAmount uint64
-
-	// BufferedAmountLowThreshold represents the threshold at which the
-	// bufferedAmount is considered to be low. When the bufferedAmount decreases
-	// from above this threshold to equal or below it, the bufferedamountlow
-	// event fires. BufferedAmountLowThreshold is initially zero on each new
-	// RTCDataChannel, but the application may change its value at any time.
-	BufferedAmountLowThreshold uint64
+	// bufferedAmountLowThreshold is returned by BufferedAmountLowThreshold
+	bufferedAmountLowThreshold uint64
 
 	// The binaryType represents attribute MUST, on getting, return the value to
 	// which it was last set. On setting, if the new value is either the string
@@ -84,10 +69,22 @@ type RTCDataChannel struct {
 	// "blob". This attribute controls how binary data is exposed to scripts.
 	// binaryType                 string
 
-	// OnOpen              func()
-	// OnBufferedAmountLow func()
-	// OnError             func()
-	// OnClose             func()
+	// OnOpen designates an event handler which is invoked when the remote
+	// peer has acknowledged the opening of this RTCDataChannel.
+	OnOpen func()
+
+	// OnBufferedAmountLow designates an event handler which is invoked when
+	// the BufferedAmount decreases from above the BufferedAmountLowThreshold
+	// to equal or below it.
+	OnBufferedAmountLow func()
+
+	// OnError designates an event handler which is invoked when the
+	// DataChannel fails to open.
+	OnError func(error)
+
+	// OnClose designates an event handler which is invoked when the
+	// underlying SCTP stream has been reset, by either peer.
+	OnClose func()
 
 	// Onmessage designates an event handler which is invoked on a message
 	// arrival over the sctp transport from a remote peer.
@@ -99,6 +96,9 @@ type RTCDataChannel struct {
 	// arrival over the sctp transport from a remote peer.
 	OnMessage func(datachannel.Payload)
 
+	// openSent is set once the DATA_CHANNEL_OPEN message has been sent
+	openSent bool
+
 	// Deprecated: Will be removed when networkManager is deprecated.
 	rtcPeerConnection *RTCPeerConnection
 }
@@ -122,19 +122,192 @@ type RTCDataChannel struct {
 // 	return &rtcerr.OperationError{Err: ErrMaxDataChannelID}
 // }
 
-// SendOpenChannelMessage is a test to send OpenChannel manually
+// SendOpenChannelMessage sends the DATA_CHANNEL_OPEN message which opens
+// the DataChannel on the remote peer, OnOpen fires once it is acknowledged.
+// It is only sent once.
+//
+// Deprecated: DataChannels are opened once the SCTP association is
+// established, there is no need to call it.
 func (d *RTCDataChannel) SendOpenChannelMessage() error {
-	if err := d.rtcPeerConnection.networkManager.SendOpenChannelMessage(*d.ID, d.Label); err != nil {
+	d.Lock()
+	if d.openSent || d.ReadyState != RTCDataChannelStateConnecting {
+		d.Unlock()
+		return nil
+	}
+	d.openSent = true
+	msg := d.channelOpen()
+	threshold := d.bufferedAmountLowThreshold
+	d.Unlock()
+
+	// The ID may have changed since the threshold was set
+	d.rtcPeerConnection.networkManager.SetBufferedAmountLowThreshold(*d.ID, threshold)
+	if err := d.rtcPeerConnection.networkManager.SendOpenChannelMessage(*d.ID, msg); err != nil {
+		d.Lock()
+		d.openSent = false
+		d.Unlock()
 		return &rtcerr.UnknownError{Err: err}
 	}
 	return nil
+}
+
+// open opens the DataChannel once the SCTP association is established. The
+// ones negotiated by the application are open right away, the others once
+// the remote peer acknowledges their DATA_CHANNEL_OPEN.
+func (d *RTCDataChannel) open() {
+	d.Lock()
+	if d.Negotiated {
+		d.ReadyState = RTCDataChannelStateOpen
+		if d.OnOpen != nil {
+			go d.OnOpen()
+		}
+		d.Unlock()
+		return
+	}
+	d.Unlock()
+
+	if err := d.SendOpenChannelMessage(); err != nil {
+		d.RLock()
+		if d.OnError != nil {
+			go d.OnError(err)
+		}
+		d.RUnlock()
+	}
+}
+
+// channelOpen builds the DATA_CHANNEL_OPEN message describing the DataChannel
+// https://tools.ietf.org/html/rfc8832#section-5.1
+func (d *RTCDataChannel) channelOpen() *datachannel.ChannelOpen {
+	msg := &datachannel.ChannelOpen{
+		ChannelType: datachannel.ChannelTypeReliable,
+		Label:       []byte(d.Label),
+		Protocol:    []byte(d.Protocol),
+	}
+
+	switch {
+	case d.MaxRetransmits != nil:
+		msg.ChannelType = datachannel.ChannelTypePartialReliableRexmit
+		msg.ReliabilityParameter = uint32(*d.MaxRetransmits)
+	case d.MaxPacketLifeTime != nil:
+		msg.ChannelType = datachannel.ChannelTypePartialReliableTimed
+		msg.ReliabilityParameter = uint32(*d.MaxPacketLifeTime)
+	}
+	if !d.Ordered {
+		msg.ChannelType |= datachannel.ChannelTypeReliableUnordered
+	}
+
+	switch d.Priority {
+	case RTCPriorityTypeVeryLow:
+		msg.Priority = datachannel.ChannelPriorityBelowNormal
+	case RTCPriorityTypeMedium:
+		msg.Priority = datachannel.ChannelPriorityHigh
+	case RTCPriorityTypeHigh:
+		msg.Priority = datachannel.ChannelPriorityExtraHigh
+	default:
+		msg.Priority = datachannel.ChannelPriorityNormal
+	}
+
+	return msg
+}
+
+// setChannelOpen applies the parameters of a DATA_CHANNEL_OPEN message sent
+// by the remote peer
+func (d *RTCDataChannel) setChannelOpen(label, protocol string, channelType datachannel.ChannelType, priority uint16, reliabilityParameter uint32) {
+	d.Label = label
+	d.Protocol = protocol
+	d.Priority = newRTCPriorityTypeFromUint16(priority)
+	d.Ordered = channelType&datachannel.ChannelTypeReliableUnordered == 0
+
+	// ReliabilityParameter is 32 bits, the API only has room for 16
+	value := uint16(reliabilityParameter)
+	if reliabilityParameter > math.MaxUint16 {
+		value = math.MaxUint16
+	}
 
+	switch channelType &^ datachannel.ChannelTypeReliableUnordered {
+	case datachannel.ChannelTypePartialReliableRexmit:
+		d.MaxRetransmits = &value
+	case datachannel.ChannelTypePartialReliableTimed:
+		d.MaxPacketLifeTime = &value
+	}
 }
 
 // Send sends the passed message to the DataChannel peer
 func (d *RTCDataChannel) Send(p datachannel.Payload) error {
+	d.RLock()
+	state := d.ReadyState
+	d.RUnlock()
+
+	if state != RTCDataChannelStateOpen {
+		return &rtcerr.InvalidStateError{Err: ErrDataChannelNotOpen}
+	}
+
 	if err := d.rtcPeerConnection.networkManager.SendDataChannelMessage(p, *d.ID); err != nil {
 		return &rtcerr.UnknownError{Err: err}
 	}
 	return nil
 }
+
+// SendText sends the passed text to the DataChannel peer
+func (d *RTCDataChannel) SendText(s string) error {
+	return d.Send(datachannel.PayloadString{Data: []byte(s)})
+}
+
+// BufferedAmount represents the number of bytes of application data
+// (UTF-8 text and binary data) that have been queued using Send() but not
+// yet acknowledged by the remote peer. The value does not include framing
+// overhead incurred by the protocol, or buffering done by the operating
+// system or network hardware. BufferedAmount does not reset to zero once
+// the channel closes.
+func (d *RTCDataChannel) BufferedAmount() uint64 {
+	d.RLock()
+	id := d.ID
+	d.RUnlock()
+
+	if id == nil {
+		return 0
+	}
+	return d.rtcPeerConnection.networkManager.BufferedAmount(*id)
+}
+
+// BufferedAmountLowThreshold represents the threshold at which the
+// BufferedAmount is considered to be low. When the BufferedAmount decreases
+// from above this threshold to equal or below it, OnBufferedAmountLow is
+// invoked. BufferedAmountLowThreshold is initially zero on each new
+// RTCDataChannel, but the application may change its value at any time.
+func (d *RTCDataChannel) BufferedAmountLowThreshold() uint64 {
+	d.RLock()
+	defer d.RUnlock()
+
+	return d.bufferedAmountLowThreshold
+}
+
+// SetBufferedAmountLowThreshold sets the BufferedAmountLowThreshold
+func (d *RTCDataChannel) SetBufferedAmountLowThreshold(threshold uint64) {
+	d.Lock()
+	d.bufferedAmountLowThreshold = threshold
+	id := d.ID
+	d.Unlock()
+
+	// The DataChannel isn't locked while the SCTP association is, its events
+	// lock them in the opposite order
+	if id != nil {
+		d.rtcPeerConnection.networkManager.SetBufferedAmountLowThreshold(*id, threshold)
+	}
+}
+
+// Close closes the DataChannel. The underlying SCTP stream is reset, which
+// closes the DataChannel on the peer as well.
+func (d *RTCDataChannel) Close() error {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.ReadyState == RTCDataChannelStateClosing || d.ReadyState == RTCDataChannelStateClosed {
+		return nil
+	}
+
+	d.ReadyState = RTCDataChannelStateClosing
+	if err := d.rtcPeerConnection.networkManager.CloseDataChannel(*d.ID); err != nil {
+		return &rtcerr.UnknownError{Err: err}
+	}
+	return nil
+}
@@ -2,6 +2,10 @@ package webrtc
 
 import (
 	"testing"
+	"time"
+
+	"github.com/pions/webrtc/pkg/datachannel"
+	"github.com/pions/webrtc/pkg/rtcerr"
 )
 
 func TestGenerateDataChannelID(t *testing.T) {
@@ -33,3 +37,103 @@ func TestGenerateDataChannelID(t *testing.T) {
 		}
 	}
 }
+
+func TestRTCDataChannel_channelOpen(t *testing.T) {
+	var value uint16 = 500
+	testCases := []struct {
+		channel              *RTCDataChannel
+		channelType          datachannel.ChannelType
+		priority             uint16
+		reliabilityParameter uint32
+	}{
+		{&RTCDataChannel{Ordered: true, Priority: RTCPriorityTypeLow}, datachannel.ChannelTypeReliable, datachannel.ChannelPriorityNormal, 0},
+		{&RTCDataChannel{Ordered: false, Priority: RTCPriorityTypeVeryLow}, datachannel.ChannelTypeReliableUnordered, datachannel.ChannelPriorityBelowNormal, 0},
+		{&RTCDataChannel{Ordered: true, MaxRetransmits: &value, Priority: RTCPriorityTypeMedium}, datachannel.ChannelTypePartialReliableRexmit, datachannel.ChannelPriorityHigh, 500},
+		{&RTCDataChannel{Ordered: false, MaxRetransmits: &value}, datachannel.ChannelTypePartialReliableRexmitUnordered, datachannel.ChannelPriorityNormal, 500},
+		{&RTCDataChannel{Ordered: true, MaxPacketLifeTime: &value, Priority: RTCPriorityTypeHigh}, datachannel.ChannelTypePartialReliableTimed, datachannel.ChannelPriorityExtraHigh, 500},
+		{&RTCDataChannel{Ordered: false, MaxPacketLifeTime: &value}, datachannel.ChannelTypePartialReliableTimedUnordered, datachannel.ChannelPriorityNormal, 500},
+	}
+
+	for i, testCase := range testCases {
+		msg := testCase.channel.channelOpen()
+		if msg.ChannelType != testCase.channelType || msg.Priority != testCase.priority || msg.ReliabilityParameter != testCase.reliabilityParameter {
+			t.Errorf("testCase %d: wrong ChannelOpen %v", i, msg)
+		}
+
+		// The remote peer ends up with the same parameters
+		remote := &RTCDataChannel{}
+		remote.setChannelOpen("", "", msg.ChannelType, msg.Priority, msg.ReliabilityParameter)
+		if remote.Ordered != testCase.channel.Ordered ||
+			(remote.MaxRetransmits == nil) != (testCase.channel.MaxRetransmits == nil) ||
+			(remote.MaxPacketLifeTime == nil) != (testCase.channel.MaxPacketLifeTime == nil) {
+			t.Errorf("testCase %d: wrong remote parameters", i)
+		}
+	}
+}
+
+func TestAssignDataChannelIDs(t *testing.T) {
+	negotiated := &RTCDataChannel{Negotiated: true}
+	guessed := &RTCDataChannel{ReadyState: RTCDataChannelStateConnecting}
+	pc := &RTCPeerConnection{
+		sctpTransport: newRTCSctpTransport(),
+		dataChannels:  map[uint16]*RTCDataChannel{0: guessed, 2: negotiated},
+	}
+
+	if err := pc.assignDataChannelIDs(false); err != nil {
+		t.Errorf("failed to assign ids: %v", err)
+		return
+	}
+	if pc.dataChannels[2] != negotiated {
+		t.Errorf("Negotiated DataChannel must keep its id")
+	}
+	if pc.dataChannels[1] != guessed || *guessed.ID != 1 {
+		t.Errorf("DataChannel should have moved to an odd id")
+	}
+	if _, ok := pc.dataChannels[0]; ok {
+		t.Errorf("DataChannel should no longer be at its old id")
+	}
+}
+
+func TestDataChannelOpenClose(t *testing.T) {
+	pc, err := New(RTCConfiguration{})
+	if err != nil {
+		t.Fatalf("failed to create RTCPeerConnection: %v", err)
+	}
+
+	negotiated := true
+	id := uint16(2)
+	d, err := pc.CreateDataChannel("data", &RTCDataChannelInit{Negotiated: &negotiated, ID: &id})
+	if err != nil {
+		t.Fatalf("failed to create DataChannel: %v", err)
+	}
+	opened := make(chan struct{})
+	d.OnOpen = func() {
+		close(opened)
+	}
+
+	// Nothing is sent until the DataChannel is open
+	if _, ok := d.SendText("ping").(*rtcerr.InvalidStateError); !ok {
+		t.Errorf("SendText should fail before the DataChannel is open")
+	}
+	if _, ok := d.Send(datachannel.PayloadBinary{Data: []byte{1}}).(*rtcerr.InvalidStateError); !ok {
+		t.Errorf("Send should fail before the DataChannel is open")
+	}
+
+	// A negotiated DataChannel is open once the SCTP association is
+	pc.sctpEstablished()
+	select {
+	case <-opened:
+	case <-time.After(time.Second):
+		t.Fatalf("OnOpen was not called")
+	}
+	if d.ReadyState != RTCDataChannelStateOpen || pc.sctpTransport.State != RTCSctpTransportStateConnected {
+		t.Errorf("DataChannel should be open")
+	}
+
+	if err := pc.Close(); err != nil {
+		t.Errorf("failed to close RTCPeerConnection: %v", err)
+	}
+	if d.ReadyState != RTCDataChannelStateClosed {
+		t.Errorf("DataChannel should be closed with the RTCPeerConnection")
+	}
+}
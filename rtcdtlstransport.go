@@ -1,13 +1,52 @@
 package webrtc
 
+import "github.com/pions/webrtc/internal/dtls"
+
 // RTCDtlsTransport allows an application access to information about the DTLS
 // transport over which RTP and RTCP packets are sent and received by
 // RTCRtpSender and RTCRtpReceiver, as well other data such as SCTP packets sent
 // and received by data channels.
 type RTCDtlsTransport struct {
 	// Transport RTCIceTransport
-	// State     RTCDtlsTransportState
 
-	// OnStateChange func()
+	// State represents the current state of the DTLS transport.
+	State RTCDtlsTransportState
+
+	// OnStateChange designates an event handler which is called when the
+	// state of the DTLS transport changes.
+	OnStateChange func(RTCDtlsTransportState)
+
 	// OnError       func()
 }
+
+func newRTCDtlsTransport() *RTCDtlsTransport {
+	return &RTCDtlsTransport{
+		State: RTCDtlsTransportStateNew,
+	}
+}
+
+// updateState sets the state from the one of the DTLS session, the caller
+// holds the lock of the RTCPeerConnection
+func (t *RTCDtlsTransport) updateState(state dtls.ConnectionState) {
+	var newState RTCDtlsTransportState
+	switch state {
+	case dtls.ConnectionStateNew:
+		newState = RTCDtlsTransportStateNew
+	case dtls.ConnectionStateConnecting:
+		newState = RTCDtlsTransportStateConnecting
+	case dtls.ConnectionStateConnected:
+		newState = RTCDtlsTransportStateConnected
+	case dtls.ConnectionStateClosed:
+		newState = RTCDtlsTransportStateClosed
+	default:
+		newState = RTCDtlsTransportStateFailed
+	}
+
+	if t.State == newState {
+		return
+	}
+	t.State = newState
+	if t.OnStateChange != nil {
+		t.OnStateChange(newState)
+	}
+}
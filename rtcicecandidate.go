@@ -0,0 +1,18 @@
+package webrtc
+
+// RTCIceCandidate describes an ICE candidate the way it is exchanged with
+// the remote peer when candidates are trickled. An empty Candidate tells
+// the remote peer has no more candidates.
+// https://www.w3.org/TR/webrtc/#rtcicecandidate-interface
+type RTCIceCandidate struct {
+	// Candidate is the candidate-attribute, as in "candidate:1 1 udp ..."
+	Candidate string `json:"candidate"`
+
+	// SdpMid is the media stream identification of the media description
+	// the candidate belongs to
+	SdpMid *string `json:"sdpMid"`
+
+	// SdpMLineIndex is the index of the media description the candidate
+	// belongs to
+	SdpMLineIndex *uint16 `json:"sdpMLineIndex"`
+}
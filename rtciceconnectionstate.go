@@ -1,5 +1,7 @@
 package webrtc
 
+import "github.com/pions/webrtc/pkg/ice"
+
 // RTCIceConnectionState indicates signaling state of the Ice Connection.
 type RTCIceConnectionState int
 
@@ -91,3 +93,26 @@ func (c RTCIceConnectionState) String() string {
 		return ErrUnknownType.Error()
 	}
 }
+
+// newRTCIceConnectionStateFromAgent returns the state of the ICE agent, the
+// only transport of the connection
+func newRTCIceConnectionStateFromAgent(state ice.ConnectionState) RTCIceConnectionState {
+	switch state {
+	case ice.ConnectionStateNew:
+		return RTCIceConnectionStateNew
+	case ice.ConnectionStateChecking:
+		return RTCIceConnectionStateChecking
+	case ice.ConnectionStateConnected:
+		return RTCIceConnectionStateConnected
+	case ice.ConnectionStateCompleted:
+		return RTCIceConnectionStateCompleted
+	case ice.ConnectionStateDisconnected:
+		return RTCIceConnectionStateDisconnected
+	case ice.ConnectionStateFailed:
+		return RTCIceConnectionStateFailed
+	case ice.ConnectionStateClosed:
+		return RTCIceConnectionStateClosed
+	default:
+		return RTCIceConnectionState(Unknown)
+	}
+}
@@ -3,6 +3,7 @@ package webrtc
 import (
 	"testing"
 
+	"github.com/pions/webrtc/pkg/ice"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -53,3 +54,27 @@ func TestRTCIceConnectionState_String(t *testing.T) {
 		)
 	}
 }
+
+func TestNewRTCIceConnectionStateFromAgent(t *testing.T) {
+	testCases := []struct {
+		agentState    ice.ConnectionState
+		expectedState RTCIceConnectionState
+	}{
+		{ice.ConnectionState(Unknown), RTCIceConnectionState(Unknown)},
+		{ice.ConnectionStateNew, RTCIceConnectionStateNew},
+		{ice.ConnectionStateChecking, RTCIceConnectionStateChecking},
+		{ice.ConnectionStateConnected, RTCIceConnectionStateConnected},
+		{ice.ConnectionStateCompleted, RTCIceConnectionStateCompleted},
+		{ice.ConnectionStateDisconnected, RTCIceConnectionStateDisconnected},
+		{ice.ConnectionStateFailed, RTCIceConnectionStateFailed},
+		{ice.ConnectionStateClosed, RTCIceConnectionStateClosed},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedState,
+			newRTCIceConnectionStateFromAgent(testCase.agentState),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
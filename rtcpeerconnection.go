@@ -2,17 +2,17 @@
 package webrtc
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"encoding/binary"
 
+	"github.com/pions/webrtc/internal/dtls"
 	"github.com/pions/webrtc/internal/network"
 	"github.com/pions/webrtc/internal/sdp"
 	"github.com/pions/webrtc/pkg/ice"
@@ -65,7 +65,7 @@ type RTCPeerConnection struct {
 
 	// IceGatheringState attribute returns the ICE gathering state of the
 	// RTCPeerConnection instance.
-	IceGatheringState RTCIceGatheringState // FIXME NOT-USED
+	IceGatheringState RTCIceGatheringState
 
 	// IceConnectionState attribute returns the ICE connection state of the
 	// RTCPeerConnection instance.
@@ -88,29 +88,58 @@ type RTCPeerConnection struct {
 	mediaEngine     *MediaEngine
 	rtpTransceivers []*RTCRtpTransceiver
 
+	// headerExtensions are the RTP header extensions the offer and answer
+	// agreed on, with the IDs they are sent with
+	headerExtensions rtp.HeaderExtensionMap
+
 	// sctpTransport
 	sctpTransport *RTCSctpTransport
 
 	// DataChannels
 	dataChannels map[uint16]*RTCDataChannel
 
-	// OnNegotiationNeeded        func() // FIXME NOT-USED
-	// OnIceCandidate             func() // FIXME NOT-USED
+	// isDTLSClient is our DTLS role, nil until the remote description settles
+	// it. It decides the parity of the stream identifiers we pick.
+	isDTLSClient *bool
+
+	// OnNegotiationNeeded designates an event handler which is called when
+	// the media of the connection changed in a way that needs an offer to
+	// be negotiated, once the signaling state is stable.
+	OnNegotiationNeeded func()
+
+	// OnIceCandidate designates an event handler which is called with each
+	// local candidate gathered after the offer or answer was created, for
+	// it to be trickled to the remote peer. It is called with nil once
+	// gathering is complete.
+	OnIceCandidate func(*RTCIceCandidate)
+
 	// OnIceCandidateError        func() // FIXME NOT-USED
-	// OnSignalingStateChange     func() // FIXME NOT-USED
+
+	// The state change handlers are called one at a time, in the order the
+	// states changed in.
+
+	// OnSignalingStateChange designates an event handler which is called
+	// when the signaling state is changed by setting a description.
+	OnSignalingStateChange func(RTCSignalingState)
 
 	// OnICEConnectionStateChange designates an event handler which is called
 	// when an ice connection state is changed.
-	//
-	// Deprecated: use OnIceConnectionStateChange instead.
-	OnICEConnectionStateChange func(ice.ConnectionState)
+	OnICEConnectionStateChange func(RTCIceConnectionState)
 
 	// OnIceConnectionStateChange designates an event handler which is called
 	// when an ice connection state is changed.
+	//
+	// Deprecated: use OnICEConnectionStateChange instead.
 	OnIceConnectionStateChange func(ice.ConnectionState)
 
-	// OnIceGatheringStateChange  func() // FIXME NOT-USED
-	// OnConnectionStateChange    func() // FIXME NOT-USED
+	// OnICEGatheringStateChange designates an event handler which is called
+	// when the ICE gathering state is changed.
+	OnICEGatheringStateChange func(RTCIceGatheringState)
+
+	// OnConnectionStateChange designates an event handler which is called
+	// when the connection state, which aggregates the states of the ICE and
+	// DTLS transports, is changed.
+	OnConnectionStateChange func(RTCPeerConnectionState)
 
 	// Ontrack designates an event handler which is called when remote track
 	// arrives from a remote peer.
@@ -119,7 +148,8 @@ type RTCPeerConnection struct {
 	Ontrack func(*RTCTrack)
 
 	// OnTrack designates an event handler which is called when remote track
-	// arrives from a remote peer.
+	// arrives from a remote peer. Its packets are read with ReadRTP, or its
+	// samples with ReadSample, the RTCRtpReceiver is returned by Receiver.
 	OnTrack func(*RTCTrack)
 
 	// Ondatachannel designates an event handler which is invoked when a data
@@ -132,6 +162,15 @@ type RTCPeerConnection struct {
 	// channel message arrives from a remote peer.
 	OnDataChannel func(*RTCDataChannel)
 
+	// closed is closed by Close, to end the received tracks
+	closed chan struct{}
+
+	// stateChanges are the calls of the state change handlers that are yet
+	// to be made
+	stateChangesLock  sync.Mutex
+	stateChanges      []func()
+	isNotifyingStates bool
+
 	// Deprecated: Internal mechanism which will be removed.
 	networkManager *network.Manager
 }
@@ -162,6 +201,7 @@ func New(configuration RTCConfiguration) (*RTCPeerConnection, error) {
 		mediaEngine:        DefaultMediaEngine,
 		sctpTransport:      newRTCSctpTransport(),
 		dataChannels:       make(map[uint16]*RTCDataChannel),
+		closed:             make(chan struct{}),
 	}
 
 	var err error
@@ -169,13 +209,35 @@ func New(configuration RTCConfiguration) (*RTCPeerConnection, error) {
 		return nil, err
 	}
 
-	pc.networkManager, err = network.NewManager(pc.generateChannel, pc.dataChannelEventHandler, pc.iceStateChange)
+	pc.sctpTransport.Transport = newRTCDtlsTransport()
+
+	// DTLS authenticates with the first certificate, the one a=fingerprint describes
+	certificate := pc.configuration.Certificates[0]
+	opts := network.ManagerOptions{
+		HideHostCandidates: pc.configuration.HideHostCandidates,
+		ICELite:            pc.configuration.IceLite,
+		NetworkTypes:       pc.configuration.IceNetworkTypes,
+		InterfaceFilter:    pc.configuration.IceInterfaceFilter,
+		NAT1To1IP:          pc.configuration.NAT1To1IP,
+	}
+	if pc.configuration.UDPMux != nil {
+		opts.UDPMux = pc.configuration.UDPMux.mux
+	}
+	pc.networkManager, err = network.NewManager(pc.generateChannel, pc.dataChannelEventHandler, pc.iceStateChange, pc.dtlsStateChange, pc.sctpEstablished, certificate.x509Cert, certificate.privateKey, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	// Host candidates are gathered by now, the others are trickled
+	pc.IceGatheringState = RTCIceGatheringStateGathering
+	pc.networkManager.IceAgent.OnCandidate(pc.iceCandidateHandler)
+
 	// FIXME Temporary code before IceAgent and RTCIceTransport Rebuild
+	// A lite agent only has host candidates, it doesn't use the servers
 	for _, server := range pc.configuration.IceServers {
+		if pc.configuration.IceLite {
+			break
+		}
 		for _, rawURL := range server.URLs {
 			url, err := ice.ParseURL(rawURL)
 			if err != nil {
@@ -188,6 +250,7 @@ func New(configuration RTCConfiguration) (*RTCPeerConnection, error) {
 			}
 		}
 	}
+	pc.networkManager.IceAgent.GatheringComplete()
 
 	return &pc, nil
 }
@@ -212,11 +275,7 @@ func (pc *RTCPeerConnection) initConfiguration(configuration RTCConfiguration) e
 			pc.configuration.Certificates = append(pc.configuration.Certificates, x509Cert)
 		}
 	} else {
-		sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-		if err != nil {
-			return &rtcerr.UnknownError{Err: err}
-		}
-		certificate, err := GenerateCertificate(sk)
+		certificate, err := GenerateECDSACertificate()
 		if err != nil {
 			return err
 		}
@@ -247,6 +306,13 @@ func (pc *RTCPeerConnection) initConfiguration(configuration RTCConfiguration) e
 		}
 		pc.configuration.IceServers = configuration.IceServers
 	}
+
+	pc.configuration.HideHostCandidates = configuration.HideHostCandidates
+	pc.configuration.IceLite = configuration.IceLite
+	pc.configuration.IceNetworkTypes = configuration.IceNetworkTypes
+	pc.configuration.IceInterfaceFilter = configuration.IceInterfaceFilter
+	pc.configuration.NAT1To1IP = configuration.NAT1To1IP
+	pc.configuration.UDPMux = configuration.UDPMux
 	return nil
 }
 
@@ -335,46 +401,115 @@ func (pc *RTCPeerConnection) GetConfiguration() RTCConfiguration {
 // --- FIXME - BELOW CODE NEEDS REVIEW/CLEANUP
 // ------------------------------------------------------------------------
 
-// CreateOffer starts the RTCPeerConnection and generates the localDescription
+// CreateOffer starts the RTCPeerConnection and generates an offer, that is
+// applied with SetLocalDescription
 func (pc *RTCPeerConnection) CreateOffer(options *RTCOfferOptions) (RTCSessionDescription, error) {
 	useIdentity := pc.idpLoginURL != nil
-	if options != nil {
-		return RTCSessionDescription{}, errors.Errorf("TODO handle options")
-	} else if useIdentity {
+	if useIdentity {
 		return RTCSessionDescription{}, errors.Errorf("TODO handle identity provider")
 	} else if pc.isClosed {
 		return RTCSessionDescription{}, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-createoffer (step #4)
+	if pc.SignalingState != RTCSignalingStateStable && pc.SignalingState != RTCSignalingStateHaveLocalOffer {
+		return RTCSessionDescription{}, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}
+	}
+
+	if options != nil && options.IceRestart {
+		pc.restartIce()
+	}
+
 	d := sdp.NewJSEPSessionDescription(pc.networkManager.DTLSFingerprint(), useIdentity)
+	if pc.configuration.IceLite {
+		d = d.WithPropertyAttribute(sdp.AttrKeyICELite)
+	}
+	d = d.WithValueAttribute(sdp.AttrKeyMsidSemantic, " "+sdp.SemanticTokenWebRTCMediaStreams+" *")
 	candidates := pc.generateLocalCandidates()
 
-	bundleValue := "BUNDLE"
+	// Unified Plan, each transceiver has a media section of its own. We
+	// offer to receive the kinds there are no transceivers for yet.
+	// https://tools.ietf.org/html/draft-ietf-rtcweb-jsep-24#section-5.2.1
+	for _, kind := range []RTCRtpCodecType{RTCRtpCodecTypeAudio, RTCRtpCodecTypeVideo} {
+		if !pc.hasTransceiver(kind) {
+			pc.newRTCRtpTransceiver(&RTCRtpReceiver{}, &RTCRtpSender{}, RTCRtpTransceiverDirectionRecvonly, kind)
+		}
+	}
 
-	if pc.addRTPMediaSection(d, RTCRtpCodecTypeAudio, "audio", RTCRtpTransceiverDirectionSendrecv, candidates, sdp.ConnectionRoleActpass) {
-		bundleValue += " audio"
+	bundleValue := "BUNDLE"
+	offered := make(map[*RTCRtpTransceiver]bool)
+	offerTransceiver := func(t *RTCRtpTransceiver) {
+		offered[t] = true
+
+		// A stopped transceiver keeps its media section, rejected, once
+		// it has one
+		// https://tools.ietf.org/html/draft-ietf-rtcweb-jsep-24#section-5.2.2
+		if t.stopped {
+			if t.Mid != "" {
+				addRejectedMediaSection(d, pc.rejectedMediaTemplate(t.kind), t.Mid)
+			}
+			return
+		}
+		if t.Mid == "" {
+			t.Mid = pc.newMid(t.kind)
+		}
+		if pc.addRTPMediaSection(d, t, t.Direction, candidates, sdp.ConnectionRoleActpass) {
+			for _, e := range pc.mediaEngine.getHeaderExtensionsByKind(t.kind) {
+				d.MediaDescriptions[len(d.MediaDescriptions)-1].WithExtMap(e)
+			}
+			bundleValue += " " + t.Mid
+		}
 	}
-	if pc.addRTPMediaSection(d, RTCRtpCodecTypeVideo, "video", RTCRtpTransceiverDirectionSendrecv, candidates, sdp.ConnectionRoleActpass) {
-		bundleValue += " video"
+	dataOffered := false
+	offerData := func(midValue string, sctpmap bool) {
+		dataOffered = true
+		pc.addDataMediaSection(d, midValue, candidates, sdp.ConnectionRoleActpass, sctpmap)
+		bundleValue += " " + midValue
+	}
+
+	// A subsequent offer keeps the media sections negotiated so far in
+	// their order, with their mids. The ones added since come after them.
+	// https://tools.ietf.org/html/draft-ietf-rtcweb-jsep-24#section-5.2.2
+	if pc.CurrentLocalDescription != nil {
+		for _, m := range pc.CurrentLocalDescription.parsed.MediaDescriptions {
+			midValue := m.MID()
+			if t := pc.transceiverByMid(midValue); t != nil {
+				offerTransceiver(t)
+				continue
+			}
+			if m.MediaName.Media == "application" && m.MediaName.Port.Value != 0 && !dataOffered {
+				params, err := m.SCTPParameters()
+				offerData(midValue, err == nil && params.Sctpmap)
+				continue
+			}
+			addRejectedMediaSection(d, m, midValue)
+		}
 	}
 
-	pc.addDataMediaSection(d, "data", candidates, sdp.ConnectionRoleActpass)
-	d = d.WithValueAttribute(sdp.AttrKeyGroup, bundleValue+" data")
+	for _, t := range pc.rtpTransceivers {
+		if !offered[t] {
+			offerTransceiver(t)
+		}
+	}
+	if !dataOffered {
+		offerData("data", false)
+	}
+	d = d.WithValueAttribute(sdp.AttrKeyGroup, bundleValue)
 
 	for _, m := range d.MediaDescriptions {
 		m.WithPropertyAttribute("setup:actpass")
 	}
 
-	pc.CurrentLocalDescription = &RTCSessionDescription{
+	pc.lastOffer = d.Marshal()
+	return RTCSessionDescription{
 		Type:   RTCSdpTypeOffer,
-		Sdp:    d.Marshal(),
+		Sdp:    pc.lastOffer,
 		parsed: d,
-	}
-
-	return *pc.CurrentLocalDescription, nil
+	}, nil
 }
 
-// CreateAnswer starts the RTCPeerConnection and generates the localDescription
+// CreateAnswer generates an answer to the remote offer, that is applied
+// with SetLocalDescription
 func (pc *RTCPeerConnection) CreateAnswer(options *RTCAnswerOptions) (RTCSessionDescription, error) {
 	useIdentity := pc.idpLoginURL != nil
 	if options != nil {
@@ -385,57 +520,147 @@ func (pc *RTCPeerConnection) CreateAnswer(options *RTCAnswerOptions) (RTCSession
 		return RTCSessionDescription{}, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-createanswer (step #5)
+	if pc.SignalingState != RTCSignalingStateHaveRemoteOffer && pc.SignalingState != RTCSignalingStateHaveLocalPranswer {
+		return RTCSessionDescription{}, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}
+	}
+	remoteDescription := pc.RemoteDescription()
+
 	candidates := pc.generateLocalCandidates()
 	d := sdp.NewJSEPSessionDescription(pc.networkManager.DTLSFingerprint(), useIdentity)
+	if pc.configuration.IceLite {
+		d = d.WithPropertyAttribute(sdp.AttrKeyICELite)
+	}
 
+	d = d.WithValueAttribute(sdp.AttrKeyMsidSemantic, " "+sdp.SemanticTokenWebRTCMediaStreams+" *")
+
+	// The DTLS role is kept once set, a subsequent offer doesn't start a
+	// new DTLS association
+	// https://tools.ietf.org/html/rfc5763#section-6.6
+	remoteRole, _, _ := remoteDTLSParameters(remoteDescription.parsed)
+	dtlsRole := answerDTLSRole(remoteRole)
+	if pc.isDTLSClient != nil {
+		dtlsRole = sdp.ConnectionRolePassive
+		if *pc.isDTLSClient {
+			dtlsRole = sdp.ConnectionRoleActive
+		}
+	}
+
+	// Each media section of the offer is answered in its order, by the
+	// transceiver with its mid. The ones we can't use are rejected.
+	// https://tools.ietf.org/html/draft-ietf-rtcweb-jsep-24#section-5.3.1
+	//
+	// Everything runs on one transport, so are the sections the offer
+	// bundles. Without a BUNDLE group only the first section can be used.
+	// https://tools.ietf.org/html/draft-ietf-mmusic-sdp-bundle-negotiation-54#section-7.3
 	bundleValue := "BUNDLE"
-	for _, remoteMedia := range pc.CurrentRemoteDescription.parsed.MediaDescriptions {
-		// TODO @trivigy better SDP parser
-		var peerDirection RTCRtpTransceiverDirection
-		midValue := ""
-		for _, a := range remoteMedia.Attributes {
-			if strings.HasPrefix(*a.String(), "mid") {
-				midValue = (*a.String())[len("mid:"):]
-			} else if strings.HasPrefix(*a.String(), "sendrecv") {
-				peerDirection = RTCRtpTransceiverDirectionSendrecv
-			} else if strings.HasPrefix(*a.String(), "sendonly") {
-				peerDirection = RTCRtpTransceiverDirectionSendonly
-			} else if strings.HasPrefix(*a.String(), "recvonly") {
-				peerDirection = RTCRtpTransceiverDirectionRecvonly
+	remoteBundle := remoteDescription.parsed.BundleGroup()
+	canBundle := func(mid string) bool {
+		if len(remoteBundle) == 0 {
+			// No section is accepted yet
+			return bundleValue == "BUNDLE"
+		}
+		for _, bundled := range remoteBundle {
+			if bundled == mid {
+				return true
 			}
 		}
+		return false
+	}
+
+	for _, remoteMedia := range remoteDescription.parsed.MediaDescriptions {
+		peerDirection := mediaDirection(remoteMedia)
+		midValue := remoteMedia.MID()
 
 		appendBundle := func() {
 			bundleValue += " " + midValue
 		}
 
-		if strings.HasPrefix(*remoteMedia.MediaName.String(), "audio") {
-			if pc.addRTPMediaSection(d, RTCRtpCodecTypeAudio, midValue, peerDirection, candidates, sdp.ConnectionRoleActive) {
+		kind := NewRTCRtpCodecType(remoteMedia.MediaName.Media)
+		switch {
+		case remoteMedia.MediaName.Port.Value == 0 || !canBundle(midValue):
+			addRejectedMediaSection(d, remoteMedia, midValue)
+		case kind != RTCRtpCodecType(Unknown):
+			t := pc.answeringTransceiver(midValue, kind)
+			direction := answerDirection(t.Direction, peerDirection)
+			if !t.stopped && pc.addRTPMediaSection(d, t, direction, candidates, dtlsRole) {
+				media := d.MediaDescriptions[len(d.MediaDescriptions)-1]
+				pc.answerHeaderExtensions(media, remoteMedia, kind)
+				answerSimulcast(media, remoteMedia, direction)
 				appendBundle()
+			} else {
+				addRejectedMediaSection(d, remoteMedia, midValue)
 			}
-		} else if strings.HasPrefix(*remoteMedia.MediaName.String(), "video") {
-			if pc.addRTPMediaSection(d, RTCRtpCodecTypeVideo, midValue, peerDirection, candidates, sdp.ConnectionRoleActive) {
-				appendBundle()
+		case remoteMedia.MediaName.Media == "application":
+			params, err := remoteMedia.SCTPParameters()
+			if err != nil {
+				addRejectedMediaSection(d, remoteMedia, midValue)
+				break
 			}
-		} else if strings.HasPrefix(*remoteMedia.MediaName.String(), "application") {
-			pc.addDataMediaSection(d, midValue, candidates, sdp.ConnectionRoleActive)
+			pc.addDataMediaSection(d, midValue, candidates, dtlsRole, params.Sctpmap)
+			appendBundle()
+		default:
+			addRejectedMediaSection(d, remoteMedia, midValue)
 		}
 	}
 
-	d = d.WithValueAttribute(sdp.AttrKeyGroup, bundleValue)
+	if len(remoteBundle) != 0 {
+		d = d.WithValueAttribute(sdp.AttrKeyGroup, bundleValue)
+	}
 
-	pc.CurrentLocalDescription = &RTCSessionDescription{
+	pc.lastAnswer = d.Marshal()
+	return RTCSessionDescription{
 		Type:   RTCSdpTypeAnswer,
-		Sdp:    d.Marshal(),
+		Sdp:    pc.lastAnswer,
 		parsed: d,
-	}
-	return *pc.CurrentLocalDescription, nil
+	}, nil
 }
 
-// // SetLocalDescription sets the SessionDescription of the local peer
-// func (pc *RTCPeerConnection) SetLocalDescription() {
-// 	panic("not implemented yet") // FIXME NOT-IMPLEMENTED nolint
-// }
+// SetLocalDescription sets the SessionDescription of the local peer, the
+// offer or answer CreateOffer or CreateAnswer returned last. An empty Sdp
+// stands for that one.
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-setlocaldescription
+func (pc *RTCPeerConnection) SetLocalDescription(desc RTCSessionDescription) error {
+	if pc.isClosed {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	nextState, err := nextSignalingState(pc.SignalingState, rtcSignalingStateOpSetLocal, desc.Type)
+	if err != nil {
+		return err
+	}
+	if desc.Type == RTCSdpTypeRollback {
+		pc.setDescription(&desc, rtcSignalingStateOpSetLocal, nextState)
+		return nil
+	}
+
+	// The description can't be modified since it was created
+	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-setlocaldescription (step #2)
+	lastCreated, errModified := pc.lastOffer, ErrSDPDoesNotMatchOffer
+	if desc.Type != RTCSdpTypeOffer {
+		lastCreated, errModified = pc.lastAnswer, ErrSDPDoesNotMatchAnswer
+	}
+	if desc.Sdp == "" {
+		desc.Sdp = lastCreated
+	}
+	if desc.Sdp != lastCreated {
+		return &rtcerr.InvalidModificationError{Err: errModified}
+	}
+
+	desc.parsed = &sdp.SessionDescription{}
+	if err := desc.parsed.Unmarshal(desc.Sdp); err != nil {
+		return err
+	}
+	switch desc.Type {
+	case RTCSdpTypeOffer:
+		// The offer negotiates the changes made so far
+		pc.negotiationNeeded = false
+	case RTCSdpTypeAnswer:
+		pc.headerExtensions = headerExtensionMap(desc.parsed)
+	}
+	pc.setDescription(&desc, rtcSignalingStateOpSetLocal, nextState)
+	return nil
+}
 
 // LocalDescription returns PendingLocalDescription if it is not null and
 // otherwise it returns CurrentLocalDescription. This property is used to
@@ -448,41 +673,247 @@ func (pc *RTCPeerConnection) LocalDescription() *RTCSessionDescription {
 	return pc.CurrentLocalDescription
 }
 
-// SetRemoteDescription sets the SessionDescription of the remote peer
+// SetRemoteDescription sets the SessionDescription of the remote peer. A
+// subsequent one with the same ICE credentials renegotiates the media on
+// the transports already running, one with new credentials restarts ICE.
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-setremotedescription
 func (pc *RTCPeerConnection) SetRemoteDescription(desc RTCSessionDescription) error {
-	if pc.CurrentRemoteDescription != nil {
-		return errors.Errorf("remoteDescription is already defined, SetRemoteDescription can only be called once")
+	if pc.isClosed {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
-	weOffer := true
-	remoteUfrag := ""
-	remotePwd := ""
-	if desc.Type == RTCSdpTypeOffer {
-		weOffer = false
+	nextState, err := nextSignalingState(pc.SignalingState, rtcSignalingStateOpSetRemote, desc.Type)
+	if err != nil {
+		return err
+	}
+	if desc.Type == RTCSdpTypeRollback {
+		pc.setDescription(&desc, rtcSignalingStateOpSetRemote, nextState)
+		return nil
 	}
+	weOffer := desc.Type != RTCSdpTypeOffer
 
-	pc.CurrentRemoteDescription = &desc
-	pc.CurrentRemoteDescription.parsed = &sdp.SessionDescription{}
-	if err := pc.CurrentRemoteDescription.parsed.Unmarshal(pc.CurrentRemoteDescription.Sdp); err != nil {
+	desc.parsed = &sdp.SessionDescription{}
+	if err := desc.parsed.Unmarshal(desc.Sdp); err != nil {
 		return err
 	}
+	// RTCP is only ever sent on the ICE component of RTP
+	// https://tools.ietf.org/html/draft-ietf-rtcweb-jsep-24#section-5.8
+	for _, m := range desc.parsed.MediaDescriptions {
+		isRTP := NewRTCRtpCodecType(m.MediaName.Media) != RTCRtpCodecType(Unknown)
+		if isRTP && m.MediaName.Port.Value != 0 && !m.HasRtcpMux() {
+			return &rtcerr.InvalidAccessError{Err: ErrNoRtcpMux}
+		}
+	}
+
+	remoteUfrag, remotePwd := desc.parsed.ICECredentials()
+
+	isRestart := false
+	if previous := pc.RemoteDescription(); previous != nil {
+		// The transports were started by the first description, or by the
+		// pranswer the answer is final for
+		if previousUfrag, _ := previous.parsed.ICECredentials(); previousUfrag == remoteUfrag {
+			if weOffer {
+				pc.headerExtensions = headerExtensionMap(desc.parsed)
+			}
+			pc.setDescription(&desc, rtcSignalingStateOpSetRemote, nextState)
+			return nil
+		}
+		isRestart = true
+
+		// The peer restarts ICE, our answer needs new credentials too
+		if !weOffer {
+			pc.restartIce()
+		}
+	}
+	pc.setDescription(&desc, rtcSignalingStateOpSetRemote, nextState)
+	if weOffer {
+		pc.headerExtensions = headerExtensionMap(desc.parsed)
+	}
 
-	for _, m := range pc.CurrentRemoteDescription.parsed.MediaDescriptions {
+	for _, m := range desc.parsed.MediaDescriptions {
 		for _, a := range m.Attributes {
 			if strings.HasPrefix(*a.String(), "candidate") {
 				if c := sdp.ICECandidateUnmarshal(*a.String()); c != nil {
-					pc.networkManager.IceAgent.AddRemoteCandidate(c)
+					pc.networkManager.AddRemoteCandidate(c)
 				} else {
 					fmt.Printf("Tried to parse ICE candidate, but failed %s ", a)
 				}
-			} else if strings.HasPrefix(*a.String(), "ice-ufrag") {
-				remoteUfrag = (*a.String())[len("ice-ufrag:"):]
-			} else if strings.HasPrefix(*a.String(), "ice-pwd") {
-				remotePwd = (*a.String())[len("ice-pwd:"):]
 			}
 		}
 	}
-	return pc.networkManager.Start(weOffer, remoteUfrag, remotePwd)
+
+	// DTLS and SCTP are kept across an ICE restart
+	if isRestart {
+		return pc.networkManager.ResumeICE(remoteUfrag, remotePwd)
+	}
+
+	remoteRole, algorithm, fingerprint := remoteDTLSParameters(desc.parsed)
+	if fingerprint == "" {
+		return &rtcerr.InvalidAccessError{Err: ErrNoRemoteFingerprint}
+	}
+	if err := pc.networkManager.SetRemoteDTLSFingerprint(algorithm, fingerprint); err != nil {
+		return &rtcerr.InvalidAccessError{Err: err}
+	}
+
+	sctpParams, err := remoteSCTPParameters(desc.parsed)
+	if err != nil {
+		return &rtcerr.InvalidAccessError{Err: err}
+	}
+	pc.networkManager.SetSCTPParameters(sdp.DefaultSCTPPort, sctpParams.Port, sctpParams.MaxMessageSize)
+	pc.sctpTransport.updateMessageSize(float64(sctpParams.MaxMessageSize))
+
+	// https://tools.ietf.org/html/rfc5763#section-5
+	// The offerer is actpass, the answerer picks its role. We answer as
+	// active unless the offerer insists on being active.
+	isDTLSClient := remoteRole == sdp.ConnectionRolePassive
+	if !weOffer {
+		isDTLSClient = answerDTLSRole(remoteRole) == sdp.ConnectionRoleActive
+	}
+	if err := pc.assignDataChannelIDs(isDTLSClient); err != nil {
+		return err
+	}
+
+	// https://tools.ietf.org/html/rfc8445#section-6.1.1
+	// The offerer controls ICE, unless it is lite and the answerer isn't
+	isControlling := weOffer
+	if isLite(desc.parsed) && !pc.configuration.IceLite {
+		isControlling = true
+	}
+
+	return pc.networkManager.Start(isControlling, isDTLSClient, remoteUfrag, remotePwd)
+}
+
+// setDescription applies a local or remote description, moving the
+// signaling state to next. Offers and pranswers are pending until the
+// answer to them makes them current, a rollback discards them.
+// https://www.w3.org/TR/webrtc/#set-description
+func (pc *RTCPeerConnection) setDescription(desc *RTCSessionDescription, op rtcSignalingStateOp, next RTCSignalingState) {
+	switch {
+	case desc.Type == RTCSdpTypeRollback:
+		pc.PendingLocalDescription = nil
+		pc.PendingRemoteDescription = nil
+	case desc.Type == RTCSdpTypeAnswer && op == rtcSignalingStateOpSetLocal:
+		pc.CurrentLocalDescription = desc
+		pc.CurrentRemoteDescription = pc.PendingRemoteDescription
+		pc.PendingLocalDescription = nil
+		pc.PendingRemoteDescription = nil
+	case desc.Type == RTCSdpTypeAnswer:
+		pc.CurrentRemoteDescription = desc
+		pc.CurrentLocalDescription = pc.PendingLocalDescription
+		pc.PendingLocalDescription = nil
+		pc.PendingRemoteDescription = nil
+	case op == rtcSignalingStateOpSetLocal:
+		pc.PendingLocalDescription = desc
+	default:
+		pc.PendingRemoteDescription = desc
+	}
+	if desc.Type == RTCSdpTypeAnswer || desc.Type == RTCSdpTypePranswer {
+		pc.updateCurrentDirections(desc.parsed, op)
+	}
+	if pc.SignalingState != next {
+		pc.SignalingState = next
+		if handler := pc.OnSignalingStateChange; handler != nil {
+			pc.notifyStateChange(func() { handler(next) })
+		}
+	}
+
+	// The changes made during the negotiation need another one
+	if next == RTCSignalingStateStable && pc.negotiationNeeded {
+		pc.updateNegotiationNeeded()
+	}
+}
+
+// updateCurrentDirections sets the current direction of the transceivers
+// from the answer that is set, and stops the ones it rejects
+// https://www.w3.org/TR/webrtc/#set-description (step #4.6.9)
+func (pc *RTCPeerConnection) updateCurrentDirections(answer *sdp.SessionDescription, op rtcSignalingStateOp) {
+	for _, m := range answer.MediaDescriptions {
+		mid := m.MID()
+		for _, t := range pc.rtpTransceivers {
+			if t.Mid == "" || t.Mid != mid {
+				continue
+			}
+
+			if m.MediaName.Port.Value == 0 {
+				t.stopped = true
+				t.CurrentDirection = RTCRtpTransceiverDirectionInactive
+				break
+			}
+
+			// The remote answer tells the directions of the remote
+			// endpoint, we receive what it sends
+			direction := mediaDirection(m)
+			if op == rtcSignalingStateOpSetRemote {
+				direction = newRTCRtpTransceiverDirection(direction.receives(), direction.sends())
+			}
+			t.CurrentDirection = direction
+			break
+		}
+	}
+}
+
+// mediaDirection returns the direction attribute of a media section,
+// sendrecv if it has none
+// https://tools.ietf.org/html/rfc3264#section-5.1
+func mediaDirection(m *sdp.MediaDescription) RTCRtpTransceiverDirection {
+	for _, a := range m.Attributes {
+		if direction := NewRTCRtpTransceiverDirection(*a.String()); direction != RTCRtpTransceiverDirection(Unknown) {
+			return direction
+		}
+	}
+	return RTCRtpTransceiverDirectionSendrecv
+}
+
+// isLite returns if a session description has the session level a=ice-lite
+func isLite(d *sdp.SessionDescription) bool {
+	for _, a := range d.Attributes {
+		if *a.String() == sdp.AttrKeyICELite {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteDTLSParameters returns the a=setup role and the a=fingerprint of a
+// session description, from its session or first media level
+func remoteDTLSParameters(d *sdp.SessionDescription) (role sdp.ConnectionRole, algorithm, fingerprint string) {
+	parse := func(attributes []sdp.Attribute) {
+		for _, a := range attributes {
+			switch attr := *a.String(); {
+			case strings.HasPrefix(attr, sdp.AttrKeyConnectionSetup+":") && role == sdp.ConnectionRole(Unknown):
+				switch attr[len(sdp.AttrKeyConnectionSetup+":"):] {
+				case sdp.ConnectionRoleActive.String():
+					role = sdp.ConnectionRoleActive
+				case sdp.ConnectionRolePassive.String():
+					role = sdp.ConnectionRolePassive
+				case sdp.ConnectionRoleActpass.String():
+					role = sdp.ConnectionRoleActpass
+				case sdp.ConnectionRoleHoldconn.String():
+					role = sdp.ConnectionRoleHoldconn
+				}
+			case strings.HasPrefix(attr, "fingerprint:") && fingerprint == "":
+				parts := strings.Fields(attr[len("fingerprint:"):])
+				if len(parts) == 2 {
+					algorithm, fingerprint = parts[0], parts[1]
+				}
+			}
+		}
+	}
+
+	parse(d.Attributes)
+	for _, m := range d.MediaDescriptions {
+		parse(m.Attributes)
+	}
+	return role, algorithm, fingerprint
+}
+
+// answerDTLSRole returns the a=setup role of our answer to an offer with
+// remoteRole
+func answerDTLSRole(remoteRole sdp.ConnectionRole) sdp.ConnectionRole {
+	if remoteRole == sdp.ConnectionRoleActive {
+		return sdp.ConnectionRolePassive
+	}
+	return sdp.ConnectionRoleActive
 }
 
 // RemoteDescription returns PendingRemoteDescription if it is not null and
@@ -496,9 +927,28 @@ func (pc *RTCPeerConnection) RemoteDescription() *RTCSessionDescription {
 	return pc.CurrentRemoteDescription
 }
 
-// func (pc *RTCPeerConnection) addIceCandidate() {
-// 	panic("not implemented yet") // FIXME NOT-IMPLEMENTED nolint
-// }
+// AddIceCandidate adds a candidate the remote peer trickled, checks start
+// on it right away. An empty candidate tells the remote peer has no more.
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-addicecandidate
+func (pc *RTCPeerConnection) AddIceCandidate(candidate RTCIceCandidate) error {
+	if pc.isClosed {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	} else if pc.RemoteDescription() == nil {
+		return &rtcerr.InvalidStateError{Err: ErrNoRemoteDescription}
+	}
+
+	raw := strings.TrimPrefix(candidate.Candidate, "a=")
+	if raw == "" {
+		return nil
+	}
+
+	c := sdp.ICECandidateUnmarshal(raw)
+	if c == nil {
+		return &rtcerr.OperationError{Err: ErrInvalidCandidate}
+	}
+	pc.networkManager.AddRemoteCandidate(c)
+	return nil
+}
 
 // ------------------------------------------------------------------------
 // --- FIXME - BELOW CODE NEEDS RE-ORGANIZATION - https://w3c.github.io/webrtc-pc/#rtp-media-api
@@ -549,8 +999,7 @@ func (pc *RTCPeerConnection) AddTrack(track *RTCTrack) (*RTCRtpSender, error) {
 		if !t.stopped &&
 			// t.Sender == nil && // TODO: check that the sender has never sent
 			t.Sender.Track == nil &&
-			t.Receiver.Track != nil &&
-			t.Receiver.Track.Kind == track.Kind {
+			t.kind == track.Kind {
 			transceiver = t
 			break
 		}
@@ -560,27 +1009,122 @@ func (pc *RTCPeerConnection) AddTrack(track *RTCTrack) (*RTCRtpSender, error) {
 			return nil, err
 		}
 	} else {
-		var receiver *RTCRtpReceiver
-		sender := newRTCRtpSender(track)
 		transceiver = pc.newRTCRtpTransceiver(
-			receiver,
-			sender,
-			RTCRtpTransceiverDirectionSendonly,
+			&RTCRtpReceiver{},
+			newRTCRtpSender(track),
+			RTCRtpTransceiverDirectionSendrecv,
+			track.Kind,
 		)
 	}
+	track.setSending(true)
 
-	transceiver.Mid = track.Kind.String() // TODO: Mid generation
-
+	pc.updateNegotiationNeeded()
 	return transceiver.Sender, nil
 }
 
-// func (pc *RTCPeerConnection) RemoveTrack() {
-// 	panic("not implemented yet") // FIXME NOT-IMPLEMENTED nolint
-// }
+// RemoveTrack stops the RTCRtpSender from sending its RTCTrack. The media
+// section of the transceiver stays, to receive with, until it is stopped.
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-removetrack
+func (pc *RTCPeerConnection) RemoveTrack(sender *RTCRtpSender) error {
+	if pc.isClosed {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	var transceiver *RTCRtpTransceiver
+	for _, t := range pc.rtpTransceivers {
+		if t.Sender == sender {
+			transceiver = t
+			break
+		}
+	}
+	if transceiver == nil {
+		return &rtcerr.InvalidAccessError{Err: ErrSenderNotCreatedByConnection}
+	} else if sender.Track == nil {
+		return nil
+	}
+
+	sender.Track.setSending(false)
+	sender.Track = nil
+	switch transceiver.Direction {
+	case RTCRtpTransceiverDirectionSendrecv:
+		transceiver.Direction = RTCRtpTransceiverDirectionRecvonly
+	case RTCRtpTransceiverDirectionSendonly:
+		transceiver.Direction = RTCRtpTransceiverDirectionInactive
+	}
+
+	pc.updateNegotiationNeeded()
+	return nil
+}
+
+// updateNegotiationNeeded flags that the media changed since the last
+// offer, OnNegotiationNeeded is called if no negotiation is in progress
+// https://www.w3.org/TR/webrtc/#dfn-update-the-negotiation-needed-flag
+func (pc *RTCPeerConnection) updateNegotiationNeeded() {
+	pc.negotiationNeeded = true
+	if pc.SignalingState == RTCSignalingStateStable && pc.OnNegotiationNeeded != nil {
+		go pc.OnNegotiationNeeded()
+	}
+}
+
+// AddTransceiver creates an RTCRtpTransceiver of the kind, with a media
+// section of its own in the next offer
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-addtransceiver
+func (pc *RTCPeerConnection) AddTransceiver(kind RTCRtpCodecType, init *RTCRtpTransceiverInit) (*RTCRtpTransceiver, error) {
+	if pc.isClosed {
+		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	} else if kind != RTCRtpCodecTypeAudio && kind != RTCRtpCodecTypeVideo {
+		return nil, &rtcerr.TypeError{Err: ErrUnknownType}
+	}
 
-// func (pc *RTCPeerConnection) AddTransceiver() RTCRtpTransceiver {
-// 	panic("not implemented yet") // FIXME NOT-IMPLEMENTED nolint
-// }
+	direction, err := transceiverInitDirection(init)
+	if err != nil {
+		return nil, err
+	}
+
+	t := pc.newRTCRtpTransceiver(&RTCRtpReceiver{}, &RTCRtpSender{}, direction, kind)
+	pc.updateNegotiationNeeded()
+	return t, nil
+}
+
+// AddTransceiverFromTrack creates an RTCRtpTransceiver that sends the track,
+// with a media section of its own in the next offer
+// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-addtransceiver
+func (pc *RTCPeerConnection) AddTransceiverFromTrack(track *RTCTrack, init *RTCRtpTransceiverInit) (*RTCRtpTransceiver, error) {
+	if pc.isClosed {
+		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+	for _, t := range pc.rtpTransceivers {
+		if t.Sender.Track != nil && t.Sender.Track.ID == track.ID {
+			return nil, &rtcerr.InvalidAccessError{Err: ErrExistingTrack}
+		}
+	}
+
+	direction, err := transceiverInitDirection(init)
+	if err != nil {
+		return nil, err
+	}
+
+	t := pc.newRTCRtpTransceiver(&RTCRtpReceiver{}, newRTCRtpSender(track), direction, track.Kind)
+	track.setSending(true)
+	pc.updateNegotiationNeeded()
+	return t, nil
+}
+
+// transceiverInitDirection returns the direction init tells, sendrecv if it
+// doesn't
+func transceiverInitDirection(init *RTCRtpTransceiverInit) (RTCRtpTransceiverDirection, error) {
+	if init == nil || init.Direction == RTCRtpTransceiverDirection(Unknown) {
+		return RTCRtpTransceiverDirectionSendrecv, nil
+	}
+
+	switch init.Direction {
+	case RTCRtpTransceiverDirectionSendrecv, RTCRtpTransceiverDirectionSendonly,
+		RTCRtpTransceiverDirectionRecvonly, RTCRtpTransceiverDirectionInactive:
+		return init.Direction, nil
+	default:
+		return RTCRtpTransceiverDirection(Unknown), &rtcerr.TypeError{Err: ErrUnknownType}
+	}
+}
 
 // ------------------------------------------------------------------------
 // --- FIXME - BELOW CODE NEEDS RE-ORGANIZATION - https://w3c.github.io/webrtc-pc/#peer-to-peer-data-api
@@ -619,8 +1163,6 @@ func (pc *RTCPeerConnection) CreateDataChannel(label string, options *RTCDataCha
 		Priority:          RTCPriorityTypeLow,
 		// https://w3c.github.io/webrtc-pc/#dfn-create-an-rtcdatachannel (Step #2)
 		ReadyState: RTCDataChannelStateConnecting,
-		// https://w3c.github.io/webrtc-pc/#dfn-create-an-rtcdatachannel (Step #3)
-		BufferedAmount: 0,
 	}
 
 	if options != nil {
@@ -683,12 +1225,11 @@ func (pc *RTCPeerConnection) CreateDataChannel(label string, options *RTCDataCha
 	// https://w3c.github.io/webrtc-pc/#peer-to-peer-data-api (Step #19)
 	if channel.ID == nil {
 		var err error
-		if channel.ID, err = pc.generateDataChannelID(true); err != nil {
+		// Until the DTLS role is known we guess we are the client, the ID is
+		// picked again by assignDataChannelIDs if that turns out wrong
+		if channel.ID, err = pc.generateDataChannelID(pc.isDTLSClient == nil || *pc.isDTLSClient); err != nil {
 			return nil, err
 		}
-		// if err := channel.generateID(); err != nil {
-		// 	return nil, err
-		// }
 	}
 
 	// // https://w3c.github.io/webrtc-pc/#peer-to-peer-data-api (Step #18)
@@ -702,10 +1243,16 @@ func (pc *RTCPeerConnection) CreateDataChannel(label string, options *RTCDataCha
 	}
 
 	// Remember datachannel
+	pc.Lock()
 	pc.dataChannels[*channel.ID] = &channel
+	connected := pc.sctpTransport.State == RTCSctpTransportStateConnected
+	pc.Unlock()
 
-	// Send opening message
-	// pc.networkManager.SendOpenChannelMessage(id, label)
+	// The DataChannels created before the SCTP association is established
+	// are opened by sctpEstablished
+	if connected {
+		channel.open()
+	}
 
 	return &channel, nil
 }
@@ -725,6 +1272,40 @@ func (pc *RTCPeerConnection) generateDataChannelID(client bool) (*uint16, error)
 	return nil, &rtcerr.OperationError{Err: ErrMaxDataChannelID}
 }
 
+// assignDataChannelIDs records our DTLS role and moves the DataChannels whose
+// ID was guessed before it was known to a stream identifier of the right
+// parity, the DTLS client uses even ones and the server odd ones
+// https://tools.ietf.org/html/rfc8832#section-6
+func (pc *RTCPeerConnection) assignDataChannelIDs(isDTLSClient bool) error {
+	pc.Lock()
+	defer pc.Unlock()
+
+	pc.isDTLSClient = &isDTLSClient
+
+	var misassigned []*RTCDataChannel
+	for id, channel := range pc.dataChannels {
+		if channel == nil || channel.Negotiated || channel.ReadyState != RTCDataChannelStateConnecting {
+			continue
+		}
+		if (id%2 == 0) != isDTLSClient {
+			misassigned = append(misassigned, channel)
+			delete(pc.dataChannels, id)
+		}
+	}
+
+	for _, channel := range misassigned {
+		id, err := pc.generateDataChannelID(isDTLSClient)
+		if err != nil {
+			return err
+		}
+		channel.Lock()
+		channel.ID = id
+		channel.Unlock()
+		pc.dataChannels[*id] = channel
+	}
+	return nil
+}
+
 // SetMediaEngine allows overwriting the default media engine used by the RTCPeerConnection
 // This enables RTCPeerConnection with support for different codecs
 func (pc *RTCPeerConnection) SetMediaEngine(m *MediaEngine) {
@@ -740,6 +1321,10 @@ func (pc *RTCPeerConnection) SetIdentityProvider(provider string) error {
 func (pc *RTCPeerConnection) Close() error {
 	pc.networkManager.Close()
 
+	// The callbacks of the networkManager may still be running
+	pc.Lock()
+	defer pc.Unlock()
+
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #2)
 	if pc.isClosed {
 		return nil
@@ -747,6 +1332,7 @@ func (pc *RTCPeerConnection) Close() error {
 
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #3)
 	pc.isClosed = true
+	close(pc.closed)
 
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #4)
 	pc.SignalingState = RTCSignalingStateClosed
@@ -755,6 +1341,14 @@ func (pc *RTCPeerConnection) Close() error {
 	// pc.IceConnectionState = RTCIceConnectionStateClosed
 	pc.IceConnectionState = ice.ConnectionStateClosed // FIXME REMOVE
 
+	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #7)
+	for _, d := range pc.dataChannels {
+		d.Lock()
+		d.ReadyState = RTCDataChannelStateClosed
+		d.Unlock()
+	}
+	pc.sctpTransport.State = RTCSctpTransportStateClosed
+
 	// https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-close (step #12)
 	pc.ConnectionState = RTCPeerConnectionStateClosed
 
@@ -763,11 +1357,20 @@ func (pc *RTCPeerConnection) Close() error {
 
 /* Everything below is private */
 func (pc *RTCPeerConnection) generateChannel(ssrc uint32, payloadType uint8) (buffers chan<- *rtp.Packet) {
-	if pc.Ontrack == nil {
+	pc.Lock()
+	defer pc.Unlock()
+
+	if pc.OnTrack == nil && pc.Ontrack == nil {
 		return nil
 	}
 
-	sdpCodec, err := pc.CurrentLocalDescription.parsed.GetCodecForPayloadType(payloadType)
+	localDescription := pc.LocalDescription()
+	if localDescription == nil {
+		fmt.Printf("No local description to find the codec of payloadType %d in\n", payloadType)
+		return nil
+	}
+
+	sdpCodec, err := localDescription.parsed.GetCodecForPayloadType(payloadType)
 	if err != nil {
 		fmt.Printf("No codec could be found in RemoteDescription for payloadType %d \n", payloadType)
 		return nil
@@ -776,6 +1379,7 @@ func (pc *RTCPeerConnection) generateChannel(ssrc uint32, payloadType uint8) (bu
 	codec, err := pc.mediaEngine.getCodecSDP(sdpCodec)
 	if err != nil {
 		fmt.Printf("Codec %s in not registered\n", sdpCodec)
+		return nil
 	}
 
 	bufferTransport := make(chan *rtp.Packet, 15)
@@ -783,16 +1387,55 @@ func (pc *RTCPeerConnection) generateChannel(ssrc uint32, payloadType uint8) (bu
 	track := &RTCTrack{
 		PayloadType: payloadType,
 		Kind:        codec.Type,
-		ID:          "0", // TODO extract from remoteDescription
-		Label:       "",  // TODO extract from remoteDescription
+		ID:          "0",
 		Ssrc:        ssrc,
 		Codec:       codec,
 		Packets:     bufferTransport,
+
+		HeaderExtensions: pc.headerExtensions,
+		done:             pc.closed,
+	}
+
+	// The stream is identified by the media section that announces its
+	// SSRC, if any, or else it is received by the first transceiver of its
+	// kind that has no track yet
+	var media *sdp.MediaDescription
+	if remoteDescription := pc.RemoteDescription(); remoteDescription != nil {
+		media = remoteDescription.parsed.MediaForSSRC(ssrc)
+	}
+	var transceiver *RTCRtpTransceiver
+	if media != nil {
+		if label, id := media.Msid(ssrc); id != "" {
+			track.Label, track.ID = label, id
+		}
+		mid := media.MID()
+		for _, t := range pc.rtpTransceivers {
+			if t.Mid == mid {
+				transceiver = t
+				break
+			}
+		}
+	} else {
+		for _, t := range pc.rtpTransceivers {
+			if !t.stopped && t.kind == codec.Type && t.Direction.receives() && t.Receiver.Track == nil {
+				transceiver = t
+				break
+			}
+		}
 	}
 
-	// TODO: Register the receiving Track
+	receiver := &RTCRtpReceiver{Track: track}
+	if transceiver != nil && transceiver.Receiver.Track == nil {
+		transceiver.Receiver.Track = track
+		receiver = transceiver.Receiver
+	}
+	track.receiver = receiver
 
-	go pc.Ontrack(track)
+	if pc.OnTrack != nil {
+		go pc.OnTrack(track)
+	} else {
+		go pc.Ontrack(track)
+	}
 	return bufferTransport
 }
 
@@ -800,10 +1443,156 @@ func (pc *RTCPeerConnection) iceStateChange(newState ice.ConnectionState) {
 	pc.Lock()
 	defer pc.Unlock()
 
-	if pc.OnICEConnectionStateChange != nil && pc.IceConnectionState != newState {
-		pc.OnICEConnectionStateChange(newState)
+	if pc.isClosed || pc.IceConnectionState == newState {
+		return
 	}
 	pc.IceConnectionState = newState
+
+	if handler := pc.OnICEConnectionStateChange; handler != nil {
+		iceState := newRTCIceConnectionStateFromAgent(newState)
+		pc.notifyStateChange(func() { handler(iceState) })
+	}
+	if handler := pc.OnIceConnectionStateChange; handler != nil {
+		pc.notifyStateChange(func() { handler(newState) })
+	}
+	pc.updateConnectionState()
+}
+
+// updateConnectionState updates the connection state after the state of
+// the ICE or DTLS transport changed
+func (pc *RTCPeerConnection) updateConnectionState() {
+	newState := newRTCPeerConnectionStateFromTransports(pc.IceConnectionState, pc.sctpTransport.Transport.State)
+	if pc.isClosed || pc.ConnectionState == newState {
+		return
+	}
+	pc.ConnectionState = newState
+
+	if handler := pc.OnConnectionStateChange; handler != nil {
+		pc.notifyStateChange(func() { handler(newState) })
+	}
+}
+
+// notifyStateChange queues a call of a state change handler. The caller may
+// hold the lock of the RTCPeerConnection, so the handlers are called from a
+// goroutine, one at a time in the order the states changed.
+func (pc *RTCPeerConnection) notifyStateChange(call func()) {
+	pc.stateChangesLock.Lock()
+	defer pc.stateChangesLock.Unlock()
+
+	pc.stateChanges = append(pc.stateChanges, call)
+	if pc.isNotifyingStates {
+		return
+	}
+	pc.isNotifyingStates = true
+
+	go func() {
+		for {
+			pc.stateChangesLock.Lock()
+			if len(pc.stateChanges) == 0 {
+				pc.isNotifyingStates = false
+				pc.stateChangesLock.Unlock()
+				return
+			}
+			call := pc.stateChanges[0]
+			pc.stateChanges = pc.stateChanges[1:]
+			pc.stateChangesLock.Unlock()
+
+			call()
+		}
+	}()
+}
+
+// restartIce restarts ICE with new local credentials, the candidates that
+// are gathered again are trickled
+func (pc *RTCPeerConnection) restartIce() {
+	pc.Lock()
+	pc.IceGatheringState = RTCIceGatheringStateGathering
+	onGatheringStateChange := pc.OnICEGatheringStateChange
+	pc.Unlock()
+
+	if onGatheringStateChange != nil {
+		onGatheringStateChange(RTCIceGatheringStateGathering)
+	}
+	pc.networkManager.RestartICE()
+}
+
+// iceCandidateHandler trickles the local candidates the IceAgent gathers,
+// nil tells gathering is complete
+func (pc *RTCPeerConnection) iceCandidateHandler(c ice.Candidate) {
+	pc.Lock()
+	var candidate *RTCIceCandidate
+	if c != nil {
+		// Every media description shares the candidates of the first one
+		var sdpMLineIndex uint16
+		candidate = &RTCIceCandidate{
+			Candidate:     "candidate:" + sdp.ICECandidateMarshal(c)[0],
+			SdpMLineIndex: &sdpMLineIndex,
+		}
+		if mid := pc.firstLocalMid(); mid != "" {
+			candidate.SdpMid = &mid
+		}
+	}
+
+	onGatheringStateChange := pc.OnICEGatheringStateChange
+	if c != nil || pc.IceGatheringState == RTCIceGatheringStateComplete {
+		onGatheringStateChange = nil
+	} else {
+		pc.IceGatheringState = RTCIceGatheringStateComplete
+	}
+	onIceCandidate := pc.OnIceCandidate
+	pc.Unlock()
+
+	if onGatheringStateChange != nil {
+		onGatheringStateChange(RTCIceGatheringStateComplete)
+	}
+	if onIceCandidate != nil {
+		onIceCandidate(candidate)
+	}
+}
+
+// firstLocalMid returns the mid of the first media description of the local
+// description, if any
+func (pc *RTCPeerConnection) firstLocalMid() string {
+	desc := pc.LocalDescription()
+	if desc == nil || desc.parsed == nil || len(desc.parsed.MediaDescriptions) == 0 {
+		return ""
+	}
+	for _, a := range desc.parsed.MediaDescriptions[0].Attributes {
+		if strings.HasPrefix(*a.String(), sdp.AttrKeyMID+":") {
+			return (*a.String())[len(sdp.AttrKeyMID+":"):]
+		}
+	}
+	return ""
+}
+
+func (pc *RTCPeerConnection) dtlsStateChange(newState dtls.ConnectionState) {
+	pc.Lock()
+	defer pc.Unlock()
+
+	pc.sctpTransport.Transport.updateState(newState)
+	pc.updateConnectionState()
+}
+
+// sctpEstablished opens the DataChannels created while the SCTP association
+// was being established
+func (pc *RTCPeerConnection) sctpEstablished() {
+	pc.Lock()
+	pc.sctpTransport.State = RTCSctpTransportStateConnected
+	var connecting []*RTCDataChannel
+	for _, d := range pc.dataChannels {
+		d.RLock()
+		if d.ReadyState == RTCDataChannelStateConnecting {
+			connecting = append(connecting, d)
+		}
+		d.RUnlock()
+	}
+	pc.Unlock()
+
+	// The events of the association lock it before the RTCPeerConnection,
+	// it is unlocked before the association is
+	for _, d := range connecting {
+		d.open()
+	}
 }
 
 func (pc *RTCPeerConnection) dataChannelEventHandler(e network.DataChannelEvent) {
@@ -813,22 +1602,68 @@ func (pc *RTCPeerConnection) dataChannelEventHandler(e network.DataChannelEvent)
 	switch event := e.(type) {
 	case *network.DataChannelCreated:
 		id := event.StreamIdentifier()
-		newDataChannel := &RTCDataChannel{ID: &id, Label: event.Label, rtcPeerConnection: pc}
+		newDataChannel := &RTCDataChannel{
+			ID:                &id,
+			Transport:         pc.sctpTransport,
+			ReadyState:        RTCDataChannelStateOpen,
+			rtcPeerConnection: pc,
+		}
+		newDataChannel.setChannelOpen(event.Label, event.Protocol, event.ChannelType, event.Priority, event.ReliabilityParameter)
 		pc.dataChannels[e.StreamIdentifier()] = newDataChannel
-		if pc.Ondatachannel != nil {
+
+		switch {
+		case pc.OnDataChannel != nil:
+			go pc.OnDataChannel(newDataChannel)
+		case pc.Ondatachannel != nil:
 			go pc.Ondatachannel(newDataChannel)
+		default:
+			fmt.Println("OnDataChannel is unset, discarding message")
+		}
+	case *network.DataChannelOpened:
+		if datachannel, ok := pc.dataChannels[e.StreamIdentifier()]; ok {
+			datachannel.Lock()
+			defer datachannel.Unlock()
+
+			datachannel.ReadyState = RTCDataChannelStateOpen
+			if datachannel.OnOpen != nil {
+				go datachannel.OnOpen()
+			}
 		} else {
-			fmt.Println("Ondatachannel is unset, discarding message")
+			fmt.Printf("No datachannel found for streamIdentifier %d \n", e.StreamIdentifier())
+		}
+	case *network.DataChannelClosed:
+		if datachannel, ok := pc.dataChannels[e.StreamIdentifier()]; ok {
+			delete(pc.dataChannels, e.StreamIdentifier())
+
+			datachannel.Lock()
+			defer datachannel.Unlock()
+
+			datachannel.ReadyState = RTCDataChannelStateClosed
+			if datachannel.OnClose != nil {
+				go datachannel.OnClose()
+			}
+		}
+	case *network.DataChannelBufferedAmountLow:
+		if datachannel, ok := pc.dataChannels[e.StreamIdentifier()]; ok {
+			datachannel.RLock()
+			defer datachannel.RUnlock()
+
+			if datachannel.OnBufferedAmountLow != nil {
+				go datachannel.OnBufferedAmountLow()
+			}
 		}
 	case *network.DataChannelMessage:
 		if datachannel, ok := pc.dataChannels[e.StreamIdentifier()]; ok {
 			datachannel.RLock()
 			defer datachannel.RUnlock()
 
-			if datachannel.Onmessage != nil {
+			switch {
+			case datachannel.OnMessage != nil:
+				go datachannel.OnMessage(event.Payload)
+			case datachannel.Onmessage != nil:
 				go datachannel.Onmessage(event.Payload)
-			} else {
-				fmt.Printf("Onmessage has not been set for Datachannel %s %d \n", datachannel.Label, e.StreamIdentifier())
+			default:
+				fmt.Printf("OnMessage has not been set for Datachannel %s %d \n", datachannel.Label, e.StreamIdentifier())
 			}
 		} else {
 			fmt.Printf("No datachannel found for streamIdentifier %d \n", e.StreamIdentifier())
@@ -850,86 +1685,251 @@ func (pc *RTCPeerConnection) generateLocalCandidates() []string {
 	return candidates
 }
 
-func localDirection(weSend bool, peerDirection RTCRtpTransceiverDirection) RTCRtpTransceiverDirection {
-	theySend := (peerDirection == RTCRtpTransceiverDirectionSendrecv || peerDirection == RTCRtpTransceiverDirectionSendonly)
-	if weSend && theySend {
-		return RTCRtpTransceiverDirectionSendrecv
-	} else if weSend && !theySend {
-		return RTCRtpTransceiverDirectionSendonly
-	} else if !weSend && theySend {
-		return RTCRtpTransceiverDirectionRecvonly
+// hasTransceiver reports whether there is a transceiver of kind
+func (pc *RTCPeerConnection) hasTransceiver(kind RTCRtpCodecType) bool {
+	for _, t := range pc.rtpTransceivers {
+		if t.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// newMid picks the mid of the first media section of a transceiver, the
+// name of its kind unless another transceiver has it already
+func (pc *RTCPeerConnection) newMid(kind RTCRtpCodecType) string {
+	isUsed := func(mid string) bool {
+		for _, t := range pc.rtpTransceivers {
+			if t.Mid == mid {
+				return true
+			}
+		}
+		return mid == "data"
+	}
+
+	mid := kind.String()
+	for i := 1; isUsed(mid); i++ {
+		mid = fmt.Sprintf("%s%d", kind, i)
+	}
+	return mid
+}
+
+// transceiverByMid returns the transceiver of the media section with mid,
+// nil if there is none
+func (pc *RTCPeerConnection) transceiverByMid(mid string) *RTCRtpTransceiver {
+	for _, t := range pc.rtpTransceivers {
+		if t.Mid != "" && t.Mid == mid {
+			return t
+		}
+	}
+	return nil
+}
+
+// answeringTransceiver returns the transceiver that answers the media
+// section of the remote offer with mid. A transceiver of its kind that has
+// no media section yet is given it, a receiving one is added otherwise.
+// https://www.w3.org/TR/webrtc/#set-description (step #4.6.9)
+func (pc *RTCPeerConnection) answeringTransceiver(mid string, kind RTCRtpCodecType) *RTCRtpTransceiver {
+	if t := pc.transceiverByMid(mid); t != nil {
+		return t
+	}
+	for _, t := range pc.rtpTransceivers {
+		if t.Mid == "" && !t.stopped && t.kind == kind {
+			t.Mid = mid
+			return t
+		}
+	}
+
+	t := pc.newRTCRtpTransceiver(&RTCRtpReceiver{}, &RTCRtpSender{}, RTCRtpTransceiverDirectionRecvonly, kind)
+	t.Mid = mid
+	return t
+}
+
+// rejectedMediaTemplate returns a media section of the kind to reject a
+// stopped transceiver with, as if it was offered
+func (pc *RTCPeerConnection) rejectedMediaTemplate(kind RTCRtpCodecType) *sdp.MediaDescription {
+	media := sdp.NewJSEPMediaDescription(kind.String(), []string{})
+	for _, codec := range pc.mediaEngine.getCodecsByKind(kind) {
+		media.MediaName.Formats = append(media.MediaName.Formats, strconv.Itoa(int(codec.PayloadType)))
+	}
+	// A media section has a format, even one that is rejected
+	if len(media.MediaName.Formats) == 0 {
+		media.MediaName.Formats = []string{"0"}
+	}
+	return media
+}
+
+// addRejectedMediaSection answers a media section of the offer we can't
+// use, or offers the one of a stopped transceiver, with port zero
+// https://tools.ietf.org/html/rfc3264#section-6
+func addRejectedMediaSection(d *sdp.SessionDescription, remoteMedia *sdp.MediaDescription, midValue string) {
+	media := &sdp.MediaDescription{
+		MediaName: sdp.MediaName{
+			Media:   remoteMedia.MediaName.Media,
+			Port:    sdp.RangedPort{Value: 0},
+			Protos:  remoteMedia.MediaName.Protos,
+			Formats: remoteMedia.MediaName.Formats,
+		},
+		ConnectionInformation: &sdp.ConnectionInformation{
+			NetworkType: "IN",
+			AddressType: "IP4",
+			Address: &sdp.Address{
+				IP: net.ParseIP("0.0.0.0"),
+			},
+		},
+	}
+	d.WithMedia(media.
+		WithValueAttribute(sdp.AttrKeyMID, midValue).
+		WithPropertyAttribute(RTCRtpTransceiverDirectionInactive.String()))
+}
+
+// answerHeaderExtensions accepts the header extensions of the offer that
+// are registered for kind, with the IDs of the offer and the directions
+// that complement its ones
+// https://tools.ietf.org/html/rfc8285#section-7
+func (pc *RTCPeerConnection) answerHeaderExtensions(media, remoteMedia *sdp.MediaDescription, kind RTCRtpCodecType) {
+	remoteExtMaps, err := remoteMedia.ExtMaps()
+	if err != nil {
+		return
 	}
 
-	return RTCRtpTransceiverDirectionInactive
+	for _, remote := range remoteExtMaps {
+		// The IDs an offer picks while the direction is negotiated can't be
+		// answered with
+		if remote.ID > 255 {
+			continue
+		}
+		for _, local := range pc.mediaEngine.getHeaderExtensionsByKind(kind) {
+			if local.URI != remote.URI {
+				continue
+			}
+			e := sdp.ExtMap{ID: remote.ID, URI: remote.URI}
+			if remote.Direction != "" {
+				e.Direction = answerDirection(RTCRtpTransceiverDirectionSendrecv, NewRTCRtpTransceiverDirection(remote.Direction)).String()
+			}
+			media.WithExtMap(e)
+			break
+		}
+	}
 }
 
-func (pc *RTCPeerConnection) addRTPMediaSection(d *sdp.SessionDescription, codecType RTCRtpCodecType, midValue string, peerDirection RTCRtpTransceiverDirection, candidates []string, dtlsRole sdp.ConnectionRole) bool {
-	if codecs := pc.mediaEngine.getCodecsByKind(codecType); len(codecs) == 0 {
+// headerExtensionMap returns the header extensions an answer agreed on,
+// from the media sections it accepted
+func headerExtensionMap(d *sdp.SessionDescription) rtp.HeaderExtensionMap {
+	m := make(rtp.HeaderExtensionMap)
+	for _, media := range d.MediaDescriptions {
+		if media.MediaName.Port.Value == 0 {
+			continue
+		}
+		extMaps, err := media.ExtMaps()
+		if err != nil {
+			continue
+		}
+		for _, e := range extMaps {
+			if e.Direction != RTCRtpTransceiverDirectionInactive.String() {
+				m[uint8(e.ID)] = e.URI
+			}
+		}
+	}
+	return m
+}
+
+// answerSimulcast accepts to receive the simulcast streams the offer
+// sends, on the media section of the answer. Invalid simulcast attributes
+// are declined by leaving them out.
+// https://tools.ietf.org/html/draft-ietf-mmusic-sdp-simulcast-14#section-5.3
+func answerSimulcast(media, remoteMedia *sdp.MediaDescription, direction RTCRtpTransceiverDirection) {
+	simulcast, err := remoteMedia.Simulcast()
+	if err != nil || simulcast == nil || len(simulcast.Send) == 0 || !direction.receives() {
+		return
+	}
+	rids, err := remoteMedia.RIDs()
+	if err != nil {
+		return
+	}
+
+	// The payload types of the restrictions are the ones of the offer, the
+	// streams are received with any of the answer
+	for _, rid := range rids {
+		if rid.Direction == sdp.SimulcastDirectionSend {
+			media.WithRID(sdp.RID{ID: rid.ID, Direction: sdp.SimulcastDirectionRecv, Restrictions: rid.Restrictions})
+		}
+	}
+	media.WithSimulcast(&sdp.Simulcast{Recv: simulcast.Send})
+}
+
+// addRTPMediaSection adds the media section of a transceiver, it reports
+// whether there are codecs for its kind to add it with
+func (pc *RTCPeerConnection) addRTPMediaSection(d *sdp.SessionDescription, t *RTCRtpTransceiver, direction RTCRtpTransceiverDirection, candidates []string, dtlsRole sdp.ConnectionRole) bool {
+	codecs := pc.mediaEngine.getCodecsByKind(t.kind)
+	if len(codecs) == 0 {
 		return false
 	}
 
-	media := sdp.NewJSEPMediaDescription(codecType.String(), []string{}).
+	media := sdp.NewJSEPMediaDescription(t.kind.String(), []string{}).
 		WithValueAttribute(sdp.AttrKeyConnectionSetup, dtlsRole.String()). // TODO: Support other connection types
-		WithValueAttribute(sdp.AttrKeyMID, midValue).
+		WithValueAttribute(sdp.AttrKeyMID, t.Mid).
 		WithICECredentials(pc.networkManager.IceAgent.LocalUfrag, pc.networkManager.IceAgent.LocalPwd).
 		WithPropertyAttribute(sdp.AttrKeyRtcpMux).  // TODO: support RTCP fallback
 		WithPropertyAttribute(sdp.AttrKeyRtcpRsize) // TODO: Support Reduced-Size RTCP?
 
-	for _, codec := range pc.mediaEngine.getCodecsByKind(codecType) {
+	for _, codec := range codecs {
 		media.WithCodec(codec.PayloadType, codec.Name, codec.ClockRate, codec.Channels, codec.SdpFmtpLine)
 	}
 
-	weSend := false
-	for _, transceiver := range pc.rtpTransceivers {
-		if transceiver.Sender == nil ||
-			transceiver.Sender.Track == nil ||
-			transceiver.Sender.Track.Kind != codecType {
-			continue
-		}
-		weSend = true
-		track := transceiver.Sender.Track
-		media = media.WithMediaSource(track.Ssrc, track.Label /* cname */, track.Label /* streamLabel */, track.Label)
+	// https://tools.ietf.org/html/draft-ietf-mmusic-msid-16#section-2
+	if track := t.Sender.Track; track != nil && direction.sends() {
+		media = media.
+			WithValueAttribute(sdp.AttrKeyMsid, track.Label+" "+track.ID).
+			WithMediaSource(track.Ssrc, track.Label /* cname */, track.Label /* streamLabel */, track.ID)
 	}
-	media = media.WithPropertyAttribute(localDirection(weSend, peerDirection).String())
+	media = media.WithPropertyAttribute(direction.String())
 
 	for _, c := range candidates {
 		media.WithCandidate(c)
 	}
-	media.WithPropertyAttribute("end-of-candidates")
+	if pc.networkManager.IceAgent.GatheringState() == ice.GatheringStateComplete {
+		media.WithPropertyAttribute("end-of-candidates")
+	}
 	d.WithMedia(media)
 	return true
 }
 
-func (pc *RTCPeerConnection) addDataMediaSection(d *sdp.SessionDescription, midValue string, candidates []string, dtlsRole sdp.ConnectionRole) {
-	media := (&sdp.MediaDescription{
-		MediaName: sdp.MediaName{
-			Media:   "application",
-			Port:    sdp.RangedPort{Value: 9},
-			Protos:  []string{"DTLS", "SCTP"},
-			Formats: []int{5000},
-		},
-		ConnectionInformation: &sdp.ConnectionInformation{
-			NetworkType: "IN",
-			AddressType: "IP4",
-			Address: &sdp.Address{
-				IP: net.ParseIP("0.0.0.0"),
-			},
-		},
+// addDataMediaSection adds the media section of the SCTP association of the
+// DataChannels, in the older a=sctpmap form when answering an offer that
+// has it
+func (pc *RTCPeerConnection) addDataMediaSection(d *sdp.SessionDescription, midValue string, candidates []string, dtlsRole sdp.ConnectionRole, sctpmap bool) {
+	media := sdp.NewJSEPDataMediaDescription(sdp.SCTPParameters{
+		Port:           sdp.DefaultSCTPPort,
+		MaxMessageSize: sctpMaxMessageSize,
+		Sctpmap:        sctpmap,
 	}).
 		WithValueAttribute(sdp.AttrKeyConnectionSetup, dtlsRole.String()). // TODO: Support other connection types
 		WithValueAttribute(sdp.AttrKeyMID, midValue).
 		WithPropertyAttribute(RTCRtpTransceiverDirectionSendrecv.String()).
-		WithPropertyAttribute("sctpmap:5000 webrtc-datachannel 1024").
 		WithICECredentials(pc.networkManager.IceAgent.LocalUfrag, pc.networkManager.IceAgent.LocalPwd)
 
 	for _, c := range candidates {
 		media.WithCandidate(c)
 	}
-	media.WithPropertyAttribute("end-of-candidates")
+	if pc.networkManager.IceAgent.GatheringState() == ice.GatheringStateComplete {
+		media.WithPropertyAttribute("end-of-candidates")
+	}
 
 	d.WithMedia(media)
 }
 
+// remoteSCTPParameters returns the SCTP parameters of the DataChannel media
+// section of a session description, the defaults when it has none
+func remoteSCTPParameters(d *sdp.SessionDescription) (sdp.SCTPParameters, error) {
+	for _, m := range d.MediaDescriptions {
+		if m.MediaName.Media == "application" && m.MediaName.Port.Value != 0 {
+			return m.SCTPParameters()
+		}
+	}
+	return sdp.SCTPParameters{Port: sdp.DefaultSCTPPort, MaxMessageSize: sdp.DefaultSCTPMaxMessageSize}, nil
+}
+
 // NewRTCTrack is used to create a new RTCTrack
 func (pc *RTCPeerConnection) NewRTCTrack(payloadType uint8, id, label string) (*RTCTrack, error) {
 	codec, err := pc.mediaEngine.getCodec(payloadType)
@@ -949,6 +1949,16 @@ func (pc *RTCPeerConnection) NewRTCTrack(payloadType uint8, id, label string) (*
 
 	trackInput := make(chan media.RTCSample, 15) // Is the buffering needed?
 	ssrc := binary.LittleEndian.Uint32(buf)
+	t := &RTCTrack{
+		PayloadType: payloadType,
+		Kind:        codec.Type,
+		ID:          id,
+		Label:       label,
+		Ssrc:        ssrc,
+		Codec:       codec,
+		Samples:     trackInput,
+	}
+
 	go func() {
 		packetizer := rtp.NewPacketizer(
 			1400,
@@ -960,6 +1970,9 @@ func (pc *RTCPeerConnection) NewRTCTrack(payloadType uint8, id, label string) (*
 		)
 		for {
 			in := <-trackInput
+			if !t.isSending() {
+				continue
+			}
 			packets := packetizer.Packetize(in.Data, in.Samples)
 			for _, p := range packets {
 				pc.networkManager.SendRTP(p)
@@ -967,16 +1980,6 @@ func (pc *RTCPeerConnection) NewRTCTrack(payloadType uint8, id, label string) (*
 		}
 	}()
 
-	t := &RTCTrack{
-		PayloadType: payloadType,
-		Kind:        codec.Type,
-		ID:          id,
-		Label:       label,
-		Ssrc:        ssrc,
-		Codec:       codec,
-		Samples:     trackInput,
-	}
-
 	return t, nil
 }
 
@@ -984,12 +1987,16 @@ func (pc *RTCPeerConnection) newRTCRtpTransceiver(
 	receiver *RTCRtpReceiver,
 	sender *RTCRtpSender,
 	direction RTCRtpTransceiverDirection,
+	kind RTCRtpCodecType,
 ) *RTCRtpTransceiver {
 
 	t := &RTCRtpTransceiver{
 		Receiver:  receiver,
 		Sender:    sender,
 		Direction: direction,
+		kind:      kind,
+
+		negotiationNeeded: pc.updateNegotiationNeeded,
 	}
 	pc.rtpTransceivers = append(pc.rtpTransceivers, t)
 	return t
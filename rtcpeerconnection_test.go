@@ -5,11 +5,17 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/x509"
+	"io"
 	"math/big"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/pions/webrtc/internal/dtls"
+	"github.com/pions/webrtc/internal/sdp"
+	"github.com/pions/webrtc/pkg/ice"
 	"github.com/pions/webrtc/pkg/rtcerr"
+	"github.com/pions/webrtc/pkg/rtp"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -277,6 +283,7 @@ a=fingerprint:sha-256 D7:06:10:DE:69:66:B1:53:0E:02:33:45:63:F8:AF:78:B2:C7:CE:A
 a=setup:active
 a=mid:video
 a=sendrecv
+a=rtcp-mux
 a=rtpmap:96 VP8/90000
 `
 
@@ -298,3 +305,786 @@ func TestSetRemoteDescription(t *testing.T) {
 		}
 	}
 }
+
+func TestSetRemoteDescriptionNoFingerprint(t *testing.T) {
+	peerConn, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+
+	offer := strings.Replace(minimalOffer, "a=fingerprint:", "a=x-fingerprint:", 1)
+	err = peerConn.SetRemoteDescription(RTCSessionDescription{Type: RTCSdpTypeOffer, Sdp: offer})
+	assert.Equal(t, &rtcerr.InvalidAccessError{Err: ErrNoRemoteFingerprint}, err)
+}
+
+func TestRemoteDTLSParameters(t *testing.T) {
+	d := &sdp.SessionDescription{}
+	assert.Nil(t, d.Unmarshal(minimalOffer))
+
+	role, algorithm, fingerprint := remoteDTLSParameters(d)
+	assert.Equal(t, sdp.ConnectionRoleActive, role)
+	assert.Equal(t, "sha-256", algorithm)
+	assert.Equal(t, "D7:06:10:DE:69:66:B1:53:0E:02:33:45:63:F8:AF:78:B2:C7:CE:AF:8E:FD:E5:13:20:50:74:93:CD:B5:C8:69", fingerprint)
+
+	testCases := []struct {
+		remoteRole sdp.ConnectionRole
+		expected   sdp.ConnectionRole
+	}{
+		{sdp.ConnectionRoleActpass, sdp.ConnectionRoleActive},
+		{sdp.ConnectionRolePassive, sdp.ConnectionRoleActive},
+		{sdp.ConnectionRoleActive, sdp.ConnectionRolePassive},
+		{sdp.ConnectionRole(Unknown), sdp.ConnectionRoleActive},
+	}
+	for i, testCase := range testCases {
+		assert.Equal(t, testCase.expected, answerDTLSRole(testCase.remoteRole), "testCase: %d %v", i, testCase)
+	}
+}
+
+func TestAddIceCandidate(t *testing.T) {
+	peerConn, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+
+	candidate := RTCIceCandidate{Candidate: "candidate:1 1 udp 2130706431 192.168.0.2 50000 typ host"}
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrNoRemoteDescription}, peerConn.AddIceCandidate(candidate))
+
+	err = peerConn.SetRemoteDescription(RTCSessionDescription{Type: RTCSdpTypeOffer, Sdp: minimalOffer})
+	assert.Nil(t, err)
+
+	assert.Nil(t, peerConn.AddIceCandidate(candidate))
+	assert.Nil(t, peerConn.AddIceCandidate(RTCIceCandidate{Candidate: "a=" + candidate.Candidate}))
+	assert.Nil(t, peerConn.AddIceCandidate(RTCIceCandidate{}))
+	assert.Equal(t, &rtcerr.OperationError{Err: ErrInvalidCandidate}, peerConn.AddIceCandidate(RTCIceCandidate{Candidate: "candidate:1 1 udp"}))
+}
+
+func TestSetRemoteDescriptionIceRestart(t *testing.T) {
+	peerConn, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, peerConn.Close()) }()
+
+	offer := RTCSessionDescription{Type: RTCSdpTypeOffer, Sdp: minimalOffer}
+	assert.Nil(t, peerConn.SetRemoteDescription(offer))
+	ufrag := peerConn.networkManager.IceAgent.LocalUfrag
+
+	// An offer with the same ICE credentials renegotiates without a restart
+	assert.Nil(t, peerConn.SetRemoteDescription(offer))
+	assert.Equal(t, ufrag, peerConn.networkManager.IceAgent.LocalUfrag)
+
+	offer.Sdp = strings.Replace(minimalOffer, "a=ice-ufrag:OgYk", "a=ice-ufrag:TzxE", 1)
+	assert.Nil(t, peerConn.SetRemoteDescription(offer))
+	assert.NotEqual(t, ufrag, peerConn.networkManager.IceAgent.LocalUfrag)
+	answer, err := peerConn.CreateAnswer(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, peerConn.SetLocalDescription(answer))
+
+	// Offering an ICE restart picks new credentials too
+	ufrag = peerConn.networkManager.IceAgent.LocalUfrag
+	restartOffer, err := peerConn.CreateOffer(&RTCOfferOptions{IceRestart: true})
+	assert.Nil(t, err)
+	assert.NotEqual(t, ufrag, peerConn.networkManager.IceAgent.LocalUfrag)
+	assert.Contains(t, restartOffer.Sdp, "a=ice-ufrag:"+peerConn.networkManager.IceAgent.LocalUfrag)
+}
+
+func TestIceLite(t *testing.T) {
+	d := &sdp.SessionDescription{}
+	assert.Nil(t, d.Unmarshal(minimalOffer))
+	assert.False(t, isLite(d))
+
+	d = &sdp.SessionDescription{}
+	assert.Nil(t, d.Unmarshal(strings.Replace(minimalOffer, "t=0 0\n", "t=0 0\na=ice-lite\n", 1)))
+	assert.True(t, isLite(d))
+
+	peerConn, err := New(RTCConfiguration{IceLite: true})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, peerConn.Close()) }()
+	assert.True(t, peerConn.GetConfiguration().IceLite)
+
+	offer, err := peerConn.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Contains(t, offer.Sdp, "a=ice-lite\r\n")
+	assert.NotContains(t, offer.Sdp, "tcptype active")
+}
+
+func TestHostCandidateGathering(t *testing.T) {
+	peerConn, err := New(RTCConfiguration{
+		IceNetworkTypes: []ice.NetworkType{ice.NetworkTypeUDP4},
+		NAT1To1IP:       "203.0.113.1",
+	})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, peerConn.Close()) }()
+
+	// Host candidates are only gathered for UDP, and signaled with the NAT
+	// address
+	for _, c := range peerConn.networkManager.IceAgent.LocalCandidates {
+		assert.Equal(t, ice.ProtoTypeUDP, c.GetBase().Protocol)
+		assert.Equal(t, "203.0.113.1", c.GetBase().Hostname)
+	}
+	offer, err := peerConn.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.NotContains(t, offer.Sdp, " tcp ")
+
+	// Interfaces the filter doesn't accept are not gathered from
+	filtered, err := New(RTCConfiguration{IceInterfaceFilter: func(string) bool { return false }})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, filtered.Close()) }()
+	assert.Empty(t, filtered.networkManager.IceAgent.LocalCandidates)
+
+	_, err = New(RTCConfiguration{NAT1To1IP: "public"})
+	assert.NotNil(t, err)
+	_, err = New(RTCConfiguration{NAT1To1IP: "203.0.113.1", HideHostCandidates: true})
+	assert.NotNil(t, err)
+}
+
+func TestUDPMux(t *testing.T) {
+	mux, err := NewUDPMux("127.0.0.1:0")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer func() { assert.Nil(t, mux.Close()) }()
+
+	// Every RTCPeerConnection has its UDP host candidate on the mux
+	for i := 0; i < 2; i++ {
+		peerConn, err := New(RTCConfiguration{UDPMux: mux})
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer func() { assert.Nil(t, peerConn.Close()) }()
+
+		var udp []ice.Candidate
+		for _, c := range peerConn.networkManager.IceAgent.LocalCandidates {
+			if c.GetBase().Protocol == ice.ProtoTypeUDP {
+				udp = append(udp, c)
+			}
+		}
+		if assert.Len(t, udp, 1) {
+			assert.Equal(t, mux.mux.LocalAddr().Port, udp[0].GetBase().Port)
+		}
+	}
+}
+
+func TestUnifiedPlan(t *testing.T) {
+	m := NewMediaEngine()
+	m.RegisterCodec(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus, 48000, 2))
+	m.RegisterCodec(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8, 90000))
+
+	offerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, offerer.Close()) }()
+	offerer.SetMediaEngine(m)
+
+	for _, id := range []string{"first", "second"} {
+		track, err := offerer.NewRTCTrack(DefaultPayloadTypeOpus, id, "stream")
+		assert.Nil(t, err)
+		_, err = offerer.AddTrack(track)
+		assert.Nil(t, err)
+	}
+
+	// Each track has a media section of its own, and there is one to
+	// receive video with
+	offer, err := offerer.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(offer.Sdp, "m=audio "))
+	assert.Equal(t, 1, strings.Count(offer.Sdp, "m=video "))
+	assert.Contains(t, offer.Sdp, "a=msid:stream first\r\n")
+	assert.Contains(t, offer.Sdp, "a=msid:stream second\r\n")
+	assert.Contains(t, offer.Sdp, "a=group:BUNDLE audio audio1 video data\r\n")
+	assert.Equal(t, 1, strings.Count(offer.Sdp, "a=recvonly\r\n"))
+
+	// The answer has the mids of the offer, in its order, with the
+	// directions that complement it
+	answerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, answerer.Close()) }()
+	answerer.SetMediaEngine(m)
+
+	assert.Nil(t, answerer.SetRemoteDescription(offer))
+	answer, err := answerer.CreateAnswer(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(answer.Sdp, "m=audio "))
+	assert.Equal(t, 2, strings.Count(answer.Sdp, "a=recvonly\r\n"))
+	assert.Contains(t, answer.Sdp, "a=inactive\r\n")
+	assert.Contains(t, answer.Sdp, "a=group:BUNDLE audio audio1 video data\r\n")
+	assert.True(t, strings.Index(answer.Sdp, "a=mid:audio\r\n") < strings.Index(answer.Sdp, "a=mid:audio1\r\n"))
+	assert.True(t, strings.Index(answer.Sdp, "a=mid:audio1\r\n") < strings.Index(answer.Sdp, "a=mid:video\r\n"))
+}
+
+func TestBundleAndRtcpMux(t *testing.T) {
+	const audio = `m=audio 43858 UDP/TLS/RTP/SAVPF 111
+c=IN IP4 172.17.0.1
+a=mid:audio
+a=sendrecv
+a=rtcp-mux
+a=rtpmap:111 opus/48000/2
+`
+	m := NewMediaEngine()
+	m.RegisterCodec(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus, 48000, 2))
+	m.RegisterCodec(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8, 90000))
+
+	answer := func(offer string) (string, error) {
+		peerConn, err := New(RTCConfiguration{})
+		assert.Nil(t, err)
+		defer func() { assert.Nil(t, peerConn.Close()) }()
+		peerConn.SetMediaEngine(m)
+
+		if err := peerConn.SetRemoteDescription(RTCSessionDescription{Type: RTCSdpTypeOffer, Sdp: offer}); err != nil {
+			return "", err
+		}
+		answer, err := peerConn.CreateAnswer(nil)
+		assert.Nil(t, err)
+		return answer.Sdp, nil
+	}
+
+	// RTCP can't be sent on its own component
+	_, err := answer(strings.Replace(minimalOffer, "a=rtcp-mux\n", "", 1))
+	assert.Equal(t, &rtcerr.InvalidAccessError{Err: ErrNoRtcpMux}, err)
+
+	// Sections the offer doesn't bundle are rejected
+	answerSdp, err := answer(minimalOffer + audio)
+	assert.Nil(t, err)
+	assert.Contains(t, answerSdp, "a=group:BUNDLE video\r\n")
+	assert.Contains(t, answerSdp, "m=video 9 ")
+	assert.Contains(t, answerSdp, "m=audio 0 ")
+
+	answerSdp, err = answer(strings.Replace(minimalOffer, "BUNDLE video", "BUNDLE video audio", 1) + audio)
+	assert.Nil(t, err)
+	assert.Contains(t, answerSdp, "a=group:BUNDLE video audio\r\n")
+	assert.Contains(t, answerSdp, "m=audio 9 ")
+
+	// Without BUNDLE only the first section is used
+	answerSdp, err = answer(strings.Replace(minimalOffer, "a=group:BUNDLE video\n", "", 1) + audio)
+	assert.Nil(t, err)
+	assert.NotContains(t, answerSdp, "a=group:BUNDLE")
+	assert.Contains(t, answerSdp, "m=video 9 ")
+	assert.Contains(t, answerSdp, "m=audio 0 ")
+}
+
+func TestSimulcastAnswer(t *testing.T) {
+	m := NewMediaEngine()
+	m.RegisterCodec(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8, 90000))
+
+	peerConn, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, peerConn.Close()) }()
+	peerConn.SetMediaEngine(m)
+
+	offer := strings.Replace(minimalOffer, "a=sendrecv\n", "a=sendonly\na=rid:hi send max-width=1280\na=rid:lo send\na=simulcast:send hi;~lo\n", 1)
+	assert.Nil(t, peerConn.SetRemoteDescription(RTCSessionDescription{Type: RTCSdpTypeOffer, Sdp: offer}))
+
+	// The layers the offer sends are received
+	answer, err := peerConn.CreateAnswer(nil)
+	assert.Nil(t, err)
+	assert.Contains(t, answer.Sdp, "a=rid:hi recv max-width=1280\r\n")
+	assert.Contains(t, answer.Sdp, "a=rid:lo recv\r\n")
+	assert.Contains(t, answer.Sdp, "a=simulcast:recv hi;~lo\r\n")
+}
+
+func TestHeaderExtensions(t *testing.T) {
+	offerEngine := NewMediaEngine()
+	offerEngine.RegisterCodec(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus, 48000, 2))
+	offerEngine.RegisterCodec(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8, 90000))
+	offerEngine.RegisterHeaderExtension(RTCRtpHeaderExtensionCapability{URI: rtp.MIDURI}, RTCRtpCodecTypeAudio)
+	offerEngine.RegisterHeaderExtension(RTCRtpHeaderExtensionCapability{URI: rtp.AudioLevelURI}, RTCRtpCodecTypeAudio)
+	offerEngine.RegisterHeaderExtension(RTCRtpHeaderExtensionCapability{URI: rtp.MIDURI}, RTCRtpCodecTypeVideo)
+
+	offerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, offerer.Close()) }()
+	offerer.SetMediaEngine(offerEngine)
+
+	// An extension has the same ID in all the media sections
+	offer, err := offerer.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(offer.Sdp, "a=extmap:1 "+rtp.MIDURI+"\r\n"))
+	assert.Equal(t, 1, strings.Count(offer.Sdp, "a=extmap:2 "+rtp.AudioLevelURI+"\r\n"))
+	assert.Nil(t, offerer.SetLocalDescription(offer))
+
+	// The answer accepts the registered ones with the IDs of the offer
+	answerEngine := NewMediaEngine()
+	answerEngine.RegisterCodec(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus, 48000, 2))
+	answerEngine.RegisterCodec(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8, 90000))
+	answerEngine.RegisterHeaderExtension(RTCRtpHeaderExtensionCapability{URI: rtp.AudioLevelURI}, RTCRtpCodecTypeAudio)
+
+	answerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, answerer.Close()) }()
+	answerer.SetMediaEngine(answerEngine)
+
+	offer.Sdp = strings.Replace(offer.Sdp, "a=extmap:2 ", "a=extmap:2/sendonly ", 1)
+	assert.Nil(t, answerer.SetRemoteDescription(offer))
+	answer, err := answerer.CreateAnswer(nil)
+	assert.Nil(t, err)
+	assert.Contains(t, answer.Sdp, "a=extmap:2/recvonly "+rtp.AudioLevelURI+"\r\n")
+	assert.NotContains(t, answer.Sdp, rtp.MIDURI)
+	assert.Nil(t, answerer.SetLocalDescription(answer))
+	assert.Equal(t, rtp.HeaderExtensionMap{2: rtp.AudioLevelURI}, answerer.headerExtensions)
+
+	assert.Nil(t, offerer.SetRemoteDescription(answer))
+	assert.Equal(t, rtp.HeaderExtensionMap{2: rtp.AudioLevelURI}, offerer.headerExtensions)
+}
+
+func TestDataChannelMediaSection(t *testing.T) {
+	const application = `m=application 9 DTLS/SCTP 5000
+c=IN IP4 0.0.0.0
+a=mid:data
+a=sctpmap:5000 webrtc-datachannel 1024
+`
+	offerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, offerer.Close()) }()
+
+	offer, err := offerer.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Contains(t, offer.Sdp, "m=application 9 UDP/DTLS/SCTP webrtc-datachannel\r\n")
+	assert.Contains(t, offer.Sdp, "a=sctp-port:5000\r\n")
+	assert.Contains(t, offer.Sdp, "a=max-message-size:262144\r\n")
+	assert.Nil(t, offerer.SetLocalDescription(offer))
+
+	// The older form is answered in kind
+	answerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, answerer.Close()) }()
+
+	legacyOffer := strings.Replace(minimalOffer, "BUNDLE video", "BUNDLE video data", 1) + application
+	assert.Nil(t, answerer.SetRemoteDescription(RTCSessionDescription{Type: RTCSdpTypeOffer, Sdp: legacyOffer}))
+	assert.Equal(t, float64(sdp.DefaultSCTPMaxMessageSize), answerer.sctpTransport.MaxMessageSize)
+
+	answer, err := answerer.CreateAnswer(nil)
+	assert.Nil(t, err)
+	assert.Contains(t, answer.Sdp, "m=application 9 DTLS/SCTP 5000\r\n")
+	assert.Contains(t, answer.Sdp, "a=sctpmap:5000 webrtc-datachannel 1024\r\n")
+
+	// The remote limits the messages sent to it
+	answer.Sdp = strings.Replace(answer.Sdp, "a=max-message-size:262144", "a=max-message-size:1024", 1)
+	assert.Nil(t, offerer.SetRemoteDescription(answer))
+	assert.Equal(t, float64(1024), offerer.sctpTransport.MaxMessageSize)
+}
+
+func TestOfferAnswer(t *testing.T) {
+	offerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, offerer.Close()) }()
+	answerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, answerer.Close()) }()
+
+	// There is nothing to answer yet
+	_, err = offerer.CreateAnswer(nil)
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}, err)
+
+	// Creating an offer doesn't apply it
+	offer, err := offerer.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, RTCSignalingStateStable, offerer.SignalingState)
+	assert.Nil(t, offerer.LocalDescription())
+
+	// Only the offer created can be set
+	modified := offer
+	modified.Sdp = strings.Replace(offer.Sdp, "a=sendrecv", "a=inactive", 1)
+	assert.Equal(t, &rtcerr.InvalidModificationError{Err: ErrSDPDoesNotMatchOffer}, offerer.SetLocalDescription(modified))
+
+	assert.Nil(t, offerer.SetLocalDescription(offer))
+	assert.Equal(t, RTCSignalingStateHaveLocalOffer, offerer.SignalingState)
+	assert.Equal(t, offer.Sdp, offerer.PendingLocalDescription.Sdp)
+	assert.Nil(t, offerer.CurrentLocalDescription)
+
+	// An answer can't be set while there is no remote offer
+	answer := RTCSessionDescription{Type: RTCSdpTypeAnswer, Sdp: offer.Sdp}
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}, answerer.SetRemoteDescription(answer))
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}, answerer.SetLocalDescription(answer))
+
+	assert.Nil(t, answerer.SetRemoteDescription(offer))
+	assert.Equal(t, RTCSignalingStateHaveRemoteOffer, answerer.SignalingState)
+	assert.Equal(t, offer.Sdp, answerer.PendingRemoteDescription.Sdp)
+
+	// The remote offer has to be answered before offering
+	_, err = answerer.CreateOffer(nil)
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}, err)
+
+	answer, err = answerer.CreateAnswer(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, answerer.SetLocalDescription(RTCSessionDescription{Type: RTCSdpTypeAnswer}))
+	assert.Equal(t, RTCSignalingStateStable, answerer.SignalingState)
+	assert.Equal(t, answer.Sdp, answerer.CurrentLocalDescription.Sdp)
+	assert.Equal(t, offer.Sdp, answerer.CurrentRemoteDescription.Sdp)
+	assert.Nil(t, answerer.PendingLocalDescription)
+	assert.Nil(t, answerer.PendingRemoteDescription)
+
+	assert.Nil(t, offerer.SetRemoteDescription(answer))
+	assert.Equal(t, RTCSignalingStateStable, offerer.SignalingState)
+	assert.Equal(t, offer.Sdp, offerer.CurrentLocalDescription.Sdp)
+	assert.Equal(t, answer.Sdp, offerer.CurrentRemoteDescription.Sdp)
+	assert.Nil(t, offerer.PendingLocalDescription)
+
+	// A stable connection has no answer to set
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}, offerer.SetRemoteDescription(answer))
+}
+
+func TestRollback(t *testing.T) {
+	peerConn, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, peerConn.Close()) }()
+
+	rollback := RTCSessionDescription{Type: RTCSdpTypeRollback}
+	assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}, peerConn.SetLocalDescription(rollback))
+
+	offer, err := peerConn.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, peerConn.SetLocalDescription(offer))
+	assert.Nil(t, peerConn.SetLocalDescription(rollback))
+	assert.Equal(t, RTCSignalingStateStable, peerConn.SignalingState)
+	assert.Nil(t, peerConn.LocalDescription())
+}
+
+func TestAddRemoveTrack(t *testing.T) {
+	m := NewMediaEngine()
+	m.RegisterCodec(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus, 48000, 2))
+
+	peerConn, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, peerConn.Close()) }()
+	peerConn.SetMediaEngine(m)
+
+	negotiationNeeded := make(chan struct{}, 1)
+	peerConn.OnNegotiationNeeded = func() {
+		negotiationNeeded <- struct{}{}
+	}
+	waitNegotiationNeeded := func() bool {
+		select {
+		case <-negotiationNeeded:
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+
+	track, err := peerConn.NewRTCTrack(DefaultPayloadTypeOpus, "audio", "stream")
+	assert.Nil(t, err)
+	sender, err := peerConn.AddTrack(track)
+	assert.Nil(t, err)
+	assert.Equal(t, track, sender.Track)
+	assert.True(t, waitNegotiationNeeded())
+
+	_, err = peerConn.AddTrack(track)
+	assert.Equal(t, &rtcerr.InvalidAccessError{Err: ErrExistingTrack}, err)
+
+	offer, err := peerConn.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Contains(t, offer.Sdp, "a=msid:stream audio\r\n")
+	assert.Nil(t, peerConn.SetLocalDescription(offer))
+
+	// The media section is kept to receive with
+	assert.Nil(t, peerConn.RemoveTrack(sender))
+	assert.Nil(t, sender.Track)
+	assert.False(t, track.isSending())
+	assert.Equal(t, RTCRtpTransceiverDirectionRecvonly, peerConn.GetTransceivers()[0].Direction)
+	assert.Nil(t, peerConn.RemoveTrack(sender))
+
+	// The change is negotiated once the pending offer is
+	assert.False(t, waitNegotiationNeeded())
+	assert.Nil(t, peerConn.SetLocalDescription(RTCSessionDescription{Type: RTCSdpTypeRollback}))
+	assert.True(t, waitNegotiationNeeded())
+
+	offer, err = peerConn.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.NotContains(t, offer.Sdp, "a=msid:")
+	assert.Contains(t, offer.Sdp, "a=recvonly\r\n")
+
+	assert.Equal(t, &rtcerr.InvalidAccessError{Err: ErrSenderNotCreatedByConnection}, peerConn.RemoveTrack(&RTCRtpSender{}))
+}
+
+func TestOnTrack(t *testing.T) {
+	m := NewMediaEngine()
+	m.RegisterCodec(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8, 90000))
+
+	peerConn, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	peerConn.SetMediaEngine(m)
+
+	offer := minimalOffer + "a=ssrc:1234 msid:stream video-track\n"
+	assert.Nil(t, peerConn.SetRemoteDescription(RTCSessionDescription{Type: RTCSdpTypeOffer, Sdp: offer}))
+	answer, err := peerConn.CreateAnswer(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, peerConn.SetLocalDescription(answer))
+
+	onTrack := make(chan *RTCTrack, 1)
+	peerConn.OnTrack = func(track *RTCTrack) {
+		assert.Equal(t, track, track.Receiver().Track)
+		onTrack <- track
+	}
+
+	packets := peerConn.generateChannel(1234, DefaultPayloadTypeVP8)
+	if !assert.NotNil(t, packets) {
+		return
+	}
+	var track *RTCTrack
+	select {
+	case track = <-onTrack:
+	case <-time.After(time.Second):
+		t.Fatal("OnTrack was not called")
+	}
+
+	// The track is received by the transceiver of its media section
+	assert.Equal(t, uint32(1234), track.Ssrc)
+	assert.Equal(t, uint8(DefaultPayloadTypeVP8), track.PayloadType)
+	assert.Equal(t, VP8, track.Codec.Name)
+	assert.Equal(t, "stream", track.Label)
+	assert.Equal(t, "video-track", track.ID)
+	assert.Equal(t, track, peerConn.GetTransceivers()[0].Receiver.Track)
+
+	newPacket := func(sequenceNumber uint16, timestamp uint32, payload []byte) *rtp.Packet {
+		p := &rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    DefaultPayloadTypeVP8,
+				SequenceNumber: sequenceNumber,
+				Timestamp:      timestamp,
+				SSRC:           1234,
+			},
+			Payload: payload,
+		}
+		_, err := p.Marshal()
+		assert.Nil(t, err)
+		return p
+	}
+
+	first := newPacket(1, 3000, []byte{0x10, 0xAA})
+	packets <- first
+	p, err := track.ReadRTP()
+	assert.Nil(t, err)
+	assert.Equal(t, first, p)
+
+	packets <- first
+	n, err := track.Read(make([]byte, 1))
+	assert.Equal(t, io.ErrShortBuffer, err)
+	assert.Equal(t, 0, n)
+
+	packets <- first
+	buf := make([]byte, 1500)
+	n, err = track.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, first.Raw, buf[:n])
+
+	// A frame is complete once the next one begins
+	packets <- newPacket(2, 6000, []byte{0x10, 0xBB})
+	packets <- newPacket(3, 6000, []byte{0x00, 0xCC})
+	packets <- newPacket(4, 9000, []byte{0x10, 0xDD})
+	sample, err := track.ReadSample()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xBB, 0xCC}, sample.Data)
+
+	_, err = (&RTCTrack{}).ReadRTP()
+	assert.Equal(t, ErrNotReceivedTrack, err)
+
+	// Closing the connection ends its tracks
+	assert.Nil(t, peerConn.Close())
+	_, err = track.ReadRTP()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestAddTransceiver(t *testing.T) {
+	m := NewMediaEngine()
+	m.RegisterCodec(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus, 48000, 2))
+	m.RegisterCodec(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8, 90000))
+
+	offerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, offerer.Close()) }()
+	offerer.SetMediaEngine(m)
+
+	_, err = offerer.AddTransceiver(RTCRtpCodecType(Unknown), nil)
+	assert.Equal(t, &rtcerr.TypeError{Err: ErrUnknownType}, err)
+	_, err = offerer.AddTransceiver(RTCRtpCodecTypeVideo, &RTCRtpTransceiverInit{Direction: RTCRtpTransceiverDirection(42)})
+	assert.Equal(t, &rtcerr.TypeError{Err: ErrUnknownType}, err)
+
+	// A viewer that only receives video
+	video, err := offerer.AddTransceiver(RTCRtpCodecTypeVideo, &RTCRtpTransceiverInit{Direction: RTCRtpTransceiverDirectionRecvonly})
+	assert.Nil(t, err)
+	assert.Equal(t, RTCRtpCodecTypeVideo, video.Kind())
+	assert.Equal(t, RTCRtpTransceiverDirection(Unknown), video.CurrentDirection)
+
+	offer, err := offerer.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(offer.Sdp, "m=video "))
+	assert.Equal(t, 2, strings.Count(offer.Sdp, "a=recvonly\r\n"))
+	assert.Nil(t, offerer.SetLocalDescription(offer))
+
+	answerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, answerer.Close()) }()
+	answerer.SetMediaEngine(m)
+
+	track, err := answerer.NewRTCTrack(DefaultPayloadTypeVP8, "video", "stream")
+	assert.Nil(t, err)
+	sender, err := answerer.AddTransceiverFromTrack(track, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, RTCRtpTransceiverDirectionSendrecv, sender.Direction)
+	_, err = answerer.AddTransceiverFromTrack(track, nil)
+	assert.Equal(t, &rtcerr.InvalidAccessError{Err: ErrExistingTrack}, err)
+
+	// The current directions are the ones the answer agrees on
+	assert.Nil(t, answerer.SetRemoteDescription(offer))
+	answer, err := answerer.CreateAnswer(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, answerer.SetLocalDescription(answer))
+	assert.Equal(t, RTCRtpTransceiverDirectionSendonly, sender.CurrentDirection)
+
+	// The offerer learns the audio section was rejected
+	answer.Sdp = strings.Replace(answer.Sdp, "m=audio 9 ", "m=audio 0 ", 1)
+	assert.Nil(t, offerer.SetRemoteDescription(answer))
+	assert.Equal(t, RTCRtpTransceiverDirectionRecvonly, video.CurrentDirection)
+	audio := offerer.rtpTransceivers[1]
+	assert.Equal(t, RTCRtpCodecTypeAudio, audio.Kind())
+	assert.True(t, audio.Stopped())
+
+	// A stopped transceiver keeps its media section, rejected
+	negotiationNeeded := make(chan struct{}, 1)
+	offerer.OnNegotiationNeeded = func() {
+		negotiationNeeded <- struct{}{}
+	}
+	assert.Nil(t, video.Stop())
+	assert.True(t, video.Stopped())
+	select {
+	case <-negotiationNeeded:
+	case <-time.After(time.Second):
+		t.Error("OnNegotiationNeeded was not called")
+	}
+
+	offer, err = offerer.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Contains(t, offer.Sdp, "m=video 0 UDP/TLS/RTP/SAVPF 96\r\n")
+	assert.Contains(t, offer.Sdp, "m=audio 0 ")
+	assert.Contains(t, offer.Sdp, "a=group:BUNDLE data\r\n")
+}
+
+func TestStateChangeHandlers(t *testing.T) {
+	pc, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, pc.Close()) }()
+
+	// Every handler reports to the same channel, to check the order of the
+	// state changes across them
+	states := make(chan interface{}, 8)
+	pc.OnSignalingStateChange = func(state RTCSignalingState) {
+		states <- state
+	}
+	pc.OnICEConnectionStateChange = func(state RTCIceConnectionState) {
+		states <- state
+	}
+	pc.OnIceConnectionStateChange = func(state ice.ConnectionState) {
+		states <- state
+	}
+	pc.OnConnectionStateChange = func(state RTCPeerConnectionState) {
+		states <- state
+	}
+	// The gathering New started is done before the handler is set
+	pc.iceCandidateHandler(nil)
+	gatheringStates := make(chan RTCIceGatheringState, 2)
+	pc.OnICEGatheringStateChange = func(state RTCIceGatheringState) {
+		gatheringStates <- state
+	}
+
+	offer, err := pc.CreateOffer(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, pc.SetLocalDescription(offer))
+	assert.Nil(t, pc.SetLocalDescription(RTCSessionDescription{Type: RTCSdpTypeRollback}))
+
+	// The connection is connected once both ICE and DTLS are
+	pc.iceStateChange(ice.ConnectionStateChecking)
+	pc.iceStateChange(ice.ConnectionStateConnected)
+	pc.dtlsStateChange(dtls.ConnectionStateConnected)
+	assert.Equal(t, RTCPeerConnectionStateConnected, pc.ConnectionState)
+
+	for i, expected := range []interface{}{
+		RTCSignalingStateHaveLocalOffer,
+		RTCSignalingStateStable,
+		RTCIceConnectionStateChecking,
+		ice.ConnectionState(ice.ConnectionStateChecking),
+		RTCPeerConnectionStateConnecting,
+		RTCIceConnectionStateConnected,
+		ice.ConnectionState(ice.ConnectionStateConnected),
+		RTCPeerConnectionStateConnected,
+	} {
+		select {
+		case state := <-states:
+			assert.Equal(t, expected, state, "state change %d", i)
+		case <-time.After(time.Second):
+			t.Fatalf("state change %d was not notified", i)
+		}
+	}
+
+	// Gathering again completes once, whoever tells it is done
+	pc.restartIce()
+	assert.Equal(t, RTCIceGatheringStateGathering, <-gatheringStates)
+	pc.iceCandidateHandler(nil)
+	pc.iceCandidateHandler(nil)
+	assert.Equal(t, RTCIceGatheringStateComplete, <-gatheringStates)
+	assert.Equal(t, RTCIceGatheringStateComplete, pc.IceGatheringState)
+	assert.Empty(t, gatheringStates)
+}
+
+func TestRenegotiation(t *testing.T) {
+	m := NewMediaEngine()
+	m.RegisterCodec(NewRTCRtpOpusCodec(DefaultPayloadTypeOpus, 48000, 2))
+	m.RegisterCodec(NewRTCRtpVP8Codec(DefaultPayloadTypeVP8, 90000))
+
+	offerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, offerer.Close()) }()
+	offerer.SetMediaEngine(m)
+	answerer, err := New(RTCConfiguration{})
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, answerer.Close()) }()
+	answerer.SetMediaEngine(m)
+
+	negotiate := func(offerer, answerer *RTCPeerConnection) (RTCSessionDescription, RTCSessionDescription) {
+		offer, err := offerer.CreateOffer(nil)
+		assert.Nil(t, err)
+		assert.Nil(t, offerer.SetLocalDescription(offer))
+		assert.Nil(t, answerer.SetRemoteDescription(offer))
+		answer, err := answerer.CreateAnswer(nil)
+		assert.Nil(t, err)
+		assert.Nil(t, answerer.SetLocalDescription(answer))
+		assert.Nil(t, offerer.SetRemoteDescription(answer))
+		return offer, answer
+	}
+
+	audioTrack, err := offerer.NewRTCTrack(DefaultPayloadTypeOpus, "audio", "stream")
+	assert.Nil(t, err)
+	audioSender, err := offerer.AddTrack(audioTrack)
+	assert.Nil(t, err)
+	_, answer := negotiate(offerer, answerer)
+	assert.Contains(t, answer.Sdp, "a=setup:active")
+	ufrag := offerer.networkManager.IceAgent.LocalUfrag
+
+	// The answerer adds a track and offers it on the transports running,
+	// the media sections keep their order and the DTLS roles are kept
+	videoTrack, err := answerer.NewRTCTrack(DefaultPayloadTypeVP8, "video", "stream")
+	assert.Nil(t, err)
+	videoSender, err := answerer.AddTrack(videoTrack)
+	assert.Nil(t, err)
+	offer, answer := negotiate(answerer, offerer)
+	assert.Equal(t, ufrag, offerer.networkManager.IceAgent.LocalUfrag)
+	assert.True(t, strings.Index(offer.Sdp, "m=audio") < strings.Index(offer.Sdp, "m=video"))
+	assert.True(t, strings.Index(offer.Sdp, "m=video") < strings.Index(offer.Sdp, "m=application"))
+	assert.Contains(t, answer.Sdp, "a=setup:passive")
+	assert.NotContains(t, answer.Sdp, "a=setup:active")
+	for _, transceiver := range answerer.rtpTransceivers {
+		if transceiver.Sender == videoSender {
+			assert.Equal(t, RTCRtpTransceiverDirectionSendonly, transceiver.CurrentDirection)
+		}
+	}
+
+	// A transceiver added later has a media section after the ones there
+	// are, removing a track changes the direction of its media section
+	_, err = offerer.AddTransceiver(RTCRtpCodecTypeVideo, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, offerer.RemoveTrack(audioSender))
+	offer, _ = negotiate(offerer, answerer)
+	assert.Equal(t, 2, strings.Count(offer.Sdp, "m=video "))
+	assert.True(t, strings.Index(offer.Sdp, "m=application") < strings.LastIndex(offer.Sdp, "m=video"))
+	assert.Equal(t, RTCRtpTransceiverDirectionInactive, offerer.rtpTransceivers[0].CurrentDirection)
+
+	// A stopped transceiver is rejected with port zero
+	assert.Nil(t, offerer.rtpTransceivers[0].Stop())
+	offer, answer = negotiate(offerer, answerer)
+	assert.Contains(t, offer.Sdp, "m=audio 0 ")
+	assert.Contains(t, answer.Sdp, "m=audio 0 ")
+	assert.True(t, answerer.rtpTransceivers[0].Stopped())
+}
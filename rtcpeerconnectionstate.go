@@ -1,5 +1,7 @@
 package webrtc
 
+import "github.com/pions/webrtc/pkg/ice"
+
 // RTCPeerConnectionState indicates the state of the RTCPeerConnection.
 type RTCPeerConnectionState int
 
@@ -81,3 +83,22 @@ func (t RTCPeerConnectionState) String() string {
 		return ErrUnknownType.Error()
 	}
 }
+
+// newRTCPeerConnectionStateFromTransports aggregates the states of the ICE
+// and DTLS transports, of which there is one each
+// https://www.w3.org/TR/webrtc/#rtcpeerconnectionstate-enum
+func newRTCPeerConnectionStateFromTransports(iceState ice.ConnectionState, dtlsState RTCDtlsTransportState) RTCPeerConnectionState {
+	switch {
+	case iceState == ice.ConnectionStateFailed || dtlsState == RTCDtlsTransportStateFailed:
+		return RTCPeerConnectionStateFailed
+	case iceState == ice.ConnectionStateDisconnected:
+		return RTCPeerConnectionStateDisconnected
+	case (iceState == ice.ConnectionStateConnected || iceState == ice.ConnectionStateCompleted) &&
+		(dtlsState == RTCDtlsTransportStateConnected || dtlsState == RTCDtlsTransportStateClosed):
+		return RTCPeerConnectionStateConnected
+	case iceState == ice.ConnectionStateNew && dtlsState == RTCDtlsTransportStateNew:
+		return RTCPeerConnectionStateNew
+	default:
+		return RTCPeerConnectionStateConnecting
+	}
+}
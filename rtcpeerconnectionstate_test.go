@@ -3,6 +3,7 @@ package webrtc
 import (
 	"testing"
 
+	"github.com/pions/webrtc/pkg/ice"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,3 +52,28 @@ func TestRTCPeerConnectionState_String(t *testing.T) {
 		)
 	}
 }
+
+func TestNewRTCPeerConnectionStateFromTransports(t *testing.T) {
+	testCases := []struct {
+		iceState      ice.ConnectionState
+		dtlsState     RTCDtlsTransportState
+		expectedState RTCPeerConnectionState
+	}{
+		{ice.ConnectionStateNew, RTCDtlsTransportStateNew, RTCPeerConnectionStateNew},
+		{ice.ConnectionStateChecking, RTCDtlsTransportStateNew, RTCPeerConnectionStateConnecting},
+		{ice.ConnectionStateConnected, RTCDtlsTransportStateConnecting, RTCPeerConnectionStateConnecting},
+		{ice.ConnectionStateConnected, RTCDtlsTransportStateConnected, RTCPeerConnectionStateConnected},
+		{ice.ConnectionStateCompleted, RTCDtlsTransportStateConnected, RTCPeerConnectionStateConnected},
+		{ice.ConnectionStateDisconnected, RTCDtlsTransportStateConnected, RTCPeerConnectionStateDisconnected},
+		{ice.ConnectionStateFailed, RTCDtlsTransportStateConnected, RTCPeerConnectionStateFailed},
+		{ice.ConnectionStateConnected, RTCDtlsTransportStateFailed, RTCPeerConnectionStateFailed},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedState,
+			newRTCPeerConnectionStateFromTransports(testCase.iceState, testCase.dtlsState),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
@@ -6,14 +6,27 @@ import (
 
 // RTCRtpTransceiver represents a combination of an RTCRtpSender and an RTCRtpReceiver that share a common mid.
 type RTCRtpTransceiver struct {
+	// Mid is empty until the transceiver is given a media section, by an
+	// offer we create or by the one of the remote description it answers
 	Mid       string
 	Sender    *RTCRtpSender
 	Receiver  *RTCRtpReceiver
 	Direction RTCRtpTransceiverDirection
-	// currentDirection RTCRtpTransceiverDirection
+
+	// CurrentDirection is the direction the last answer agreed on for the
+	// transceiver, from its point of view. It is unknown until then.
+	CurrentDirection RTCRtpTransceiverDirection
+
 	// firedDirection   RTCRtpTransceiverDirection
 	// receptive bool
 	stopped bool
+
+	// kind is the kind of the media section of the transceiver
+	kind RTCRtpCodecType
+
+	// negotiationNeeded is called when the transceiver changes in a way
+	// that needs an offer to be negotiated
+	negotiationNeeded func()
 }
 
 func (t *RTCRtpTransceiver) setSendingTrack(track *RTCTrack) error {
@@ -30,7 +43,32 @@ func (t *RTCRtpTransceiver) setSendingTrack(track *RTCTrack) error {
 	return nil
 }
 
-// Stop irreversibly stops the RTCRtpTransceiver
+// Stop irreversibly stops the RTCRtpTransceiver. Its sender stops sending,
+// and its media section is rejected once it is negotiated.
+// https://www.w3.org/TR/webrtc/#dom-rtcrtptransceiver-stop
 func (t *RTCRtpTransceiver) Stop() error {
-	return errors.Errorf("TODO")
+	if t.stopped {
+		return nil
+	}
+	t.stopped = true
+
+	if t.Sender.Track != nil {
+		t.Sender.Track.setSending(false)
+	}
+	if t.negotiationNeeded != nil {
+		t.negotiationNeeded()
+	}
+	return nil
+}
+
+// Stopped reports whether the RTCRtpTransceiver was stopped, or its media
+// section rejected
+func (t *RTCRtpTransceiver) Stopped() bool {
+	return t.stopped
+}
+
+// Kind returns the kind of the media the RTCRtpTransceiver sends and
+// receives
+func (t *RTCRtpTransceiver) Kind() RTCRtpCodecType {
+	return t.kind
 }
@@ -60,3 +60,34 @@ func (t RTCRtpTransceiverDirection) String() string {
 		return ErrUnknownType.Error()
 	}
 }
+
+// newRTCRtpTransceiverDirection returns the direction that sends and
+// receives as told
+func newRTCRtpTransceiverDirection(send, recv bool) RTCRtpTransceiverDirection {
+	switch {
+	case send && recv:
+		return RTCRtpTransceiverDirectionSendrecv
+	case send:
+		return RTCRtpTransceiverDirectionSendonly
+	case recv:
+		return RTCRtpTransceiverDirectionRecvonly
+	default:
+		return RTCRtpTransceiverDirectionInactive
+	}
+}
+
+func (t RTCRtpTransceiverDirection) sends() bool {
+	return t == RTCRtpTransceiverDirectionSendrecv || t == RTCRtpTransceiverDirectionSendonly
+}
+
+func (t RTCRtpTransceiverDirection) receives() bool {
+	return t == RTCRtpTransceiverDirectionSendrecv || t == RTCRtpTransceiverDirectionRecvonly
+}
+
+// answerDirection is the direction of a media section in an answer, we
+// send if the transceiver does and the offerer receives, and receive if
+// the transceiver does and the offerer sends
+// https://tools.ietf.org/html/rfc3264#section-6.1
+func answerDirection(local, remote RTCRtpTransceiverDirection) RTCRtpTransceiverDirection {
+	return newRTCRtpTransceiverDirection(local.sends() && remote.receives(), local.receives() && remote.sends())
+}
@@ -47,3 +47,26 @@ func TestRTCRtpTransceiverDirection_String(t *testing.T) {
 		)
 	}
 }
+
+func TestAnswerDirection(t *testing.T) {
+	testCases := []struct {
+		local    RTCRtpTransceiverDirection
+		remote   RTCRtpTransceiverDirection
+		expected RTCRtpTransceiverDirection
+	}{
+		{RTCRtpTransceiverDirectionSendrecv, RTCRtpTransceiverDirectionSendrecv, RTCRtpTransceiverDirectionSendrecv},
+		{RTCRtpTransceiverDirectionSendrecv, RTCRtpTransceiverDirectionSendonly, RTCRtpTransceiverDirectionRecvonly},
+		{RTCRtpTransceiverDirectionSendrecv, RTCRtpTransceiverDirectionRecvonly, RTCRtpTransceiverDirectionSendonly},
+		{RTCRtpTransceiverDirectionRecvonly, RTCRtpTransceiverDirectionSendrecv, RTCRtpTransceiverDirectionRecvonly},
+		{RTCRtpTransceiverDirectionRecvonly, RTCRtpTransceiverDirectionRecvonly, RTCRtpTransceiverDirectionInactive},
+		{RTCRtpTransceiverDirectionSendonly, RTCRtpTransceiverDirectionInactive, RTCRtpTransceiverDirectionInactive},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expected,
+			answerDirection(testCase.local, testCase.remote),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
@@ -0,0 +1,12 @@
+package webrtc
+
+// RTCRtpTransceiverInit can be used to configure the RTCRtpTransceiver
+// AddTransceiver creates.
+type RTCRtpTransceiverInit struct {
+	// Direction is the direction of the transceiver, sendrecv when it is
+	// not defined.
+	Direction RTCRtpTransceiverDirection
+
+	// Streams       []*RTCTrack // FIXME NOT-USED
+	// SendEncodings []RTCRtpEncodingParameters // FIXME NOT-USED
+}
@@ -2,8 +2,15 @@ package webrtc
 
 import (
 	"math"
+
+	"github.com/pions/webrtc/internal/sdp"
 )
 
+// sctpMaxMessageSize is the largest DataChannel message we receive, the
+// a=max-message-size we signal. Messages are reassembled whatever their
+// size, this is what browsers signal.
+const sctpMaxMessageSize = 262144
+
 // RTCSctpTransport provides details about the SCTP transport.
 type RTCSctpTransport struct {
 	// Transport represents the transport over which all SCTP packets for data
@@ -32,15 +39,16 @@ func newRTCSctpTransport() *RTCSctpTransport {
 		State: RTCSctpTransportStateConnecting,
 	}
 
-	res.updateMessageSize()
+	res.updateMessageSize(sdp.DefaultSCTPMaxMessageSize)
 	res.updateMaxChannels()
 
 	return res
 }
 
-func (r *RTCSctpTransport) updateMessageSize() {
-	var remoteMaxMessageSize float64 = 65536 // TODO: get from SDP
-	var canSendSize float64 = 65536          // TODO: Get from SCTP implementation
+// updateMessageSize applies the a=max-message-size of the remote
+// description, zero when it has no limit
+func (r *RTCSctpTransport) updateMessageSize(remoteMaxMessageSize float64) {
+	var canSendSize float64 // The SCTP implementation has no limit
 
 	r.MaxMessageSize = r.calcMessageSize(remoteMaxMessageSize, canSendSize)
 }
@@ -1,5 +1,7 @@
 package webrtc
 
+import "github.com/pions/webrtc/pkg/rtcerr"
+
 // RTCSignalingState indicates the signaling state of the offer/answer process.
 type RTCSignalingState int
 
@@ -78,3 +80,46 @@ func (t RTCSignalingState) String() string {
 		return ErrUnknownType.Error()
 	}
 }
+
+// rtcSignalingStateOp is the kind of description a signaling state change
+// is for
+type rtcSignalingStateOp int
+
+const (
+	rtcSignalingStateOpSetLocal rtcSignalingStateOp = iota + 1
+	rtcSignalingStateOpSetRemote
+)
+
+// nextSignalingState returns the state setting a description of the type
+// moves to from cur, or an error if it can't be set in cur
+// https://tools.ietf.org/html/draft-ietf-rtcweb-jsep-24#section-4.1.2
+// https://www.w3.org/TR/webrtc/#rtcsignalingstate-enum
+func nextSignalingState(cur RTCSignalingState, op rtcSignalingStateOp, sdpType RTCSdpType) (RTCSignalingState, error) {
+	// The states of the offers and answers set on this side, and of the
+	// offers set on the other side that they answer
+	haveOffer, havePranswer, haveAnsweredOffer := RTCSignalingStateHaveLocalOffer, RTCSignalingStateHaveLocalPranswer, RTCSignalingStateHaveRemoteOffer
+	if op == rtcSignalingStateOpSetRemote {
+		haveOffer, havePranswer, haveAnsweredOffer = RTCSignalingStateHaveRemoteOffer, RTCSignalingStateHaveRemotePranswer, RTCSignalingStateHaveLocalOffer
+	}
+
+	switch sdpType {
+	case RTCSdpTypeOffer:
+		// A pending offer may be replaced by another one
+		if cur == RTCSignalingStateStable || cur == haveOffer {
+			return haveOffer, nil
+		}
+	case RTCSdpTypePranswer:
+		if cur == haveAnsweredOffer || cur == havePranswer {
+			return havePranswer, nil
+		}
+	case RTCSdpTypeAnswer:
+		if cur == haveAnsweredOffer || cur == havePranswer {
+			return RTCSignalingStateStable, nil
+		}
+	case RTCSdpTypeRollback:
+		if cur == haveOffer {
+			return RTCSignalingStateStable, nil
+		}
+	}
+	return cur, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}
+}
@@ -3,6 +3,7 @@ package webrtc
 import (
 	"testing"
 
+	"github.com/pions/webrtc/pkg/rtcerr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,3 +52,48 @@ func TestRTCSignalingState_String(t *testing.T) {
 		)
 	}
 }
+
+func TestNextSignalingState(t *testing.T) {
+	const (
+		local  = rtcSignalingStateOpSetLocal
+		remote = rtcSignalingStateOpSetRemote
+	)
+	testCases := []struct {
+		cur           RTCSignalingState
+		op            rtcSignalingStateOp
+		sdpType       RTCSdpType
+		expectedState RTCSignalingState
+		expectedErr   bool
+	}{
+		{RTCSignalingStateStable, local, RTCSdpTypeOffer, RTCSignalingStateHaveLocalOffer, false},
+		{RTCSignalingStateStable, remote, RTCSdpTypeOffer, RTCSignalingStateHaveRemoteOffer, false},
+		{RTCSignalingStateHaveLocalOffer, local, RTCSdpTypeOffer, RTCSignalingStateHaveLocalOffer, false},
+		{RTCSignalingStateHaveLocalOffer, remote, RTCSdpTypeAnswer, RTCSignalingStateStable, false},
+		{RTCSignalingStateHaveLocalOffer, remote, RTCSdpTypePranswer, RTCSignalingStateHaveRemotePranswer, false},
+		{RTCSignalingStateHaveLocalOffer, local, RTCSdpTypeRollback, RTCSignalingStateStable, false},
+		{RTCSignalingStateHaveRemotePranswer, remote, RTCSdpTypeAnswer, RTCSignalingStateStable, false},
+		{RTCSignalingStateHaveRemoteOffer, local, RTCSdpTypeAnswer, RTCSignalingStateStable, false},
+		{RTCSignalingStateHaveRemoteOffer, local, RTCSdpTypePranswer, RTCSignalingStateHaveLocalPranswer, false},
+		{RTCSignalingStateHaveRemoteOffer, remote, RTCSdpTypeRollback, RTCSignalingStateStable, false},
+		{RTCSignalingStateHaveLocalPranswer, local, RTCSdpTypeAnswer, RTCSignalingStateStable, false},
+
+		{RTCSignalingStateStable, local, RTCSdpTypeAnswer, RTCSignalingStateStable, true},
+		{RTCSignalingStateStable, remote, RTCSdpTypeAnswer, RTCSignalingStateStable, true},
+		{RTCSignalingStateStable, local, RTCSdpTypeRollback, RTCSignalingStateStable, true},
+		{RTCSignalingStateHaveLocalOffer, remote, RTCSdpTypeOffer, RTCSignalingStateHaveLocalOffer, true},
+		{RTCSignalingStateHaveLocalOffer, local, RTCSdpTypeAnswer, RTCSignalingStateHaveLocalOffer, true},
+		{RTCSignalingStateHaveRemoteOffer, local, RTCSdpTypeOffer, RTCSignalingStateHaveRemoteOffer, true},
+		{RTCSignalingStateHaveRemoteOffer, local, RTCSdpTypeRollback, RTCSignalingStateHaveRemoteOffer, true},
+		{RTCSignalingStateHaveRemotePranswer, local, RTCSdpTypeOffer, RTCSignalingStateHaveRemotePranswer, true},
+	}
+
+	for i, testCase := range testCases {
+		state, err := nextSignalingState(testCase.cur, testCase.op, testCase.sdpType)
+		assert.Equal(t, testCase.expectedState, state, "testCase: %d %v", i, testCase)
+		if testCase.expectedErr {
+			assert.Equal(t, &rtcerr.InvalidStateError{Err: ErrIncorrectSignalingState}, err, "testCase: %d %v", i, testCase)
+		} else {
+			assert.Nil(t, err, "testCase: %d %v", i, testCase)
+		}
+	}
+}
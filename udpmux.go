@@ -0,0 +1,30 @@
+package webrtc
+
+import (
+	"github.com/pions/webrtc/internal/network"
+)
+
+// UDPMux shares one UDP port between the ICE agents of many
+// RTCPeerConnections, for servers that run more of them than they can open
+// ports for. Each RTCPeerConnection given it in its RTCConfiguration has a
+// single UDP host candidate, on the port of the mux. It is not part of the
+// W3C specification.
+type UDPMux struct {
+	mux *network.UDPMux
+}
+
+// NewUDPMux listens on address, it must have an IP as the host candidates
+// on the mux are signaled with it
+func NewUDPMux(address string) (*UDPMux, error) {
+	mux, err := network.NewUDPMux(address)
+	if err != nil {
+		return nil, err
+	}
+	return &UDPMux{mux: mux}, nil
+}
+
+// Close closes the port, the RTCPeerConnections that share it can't use it
+// anymore
+func (u *UDPMux) Close() error {
+	return u.mux.Close()
+}